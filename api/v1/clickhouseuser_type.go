@@ -0,0 +1,197 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClickHouseHostType defines how a host restriction is matched
+// +kubebuilder:validation:Enum=IP;NAME;REGEXP;LIKE
+type ClickHouseHostType string
+
+const (
+	ClickHouseHostIP     ClickHouseHostType = "IP"
+	ClickHouseHostName   ClickHouseHostType = "NAME"
+	ClickHouseHostRegexp ClickHouseHostType = "REGEXP"
+	ClickHouseHostLike   ClickHouseHostType = "LIKE"
+)
+
+type ClickHouseUserSpec struct {
+	// +required
+	Database *DatabaseReference `json:"database"`
+
+	// +required
+	Credentials *SecretReference `json:"credentials"`
+
+	// Hosts restrict from where the user can connect, by default any host is allowed
+	// +optional
+	Hosts []ClickHouseHost `json:"hosts,omitempty"`
+
+	// Grants are privileges on the referenced database or its tables.
+	// Privileges on the database which are not part of the grants are revoked.
+	// +kubebuilder:default:={{privileges: {ALL}, table: "*"}}
+	Grants []ClickHouseGrant `json:"grants,omitempty"`
+
+	// Profile is the name of an existing settings profile assigned to the user
+	// +optional
+	Profile string `json:"profile,omitempty"`
+
+	// Settings are applied to every session of the user
+	// +optional
+	Settings []ClickHouseSetting `json:"settings,omitempty"`
+
+	// Quotas limit the resource usage of the user within the given intervals
+	// +optional
+	Quotas []ClickHouseQuota `json:"quotas,omitempty"`
+
+	// ValidUntil defines until when this database user should remain active.
+	// After this timestamp, the controller blocks logins from any host and kills running queries of the user.
+	// When omitted, the user remains active until the resource is deleted.
+	// +optional
+	ValidUntil *metav1.Time `json:"validUntil,omitempty"`
+
+	// DeletionPolicy defines what happens to the user once the resource is deleted.
+	// Disable revokes its privileges on the database, randomizes the password and blocks logins
+	// while Drop drops the user.
+	// +optional
+	// +kubebuilder:default:=Disable
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+}
+
+// ClickHouseHost is a host restriction
+type ClickHouseHost struct {
+	// +required
+	Type ClickHouseHostType `json:"type"`
+
+	// Value is an ip address or subnet, a host name, a regular expression or a LIKE pattern depending on the type
+	// +required
+	Value string `json:"value"`
+}
+
+// ClickHouseGrant grants privileges on the referenced database or one of its tables
+type ClickHouseGrant struct {
+	// Table within the referenced database, * grants the privileges on the whole database
+	// +optional
+	// +kubebuilder:default:=*
+	Table string `json:"table,omitempty"`
+
+	// +required
+	Privileges []Privilege `json:"privileges"`
+}
+
+// ClickHouseSetting is a setting applied to the sessions of the user
+type ClickHouseSetting struct {
+	// +kubebuilder:validation:Pattern=`^[a-z_][a-z0-9_]*$`
+	// +required
+	Name string `json:"name"`
+
+	// +required
+	Value string `json:"value"`
+
+	// Readonly prevents the user from changing the setting
+	// +optional
+	Readonly bool `json:"readonly,omitempty"`
+}
+
+// ClickHouseQuota limits the resource usage within an interval, 0 means unlimited
+type ClickHouseQuota struct {
+	// +required
+	Interval metav1.Duration `json:"interval"`
+
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxQueries int64 `json:"maxQueries,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxErrors int64 `json:"maxErrors,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxResultRows int64 `json:"maxResultRows,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxReadRows int64 `json:"maxReadRows,omitempty"`
+
+	// MaxExecutionTime is the total query execution time in seconds
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxExecutionTime int64 `json:"maxExecutionTime,omitempty"`
+}
+
+// GetStatusConditions returns a pointer to the Status.Conditions slice
+func (in *ClickHouseUser) GetStatusConditions() *[]metav1.Condition {
+	return &in.Status.Conditions
+}
+
+// ClickHouseUserStatus defines the observed state of ClickHouseUser
+// IMPORTANT: Run "make" to regenerate code after modifying this file
+type ClickHouseUserStatus struct {
+	// Conditions holds the conditions for the ClickHouseUser.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Username of the created user.
+	// +optional
+	Username string `json:"username,omitempty"`
+
+	// ObservedGeneration is the last generation reconciled by the controller
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// +genclient
+// +genclient:Namespaced
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=chu
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"UserReady\")].status",description=""
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type==\"UserReady\")].message",description=""
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+
+// ClickHouseUser is the Schema for the clickhouseusers API
+type ClickHouseUser struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClickHouseUserSpec   `json:"spec,omitempty"`
+	Status ClickHouseUserStatus `json:"status,omitempty"`
+}
+
+func (in *ClickHouseUser) GetDatabase() string {
+	return in.Spec.Database.Name
+}
+
+// GetCredentials returns the credentials secret reference, the namespace defaults to the namespace of the user
+func (in *ClickHouseUser) GetCredentials() *SecretReference {
+	return in.Spec.Credentials.WithNamespace(in.GetNamespace())
+}
+
+// +kubebuilder:object:root=true
+
+// ClickHouseUserList contains a list of ClickHouseUser
+type ClickHouseUserList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClickHouseUser `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClickHouseUser{}, &ClickHouseUserList{})
+}
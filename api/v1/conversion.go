@@ -0,0 +1,59 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+// Hub marks this type as a conversion hub.
+func (*ClickHouseDatabase) Hub() {}
+
+// Hub marks this type as a conversion hub.
+func (*ClickHouseUser) Hub() {}
+
+// Hub marks this type as a conversion hub.
+func (*MongoDBDatabase) Hub() {}
+
+// Hub marks this type as a conversion hub.
+func (*MongoDBUser) Hub() {}
+
+// Hub marks this type as a conversion hub.
+func (*MSSQLDatabase) Hub() {}
+
+// Hub marks this type as a conversion hub.
+func (*MSSQLUser) Hub() {}
+
+// Hub marks this type as a conversion hub.
+func (*MySQLDatabase) Hub() {}
+
+// Hub marks this type as a conversion hub.
+func (*MySQLUser) Hub() {}
+
+// Hub marks this type as a conversion hub.
+func (*PostgreSQLDatabase) Hub() {}
+
+// Hub marks this type as a conversion hub.
+func (*PostgreSQLUser) Hub() {}
+
+// Hub marks this type as a conversion hub.
+func (*RabbitMQUser) Hub() {}
+
+// Hub marks this type as a conversion hub.
+func (*RabbitMQVhost) Hub() {}
+
+// Hub marks this type as a conversion hub.
+func (*RedisServer) Hub() {}
+
+// Hub marks this type as a conversion hub.
+func (*RedisUser) Hub() {}
@@ -0,0 +1,152 @@
+package v1
+
+import (
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Finalizer
+const (
+	Finalizer = "infra.finalizers.doodle.com"
+)
+
+// Status conditions
+const (
+	DatabaseReadyConditionType   = "DatabaseReady"
+	UserReadyConditionType       = "UserReady"
+	ExtensionReadyConditionType  = "ExtensionReady"
+	SchemaReadyConditionType     = "SchemaReady"
+	SeedReadyConditionType       = "SeedReady"
+	AccessListReadyConditionType = "AccessListReady"
+	ServerReadyConditionType     = "ServerReady"
+)
+
+// Status reasons
+const (
+	SecretNotFoundReason                 = "SecretNotFoundFailed"
+	ConnectionFailedReason               = "ConnectionFailed"
+	DatabaseProvisioningFailedReason     = "DatabaseProvisioningFailed"
+	DatabaseProvisioningSuccessfulReason = "DatabaseProvisiningSuccessful"
+	DatabaseNotFoundReason               = "DatabaseNotFoundReason"
+	UserNotProvisionedReason             = "UserNotProvisioned"
+	UserProvisioningSuccessfulReason     = "UserProvisioningSuccessful"
+	UserExpiredReason                    = "UserExpired"
+	CredentialsNotFoundReason            = "CredentialsNotFound"
+	CreateDatabaseFailedReason           = "CreateDatabaseFailed"
+	CreateExtensionsFailedReason         = "CreateExtensionsFailed"
+	CreateSchemasFailedReason            = "CreateSchemasFailed"
+	ProgressingReason                    = "ProgressingReason"
+	CreateExtensionsSuccessfulReason     = "CreateExtensionsSuccessful"
+	CreateSchemasSuccessfulReason        = "CreateSchemasSuccessful"
+	ConfigMapNotFoundReason              = "ConfigMapNotFound"
+	SeedFailedReason                     = "SeedFailed"
+	SeedSuccessfulReason                 = "SeedSuccessful"
+	AuthenticationFailedReason           = "AuthenticationFailed"
+	RateLimitedReason                    = "RateLimited"
+	ServerErrorReason                    = "ServerError"
+	AccessListFailedReason               = "AccessListFailed"
+	AccessListSuccessfulReason           = "AccessListSuccessful"
+	ConnectionSuccessfulReason           = "ConnectionSuccessful"
+	UnsupportedByFlavorReason            = "UnsupportedByFlavor"
+)
+
+// DatabaseSpec defines the desired state of a *Database
+// +kubebuilder:validation:XValidation:rule="!has(oldSelf.databaseName) || size(oldSelf.databaseName) == 0 || (has(self.databaseName) && self.databaseName == oldSelf.databaseName)",message="databaseName is immutable"
+type DatabaseSpec struct {
+	// Timeout reconciling the database and referenced resources
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// DatabaseName is by default the same as metadata.name
+	// +optional
+	DatabaseName string `json:"databaseName,omitempty"`
+
+	// The connect URI
+	// +optional
+	Address string `json:"address,omitempty"`
+
+	// Contains a credentials set of a user with enough permission to manage databases and user accounts.
+	// The root secret is required unless a MongoDBDatabase is provisioned in MongoDB Atlas.
+	// +optional
+	RootSecret *SecretReference `json:"rootSecret,omitempty"`
+}
+
+// DatabaseReference is a named reference to a database kind
+type DatabaseReference struct {
+	// Name referrs to the name of the database kind, mist be located within the same namespace
+	// +required
+	Name string `json:"name"`
+}
+
+// SecretReference is a named reference to a secret which contains user credentials
+type SecretReference struct {
+	// Name referrs to the name of the secret, must be located whithin the same namespace
+	// +required
+	Name string `json:"name"`
+
+	// Namespace, by default the same namespace is used.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// +optional
+	// +kubebuilder:default:=username
+	UserField string `json:"userField"`
+
+	// +optional
+	// +kubebuilder:default:=password
+	PasswordField string `json:"passwordField"`
+
+	// +optional
+	// +kubebuilder:default:=address
+	AddressField string `json:"addressField"`
+}
+
+// WithNamespace returns a copy of the reference which falls back to namespace if no namespace is set
+func (in *SecretReference) WithNamespace(namespace string) *SecretReference {
+	if in == nil {
+		return nil
+	}
+
+	ref := in.DeepCopy()
+	if ref.Namespace == "" {
+		ref.Namespace = namespace
+	}
+
+	return ref
+}
+
+// conditionalResource is a resource with conditions
+type conditionalResource interface {
+	GetStatusConditions() *[]metav1.Condition
+}
+
+func DatabaseNotReadyCondition(in conditionalResource, reason, message string) {
+	setResourceCondition(in, DatabaseReadyConditionType, metav1.ConditionFalse, reason, message)
+}
+
+func DatabaseReadyCondition(in conditionalResource, reason, message string) {
+	setResourceCondition(in, DatabaseReadyConditionType, metav1.ConditionTrue, reason, message)
+}
+
+func UserNotReadyCondition(in conditionalResource, reason, message string) {
+	setResourceCondition(in, UserReadyConditionType, metav1.ConditionFalse, reason, message)
+}
+
+func UserReadyCondition(in conditionalResource, reason, message string) {
+	setResourceCondition(in, UserReadyConditionType, metav1.ConditionTrue, reason, message)
+}
+
+// setResourceCondition sets the given condition with the given status,
+// reason and message on a resource.
+func setResourceCondition(resource conditionalResource, condition string, status metav1.ConditionStatus, reason, message string) {
+	conditions := resource.GetStatusConditions()
+
+	newCondition := metav1.Condition{
+		Type:    condition,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	}
+
+	apimeta.SetStatusCondition(conditions, newCondition)
+}
@@ -0,0 +1,355 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MongoDBSeedType defines how the contents of a seed are applied
+// +kubebuilder:validation:Enum=Documents;Commands
+type MongoDBSeedType string
+
+const (
+	// MongoDBSeedDocuments inserts the seed contents as documents into a collection
+	MongoDBSeedDocuments MongoDBSeedType = "Documents"
+	// MongoDBSeedCommands runs the seed contents as database commands
+	MongoDBSeedCommands MongoDBSeedType = "Commands"
+)
+
+// ConfigMapReference is a named reference to a ConfigMap
+type ConfigMapReference struct {
+	// Name referrs to the name of the ConfigMap, must be located whithin the same namespace
+	// +required
+	Name string `json:"name"`
+}
+
+// MongoDBSeed references a ConfigMap with extended JSON documents or commands
+// which are applied once to the database.
+// Each key of the ConfigMap holds either a single document or an array of documents
+// and keys are applied in lexical order.
+// +kubebuilder:validation:XValidation:rule="!has(self.type) || self.type != 'Documents' || (has(self.collection) && size(self.collection) > 0)",message="collection is required if the type is Documents"
+type MongoDBSeed struct {
+	// Name identifies the seed, applied seeds are recorded by this name
+	// +required
+	Name string `json:"name"`
+
+	// ConfigMap which holds the seed contents
+	// +required
+	ConfigMap *ConfigMapReference `json:"configMap"`
+
+	// Type of the seed contents
+	// +optional
+	// +kubebuilder:default:=Commands
+	Type MongoDBSeedType `json:"type,omitempty"`
+
+	// Collection the documents are inserted into, required if type is Documents
+	// +optional
+	Collection string `json:"collection,omitempty"`
+}
+
+// MongoDBAuthMechanism is the authentication mechanism used to connect to MongoDB
+// +kubebuilder:validation:Enum=SCRAM-SHA-1;SCRAM-SHA-256;MONGODB-X509
+type MongoDBAuthMechanism string
+
+const (
+	MongoDBAuthMechanismSCRAMSHA1   MongoDBAuthMechanism = "SCRAM-SHA-1"
+	MongoDBAuthMechanismSCRAMSHA256 MongoDBAuthMechanism = "SCRAM-SHA-256"
+	MongoDBAuthMechanismX509        MongoDBAuthMechanism = "MONGODB-X509"
+)
+
+// TLSSecretReference is a named reference to a secret which contains a TLS client certificate
+// using the keys tls.crt and tls.key and optionally a CA certificate using the key ca.crt
+type TLSSecretReference struct {
+	// Name referrs to the name of the secret, must be located whithin the same namespace
+	// +required
+	Name string `json:"name"`
+
+	// Namespace, by default the same namespace is used.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// AtlasAccessListEntry is an entry of the MongoDB Atlas project IP access list.
+// Either CIDRBlock or IPAddress must be set.
+// +kubebuilder:validation:XValidation:rule="(has(self.cidrBlock) && size(self.cidrBlock) > 0) != (has(self.ipAddress) && size(self.ipAddress) > 0)",message="either cidrBlock or ipAddress must be set"
+type AtlasAccessListEntry struct {
+	// CIDRBlock is a range of IP addresses in CIDR notation
+	// +optional
+	CIDRBlock string `json:"cidrBlock,omitempty"`
+
+	// IPAddress is a single IP address
+	// +optional
+	IPAddress string `json:"ipAddress,omitempty"`
+
+	// Comment associated with the entry
+	// +optional
+	Comment string `json:"comment,omitempty"`
+
+	// ExpiresAt defines when the entry is removed from the access list.
+	// When omitted, the entry remains until it is removed from the spec.
+	// +optional
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+}
+
+// GetEntry returns the CIDR block or IP address of the entry
+func (in AtlasAccessListEntry) GetEntry() string {
+	if in.CIDRBlock != "" {
+		return in.CIDRBlock
+	}
+
+	return in.IPAddress
+}
+
+// AtlasAPIKeySecretReference is a named reference to a secret which contains a MongoDB Atlas API key pair
+type AtlasAPIKeySecretReference struct {
+	// Name referrs to the name of the secret, must be located whithin the same namespace
+	// +required
+	Name string `json:"name"`
+
+	// Namespace, by default the same namespace is used.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// PublicKeyField is the key of the public API key within the secret
+	// +optional
+	// +kubebuilder:default:=publicKey
+	PublicKeyField string `json:"publicKeyField"`
+
+	// PrivateKeyField is the key of the private API key within the secret
+	// +optional
+	// +kubebuilder:default:=privateKey
+	PrivateKeyField string `json:"privateKeyField"`
+}
+
+// WithNamespace returns a copy of the reference which falls back to namespace if no namespace is set
+func (in *AtlasAPIKeySecretReference) WithNamespace(namespace string) *AtlasAPIKeySecretReference {
+	if in == nil {
+		return nil
+	}
+
+	ref := in.DeepCopy()
+	if ref.Namespace == "" {
+		ref.Namespace = namespace
+	}
+
+	return ref
+}
+
+// MongoDBAtlasSpec provisions the database in a MongoDB Atlas project
+type MongoDBAtlasSpec struct {
+	// GroupID is the id of the Atlas project
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	GroupID string `json:"groupId"`
+
+	// BaseURL is the base URL of the MongoDB Atlas API.
+	// By default https://cloud.mongodb.com/ is used.
+	// +optional
+	BaseURL string `json:"baseURL,omitempty"`
+
+	// APIKeySecret contains an API key pair which is allowed to manage the project
+	// +required
+	APIKeySecret *AtlasAPIKeySecretReference `json:"apiKeySecret"`
+
+	// AccessList entries are added to the IP access list of the Atlas project.
+	// Entries which are removed from the spec are removed from the access list.
+	// +optional
+	AccessList []AtlasAccessListEntry `json:"accessList,omitempty"`
+}
+
+// MongoDBDatabaseSpec defines the desired state of MongoDBDatabase
+// +kubebuilder:validation:XValidation:rule="has(self.rootSecret) != has(self.atlas)",message="either rootSecret or atlas must be set"
+// +kubebuilder:validation:XValidation:rule="!has(self.atlas) || ((!has(self.address) || size(self.address) == 0) && !has(self.authMechanism) && !has(self.authSource) && !has(self.tlsSecret))",message="address, authMechanism, authSource and tlsSecret are not supported for MongoDB Atlas"
+// +kubebuilder:validation:XValidation:rule="!has(self.atlas) || !has(self.seeds) || size(self.seeds) == 0",message="seeds are not supported for MongoDB Atlas"
+type MongoDBDatabaseSpec struct {
+	DatabaseSpec `json:",inline"`
+
+	// Atlas provisions the database in a MongoDB Atlas project instead of a MongoDB server.
+	// +optional
+	Atlas *MongoDBAtlasSpec `json:"atlas,omitempty"`
+
+	// AuthSource is the database the root user authenticates against.
+	// By default the authSource from the address is used or admin if none is given.
+	// +optional
+	AuthSource string `json:"authSource,omitempty"`
+
+	// AuthMechanism used to authenticate the root user.
+	// If MONGODB-X509 is used the client certificate from the tlsSecret authenticates the root user
+	// and the password in the root secret is not required.
+	// +optional
+	AuthMechanism MongoDBAuthMechanism `json:"authMechanism,omitempty"`
+
+	// TLSSecret contains a client certificate and CA used to connect to MongoDB
+	// +optional
+	TLSSecret *TLSSecretReference `json:"tlsSecret,omitempty"`
+
+	// ReplicaSet name the server must belong to
+	// +optional
+	ReplicaSet string `json:"replicaSet,omitempty"`
+
+	// ReadPreference used for the connection
+	// +optional
+	// +kubebuilder:validation:Enum=primary;primaryPreferred;secondary;secondaryPreferred;nearest
+	ReadPreference string `json:"readPreference,omitempty"`
+
+	// Seeds are applied once against the database.
+	// Seeding is not supported for MongoDB Atlas.
+	// +optional
+	Seeds []MongoDBSeed `json:"seeds,omitempty"`
+}
+
+// GetStatusConditions returns a pointer to the Status.Conditions slice
+func (in *MongoDBDatabase) GetStatusConditions() *[]metav1.Condition {
+	return &in.Status.Conditions
+}
+
+// MongoDBDatabaseStatus defines the observed state of MongoDBDatabase
+// IMPORTANT: Run "make" to regenerate code after modifying this file
+type MongoDBDatabaseStatus struct {
+	// Conditions holds the conditions for the MongoDBDatabase.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// ObservedGeneration is the last generation reconciled by the controller
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// AccessList holds the entries of the Atlas project IP access list managed by the controller.
+	// +optional
+	AccessList []string `json:"accessList,omitempty"`
+}
+
+// +genclient
+// +genclient:Namespaced
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=mdb
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"DatabaseReady\")].status",description=""
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type==\"DatabaseReady\")].message",description=""
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+
+// MongoDBDatabase is the Schema for the mongodbs API
+type MongoDBDatabase struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MongoDBDatabaseSpec   `json:"spec,omitempty"`
+	Status MongoDBDatabaseStatus `json:"status,omitempty"`
+}
+
+// GetRootSecret returns the root secret reference, the namespace defaults to the namespace of the database
+func (in *MongoDBDatabase) GetRootSecret() *SecretReference {
+	return in.Spec.RootSecret.WithNamespace(in.GetNamespace())
+}
+
+// IsAtlas returns true if the database is provisioned in a MongoDB Atlas project
+func (in *MongoDBDatabase) IsAtlas() bool {
+	return in.Spec.Atlas != nil
+}
+
+// GetAtlasAPIKeySecret returns the Atlas API key secret reference if any, the namespace defaults to the namespace of the database
+func (in *MongoDBDatabase) GetAtlasAPIKeySecret() *AtlasAPIKeySecretReference {
+	if in.Spec.Atlas == nil {
+		return nil
+	}
+
+	return in.Spec.Atlas.APIKeySecret.WithNamespace(in.GetNamespace())
+}
+
+// GetAccessList returns the entries of the Atlas project IP access list
+func (in *MongoDBDatabase) GetAccessList() []AtlasAccessListEntry {
+	if in.Spec.Atlas == nil {
+		return nil
+	}
+
+	return in.Spec.Atlas.AccessList
+}
+
+func (in *MongoDBDatabase) GetDatabaseName() string {
+	if in.Spec.DatabaseName != "" {
+		return in.Spec.DatabaseName
+	}
+
+	return in.GetName()
+}
+
+func (in *MongoDBDatabase) GetRootDatabaseName() string {
+	return in.Spec.AuthSource
+}
+
+// GetTLSSecret returns the TLS secret reference if any, the namespace defaults to the namespace of the database
+func (in *MongoDBDatabase) GetTLSSecret() *TLSSecretReference {
+	if in.Spec.TLSSecret == nil {
+		return nil
+	}
+
+	ref := in.Spec.TLSSecret.DeepCopy()
+	if ref.Namespace == "" {
+		ref.Namespace = in.GetNamespace()
+	}
+
+	return ref
+}
+
+// +kubebuilder:object:root=true
+
+// MongoDBDatabaseList contains a list of MongoDBDatabase
+type MongoDBDatabaseList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MongoDBDatabase `json:"items"`
+}
+
+// Default sets the database name and the namespaces of the referenced secrets if they are not set
+func (in *MongoDBDatabase) Default() {
+	if in.Spec.DatabaseName == "" {
+		in.Spec.DatabaseName = in.GetName()
+	}
+
+	if in.Spec.RootSecret != nil && in.Spec.RootSecret.Namespace == "" {
+		in.Spec.RootSecret.Namespace = in.GetNamespace()
+	}
+
+	if in.Spec.TLSSecret != nil && in.Spec.TLSSecret.Namespace == "" {
+		in.Spec.TLSSecret.Namespace = in.GetNamespace()
+	}
+
+	if in.Spec.Atlas != nil && in.Spec.Atlas.APIKeySecret != nil && in.Spec.Atlas.APIKeySecret.Namespace == "" {
+		in.Spec.Atlas.APIKeySecret.Namespace = in.GetNamespace()
+	}
+}
+
+func SeedNotReadyCondition(in conditionalResource, reason, message string) {
+	setResourceCondition(in, SeedReadyConditionType, metav1.ConditionFalse, reason, message)
+}
+
+func SeedReadyCondition(in conditionalResource, reason, message string) {
+	setResourceCondition(in, SeedReadyConditionType, metav1.ConditionTrue, reason, message)
+}
+
+func AccessListNotReadyCondition(in conditionalResource, reason, message string) {
+	setResourceCondition(in, AccessListReadyConditionType, metav1.ConditionFalse, reason, message)
+}
+
+func AccessListReadyCondition(in conditionalResource, reason, message string) {
+	setResourceCondition(in, AccessListReadyConditionType, metav1.ConditionTrue, reason, message)
+}
+
+func init() {
+	SchemeBuilder.Register(&MongoDBDatabase{}, &MongoDBDatabaseList{})
+}
@@ -0,0 +1,269 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type MongoDBUserRole struct {
+	Name string `json:"name"`
+
+	// +optional
+	DB string `json:"db,omitempty"`
+}
+
+// MongoDBAuthenticationRestriction restricts from where a user may authenticate
+type MongoDBAuthenticationRestriction struct {
+	// ClientSource is a list of IP addresses or CIDR ranges the client must connect from
+	// +optional
+	ClientSource []string `json:"clientSource,omitempty"`
+
+	// ServerAddress is a list of IP addresses or CIDR ranges the client must connect to
+	// +optional
+	ServerAddress []string `json:"serverAddress,omitempty"`
+}
+
+// MongoDBUserMechanism is a SCRAM mechanism a user supports
+// +kubebuilder:validation:Enum=SCRAM-SHA-1;SCRAM-SHA-256
+type MongoDBUserMechanism string
+
+// MongoDBAtlasScopeType is the type of an Atlas resource a user can be limited to
+// +kubebuilder:validation:Enum=CLUSTER;DATA_LAKE
+type MongoDBAtlasScopeType string
+
+const (
+	MongoDBAtlasScopeCluster  MongoDBAtlasScopeType = "CLUSTER"
+	MongoDBAtlasScopeDataLake MongoDBAtlasScopeType = "DATA_LAKE"
+)
+
+// MongoDBAtlasScope limits an Atlas user to a cluster or data lake
+type MongoDBAtlasScope struct {
+	// Name of the cluster or data lake
+	Name string `json:"name"`
+
+	// +optional
+	// +kubebuilder:default:=CLUSTER
+	Type MongoDBAtlasScopeType `json:"type,omitempty"`
+}
+
+// MongoDBAtlasLabel tags an Atlas user
+type MongoDBAtlasLabel struct {
+	Key string `json:"key"`
+
+	// +optional
+	Value string `json:"value,omitempty"`
+}
+
+// MongoDBAtlasX509Type defines if an Atlas user authenticates using X.509 certificates
+// +kubebuilder:validation:Enum=NONE;MANAGED;CUSTOMER
+type MongoDBAtlasX509Type string
+
+// MongoDBAtlasAWSIAMType defines if an Atlas user authenticates using an AWS IAM user or role
+// +kubebuilder:validation:Enum=NONE;USER;ROLE
+type MongoDBAtlasAWSIAMType string
+
+// MongoDBAtlasLDAPAuthType defines if an Atlas user is an LDAP user or group
+// +kubebuilder:validation:Enum=NONE;USER;GROUP
+type MongoDBAtlasLDAPAuthType string
+
+const (
+	MongoDBAtlasUserTypeNone = "NONE"
+)
+
+// MongoDBAtlasUserSpec holds settings which only apply to MongoDB Atlas users
+type MongoDBAtlasUserSpec struct {
+	// Scopes limit the user to the listed clusters and data lakes.
+	// By default the user has access to all clusters and data lakes of the project.
+	// +optional
+	Scopes []MongoDBAtlasScope `json:"scopes,omitempty"`
+
+	// Labels tag the user in Atlas.
+	// +optional
+	Labels []MongoDBAtlasLabel `json:"labels,omitempty"`
+
+	// X509Type creates a user which authenticates using X.509 certificates.
+	// The username is the distinguished name of the certificate and no password is required.
+	// +optional
+	X509Type MongoDBAtlasX509Type `json:"x509Type,omitempty"`
+
+	// AWSIAMType creates a user which authenticates using an AWS IAM user or role.
+	// The username is the ARN of the IAM user or role and no password is required.
+	// +optional
+	AWSIAMType MongoDBAtlasAWSIAMType `json:"awsIAMType,omitempty"`
+
+	// LDAPAuthType creates an LDAP user or group.
+	// The username is the distinguished name of the LDAP user or group and no password is required.
+	// +optional
+	LDAPAuthType MongoDBAtlasLDAPAuthType `json:"ldapAuthType,omitempty"`
+}
+
+type MongoDBUserSpec struct {
+	// +required
+	Database *DatabaseReference `json:"database"`
+
+	// +required
+	Credentials *SecretReference `json:"credentials"`
+
+	// +optional
+	// +kubebuilder:default:={{name: readWrite}}
+	Roles []MongoDBUserRole `json:"roles"`
+
+	// Mechanisms are the SCRAM mechanisms the user supports.
+	// By default the server decides which mechanisms are supported.
+	// Mechanisms are not supported for MongoDB Atlas.
+	// +optional
+	Mechanisms []MongoDBUserMechanism `json:"mechanisms,omitempty"`
+
+	// AuthenticationRestrictions restrict from where the user may authenticate.
+	// AuthenticationRestrictions are not supported for MongoDB Atlas.
+	// +optional
+	AuthenticationRestrictions []MongoDBAuthenticationRestriction `json:"authenticationRestrictions,omitempty"`
+
+	// CustomData is free-form data stored alongside the user.
+	// CustomData is not supported for MongoDB Atlas.
+	// +optional
+	// +kubebuilder:pruning:PreserveUnknownFields
+	CustomData *runtime.RawExtension `json:"customData,omitempty"`
+
+	// Atlas holds settings which only apply if the referenced database is a MongoDB Atlas project.
+	// +optional
+	Atlas *MongoDBAtlasUserSpec `json:"atlas,omitempty"`
+
+	// ValidUntil defines until when this database user should remain active.
+	// After this timestamp, the controller disables the user by deleting it.
+	// When omitted, the user remains active until the resource is deleted.
+	// For MongoDB Atlas the user is additionally created with a deleteAfterDate
+	// once ValidUntil is less than a week ahead.
+	// The timestamp must be in RFC 3339 format.
+	// +optional
+	// +kubebuilder:validation:Format=date-time
+	ValidUntil *metav1.Time `json:"validUntil,omitempty"`
+
+	// TerminateSessions defines if active sessions of the user are terminated
+	// once the user expires or is deleted.
+	// Terminating sessions is not supported for MongoDB Atlas.
+	// +optional
+	// +kubebuilder:default:=true
+	TerminateSessions *bool `json:"terminateSessions,omitempty"`
+}
+
+// GetStatusConditions returns a pointer to the Status.Conditions slice
+func (in *MongoDBUser) GetStatusConditions() *[]metav1.Condition {
+	return &in.Status.Conditions
+}
+
+// MongoDBUserStatus defines the observed state of MongoDBUser
+// IMPORTANT: Run "make" to regenerate code after modifying this file
+type MongoDBUserStatus struct {
+	// Conditions holds the conditions for the MongoDBUser.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Username of the created user.
+	// +optional
+	Username string `json:"username,omitempty"`
+
+	// CredentialsHash is an HMAC-SHA256 of the credentials which were applied last, keyed with CredentialsSalt.
+	// The password of an existing user is only updated if the credentials differ from this hash.
+	// +optional
+	CredentialsHash string `json:"credentialsHash,omitempty"`
+
+	// CredentialsSalt is the random key of CredentialsHash.
+	// +optional
+	CredentialsSalt string `json:"credentialsSalt,omitempty"`
+
+	// ObservedGeneration is the last generation reconciled by the controller
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// +genclient
+// +genclient:Namespaced
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=mdu
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"UserReady\")].status",description=""
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type==\"UserReady\")].message",description=""
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+
+// MongoDBUser is the Schema for the mongodbs API
+type MongoDBUser struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MongoDBUserSpec   `json:"spec,omitempty"`
+	Status MongoDBUserStatus `json:"status,omitempty"`
+}
+
+func (in *MongoDBUser) GetDatabase() string {
+	return in.Spec.Database.Name
+}
+
+// GetCredentials returns the credentials secret reference, the namespace defaults to the namespace of the user
+func (in *MongoDBUser) GetCredentials() *SecretReference {
+	return in.Spec.Credentials.WithNamespace(in.GetNamespace())
+}
+
+// Default sets the namespace of the credentials secret if it is not set
+func (in *MongoDBUser) Default() {
+	if in.Spec.Credentials != nil && in.Spec.Credentials.Namespace == "" {
+		in.Spec.Credentials.Namespace = in.GetNamespace()
+	}
+}
+
+func (in *MongoDBUser) GetRoles() []MongoDBUserRole {
+	if in.Spec.Roles == nil {
+		return []MongoDBUserRole{}
+	}
+
+	return in.Spec.Roles
+}
+
+// RequiresPassword returns false if the user authenticates using an external
+// mechanism such as X.509, AWS IAM or LDAP
+func (in *MongoDBUser) RequiresPassword() bool {
+	if in.Spec.Atlas == nil {
+		return true
+	}
+
+	for _, t := range []string{string(in.Spec.Atlas.X509Type), string(in.Spec.Atlas.AWSIAMType), string(in.Spec.Atlas.LDAPAuthType)} {
+		if t != "" && t != MongoDBAtlasUserTypeNone {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (in *MongoDBUser) ShouldTerminateSessions() bool {
+	return in.Spec.TerminateSessions == nil || *in.Spec.TerminateSessions
+}
+
+// +kubebuilder:object:root=true
+
+// MongoDBUserList contains a list of MongoDBUser
+type MongoDBUserList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MongoDBUser `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MongoDBUser{}, &MongoDBUserList{})
+}
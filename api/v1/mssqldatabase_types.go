@@ -0,0 +1,102 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MSSQLDatabaseSpec defines the desired state of MSSQLDatabase
+// +kubebuilder:validation:XValidation:rule="has(self.rootSecret)",message="rootSecret is required"
+type MSSQLDatabaseSpec struct {
+	DatabaseSpec `json:",inline"`
+
+	// Collation of the database, by default the server collation is used.
+	// The collation is only applied when the database gets created.
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9_]+$`
+	// +optional
+	Collation string `json:"collation,omitempty"`
+}
+
+// GetStatusConditions returns a pointer to the Status.Conditions slice
+func (in *MSSQLDatabase) GetStatusConditions() *[]metav1.Condition {
+	return &in.Status.Conditions
+}
+
+// MSSQLDatabaseStatus defines the observed state of MSSQLDatabase
+// IMPORTANT: Run "make" to regenerate code after modifying this file
+type MSSQLDatabaseStatus struct {
+	// Conditions holds the conditions for the MSSQLDatabase.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// ObservedGeneration is the last generation reconciled by the controller
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// +genclient
+// +genclient:Namespaced
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=msd
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"DatabaseReady\")].status",description=""
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type==\"DatabaseReady\")].message",description=""
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+
+// MSSQLDatabase is the Schema for the mssqldatabases API
+type MSSQLDatabase struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MSSQLDatabaseSpec   `json:"spec,omitempty"`
+	Status MSSQLDatabaseStatus `json:"status,omitempty"`
+}
+
+// GetRootSecret returns the root secret reference, the namespace defaults to the namespace of the database
+func (in *MSSQLDatabase) GetRootSecret() *SecretReference {
+	return in.Spec.RootSecret.WithNamespace(in.GetNamespace())
+}
+
+func (in *MSSQLDatabase) GetDatabaseName() string {
+	if in.Spec.DatabaseName != "" {
+		return in.Spec.DatabaseName
+	}
+
+	return in.GetName()
+}
+
+// +kubebuilder:object:root=true
+
+// MSSQLDatabaseList contains a list of MSSQLDatabase
+type MSSQLDatabaseList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MSSQLDatabase `json:"items"`
+}
+
+func (d *MSSQLDatabase) SetDefaults() error {
+	if d.Spec.DatabaseName == "" {
+		d.Spec.DatabaseName = d.GetName()
+	}
+
+	return nil
+}
+
+func init() {
+	SchemeBuilder.Register(&MSSQLDatabase{}, &MSSQLDatabaseList{})
+}
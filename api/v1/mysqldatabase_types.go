@@ -0,0 +1,108 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MySQLDatabaseSpec defines the desired state of MySQLDatabase
+// +kubebuilder:validation:XValidation:rule="has(self.rootSecret)",message="rootSecret is required"
+type MySQLDatabaseSpec struct {
+	DatabaseSpec `json:",inline"`
+
+	// CharacterSet is the default character set of the database, for example utf8mb4.
+	// By default the server default is used.
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9_]+$`
+	// +optional
+	CharacterSet string `json:"characterSet,omitempty"`
+
+	// Collation is the default collation of the database, for example utf8mb4_unicode_ci.
+	// By default the default collation of the character set is used.
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9_]+$`
+	// +optional
+	Collation string `json:"collation,omitempty"`
+}
+
+// GetStatusConditions returns a pointer to the Status.Conditions slice
+func (in *MySQLDatabase) GetStatusConditions() *[]metav1.Condition {
+	return &in.Status.Conditions
+}
+
+// MySQLDatabaseStatus defines the observed state of MySQLDatabase
+// IMPORTANT: Run "make" to regenerate code after modifying this file
+type MySQLDatabaseStatus struct {
+	// Conditions holds the conditions for the MySQLDatabase.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// ObservedGeneration is the last generation reconciled by the controller
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// +genclient
+// +genclient:Namespaced
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=myd
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"DatabaseReady\")].status",description=""
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type==\"DatabaseReady\")].message",description=""
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+
+// MySQLDatabase is the Schema for the mysqldatabases API
+type MySQLDatabase struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MySQLDatabaseSpec   `json:"spec,omitempty"`
+	Status MySQLDatabaseStatus `json:"status,omitempty"`
+}
+
+// GetRootSecret returns the root secret reference, the namespace defaults to the namespace of the database
+func (in *MySQLDatabase) GetRootSecret() *SecretReference {
+	return in.Spec.RootSecret.WithNamespace(in.GetNamespace())
+}
+
+func (in *MySQLDatabase) GetDatabaseName() string {
+	if in.Spec.DatabaseName != "" {
+		return in.Spec.DatabaseName
+	}
+
+	return in.GetName()
+}
+
+// +kubebuilder:object:root=true
+
+// MySQLDatabaseList contains a list of MySQLDatabase
+type MySQLDatabaseList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MySQLDatabase `json:"items"`
+}
+
+func (d *MySQLDatabase) SetDefaults() error {
+	if d.Spec.DatabaseName == "" {
+		d.Spec.DatabaseName = d.GetName()
+	}
+
+	return nil
+}
+
+func init() {
+	SchemeBuilder.Register(&MySQLDatabase{}, &MySQLDatabaseList{})
+}
@@ -0,0 +1,172 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Extension is a resource representing database extension
+type Extension struct {
+	Name string `json:"name"`
+}
+
+// Extensions is a collection of Extension types
+type Extensions []Extension
+
+// Schema is a resource representing database schema
+type Schema struct {
+	Name string `json:"name"`
+}
+
+// Schemas is a collection of Schema types
+type Schemas []Schema
+
+// PostgreSQLFlavor is the server implementation behind the PostgreSQL wire protocol
+// +kubebuilder:validation:Enum=PostgreSQL;CockroachDB;YugabyteDB
+type PostgreSQLFlavor string
+
+const (
+	PostgreSQLFlavorPostgreSQL  PostgreSQLFlavor = "PostgreSQL"
+	PostgreSQLFlavorCockroachDB PostgreSQLFlavor = "CockroachDB"
+	PostgreSQLFlavorYugabyteDB  PostgreSQLFlavor = "YugabyteDB"
+)
+
+// PostgreSQLDatabaseSpec defines the desired state of PostgreSQLDatabase
+// +kubebuilder:validation:XValidation:rule="has(self.rootSecret)",message="rootSecret is required"
+type PostgreSQLDatabaseSpec struct {
+	DatabaseSpec `json:",inline"`
+
+	// Flavor selects the SQL dialect of the server.
+	// Features which are not supported by the flavor are skipped and reported in the conditions.
+	// +kubebuilder:default:=PostgreSQL
+	// +optional
+	Flavor PostgreSQLFlavor `json:"flavor,omitempty"`
+
+	// Database extensions
+	// +optional
+	Extensions Extensions `json:"extensions,omitempty"`
+
+	// Search path
+	// +optional
+	SearchPath Schemas `json:"searchPath,omitempty"`
+
+	// Database schemas
+	// +kubebuilder:default:={{name: public}}
+	// +optional
+	Schemas Schemas `json:"schemas,omitempty"`
+}
+
+// GetStatusConditions returns a pointer to the Status.Conditions slice
+func (in *PostgreSQLDatabase) GetStatusConditions() *[]metav1.Condition {
+	return &in.Status.Conditions
+}
+
+// PostgreSQLDatabaseStatus defines the observed state of PostgreSQLDatabase
+// IMPORTANT: Run "make" to regenerate code after modifying this file
+type PostgreSQLDatabaseStatus struct {
+	// Conditions holds the conditions for the PostgreSQLDatabase.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// ObservedGeneration is the last generation reconciled by the controller
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// +genclient
+// +genclient:Namespaced
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=pgd
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"DatabaseReady\")].status",description=""
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type==\"DatabaseReady\")].message",description=""
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+
+// PostgreSQLDatabase is the Schema for the postgresqls API
+type PostgreSQLDatabase struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PostgreSQLDatabaseSpec   `json:"spec,omitempty"`
+	Status PostgreSQLDatabaseStatus `json:"status,omitempty"`
+}
+
+// GetRootSecret returns the root secret reference, the namespace defaults to the namespace of the database
+func (in *PostgreSQLDatabase) GetRootSecret() *SecretReference {
+	return in.Spec.RootSecret.WithNamespace(in.GetNamespace())
+}
+
+func (in *PostgreSQLDatabase) GetDatabaseName() string {
+	if in.Spec.DatabaseName != "" {
+		return in.Spec.DatabaseName
+	}
+
+	return in.GetName()
+}
+
+func (in *PostgreSQLDatabase) GetFlavor() PostgreSQLFlavor {
+	if in.Spec.Flavor != "" {
+		return in.Spec.Flavor
+	}
+
+	return PostgreSQLFlavorPostgreSQL
+}
+
+func (in *PostgreSQLDatabase) GetRootDatabaseName() string {
+	return ""
+}
+
+// +kubebuilder:object:root=true
+
+// PostgreSQLDatabaseList contains a list of PostgreSQLDatabase
+type PostgreSQLDatabaseList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PostgreSQLDatabase `json:"items"`
+}
+
+func ExtensionNotReadyCondition(in conditionalResource, reason, message string) {
+	setResourceCondition(in, ExtensionReadyConditionType, metav1.ConditionFalse, reason, message)
+}
+
+func SchemaNotReadyCondition(in conditionalResource, reason, message string) {
+	setResourceCondition(in, SchemaReadyConditionType, metav1.ConditionFalse, reason, message)
+}
+
+func ExtensionReadyCondition(in conditionalResource, reason, message string) {
+	setResourceCondition(in, ExtensionReadyConditionType, metav1.ConditionTrue, reason, message)
+}
+
+func SchemaReadyCondition(in conditionalResource, reason, message string) {
+	setResourceCondition(in, SchemaReadyConditionType, metav1.ConditionTrue, reason, message)
+}
+
+// Default sets the database name and the namespace of the root secret if they are not set
+func (in *PostgreSQLDatabase) Default() {
+	if in.Spec.DatabaseName == "" {
+		in.Spec.DatabaseName = in.GetName()
+	}
+
+	if in.Spec.RootSecret != nil && in.Spec.RootSecret.Namespace == "" {
+		in.Spec.RootSecret.Namespace = in.GetNamespace()
+	}
+}
+
+func init() {
+	SchemeBuilder.Register(&PostgreSQLDatabase{}, &PostgreSQLDatabaseList{})
+}
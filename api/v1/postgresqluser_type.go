@@ -0,0 +1,178 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Privilege is a privilege granted on a database object, for example SELECT or ALL
+type Privilege string
+
+const (
+	SelectPrivilege Privilege = "SELECT"
+	AllPrivileges   Privilege = "ALL"
+)
+
+// DeletionPolicy defines what happens to a database user once its resource is deleted
+// +kubebuilder:validation:Enum=Disable;Drop
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDisable revokes the privileges and randomizes the password of the user
+	DeletionPolicyDisable DeletionPolicy = "Disable"
+	// DeletionPolicyDrop reassigns and drops owned objects and drops the user afterwards
+	DeletionPolicyDrop DeletionPolicy = "Drop"
+)
+
+type PostgreSQLUserSpec struct {
+	// +required
+	Database *DatabaseReference `json:"database"`
+
+	// +required
+	Credentials *SecretReference `json:"credentials"`
+
+	// +kubebuilder:default:={{privileges: {ALL}, object: SCHEMA, objectName: public}}
+	// +kubebuilder:validation:MaxItems=64
+	Grants []Grant `json:"grants,omitempty"`
+
+	// Roles are postgres roles granted to this user
+	Roles []string `json:"roles,omitempty"`
+
+	// Attributes are postgres attributes associated with this user
+	// +kubebuilder:validation:MaxItems=32
+	// +kubebuilder:validation:items:MaxLength=16
+	// +kubebuilder:validation:XValidation:rule="self.all(a, a in ['LOGIN', 'NOLOGIN', 'SUPERUSER', 'NOSUPERUSER', 'CREATEDB', 'NOCREATEDB', 'CREATEROLE', 'NOCREATEROLE', 'REPLICATION', 'NOREPLICATION', 'BYPASSRLS', 'NOBYPASSRLS', 'INHERIT', 'NOINHERIT'])",message="attributes must be role attributes such as LOGIN, CREATEDB or NOINHERIT"
+	Attributes []string `json:"attributes,omitempty"`
+
+	// ValidUntil defines until when this database user should remain active.
+	// The timestamp is set as VALID UNTIL on the role so the server enforces it.
+	// After this timestamp, the controller additionally sets NOLOGIN on the role
+	// and terminates its active sessions.
+	// When omitted, the user remains active until the resource is deleted.
+	// The timestamp must be in RFC 3339 format.
+	// +optional
+	// +kubebuilder:validation:Format=date-time
+	ValidUntil *metav1.Time `json:"validUntil,omitempty"`
+
+	// DeletionPolicy defines what happens to the user once the resource is deleted.
+	// Disable revokes its privileges and randomizes the password while Drop reassigns
+	// objects owned by the user in every affected database and drops the user afterwards.
+	// +optional
+	// +kubebuilder:default:=Disable
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// ReassignOwnedTo is the role objects owned by the user are reassigned to if
+	// the deletion policy is Drop. By default objects are reassigned to the root user.
+	// +optional
+	ReassignOwnedTo string `json:"reassignOwnedTo,omitempty"`
+
+	// TerminateSessions defines if active sessions of the user are terminated
+	// once the user gets disabled, expires or is deleted.
+	// +optional
+	// +kubebuilder:default:=true
+	TerminateSessions *bool `json:"terminateSessions,omitempty"`
+}
+
+// +kubebuilder:validation:XValidation:rule="has(self.objectName) && size(self.objectName) > 0",message="objectName is required"
+type Grant struct {
+	// Object is the type of the object privileges are granted on, for example SCHEMA or ALL TABLES IN SCHEMA
+	// +kubebuilder:validation:MaxLength=32
+	// +kubebuilder:validation:XValidation:rule="size(self) == 0 || self.upperAscii() in ['TABLE', 'SEQUENCE', 'DATABASE', 'DOMAIN', 'FOREIGN DATA WRAPPER', 'FOREIGN SERVER', 'FUNCTION', 'PROCEDURE', 'ROUTINE', 'LANGUAGE', 'PARAMETER', 'SCHEMA', 'TABLESPACE', 'TYPE', 'ALL TABLES IN SCHEMA', 'ALL SEQUENCES IN SCHEMA', 'ALL FUNCTIONS IN SCHEMA', 'ALL PROCEDURES IN SCHEMA', 'ALL ROUTINES IN SCHEMA']",message="object must be an object type such as SCHEMA, TABLE or ALL TABLES IN SCHEMA"
+	Object string `json:"object,omitempty"`
+
+	// +kubebuilder:validation:MaxLength=63
+	ObjectName string `json:"objectName,omitempty"`
+	User       string `json:"user,omitempty"`
+
+	// +kubebuilder:validation:MaxItems=32
+	// +kubebuilder:validation:items:MaxLength=16
+	// +kubebuilder:validation:XValidation:rule="self.all(p, p.upperAscii() in ['SELECT', 'INSERT', 'UPDATE', 'DELETE', 'TRUNCATE', 'REFERENCES', 'TRIGGER', 'CREATE', 'CONNECT', 'TEMPORARY', 'TEMP', 'EXECUTE', 'USAGE', 'SET', 'ALTER SYSTEM', 'MAINTAIN', 'ALL', 'ALL PRIVILEGES', 'DROP', 'ZONECONFIG', 'BACKUP', 'RESTORE', 'CHANGEFEED'])",message="privileges must be privileges such as SELECT, USAGE or ALL"
+	Privileges []Privilege `json:"privileges,omitempty"`
+}
+
+// GetStatusConditions returns a pointer to the Status.Conditions slice
+func (in *PostgreSQLUser) GetStatusConditions() *[]metav1.Condition {
+	return &in.Status.Conditions
+}
+
+// PostgreSQLUserStatus defines the observed state of PostgreSQLUser
+// IMPORTANT: Run "make" to regenerate code after modifying this file
+type PostgreSQLUserStatus struct {
+	// Conditions holds the conditions for the PostgreSQLUser.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Username of the created user.
+	// +optional
+	Username string `json:"username,omitempty"`
+
+	// ObservedGeneration is the last generation reconciled by the controller
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// +genclient
+// +genclient:Namespaced
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=pgu
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"UserReady\")].status",description=""
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type==\"UserReady\")].message",description=""
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+
+// PostgreSQLUser is the Schema for the mongodbs API
+type PostgreSQLUser struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PostgreSQLUserSpec   `json:"spec,omitempty"`
+	Status PostgreSQLUserStatus `json:"status,omitempty"`
+}
+
+func (in *PostgreSQLUser) GetDatabase() string {
+	return in.Spec.Database.Name
+}
+
+// GetCredentials returns the credentials secret reference, the namespace defaults to the namespace of the user
+func (in *PostgreSQLUser) GetCredentials() *SecretReference {
+	return in.Spec.Credentials.WithNamespace(in.GetNamespace())
+}
+
+// Default sets the namespace of the credentials secret if it is not set
+func (in *PostgreSQLUser) Default() {
+	if in.Spec.Credentials != nil && in.Spec.Credentials.Namespace == "" {
+		in.Spec.Credentials.Namespace = in.GetNamespace()
+	}
+}
+
+func (in *PostgreSQLUser) ShouldTerminateSessions() bool {
+	return in.Spec.TerminateSessions == nil || *in.Spec.TerminateSessions
+}
+
+// +kubebuilder:object:root=true
+
+// PostgreSQLUserList contains a list of PostgreSQLUser
+type PostgreSQLUserList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PostgreSQLUser `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PostgreSQLUser{}, &PostgreSQLUserList{})
+}
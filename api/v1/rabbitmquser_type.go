@@ -0,0 +1,173 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RabbitMQPermissions are the regular expressions matched against resource names within the virtual host
+type RabbitMQPermissions struct {
+	// Configure permission regex
+	// +kubebuilder:default:=".*"
+	// +optional
+	Configure string `json:"configure"`
+
+	// Write permission regex
+	// +kubebuilder:default:=".*"
+	// +optional
+	Write string `json:"write"`
+
+	// Read permission regex
+	// +kubebuilder:default:=".*"
+	// +optional
+	Read string `json:"read"`
+}
+
+// RabbitMQTopicPermission restricts the routing keys on a topic exchange
+type RabbitMQTopicPermission struct {
+	// Exchange the permission applies to
+	// +kubebuilder:default:=amq.topic
+	// +optional
+	Exchange string `json:"exchange"`
+
+	// Write permission regex matched against routing keys
+	// +optional
+	Write string `json:"write"`
+
+	// Read permission regex matched against routing keys
+	// +optional
+	Read string `json:"read"`
+}
+
+type RabbitMQUserSpec struct {
+	// +required
+	Vhost *DatabaseReference `json:"vhost"`
+
+	// +required
+	Credentials *SecretReference `json:"credentials"`
+
+	// Tags of the user like management, monitoring or administrator
+	// +optional
+	Tags []string `json:"tags,omitempty"`
+
+	// Permissions of the user within the virtual host
+	// +kubebuilder:default:={configure: ".*", write: ".*", read: ".*"}
+	// +optional
+	Permissions *RabbitMQPermissions `json:"permissions,omitempty"`
+
+	// TopicPermissions of the user within the virtual host.
+	// Topic permissions for exchanges which are not listed are removed.
+	// +optional
+	TopicPermissions []RabbitMQTopicPermission `json:"topicPermissions,omitempty"`
+
+	// ValidUntil defines until when this user should remain active.
+	// After this timestamp, the controller clears the permissions of the user on the virtual host.
+	// When omitted, the user remains active until the resource is deleted.
+	// +optional
+	ValidUntil *metav1.Time `json:"validUntil,omitempty"`
+
+	// DeletionPolicy defines what happens to the user once the resource is deleted.
+	// Disable clears the permissions of the user on the virtual host while Drop additionally deletes the user.
+	// In both cases the user is disabled respectively deleted only if it has no permissions on another virtual host.
+	// +optional
+	// +kubebuilder:default:=Disable
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// TerminateSessions closes the connections of the user to the virtual host
+	// once the user expires or gets disabled or dropped.
+	// +optional
+	// +kubebuilder:default:=true
+	TerminateSessions *bool `json:"terminateSessions,omitempty"`
+}
+
+// GetStatusConditions returns a pointer to the Status.Conditions slice
+func (in *RabbitMQUser) GetStatusConditions() *[]metav1.Condition {
+	return &in.Status.Conditions
+}
+
+// RabbitMQUserStatus defines the observed state of RabbitMQUser
+// IMPORTANT: Run "make" to regenerate code after modifying this file
+type RabbitMQUserStatus struct {
+	// Conditions holds the conditions for the RabbitMQUser.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Username of the created user.
+	// +optional
+	Username string `json:"username,omitempty"`
+
+	// ObservedGeneration is the last generation reconciled by the controller
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// +genclient
+// +genclient:Namespaced
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=rmu
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"UserReady\")].status",description=""
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type==\"UserReady\")].message",description=""
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+
+// RabbitMQUser is the Schema for the rabbitmqusers API
+type RabbitMQUser struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RabbitMQUserSpec   `json:"spec,omitempty"`
+	Status RabbitMQUserStatus `json:"status,omitempty"`
+}
+
+func (in *RabbitMQUser) GetVhost() string {
+	return in.Spec.Vhost.Name
+}
+
+// GetCredentials returns the credentials secret reference, the namespace defaults to the namespace of the user
+func (in *RabbitMQUser) GetCredentials() *SecretReference {
+	return in.Spec.Credentials.WithNamespace(in.GetNamespace())
+}
+
+func (in *RabbitMQUser) GetPermissions() RabbitMQPermissions {
+	if in.Spec.Permissions != nil {
+		return *in.Spec.Permissions
+	}
+
+	return RabbitMQPermissions{
+		Configure: ".*",
+		Write:     ".*",
+		Read:      ".*",
+	}
+}
+
+func (in *RabbitMQUser) ShouldTerminateSessions() bool {
+	return in.Spec.TerminateSessions == nil || *in.Spec.TerminateSessions
+}
+
+// +kubebuilder:object:root=true
+
+// RabbitMQUserList contains a list of RabbitMQUser
+type RabbitMQUserList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RabbitMQUser `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RabbitMQUser{}, &RabbitMQUserList{})
+}
@@ -0,0 +1,110 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RabbitMQVhostSpec defines the desired state of RabbitMQVhost
+// The address is the URL of the management HTTP API, for example http://rabbitmq:15672.
+// The database name is used as the name of the virtual host.
+// +kubebuilder:validation:XValidation:rule="has(self.rootSecret)",message="rootSecret is required"
+type RabbitMQVhostSpec struct {
+	DatabaseSpec `json:",inline"`
+
+	// Description of the virtual host
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// Tags of the virtual host
+	// +optional
+	Tags []string `json:"tags,omitempty"`
+
+	// Tracing enables message tracing for the virtual host
+	// +optional
+	Tracing bool `json:"tracing,omitempty"`
+}
+
+// GetStatusConditions returns a pointer to the Status.Conditions slice
+func (in *RabbitMQVhost) GetStatusConditions() *[]metav1.Condition {
+	return &in.Status.Conditions
+}
+
+// RabbitMQVhostStatus defines the observed state of RabbitMQVhost
+// IMPORTANT: Run "make" to regenerate code after modifying this file
+type RabbitMQVhostStatus struct {
+	// Conditions holds the conditions for the RabbitMQVhost.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// ObservedGeneration is the last generation reconciled by the controller
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// +genclient
+// +genclient:Namespaced
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=rmv
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"DatabaseReady\")].status",description=""
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type==\"DatabaseReady\")].message",description=""
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+
+// RabbitMQVhost is the Schema for the rabbitmqvhosts API
+type RabbitMQVhost struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RabbitMQVhostSpec   `json:"spec,omitempty"`
+	Status RabbitMQVhostStatus `json:"status,omitempty"`
+}
+
+// GetRootSecret returns the root secret reference, the namespace defaults to the namespace of the virtual host
+func (in *RabbitMQVhost) GetRootSecret() *SecretReference {
+	return in.Spec.RootSecret.WithNamespace(in.GetNamespace())
+}
+
+func (in *RabbitMQVhost) GetDatabaseName() string {
+	if in.Spec.DatabaseName != "" {
+		return in.Spec.DatabaseName
+	}
+
+	return in.GetName()
+}
+
+// +kubebuilder:object:root=true
+
+// RabbitMQVhostList contains a list of RabbitMQVhost
+type RabbitMQVhostList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RabbitMQVhost `json:"items"`
+}
+
+func (d *RabbitMQVhost) SetDefaults() error {
+	if d.Spec.DatabaseName == "" {
+		d.Spec.DatabaseName = d.GetName()
+	}
+
+	return nil
+}
+
+func init() {
+	SchemeBuilder.Register(&RabbitMQVhost{}, &RabbitMQVhostList{})
+}
@@ -0,0 +1,129 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RedisCategory is an ACL command category rule like +@read or -@dangerous
+// +kubebuilder:validation:Pattern=`^[+-]@[a-z]+$`
+type RedisCategory string
+
+type RedisUserSpec struct {
+	// Server references the RedisServer the user is provisioned on
+	// +required
+	Server *DatabaseReference `json:"server"`
+
+	// +required
+	Credentials *SecretReference `json:"credentials"`
+
+	// KeyPatterns are glob-style patterns of keys the user can access, for example tenant-a:*
+	// +optional
+	KeyPatterns []string `json:"keyPatterns,omitempty"`
+
+	// ChannelPatterns are glob-style patterns of Pub/Sub channels the user can access
+	// +optional
+	ChannelPatterns []string `json:"channelPatterns,omitempty"`
+
+	// Categories are command category rules applied in order, for example +@read, +@write or -@dangerous
+	// +optional
+	Categories []RedisCategory `json:"categories,omitempty"`
+
+	// ValidUntil defines until when this database user should remain active.
+	// After this timestamp, the controller disables the user and terminates its active connections.
+	// When omitted, the user remains active until the resource is deleted.
+	// +optional
+	ValidUntil *metav1.Time `json:"validUntil,omitempty"`
+
+	// DeletionPolicy defines what happens to the user once the resource is deleted.
+	// Disable switches the user off and removes its password while Drop deletes the user.
+	// +optional
+	// +kubebuilder:default:=Drop
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// TerminateSessions defines if active connections of the user are terminated
+	// once the user gets disabled, expires or is deleted.
+	// +optional
+	// +kubebuilder:default:=true
+	TerminateSessions *bool `json:"terminateSessions,omitempty"`
+}
+
+// GetStatusConditions returns a pointer to the Status.Conditions slice
+func (in *RedisUser) GetStatusConditions() *[]metav1.Condition {
+	return &in.Status.Conditions
+}
+
+// RedisUserStatus defines the observed state of RedisUser
+// IMPORTANT: Run "make" to regenerate code after modifying this file
+type RedisUserStatus struct {
+	// Conditions holds the conditions for the RedisUser.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Username of the created user.
+	// +optional
+	Username string `json:"username,omitempty"`
+
+	// ObservedGeneration is the last generation reconciled by the controller
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// +genclient
+// +genclient:Namespaced
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=rdu
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"UserReady\")].status",description=""
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type==\"UserReady\")].message",description=""
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+
+// RedisUser is the Schema for the redisusers API
+type RedisUser struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RedisUserSpec   `json:"spec,omitempty"`
+	Status RedisUserStatus `json:"status,omitempty"`
+}
+
+func (in *RedisUser) GetServer() string {
+	return in.Spec.Server.Name
+}
+
+// GetCredentials returns the credentials secret reference, the namespace defaults to the namespace of the user
+func (in *RedisUser) GetCredentials() *SecretReference {
+	return in.Spec.Credentials.WithNamespace(in.GetNamespace())
+}
+
+func (in *RedisUser) ShouldTerminateSessions() bool {
+	return in.Spec.TerminateSessions == nil || *in.Spec.TerminateSessions
+}
+
+// +kubebuilder:object:root=true
+
+// RedisUserList contains a list of RedisUser
+type RedisUserList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RedisUser `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RedisUser{}, &RedisUserList{})
+}
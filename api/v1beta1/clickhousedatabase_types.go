@@ -0,0 +1,113 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClickHouseDatabaseSpec defines the desired state of ClickHouseDatabase
+type ClickHouseDatabaseSpec struct {
+	*DatabaseSpec `json:",inline"`
+
+	// Engine is the database engine, by default the server default (Atomic) is used.
+	// The engine is only applied when the database gets created.
+	// +kubebuilder:validation:Pattern=`^[A-Za-z][A-Za-z0-9_]*$`
+	// +optional
+	Engine string `json:"engine,omitempty"`
+
+	// EngineArguments are passed as string literals to the engine, for example the zookeeper path,
+	// shard and replica name of the Replicated engine.
+	// +optional
+	EngineArguments []string `json:"engineArguments,omitempty"`
+
+	// Cluster runs all statements for this database and its users ON CLUSTER
+	// +optional
+	Cluster string `json:"cluster,omitempty"`
+}
+
+// GetStatusConditions returns a pointer to the Status.Conditions slice
+func (in *ClickHouseDatabase) GetStatusConditions() *[]metav1.Condition {
+	return &in.Status.Conditions
+}
+
+// ClickHouseDatabaseStatus defines the observed state of ClickHouseDatabase
+// IMPORTANT: Run "make" to regenerate code after modifying this file
+type ClickHouseDatabaseStatus struct {
+	// Conditions holds the conditions for the ClickHouseDatabase.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// ObservedGeneration is the last generation reconciled by the controller
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// +genclient
+// +genclient:Namespaced
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=chd
+// +kubebuilder:subresource:status
+// +kubebuilder:deprecatedversion:warning="dbprovisioning.infra.doodle.com/v1beta1 is deprecated, use dbprovisioning.infra.doodle.com/v1"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"DatabaseReady\")].status",description=""
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type==\"DatabaseReady\")].message",description=""
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+
+// ClickHouseDatabase is the Schema for the clickhousedatabases API
+type ClickHouseDatabase struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClickHouseDatabaseSpec   `json:"spec,omitempty"`
+	Status ClickHouseDatabaseStatus `json:"status,omitempty"`
+}
+
+func (in *ClickHouseDatabase) GetRootSecret() *SecretReference {
+	if in.Spec.RootSecret.Namespace == "" {
+		in.Spec.RootSecret.Namespace = in.GetNamespace()
+	}
+
+	return in.Spec.RootSecret
+}
+
+func (in *ClickHouseDatabase) GetDatabaseName() string {
+	if in.Spec.DatabaseName != "" {
+		return in.Spec.DatabaseName
+	}
+
+	return in.GetName()
+}
+
+// +kubebuilder:object:root=true
+
+// ClickHouseDatabaseList contains a list of ClickHouseDatabase
+type ClickHouseDatabaseList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClickHouseDatabase `json:"items"`
+}
+
+func (d *ClickHouseDatabase) SetDefaults() error {
+	if d.Spec.DatabaseName == "" {
+		d.Spec.DatabaseName = d.GetName()
+	}
+
+	return nil
+}
+
+func init() {
+	SchemeBuilder.Register(&ClickHouseDatabase{}, &ClickHouseDatabaseList{})
+}
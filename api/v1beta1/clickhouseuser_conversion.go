@@ -0,0 +1,79 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	v1 "github.com/doodlescheduling/db-controller/api/v1"
+)
+
+// ConvertTo converts this ClickHouseUser to the hub version
+func (src *ClickHouseUser) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1.ClickHouseUser)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = v1.ClickHouseUserSpec{
+		Database:    convertPtr(src.Spec.Database, convertDatabaseReferenceTo),
+		Credentials: convertPtr(src.Spec.Credentials, convertSecretReferenceTo),
+		Hosts: convertSlice(src.Spec.Hosts, func(in ClickHouseHost) v1.ClickHouseHost {
+			return v1.ClickHouseHost{Type: v1.ClickHouseHostType(in.Type), Value: in.Value}
+		}),
+		Grants: convertSlice(src.Spec.Grants, func(in ClickHouseGrant) v1.ClickHouseGrant {
+			return v1.ClickHouseGrant{Table: in.Table, Privileges: convertSlice(in.Privileges, convertPrivilegeTo)}
+		}),
+		Profile: src.Spec.Profile,
+		Settings: convertSlice(src.Spec.Settings, func(in ClickHouseSetting) v1.ClickHouseSetting {
+			return v1.ClickHouseSetting(in)
+		}),
+		Quotas: convertSlice(src.Spec.Quotas, func(in ClickHouseQuota) v1.ClickHouseQuota {
+			return v1.ClickHouseQuota(in)
+		}),
+		ValidUntil:     src.Spec.ValidUntil,
+		DeletionPolicy: v1.DeletionPolicy(src.Spec.DeletionPolicy),
+	}
+	dst.Status = v1.ClickHouseUserStatus(src.Status)
+
+	return nil
+}
+
+// ConvertFrom converts from the hub version to this ClickHouseUser
+func (dst *ClickHouseUser) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1.ClickHouseUser)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = ClickHouseUserSpec{
+		Database:    convertPtr(src.Spec.Database, convertDatabaseReferenceFrom),
+		Credentials: convertPtr(src.Spec.Credentials, convertSecretReferenceFrom),
+		Hosts: convertSlice(src.Spec.Hosts, func(in v1.ClickHouseHost) ClickHouseHost {
+			return ClickHouseHost{Type: ClickHouseHostType(in.Type), Value: in.Value}
+		}),
+		Grants: convertSlice(src.Spec.Grants, func(in v1.ClickHouseGrant) ClickHouseGrant {
+			return ClickHouseGrant{Table: in.Table, Privileges: convertSlice(in.Privileges, convertPrivilegeFrom)}
+		}),
+		Profile: src.Spec.Profile,
+		Settings: convertSlice(src.Spec.Settings, func(in v1.ClickHouseSetting) ClickHouseSetting {
+			return ClickHouseSetting(in)
+		}),
+		Quotas: convertSlice(src.Spec.Quotas, func(in v1.ClickHouseQuota) ClickHouseQuota {
+			return ClickHouseQuota(in)
+		}),
+		ValidUntil:     src.Spec.ValidUntil,
+		DeletionPolicy: DeletionPolicy(src.Spec.DeletionPolicy),
+	}
+	dst.Status = ClickHouseUserStatus(src.Status)
+
+	return nil
+}
@@ -0,0 +1,99 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	v1 "github.com/doodlescheduling/db-controller/api/v1"
+)
+
+// v1beta1 is a spoke of the v1 hub, every kind converts to and from its v1 counterpart.
+// The fields are copied one by one as the v1 types differ in the pointer semantics of
+// embedded and nested fields.
+
+// convertSlice converts each element of a slice, nil stays nil
+func convertSlice[S, D any](in []S, convert func(S) D) []D {
+	if in == nil {
+		return nil
+	}
+
+	out := make([]D, len(in))
+	for i := range in {
+		out[i] = convert(in[i])
+	}
+
+	return out
+}
+
+// convertPtr converts the value behind a pointer, nil stays nil
+func convertPtr[S, D any](in *S, convert func(S) D) *D {
+	if in == nil {
+		return nil
+	}
+
+	out := convert(*in)
+	return &out
+}
+
+func convertDatabaseSpecTo(in *DatabaseSpec) v1.DatabaseSpec {
+	if in == nil {
+		return v1.DatabaseSpec{}
+	}
+
+	return v1.DatabaseSpec{
+		Timeout:      in.Timeout,
+		DatabaseName: in.DatabaseName,
+		Address:      in.Address,
+		RootSecret:   convertPtr(in.RootSecret, convertSecretReferenceTo),
+	}
+}
+
+func convertDatabaseSpecFrom(in v1.DatabaseSpec) *DatabaseSpec {
+	if in.Timeout == nil && in.DatabaseName == "" && in.Address == "" && in.RootSecret == nil {
+		return nil
+	}
+
+	return &DatabaseSpec{
+		Timeout:      in.Timeout,
+		DatabaseName: in.DatabaseName,
+		Address:      in.Address,
+		RootSecret:   convertPtr(in.RootSecret, convertSecretReferenceFrom),
+	}
+}
+
+func convertSecretReferenceTo(in SecretReference) v1.SecretReference {
+	return v1.SecretReference(in)
+}
+
+func convertSecretReferenceFrom(in v1.SecretReference) SecretReference {
+	return SecretReference(in)
+}
+
+func convertDatabaseReferenceTo(in DatabaseReference) v1.DatabaseReference {
+	return v1.DatabaseReference(in)
+}
+
+func convertDatabaseReferenceFrom(in v1.DatabaseReference) DatabaseReference {
+	return DatabaseReference(in)
+}
+
+func convertPrivilegeTo(in Privilege) v1.Privilege {
+	return v1.Privilege(in)
+}
+
+func convertPrivilegeFrom(in v1.Privilege) Privilege {
+	return Privilege(in)
+}
@@ -0,0 +1,182 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/doodlescheduling/db-controller/api/v1"
+	"github.com/doodlescheduling/db-controller/api/v1beta1"
+)
+
+var _ = Describe("Conversion", func() {
+	objectMeta := metav1.ObjectMeta{
+		Name:       "example",
+		Namespace:  "default",
+		Generation: 2,
+	}
+
+	It("converts an Atlas MongoDBDatabase from and to the hub", func() {
+		src := &v1beta1.MongoDBDatabase{
+			ObjectMeta: objectMeta,
+			Spec: v1beta1.MongoDBDatabaseSpec{
+				DatabaseSpec: &v1beta1.DatabaseSpec{
+					DatabaseName: "app",
+					RootSecret: &v1beta1.SecretReference{
+						Name:          "atlas",
+						Namespace:     "default",
+						UserField:     "publicKey",
+						PasswordField: "privateKey",
+					},
+				},
+				AtlasGroupId: "group",
+				AtlasBaseURL: "https://atlas.example.com/",
+				AccessList: []v1beta1.AtlasAccessListEntry{
+					{CIDRBlock: "10.0.0.0/24", Comment: "office"},
+				},
+			},
+			Status: v1beta1.MongoDBDatabaseStatus{
+				ObservedGeneration: 2,
+				AccessList:         []string{"10.0.0.0/24"},
+			},
+		}
+
+		hub := &v1.MongoDBDatabase{}
+		Expect(src.ConvertTo(hub)).To(Succeed())
+		Expect(hub.Spec.RootSecret).To(BeNil())
+		Expect(hub.Spec.DatabaseName).To(Equal("app"))
+		Expect(hub.Spec.Atlas).To(Equal(&v1.MongoDBAtlasSpec{
+			GroupID: "group",
+			BaseURL: "https://atlas.example.com/",
+			APIKeySecret: &v1.AtlasAPIKeySecretReference{
+				Name:            "atlas",
+				Namespace:       "default",
+				PublicKeyField:  "publicKey",
+				PrivateKeyField: "privateKey",
+			},
+			AccessList: []v1.AtlasAccessListEntry{
+				{CIDRBlock: "10.0.0.0/24", Comment: "office"},
+			},
+		}))
+		Expect(hub.Status.AccessList).To(ConsistOf("10.0.0.0/24"))
+
+		dst := &v1beta1.MongoDBDatabase{}
+		Expect(dst.ConvertFrom(hub)).To(Succeed())
+		Expect(dst).To(Equal(src))
+	})
+
+	It("converts a self hosted MongoDBDatabase from and to the hub", func() {
+		src := &v1beta1.MongoDBDatabase{
+			ObjectMeta: objectMeta,
+			Spec: v1beta1.MongoDBDatabaseSpec{
+				DatabaseSpec: &v1beta1.DatabaseSpec{
+					RootSecret: &v1beta1.SecretReference{Name: "root", UserField: "username", PasswordField: "password"},
+				},
+				AuthMechanism: v1beta1.MongoDBAuthMechanismX509,
+				TLSSecret:     &v1beta1.TLSSecretReference{Name: "tls"},
+				Seeds: []v1beta1.MongoDBSeed{
+					{Name: "users", ConfigMap: &v1beta1.ConfigMapReference{Name: "users"}, Type: v1beta1.MongoDBSeedDocuments, Collection: "users"},
+				},
+			},
+		}
+
+		hub := &v1.MongoDBDatabase{}
+		Expect(src.ConvertTo(hub)).To(Succeed())
+		Expect(hub.Spec.Atlas).To(BeNil())
+		Expect(hub.Spec.RootSecret.Name).To(Equal("root"))
+
+		dst := &v1beta1.MongoDBDatabase{}
+		Expect(dst.ConvertFrom(hub)).To(Succeed())
+		Expect(dst).To(Equal(src))
+	})
+
+	It("converts the MongoDBUser roles from and to the hub", func() {
+		src := &v1beta1.MongoDBUser{
+			ObjectMeta: objectMeta,
+			Spec: v1beta1.MongoDBUserSpec{
+				Database:    &v1beta1.DatabaseReference{Name: "database"},
+				Credentials: &v1beta1.SecretReference{Name: "credentials"},
+				Roles: &[]v1beta1.MongoDBUserRole{
+					{Name: "read", DB: "reporting"},
+				},
+			},
+		}
+
+		hub := &v1.MongoDBUser{}
+		Expect(src.ConvertTo(hub)).To(Succeed())
+		Expect(hub.Spec.Roles).To(Equal([]v1.MongoDBUserRole{{Name: "read", DB: "reporting"}}))
+
+		dst := &v1beta1.MongoDBUser{}
+		Expect(dst.ConvertFrom(hub)).To(Succeed())
+		Expect(dst).To(Equal(src))
+	})
+
+	It("converts the PostgreSQLUser privileges from and to the hub", func() {
+		src := &v1beta1.PostgreSQLUser{
+			ObjectMeta: objectMeta,
+			Spec: v1beta1.PostgreSQLUserSpec{
+				Database:    &v1beta1.DatabaseReference{Name: "database"},
+				Credentials: &v1beta1.SecretReference{Name: "credentials"},
+				Grants: []v1beta1.Grant{
+					{Object: "TABLE", ObjectName: "users", Privileges: []v1beta1.Privilege{v1beta1.AlPrivilege}},
+				},
+			},
+		}
+
+		hub := &v1.PostgreSQLUser{}
+		Expect(src.ConvertTo(hub)).To(Succeed())
+		Expect(hub.Spec.Grants[0].Privileges).To(ConsistOf(v1.AllPrivileges))
+
+		dst := &v1beta1.PostgreSQLUser{}
+		Expect(dst.ConvertFrom(hub)).To(Succeed())
+		Expect(dst).To(Equal(src))
+	})
+
+	It("keeps an empty DatabaseSpec nil", func() {
+		src := &v1beta1.RabbitMQVhost{ObjectMeta: objectMeta}
+
+		hub := &v1.RabbitMQVhost{}
+		Expect(src.ConvertTo(hub)).To(Succeed())
+
+		dst := &v1beta1.RabbitMQVhost{}
+		Expect(dst.ConvertFrom(hub)).To(Succeed())
+		Expect(dst.Spec.DatabaseSpec).To(BeNil())
+	})
+
+	It("omits an unset databaseName from the hub", func() {
+		src := &v1beta1.MySQLDatabase{
+			ObjectMeta: objectMeta,
+			Spec: v1beta1.MySQLDatabaseSpec{
+				DatabaseSpec: &v1beta1.DatabaseSpec{
+					RootSecret: &v1beta1.SecretReference{Name: "root"},
+				},
+			},
+		}
+
+		hub := &v1.MySQLDatabase{}
+		Expect(src.ConvertTo(hub)).To(Succeed())
+
+		b, err := json.Marshal(hub)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).NotTo(ContainSubstring("databaseName"))
+		Expect(hub.GetDatabaseName()).To(Equal(objectMeta.Name))
+	})
+})
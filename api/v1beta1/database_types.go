@@ -12,10 +12,13 @@ const (
 
 // Status conditions
 const (
-	DatabaseReadyConditionType  = "DatabaseReady"
-	UserReadyConditionType      = "UserReady"
-	ExtensionReadyConditionType = "ExtensionReady"
-	SchemaReadyConditionType    = "SchemaReady"
+	DatabaseReadyConditionType   = "DatabaseReady"
+	UserReadyConditionType       = "UserReady"
+	ExtensionReadyConditionType  = "ExtensionReady"
+	SchemaReadyConditionType     = "SchemaReady"
+	SeedReadyConditionType       = "SeedReady"
+	AccessListReadyConditionType = "AccessListReady"
+	ServerReadyConditionType     = "ServerReady"
 )
 
 // Status reasons
@@ -35,9 +38,20 @@ const (
 	ProgressingReason                    = "ProgressingReason"
 	CreateExtensionsSuccessfulReason     = "CreateExtensionsSuccessful"
 	CreateSchemasSuccessfulReason        = "CreateSchemasSuccessful"
+	ConfigMapNotFoundReason              = "ConfigMapNotFound"
+	SeedFailedReason                     = "SeedFailed"
+	SeedSuccessfulReason                 = "SeedSuccessful"
+	AuthenticationFailedReason           = "AuthenticationFailed"
+	RateLimitedReason                    = "RateLimited"
+	ServerErrorReason                    = "ServerError"
+	AccessListFailedReason               = "AccessListFailed"
+	AccessListSuccessfulReason           = "AccessListSuccessful"
+	ConnectionSuccessfulReason           = "ConnectionSuccessful"
+	UnsupportedByFlavorReason            = "UnsupportedByFlavor"
 )
 
 // DatabaseSpec defines the desired state of a *Database
+// +kubebuilder:validation:XValidation:rule="!has(oldSelf.databaseName) || size(oldSelf.databaseName) == 0 || (has(self.databaseName) && self.databaseName == oldSelf.databaseName)",message="databaseName is immutable"
 type DatabaseSpec struct {
 	// Timeout reconciling the database and referenced resources
 	// +optional
@@ -86,6 +100,20 @@ type SecretReference struct {
 	AddressField string `json:"addressField"`
 }
 
+// WithNamespace returns a copy of the reference which falls back to namespace if no namespace is set
+func (in *SecretReference) WithNamespace(namespace string) *SecretReference {
+	if in == nil {
+		return nil
+	}
+
+	ref := in.DeepCopy()
+	if ref.Namespace == "" {
+		ref.Namespace = namespace
+	}
+
+	return ref
+}
+
 // conditionalResource is a resource with conditions
 type conditionalResource interface {
 	GetStatusConditions() *[]metav1.Condition
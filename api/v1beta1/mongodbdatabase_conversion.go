@@ -0,0 +1,126 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	v1 "github.com/doodlescheduling/db-controller/api/v1"
+)
+
+// ConvertTo converts this MongoDBDatabase to the hub version.
+// For Atlas projects the root secret holds the API key pair, it becomes atlas.apiKeySecret
+// with the user and password fields as public and private key fields.
+func (src *MongoDBDatabase) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1.MongoDBDatabase)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = v1.MongoDBDatabaseSpec{
+		DatabaseSpec:  convertDatabaseSpecTo(src.Spec.DatabaseSpec),
+		AuthSource:    src.Spec.AuthSource,
+		AuthMechanism: v1.MongoDBAuthMechanism(src.Spec.AuthMechanism),
+		TLSSecret: convertPtr(src.Spec.TLSSecret, func(in TLSSecretReference) v1.TLSSecretReference {
+			return v1.TLSSecretReference(in)
+		}),
+		ReplicaSet:     src.Spec.ReplicaSet,
+		ReadPreference: src.Spec.ReadPreference,
+		Seeds: convertSlice(src.Spec.Seeds, func(in MongoDBSeed) v1.MongoDBSeed {
+			return v1.MongoDBSeed{
+				Name: in.Name,
+				ConfigMap: convertPtr(in.ConfigMap, func(in ConfigMapReference) v1.ConfigMapReference {
+					return v1.ConfigMapReference(in)
+				}),
+				Type:       v1.MongoDBSeedType(in.Type),
+				Collection: in.Collection,
+			}
+		}),
+	}
+
+	if src.Spec.AtlasGroupId != "" {
+		dst.Spec.Atlas = &v1.MongoDBAtlasSpec{
+			GroupID: src.Spec.AtlasGroupId,
+			BaseURL: src.Spec.AtlasBaseURL,
+			APIKeySecret: convertPtr(dst.Spec.RootSecret, func(in v1.SecretReference) v1.AtlasAPIKeySecretReference {
+				return v1.AtlasAPIKeySecretReference{
+					Name:            in.Name,
+					Namespace:       in.Namespace,
+					PublicKeyField:  in.UserField,
+					PrivateKeyField: in.PasswordField,
+				}
+			}),
+			AccessList: convertSlice(src.Spec.AccessList, func(in AtlasAccessListEntry) v1.AtlasAccessListEntry {
+				return v1.AtlasAccessListEntry(in)
+			}),
+		}
+
+		dst.Spec.RootSecret = nil
+	}
+
+	dst.Status = v1.MongoDBDatabaseStatus(src.Status)
+
+	return nil
+}
+
+// ConvertFrom converts from the hub version to this MongoDBDatabase
+func (dst *MongoDBDatabase) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1.MongoDBDatabase)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = MongoDBDatabaseSpec{
+		DatabaseSpec:  convertDatabaseSpecFrom(src.Spec.DatabaseSpec),
+		AuthSource:    src.Spec.AuthSource,
+		AuthMechanism: MongoDBAuthMechanism(src.Spec.AuthMechanism),
+		TLSSecret: convertPtr(src.Spec.TLSSecret, func(in v1.TLSSecretReference) TLSSecretReference {
+			return TLSSecretReference(in)
+		}),
+		ReplicaSet:     src.Spec.ReplicaSet,
+		ReadPreference: src.Spec.ReadPreference,
+		Seeds: convertSlice(src.Spec.Seeds, func(in v1.MongoDBSeed) MongoDBSeed {
+			return MongoDBSeed{
+				Name: in.Name,
+				ConfigMap: convertPtr(in.ConfigMap, func(in v1.ConfigMapReference) ConfigMapReference {
+					return ConfigMapReference(in)
+				}),
+				Type:       MongoDBSeedType(in.Type),
+				Collection: in.Collection,
+			}
+		}),
+	}
+
+	if atlas := src.Spec.Atlas; atlas != nil {
+		dst.Spec.AtlasGroupId = atlas.GroupID
+		dst.Spec.AtlasBaseURL = atlas.BaseURL
+		dst.Spec.AccessList = convertSlice(atlas.AccessList, func(in v1.AtlasAccessListEntry) AtlasAccessListEntry {
+			return AtlasAccessListEntry(in)
+		})
+
+		if atlas.APIKeySecret != nil {
+			if dst.Spec.DatabaseSpec == nil {
+				dst.Spec.DatabaseSpec = &DatabaseSpec{}
+			}
+
+			dst.Spec.RootSecret = &SecretReference{
+				Name:          atlas.APIKeySecret.Name,
+				Namespace:     atlas.APIKeySecret.Namespace,
+				UserField:     atlas.APIKeySecret.PublicKeyField,
+				PasswordField: atlas.APIKeySecret.PrivateKeyField,
+			}
+		}
+	}
+
+	dst.Status = MongoDBDatabaseStatus(src.Status)
+
+	return nil
+}
@@ -20,10 +20,150 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// MongoDBSeedType defines how the contents of a seed are applied
+// +kubebuilder:validation:Enum=Documents;Commands
+type MongoDBSeedType string
+
+const (
+	// MongoDBSeedDocuments inserts the seed contents as documents into a collection
+	MongoDBSeedDocuments MongoDBSeedType = "Documents"
+	// MongoDBSeedCommands runs the seed contents as database commands
+	MongoDBSeedCommands MongoDBSeedType = "Commands"
+)
+
+// ConfigMapReference is a named reference to a ConfigMap
+type ConfigMapReference struct {
+	// Name referrs to the name of the ConfigMap, must be located whithin the same namespace
+	// +required
+	Name string `json:"name"`
+}
+
+// MongoDBSeed references a ConfigMap with extended JSON documents or commands
+// which are applied once to the database.
+// Each key of the ConfigMap holds either a single document or an array of documents
+// and keys are applied in lexical order.
+// +kubebuilder:validation:XValidation:rule="!has(self.type) || self.type != 'Documents' || (has(self.collection) && size(self.collection) > 0)",message="collection is required if the type is Documents"
+type MongoDBSeed struct {
+	// Name identifies the seed, applied seeds are recorded by this name
+	// +required
+	Name string `json:"name"`
+
+	// ConfigMap which holds the seed contents
+	// +required
+	ConfigMap *ConfigMapReference `json:"configMap"`
+
+	// Type of the seed contents
+	// +optional
+	// +kubebuilder:default:=Commands
+	Type MongoDBSeedType `json:"type,omitempty"`
+
+	// Collection the documents are inserted into, required if type is Documents
+	// +optional
+	Collection string `json:"collection,omitempty"`
+}
+
+// MongoDBAuthMechanism is the authentication mechanism used to connect to MongoDB
+// +kubebuilder:validation:Enum=SCRAM-SHA-1;SCRAM-SHA-256;MONGODB-X509
+type MongoDBAuthMechanism string
+
+const (
+	MongoDBAuthMechanismSCRAMSHA1   MongoDBAuthMechanism = "SCRAM-SHA-1"
+	MongoDBAuthMechanismSCRAMSHA256 MongoDBAuthMechanism = "SCRAM-SHA-256"
+	MongoDBAuthMechanismX509        MongoDBAuthMechanism = "MONGODB-X509"
+)
+
+// TLSSecretReference is a named reference to a secret which contains a TLS client certificate
+// using the keys tls.crt and tls.key and optionally a CA certificate using the key ca.crt
+type TLSSecretReference struct {
+	// Name referrs to the name of the secret, must be located whithin the same namespace
+	// +required
+	Name string `json:"name"`
+
+	// Namespace, by default the same namespace is used.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// AtlasAccessListEntry is an entry of the MongoDB Atlas project IP access list.
+// Either CIDRBlock or IPAddress must be set.
+// +kubebuilder:validation:XValidation:rule="(has(self.cidrBlock) && size(self.cidrBlock) > 0) != (has(self.ipAddress) && size(self.ipAddress) > 0)",message="either cidrBlock or ipAddress must be set"
+type AtlasAccessListEntry struct {
+	// CIDRBlock is a range of IP addresses in CIDR notation
+	// +optional
+	CIDRBlock string `json:"cidrBlock,omitempty"`
+
+	// IPAddress is a single IP address
+	// +optional
+	IPAddress string `json:"ipAddress,omitempty"`
+
+	// Comment associated with the entry
+	// +optional
+	Comment string `json:"comment,omitempty"`
+
+	// ExpiresAt defines when the entry is removed from the access list.
+	// When omitted, the entry remains until it is removed from the spec.
+	// +optional
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+}
+
+// GetEntry returns the CIDR block or IP address of the entry
+func (in AtlasAccessListEntry) GetEntry() string {
+	if in.CIDRBlock != "" {
+		return in.CIDRBlock
+	}
+
+	return in.IPAddress
+}
+
 // MongoDBDatabaseSpec defines the desired state of MongoDBDatabase
+// +kubebuilder:validation:XValidation:rule="!has(self.atlasGroupId) || size(self.atlasGroupId) == 0 || !has(self.address) || size(self.address) == 0",message="address and atlasGroupId are mutually exclusive, the Atlas API is set using atlasBaseURL"
+// +kubebuilder:validation:XValidation:rule="!has(self.atlasGroupId) || size(self.atlasGroupId) == 0 || (!has(self.authMechanism) && !has(self.authSource) && !has(self.tlsSecret))",message="atlasGroupId requires an Atlas API key pair as rootSecret, authMechanism, authSource and tlsSecret are not supported"
+// +kubebuilder:validation:XValidation:rule="!has(self.atlasBaseURL) || size(self.atlasBaseURL) == 0 || (has(self.atlasGroupId) && size(self.atlasGroupId) > 0)",message="atlasBaseURL requires atlasGroupId"
+// +kubebuilder:validation:XValidation:rule="!has(self.accessList) || size(self.accessList) == 0 || (has(self.atlasGroupId) && size(self.atlasGroupId) > 0)",message="accessList requires atlasGroupId"
+// +kubebuilder:validation:XValidation:rule="!has(self.seeds) || size(self.seeds) == 0 || !has(self.atlasGroupId) || size(self.atlasGroupId) == 0",message="seeds are not supported for MongoDB Atlas"
 type MongoDBDatabaseSpec struct {
 	*DatabaseSpec `json:",inline"`
 	AtlasGroupId  string `json:"atlasGroupId,omitempty"`
+
+	// AtlasBaseURL is the base URL of the MongoDB Atlas API.
+	// By default https://cloud.mongodb.com/ is used.
+	// +optional
+	AtlasBaseURL string `json:"atlasBaseURL,omitempty"`
+
+	// AuthSource is the database the root user authenticates against.
+	// By default the authSource from the address is used or admin if none is given.
+	// +optional
+	AuthSource string `json:"authSource,omitempty"`
+
+	// AuthMechanism used to authenticate the root user.
+	// If MONGODB-X509 is used the client certificate from the tlsSecret authenticates the root user
+	// and the password in the root secret is not required.
+	// +optional
+	AuthMechanism MongoDBAuthMechanism `json:"authMechanism,omitempty"`
+
+	// TLSSecret contains a client certificate and CA used to connect to MongoDB
+	// +optional
+	TLSSecret *TLSSecretReference `json:"tlsSecret,omitempty"`
+
+	// ReplicaSet name the server must belong to
+	// +optional
+	ReplicaSet string `json:"replicaSet,omitempty"`
+
+	// ReadPreference used for the connection
+	// +optional
+	// +kubebuilder:validation:Enum=primary;primaryPreferred;secondary;secondaryPreferred;nearest
+	ReadPreference string `json:"readPreference,omitempty"`
+
+	// Seeds are applied once against the database.
+	// Seeding is not supported for MongoDB Atlas.
+	// +optional
+	Seeds []MongoDBSeed `json:"seeds,omitempty"`
+
+	// AccessList entries are added to the IP access list of the Atlas project.
+	// Entries which are removed from the spec are removed from the access list.
+	// Only supported for MongoDB Atlas.
+	// +optional
+	AccessList []AtlasAccessListEntry `json:"accessList,omitempty"`
 }
 
 // GetStatusConditions returns a pointer to the Status.Conditions slice
@@ -40,6 +180,10 @@ type MongoDBDatabaseStatus struct {
 
 	// ObservedGeneration is the last generation reconciled by the controller
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// AccessList holds the entries of the Atlas project IP access list managed by the controller.
+	// +optional
+	AccessList []string `json:"accessList,omitempty"`
 }
 
 // +genclient
@@ -47,6 +191,7 @@ type MongoDBDatabaseStatus struct {
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:shortName=mdb
 // +kubebuilder:subresource:status
+// +kubebuilder:deprecatedversion:warning="dbprovisioning.infra.doodle.com/v1beta1 is deprecated, use dbprovisioning.infra.doodle.com/v1"
 // +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"DatabaseReady\")].status",description=""
 // +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type==\"DatabaseReady\")].message",description=""
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
@@ -60,12 +205,9 @@ type MongoDBDatabase struct {
 	Status MongoDBDatabaseStatus `json:"status,omitempty"`
 }
 
+// GetRootSecret returns the root secret reference, the namespace defaults to the namespace of the database
 func (in *MongoDBDatabase) GetRootSecret() *SecretReference {
-	if in.Spec.RootSecret.Namespace == "" {
-		in.Spec.RootSecret.Namespace = in.GetNamespace()
-	}
-
-	return in.Spec.RootSecret
+	return in.Spec.RootSecret.WithNamespace(in.GetNamespace())
 }
 
 func (in *MongoDBDatabase) GetDatabaseName() string {
@@ -77,7 +219,21 @@ func (in *MongoDBDatabase) GetDatabaseName() string {
 }
 
 func (in *MongoDBDatabase) GetRootDatabaseName() string {
-	return ""
+	return in.Spec.AuthSource
+}
+
+// GetTLSSecret returns the TLS secret reference if any, the namespace defaults to the namespace of the database
+func (in *MongoDBDatabase) GetTLSSecret() *TLSSecretReference {
+	if in.Spec.TLSSecret == nil {
+		return nil
+	}
+
+	ref := in.Spec.TLSSecret.DeepCopy()
+	if ref.Namespace == "" {
+		ref.Namespace = in.GetNamespace()
+	}
+
+	return ref
 }
 
 // +kubebuilder:object:root=true
@@ -89,12 +245,39 @@ type MongoDBDatabaseList struct {
 	Items           []MongoDBDatabase `json:"items"`
 }
 
-func (d *MongoDBDatabase) SetDefaults() error {
-	if d.Spec.DatabaseName == "" {
-		d.Spec.DatabaseName = d.GetName()
+// Default sets the database name and the namespaces of the root and TLS secrets if they are not set
+func (in *MongoDBDatabase) Default() {
+	if in.Spec.DatabaseSpec == nil {
+		in.Spec.DatabaseSpec = &DatabaseSpec{}
+	}
+
+	if in.Spec.DatabaseName == "" {
+		in.Spec.DatabaseName = in.GetName()
+	}
+
+	if in.Spec.RootSecret != nil && in.Spec.RootSecret.Namespace == "" {
+		in.Spec.RootSecret.Namespace = in.GetNamespace()
+	}
+
+	if in.Spec.TLSSecret != nil && in.Spec.TLSSecret.Namespace == "" {
+		in.Spec.TLSSecret.Namespace = in.GetNamespace()
 	}
+}
+
+func SeedNotReadyCondition(in conditionalResource, reason, message string) {
+	setResourceCondition(in, SeedReadyConditionType, metav1.ConditionFalse, reason, message)
+}
+
+func SeedReadyCondition(in conditionalResource, reason, message string) {
+	setResourceCondition(in, SeedReadyConditionType, metav1.ConditionTrue, reason, message)
+}
+
+func AccessListNotReadyCondition(in conditionalResource, reason, message string) {
+	setResourceCondition(in, AccessListReadyConditionType, metav1.ConditionFalse, reason, message)
+}
 
-	return nil
+func AccessListReadyCondition(in conditionalResource, reason, message string) {
+	setResourceCondition(in, AccessListReadyConditionType, metav1.ConditionTrue, reason, message)
 }
 
 func init() {
@@ -0,0 +1,96 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	v1 "github.com/doodlescheduling/db-controller/api/v1"
+)
+
+// ConvertTo converts this MongoDBUser to the hub version
+func (src *MongoDBUser) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1.MongoDBUser)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = v1.MongoDBUserSpec{
+		Database:    convertPtr(src.Spec.Database, convertDatabaseReferenceTo),
+		Credentials: convertPtr(src.Spec.Credentials, convertSecretReferenceTo),
+		Mechanisms:  convertSlice(src.Spec.Mechanisms, func(in MongoDBUserMechanism) v1.MongoDBUserMechanism { return v1.MongoDBUserMechanism(in) }),
+		AuthenticationRestrictions: convertSlice(src.Spec.AuthenticationRestrictions, func(in MongoDBAuthenticationRestriction) v1.MongoDBAuthenticationRestriction {
+			return v1.MongoDBAuthenticationRestriction(in)
+		}),
+		CustomData: src.Spec.CustomData,
+		Atlas: convertPtr(src.Spec.Atlas, func(in MongoDBAtlasUserSpec) v1.MongoDBAtlasUserSpec {
+			return v1.MongoDBAtlasUserSpec{
+				Scopes: convertSlice(in.Scopes, func(in MongoDBAtlasScope) v1.MongoDBAtlasScope {
+					return v1.MongoDBAtlasScope{Name: in.Name, Type: v1.MongoDBAtlasScopeType(in.Type)}
+				}),
+				Labels:       convertSlice(in.Labels, func(in MongoDBAtlasLabel) v1.MongoDBAtlasLabel { return v1.MongoDBAtlasLabel(in) }),
+				X509Type:     v1.MongoDBAtlasX509Type(in.X509Type),
+				AWSIAMType:   v1.MongoDBAtlasAWSIAMType(in.AWSIAMType),
+				LDAPAuthType: v1.MongoDBAtlasLDAPAuthType(in.LDAPAuthType),
+			}
+		}),
+		ValidUntil:        src.Spec.ValidUntil,
+		TerminateSessions: src.Spec.TerminateSessions,
+	}
+
+	if src.Spec.Roles != nil {
+		dst.Spec.Roles = convertSlice(*src.Spec.Roles, func(in MongoDBUserRole) v1.MongoDBUserRole { return v1.MongoDBUserRole(in) })
+	}
+
+	dst.Status = v1.MongoDBUserStatus(src.Status)
+
+	return nil
+}
+
+// ConvertFrom converts from the hub version to this MongoDBUser
+func (dst *MongoDBUser) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1.MongoDBUser)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = MongoDBUserSpec{
+		Database:    convertPtr(src.Spec.Database, convertDatabaseReferenceFrom),
+		Credentials: convertPtr(src.Spec.Credentials, convertSecretReferenceFrom),
+		Mechanisms:  convertSlice(src.Spec.Mechanisms, func(in v1.MongoDBUserMechanism) MongoDBUserMechanism { return MongoDBUserMechanism(in) }),
+		AuthenticationRestrictions: convertSlice(src.Spec.AuthenticationRestrictions, func(in v1.MongoDBAuthenticationRestriction) MongoDBAuthenticationRestriction {
+			return MongoDBAuthenticationRestriction(in)
+		}),
+		CustomData: src.Spec.CustomData,
+		Atlas: convertPtr(src.Spec.Atlas, func(in v1.MongoDBAtlasUserSpec) MongoDBAtlasUserSpec {
+			return MongoDBAtlasUserSpec{
+				Scopes: convertSlice(in.Scopes, func(in v1.MongoDBAtlasScope) MongoDBAtlasScope {
+					return MongoDBAtlasScope{Name: in.Name, Type: MongoDBAtlasScopeType(in.Type)}
+				}),
+				Labels:       convertSlice(in.Labels, func(in v1.MongoDBAtlasLabel) MongoDBAtlasLabel { return MongoDBAtlasLabel(in) }),
+				X509Type:     MongoDBAtlasX509Type(in.X509Type),
+				AWSIAMType:   MongoDBAtlasAWSIAMType(in.AWSIAMType),
+				LDAPAuthType: MongoDBAtlasLDAPAuthType(in.LDAPAuthType),
+			}
+		}),
+		ValidUntil:        src.Spec.ValidUntil,
+		TerminateSessions: src.Spec.TerminateSessions,
+	}
+
+	if src.Spec.Roles != nil {
+		roles := convertSlice(src.Spec.Roles, func(in v1.MongoDBUserRole) MongoDBUserRole { return MongoDBUserRole(in) })
+		dst.Spec.Roles = &roles
+	}
+
+	dst.Status = MongoDBUserStatus(src.Status)
+
+	return nil
+}
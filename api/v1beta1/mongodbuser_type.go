@@ -18,6 +18,7 @@ package v1beta1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 type MongoDBUserRole struct {
@@ -27,6 +28,91 @@ type MongoDBUserRole struct {
 	DB string `json:"db,omitempty"`
 }
 
+// MongoDBAuthenticationRestriction restricts from where a user may authenticate
+type MongoDBAuthenticationRestriction struct {
+	// ClientSource is a list of IP addresses or CIDR ranges the client must connect from
+	// +optional
+	ClientSource []string `json:"clientSource,omitempty"`
+
+	// ServerAddress is a list of IP addresses or CIDR ranges the client must connect to
+	// +optional
+	ServerAddress []string `json:"serverAddress,omitempty"`
+}
+
+// MongoDBUserMechanism is a SCRAM mechanism a user supports
+// +kubebuilder:validation:Enum=SCRAM-SHA-1;SCRAM-SHA-256
+type MongoDBUserMechanism string
+
+// MongoDBAtlasScopeType is the type of an Atlas resource a user can be limited to
+// +kubebuilder:validation:Enum=CLUSTER;DATA_LAKE
+type MongoDBAtlasScopeType string
+
+const (
+	MongoDBAtlasScopeCluster  MongoDBAtlasScopeType = "CLUSTER"
+	MongoDBAtlasScopeDataLake MongoDBAtlasScopeType = "DATA_LAKE"
+)
+
+// MongoDBAtlasScope limits an Atlas user to a cluster or data lake
+type MongoDBAtlasScope struct {
+	// Name of the cluster or data lake
+	Name string `json:"name"`
+
+	// +optional
+	// +kubebuilder:default:=CLUSTER
+	Type MongoDBAtlasScopeType `json:"type,omitempty"`
+}
+
+// MongoDBAtlasLabel tags an Atlas user
+type MongoDBAtlasLabel struct {
+	Key string `json:"key"`
+
+	// +optional
+	Value string `json:"value,omitempty"`
+}
+
+// MongoDBAtlasX509Type defines if an Atlas user authenticates using X.509 certificates
+// +kubebuilder:validation:Enum=NONE;MANAGED;CUSTOMER
+type MongoDBAtlasX509Type string
+
+// MongoDBAtlasAWSIAMType defines if an Atlas user authenticates using an AWS IAM user or role
+// +kubebuilder:validation:Enum=NONE;USER;ROLE
+type MongoDBAtlasAWSIAMType string
+
+// MongoDBAtlasLDAPAuthType defines if an Atlas user is an LDAP user or group
+// +kubebuilder:validation:Enum=NONE;USER;GROUP
+type MongoDBAtlasLDAPAuthType string
+
+const (
+	MongoDBAtlasUserTypeNone = "NONE"
+)
+
+// MongoDBAtlasUserSpec holds settings which only apply to MongoDB Atlas users
+type MongoDBAtlasUserSpec struct {
+	// Scopes limit the user to the listed clusters and data lakes.
+	// By default the user has access to all clusters and data lakes of the project.
+	// +optional
+	Scopes []MongoDBAtlasScope `json:"scopes,omitempty"`
+
+	// Labels tag the user in Atlas.
+	// +optional
+	Labels []MongoDBAtlasLabel `json:"labels,omitempty"`
+
+	// X509Type creates a user which authenticates using X.509 certificates.
+	// The username is the distinguished name of the certificate and no password is required.
+	// +optional
+	X509Type MongoDBAtlasX509Type `json:"x509Type,omitempty"`
+
+	// AWSIAMType creates a user which authenticates using an AWS IAM user or role.
+	// The username is the ARN of the IAM user or role and no password is required.
+	// +optional
+	AWSIAMType MongoDBAtlasAWSIAMType `json:"awsIAMType,omitempty"`
+
+	// LDAPAuthType creates an LDAP user or group.
+	// The username is the distinguished name of the LDAP user or group and no password is required.
+	// +optional
+	LDAPAuthType MongoDBAtlasLDAPAuthType `json:"ldapAuthType,omitempty"`
+}
+
 type MongoDBUserSpec struct {
 	// +required
 	Database *DatabaseReference `json:"database"`
@@ -37,11 +123,44 @@ type MongoDBUserSpec struct {
 	// +optional
 	// +kubebuilder:default:={{name: readWrite}}
 	Roles *[]MongoDBUserRole `json:"roles"`
+
+	// Mechanisms are the SCRAM mechanisms the user supports.
+	// By default the server decides which mechanisms are supported.
+	// Mechanisms are not supported for MongoDB Atlas.
+	// +optional
+	Mechanisms []MongoDBUserMechanism `json:"mechanisms,omitempty"`
+
+	// AuthenticationRestrictions restrict from where the user may authenticate.
+	// AuthenticationRestrictions are not supported for MongoDB Atlas.
+	// +optional
+	AuthenticationRestrictions []MongoDBAuthenticationRestriction `json:"authenticationRestrictions,omitempty"`
+
+	// CustomData is free-form data stored alongside the user.
+	// CustomData is not supported for MongoDB Atlas.
+	// +optional
+	// +kubebuilder:pruning:PreserveUnknownFields
+	CustomData *runtime.RawExtension `json:"customData,omitempty"`
+
+	// Atlas holds settings which only apply if the referenced database is a MongoDB Atlas project.
+	// +optional
+	Atlas *MongoDBAtlasUserSpec `json:"atlas,omitempty"`
+
 	// ValidUntil defines until when this database user should remain active.
 	// After this timestamp, the controller disables the user by deleting it.
 	// When omitted, the user remains active until the resource is deleted.
+	// For MongoDB Atlas the user is additionally created with a deleteAfterDate
+	// once ValidUntil is less than a week ahead.
+	// The timestamp must be in RFC 3339 format.
 	// +optional
+	// +kubebuilder:validation:Format=date-time
 	ValidUntil *metav1.Time `json:"validUntil,omitempty"`
+
+	// TerminateSessions defines if active sessions of the user are terminated
+	// once the user expires or is deleted.
+	// Terminating sessions is not supported for MongoDB Atlas.
+	// +optional
+	// +kubebuilder:default:=true
+	TerminateSessions *bool `json:"terminateSessions,omitempty"`
 }
 
 // GetStatusConditions returns a pointer to the Status.Conditions slice
@@ -60,6 +179,15 @@ type MongoDBUserStatus struct {
 	// +optional
 	Username string `json:"username,omitempty"`
 
+	// CredentialsHash is an HMAC-SHA256 of the credentials which were applied last, keyed with CredentialsSalt.
+	// The password of an existing user is only updated if the credentials differ from this hash.
+	// +optional
+	CredentialsHash string `json:"credentialsHash,omitempty"`
+
+	// CredentialsSalt is the random key of CredentialsHash.
+	// +optional
+	CredentialsSalt string `json:"credentialsSalt,omitempty"`
+
 	// ObservedGeneration is the last generation reconciled by the controller
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 }
@@ -69,6 +197,7 @@ type MongoDBUserStatus struct {
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:shortName=mdu
 // +kubebuilder:subresource:status
+// +kubebuilder:deprecatedversion:warning="dbprovisioning.infra.doodle.com/v1beta1 is deprecated, use dbprovisioning.infra.doodle.com/v1"
 // +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"UserReady\")].status",description=""
 // +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type==\"UserReady\")].message",description=""
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
@@ -86,13 +215,16 @@ func (in *MongoDBUser) GetDatabase() string {
 	return in.Spec.Database.Name
 }
 
+// GetCredentials returns the credentials secret reference, the namespace defaults to the namespace of the user
 func (in *MongoDBUser) GetCredentials() *SecretReference {
-	sec := in.Spec.Credentials
-	if sec.Namespace == "" {
-		sec.Namespace = in.GetNamespace()
-	}
+	return in.Spec.Credentials.WithNamespace(in.GetNamespace())
+}
 
-	return sec
+// Default sets the namespace of the credentials secret if it is not set
+func (in *MongoDBUser) Default() {
+	if in.Spec.Credentials != nil && in.Spec.Credentials.Namespace == "" {
+		in.Spec.Credentials.Namespace = in.GetNamespace()
+	}
 }
 
 func (in *MongoDBUser) GetRoles() []MongoDBUserRole {
@@ -103,6 +235,26 @@ func (in *MongoDBUser) GetRoles() []MongoDBUserRole {
 	return *in.Spec.Roles
 }
 
+// RequiresPassword returns false if the user authenticates using an external
+// mechanism such as X.509, AWS IAM or LDAP
+func (in *MongoDBUser) RequiresPassword() bool {
+	if in.Spec.Atlas == nil {
+		return true
+	}
+
+	for _, t := range []string{string(in.Spec.Atlas.X509Type), string(in.Spec.Atlas.AWSIAMType), string(in.Spec.Atlas.LDAPAuthType)} {
+		if t != "" && t != MongoDBAtlasUserTypeNone {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (in *MongoDBUser) ShouldTerminateSessions() bool {
+	return in.Spec.TerminateSessions == nil || *in.Spec.TerminateSessions
+}
+
 // +kubebuilder:object:root=true
 
 // MongoDBUserList contains a list of MongoDBUser
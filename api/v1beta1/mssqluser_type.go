@@ -0,0 +1,143 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultMSSQLSchema is the default schema of database users
+const DefaultMSSQLSchema = "dbo"
+
+type MSSQLUserSpec struct {
+	// +required
+	Database *DatabaseReference `json:"database"`
+
+	// +required
+	Credentials *SecretReference `json:"credentials"`
+
+	// Roles are fixed database roles like db_datareader or custom roles which already exist in the database.
+	// Memberships in roles which are not listed are removed.
+	// +kubebuilder:default:={db_owner}
+	Roles []string `json:"roles,omitempty"`
+
+	// DefaultSchema of the database user
+	// +kubebuilder:default:=dbo
+	// +optional
+	DefaultSchema string `json:"defaultSchema,omitempty"`
+
+	// CheckPolicy enforces the password policy of the server for the login
+	// +optional
+	CheckPolicy bool `json:"checkPolicy,omitempty"`
+
+	// ValidUntil defines until when this database user should remain active.
+	// After this timestamp, the controller revokes the connect permission of the user on the database.
+	// When omitted, the user remains active until the resource is deleted.
+	// +optional
+	ValidUntil *metav1.Time `json:"validUntil,omitempty"`
+
+	// DeletionPolicy defines what happens to the user once the resource is deleted.
+	// Disable removes the role memberships and revokes the connect permission of the database user
+	// while Drop drops the database user.
+	// In both cases the server login is disabled respectively dropped only if it is not mapped to a user in another database.
+	// +optional
+	// +kubebuilder:default:=Disable
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// TerminateSessions kills the sessions of the login within the database
+	// once the user expires or gets disabled or dropped.
+	// +optional
+	// +kubebuilder:default:=true
+	TerminateSessions *bool `json:"terminateSessions,omitempty"`
+}
+
+// GetStatusConditions returns a pointer to the Status.Conditions slice
+func (in *MSSQLUser) GetStatusConditions() *[]metav1.Condition {
+	return &in.Status.Conditions
+}
+
+// MSSQLUserStatus defines the observed state of MSSQLUser
+// IMPORTANT: Run "make" to regenerate code after modifying this file
+type MSSQLUserStatus struct {
+	// Conditions holds the conditions for the MSSQLUser.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Username of the created login and database user.
+	// +optional
+	Username string `json:"username,omitempty"`
+
+	// ObservedGeneration is the last generation reconciled by the controller
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// +genclient
+// +genclient:Namespaced
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=msu
+// +kubebuilder:subresource:status
+// +kubebuilder:deprecatedversion:warning="dbprovisioning.infra.doodle.com/v1beta1 is deprecated, use dbprovisioning.infra.doodle.com/v1"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"UserReady\")].status",description=""
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type==\"UserReady\")].message",description=""
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+
+// MSSQLUser is the Schema for the mssqlusers API
+type MSSQLUser struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MSSQLUserSpec   `json:"spec,omitempty"`
+	Status MSSQLUserStatus `json:"status,omitempty"`
+}
+
+func (in *MSSQLUser) GetDatabase() string {
+	return in.Spec.Database.Name
+}
+
+func (in *MSSQLUser) GetCredentials() *SecretReference {
+	sec := in.Spec.Credentials
+	if sec.Namespace == "" {
+		sec.Namespace = in.GetNamespace()
+	}
+
+	return sec
+}
+
+func (in *MSSQLUser) GetDefaultSchema() string {
+	if in.Spec.DefaultSchema != "" {
+		return in.Spec.DefaultSchema
+	}
+
+	return DefaultMSSQLSchema
+}
+
+func (in *MSSQLUser) ShouldTerminateSessions() bool {
+	return in.Spec.TerminateSessions == nil || *in.Spec.TerminateSessions
+}
+
+// +kubebuilder:object:root=true
+
+// MSSQLUserList contains a list of MSSQLUser
+type MSSQLUserList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MSSQLUser `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MSSQLUser{}, &MSSQLUserList{})
+}
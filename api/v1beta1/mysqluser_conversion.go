@@ -0,0 +1,71 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	v1 "github.com/doodlescheduling/db-controller/api/v1"
+)
+
+// ConvertTo converts this MySQLUser to the hub version
+func (src *MySQLUser) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1.MySQLUser)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = v1.MySQLUserSpec{
+		Database:    convertPtr(src.Spec.Database, convertDatabaseReferenceTo),
+		Credentials: convertPtr(src.Spec.Credentials, convertSecretReferenceTo),
+		Host:        src.Spec.Host,
+		AuthPlugin:  src.Spec.AuthPlugin,
+		Grants: convertSlice(src.Spec.Grants, func(in MySQLGrant) v1.MySQLGrant {
+			return v1.MySQLGrant{Table: in.Table, Privileges: convertSlice(in.Privileges, convertPrivilegeTo)}
+		}),
+		ResourceLimits: convertPtr(src.Spec.ResourceLimits, func(in MySQLResourceLimits) v1.MySQLResourceLimits {
+			return v1.MySQLResourceLimits(in)
+		}),
+		ValidUntil:        src.Spec.ValidUntil,
+		DeletionPolicy:    v1.DeletionPolicy(src.Spec.DeletionPolicy),
+		TerminateSessions: src.Spec.TerminateSessions,
+	}
+	dst.Status = v1.MySQLUserStatus(src.Status)
+
+	return nil
+}
+
+// ConvertFrom converts from the hub version to this MySQLUser
+func (dst *MySQLUser) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1.MySQLUser)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = MySQLUserSpec{
+		Database:    convertPtr(src.Spec.Database, convertDatabaseReferenceFrom),
+		Credentials: convertPtr(src.Spec.Credentials, convertSecretReferenceFrom),
+		Host:        src.Spec.Host,
+		AuthPlugin:  src.Spec.AuthPlugin,
+		Grants: convertSlice(src.Spec.Grants, func(in v1.MySQLGrant) MySQLGrant {
+			return MySQLGrant{Table: in.Table, Privileges: convertSlice(in.Privileges, convertPrivilegeFrom)}
+		}),
+		ResourceLimits: convertPtr(src.Spec.ResourceLimits, func(in v1.MySQLResourceLimits) MySQLResourceLimits {
+			return MySQLResourceLimits(in)
+		}),
+		ValidUntil:        src.Spec.ValidUntil,
+		DeletionPolicy:    DeletionPolicy(src.Spec.DeletionPolicy),
+		TerminateSessions: src.Spec.TerminateSessions,
+	}
+	dst.Status = MySQLUserStatus(src.Status)
+
+	return nil
+}
@@ -0,0 +1,182 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultMySQLHost allows the user to connect from any host
+const DefaultMySQLHost = "%"
+
+type MySQLUserSpec struct {
+	// +required
+	Database *DatabaseReference `json:"database"`
+
+	// +required
+	Credentials *SecretReference `json:"credentials"`
+
+	// Host is the host pattern the user is allowed to connect from, for example 10.0.0.% or %.example.com.
+	// +optional
+	// +kubebuilder:default:=%
+	Host string `json:"host,omitempty"`
+
+	// AuthPlugin is the authentication plugin of the user, for example caching_sha2_password or mysql_native_password.
+	// By default the server default is used.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9_]+$`
+	// +optional
+	AuthPlugin string `json:"authPlugin,omitempty"`
+
+	// Grants are privileges on the referenced database or its tables.
+	// Privileges on the database which are not part of the grants are revoked.
+	// +kubebuilder:default:={{privileges: {ALL}, table: "*"}}
+	Grants []MySQLGrant `json:"grants,omitempty"`
+
+	// ResourceLimits restrict the usage of server resources by the user
+	// +optional
+	ResourceLimits *MySQLResourceLimits `json:"resourceLimits,omitempty"`
+
+	// ValidUntil defines until when this database user should remain active.
+	// After this timestamp, the controller locks the account and terminates its active sessions.
+	// When omitted, the user remains active until the resource is deleted.
+	// +optional
+	ValidUntil *metav1.Time `json:"validUntil,omitempty"`
+
+	// DeletionPolicy defines what happens to the user once the resource is deleted.
+	// Disable revokes its privileges on the database, randomizes the password and locks the account
+	// while Drop drops the user.
+	// +optional
+	// +kubebuilder:default:=Disable
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// TerminateSessions defines if active sessions of the user are terminated
+	// once the user gets disabled, expires or is deleted.
+	// +optional
+	// +kubebuilder:default:=true
+	TerminateSessions *bool `json:"terminateSessions,omitempty"`
+}
+
+// MySQLGrant grants privileges on the referenced database or one of its tables
+type MySQLGrant struct {
+	// Table within the referenced database, * grants the privileges on the whole database
+	// +optional
+	// +kubebuilder:default:=*
+	Table string `json:"table,omitempty"`
+
+	// +required
+	Privileges []Privilege `json:"privileges"`
+}
+
+// MySQLResourceLimits are per account resource limits, 0 means unlimited
+type MySQLResourceLimits struct {
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxQueriesPerHour int64 `json:"maxQueriesPerHour,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxUpdatesPerHour int64 `json:"maxUpdatesPerHour,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxConnectionsPerHour int64 `json:"maxConnectionsPerHour,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxUserConnections int64 `json:"maxUserConnections,omitempty"`
+}
+
+// GetStatusConditions returns a pointer to the Status.Conditions slice
+func (in *MySQLUser) GetStatusConditions() *[]metav1.Condition {
+	return &in.Status.Conditions
+}
+
+// MySQLUserStatus defines the observed state of MySQLUser
+// IMPORTANT: Run "make" to regenerate code after modifying this file
+type MySQLUserStatus struct {
+	// Conditions holds the conditions for the MySQLUser.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Username of the created user.
+	// +optional
+	Username string `json:"username,omitempty"`
+
+	// Host pattern of the created user.
+	// +optional
+	Host string `json:"host,omitempty"`
+
+	// ObservedGeneration is the last generation reconciled by the controller
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// +genclient
+// +genclient:Namespaced
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=myu
+// +kubebuilder:subresource:status
+// +kubebuilder:deprecatedversion:warning="dbprovisioning.infra.doodle.com/v1beta1 is deprecated, use dbprovisioning.infra.doodle.com/v1"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"UserReady\")].status",description=""
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type==\"UserReady\")].message",description=""
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+
+// MySQLUser is the Schema for the mysqlusers API
+type MySQLUser struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MySQLUserSpec   `json:"spec,omitempty"`
+	Status MySQLUserStatus `json:"status,omitempty"`
+}
+
+func (in *MySQLUser) GetDatabase() string {
+	return in.Spec.Database.Name
+}
+
+func (in *MySQLUser) GetCredentials() *SecretReference {
+	sec := in.Spec.Credentials
+	if sec.Namespace == "" {
+		sec.Namespace = in.GetNamespace()
+	}
+
+	return sec
+}
+
+func (in *MySQLUser) GetHost() string {
+	if in.Spec.Host != "" {
+		return in.Spec.Host
+	}
+
+	return DefaultMySQLHost
+}
+
+func (in *MySQLUser) ShouldTerminateSessions() bool {
+	return in.Spec.TerminateSessions == nil || *in.Spec.TerminateSessions
+}
+
+// +kubebuilder:object:root=true
+
+// MySQLUserList contains a list of MySQLUser
+type MySQLUserList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MySQLUser `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MySQLUser{}, &MySQLUserList{})
+}
@@ -0,0 +1,63 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	v1 "github.com/doodlescheduling/db-controller/api/v1"
+)
+
+// ConvertTo converts this PostgreSQLDatabase to the hub version
+func (src *PostgreSQLDatabase) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1.PostgreSQLDatabase)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = v1.PostgreSQLDatabaseSpec{
+		DatabaseSpec: convertDatabaseSpecTo(src.Spec.DatabaseSpec),
+		Flavor:       v1.PostgreSQLFlavor(src.Spec.Flavor),
+		Extensions:   convertSlice(src.Spec.Extensions, func(in Extension) v1.Extension { return v1.Extension(in) }),
+		SearchPath:   convertSlice(src.Spec.SearchPath, convertSchemaTo),
+		Schemas:      convertSlice(src.Spec.Schemas, convertSchemaTo),
+	}
+	dst.Status = v1.PostgreSQLDatabaseStatus(src.Status)
+
+	return nil
+}
+
+// ConvertFrom converts from the hub version to this PostgreSQLDatabase
+func (dst *PostgreSQLDatabase) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1.PostgreSQLDatabase)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = PostgreSQLDatabaseSpec{
+		DatabaseSpec: convertDatabaseSpecFrom(src.Spec.DatabaseSpec),
+		Flavor:       PostgreSQLFlavor(src.Spec.Flavor),
+		Extensions:   convertSlice(src.Spec.Extensions, func(in v1.Extension) Extension { return Extension(in) }),
+		SearchPath:   convertSlice(src.Spec.SearchPath, convertSchemaFrom),
+		Schemas:      convertSlice(src.Spec.Schemas, convertSchemaFrom),
+	}
+	dst.Status = PostgreSQLDatabaseStatus(src.Status)
+
+	return nil
+}
+
+func convertSchemaTo(in Schema) v1.Schema {
+	return v1.Schema(in)
+}
+
+func convertSchemaFrom(in v1.Schema) Schema {
+	return Schema(in)
+}
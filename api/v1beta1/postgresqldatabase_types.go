@@ -36,10 +36,26 @@ type Schema struct {
 // Schemas is a collection of Schema types
 type Schemas []Schema
 
+// PostgreSQLFlavor is the server implementation behind the PostgreSQL wire protocol
+// +kubebuilder:validation:Enum=PostgreSQL;CockroachDB;YugabyteDB
+type PostgreSQLFlavor string
+
+const (
+	PostgreSQLFlavorPostgreSQL  PostgreSQLFlavor = "PostgreSQL"
+	PostgreSQLFlavorCockroachDB PostgreSQLFlavor = "CockroachDB"
+	PostgreSQLFlavorYugabyteDB  PostgreSQLFlavor = "YugabyteDB"
+)
+
 // PostgreSQLDatabaseSpec defines the desired state of PostgreSQLDatabase
 type PostgreSQLDatabaseSpec struct {
 	*DatabaseSpec `json:",inline"`
 
+	// Flavor selects the SQL dialect of the server.
+	// Features which are not supported by the flavor are skipped and reported in the conditions.
+	// +kubebuilder:default:=PostgreSQL
+	// +optional
+	Flavor PostgreSQLFlavor `json:"flavor,omitempty"`
+
 	// Database extensions
 	// +optional
 	Extensions Extensions `json:"extensions,omitempty"`
@@ -75,6 +91,7 @@ type PostgreSQLDatabaseStatus struct {
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:shortName=pgd
 // +kubebuilder:subresource:status
+// +kubebuilder:deprecatedversion:warning="dbprovisioning.infra.doodle.com/v1beta1 is deprecated, use dbprovisioning.infra.doodle.com/v1"
 // +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"DatabaseReady\")].status",description=""
 // +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type==\"DatabaseReady\")].message",description=""
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
@@ -88,12 +105,9 @@ type PostgreSQLDatabase struct {
 	Status PostgreSQLDatabaseStatus `json:"status,omitempty"`
 }
 
+// GetRootSecret returns the root secret reference, the namespace defaults to the namespace of the database
 func (in *PostgreSQLDatabase) GetRootSecret() *SecretReference {
-	if in.Spec.RootSecret.Namespace == "" {
-		in.Spec.RootSecret.Namespace = in.GetNamespace()
-	}
-
-	return in.Spec.RootSecret
+	return in.Spec.RootSecret.WithNamespace(in.GetNamespace())
 }
 
 func (in *PostgreSQLDatabase) GetDatabaseName() string {
@@ -104,6 +118,14 @@ func (in *PostgreSQLDatabase) GetDatabaseName() string {
 	return in.GetName()
 }
 
+func (in *PostgreSQLDatabase) GetFlavor() PostgreSQLFlavor {
+	if in.Spec.Flavor != "" {
+		return in.Spec.Flavor
+	}
+
+	return PostgreSQLFlavorPostgreSQL
+}
+
 func (in *PostgreSQLDatabase) GetRootDatabaseName() string {
 	return ""
 }
@@ -133,12 +155,19 @@ func SchemaReadyCondition(in conditionalResource, reason, message string) {
 	setResourceCondition(in, SchemaReadyConditionType, metav1.ConditionTrue, reason, message)
 }
 
-func (d *PostgreSQLDatabase) SetDefaults() error {
-	if d.Spec.DatabaseName == "" {
-		d.Spec.DatabaseName = d.GetName()
+// Default sets the database name and the namespace of the root secret if they are not set
+func (in *PostgreSQLDatabase) Default() {
+	if in.Spec.DatabaseSpec == nil {
+		in.Spec.DatabaseSpec = &DatabaseSpec{}
 	}
 
-	return nil
+	if in.Spec.DatabaseName == "" {
+		in.Spec.DatabaseName = in.GetName()
+	}
+
+	if in.Spec.RootSecret != nil && in.Spec.RootSecret.Namespace == "" {
+		in.Spec.RootSecret.Namespace = in.GetNamespace()
+	}
 }
 
 func init() {
@@ -0,0 +1,77 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	v1 "github.com/doodlescheduling/db-controller/api/v1"
+)
+
+// ConvertTo converts this PostgreSQLUser to the hub version
+func (src *PostgreSQLUser) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1.PostgreSQLUser)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = v1.PostgreSQLUserSpec{
+		Database:    convertPtr(src.Spec.Database, convertDatabaseReferenceTo),
+		Credentials: convertPtr(src.Spec.Credentials, convertSecretReferenceTo),
+		Grants: convertSlice(src.Spec.Grants, func(in Grant) v1.Grant {
+			return v1.Grant{
+				Object:     in.Object,
+				ObjectName: in.ObjectName,
+				User:       in.User,
+				Privileges: convertSlice(in.Privileges, convertPrivilegeTo),
+			}
+		}),
+		Roles:             src.Spec.Roles,
+		Attributes:        src.Spec.Attributes,
+		ValidUntil:        src.Spec.ValidUntil,
+		DeletionPolicy:    v1.DeletionPolicy(src.Spec.DeletionPolicy),
+		ReassignOwnedTo:   src.Spec.ReassignOwnedTo,
+		TerminateSessions: src.Spec.TerminateSessions,
+	}
+	dst.Status = v1.PostgreSQLUserStatus(src.Status)
+
+	return nil
+}
+
+// ConvertFrom converts from the hub version to this PostgreSQLUser
+func (dst *PostgreSQLUser) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1.PostgreSQLUser)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = PostgreSQLUserSpec{
+		Database:    convertPtr(src.Spec.Database, convertDatabaseReferenceFrom),
+		Credentials: convertPtr(src.Spec.Credentials, convertSecretReferenceFrom),
+		Grants: convertSlice(src.Spec.Grants, func(in v1.Grant) Grant {
+			return Grant{
+				Object:     in.Object,
+				ObjectName: in.ObjectName,
+				User:       in.User,
+				Privileges: convertSlice(in.Privileges, convertPrivilegeFrom),
+			}
+		}),
+		Roles:             src.Spec.Roles,
+		Attributes:        src.Spec.Attributes,
+		ValidUntil:        src.Spec.ValidUntil,
+		DeletionPolicy:    DeletionPolicy(src.Spec.DeletionPolicy),
+		ReassignOwnedTo:   src.Spec.ReassignOwnedTo,
+		TerminateSessions: src.Spec.TerminateSessions,
+	}
+	dst.Status = PostgreSQLUserStatus(src.Status)
+
+	return nil
+}
@@ -23,8 +23,21 @@ import (
 type Privilege string
 
 var SelectPrivilege Privilege = "SELECT"
+
+// Deprecated: AlPrivilege is misspelled, use AllPrivileges of the v1 API.
 var AlPrivilege Privilege = "ALL"
 
+// DeletionPolicy defines what happens to a database user once its resource is deleted
+// +kubebuilder:validation:Enum=Disable;Drop
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDisable revokes the privileges and randomizes the password of the user
+	DeletionPolicyDisable DeletionPolicy = "Disable"
+	// DeletionPolicyDrop reassigns and drops owned objects and drops the user afterwards
+	DeletionPolicyDrop DeletionPolicy = "Drop"
+)
+
 type PostgreSQLUserSpec struct {
 	// +required
 	Database *DatabaseReference `json:"database"`
@@ -33,26 +46,61 @@ type PostgreSQLUserSpec struct {
 	Credentials *SecretReference `json:"credentials"`
 
 	// +kubebuilder:default:={{privileges: {ALL}, object: SCHEMA, objectName: public}}
+	// +kubebuilder:validation:MaxItems=64
 	Grants []Grant `json:"grants,omitempty"`
 
 	// Roles are postgres roles granted to this user
 	Roles []string `json:"roles,omitempty"`
 
 	// Attributes are postgres attributes associated with this user
+	// +kubebuilder:validation:MaxItems=32
+	// +kubebuilder:validation:items:MaxLength=16
+	// +kubebuilder:validation:XValidation:rule="self.all(a, a in ['LOGIN', 'NOLOGIN', 'SUPERUSER', 'NOSUPERUSER', 'CREATEDB', 'NOCREATEDB', 'CREATEROLE', 'NOCREATEROLE', 'REPLICATION', 'NOREPLICATION', 'BYPASSRLS', 'NOBYPASSRLS', 'INHERIT', 'NOINHERIT'])",message="attributes must be role attributes such as LOGIN, CREATEDB or NOINHERIT"
 	Attributes []string `json:"attributes,omitempty"`
 
 	// ValidUntil defines until when this database user should remain active.
-	// After this timestamp, the controller disables the user by rotating its password
-	// and revoking its privileges.
+	// The timestamp is set as VALID UNTIL on the role so the server enforces it.
+	// After this timestamp, the controller additionally sets NOLOGIN on the role
+	// and terminates its active sessions.
 	// When omitted, the user remains active until the resource is deleted.
+	// The timestamp must be in RFC 3339 format.
 	// +optional
+	// +kubebuilder:validation:Format=date-time
 	ValidUntil *metav1.Time `json:"validUntil,omitempty"`
+
+	// DeletionPolicy defines what happens to the user once the resource is deleted.
+	// Disable revokes its privileges and randomizes the password while Drop reassigns
+	// objects owned by the user in every affected database and drops the user afterwards.
+	// +optional
+	// +kubebuilder:default:=Disable
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// ReassignOwnedTo is the role objects owned by the user are reassigned to if
+	// the deletion policy is Drop. By default objects are reassigned to the root user.
+	// +optional
+	ReassignOwnedTo string `json:"reassignOwnedTo,omitempty"`
+
+	// TerminateSessions defines if active sessions of the user are terminated
+	// once the user gets disabled, expires or is deleted.
+	// +optional
+	// +kubebuilder:default:=true
+	TerminateSessions *bool `json:"terminateSessions,omitempty"`
 }
 
+// +kubebuilder:validation:XValidation:rule="has(self.objectName) && size(self.objectName) > 0",message="objectName is required"
 type Grant struct {
-	Object     string      `json:"object,omitempty"`
-	ObjectName string      `json:"objectName,omitempty"`
-	User       string      `json:"user,omitempty"`
+	// Object is the type of the object privileges are granted on, for example SCHEMA or ALL TABLES IN SCHEMA
+	// +kubebuilder:validation:MaxLength=32
+	// +kubebuilder:validation:XValidation:rule="size(self) == 0 || self.upperAscii() in ['TABLE', 'SEQUENCE', 'DATABASE', 'DOMAIN', 'FOREIGN DATA WRAPPER', 'FOREIGN SERVER', 'FUNCTION', 'PROCEDURE', 'ROUTINE', 'LANGUAGE', 'PARAMETER', 'SCHEMA', 'TABLESPACE', 'TYPE', 'ALL TABLES IN SCHEMA', 'ALL SEQUENCES IN SCHEMA', 'ALL FUNCTIONS IN SCHEMA', 'ALL PROCEDURES IN SCHEMA', 'ALL ROUTINES IN SCHEMA']",message="object must be an object type such as SCHEMA, TABLE or ALL TABLES IN SCHEMA"
+	Object string `json:"object,omitempty"`
+
+	// +kubebuilder:validation:MaxLength=63
+	ObjectName string `json:"objectName,omitempty"`
+	User       string `json:"user,omitempty"`
+
+	// +kubebuilder:validation:MaxItems=32
+	// +kubebuilder:validation:items:MaxLength=16
+	// +kubebuilder:validation:XValidation:rule="self.all(p, p.upperAscii() in ['SELECT', 'INSERT', 'UPDATE', 'DELETE', 'TRUNCATE', 'REFERENCES', 'TRIGGER', 'CREATE', 'CONNECT', 'TEMPORARY', 'TEMP', 'EXECUTE', 'USAGE', 'SET', 'ALTER SYSTEM', 'MAINTAIN', 'ALL', 'ALL PRIVILEGES', 'DROP', 'ZONECONFIG', 'BACKUP', 'RESTORE', 'CHANGEFEED'])",message="privileges must be privileges such as SELECT, USAGE or ALL"
 	Privileges []Privilege `json:"privileges,omitempty"`
 }
 
@@ -81,6 +129,7 @@ type PostgreSQLUserStatus struct {
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:shortName=pgu
 // +kubebuilder:subresource:status
+// +kubebuilder:deprecatedversion:warning="dbprovisioning.infra.doodle.com/v1beta1 is deprecated, use dbprovisioning.infra.doodle.com/v1"
 // +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"UserReady\")].status",description=""
 // +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type==\"UserReady\")].message",description=""
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
@@ -98,13 +147,20 @@ func (in *PostgreSQLUser) GetDatabase() string {
 	return in.Spec.Database.Name
 }
 
+// GetCredentials returns the credentials secret reference, the namespace defaults to the namespace of the user
 func (in *PostgreSQLUser) GetCredentials() *SecretReference {
-	sec := in.Spec.Credentials
-	if sec.Namespace == "" {
-		sec.Namespace = in.GetNamespace()
+	return in.Spec.Credentials.WithNamespace(in.GetNamespace())
+}
+
+// Default sets the namespace of the credentials secret if it is not set
+func (in *PostgreSQLUser) Default() {
+	if in.Spec.Credentials != nil && in.Spec.Credentials.Namespace == "" {
+		in.Spec.Credentials.Namespace = in.GetNamespace()
 	}
+}
 
-	return sec
+func (in *PostgreSQLUser) ShouldTerminateSessions() bool {
+	return in.Spec.TerminateSessions == nil || *in.Spec.TerminateSessions
 }
 
 // +kubebuilder:object:root=true
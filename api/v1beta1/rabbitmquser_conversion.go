@@ -0,0 +1,69 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	v1 "github.com/doodlescheduling/db-controller/api/v1"
+)
+
+// ConvertTo converts this RabbitMQUser to the hub version
+func (src *RabbitMQUser) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1.RabbitMQUser)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = v1.RabbitMQUserSpec{
+		Vhost:       convertPtr(src.Spec.Vhost, convertDatabaseReferenceTo),
+		Credentials: convertPtr(src.Spec.Credentials, convertSecretReferenceTo),
+		Tags:        src.Spec.Tags,
+		Permissions: convertPtr(src.Spec.Permissions, func(in RabbitMQPermissions) v1.RabbitMQPermissions {
+			return v1.RabbitMQPermissions(in)
+		}),
+		TopicPermissions: convertSlice(src.Spec.TopicPermissions, func(in RabbitMQTopicPermission) v1.RabbitMQTopicPermission {
+			return v1.RabbitMQTopicPermission(in)
+		}),
+		ValidUntil:        src.Spec.ValidUntil,
+		DeletionPolicy:    v1.DeletionPolicy(src.Spec.DeletionPolicy),
+		TerminateSessions: src.Spec.TerminateSessions,
+	}
+	dst.Status = v1.RabbitMQUserStatus(src.Status)
+
+	return nil
+}
+
+// ConvertFrom converts from the hub version to this RabbitMQUser
+func (dst *RabbitMQUser) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1.RabbitMQUser)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = RabbitMQUserSpec{
+		Vhost:       convertPtr(src.Spec.Vhost, convertDatabaseReferenceFrom),
+		Credentials: convertPtr(src.Spec.Credentials, convertSecretReferenceFrom),
+		Tags:        src.Spec.Tags,
+		Permissions: convertPtr(src.Spec.Permissions, func(in v1.RabbitMQPermissions) RabbitMQPermissions {
+			return RabbitMQPermissions(in)
+		}),
+		TopicPermissions: convertSlice(src.Spec.TopicPermissions, func(in v1.RabbitMQTopicPermission) RabbitMQTopicPermission {
+			return RabbitMQTopicPermission(in)
+		}),
+		ValidUntil:        src.Spec.ValidUntil,
+		DeletionPolicy:    DeletionPolicy(src.Spec.DeletionPolicy),
+		TerminateSessions: src.Spec.TerminateSessions,
+	}
+	dst.Status = RabbitMQUserStatus(src.Status)
+
+	return nil
+}
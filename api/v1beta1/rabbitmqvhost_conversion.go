@@ -0,0 +1,53 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	v1 "github.com/doodlescheduling/db-controller/api/v1"
+)
+
+// ConvertTo converts this RabbitMQVhost to the hub version
+func (src *RabbitMQVhost) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1.RabbitMQVhost)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = v1.RabbitMQVhostSpec{
+		DatabaseSpec: convertDatabaseSpecTo(src.Spec.DatabaseSpec),
+		Description:  src.Spec.Description,
+		Tags:         src.Spec.Tags,
+		Tracing:      src.Spec.Tracing,
+	}
+	dst.Status = v1.RabbitMQVhostStatus(src.Status)
+
+	return nil
+}
+
+// ConvertFrom converts from the hub version to this RabbitMQVhost
+func (dst *RabbitMQVhost) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1.RabbitMQVhost)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = RabbitMQVhostSpec{
+		DatabaseSpec: convertDatabaseSpecFrom(src.Spec.DatabaseSpec),
+		Description:  src.Spec.Description,
+		Tags:         src.Spec.Tags,
+		Tracing:      src.Spec.Tracing,
+	}
+	dst.Status = RabbitMQVhostStatus(src.Status)
+
+	return nil
+}
@@ -0,0 +1,53 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	v1 "github.com/doodlescheduling/db-controller/api/v1"
+)
+
+// ConvertTo converts this RedisServer to the hub version
+func (src *RedisServer) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1.RedisServer)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = v1.RedisServerSpec{
+		Timeout:    src.Spec.Timeout,
+		Address:    src.Spec.Address,
+		RootSecret: convertPtr(src.Spec.RootSecret, convertSecretReferenceTo),
+		SaveACL:    src.Spec.SaveACL,
+	}
+	dst.Status = v1.RedisServerStatus(src.Status)
+
+	return nil
+}
+
+// ConvertFrom converts from the hub version to this RedisServer
+func (dst *RedisServer) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1.RedisServer)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = RedisServerSpec{
+		Timeout:    src.Spec.Timeout,
+		Address:    src.Spec.Address,
+		RootSecret: convertPtr(src.Spec.RootSecret, convertSecretReferenceFrom),
+		SaveACL:    src.Spec.SaveACL,
+	}
+	dst.Status = RedisServerStatus(src.Status)
+
+	return nil
+}
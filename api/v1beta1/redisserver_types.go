@@ -0,0 +1,106 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RedisServerSpec defines the desired state of RedisServer
+type RedisServerSpec struct {
+	// Timeout reconciling the server and referenced resources
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// The connect URI, either host:port or redis://host:port (rediss:// for TLS)
+	// +optional
+	Address string `json:"address,omitempty"`
+
+	// Contains a credentials set of a user with enough permission to manage ACL users.
+	// Use the username default if the server only has a password configured.
+	// +required
+	RootSecret *SecretReference `json:"rootSecret"`
+
+	// SaveACL runs ACL SAVE after users have been changed.
+	// Enable it if the server persists its users in an ACL file.
+	// +optional
+	SaveACL bool `json:"saveACL,omitempty"`
+}
+
+// GetStatusConditions returns a pointer to the Status.Conditions slice
+func (in *RedisServer) GetStatusConditions() *[]metav1.Condition {
+	return &in.Status.Conditions
+}
+
+// RedisServerStatus defines the observed state of RedisServer
+// IMPORTANT: Run "make" to regenerate code after modifying this file
+type RedisServerStatus struct {
+	// Conditions holds the conditions for the RedisServer.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// ObservedGeneration is the last generation reconciled by the controller
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// +genclient
+// +genclient:Namespaced
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=rds
+// +kubebuilder:subresource:status
+// +kubebuilder:deprecatedversion:warning="dbprovisioning.infra.doodle.com/v1beta1 is deprecated, use dbprovisioning.infra.doodle.com/v1"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"ServerReady\")].status",description=""
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type==\"ServerReady\")].message",description=""
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+
+// RedisServer is the Schema for the redisservers API
+type RedisServer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RedisServerSpec   `json:"spec,omitempty"`
+	Status RedisServerStatus `json:"status,omitempty"`
+}
+
+func (in *RedisServer) GetRootSecret() *SecretReference {
+	if in.Spec.RootSecret.Namespace == "" {
+		in.Spec.RootSecret.Namespace = in.GetNamespace()
+	}
+
+	return in.Spec.RootSecret
+}
+
+// +kubebuilder:object:root=true
+
+// RedisServerList contains a list of RedisServer
+type RedisServerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RedisServer `json:"items"`
+}
+
+func ServerNotReadyCondition(in conditionalResource, reason, message string) {
+	setResourceCondition(in, ServerReadyConditionType, metav1.ConditionFalse, reason, message)
+}
+
+func ServerReadyCondition(in conditionalResource, reason, message string) {
+	setResourceCondition(in, ServerReadyConditionType, metav1.ConditionTrue, reason, message)
+}
+
+func init() {
+	SchemeBuilder.Register(&RedisServer{}, &RedisServerList{})
+}
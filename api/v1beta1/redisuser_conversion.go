@@ -0,0 +1,61 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	v1 "github.com/doodlescheduling/db-controller/api/v1"
+)
+
+// ConvertTo converts this RedisUser to the hub version
+func (src *RedisUser) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1.RedisUser)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = v1.RedisUserSpec{
+		Server:            convertPtr(src.Spec.Server, convertDatabaseReferenceTo),
+		Credentials:       convertPtr(src.Spec.Credentials, convertSecretReferenceTo),
+		KeyPatterns:       src.Spec.KeyPatterns,
+		ChannelPatterns:   src.Spec.ChannelPatterns,
+		Categories:        convertSlice(src.Spec.Categories, func(in RedisCategory) v1.RedisCategory { return v1.RedisCategory(in) }),
+		ValidUntil:        src.Spec.ValidUntil,
+		DeletionPolicy:    v1.DeletionPolicy(src.Spec.DeletionPolicy),
+		TerminateSessions: src.Spec.TerminateSessions,
+	}
+	dst.Status = v1.RedisUserStatus(src.Status)
+
+	return nil
+}
+
+// ConvertFrom converts from the hub version to this RedisUser
+func (dst *RedisUser) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1.RedisUser)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = RedisUserSpec{
+		Server:            convertPtr(src.Spec.Server, convertDatabaseReferenceFrom),
+		Credentials:       convertPtr(src.Spec.Credentials, convertSecretReferenceFrom),
+		KeyPatterns:       src.Spec.KeyPatterns,
+		ChannelPatterns:   src.Spec.ChannelPatterns,
+		Categories:        convertSlice(src.Spec.Categories, func(in v1.RedisCategory) RedisCategory { return RedisCategory(in) }),
+		ValidUntil:        src.Spec.ValidUntil,
+		DeletionPolicy:    DeletionPolicy(src.Spec.DeletionPolicy),
+		TerminateSessions: src.Spec.TerminateSessions,
+	}
+	dst.Status = RedisUserStatus(src.Status)
+
+	return nil
+}
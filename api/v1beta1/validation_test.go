@@ -0,0 +1,226 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/doodlescheduling/db-controller/api/v1beta1"
+)
+
+var _ = Describe("CRD validation rules", Ordered, func() {
+	namespace := setupNamespace()
+
+	expectInvalid := func(err error, message string) {
+		Expect(apierrors.IsInvalid(err)).To(BeTrue(), "expected an invalid error, got %v", err)
+		Expect(err.Error()).To(ContainSubstring(message))
+	}
+
+	objectMeta := func(prefix string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{
+			Name:      prefix + "-" + randStringRunes(5),
+			Namespace: namespace.Name,
+		}
+	}
+
+	newPostgreSQLUser := func(spec v1beta1.PostgreSQLUserSpec) *v1beta1.PostgreSQLUser {
+		spec.Database = &v1beta1.DatabaseReference{Name: "database"}
+		spec.Credentials = &v1beta1.SecretReference{Name: "credentials"}
+
+		return &v1beta1.PostgreSQLUser{
+			ObjectMeta: objectMeta("postgresqluser"),
+			Spec:       spec,
+		}
+	}
+
+	newMongoDBDatabase := func(spec v1beta1.MongoDBDatabaseSpec) *v1beta1.MongoDBDatabase {
+		if spec.DatabaseSpec == nil {
+			spec.DatabaseSpec = &v1beta1.DatabaseSpec{}
+		}
+
+		spec.RootSecret = &v1beta1.SecretReference{Name: "root"}
+
+		return &v1beta1.MongoDBDatabase{
+			ObjectMeta: objectMeta("mongodbdatabase"),
+			Spec:       spec,
+		}
+	}
+
+	Describe("databaseName", func() {
+		It("can be set once", func() {
+			db := &v1beta1.PostgreSQLDatabase{
+				ObjectMeta: objectMeta("postgresqldatabase"),
+				Spec: v1beta1.PostgreSQLDatabaseSpec{
+					DatabaseSpec: &v1beta1.DatabaseSpec{
+						RootSecret: &v1beta1.SecretReference{Name: "root"},
+					},
+				},
+			}
+			Expect(k8sClient.Create(context.Background(), db)).Should(Succeed())
+
+			db.Spec.DatabaseName = "app"
+			Expect(k8sClient.Update(context.Background(), db)).Should(Succeed())
+		})
+
+		It("is immutable after creation", func() {
+			db := &v1beta1.MySQLDatabase{
+				ObjectMeta: objectMeta("mysqldatabase"),
+				Spec: v1beta1.MySQLDatabaseSpec{
+					DatabaseSpec: &v1beta1.DatabaseSpec{
+						DatabaseName: "app",
+						RootSecret:   &v1beta1.SecretReference{Name: "root"},
+					},
+				},
+			}
+			Expect(k8sClient.Create(context.Background(), db)).Should(Succeed())
+
+			db.Spec.DatabaseName = "other"
+			expectInvalid(k8sClient.Update(context.Background(), db), "databaseName is immutable")
+
+			db.Spec.DatabaseName = ""
+			expectInvalid(k8sClient.Update(context.Background(), db), "databaseName is immutable")
+		})
+	})
+
+	Describe("PostgreSQLUser", func() {
+		It("accepts valid attributes and case insensitive privileges", func() {
+			Expect(k8sClient.Create(context.Background(), newPostgreSQLUser(v1beta1.PostgreSQLUserSpec{
+				Attributes: []string{"CREATEDB", "NOINHERIT"},
+				Grants: []v1beta1.Grant{
+					{Object: "all tables in schema", ObjectName: "public", Privileges: []v1beta1.Privilege{"select", "INSERT"}},
+				},
+			}))).Should(Succeed())
+		})
+
+		It("rejects an unknown attribute", func() {
+			expectInvalid(k8sClient.Create(context.Background(), newPostgreSQLUser(v1beta1.PostgreSQLUserSpec{
+				Attributes: []string{"SUPERDUPERUSER"},
+			})), "attributes must be role attributes")
+		})
+
+		It("rejects an unknown privilege", func() {
+			expectInvalid(k8sClient.Create(context.Background(), newPostgreSQLUser(v1beta1.PostgreSQLUserSpec{
+				Grants: []v1beta1.Grant{
+					{Object: "SCHEMA", ObjectName: "public", Privileges: []v1beta1.Privilege{"USAGE", "EVERYTHING"}},
+				},
+			})), "privileges must be privileges")
+		})
+
+		It("rejects an unknown grant object", func() {
+			expectInvalid(k8sClient.Create(context.Background(), newPostgreSQLUser(v1beta1.PostgreSQLUserSpec{
+				Grants: []v1beta1.Grant{
+					{Object: "SCHEMA public TO public; --", ObjectName: "public", Privileges: []v1beta1.Privilege{"ALL"}},
+				},
+			})), "object must be an object type")
+		})
+
+		It("rejects a grant without object name", func() {
+			expectInvalid(k8sClient.Create(context.Background(), newPostgreSQLUser(v1beta1.PostgreSQLUserSpec{
+				Grants: []v1beta1.Grant{
+					{Object: "SCHEMA", Privileges: []v1beta1.Privilege{"ALL"}},
+				},
+			})), "objectName is required")
+		})
+
+		It("rejects validUntil which is not a RFC 3339 timestamp", func() {
+			for _, kind := range []string{"PostgreSQLUser", "MongoDBUser"} {
+				user := &unstructured.Unstructured{}
+				user.SetGroupVersionKind(v1beta1.GroupVersion.WithKind(kind))
+				user.SetName("user-" + randStringRunes(5))
+				user.SetNamespace(namespace.Name)
+				Expect(unstructured.SetNestedField(user.Object, "tomorrow", "spec", "validUntil")).To(Succeed())
+				Expect(unstructured.SetNestedField(user.Object, "database", "spec", "database", "name")).To(Succeed())
+				Expect(unstructured.SetNestedField(user.Object, "credentials", "spec", "credentials", "name")).To(Succeed())
+
+				expectInvalid(k8sClient.Create(context.Background(), client.Object(user)), "spec.validUntil")
+			}
+		})
+	})
+
+	Describe("MongoDBDatabase", func() {
+		It("rejects an address together with an Atlas project", func() {
+			expectInvalid(k8sClient.Create(context.Background(), newMongoDBDatabase(v1beta1.MongoDBDatabaseSpec{
+				DatabaseSpec: &v1beta1.DatabaseSpec{Address: "mongodb://mongodb:27017"},
+				AtlasGroupId: "group",
+			})), "address and atlasGroupId are mutually exclusive")
+		})
+
+		It("rejects connection settings for an Atlas project", func() {
+			for _, spec := range []v1beta1.MongoDBDatabaseSpec{
+				{AtlasGroupId: "group", AuthSource: "admin"},
+				{AtlasGroupId: "group", AuthMechanism: v1beta1.MongoDBAuthMechanismX509},
+				{AtlasGroupId: "group", TLSSecret: &v1beta1.TLSSecretReference{Name: "tls"}},
+			} {
+				expectInvalid(k8sClient.Create(context.Background(), newMongoDBDatabase(spec)), "atlasGroupId requires an Atlas API key pair")
+			}
+		})
+
+		It("rejects Atlas only settings without an Atlas project", func() {
+			expectInvalid(k8sClient.Create(context.Background(), newMongoDBDatabase(v1beta1.MongoDBDatabaseSpec{
+				AtlasBaseURL: "https://cloud.mongodb.com",
+			})), "atlasBaseURL requires atlasGroupId")
+
+			expectInvalid(k8sClient.Create(context.Background(), newMongoDBDatabase(v1beta1.MongoDBDatabaseSpec{
+				AccessList: []v1beta1.AtlasAccessListEntry{{IPAddress: "10.0.0.1"}},
+			})), "accessList requires atlasGroupId")
+		})
+
+		It("rejects access list entries with both or none of cidrBlock and ipAddress", func() {
+			expectInvalid(k8sClient.Create(context.Background(), newMongoDBDatabase(v1beta1.MongoDBDatabaseSpec{
+				AtlasGroupId: "group",
+				AccessList: []v1beta1.AtlasAccessListEntry{
+					{CIDRBlock: "10.0.0.0/24", IPAddress: "10.0.0.1"},
+					{Comment: "office"},
+				},
+			})), "either cidrBlock or ipAddress must be set")
+		})
+
+		It("rejects seeds for an Atlas project", func() {
+			expectInvalid(k8sClient.Create(context.Background(), newMongoDBDatabase(v1beta1.MongoDBDatabaseSpec{
+				AtlasGroupId: "group",
+				Seeds: []v1beta1.MongoDBSeed{
+					{Name: "users", ConfigMap: &v1beta1.ConfigMapReference{Name: "users"}, Type: v1beta1.MongoDBSeedCommands},
+				},
+			})), "seeds are not supported for MongoDB Atlas")
+		})
+
+		It("rejects a documents seed without collection", func() {
+			expectInvalid(k8sClient.Create(context.Background(), newMongoDBDatabase(v1beta1.MongoDBDatabaseSpec{
+				Seeds: []v1beta1.MongoDBSeed{
+					{Name: "users", ConfigMap: &v1beta1.ConfigMapReference{Name: "users"}, Type: v1beta1.MongoDBSeedDocuments},
+				},
+			})), "collection is required if the type is Documents")
+		})
+
+		It("accepts an Atlas project with an access list", func() {
+			Expect(k8sClient.Create(context.Background(), newMongoDBDatabase(v1beta1.MongoDBDatabaseSpec{
+				AtlasGroupId: "group",
+				AccessList: []v1beta1.AtlasAccessListEntry{
+					{CIDRBlock: "10.0.0.0/24"},
+					{IPAddress: "10.0.1.1"},
+				},
+			}))).Should(Succeed())
+		})
+	})
+})
@@ -22,105 +22,1591 @@ package v1beta1
 
 import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
-	runtime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AtlasAccessListEntry) DeepCopyInto(out *AtlasAccessListEntry) {
+	*out = *in
+	if in.ExpiresAt != nil {
+		in, out := &in.ExpiresAt, &out.ExpiresAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AtlasAccessListEntry.
+func (in *AtlasAccessListEntry) DeepCopy() *AtlasAccessListEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(AtlasAccessListEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClickHouseDatabase) DeepCopyInto(out *ClickHouseDatabase) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClickHouseDatabase.
+func (in *ClickHouseDatabase) DeepCopy() *ClickHouseDatabase {
+	if in == nil {
+		return nil
+	}
+	out := new(ClickHouseDatabase)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClickHouseDatabase) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClickHouseDatabaseList) DeepCopyInto(out *ClickHouseDatabaseList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClickHouseDatabase, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClickHouseDatabaseList.
+func (in *ClickHouseDatabaseList) DeepCopy() *ClickHouseDatabaseList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClickHouseDatabaseList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClickHouseDatabaseList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClickHouseDatabaseSpec) DeepCopyInto(out *ClickHouseDatabaseSpec) {
+	*out = *in
+	if in.DatabaseSpec != nil {
+		in, out := &in.DatabaseSpec, &out.DatabaseSpec
+		*out = new(DatabaseSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EngineArguments != nil {
+		in, out := &in.EngineArguments, &out.EngineArguments
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClickHouseDatabaseSpec.
+func (in *ClickHouseDatabaseSpec) DeepCopy() *ClickHouseDatabaseSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClickHouseDatabaseSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClickHouseDatabaseStatus) DeepCopyInto(out *ClickHouseDatabaseStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClickHouseDatabaseStatus.
+func (in *ClickHouseDatabaseStatus) DeepCopy() *ClickHouseDatabaseStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ClickHouseDatabaseStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClickHouseGrant) DeepCopyInto(out *ClickHouseGrant) {
+	*out = *in
+	if in.Privileges != nil {
+		in, out := &in.Privileges, &out.Privileges
+		*out = make([]Privilege, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClickHouseGrant.
+func (in *ClickHouseGrant) DeepCopy() *ClickHouseGrant {
+	if in == nil {
+		return nil
+	}
+	out := new(ClickHouseGrant)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClickHouseHost) DeepCopyInto(out *ClickHouseHost) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClickHouseHost.
+func (in *ClickHouseHost) DeepCopy() *ClickHouseHost {
+	if in == nil {
+		return nil
+	}
+	out := new(ClickHouseHost)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClickHouseQuota) DeepCopyInto(out *ClickHouseQuota) {
+	*out = *in
+	out.Interval = in.Interval
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClickHouseQuota.
+func (in *ClickHouseQuota) DeepCopy() *ClickHouseQuota {
+	if in == nil {
+		return nil
+	}
+	out := new(ClickHouseQuota)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClickHouseSetting) DeepCopyInto(out *ClickHouseSetting) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClickHouseSetting.
+func (in *ClickHouseSetting) DeepCopy() *ClickHouseSetting {
+	if in == nil {
+		return nil
+	}
+	out := new(ClickHouseSetting)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClickHouseUser) DeepCopyInto(out *ClickHouseUser) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClickHouseUser.
+func (in *ClickHouseUser) DeepCopy() *ClickHouseUser {
+	if in == nil {
+		return nil
+	}
+	out := new(ClickHouseUser)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClickHouseUser) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClickHouseUserList) DeepCopyInto(out *ClickHouseUserList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClickHouseUser, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClickHouseUserList.
+func (in *ClickHouseUserList) DeepCopy() *ClickHouseUserList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClickHouseUserList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClickHouseUserList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClickHouseUserSpec) DeepCopyInto(out *ClickHouseUserSpec) {
+	*out = *in
+	if in.Database != nil {
+		in, out := &in.Database, &out.Database
+		*out = new(DatabaseReference)
+		**out = **in
+	}
+	if in.Credentials != nil {
+		in, out := &in.Credentials, &out.Credentials
+		*out = new(SecretReference)
+		**out = **in
+	}
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]ClickHouseHost, len(*in))
+		copy(*out, *in)
+	}
+	if in.Grants != nil {
+		in, out := &in.Grants, &out.Grants
+		*out = make([]ClickHouseGrant, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Settings != nil {
+		in, out := &in.Settings, &out.Settings
+		*out = make([]ClickHouseSetting, len(*in))
+		copy(*out, *in)
+	}
+	if in.Quotas != nil {
+		in, out := &in.Quotas, &out.Quotas
+		*out = make([]ClickHouseQuota, len(*in))
+		copy(*out, *in)
+	}
+	if in.ValidUntil != nil {
+		in, out := &in.ValidUntil, &out.ValidUntil
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClickHouseUserSpec.
+func (in *ClickHouseUserSpec) DeepCopy() *ClickHouseUserSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClickHouseUserSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClickHouseUserStatus) DeepCopyInto(out *ClickHouseUserStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClickHouseUserStatus.
+func (in *ClickHouseUserStatus) DeepCopy() *ClickHouseUserStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ClickHouseUserStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigMapReference) DeepCopyInto(out *ConfigMapReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigMapReference.
+func (in *ConfigMapReference) DeepCopy() *ConfigMapReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigMapReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DatabaseReference) DeepCopyInto(out *DatabaseReference) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseReference.
-func (in *DatabaseReference) DeepCopy() *DatabaseReference {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseReference.
+func (in *DatabaseReference) DeepCopy() *DatabaseReference {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseSpec) DeepCopyInto(out *DatabaseSpec) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.RootSecret != nil {
+		in, out := &in.RootSecret, &out.RootSecret
+		*out = new(SecretReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseSpec.
+func (in *DatabaseSpec) DeepCopy() *DatabaseSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Extension) DeepCopyInto(out *Extension) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Extension.
+func (in *Extension) DeepCopy() *Extension {
+	if in == nil {
+		return nil
+	}
+	out := new(Extension)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in Extensions) DeepCopyInto(out *Extensions) {
+	{
+		in := &in
+		*out = make(Extensions, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Extensions.
+func (in Extensions) DeepCopy() Extensions {
+	if in == nil {
+		return nil
+	}
+	out := new(Extensions)
+	in.DeepCopyInto(out)
+	return *out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Grant) DeepCopyInto(out *Grant) {
+	*out = *in
+	if in.Privileges != nil {
+		in, out := &in.Privileges, &out.Privileges
+		*out = make([]Privilege, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Grant.
+func (in *Grant) DeepCopy() *Grant {
+	if in == nil {
+		return nil
+	}
+	out := new(Grant)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MSSQLDatabase) DeepCopyInto(out *MSSQLDatabase) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MSSQLDatabase.
+func (in *MSSQLDatabase) DeepCopy() *MSSQLDatabase {
+	if in == nil {
+		return nil
+	}
+	out := new(MSSQLDatabase)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MSSQLDatabase) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MSSQLDatabaseList) DeepCopyInto(out *MSSQLDatabaseList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MSSQLDatabase, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MSSQLDatabaseList.
+func (in *MSSQLDatabaseList) DeepCopy() *MSSQLDatabaseList {
+	if in == nil {
+		return nil
+	}
+	out := new(MSSQLDatabaseList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MSSQLDatabaseList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MSSQLDatabaseSpec) DeepCopyInto(out *MSSQLDatabaseSpec) {
+	*out = *in
+	if in.DatabaseSpec != nil {
+		in, out := &in.DatabaseSpec, &out.DatabaseSpec
+		*out = new(DatabaseSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MSSQLDatabaseSpec.
+func (in *MSSQLDatabaseSpec) DeepCopy() *MSSQLDatabaseSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MSSQLDatabaseSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MSSQLDatabaseStatus) DeepCopyInto(out *MSSQLDatabaseStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MSSQLDatabaseStatus.
+func (in *MSSQLDatabaseStatus) DeepCopy() *MSSQLDatabaseStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MSSQLDatabaseStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MSSQLUser) DeepCopyInto(out *MSSQLUser) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MSSQLUser.
+func (in *MSSQLUser) DeepCopy() *MSSQLUser {
+	if in == nil {
+		return nil
+	}
+	out := new(MSSQLUser)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MSSQLUser) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MSSQLUserList) DeepCopyInto(out *MSSQLUserList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MSSQLUser, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MSSQLUserList.
+func (in *MSSQLUserList) DeepCopy() *MSSQLUserList {
+	if in == nil {
+		return nil
+	}
+	out := new(MSSQLUserList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MSSQLUserList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MSSQLUserSpec) DeepCopyInto(out *MSSQLUserSpec) {
+	*out = *in
+	if in.Database != nil {
+		in, out := &in.Database, &out.Database
+		*out = new(DatabaseReference)
+		**out = **in
+	}
+	if in.Credentials != nil {
+		in, out := &in.Credentials, &out.Credentials
+		*out = new(SecretReference)
+		**out = **in
+	}
+	if in.Roles != nil {
+		in, out := &in.Roles, &out.Roles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ValidUntil != nil {
+		in, out := &in.ValidUntil, &out.ValidUntil
+		*out = (*in).DeepCopy()
+	}
+	if in.TerminateSessions != nil {
+		in, out := &in.TerminateSessions, &out.TerminateSessions
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MSSQLUserSpec.
+func (in *MSSQLUserSpec) DeepCopy() *MSSQLUserSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MSSQLUserSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MSSQLUserStatus) DeepCopyInto(out *MSSQLUserStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MSSQLUserStatus.
+func (in *MSSQLUserStatus) DeepCopy() *MSSQLUserStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MSSQLUserStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBAtlasLabel) DeepCopyInto(out *MongoDBAtlasLabel) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBAtlasLabel.
+func (in *MongoDBAtlasLabel) DeepCopy() *MongoDBAtlasLabel {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBAtlasLabel)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBAtlasScope) DeepCopyInto(out *MongoDBAtlasScope) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBAtlasScope.
+func (in *MongoDBAtlasScope) DeepCopy() *MongoDBAtlasScope {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBAtlasScope)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBAtlasUserSpec) DeepCopyInto(out *MongoDBAtlasUserSpec) {
+	*out = *in
+	if in.Scopes != nil {
+		in, out := &in.Scopes, &out.Scopes
+		*out = make([]MongoDBAtlasScope, len(*in))
+		copy(*out, *in)
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make([]MongoDBAtlasLabel, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBAtlasUserSpec.
+func (in *MongoDBAtlasUserSpec) DeepCopy() *MongoDBAtlasUserSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBAtlasUserSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBAuthenticationRestriction) DeepCopyInto(out *MongoDBAuthenticationRestriction) {
+	*out = *in
+	if in.ClientSource != nil {
+		in, out := &in.ClientSource, &out.ClientSource
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ServerAddress != nil {
+		in, out := &in.ServerAddress, &out.ServerAddress
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBAuthenticationRestriction.
+func (in *MongoDBAuthenticationRestriction) DeepCopy() *MongoDBAuthenticationRestriction {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBAuthenticationRestriction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBDatabase) DeepCopyInto(out *MongoDBDatabase) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBDatabase.
+func (in *MongoDBDatabase) DeepCopy() *MongoDBDatabase {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBDatabase)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MongoDBDatabase) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBDatabaseList) DeepCopyInto(out *MongoDBDatabaseList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MongoDBDatabase, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBDatabaseList.
+func (in *MongoDBDatabaseList) DeepCopy() *MongoDBDatabaseList {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBDatabaseList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MongoDBDatabaseList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBDatabaseSpec) DeepCopyInto(out *MongoDBDatabaseSpec) {
+	*out = *in
+	if in.DatabaseSpec != nil {
+		in, out := &in.DatabaseSpec, &out.DatabaseSpec
+		*out = new(DatabaseSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TLSSecret != nil {
+		in, out := &in.TLSSecret, &out.TLSSecret
+		*out = new(TLSSecretReference)
+		**out = **in
+	}
+	if in.Seeds != nil {
+		in, out := &in.Seeds, &out.Seeds
+		*out = make([]MongoDBSeed, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AccessList != nil {
+		in, out := &in.AccessList, &out.AccessList
+		*out = make([]AtlasAccessListEntry, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBDatabaseSpec.
+func (in *MongoDBDatabaseSpec) DeepCopy() *MongoDBDatabaseSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBDatabaseSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBDatabaseStatus) DeepCopyInto(out *MongoDBDatabaseStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AccessList != nil {
+		in, out := &in.AccessList, &out.AccessList
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBDatabaseStatus.
+func (in *MongoDBDatabaseStatus) DeepCopy() *MongoDBDatabaseStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBDatabaseStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBSeed) DeepCopyInto(out *MongoDBSeed) {
+	*out = *in
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(ConfigMapReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBSeed.
+func (in *MongoDBSeed) DeepCopy() *MongoDBSeed {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBSeed)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBUser) DeepCopyInto(out *MongoDBUser) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBUser.
+func (in *MongoDBUser) DeepCopy() *MongoDBUser {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBUser)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MongoDBUser) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBUserList) DeepCopyInto(out *MongoDBUserList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MongoDBUser, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBUserList.
+func (in *MongoDBUserList) DeepCopy() *MongoDBUserList {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBUserList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MongoDBUserList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBUserRole) DeepCopyInto(out *MongoDBUserRole) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBUserRole.
+func (in *MongoDBUserRole) DeepCopy() *MongoDBUserRole {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBUserRole)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBUserSpec) DeepCopyInto(out *MongoDBUserSpec) {
+	*out = *in
+	if in.Database != nil {
+		in, out := &in.Database, &out.Database
+		*out = new(DatabaseReference)
+		**out = **in
+	}
+	if in.Credentials != nil {
+		in, out := &in.Credentials, &out.Credentials
+		*out = new(SecretReference)
+		**out = **in
+	}
+	if in.Roles != nil {
+		in, out := &in.Roles, &out.Roles
+		*out = new([]MongoDBUserRole)
+		if **in != nil {
+			in, out := *in, *out
+			*out = make([]MongoDBUserRole, len(*in))
+			copy(*out, *in)
+		}
+	}
+	if in.Mechanisms != nil {
+		in, out := &in.Mechanisms, &out.Mechanisms
+		*out = make([]MongoDBUserMechanism, len(*in))
+		copy(*out, *in)
+	}
+	if in.AuthenticationRestrictions != nil {
+		in, out := &in.AuthenticationRestrictions, &out.AuthenticationRestrictions
+		*out = make([]MongoDBAuthenticationRestriction, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CustomData != nil {
+		in, out := &in.CustomData, &out.CustomData
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Atlas != nil {
+		in, out := &in.Atlas, &out.Atlas
+		*out = new(MongoDBAtlasUserSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ValidUntil != nil {
+		in, out := &in.ValidUntil, &out.ValidUntil
+		*out = (*in).DeepCopy()
+	}
+	if in.TerminateSessions != nil {
+		in, out := &in.TerminateSessions, &out.TerminateSessions
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBUserSpec.
+func (in *MongoDBUserSpec) DeepCopy() *MongoDBUserSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBUserSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBUserStatus) DeepCopyInto(out *MongoDBUserStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBUserStatus.
+func (in *MongoDBUserStatus) DeepCopy() *MongoDBUserStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBUserStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MySQLDatabase) DeepCopyInto(out *MySQLDatabase) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MySQLDatabase.
+func (in *MySQLDatabase) DeepCopy() *MySQLDatabase {
+	if in == nil {
+		return nil
+	}
+	out := new(MySQLDatabase)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MySQLDatabase) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MySQLDatabaseList) DeepCopyInto(out *MySQLDatabaseList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MySQLDatabase, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MySQLDatabaseList.
+func (in *MySQLDatabaseList) DeepCopy() *MySQLDatabaseList {
+	if in == nil {
+		return nil
+	}
+	out := new(MySQLDatabaseList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MySQLDatabaseList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MySQLDatabaseSpec) DeepCopyInto(out *MySQLDatabaseSpec) {
+	*out = *in
+	if in.DatabaseSpec != nil {
+		in, out := &in.DatabaseSpec, &out.DatabaseSpec
+		*out = new(DatabaseSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MySQLDatabaseSpec.
+func (in *MySQLDatabaseSpec) DeepCopy() *MySQLDatabaseSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MySQLDatabaseSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MySQLDatabaseStatus) DeepCopyInto(out *MySQLDatabaseStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MySQLDatabaseStatus.
+func (in *MySQLDatabaseStatus) DeepCopy() *MySQLDatabaseStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MySQLDatabaseStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MySQLGrant) DeepCopyInto(out *MySQLGrant) {
+	*out = *in
+	if in.Privileges != nil {
+		in, out := &in.Privileges, &out.Privileges
+		*out = make([]Privilege, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MySQLGrant.
+func (in *MySQLGrant) DeepCopy() *MySQLGrant {
+	if in == nil {
+		return nil
+	}
+	out := new(MySQLGrant)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MySQLResourceLimits) DeepCopyInto(out *MySQLResourceLimits) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MySQLResourceLimits.
+func (in *MySQLResourceLimits) DeepCopy() *MySQLResourceLimits {
+	if in == nil {
+		return nil
+	}
+	out := new(MySQLResourceLimits)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MySQLUser) DeepCopyInto(out *MySQLUser) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MySQLUser.
+func (in *MySQLUser) DeepCopy() *MySQLUser {
+	if in == nil {
+		return nil
+	}
+	out := new(MySQLUser)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MySQLUser) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MySQLUserList) DeepCopyInto(out *MySQLUserList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MySQLUser, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MySQLUserList.
+func (in *MySQLUserList) DeepCopy() *MySQLUserList {
+	if in == nil {
+		return nil
+	}
+	out := new(MySQLUserList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MySQLUserList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MySQLUserSpec) DeepCopyInto(out *MySQLUserSpec) {
+	*out = *in
+	if in.Database != nil {
+		in, out := &in.Database, &out.Database
+		*out = new(DatabaseReference)
+		**out = **in
+	}
+	if in.Credentials != nil {
+		in, out := &in.Credentials, &out.Credentials
+		*out = new(SecretReference)
+		**out = **in
+	}
+	if in.Grants != nil {
+		in, out := &in.Grants, &out.Grants
+		*out = make([]MySQLGrant, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ResourceLimits != nil {
+		in, out := &in.ResourceLimits, &out.ResourceLimits
+		*out = new(MySQLResourceLimits)
+		**out = **in
+	}
+	if in.ValidUntil != nil {
+		in, out := &in.ValidUntil, &out.ValidUntil
+		*out = (*in).DeepCopy()
+	}
+	if in.TerminateSessions != nil {
+		in, out := &in.TerminateSessions, &out.TerminateSessions
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MySQLUserSpec.
+func (in *MySQLUserSpec) DeepCopy() *MySQLUserSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MySQLUserSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MySQLUserStatus) DeepCopyInto(out *MySQLUserStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MySQLUserStatus.
+func (in *MySQLUserStatus) DeepCopy() *MySQLUserStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MySQLUserStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostgreSQLDatabase) DeepCopyInto(out *PostgreSQLDatabase) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgreSQLDatabase.
+func (in *PostgreSQLDatabase) DeepCopy() *PostgreSQLDatabase {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgreSQLDatabase)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PostgreSQLDatabase) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostgreSQLDatabaseList) DeepCopyInto(out *PostgreSQLDatabaseList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PostgreSQLDatabase, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgreSQLDatabaseList.
+func (in *PostgreSQLDatabaseList) DeepCopy() *PostgreSQLDatabaseList {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgreSQLDatabaseList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PostgreSQLDatabaseList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostgreSQLDatabaseSpec) DeepCopyInto(out *PostgreSQLDatabaseSpec) {
+	*out = *in
+	if in.DatabaseSpec != nil {
+		in, out := &in.DatabaseSpec, &out.DatabaseSpec
+		*out = new(DatabaseSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Extensions != nil {
+		in, out := &in.Extensions, &out.Extensions
+		*out = make(Extensions, len(*in))
+		copy(*out, *in)
+	}
+	if in.SearchPath != nil {
+		in, out := &in.SearchPath, &out.SearchPath
+		*out = make(Schemas, len(*in))
+		copy(*out, *in)
+	}
+	if in.Schemas != nil {
+		in, out := &in.Schemas, &out.Schemas
+		*out = make(Schemas, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgreSQLDatabaseSpec.
+func (in *PostgreSQLDatabaseSpec) DeepCopy() *PostgreSQLDatabaseSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgreSQLDatabaseSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostgreSQLDatabaseStatus) DeepCopyInto(out *PostgreSQLDatabaseStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgreSQLDatabaseStatus.
+func (in *PostgreSQLDatabaseStatus) DeepCopy() *PostgreSQLDatabaseStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgreSQLDatabaseStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostgreSQLUser) DeepCopyInto(out *PostgreSQLUser) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgreSQLUser.
+func (in *PostgreSQLUser) DeepCopy() *PostgreSQLUser {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgreSQLUser)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PostgreSQLUser) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostgreSQLUserList) DeepCopyInto(out *PostgreSQLUserList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PostgreSQLUser, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgreSQLUserList.
+func (in *PostgreSQLUserList) DeepCopy() *PostgreSQLUserList {
 	if in == nil {
 		return nil
 	}
-	out := new(DatabaseReference)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *DatabaseSpec) DeepCopyInto(out *DatabaseSpec) {
-	*out = *in
-	if in.Timeout != nil {
-		in, out := &in.Timeout, &out.Timeout
-		*out = new(v1.Duration)
-		**out = **in
+	out := new(PostgreSQLUserList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PostgreSQLUserList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostgreSQLUserSpec) DeepCopyInto(out *PostgreSQLUserSpec) {
+	*out = *in
+	if in.Database != nil {
+		in, out := &in.Database, &out.Database
+		*out = new(DatabaseReference)
+		**out = **in
+	}
+	if in.Credentials != nil {
+		in, out := &in.Credentials, &out.Credentials
+		*out = new(SecretReference)
+		**out = **in
+	}
+	if in.Grants != nil {
+		in, out := &in.Grants, &out.Grants
+		*out = make([]Grant, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Roles != nil {
+		in, out := &in.Roles, &out.Roles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Attributes != nil {
+		in, out := &in.Attributes, &out.Attributes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ValidUntil != nil {
+		in, out := &in.ValidUntil, &out.ValidUntil
+		*out = (*in).DeepCopy()
 	}
-	if in.RootSecret != nil {
-		in, out := &in.RootSecret, &out.RootSecret
-		*out = new(SecretReference)
+	if in.TerminateSessions != nil {
+		in, out := &in.TerminateSessions, &out.TerminateSessions
+		*out = new(bool)
 		**out = **in
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseSpec.
-func (in *DatabaseSpec) DeepCopy() *DatabaseSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgreSQLUserSpec.
+func (in *PostgreSQLUserSpec) DeepCopy() *PostgreSQLUserSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(DatabaseSpec)
+	out := new(PostgreSQLUserSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Extension) DeepCopyInto(out *Extension) {
+func (in *PostgreSQLUserStatus) DeepCopyInto(out *PostgreSQLUserStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Extension.
-func (in *Extension) DeepCopy() *Extension {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgreSQLUserStatus.
+func (in *PostgreSQLUserStatus) DeepCopy() *PostgreSQLUserStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(Extension)
+	out := new(PostgreSQLUserStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in Extensions) DeepCopyInto(out *Extensions) {
-	{
-		in := &in
-		*out = make(Extensions, len(*in))
-		copy(*out, *in)
-	}
+func (in *RabbitMQPermissions) DeepCopyInto(out *RabbitMQPermissions) {
+	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Extensions.
-func (in Extensions) DeepCopy() Extensions {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitMQPermissions.
+func (in *RabbitMQPermissions) DeepCopy() *RabbitMQPermissions {
 	if in == nil {
 		return nil
 	}
-	out := new(Extensions)
+	out := new(RabbitMQPermissions)
 	in.DeepCopyInto(out)
-	return *out
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Grant) DeepCopyInto(out *Grant) {
+func (in *RabbitMQTopicPermission) DeepCopyInto(out *RabbitMQTopicPermission) {
 	*out = *in
-	if in.Privileges != nil {
-		in, out := &in.Privileges, &out.Privileges
-		*out = make([]Privilege, len(*in))
-		copy(*out, *in)
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Grant.
-func (in *Grant) DeepCopy() *Grant {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitMQTopicPermission.
+func (in *RabbitMQTopicPermission) DeepCopy() *RabbitMQTopicPermission {
 	if in == nil {
 		return nil
 	}
-	out := new(Grant)
+	out := new(RabbitMQTopicPermission)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MongoDBDatabase) DeepCopyInto(out *MongoDBDatabase) {
+func (in *RabbitMQUser) DeepCopyInto(out *RabbitMQUser) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
@@ -128,18 +1614,18 @@ func (in *MongoDBDatabase) DeepCopyInto(out *MongoDBDatabase) {
 	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBDatabase.
-func (in *MongoDBDatabase) DeepCopy() *MongoDBDatabase {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitMQUser.
+func (in *RabbitMQUser) DeepCopy() *RabbitMQUser {
 	if in == nil {
 		return nil
 	}
-	out := new(MongoDBDatabase)
+	out := new(RabbitMQUser)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *MongoDBDatabase) DeepCopyObject() runtime.Object {
+func (in *RabbitMQUser) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -147,31 +1633,31 @@ func (in *MongoDBDatabase) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MongoDBDatabaseList) DeepCopyInto(out *MongoDBDatabaseList) {
+func (in *RabbitMQUserList) DeepCopyInto(out *RabbitMQUserList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]MongoDBDatabase, len(*in))
+		*out = make([]RabbitMQUser, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBDatabaseList.
-func (in *MongoDBDatabaseList) DeepCopy() *MongoDBDatabaseList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitMQUserList.
+func (in *RabbitMQUserList) DeepCopy() *RabbitMQUserList {
 	if in == nil {
 		return nil
 	}
-	out := new(MongoDBDatabaseList)
+	out := new(RabbitMQUserList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *MongoDBDatabaseList) DeepCopyObject() runtime.Object {
+func (in *RabbitMQUserList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -179,27 +1665,56 @@ func (in *MongoDBDatabaseList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MongoDBDatabaseSpec) DeepCopyInto(out *MongoDBDatabaseSpec) {
+func (in *RabbitMQUserSpec) DeepCopyInto(out *RabbitMQUserSpec) {
 	*out = *in
-	if in.DatabaseSpec != nil {
-		in, out := &in.DatabaseSpec, &out.DatabaseSpec
-		*out = new(DatabaseSpec)
-		(*in).DeepCopyInto(*out)
+	if in.Vhost != nil {
+		in, out := &in.Vhost, &out.Vhost
+		*out = new(DatabaseReference)
+		**out = **in
+	}
+	if in.Credentials != nil {
+		in, out := &in.Credentials, &out.Credentials
+		*out = new(SecretReference)
+		**out = **in
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Permissions != nil {
+		in, out := &in.Permissions, &out.Permissions
+		*out = new(RabbitMQPermissions)
+		**out = **in
+	}
+	if in.TopicPermissions != nil {
+		in, out := &in.TopicPermissions, &out.TopicPermissions
+		*out = make([]RabbitMQTopicPermission, len(*in))
+		copy(*out, *in)
+	}
+	if in.ValidUntil != nil {
+		in, out := &in.ValidUntil, &out.ValidUntil
+		*out = (*in).DeepCopy()
+	}
+	if in.TerminateSessions != nil {
+		in, out := &in.TerminateSessions, &out.TerminateSessions
+		*out = new(bool)
+		**out = **in
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBDatabaseSpec.
-func (in *MongoDBDatabaseSpec) DeepCopy() *MongoDBDatabaseSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitMQUserSpec.
+func (in *RabbitMQUserSpec) DeepCopy() *RabbitMQUserSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(MongoDBDatabaseSpec)
+	out := new(RabbitMQUserSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MongoDBDatabaseStatus) DeepCopyInto(out *MongoDBDatabaseStatus) {
+func (in *RabbitMQUserStatus) DeepCopyInto(out *RabbitMQUserStatus) {
 	*out = *in
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
@@ -210,18 +1725,18 @@ func (in *MongoDBDatabaseStatus) DeepCopyInto(out *MongoDBDatabaseStatus) {
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBDatabaseStatus.
-func (in *MongoDBDatabaseStatus) DeepCopy() *MongoDBDatabaseStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitMQUserStatus.
+func (in *RabbitMQUserStatus) DeepCopy() *RabbitMQUserStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(MongoDBDatabaseStatus)
+	out := new(RabbitMQUserStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MongoDBUser) DeepCopyInto(out *MongoDBUser) {
+func (in *RabbitMQVhost) DeepCopyInto(out *RabbitMQVhost) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
@@ -229,18 +1744,18 @@ func (in *MongoDBUser) DeepCopyInto(out *MongoDBUser) {
 	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBUser.
-func (in *MongoDBUser) DeepCopy() *MongoDBUser {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitMQVhost.
+func (in *RabbitMQVhost) DeepCopy() *RabbitMQVhost {
 	if in == nil {
 		return nil
 	}
-	out := new(MongoDBUser)
+	out := new(RabbitMQVhost)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *MongoDBUser) DeepCopyObject() runtime.Object {
+func (in *RabbitMQVhost) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -248,31 +1763,31 @@ func (in *MongoDBUser) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MongoDBUserList) DeepCopyInto(out *MongoDBUserList) {
+func (in *RabbitMQVhostList) DeepCopyInto(out *RabbitMQVhostList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]MongoDBUser, len(*in))
+		*out = make([]RabbitMQVhost, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBUserList.
-func (in *MongoDBUserList) DeepCopy() *MongoDBUserList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitMQVhostList.
+func (in *RabbitMQVhostList) DeepCopy() *RabbitMQVhostList {
 	if in == nil {
 		return nil
 	}
-	out := new(MongoDBUserList)
+	out := new(RabbitMQVhostList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *MongoDBUserList) DeepCopyObject() runtime.Object {
+func (in *RabbitMQVhostList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -280,60 +1795,32 @@ func (in *MongoDBUserList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MongoDBUserRole) DeepCopyInto(out *MongoDBUserRole) {
-	*out = *in
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBUserRole.
-func (in *MongoDBUserRole) DeepCopy() *MongoDBUserRole {
-	if in == nil {
-		return nil
-	}
-	out := new(MongoDBUserRole)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MongoDBUserSpec) DeepCopyInto(out *MongoDBUserSpec) {
+func (in *RabbitMQVhostSpec) DeepCopyInto(out *RabbitMQVhostSpec) {
 	*out = *in
-	if in.Database != nil {
-		in, out := &in.Database, &out.Database
-		*out = new(DatabaseReference)
-		**out = **in
-	}
-	if in.Credentials != nil {
-		in, out := &in.Credentials, &out.Credentials
-		*out = new(SecretReference)
-		**out = **in
-	}
-	if in.Roles != nil {
-		in, out := &in.Roles, &out.Roles
-		*out = new([]MongoDBUserRole)
-		if **in != nil {
-			in, out := *in, *out
-			*out = make([]MongoDBUserRole, len(*in))
-			copy(*out, *in)
-		}
+	if in.DatabaseSpec != nil {
+		in, out := &in.DatabaseSpec, &out.DatabaseSpec
+		*out = new(DatabaseSpec)
+		(*in).DeepCopyInto(*out)
 	}
-	if in.ValidUntil != nil {
-		in, out := &in.ValidUntil, &out.ValidUntil
-		*out = (*in).DeepCopy()
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBUserSpec.
-func (in *MongoDBUserSpec) DeepCopy() *MongoDBUserSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitMQVhostSpec.
+func (in *RabbitMQVhostSpec) DeepCopy() *RabbitMQVhostSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(MongoDBUserSpec)
+	out := new(RabbitMQVhostSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MongoDBUserStatus) DeepCopyInto(out *MongoDBUserStatus) {
+func (in *RabbitMQVhostStatus) DeepCopyInto(out *RabbitMQVhostStatus) {
 	*out = *in
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
@@ -344,18 +1831,18 @@ func (in *MongoDBUserStatus) DeepCopyInto(out *MongoDBUserStatus) {
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBUserStatus.
-func (in *MongoDBUserStatus) DeepCopy() *MongoDBUserStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitMQVhostStatus.
+func (in *RabbitMQVhostStatus) DeepCopy() *RabbitMQVhostStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(MongoDBUserStatus)
+	out := new(RabbitMQVhostStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PostgreSQLDatabase) DeepCopyInto(out *PostgreSQLDatabase) {
+func (in *RedisServer) DeepCopyInto(out *RedisServer) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
@@ -363,18 +1850,18 @@ func (in *PostgreSQLDatabase) DeepCopyInto(out *PostgreSQLDatabase) {
 	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgreSQLDatabase.
-func (in *PostgreSQLDatabase) DeepCopy() *PostgreSQLDatabase {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedisServer.
+func (in *RedisServer) DeepCopy() *RedisServer {
 	if in == nil {
 		return nil
 	}
-	out := new(PostgreSQLDatabase)
+	out := new(RedisServer)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *PostgreSQLDatabase) DeepCopyObject() runtime.Object {
+func (in *RedisServer) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -382,31 +1869,31 @@ func (in *PostgreSQLDatabase) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PostgreSQLDatabaseList) DeepCopyInto(out *PostgreSQLDatabaseList) {
+func (in *RedisServerList) DeepCopyInto(out *RedisServerList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]PostgreSQLDatabase, len(*in))
+		*out = make([]RedisServer, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgreSQLDatabaseList.
-func (in *PostgreSQLDatabaseList) DeepCopy() *PostgreSQLDatabaseList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedisServerList.
+func (in *RedisServerList) DeepCopy() *RedisServerList {
 	if in == nil {
 		return nil
 	}
-	out := new(PostgreSQLDatabaseList)
+	out := new(RedisServerList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *PostgreSQLDatabaseList) DeepCopyObject() runtime.Object {
+func (in *RedisServerList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -414,42 +1901,32 @@ func (in *PostgreSQLDatabaseList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PostgreSQLDatabaseSpec) DeepCopyInto(out *PostgreSQLDatabaseSpec) {
+func (in *RedisServerSpec) DeepCopyInto(out *RedisServerSpec) {
 	*out = *in
-	if in.DatabaseSpec != nil {
-		in, out := &in.DatabaseSpec, &out.DatabaseSpec
-		*out = new(DatabaseSpec)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.Extensions != nil {
-		in, out := &in.Extensions, &out.Extensions
-		*out = make(Extensions, len(*in))
-		copy(*out, *in)
-	}
-	if in.SearchPath != nil {
-		in, out := &in.SearchPath, &out.SearchPath
-		*out = make(Schemas, len(*in))
-		copy(*out, *in)
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
 	}
-	if in.Schemas != nil {
-		in, out := &in.Schemas, &out.Schemas
-		*out = make(Schemas, len(*in))
-		copy(*out, *in)
+	if in.RootSecret != nil {
+		in, out := &in.RootSecret, &out.RootSecret
+		*out = new(SecretReference)
+		**out = **in
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgreSQLDatabaseSpec.
-func (in *PostgreSQLDatabaseSpec) DeepCopy() *PostgreSQLDatabaseSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedisServerSpec.
+func (in *RedisServerSpec) DeepCopy() *RedisServerSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(PostgreSQLDatabaseSpec)
+	out := new(RedisServerSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PostgreSQLDatabaseStatus) DeepCopyInto(out *PostgreSQLDatabaseStatus) {
+func (in *RedisServerStatus) DeepCopyInto(out *RedisServerStatus) {
 	*out = *in
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
@@ -460,18 +1937,18 @@ func (in *PostgreSQLDatabaseStatus) DeepCopyInto(out *PostgreSQLDatabaseStatus)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgreSQLDatabaseStatus.
-func (in *PostgreSQLDatabaseStatus) DeepCopy() *PostgreSQLDatabaseStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedisServerStatus.
+func (in *RedisServerStatus) DeepCopy() *RedisServerStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(PostgreSQLDatabaseStatus)
+	out := new(RedisServerStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PostgreSQLUser) DeepCopyInto(out *PostgreSQLUser) {
+func (in *RedisUser) DeepCopyInto(out *RedisUser) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
@@ -479,18 +1956,18 @@ func (in *PostgreSQLUser) DeepCopyInto(out *PostgreSQLUser) {
 	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgreSQLUser.
-func (in *PostgreSQLUser) DeepCopy() *PostgreSQLUser {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedisUser.
+func (in *RedisUser) DeepCopy() *RedisUser {
 	if in == nil {
 		return nil
 	}
-	out := new(PostgreSQLUser)
+	out := new(RedisUser)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *PostgreSQLUser) DeepCopyObject() runtime.Object {
+func (in *RedisUser) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -498,31 +1975,31 @@ func (in *PostgreSQLUser) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PostgreSQLUserList) DeepCopyInto(out *PostgreSQLUserList) {
+func (in *RedisUserList) DeepCopyInto(out *RedisUserList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]PostgreSQLUser, len(*in))
+		*out = make([]RedisUser, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgreSQLUserList.
-func (in *PostgreSQLUserList) DeepCopy() *PostgreSQLUserList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedisUserList.
+func (in *RedisUserList) DeepCopy() *RedisUserList {
 	if in == nil {
 		return nil
 	}
-	out := new(PostgreSQLUserList)
+	out := new(RedisUserList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *PostgreSQLUserList) DeepCopyObject() runtime.Object {
+func (in *RedisUserList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -530,10 +2007,10 @@ func (in *PostgreSQLUserList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PostgreSQLUserSpec) DeepCopyInto(out *PostgreSQLUserSpec) {
+func (in *RedisUserSpec) DeepCopyInto(out *RedisUserSpec) {
 	*out = *in
-	if in.Database != nil {
-		in, out := &in.Database, &out.Database
+	if in.Server != nil {
+		in, out := &in.Server, &out.Server
 		*out = new(DatabaseReference)
 		**out = **in
 	}
@@ -542,41 +2019,44 @@ func (in *PostgreSQLUserSpec) DeepCopyInto(out *PostgreSQLUserSpec) {
 		*out = new(SecretReference)
 		**out = **in
 	}
-	if in.Grants != nil {
-		in, out := &in.Grants, &out.Grants
-		*out = make([]Grant, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
-	if in.Roles != nil {
-		in, out := &in.Roles, &out.Roles
+	if in.KeyPatterns != nil {
+		in, out := &in.KeyPatterns, &out.KeyPatterns
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
-	if in.Attributes != nil {
-		in, out := &in.Attributes, &out.Attributes
+	if in.ChannelPatterns != nil {
+		in, out := &in.ChannelPatterns, &out.ChannelPatterns
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Categories != nil {
+		in, out := &in.Categories, &out.Categories
+		*out = make([]RedisCategory, len(*in))
+		copy(*out, *in)
+	}
 	if in.ValidUntil != nil {
 		in, out := &in.ValidUntil, &out.ValidUntil
 		*out = (*in).DeepCopy()
 	}
+	if in.TerminateSessions != nil {
+		in, out := &in.TerminateSessions, &out.TerminateSessions
+		*out = new(bool)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgreSQLUserSpec.
-func (in *PostgreSQLUserSpec) DeepCopy() *PostgreSQLUserSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedisUserSpec.
+func (in *RedisUserSpec) DeepCopy() *RedisUserSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(PostgreSQLUserSpec)
+	out := new(RedisUserSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PostgreSQLUserStatus) DeepCopyInto(out *PostgreSQLUserStatus) {
+func (in *RedisUserStatus) DeepCopyInto(out *RedisUserStatus) {
 	*out = *in
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
@@ -587,12 +2067,12 @@ func (in *PostgreSQLUserStatus) DeepCopyInto(out *PostgreSQLUserStatus) {
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgreSQLUserStatus.
-func (in *PostgreSQLUserStatus) DeepCopy() *PostgreSQLUserStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedisUserStatus.
+func (in *RedisUserStatus) DeepCopy() *RedisUserStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(PostgreSQLUserStatus)
+	out := new(RedisUserStatus)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -645,3 +2125,18 @@ func (in *SecretReference) DeepCopy() *SecretReference {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TLSSecretReference) DeepCopyInto(out *TLSSecretReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TLSSecretReference.
+func (in *TLSSecretReference) DeepCopy() *TLSSecretReference {
+	if in == nil {
+		return nil
+	}
+	out := new(TLSSecretReference)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,175 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package audit records every mutating statement or command the controller sends to a database server.
+// Entries are written to the sink configured using --audit-sink, no entries are recorded if none is set.
+package audit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/doodlescheduling/db-controller/internal/metrics"
+)
+
+// Trigger is the change which caused the reconciliation an entry was recorded in
+type Trigger string
+
+const (
+	// TriggerSpec is used if the spec of the object changed or the object is deleted
+	TriggerSpec Trigger = "Spec"
+	// TriggerSecret is used if a Secret referenced by the object changed
+	TriggerSecret Trigger = "Secret"
+	// TriggerResync is used for all other reconciliations like retries, the periodic interval
+	// or changes of a referenced database
+	TriggerResync Trigger = "Resync"
+)
+
+// Object identifies the Kubernetes object an entry was recorded for
+type Object struct {
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace"`
+	Name       string `json:"name"`
+	Generation int64  `json:"generation"`
+}
+
+// Source describes the object and the change which caused the reconciliation
+type Source struct {
+	Object  Object  `json:"object"`
+	Trigger Trigger `json:"trigger"`
+	// Secret is the namespace/name of the changed Secret if the trigger is Secret
+	Secret string `json:"secret,omitempty"`
+}
+
+// Entry is a single mutating statement or command
+type Entry struct {
+	Time time.Time `json:"time"`
+	Source
+	// Engine is the database engine like PostgreSQL, MongoDB or Atlas
+	Engine string `json:"engine"`
+	// Server is the address of the server without credentials
+	Server   string `json:"server"`
+	Database string `json:"database,omitempty"`
+	// Operation is the statement type or command name
+	Operation string `json:"operation"`
+	// Statement is the statement or command with passwords redacted
+	Statement string `json:"statement"`
+	Error     string `json:"error,omitempty"`
+}
+
+type sourceKey struct{}
+
+// WithSource returns a context which attributes all entries recorded using it to src
+func WithSource(ctx context.Context, src Source) context.Context {
+	return context.WithValue(ctx, sourceKey{}, src)
+}
+
+// SourceFrom returns the source stored in the context
+func SourceFrom(ctx context.Context) (Source, bool) {
+	src, ok := ctx.Value(sourceKey{}).(Source)
+	return src, ok
+}
+
+var (
+	mu   sync.RWMutex
+	sink Sink
+)
+
+// SetSink sets the sink entries are written to, nil disables the audit log
+func SetSink(s Sink) {
+	mu.Lock()
+	defer mu.Unlock()
+	sink = s
+}
+
+// Enabled reports whether a sink is configured
+func Enabled() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return sink != nil
+}
+
+// Record writes the entry to the configured sink.
+// The time, the source from the context and a sanitized server address are filled in.
+// Failures to write the entry are logged and counted by the audit_write_failures_total and
+// audit_entries_dropped_total metrics, they never fail the reconciliation.
+func Record(ctx context.Context, entry Entry, err error) {
+	mu.RLock()
+	s := sink
+	mu.RUnlock()
+
+	if s == nil {
+		return
+	}
+
+	entry.Time = time.Now().UTC()
+	entry.Server = metrics.ServerAddress(entry.Server)
+	if src, ok := SourceFrom(ctx); ok {
+		entry.Source = src
+	}
+
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	if err := s.Write(context.WithoutCancel(ctx), entry); err != nil {
+		metrics.AuditWriteFailuresTotal.Inc()
+		metrics.AuditEntriesDroppedTotal.Inc()
+		log.FromContext(ctx).Error(err, "failed to write audit entry", "operation", entry.Operation)
+	}
+}
+
+// Triggers remembers which objects were enqueued because of a Secret change.
+// The zero value is ready to use.
+type Triggers struct {
+	secrets sync.Map
+}
+
+// SecretChanged marks the object as enqueued because of the Secret
+func (t *Triggers) SecretChanged(obj types.NamespacedName, secret types.NamespacedName) {
+	t.secrets.Store(obj, secret.String())
+}
+
+// Source returns the source of the current reconciliation of obj and forgets a pending Secret change.
+// A changed generation or deletion takes precedence over a Secret change.
+func (t *Triggers) Source(kind string, obj client.Object, observedGeneration int64) Source {
+	src := Source{
+		Object: Object{
+			Kind:       kind,
+			Namespace:  obj.GetNamespace(),
+			Name:       obj.GetName(),
+			Generation: obj.GetGeneration(),
+		},
+		Trigger: TriggerResync,
+	}
+
+	secret, changed := t.secrets.LoadAndDelete(types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()})
+
+	switch {
+	case obj.GetGeneration() != observedGeneration || !obj.GetDeletionTimestamp().IsZero():
+		src.Trigger = TriggerSpec
+	case changed:
+		src.Trigger = TriggerSecret
+		src.Secret = secret.(string)
+	}
+
+	return src
+}
@@ -0,0 +1,32 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"regexp"
+)
+
+// Redacted replaces secrets within statements and commands
+const Redacted = "[REDACTED]"
+
+// sqlPassword matches password literals like PASSWORD 'secret' or ENCRYPTED PASSWORD 'sec”ret'
+var sqlPassword = regexp.MustCompile(`(?i)(\bPASSWORD\s*=?\s*)'(?:[^']|'')*'`)
+
+// RedactSQL replaces all password literals within the statement
+func RedactSQL(stmt string) string {
+	return sqlPassword.ReplaceAllString(stmt, "${1}'"+Redacted+"'")
+}
@@ -0,0 +1,293 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/doodlescheduling/db-controller/internal/metrics"
+)
+
+// Sink receives the audit entries
+type Sink interface {
+	Write(ctx context.Context, entry Entry) error
+	Close() error
+}
+
+// NewSink creates the sink for the target given by --audit-sink.
+// stdout writes JSON lines to stdout, http:// and https:// URLs are used as webhook and
+// everything else is a file path, optionally prefixed with file://.
+// No sink is returned for an empty target.
+func NewSink(target string) (Sink, error) {
+	switch {
+	case target == "":
+		return nil, nil
+	case target == "stdout":
+		return NewWriterSink(os.Stdout), nil
+	case strings.HasPrefix(target, "http://"), strings.HasPrefix(target, "https://"):
+		return NewWebhookSink(target, &http.Client{Timeout: 10 * time.Second}), nil
+	default:
+		s, err := NewFileSink(strings.TrimPrefix(target, "file://"))
+		if err != nil {
+			return nil, err
+		}
+
+		return s, nil
+	}
+}
+
+// WriterSink writes each entry as a single line of JSON
+type WriterSink struct {
+	mu  sync.Mutex
+	w   io.Writer
+	enc *json.Encoder
+}
+
+// NewWriterSink returns a sink writing JSON lines to w
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{
+		w:   w,
+		enc: json.NewEncoder(w),
+	}
+}
+
+// NewFileSink returns a sink appending JSON lines to the file at path
+func NewFileSink(path string) (*WriterSink, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	return NewWriterSink(f), nil
+}
+
+func (s *WriterSink) Write(_ context.Context, entry Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enc.Encode(entry)
+}
+
+// Close closes the underlying writer unless it is stdout or stderr
+func (s *WriterSink) Close() error {
+	if s.w == os.Stdout || s.w == os.Stderr {
+		return nil
+	}
+
+	if c, ok := s.w.(io.Closer); ok {
+		return c.Close()
+	}
+
+	return nil
+}
+
+const (
+	// webhookQueueSize is the number of entries the webhook sink holds while the endpoint is unavailable
+	webhookQueueSize  = 10000
+	webhookMinBackoff = time.Second
+	webhookMaxBackoff = time.Minute
+)
+
+// errRejected is returned if the webhook rejects an entry with a 4xx status other than 429,
+// posting the same entry again would fail the same way
+var errRejected = errors.New("audit webhook rejected the entry")
+
+// WebhookSink posts each entry as JSON to an HTTP endpoint.
+// Entries are queued and delivered in order by a background worker so a slow or unavailable endpoint
+// never blocks the statements which are recorded. Failed deliveries are retried with an exponential backoff,
+// entries are only dropped if they are rejected by the endpoint, the queue is full or they can't be delivered once the sink is closed.
+type WebhookSink struct {
+	url    string
+	client *http.Client
+
+	queue      chan Entry
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	closeOnce sync.Once
+	done      chan struct{}
+	stopped   chan struct{}
+	err       error
+}
+
+// NewWebhookSink returns a sink posting entries to url and starts its worker
+func NewWebhookSink(url string, client *http.Client) *WebhookSink {
+	s := &WebhookSink{
+		url:        url,
+		client:     client,
+		queue:      make(chan Entry, webhookQueueSize),
+		minBackoff: webhookMinBackoff,
+		maxBackoff: webhookMaxBackoff,
+		done:       make(chan struct{}),
+		stopped:    make(chan struct{}),
+	}
+
+	go s.run()
+	return s
+}
+
+// Write queues the entry, an error is returned if the queue is full or the sink is closed
+func (s *WebhookSink) Write(_ context.Context, entry Entry) error {
+	select {
+	case <-s.done:
+		return errors.New("audit webhook sink is closed")
+	default:
+	}
+
+	select {
+	case s.queue <- entry:
+		metrics.AuditEntriesPending.Inc()
+		return nil
+	default:
+		return fmt.Errorf("audit webhook queue is full with %d entries", webhookQueueSize)
+	}
+}
+
+// Close stops the worker after trying to deliver the queued entries once.
+// The delivery stops at the first failure, the remaining entries are dropped and reported as error.
+func (s *WebhookSink) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		<-s.stopped
+		s.client.CloseIdleConnections()
+	})
+
+	return s.err
+}
+
+func (s *WebhookSink) run() {
+	defer close(s.stopped)
+
+	for {
+		select {
+		case entry := <-s.queue:
+			if !s.deliver(entry) {
+				s.err = s.flush(entry)
+				return
+			}
+		case <-s.done:
+			s.err = s.flush()
+			return
+		}
+	}
+}
+
+// deliver posts the entry until it succeeds or is rejected, false is returned if the sink was closed before
+func (s *WebhookSink) deliver(entry Entry) bool {
+	backoff := s.minBackoff
+
+	for {
+		err := s.post(entry)
+		if err == nil {
+			metrics.AuditEntriesPending.Dec()
+			return true
+		}
+
+		metrics.AuditWriteFailuresTotal.Inc()
+		if errors.Is(err, errRejected) {
+			metrics.AuditEntriesDroppedTotal.Inc()
+			metrics.AuditEntriesPending.Dec()
+			log.Log.WithName("audit").Error(err, "failed to deliver audit entry, dropping it", "operation", entry.Operation)
+			return true
+		}
+
+		log.Log.WithName("audit").Error(err, "failed to deliver audit entry, retrying", "operation", entry.Operation, "backoff", backoff.String())
+
+		select {
+		case <-s.done:
+			return false
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, s.maxBackoff)
+	}
+}
+
+// flush posts the pending and all queued entries once, entries rejected by the webhook are skipped
+func (s *WebhookSink) flush(pending ...Entry) error {
+	for {
+		select {
+		case entry := <-s.queue:
+			pending = append(pending, entry)
+		default:
+			for i, entry := range pending {
+				err := s.post(entry)
+				if errors.Is(err, errRejected) {
+					metrics.AuditWriteFailuresTotal.Inc()
+					metrics.AuditEntriesDroppedTotal.Inc()
+					metrics.AuditEntriesPending.Dec()
+					log.Log.WithName("audit").Error(err, "failed to deliver audit entry, dropping it", "operation", entry.Operation)
+					continue
+				}
+
+				if err != nil {
+					dropped := len(pending) - i
+					metrics.AuditWriteFailuresTotal.Inc()
+					metrics.AuditEntriesDroppedTotal.Add(float64(dropped))
+					metrics.AuditEntriesPending.Sub(float64(dropped))
+					return fmt.Errorf("failed to deliver %d audit entries: %w", dropped, err)
+				}
+
+				metrics.AuditEntriesPending.Dec()
+			}
+
+			return nil
+		}
+	}
+}
+
+func (s *WebhookSink) post(entry Entry) error {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = res.Body.Close() }()
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode >= 400 && res.StatusCode <= 499 && res.StatusCode != http.StatusTooManyRequests {
+		return fmt.Errorf("%w with status %d", errRejected, res.StatusCode)
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("audit webhook returned status %d", res.StatusCode)
+	}
+
+	return nil
+}
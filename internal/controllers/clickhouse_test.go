@@ -0,0 +1,549 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	infrav1 "github.com/doodlescheduling/db-controller/api/v1"
+)
+
+const (
+	clickhouseRootUsername = "root"
+	clickhouseRootPassword = "password"
+)
+
+type clickhouseContainer struct {
+	testcontainers.Container
+	Addr string
+	URI  string
+}
+
+func setupClickHouseContainer(ctx context.Context, image string) (*clickhouseContainer, error) {
+	req := testcontainers.ContainerRequest{
+		Image:        image,
+		ExposedPorts: []string{"9000/tcp"},
+		WaitingFor:   wait.ForLog("Ready for connections").WithStartupTimeout(120 * time.Second),
+		Env: map[string]string{
+			"CLICKHOUSE_USER":                      clickhouseRootUsername,
+			"CLICKHOUSE_PASSWORD":                  clickhouseRootPassword,
+			"CLICKHOUSE_DEFAULT_ACCESS_MANAGEMENT": "1",
+		},
+	}
+
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	host, err := container.Host(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	port, err := container.MappedPort(ctx, "9000/tcp")
+	if err != nil {
+		return nil, err
+	}
+
+	addr := fmt.Sprintf("%s:%s", host, port.Port())
+	return &clickhouseContainer{Container: container, Addr: addr, URI: "clickhouse://" + addr}, nil
+}
+
+// clickhouseConnect opens a connection to the server and verifies the credentials
+func clickhouseConnect(addr, username, password, database string) (*sql.DB, error) {
+	db := clickhouse.OpenDB(&clickhouse.Options{
+		Addr: []string{addr},
+		Auth: clickhouse.Auth{
+			Database: database,
+			Username: username,
+			Password: password,
+		},
+		DialTimeout: 2 * time.Second,
+	})
+
+	if err := db.PingContext(context.Background()); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+var _ = Describe("ClickHouse", func() {
+	const (
+		timeout  = time.Second * 5
+		interval = time.Second * 1
+	)
+
+	for _, image := range []string{"clickhouse/clickhouse-server:24.8", "clickhouse/clickhouse-server:25.3"} {
+		var _ = Describe(image, func() {
+			var (
+				container *clickhouseContainer
+				err       error
+			)
+
+			container, err = setupClickHouseContainer(context.Background(), image)
+			Expect(err).NotTo(HaveOccurred(), "failed to start clickhouse container")
+
+			rootQuery := func(query string, args ...interface{}) *sql.Row {
+				db, err := clickhouseConnect(container.Addr, clickhouseRootUsername, clickhouseRootPassword, "")
+				Expect(err).NotTo(HaveOccurred(), "failed to connect to clickhouse")
+				defer func() { _ = db.Close() }()
+
+				return db.QueryRowContext(context.Background(), query, args...)
+			}
+
+			userReady := func(key types.NamespacedName) func() bool {
+				return func() bool {
+					got := &infrav1.ClickHouseUser{}
+					_ = k8sClient.Get(context.Background(), key, got)
+					return len(got.Status.Conditions) == 1 &&
+						got.Status.Conditions[0].Reason == infrav1.UserProvisioningSuccessfulReason &&
+						got.Status.Conditions[0].Status == "True" &&
+						got.Status.Conditions[0].Type == infrav1.UserReadyConditionType &&
+						got.ObjectMeta.Generation == got.Status.ObservedGeneration
+				}
+			}
+
+			updateUser := func(key types.NamespacedName, mutate func(user *infrav1.ClickHouseUser)) {
+				Eventually(func() error {
+					user := &infrav1.ClickHouseUser{}
+					if err := k8sClient.Get(context.Background(), key, user); err != nil {
+						return err
+					}
+
+					mutate(user)
+					return k8sClient.Update(context.Background(), user)
+				}, timeout, interval).Should(Succeed())
+			}
+
+			Describe("fails if database can't be reached", Ordered, func() {
+				var keyDB types.NamespacedName
+
+				namespace, rootSecret := setupNamespace()
+
+				It("adds database", func() {
+					keyDB = types.NamespacedName{
+						Name:      "clickhousedatabase-" + randStringRunes(5),
+						Namespace: namespace.Name,
+					}
+					createdDB := &infrav1.ClickHouseDatabase{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      keyDB.Name,
+							Namespace: keyDB.Namespace,
+						},
+						Spec: infrav1.ClickHouseDatabaseSpec{
+							DatabaseSpec: infrav1.DatabaseSpec{
+								Timeout: &metav1.Duration{
+									Duration: time.Millisecond * 100,
+								},
+								Address: "clickhouse://does-not-exist:9000",
+								RootSecret: &infrav1.SecretReference{
+									Name: rootSecret.Name,
+								},
+							},
+						},
+					}
+					Expect(k8sClient.Create(context.Background(), createdDB)).Should(Succeed())
+				})
+
+				It("fails reconcile because database can't be reached", func() {
+					got := &infrav1.ClickHouseDatabase{}
+					Eventually(func() bool {
+						_ = k8sClient.Get(context.Background(), keyDB, got)
+						return len(got.Status.Conditions) == 1 &&
+							got.Status.Conditions[0].Reason == infrav1.ConnectionFailedReason &&
+							got.Status.Conditions[0].Status == "False" &&
+							got.Status.Conditions[0].Type == infrav1.DatabaseReadyConditionType
+					}, timeout, interval).Should(BeTrue())
+				})
+			})
+
+			Describe("Successful user creation", Ordered, func() {
+				var (
+					createdDB     *infrav1.ClickHouseDatabase
+					createdUser   *infrav1.ClickHouseUser
+					createdSecret *corev1.Secret
+					keyUser       types.NamespacedName
+					keyDB         types.NamespacedName
+					keySecret     types.NamespacedName
+					password      string
+				)
+
+				namespace, rootSecret := setupNamespace()
+
+				Describe("creates database with engine", Ordered, func() {
+					It("adds database", func() {
+						keyDB = types.NamespacedName{
+							Name:      "clickhousedatabase-" + randStringRunes(5),
+							Namespace: namespace.Name,
+						}
+						createdDB = &infrav1.ClickHouseDatabase{
+							ObjectMeta: metav1.ObjectMeta{
+								Name:      keyDB.Name,
+								Namespace: keyDB.Namespace,
+							},
+							Spec: infrav1.ClickHouseDatabaseSpec{
+								DatabaseSpec: infrav1.DatabaseSpec{
+									Timeout: &metav1.Duration{
+										Duration: time.Second * 2,
+									},
+									Address: container.URI,
+									RootSecret: &infrav1.SecretReference{
+										Name: rootSecret.Name,
+									},
+								},
+								Engine: "Atomic",
+							},
+						}
+
+						Expect(k8sClient.Create(context.Background(), createdDB)).Should(Succeed())
+					})
+
+					It("expects ready database", func() {
+						got := &infrav1.ClickHouseDatabase{}
+						Eventually(func() bool {
+							_ = k8sClient.Get(context.Background(), keyDB, got)
+							return len(got.Status.Conditions) == 1 &&
+								got.Status.Conditions[0].Reason == infrav1.DatabaseProvisioningSuccessfulReason &&
+								got.Status.Conditions[0].Status == "True" &&
+								got.Status.Conditions[0].Type == infrav1.DatabaseReadyConditionType
+						}, timeout, interval).Should(BeTrue())
+					})
+
+					It("created the database with the engine", func() {
+						var engine string
+						Expect(rootQuery("SELECT engine FROM system.databases WHERE name = ?", keyDB.Name).Scan(&engine)).To(Succeed())
+						Expect(engine).To(Equal("Atomic"))
+					})
+				})
+
+				Describe("creates user if it does not exists", Ordered, func() {
+					It("adds secret", func() {
+						keyUser = types.NamespacedName{
+							Name:      "clickhouseuser-" + randStringRunes(5),
+							Namespace: namespace.Name,
+						}
+						keySecret = types.NamespacedName{
+							Name:      "secret-" + randStringRunes(5),
+							Namespace: namespace.Name,
+						}
+						password = randStringRunes(5)
+						createdSecret = &corev1.Secret{
+							ObjectMeta: metav1.ObjectMeta{
+								Name:      keySecret.Name,
+								Namespace: keySecret.Namespace,
+							},
+							Data: map[string][]byte{
+								"username": []byte(keyUser.Name),
+								"password": []byte(password),
+							},
+						}
+						Expect(k8sClient.Create(context.Background(), createdSecret)).Should(Succeed())
+					})
+
+					It("adds user", func() {
+						createdUser = &infrav1.ClickHouseUser{
+							ObjectMeta: metav1.ObjectMeta{
+								Name:      keyUser.Name,
+								Namespace: keyUser.Namespace,
+							},
+							Spec: infrav1.ClickHouseUserSpec{
+								Database: &infrav1.DatabaseReference{
+									Name: keyDB.Name,
+								},
+								Credentials: &infrav1.SecretReference{
+									Name: keySecret.Name,
+								},
+								Profile: "default",
+								Settings: []infrav1.ClickHouseSetting{
+									{
+										Name:     "max_threads",
+										Value:    "2",
+										Readonly: true,
+									},
+								},
+								Quotas: []infrav1.ClickHouseQuota{
+									{
+										Interval:   metav1.Duration{Duration: time.Hour},
+										MaxQueries: 1000,
+									},
+								},
+								DeletionPolicy: infrav1.DeletionPolicyDrop,
+							},
+						}
+						Expect(k8sClient.Create(context.Background(), createdUser)).Should(Succeed())
+					})
+
+					It("expects ready user", func() {
+						Eventually(userReady(keyUser), timeout, interval).Should(BeTrue())
+					})
+
+					It("assigned the settings profile and settings", func() {
+						var count uint64
+						Expect(rootQuery("SELECT count() FROM system.settings_profile_elements WHERE user_name = ? AND inherit_profile = 'default'", keyUser.Name).Scan(&count)).To(Succeed())
+						Expect(count).To(Equal(uint64(1)))
+
+						var value string
+						Expect(rootQuery("SELECT value FROM system.settings_profile_elements WHERE user_name = ? AND setting_name = 'max_threads'", keyUser.Name).Scan(&value)).To(Succeed())
+						Expect(value).To(Equal("2"))
+					})
+
+					It("created the quota", func() {
+						var maxQueries uint64
+						Expect(rootQuery("SELECT max_queries FROM system.quota_limits WHERE quota_name = ?", keyUser.Name+"_quota").Scan(&maxQueries)).To(Succeed())
+						Expect(maxQueries).To(Equal(uint64(1000)))
+					})
+
+					It("can access the created database", func() {
+						var db *sql.DB
+						Eventually(func() error {
+							c, err := clickhouseConnect(container.Addr, keyUser.Name, password, keyDB.Name)
+							db = c
+							return err
+						}, timeout, interval).Should(Succeed())
+
+						defer func() { _ = db.Close() }()
+
+						_, err := db.ExecContext(context.Background(), "CREATE TABLE foo (id UInt32) ENGINE = MergeTree ORDER BY id;")
+						Expect(err).NotTo(HaveOccurred(), "failed to create table")
+
+						_, err = db.ExecContext(context.Background(), "CREATE TABLE bar (id UInt32) ENGINE = MergeTree ORDER BY id;")
+						Expect(err).NotTo(HaveOccurred(), "failed to create table")
+					})
+
+					It("can't change a readonly setting", func() {
+						db, err := clickhouseConnect(container.Addr, keyUser.Name, password, keyDB.Name)
+						Expect(err).NotTo(HaveOccurred())
+						defer func() { _ = db.Close() }()
+
+						_, err = db.ExecContext(context.Background(), "SET max_threads = 8;")
+						Expect(err).To(HaveOccurred())
+					})
+
+					It("can't read the users of the server", func() {
+						db, err := clickhouseConnect(container.Addr, keyUser.Name, password, keyDB.Name)
+						Expect(err).NotTo(HaveOccurred())
+						defer func() { _ = db.Close() }()
+
+						_, err = db.ExecContext(context.Background(), "SELECT name FROM system.users;")
+						Expect(err).To(HaveOccurred())
+					})
+
+					It("can't access the created database with invalid credentials", func() {
+						_, err := clickhouseConnect(container.Addr, keyUser.Name, "invalid-password", keyDB.Name)
+						Expect(err).To(HaveOccurred())
+					})
+				})
+
+				Describe("Change password for user", Ordered, func() {
+					It("changes password in referenced user secret", func() {
+						password = randStringRunes(5)
+						createdSecret.Data = map[string][]byte{
+							"username": []byte(keyUser.Name),
+							"password": []byte(password),
+						}
+						Expect(k8sClient.Update(context.Background(), createdSecret)).Should(Succeed())
+					})
+
+					It("can access the database with the new password", func() {
+						Eventually(func() error {
+							db, err := clickhouseConnect(container.Addr, keyUser.Name, password, keyDB.Name)
+							if err == nil {
+								_ = db.Close()
+							}
+							return err
+						}, timeout, interval).Should(Succeed())
+					})
+				})
+
+				Describe("Grants", Ordered, func() {
+					It("restricts the user to select on table foo", func() {
+						updateUser(keyUser, func(user *infrav1.ClickHouseUser) {
+							user.Spec.Grants = []infrav1.ClickHouseGrant{
+								{
+									Table:      "foo",
+									Privileges: []infrav1.Privilege{"SELECT"},
+								},
+							}
+						})
+					})
+
+					It("expects ready user", func() {
+						Eventually(userReady(keyUser), timeout, interval).Should(BeTrue())
+					})
+
+					It("can only select from table foo", func() {
+						db, err := clickhouseConnect(container.Addr, keyUser.Name, password, keyDB.Name)
+						Expect(err).NotTo(HaveOccurred())
+						defer func() { _ = db.Close() }()
+
+						_, err = db.ExecContext(context.Background(), "SELECT * FROM foo;")
+						Expect(err).NotTo(HaveOccurred())
+
+						_, err = db.ExecContext(context.Background(), "INSERT INTO foo VALUES (1);")
+						Expect(err).To(HaveOccurred())
+
+						_, err = db.ExecContext(context.Background(), "SELECT * FROM bar;")
+						Expect(err).To(HaveOccurred())
+					})
+
+					It("rejects invalid privileges", func() {
+						updateUser(keyUser, func(user *infrav1.ClickHouseUser) {
+							user.Spec.Grants = []infrav1.ClickHouseGrant{
+								{
+									Table:      "foo",
+									Privileges: []infrav1.Privilege{"SELECT; DROP DATABASE system"},
+								},
+							}
+						})
+
+						got := &infrav1.ClickHouseUser{}
+						Eventually(func() bool {
+							_ = k8sClient.Get(context.Background(), keyUser, got)
+							return len(got.Status.Conditions) == 1 &&
+								got.Status.Conditions[0].Status == "False" &&
+								got.ObjectMeta.Generation == got.Status.ObservedGeneration
+						}, timeout, interval).Should(BeTrue())
+					})
+
+					It("grants all privileges on the database again", func() {
+						updateUser(keyUser, func(user *infrav1.ClickHouseUser) {
+							user.Spec.Grants = []infrav1.ClickHouseGrant{
+								{
+									Table:      "*",
+									Privileges: []infrav1.Privilege{"ALL"},
+								},
+							}
+						})
+
+						Eventually(userReady(keyUser), timeout, interval).Should(BeTrue())
+					})
+
+					It("revoked the table privileges", func() {
+						var count uint64
+						Expect(rootQuery("SELECT count() FROM system.grants WHERE user_name = ? AND table = 'foo'", keyUser.Name).Scan(&count)).To(Succeed())
+						Expect(count).To(Equal(uint64(0)))
+					})
+				})
+
+				Describe("ValidUntil", Ordered, func() {
+					It("sets validUntil in the past for the user", func() {
+						updateUser(keyUser, func(user *infrav1.ClickHouseUser) {
+							validUntil := metav1.NewTime(time.Now().Add(-1 * time.Hour).UTC())
+							user.Spec.ValidUntil = &validUntil
+						})
+					})
+
+					It("sets expired status after validUntil expires", func() {
+						got := &infrav1.ClickHouseUser{}
+
+						Eventually(func() bool {
+							_ = k8sClient.Get(context.Background(), keyUser, got)
+
+							return len(got.Status.Conditions) == 1 &&
+								got.Status.Conditions[0].Reason == infrav1.UserExpiredReason &&
+								got.Status.Conditions[0].Status == "False" &&
+								got.ObjectMeta.Generation == got.Status.ObservedGeneration
+						}, timeout, interval).Should(BeTrue())
+					})
+
+					It("cannot access the database after validUntil expired", func() {
+						_, err := clickhouseConnect(container.Addr, keyUser.Name, password, keyDB.Name)
+						Expect(err).To(HaveOccurred())
+					})
+
+					It("clears validUntil for the user", func() {
+						updateUser(keyUser, func(user *infrav1.ClickHouseUser) {
+							user.Spec.ValidUntil = nil
+						})
+
+						Eventually(userReady(keyUser), timeout, interval).Should(BeTrue())
+					})
+
+					It("can access the database again after clearing validUntil", func() {
+						Eventually(func() error {
+							db, err := clickhouseConnect(container.Addr, keyUser.Name, password, keyDB.Name)
+							if err == nil {
+								_ = db.Close()
+							}
+							return err
+						}, timeout, interval).Should(Succeed())
+					})
+				})
+
+				Describe("Host restrictions", Ordered, func() {
+					It("restricts the user to a foreign subnet", func() {
+						updateUser(keyUser, func(user *infrav1.ClickHouseUser) {
+							user.Spec.Hosts = []infrav1.ClickHouseHost{
+								{
+									Type:  infrav1.ClickHouseHostIP,
+									Value: "192.0.2.0/24",
+								},
+							}
+						})
+
+						Eventually(userReady(keyUser), timeout, interval).Should(BeTrue())
+					})
+
+					It("can't connect from a host outside of the subnet", func() {
+						_, err := clickhouseConnect(container.Addr, keyUser.Name, password, keyDB.Name)
+						Expect(err).To(HaveOccurred())
+					})
+				})
+
+				Describe("Delete user drops user from clickhouse", Ordered, func() {
+					It("deletes user", func() {
+						Expect(k8sClient.Delete(context.Background(), createdUser)).Should(Succeed())
+					})
+
+					It("expects gone", func() {
+						got := &infrav1.ClickHouseUser{}
+						Eventually(func() error {
+							return k8sClient.Get(context.Background(), keyUser, got)
+						}, timeout, interval).ShouldNot(Succeed())
+					})
+
+					It("dropped the user and its quota", func() {
+						var count uint64
+						Expect(rootQuery("SELECT count() FROM system.users WHERE name = ?", keyUser.Name).Scan(&count)).To(Succeed())
+						Expect(count).To(Equal(uint64(0)))
+
+						Expect(rootQuery("SELECT count() FROM system.quotas WHERE name = ?", keyUser.Name+"_quota").Scan(&count)).To(Succeed())
+						Expect(count).To(Equal(uint64(0)))
+					})
+				})
+			})
+		})
+	}
+})
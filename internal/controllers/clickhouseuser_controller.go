@@ -0,0 +1,355 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	infrav1 "github.com/doodlescheduling/db-controller/api/v1"
+	"github.com/doodlescheduling/db-controller/internal/database"
+	"github.com/doodlescheduling/db-controller/internal/metrics"
+	"github.com/doodlescheduling/db-controller/internal/stringutils"
+	"github.com/doodlescheduling/db-controller/internal/tracing"
+)
+
+// +kubebuilder:rbac:groups=dbprovisioning.infra.doodle.com,resources=clickhouseusers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=dbprovisioning.infra.doodle.com,resources=clickhouseusers/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// ClickHouseUserReconciler reconciles a ClickHouseUser object
+type ClickHouseUserReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder events.EventRecorder
+	// Provisioners is the registry to connect to the servers, the default registry is used if not set
+	Provisioners *database.Registry
+}
+
+func (r *ClickHouseUserReconciler) SetupWithManager(mgr ctrl.Manager, maxConcurrentReconciles int) error {
+	// Index the ClickHouseUser by the Credentials references they point at
+	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &infrav1.ClickHouseUser{}, credentialsIndexKey,
+		func(o client.Object) []string {
+			usr := o.(*infrav1.ClickHouseUser)
+			return []string{
+				fmt.Sprintf("%s/%s", usr.GetNamespace(), usr.Spec.Credentials.Name),
+			}
+		},
+	); err != nil {
+		return err
+	}
+
+	// Index the ClickHouseUser by the Database references they point at
+	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &infrav1.ClickHouseUser{}, dbIndexKey,
+		func(o client.Object) []string {
+			usr := o.(*infrav1.ClickHouseUser)
+			return []string{
+				fmt.Sprintf("%s/%s", usr.GetNamespace(), usr.Spec.Database.Name),
+			}
+		},
+	); err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&infrav1.ClickHouseUser{}, builder.WithPredicates(
+			predicate.GenerationChangedPredicate{},
+		)).
+		Watches(
+			&corev1.Secret{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForSecretChange),
+		).
+		Watches(
+			&infrav1.ClickHouseDatabase{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForDatabaseChange),
+		).
+		WithOptions(controller.Options{MaxConcurrentReconciles: maxConcurrentReconciles}).
+		Complete(r)
+}
+
+func (r *ClickHouseUserReconciler) requestsForSecretChange(ctx context.Context, o client.Object) []reconcile.Request {
+	s, ok := o.(*corev1.Secret)
+	if !ok {
+		panic(fmt.Sprintf("expected a Secret, got %T", o))
+	}
+
+	var list infrav1.ClickHouseUserList
+	if err := r.List(ctx, &list, client.MatchingFields{
+		credentialsIndexKey: objectKey(s).String(),
+	}); err != nil {
+		return nil
+	}
+
+	var reqs []reconcile.Request
+	for _, i := range list.Items {
+		r.Log.Info("referenced secret from a clickhouseuser change detected", "namespace", i.GetNamespace(), "name", i.GetName())
+		reqs = append(reqs, reconcile.Request{NamespacedName: objectKey(&i)})
+	}
+
+	return reqs
+}
+
+func (r *ClickHouseUserReconciler) requestsForDatabaseChange(ctx context.Context, o client.Object) []reconcile.Request {
+	s, ok := o.(*infrav1.ClickHouseDatabase)
+	if !ok {
+		panic(fmt.Sprintf("expected a ClickHouseDatabase, got %T", o))
+	}
+
+	var list infrav1.ClickHouseUserList
+	if err := r.List(ctx, &list, client.MatchingFields{
+		dbIndexKey: objectKey(s).String(),
+	}); err != nil {
+		return nil
+	}
+
+	var reqs []reconcile.Request
+	for _, i := range list.Items {
+		r.Log.Info("referenced database from a clickhouseuser change detected, reconcile", "namespace", i.GetNamespace(), "name", i.GetName())
+		reqs = append(reqs, reconcile.Request{NamespacedName: objectKey(&i)})
+	}
+
+	return reqs
+}
+
+func (r *ClickHouseUserReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx, span := tracing.Start(ctx, "ClickHouseUser.Reconcile", tracing.Object("ClickHouseUser", req.NamespacedName)...)
+	defer span.End()
+
+	logger := r.Log.WithValues("ClickHouseUser", req.NamespacedName)
+	logger.Info("reconciling ClickHouseUser")
+
+	var user infrav1.ClickHouseUser
+	if err := r.Get(ctx, req.NamespacedName, &user); err != nil {
+		if apierrors.IsNotFound(err) {
+			metrics.Users.Forget("ClickHouseUser", req.NamespacedName)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// examine DeletionTimestamp to determine if object is under deletion
+	if user.DeletionTimestamp.IsZero() {
+		if !stringutils.ContainsString(user.GetFinalizers(), infrav1.Finalizer) {
+			controllerutil.AddFinalizer(&user, infrav1.Finalizer)
+			if err := r.Update(ctx, &user); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
+	user, res, reconcileErr := r.reconcile(ctx, user)
+	tracing.RecordError(span, reconcileErr)
+	user.Status.ObservedGeneration = user.GetGeneration()
+
+	if reconcileErr != nil {
+		r.Recorder.Eventf(&user, nil, "Normal", "error", "Reconcile", "%s", reconcileErr.Error())
+	} else if !isUserExpired(user.Status.Conditions) {
+		msg := "User successfully provisioned"
+		r.Recorder.Eventf(&user, nil, "Normal", "info", "Reconcile", "%s", msg)
+		infrav1.UserReadyCondition(&user, infrav1.UserProvisioningSuccessfulReason, msg)
+	} else {
+		msg := "User has expired and was disabled"
+		r.Recorder.Eventf(&user, nil, "Normal", "info", "Reconcile", "%s", msg)
+	}
+
+	recordReconcile("ClickHouseUser", user.Status.Conditions, infrav1.UserReadyConditionType)
+	recordUser("ClickHouseUser", &user, user.Spec.ValidUntil, user.Status.Conditions, reconcileErr)
+
+	// Update status after reconciliation.
+	if err := r.patchStatus(ctx, &user); err != nil {
+		logger.Error(err, "unable to update status after reconciliation")
+		return res, err
+	}
+
+	return res, reconcileErr
+}
+
+func (r *ClickHouseUserReconciler) reconcile(ctx context.Context, user infrav1.ClickHouseUser) (infrav1.ClickHouseUser, ctrl.Result, error) {
+	res := ctrl.Result{}
+
+	// Fetch referencing database
+	var db infrav1.ClickHouseDatabase
+	databaseName := types.NamespacedName{
+		Namespace: user.GetNamespace(),
+		Name:      user.GetDatabase(),
+	}
+
+	err := r.Get(ctx, databaseName, &db)
+	if err != nil {
+		err = fmt.Errorf("referencing database was not found: %w", err)
+		infrav1.UserNotReadyCondition(&user, infrav1.DatabaseNotFoundReason, err.Error())
+		return user, res, err
+	}
+
+	if db.Spec.Timeout != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, db.Spec.Timeout.Duration)
+		defer cancel()
+	}
+
+	// Fetch referencing secret
+	usr, pw, _, err := getSecret(ctx, r.Client, user.GetCredentials())
+
+	if err != nil {
+		infrav1.UserNotReadyCondition(&user, infrav1.CredentialsNotFoundReason, err.Error())
+		return user, res, err
+	}
+
+	// Fetch referencing root secret
+	rootUsr, rootPw, addr, err := getSecret(ctx, r.Client, db.GetRootSecret())
+
+	if err != nil {
+		infrav1.UserNotReadyCondition(&user, infrav1.CredentialsNotFoundReason, err.Error())
+		return user, res, err
+	}
+
+	dbHandler, err := setupClickHouse[database.UserProvisioner](ctx, r.Provisioners, db, rootUsr, rootPw, addr)
+
+	if err != nil {
+		infrav1.UserNotReadyCondition(&user, infrav1.ConnectionFailedReason, err.Error())
+		return user, res, err
+	}
+
+	defer func() { _ = dbHandler.Close(ctx) }()
+
+	if !user.DeletionTimestamp.IsZero() {
+		err := finalizeUser(ctx, r.Client, r.Recorder, &user, dbHandler, r.statusUser(user, db), user.Spec.DeletionPolicy == infrav1.DeletionPolicyDrop)
+		return user, res, err
+	}
+
+	// The username changed, drop the previous user instead of leaving it behind
+	if user.Status.Username != "" && user.Status.Username != usr {
+		if err := dbHandler.DropUser(ctx, r.statusUser(user, db)); err != nil {
+			err = fmt.Errorf("failed to drop previous user account: %w", err)
+			infrav1.UserNotReadyCondition(&user, infrav1.UserNotProvisionedReason, err.Error())
+			return user, res, err
+		}
+	}
+
+	user.Status.Username = usr
+
+	if user.Spec.ValidUntil != nil {
+		validUntil := user.Spec.ValidUntil.UTC()
+		now := time.Now().UTC()
+
+		if !validUntil.After(now) {
+			if err := expireUser(ctx, r.Recorder, &user, dbHandler, r.statusUser(user, db)); err != nil {
+				return user, res, err
+			}
+			infrav1.UserNotReadyCondition(
+				&user,
+				infrav1.UserExpiredReason,
+				"User has expired and was disabled",
+			)
+			return user, res, nil
+		}
+
+		res.RequeueAfter = validUntil.Sub(now)
+	}
+
+	opts := database.ClickHouseUserOptions{
+		Profile: user.Spec.Profile,
+	}
+
+	for _, host := range user.Spec.Hosts {
+		opts.Hosts = append(opts.Hosts, database.ClickHouseHost{
+			Type:  string(host.Type),
+			Value: host.Value,
+		})
+	}
+
+	for _, grant := range user.Spec.Grants {
+		var privs []database.Privilege
+		for _, p := range grant.Privileges {
+			privs = append(privs, database.Privilege(p))
+		}
+
+		opts.Grants = append(opts.Grants, database.ClickHouseGrant{
+			Table:      grant.Table,
+			Privileges: privs,
+		})
+	}
+
+	for _, setting := range user.Spec.Settings {
+		opts.Settings = append(opts.Settings, database.ClickHouseSetting{
+			Name:     setting.Name,
+			Value:    setting.Value,
+			Readonly: setting.Readonly,
+		})
+	}
+
+	for _, quota := range user.Spec.Quotas {
+		opts.Quotas = append(opts.Quotas, database.ClickHouseQuota{
+			Interval:         quota.Interval.Duration,
+			MaxQueries:       quota.MaxQueries,
+			MaxErrors:        quota.MaxErrors,
+			MaxResultRows:    quota.MaxResultRows,
+			MaxReadRows:      quota.MaxReadRows,
+			MaxExecutionTime: quota.MaxExecutionTime,
+		})
+	}
+
+	err = dbHandler.SetupUser(ctx, database.User{
+		Database: db.GetDatabaseName(),
+		Username: usr,
+		Password: pw,
+		Options:  opts,
+	})
+	if err != nil {
+		err = fmt.Errorf("failed to provision user account: %w", err)
+		infrav1.UserNotReadyCondition(&user, infrav1.ConnectionFailedReason, err.Error())
+		return user, res, err
+	}
+
+	return user, res, nil
+}
+
+// statusUser returns the user which was provisioned
+func (r *ClickHouseUserReconciler) statusUser(user infrav1.ClickHouseUser, db infrav1.ClickHouseDatabase) database.User {
+	return database.User{
+		Database: db.GetDatabaseName(),
+		Username: user.Status.Username,
+	}
+}
+
+func (r *ClickHouseUserReconciler) patchStatus(ctx context.Context, database *infrav1.ClickHouseUser) error {
+	key := client.ObjectKeyFromObject(database)
+	latest := &infrav1.ClickHouseUser{}
+	if err := r.Get(ctx, key, latest); err != nil {
+		return err
+	}
+
+	return r.Client.Status().Patch(ctx, database, client.MergeFrom(latest))
+}
@@ -2,16 +2,26 @@ package controllers
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"time"
 
+	"go.opentelemetry.io/otel/attribute"
 	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
-	infrav1beta1 "github.com/doodlescheduling/db-controller/api/v1beta1"
+	infrav1 "github.com/doodlescheduling/db-controller/api/v1"
 	"github.com/doodlescheduling/db-controller/internal/database"
+	"github.com/doodlescheduling/db-controller/internal/metrics"
+	"github.com/doodlescheduling/db-controller/internal/stringutils"
+	"github.com/doodlescheduling/db-controller/internal/tracing"
 )
 
 // Index keys
@@ -19,12 +29,10 @@ const (
 	secretIndexKey      string = ".metadata.secret"
 	credentialsIndexKey string = ".metadata.credentials"
 	dbIndexKey          string = ".metadata.database"
+	configMapIndexKey   string = ".metadata.configmap"
+	serverIndexKey      string = ".metadata.server"
 )
 
-type userDropper interface {
-	DropUser(ctx context.Context, db, username string) error
-}
-
 // objectKey returns c.ObjectKey for the object.
 func objectKey(object metav1.Object) client.ObjectKey {
 	return client.ObjectKey{
@@ -33,19 +41,110 @@ func objectKey(object metav1.Object) client.ObjectKey {
 	}
 }
 
-func extractMongoDBUserRoles(roles []infrav1beta1.MongoDBUserRole) database.MongoDBRoles {
-	list := make(database.MongoDBRoles, 0)
+// provisionerRegistry returns the registry to connect provisioners from, the default registry is used if none is set
+func provisionerRegistry(registry *database.Registry) *database.Registry {
+	if registry != nil {
+		return registry
+	}
+
+	return database.DefaultRegistry
+}
+
+func extractMongoDBUserRoles(roles []infrav1.MongoDBUserRole) []database.Role {
+	list := make([]database.Role, 0)
 	for _, r := range roles {
-		list = append(list, database.MongoDBRole{
-			Name: r.Name,
-			DB:   r.DB,
+		list = append(list, database.Role{
+			Name:     r.Name,
+			Database: r.DB,
 		})
 	}
 
 	return list
 }
 
-func extractCredentials(credentials *infrav1beta1.SecretReference, secret *corev1.Secret) (string, string, string, error) {
+func extractMongoDBUserOptions(user infrav1.MongoDBUser) database.MongoDBUserOptions {
+	var opts database.MongoDBUserOptions
+	for _, m := range user.Spec.Mechanisms {
+		opts.Mechanisms = append(opts.Mechanisms, string(m))
+	}
+
+	for _, r := range user.Spec.AuthenticationRestrictions {
+		opts.AuthenticationRestrictions = append(opts.AuthenticationRestrictions, database.MongoDBAuthenticationRestriction{
+			ClientSource:  r.ClientSource,
+			ServerAddress: r.ServerAddress,
+		})
+	}
+
+	if user.Spec.CustomData != nil {
+		opts.CustomData = user.Spec.CustomData.Raw
+	}
+
+	return opts
+}
+
+// hashCredentials returns an HMAC-SHA256 of the given credential values keyed with the salt.
+// The hash is safe to be stored in the status and cheap enough to be compared on every reconciliation.
+func hashCredentials(salt string, values ...string) string {
+	mac := hmac.New(sha256.New, []byte(salt))
+	for _, v := range values {
+		_, _ = mac.Write([]byte(v))
+		_, _ = mac.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// credentialsMatch reports whether the given credential values match a hash from hashCredentials
+func credentialsMatch(hash, salt string, values ...string) bool {
+	if hash == "" || salt == "" {
+		return false
+	}
+
+	return hmac.Equal([]byte(hash), []byte(hashCredentials(salt, values...)))
+}
+
+func extractAtlasUserOptions(user infrav1.MongoDBUser) database.AtlasUserOptions {
+	var opts database.AtlasUserOptions
+	if user.Spec.Atlas == nil {
+		return opts
+	}
+
+	for _, s := range user.Spec.Atlas.Scopes {
+		scopeType := s.Type
+		if scopeType == "" {
+			scopeType = infrav1.MongoDBAtlasScopeCluster
+		}
+
+		opts.Scopes = append(opts.Scopes, database.AtlasScope{
+			Name: s.Name,
+			Type: string(scopeType),
+		})
+	}
+
+	for _, l := range user.Spec.Atlas.Labels {
+		opts.Labels = append(opts.Labels, database.AtlasLabel{
+			Key:   l.Key,
+			Value: l.Value,
+		})
+	}
+
+	opts.X509Type = string(user.Spec.Atlas.X509Type)
+	opts.AWSIAMType = string(user.Spec.Atlas.AWSIAMType)
+	opts.LDAPAuthType = string(user.Spec.Atlas.LDAPAuthType)
+
+	return opts
+}
+
+// getMongoDBUserDatabase returns the database the user is stored in
+func getMongoDBUserDatabase(user infrav1.MongoDBUser, db infrav1.MongoDBDatabase) string {
+	if db.IsAtlas() {
+		return database.AtlasAuthDatabase(extractAtlasUserOptions(user))
+	}
+
+	return db.GetDatabaseName()
+}
+
+func extractCredentials(credentials *infrav1.SecretReference, secret *corev1.Secret) (string, string, string, error) {
 	var (
 		user string
 		pw   string
@@ -86,12 +185,28 @@ func extractCredentials(credentials *infrav1beta1.SecretReference, secret *corev
 	return user, pw, addr, nil
 }
 
-func setupAtlas(ctx context.Context, db infrav1beta1.MongoDBDatabase, pubKey, privKey, addr string) (*database.AtlasRepository, error) {
-	handler, err := database.NewAtlasRepository(ctx, database.AtlasOptions{
-		GroupID:    db.Spec.AtlasGroupId,
-		PrivateKey: privKey,
-		PublicKey:  pubKey,
-	})
+// atlasOptions returns the options to connect to the Atlas admin API, the address of the root secret has no meaning for Atlas
+func atlasOptions(db infrav1.MongoDBDatabase, pubKey, privKey string) database.ProvisionerOptions {
+	address := db.Spec.Atlas.BaseURL
+	if address == "" {
+		address = defaultAtlasBaseURL
+	}
+
+	return database.ProvisionerOptions{
+		URI:      address,
+		Username: pubKey,
+		Password: privKey,
+		Flavor:   database.MongoDBFlavorAtlas,
+		GroupID:  db.Spec.Atlas.GroupID,
+	}
+}
+
+func setupAtlas[T database.Provisioner](ctx context.Context, registry *database.Registry, db infrav1.MongoDBDatabase, pubKey, privKey string) (T, error) {
+	opts := atlasOptions(db, pubKey, privKey)
+
+	start := time.Now()
+	handler, err := database.NewProvisioner[T](ctx, provisionerRegistry(registry), database.EngineMongoDB, opts)
+	metrics.ObserveConnection(database.MongoDBFlavorAtlas, opts.URI, time.Since(start), err)
 
 	if err != nil {
 		return handler, fmt.Errorf("failed to setup connection to mongodb atlas: %w", err)
@@ -100,11 +215,12 @@ func setupAtlas(ctx context.Context, db infrav1beta1.MongoDBDatabase, pubKey, pr
 	return handler, nil
 }
 
-func setupPostgreSQL(ctx context.Context, db infrav1beta1.PostgreSQLDatabase, usr, pw, addr string, switchDB bool) (*database.PostgreSQLRepository, error) {
-	opts := database.PostgreSQLOptions{
+func postgreSQLOptions(db infrav1.PostgreSQLDatabase, usr, pw, addr string, switchDB bool) database.ProvisionerOptions {
+	opts := database.ProvisionerOptions{
 		URI:      addr,
 		Username: usr,
 		Password: pw,
+		Flavor:   string(db.GetFlavor()),
 	}
 
 	if db.Spec.Address != "" {
@@ -115,7 +231,15 @@ func setupPostgreSQL(ctx context.Context, db infrav1beta1.PostgreSQLDatabase, us
 		opts.DatabaseName = db.GetDatabaseName()
 	}
 
-	handler, err := database.NewPostgreSQLRepository(ctx, opts)
+	return opts
+}
+
+func setupPostgreSQL[T database.Provisioner](ctx context.Context, registry *database.Registry, db infrav1.PostgreSQLDatabase, usr, pw, addr string, switchDB bool) (T, error) {
+	opts := postgreSQLOptions(db, usr, pw, addr, switchDB)
+
+	start := time.Now()
+	handler, err := database.NewProvisioner[T](ctx, provisionerRegistry(registry), database.EnginePostgreSQL, opts)
+	metrics.ObserveConnection(string(database.EnginePostgreSQL), opts.URI, time.Since(start), err)
 
 	if err != nil {
 		return handler, fmt.Errorf("failed to setup connection to postgres server: %w", err)
@@ -124,20 +248,189 @@ func setupPostgreSQL(ctx context.Context, db infrav1beta1.PostgreSQLDatabase, us
 	return handler, nil
 }
 
-func setupMongoDB(ctx context.Context, db infrav1beta1.MongoDBDatabase, usr, pw, addr string) (*database.MongoDBRepository, error) {
-	opts := database.MongoDBOptions{
+func mySQLOptions(db infrav1.MySQLDatabase, usr, pw, addr string) database.ProvisionerOptions {
+	opts := database.ProvisionerOptions{
+		URI:      addr,
+		Username: usr,
+		Password: pw,
+	}
+
+	if db.Spec.Address != "" {
+		opts.URI = db.Spec.Address
+	}
+
+	return opts
+}
+
+func setupMySQL[T database.Provisioner](ctx context.Context, registry *database.Registry, db infrav1.MySQLDatabase, usr, pw, addr string) (T, error) {
+	opts := mySQLOptions(db, usr, pw, addr)
+
+	start := time.Now()
+	handler, err := database.NewProvisioner[T](ctx, provisionerRegistry(registry), database.EngineMySQL, opts)
+	metrics.ObserveConnection(string(database.EngineMySQL), opts.URI, time.Since(start), err)
+
+	if err != nil {
+		return handler, fmt.Errorf("failed to setup connection to mysql server: %w", err)
+	}
+
+	return handler, nil
+}
+
+func clickHouseOptions(db infrav1.ClickHouseDatabase, usr, pw, addr string) database.ProvisionerOptions {
+	opts := database.ProvisionerOptions{
+		URI:      addr,
+		Username: usr,
+		Password: pw,
+		Cluster:  db.Spec.Cluster,
+	}
+
+	if db.Spec.Address != "" {
+		opts.URI = db.Spec.Address
+	}
+
+	return opts
+}
+
+func setupClickHouse[T database.Provisioner](ctx context.Context, registry *database.Registry, db infrav1.ClickHouseDatabase, usr, pw, addr string) (T, error) {
+	opts := clickHouseOptions(db, usr, pw, addr)
+
+	start := time.Now()
+	handler, err := database.NewProvisioner[T](ctx, provisionerRegistry(registry), database.EngineClickHouse, opts)
+	metrics.ObserveConnection(string(database.EngineClickHouse), opts.URI, time.Since(start), err)
+
+	if err != nil {
+		return handler, fmt.Errorf("failed to setup connection to clickhouse server: %w", err)
+	}
+
+	return handler, nil
+}
+
+func mssqlOptions(db infrav1.MSSQLDatabase, usr, pw, addr string) database.ProvisionerOptions {
+	opts := database.ProvisionerOptions{
+		URI:      addr,
+		Username: usr,
+		Password: pw,
+	}
+
+	if db.Spec.Address != "" {
+		opts.URI = db.Spec.Address
+	}
+
+	return opts
+}
+
+func setupMSSQL[T database.Provisioner](ctx context.Context, registry *database.Registry, db infrav1.MSSQLDatabase, usr, pw, addr string) (T, error) {
+	opts := mssqlOptions(db, usr, pw, addr)
+
+	start := time.Now()
+	handler, err := database.NewProvisioner[T](ctx, provisionerRegistry(registry), database.EngineMSSQL, opts)
+	metrics.ObserveConnection(string(database.EngineMSSQL), opts.URI, time.Since(start), err)
+
+	if err != nil {
+		return handler, fmt.Errorf("failed to setup connection to mssql server: %w", err)
+	}
+
+	return handler, nil
+}
+
+func rabbitMQOptions(vhost infrav1.RabbitMQVhost, usr, pw, addr string) database.ProvisionerOptions {
+	opts := database.ProvisionerOptions{
+		URI:      addr,
+		Username: usr,
+		Password: pw,
+	}
+
+	if vhost.Spec.Address != "" {
+		opts.URI = vhost.Spec.Address
+	}
+
+	return opts
+}
+
+func setupRabbitMQ[T database.Provisioner](ctx context.Context, registry *database.Registry, vhost infrav1.RabbitMQVhost, usr, pw, addr string) (T, error) {
+	opts := rabbitMQOptions(vhost, usr, pw, addr)
+
+	start := time.Now()
+	handler, err := database.NewProvisioner[T](ctx, provisionerRegistry(registry), database.EngineRabbitMQ, opts)
+	metrics.ObserveConnection(string(database.EngineRabbitMQ), opts.URI, time.Since(start), err)
+
+	if err != nil {
+		return handler, fmt.Errorf("failed to setup connection to rabbitmq management api: %w", err)
+	}
+
+	return handler, nil
+}
+
+func redisOptions(server infrav1.RedisServer, usr, pw, addr string) database.ProvisionerOptions {
+	opts := database.ProvisionerOptions{
+		URI:      addr,
+		Username: usr,
+		Password: pw,
+		SaveACL:  server.Spec.SaveACL,
+	}
+
+	if server.Spec.Address != "" {
+		opts.URI = server.Spec.Address
+	}
+
+	return opts
+}
+
+func setupRedis[T database.Provisioner](ctx context.Context, registry *database.Registry, server infrav1.RedisServer, usr, pw, addr string) (T, error) {
+	opts := redisOptions(server, usr, pw, addr)
+
+	start := time.Now()
+	handler, err := database.NewProvisioner[T](ctx, provisionerRegistry(registry), database.EngineRedis, opts)
+	metrics.ObserveConnection(string(database.EngineRedis), opts.URI, time.Since(start), err)
+
+	if err != nil {
+		return handler, fmt.Errorf("failed to setup connection to redis server: %w", err)
+	}
+
+	return handler, nil
+}
+
+// mongoDBOptions returns the options to connect to a MongoDB server including the client certificate of the TLS secret
+func mongoDBOptions(ctx context.Context, c client.Client, db infrav1.MongoDBDatabase, usr, pw, addr string) (database.ProvisionerOptions, error) {
+	opts := database.ProvisionerOptions{
 		URI:              addr,
 		AuthDatabaseName: db.GetRootDatabaseName(),
+		AuthMechanism:    string(db.Spec.AuthMechanism),
 		DatabaseName:     db.GetDatabaseName(),
 		Username:         usr,
 		Password:         pw,
+		ReplicaSet:       db.Spec.ReplicaSet,
+		ReadPreference:   db.Spec.ReadPreference,
 	}
 
 	if db.Spec.Address != "" {
 		opts.URI = db.Spec.Address
 	}
 
-	handler, err := database.NewMongoDBRepository(ctx, opts)
+	if ref := db.GetTLSSecret(); ref != nil {
+		cert, key, ca, err := getTLSSecret(ctx, c, ref)
+		if err != nil {
+			return opts, err
+		}
+
+		opts.TLSCertificate = cert
+		opts.TLSKey = key
+		opts.TLSCA = ca
+	}
+
+	return opts, nil
+}
+
+func setupMongoDB[T database.Provisioner](ctx context.Context, c client.Client, registry *database.Registry, db infrav1.MongoDBDatabase, usr, pw, addr string) (T, error) {
+	opts, err := mongoDBOptions(ctx, c, db, usr, pw, addr)
+	if err != nil {
+		var empty T
+		return empty, err
+	}
+
+	start := time.Now()
+	handler, err := database.NewProvisioner[T](ctx, provisionerRegistry(registry), database.EngineMongoDB, opts)
+	metrics.ObserveConnection(string(database.EngineMongoDB), opts.URI, time.Since(start), err)
 
 	if err != nil {
 		return handler, fmt.Errorf("failed to setup connection to mongodb: %w", err)
@@ -146,34 +439,303 @@ func setupMongoDB(ctx context.Context, db infrav1beta1.MongoDBDatabase, usr, pw,
 	return handler, nil
 }
 
-func getSecret(ctx context.Context, c client.Client, sec *infrav1beta1.SecretReference) (string, string, string, error) {
+func getSecret(ctx context.Context, c client.Client, sec *infrav1.SecretReference) (usr, pw, addr string, err error) {
+	ctx, span := tracing.Start(ctx, "getSecret",
+		attribute.String("k8s.secret.namespace", sec.Namespace),
+		attribute.String("k8s.secret.name", sec.Name),
+	)
+	defer func() { tracing.End(span, err) }()
+
 	// Fetch referencing root secret
 	secret := &corev1.Secret{}
 	secretName := types.NamespacedName{
 		Namespace: sec.Namespace,
 		Name:      sec.Name,
 	}
-	err := c.Get(ctx, secretName, secret)
+	err = c.Get(ctx, secretName, secret)
 
 	// Failed to fetch referenced secret, requeue immediately
 	if err != nil {
 		return "", "", "", fmt.Errorf("referencing secret was not found: %w", err)
 	}
 
-	usr, pw, addr, err := extractCredentials(sec, secret)
+	usr, pw, addr, err = extractCredentials(sec, secret)
 	if err != nil {
 		return usr, pw, "", fmt.Errorf("credentials field not found in referenced secret: %w", err)
 	}
 
+	// The password is never added to the span
+	span.SetAttributes(tracing.User(usr))
+	if addr != "" {
+		span.SetAttributes(tracing.Server(addr))
+	}
+
 	return usr, pw, addr, err
 }
 
+// getMongoDBRootSecret returns the root credentials of a MongoDBDatabase, for Atlas the public and private api key.
+// The password is not required if the root user authenticates using a client certificate.
+func getMongoDBRootSecret(ctx context.Context, c client.Client, db infrav1.MongoDBDatabase) (string, string, string, error) {
+	if db.IsAtlas() {
+		ref := db.GetAtlasAPIKeySecret()
+		if ref == nil {
+			return "", "", "", errors.New("no atlas api key secret referenced")
+		}
+
+		return getSecret(ctx, c, atlasAPIKeySecret(ref))
+	}
+
+	if db.Spec.AuthMechanism != infrav1.MongoDBAuthMechanismX509 {
+		return getSecret(ctx, c, db.GetRootSecret())
+	}
+
+	usr, addr, err := getSecretUsername(ctx, c, db.GetRootSecret())
+	return usr, "", addr, err
+}
+
+// atlasAPIKeySecret maps an Atlas API key secret to a SecretReference which reads the public key as username
+// and the private key as password
+func atlasAPIKeySecret(ref *infrav1.AtlasAPIKeySecretReference) *infrav1.SecretReference {
+	sec := &infrav1.SecretReference{
+		Name:          ref.Name,
+		Namespace:     ref.Namespace,
+		UserField:     ref.PublicKeyField,
+		PasswordField: ref.PrivateKeyField,
+	}
+
+	if sec.UserField == "" {
+		sec.UserField = "publicKey"
+	}
+
+	if sec.PasswordField == "" {
+		sec.PasswordField = "privateKey"
+	}
+
+	return sec
+}
+
+// getSecretUsername returns the username and address from a secret for users which don't authenticate using a password
+func getSecretUsername(ctx context.Context, c client.Client, sec *infrav1.SecretReference) (string, string, error) {
+	secret := &corev1.Secret{}
+	secretName := types.NamespacedName{
+		Namespace: sec.Namespace,
+		Name:      sec.Name,
+	}
+
+	if err := c.Get(ctx, secretName, secret); err != nil {
+		return "", "", fmt.Errorf("referencing secret was not found: %w", err)
+	}
+
+	userField := sec.UserField
+	if userField == "" {
+		userField = "username"
+	}
+
+	addrField := sec.AddressField
+	if addrField == "" {
+		addrField = "address"
+	}
+
+	return string(secret.Data[userField]), string(secret.Data[addrField]), nil
+}
+
+func getTLSSecret(ctx context.Context, c client.Client, ref *infrav1.TLSSecretReference) ([]byte, []byte, []byte, error) {
+	secret := &corev1.Secret{}
+	secretName := types.NamespacedName{
+		Namespace: ref.Namespace,
+		Name:      ref.Name,
+	}
+
+	if err := c.Get(ctx, secretName, secret); err != nil {
+		return nil, nil, nil, fmt.Errorf("referencing tls secret was not found: %w", err)
+	}
+
+	return secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey], secret.Data["ca.crt"], nil
+}
+
+// errorReason maps well known errors to a condition reason and returns the fallback reason for all others
+func errorReason(err error, fallback string) string {
+	switch {
+	case errors.Is(err, database.ErrAtlasUnauthorized):
+		return infrav1.AuthenticationFailedReason
+	case errors.Is(err, database.ErrAtlasRateLimited):
+		return infrav1.RateLimitedReason
+	case errors.Is(err, database.ErrAtlasServerError):
+		return infrav1.ServerErrorReason
+	}
+
+	return fallback
+}
+
 func isUserExpired(conditions []metav1.Condition) bool {
 	for _, condition := range conditions {
-		if condition.Type == infrav1beta1.UserReadyConditionType &&
-			condition.Reason == infrav1beta1.UserExpiredReason {
+		if condition.Type == infrav1.UserReadyConditionType &&
+			condition.Reason == infrav1.UserExpiredReason {
 			return true
 		}
 	}
 	return false
 }
+
+// userResource is a user resource whose server side account is managed by the shared user helpers
+type userResource interface {
+	client.Object
+	GetStatusConditions() *[]metav1.Condition
+}
+
+// sessionTerminatingResource is implemented by user resources which can ask for their active sessions to be terminated
+type sessionTerminatingResource interface {
+	ShouldTerminateSessions() bool
+}
+
+// finalizeUser drops or disables the account of the user and removes the finalizer afterwards
+func finalizeUser(ctx context.Context, c client.Client, recorder events.EventRecorder, user userResource, handler database.UserProvisioner, userSpec database.User, drop bool) error {
+	var err error
+	if drop {
+		err = dropUser(ctx, recorder, user, handler, userSpec)
+	} else {
+		err = disableUser(ctx, recorder, user, handler, userSpec)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return removeFinalizer(ctx, c, user)
+}
+
+// releaseUser removes the finalizer of a user whose database resource or root secret was deleted first.
+// The account is left on the server as there is no way to connect to it anymore.
+func releaseUser(ctx context.Context, c client.Client, recorder events.EventRecorder, user userResource, cause error) error {
+	recorder.Eventf(user, nil, "Normal", "error", "Reconcile", "User account was not removed from the server: %s", cause.Error())
+	return removeFinalizer(ctx, c, user)
+}
+
+// removeFinalizer removes the finalizer of the controller from the object
+func removeFinalizer(ctx context.Context, c client.Client, obj client.Object) error {
+	if !stringutils.ContainsString(obj.GetFinalizers(), infrav1.Finalizer) {
+		return nil
+	}
+
+	obj.SetFinalizers(stringutils.RemoveString(obj.GetFinalizers(), infrav1.Finalizer))
+	return c.Update(ctx, obj)
+}
+
+// expireUser blocks new logins of the account and terminates its active sessions
+func expireUser(ctx context.Context, recorder events.EventRecorder, user userResource, handler database.UserProvisioner, userSpec database.User) error {
+	if userSpec.Username == "" {
+		return nil
+	}
+
+	expirer, ok := handler.(database.UserExpirer)
+	if !ok {
+		err := errors.New("provisioner does not support expiring users")
+		infrav1.UserNotReadyCondition(user, infrav1.ConnectionFailedReason, err.Error())
+		return err
+	}
+
+	if err := expirer.ExpireUser(ctx, userSpec); err != nil {
+		err = fmt.Errorf("failed to expire user account: %w", err)
+		infrav1.UserNotReadyCondition(user, errorReason(err, infrav1.ConnectionFailedReason), err.Error())
+		return err
+	}
+
+	return terminateSessions(ctx, recorder, user, handler, userSpec)
+}
+
+// terminateSessions terminates the active sessions of the account if the user asks for it and the provisioner supports it
+func terminateSessions(ctx context.Context, recorder events.EventRecorder, user userResource, handler database.UserProvisioner, userSpec database.User) error {
+	if resource, ok := user.(sessionTerminatingResource); !ok || !resource.ShouldTerminateSessions() {
+		return nil
+	}
+
+	terminator, ok := handler.(database.SessionTerminator)
+	if !ok {
+		return nil
+	}
+
+	sessions, err := terminator.TerminateSessions(ctx, userSpec)
+	if err != nil {
+		err = fmt.Errorf("failed to terminate sessions of user account: %w", err)
+		infrav1.UserNotReadyCondition(user, errorReason(err, infrav1.ConnectionFailedReason), err.Error())
+		return err
+	}
+
+	recorder.Eventf(user, nil, "Normal", "info", "TerminateSessions", "Terminated %d active sessions of user %s", sessions, userSpec.Username)
+	return nil
+}
+
+// dropUser terminates the active sessions of the account before it gets dropped
+func dropUser(ctx context.Context, recorder events.EventRecorder, user userResource, handler database.UserProvisioner, userSpec database.User) error {
+	if userSpec.Username == "" {
+		return nil
+	}
+
+	if err := terminateSessions(ctx, recorder, user, handler, userSpec); err != nil {
+		return err
+	}
+
+	if err := handler.DropUser(ctx, userSpec); err != nil {
+		err = fmt.Errorf("failed to drop user account: %w", err)
+		infrav1.UserNotReadyCondition(user, errorReason(err, infrav1.ConnectionFailedReason), err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// disableUser randomizes the password of the account, revokes its privileges and terminates its active sessions.
+// What disabling means in detail is up to the provisioner.
+func disableUser(ctx context.Context, recorder events.EventRecorder, user userResource, handler database.UserProvisioner, userSpec database.User) error {
+	if userSpec.Username == "" {
+		return nil
+	}
+
+	disabler, ok := handler.(database.UserDisabler)
+	if !ok {
+		err := errors.New("provisioner does not support disabling users")
+		infrav1.UserNotReadyCondition(user, infrav1.ConnectionFailedReason, err.Error())
+		return err
+	}
+
+	userSpec.Password = generateToken(32)
+	if err := disabler.DisableUser(ctx, userSpec); err != nil {
+		err = fmt.Errorf("failed to disable user account: %w", err)
+		infrav1.UserNotReadyCondition(user, errorReason(err, infrav1.ConnectionFailedReason), err.Error())
+		return err
+	}
+
+	return terminateSessions(ctx, recorder, user, handler, userSpec)
+}
+
+// recordReconcile counts the reconciliation by the reason of the ready condition
+func recordReconcile(kind string, conditions []metav1.Condition, conditionType string) {
+	var reason string
+	if condition := apimeta.FindStatusCondition(conditions, conditionType); condition != nil {
+		reason = condition.Reason
+	}
+
+	metrics.RecordReconcile(kind, reason)
+}
+
+// recordUser updates the expiry and the credential sync of the user metrics.
+// The credentials are synced if the user was reconciled without error,
+// the sync of an expired user is left untouched as it is reported as expired instead.
+func recordUser(kind string, user metav1.Object, validUntil *metav1.Time, conditions []metav1.Condition, reconcileErr error) {
+	key := types.NamespacedName{Namespace: user.GetNamespace(), Name: user.GetName()}
+	if !user.GetDeletionTimestamp().IsZero() {
+		metrics.Users.Forget(kind, key)
+		return
+	}
+
+	var until *time.Time
+	if validUntil != nil {
+		until = &validUntil.Time
+	}
+
+	metrics.Users.Observe(kind, key, until)
+
+	if !isUserExpired(conditions) {
+		metrics.Users.CredentialsSynced(kind, key, reconcileErr == nil)
+	}
+}
@@ -0,0 +1,499 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inmemory
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	infrav1 "github.com/doodlescheduling/db-controller/api/v1"
+	"github.com/doodlescheduling/db-controller/internal/database"
+)
+
+var _ = Describe("MongoDB", func() {
+	const (
+		timeout  = time.Second * 10
+		interval = time.Millisecond * 250
+		groupID  = "atlas-group"
+	)
+
+	databaseCondition := func(key types.NamespacedName, conditionType string) func() metav1.Condition {
+		return func() metav1.Condition {
+			got := &infrav1.MongoDBDatabase{}
+			_ = k8sClient.Get(context.Background(), key, got)
+			if condition := meta.FindStatusCondition(got.Status.Conditions, conditionType); condition != nil {
+				return *condition
+			}
+
+			return metav1.Condition{}
+		}
+	}
+
+	userCondition := func(key types.NamespacedName) func() metav1.Condition {
+		return func() metav1.Condition {
+			got := &infrav1.MongoDBUser{}
+			_ = k8sClient.Get(context.Background(), key, got)
+			if condition := meta.FindStatusCondition(got.Status.Conditions, infrav1.UserReadyConditionType); condition != nil {
+				return *condition
+			}
+
+			return metav1.Condition{}
+		}
+	}
+
+	createDatabase := func(namespace, rootSecret string, spec infrav1.MongoDBDatabaseSpec) (types.NamespacedName, string) {
+		keyDB := types.NamespacedName{
+			Name:      "mongodbdatabase-" + randStringRunes(5),
+			Namespace: namespace,
+		}
+
+		dbName := "database-" + randStringRunes(5)
+		spec.DatabaseName = dbName
+
+		// The Atlas API key pair is read from the root secret as well
+		if spec.Atlas != nil {
+			spec.Atlas.APIKeySecret = &infrav1.AtlasAPIKeySecretReference{
+				Name:            rootSecret,
+				PublicKeyField:  "username",
+				PrivateKeyField: "password",
+			}
+		} else {
+			spec.RootSecret = &infrav1.SecretReference{
+				Name: rootSecret,
+			}
+		}
+
+		Expect(k8sClient.Create(context.Background(), &infrav1.MongoDBDatabase{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      keyDB.Name,
+				Namespace: keyDB.Namespace,
+			},
+			Spec: spec,
+		})).Should(Succeed())
+
+		return keyDB, dbName
+	}
+
+	createUser := func(keyDB types.NamespacedName, username string, spec infrav1.MongoDBUserSpec) types.NamespacedName {
+		keyUser := types.NamespacedName{
+			Name:      "mongodbuser-" + randStringRunes(5),
+			Namespace: keyDB.Namespace,
+		}
+
+		createSecret(keyUser.Namespace, keyUser.Name, username, "secret")
+
+		spec.Database = &infrav1.DatabaseReference{
+			Name: keyDB.Name,
+		}
+		spec.Credentials = &infrav1.SecretReference{
+			Name: keyUser.Name,
+		}
+
+		Expect(k8sClient.Create(context.Background(), &infrav1.MongoDBUser{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      keyUser.Name,
+				Namespace: keyUser.Namespace,
+			},
+			Spec: spec,
+		})).Should(Succeed())
+
+		return keyUser
+	}
+
+	updateUser := func(key types.NamespacedName, mutate func(user *infrav1.MongoDBUser)) {
+		Eventually(func() error {
+			got := &infrav1.MongoDBUser{}
+			if err := k8sClient.Get(context.Background(), key, got); err != nil {
+				return err
+			}
+
+			mutate(got)
+			return k8sClient.Update(context.Background(), got)
+		}, timeout, interval).Should(Succeed())
+	}
+
+	deleteUser := func(key types.NamespacedName) {
+		Expect(k8sClient.Delete(context.Background(), &infrav1.MongoDBUser{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      key.Name,
+				Namespace: key.Namespace,
+			},
+		})).Should(Succeed())
+	}
+
+	userGone := func(key types.NamespacedName) func() bool {
+		return func() bool {
+			err := k8sClient.Get(context.Background(), key, &infrav1.MongoDBUser{})
+			return apierrors.IsNotFound(err)
+		}
+	}
+
+	Describe("Database seeds", Ordered, func() {
+		var (
+			keyDB  types.NamespacedName
+			dbName string
+		)
+
+		namespace, rootSecret := setupNamespace()
+
+		It("creates database", func() {
+			Expect(k8sClient.Create(context.Background(), &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "seed",
+					Namespace: namespace.Name,
+				},
+				Data: map[string]string{
+					"users.json": `[{"name": "admin"}]`,
+				},
+			})).Should(Succeed())
+
+			keyDB, dbName = createDatabase(namespace.Name, rootSecret.Name, infrav1.MongoDBDatabaseSpec{
+				Seeds: []infrav1.MongoDBSeed{
+					{
+						Name:       "initial",
+						Type:       infrav1.MongoDBSeedDocuments,
+						Collection: "users",
+						ConfigMap: &infrav1.ConfigMapReference{
+							Name: "seed",
+						},
+					},
+				},
+			})
+		})
+
+		It("applies the seed", func() {
+			Eventually(databaseCondition(keyDB, infrav1.SeedReadyConditionType), timeout, interval).Should(And(
+				HaveField("Reason", infrav1.SeedSuccessfulReason),
+				HaveField("Status", metav1.ConditionTrue),
+			))
+			Expect(databaseCondition(keyDB, infrav1.DatabaseReadyConditionType)()).To(HaveField("Status", metav1.ConditionTrue))
+			Expect(mongodb.Seeds(dbName)).To(HaveKey("initial/users.json"))
+		})
+
+		It("fails if a seed is invalid", func() {
+			Eventually(func() error {
+				cm := &corev1.ConfigMap{}
+				if err := k8sClient.Get(context.Background(), types.NamespacedName{Name: "seed", Namespace: namespace.Name}, cm); err != nil {
+					return err
+				}
+
+				cm.Data["z-invalid.json"] = `{`
+				return k8sClient.Update(context.Background(), cm)
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(databaseCondition(keyDB, infrav1.SeedReadyConditionType), timeout, interval).Should(And(
+				HaveField("Reason", infrav1.SeedFailedReason),
+				HaveField("Status", metav1.ConditionFalse),
+			))
+			Expect(mongodb.Seeds(dbName)).To(HaveLen(1))
+		})
+	})
+
+	Describe("Connection options", Ordered, func() {
+		const address = "mongodb+srv://cluster.example.com"
+
+		var (
+			keyDB     types.NamespacedName
+			keySecret types.NamespacedName
+		)
+
+		namespace, rootSecret := setupNamespace()
+		tlsNamespace, _ := setupNamespace()
+
+		updateTLSSecret := func(cert string) {
+			Eventually(func() error {
+				secret := &corev1.Secret{}
+				if err := k8sClient.Get(context.Background(), keySecret, secret); err != nil {
+					return err
+				}
+
+				secret.Data[corev1.TLSCertKey] = []byte(cert)
+				return k8sClient.Update(context.Background(), secret)
+			}, timeout, interval).Should(Succeed())
+		}
+
+		connectOptions := func() database.ProvisionerOptions {
+			var last database.ProvisionerOptions
+			for _, opts := range mongodb.ConnectOptions() {
+				if opts.URI == address {
+					last = opts
+				}
+			}
+
+			return last
+		}
+
+		It("creates database authenticating with a client certificate from another namespace", func() {
+			keySecret = types.NamespacedName{
+				Name:      "tls-" + randStringRunes(5),
+				Namespace: tlsNamespace.Name,
+			}
+
+			Expect(k8sClient.Create(context.Background(), &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      keySecret.Name,
+					Namespace: keySecret.Namespace,
+				},
+				Data: map[string][]byte{
+					corev1.TLSCertKey:       []byte("cert"),
+					corev1.TLSPrivateKeyKey: []byte("key"),
+					"ca.crt":                []byte("ca"),
+				},
+			})).Should(Succeed())
+
+			keyDB, _ = createDatabase(namespace.Name, rootSecret.Name, infrav1.MongoDBDatabaseSpec{
+				DatabaseSpec: infrav1.DatabaseSpec{
+					Address: address,
+				},
+				AuthMechanism: infrav1.MongoDBAuthMechanismX509,
+				TLSSecret: &infrav1.TLSSecretReference{
+					Name:      keySecret.Name,
+					Namespace: keySecret.Namespace,
+				},
+			})
+		})
+
+		It("expects ready database", func() {
+			Eventually(databaseCondition(keyDB, infrav1.DatabaseReadyConditionType), timeout, interval).Should(And(
+				HaveField("Reason", infrav1.DatabaseProvisioningSuccessfulReason),
+				HaveField("Status", metav1.ConditionTrue),
+			))
+		})
+
+		It("keeps the mongodb+srv address and uses the client certificate", func() {
+			Expect(connectOptions()).To(And(
+				HaveField("URI", address),
+				HaveField("Username", rootUsername),
+				HaveField("AuthMechanism", string(infrav1.MongoDBAuthMechanismX509)),
+				HaveField("TLSCertificate", []byte("cert")),
+				HaveField("TLSKey", []byte("key")),
+				HaveField("TLSCA", []byte("ca")),
+			))
+		})
+
+		It("reconciles once the TLS secret in the other namespace changes", func() {
+			updateTLSSecret("rotated")
+
+			Eventually(func() []byte {
+				return connectOptions().TLSCertificate
+			}, timeout, interval).Should(Equal([]byte("rotated")))
+		})
+	})
+
+	Describe("User lifecycle", Ordered, func() {
+		var (
+			keyUser  types.NamespacedName
+			dbName   string
+			username string
+		)
+
+		namespace, rootSecret := setupNamespace()
+
+		It("creates user", func() {
+			var keyDB types.NamespacedName
+			keyDB, dbName = createDatabase(namespace.Name, rootSecret.Name, infrav1.MongoDBDatabaseSpec{})
+			username = "user-" + randStringRunes(5)
+			keyUser = createUser(keyDB, username, infrav1.MongoDBUserSpec{
+				Roles: []infrav1.MongoDBUserRole{
+					{Name: "read"},
+					{Name: "clusterMonitor", DB: "admin"},
+				},
+			})
+		})
+
+		It("expects ready user", func() {
+			Eventually(userCondition(keyUser), timeout, interval).Should(And(
+				HaveField("Reason", infrav1.UserProvisioningSuccessfulReason),
+				HaveField("Status", metav1.ConditionTrue),
+			))
+		})
+
+		It("creates the user with its roles", func() {
+			Expect(mongodb.Authenticate(dbName, username, "secret")).To(Succeed())
+
+			user, ok := mongodb.User(dbName, username)
+			Expect(ok).To(BeTrue())
+			Expect(user.Roles).To(ConsistOf(
+				database.Role{Name: "read", Database: dbName},
+				database.Role{Name: "clusterMonitor", Database: "admin"},
+			))
+
+			got := &infrav1.MongoDBUser{}
+			Expect(k8sClient.Get(context.Background(), keyUser, got)).To(Succeed())
+			Expect(got.Status.CredentialsSalt).NotTo(BeEmpty())
+			Expect(got.Status.CredentialsHash).To(MatchRegexp("^[0-9a-f]{64}$"))
+		})
+
+		It("updates the password once the secret changes", func() {
+			Eventually(func() error {
+				secret := &corev1.Secret{}
+				if err := k8sClient.Get(context.Background(), keyUser, secret); err != nil {
+					return err
+				}
+
+				secret.Data["password"] = []byte("new-secret")
+				return k8sClient.Update(context.Background(), secret)
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func() error {
+				return mongodb.Authenticate(dbName, username, "new-secret")
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("drops the user once validUntil passed", func() {
+			mongodb.OpenSession(dbName, username)
+
+			updateUser(keyUser, func(user *infrav1.MongoDBUser) {
+				user.Spec.ValidUntil = &metav1.Time{Time: time.Now().Add(-time.Minute)}
+			})
+
+			Eventually(userCondition(keyUser), timeout, interval).Should(And(
+				HaveField("Reason", infrav1.UserExpiredReason),
+				HaveField("Status", metav1.ConditionFalse),
+			))
+
+			_, ok := mongodb.User(dbName, username)
+			Expect(ok).To(BeFalse())
+			Expect(mongodb.Sessions(dbName, username)).To(BeZero())
+		})
+
+		It("recreates the user once validUntil is removed", func() {
+			updateUser(keyUser, func(user *infrav1.MongoDBUser) {
+				user.Spec.ValidUntil = nil
+			})
+
+			Eventually(userCondition(keyUser), timeout, interval).Should(And(
+				HaveField("Reason", infrav1.UserProvisioningSuccessfulReason),
+				HaveField("Status", metav1.ConditionTrue),
+			))
+			Expect(mongodb.Authenticate(dbName, username, "new-secret")).To(Succeed())
+		})
+
+		It("keeps the finalizer while the user can't be removed", func() {
+			mongodb.FailOn("DropUser", errors.New("not primary"))
+			DeferCleanup(mongodb.FailOn, "DropUser", nil)
+
+			deleteUser(keyUser)
+
+			Eventually(userCondition(keyUser), timeout, interval).Should(And(
+				HaveField("Reason", infrav1.ConnectionFailedReason),
+				HaveField("Message", ContainSubstring("not primary")),
+			))
+			Expect(userGone(keyUser)()).To(BeFalse())
+		})
+
+		It("removes the user on deletion", func() {
+			Eventually(userGone(keyUser), timeout, interval).Should(BeTrue())
+
+			_, ok := mongodb.User(dbName, username)
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Describe("Atlas", Ordered, func() {
+		var (
+			keyDB    types.NamespacedName
+			keyUser  types.NamespacedName
+			username string
+		)
+
+		namespace, rootSecret := setupNamespace()
+
+		It("creates database", func() {
+			keyDB, _ = createDatabase(namespace.Name, rootSecret.Name, infrav1.MongoDBDatabaseSpec{
+				Atlas: &infrav1.MongoDBAtlasSpec{
+					GroupID: groupID,
+					BaseURL: "https://atlas.invalid",
+					AccessList: []infrav1.AtlasAccessListEntry{
+						{CIDRBlock: "10.0.0.0/24", Comment: "office"},
+					},
+				},
+			})
+		})
+
+		It("manages the access list", func() {
+			Eventually(databaseCondition(keyDB, infrav1.AccessListReadyConditionType), timeout, interval).Should(And(
+				HaveField("Reason", infrav1.AccessListSuccessfulReason),
+				HaveField("Status", metav1.ConditionTrue),
+			))
+
+			entry, ok := atlas.AccessListEntry(groupID, "10.0.0.0/24")
+			Expect(ok).To(BeTrue())
+			Expect(entry.Comment).To(Equal("office"))
+		})
+
+		It("creates user which expires within a week", func() {
+			username = "user-" + randStringRunes(5)
+			keyUser = createUser(keyDB, username, infrav1.MongoDBUserSpec{
+				ValidUntil: &metav1.Time{Time: time.Now().Add(48 * time.Hour)},
+			})
+
+			Eventually(userCondition(keyUser), timeout, interval).Should(And(
+				HaveField("Reason", infrav1.UserProvisioningSuccessfulReason),
+				HaveField("Status", metav1.ConditionTrue),
+			))
+
+			user, ok := atlas.User(groupID, "admin", username)
+			Expect(ok).To(BeTrue())
+			Expect(user.Password).To(Equal("secret"))
+			Expect(user.DeleteAfterDate).NotTo(BeNil())
+		})
+
+		It("reports rate limits", func() {
+			atlas.FailOn("SetupUser", database.ErrAtlasRateLimited)
+			DeferCleanup(atlas.FailOn, "SetupUser", nil)
+
+			updateUser(keyUser, func(user *infrav1.MongoDBUser) {
+				user.Spec.Atlas = &infrav1.MongoDBAtlasUserSpec{
+					Labels: []infrav1.MongoDBAtlasLabel{{Key: "team", Value: "payments"}},
+				}
+			})
+
+			Eventually(userCondition(keyUser), timeout, interval).Should(And(
+				HaveField("Reason", infrav1.RateLimitedReason),
+				HaveField("Status", metav1.ConditionFalse),
+			))
+		})
+
+		It("recovers once the rate limit is lifted", func() {
+			Eventually(userCondition(keyUser), timeout, interval).Should(
+				HaveField("Status", metav1.ConditionTrue),
+			)
+
+			user, _ := atlas.User(groupID, "admin", username)
+			Expect(user.Labels).To(ConsistOf(database.AtlasLabel{Key: "team", Value: "payments"}))
+		})
+
+		It("removes the user on deletion", func() {
+			deleteUser(keyUser)
+
+			Eventually(userGone(keyUser), timeout, interval).Should(BeTrue())
+
+			_, ok := atlas.User(groupID, "admin", username)
+			Expect(ok).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,503 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inmemory
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	infrav1 "github.com/doodlescheduling/db-controller/api/v1"
+	"github.com/doodlescheduling/db-controller/internal/database/databasetest"
+	"github.com/doodlescheduling/db-controller/internal/metrics"
+)
+
+var _ = Describe("PostgreSQL", func() {
+	const (
+		timeout  = time.Second * 10
+		interval = time.Millisecond * 250
+	)
+
+	databaseCondition := func(key types.NamespacedName, conditionType string) func() metav1.Condition {
+		return func() metav1.Condition {
+			got := &infrav1.PostgreSQLDatabase{}
+			_ = k8sClient.Get(context.Background(), key, got)
+			if condition := meta.FindStatusCondition(got.Status.Conditions, conditionType); condition != nil {
+				return *condition
+			}
+
+			return metav1.Condition{}
+		}
+	}
+
+	userCondition := func(key types.NamespacedName) func() metav1.Condition {
+		return func() metav1.Condition {
+			got := &infrav1.PostgreSQLUser{}
+			_ = k8sClient.Get(context.Background(), key, got)
+			if condition := meta.FindStatusCondition(got.Status.Conditions, infrav1.UserReadyConditionType); condition != nil {
+				return *condition
+			}
+
+			return metav1.Condition{}
+		}
+	}
+
+	createDatabase := func(namespace, rootSecret string, spec infrav1.PostgreSQLDatabaseSpec) (types.NamespacedName, string) {
+		keyDB := types.NamespacedName{
+			Name:      "postgresqldatabase-" + randStringRunes(5),
+			Namespace: namespace,
+		}
+
+		dbName := "database_" + randStringRunes(5)
+		spec.DatabaseSpec = infrav1.DatabaseSpec{
+			DatabaseName: dbName,
+			RootSecret: &infrav1.SecretReference{
+				Name: rootSecret,
+			},
+		}
+
+		Expect(k8sClient.Create(context.Background(), &infrav1.PostgreSQLDatabase{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      keyDB.Name,
+				Namespace: keyDB.Namespace,
+			},
+			Spec: spec,
+		})).Should(Succeed())
+
+		return keyDB, dbName
+	}
+
+	createUser := func(keyDB types.NamespacedName, username string, spec infrav1.PostgreSQLUserSpec) types.NamespacedName {
+		keyUser := types.NamespacedName{
+			Name:      "postgresqluser-" + randStringRunes(5),
+			Namespace: keyDB.Namespace,
+		}
+
+		createSecret(keyUser.Namespace, keyUser.Name, username, "secret")
+
+		spec.Database = &infrav1.DatabaseReference{
+			Name: keyDB.Name,
+		}
+		spec.Credentials = &infrav1.SecretReference{
+			Name: keyUser.Name,
+		}
+
+		Expect(k8sClient.Create(context.Background(), &infrav1.PostgreSQLUser{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      keyUser.Name,
+				Namespace: keyUser.Namespace,
+			},
+			Spec: spec,
+		})).Should(Succeed())
+
+		return keyUser
+	}
+
+	updateUser := func(key types.NamespacedName, mutate func(user *infrav1.PostgreSQLUser)) {
+		Eventually(func() error {
+			got := &infrav1.PostgreSQLUser{}
+			if err := k8sClient.Get(context.Background(), key, got); err != nil {
+				return err
+			}
+
+			mutate(got)
+			return k8sClient.Update(context.Background(), got)
+		}, timeout, interval).Should(Succeed())
+	}
+
+	deleteUser := func(key types.NamespacedName) {
+		Expect(k8sClient.Delete(context.Background(), &infrav1.PostgreSQLUser{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      key.Name,
+				Namespace: key.Namespace,
+			},
+		})).Should(Succeed())
+	}
+
+	userGone := func(key types.NamespacedName) func() bool {
+		return func() bool {
+			err := k8sClient.Get(context.Background(), key, &infrav1.PostgreSQLUser{})
+			return apierrors.IsNotFound(err)
+		}
+	}
+
+	Describe("Database provisioning", Ordered, func() {
+		var (
+			keyDB  types.NamespacedName
+			dbName string
+		)
+
+		namespace, rootSecret := setupNamespace()
+
+		It("creates database", func() {
+			keyDB, dbName = createDatabase(namespace.Name, rootSecret.Name, infrav1.PostgreSQLDatabaseSpec{
+				Extensions: infrav1.Extensions{{Name: "pgcrypto"}},
+				Schemas:    infrav1.Schemas{{Name: "app"}},
+				SearchPath: infrav1.Schemas{{Name: "app"}, {Name: "public"}},
+			})
+		})
+
+		It("expects ready database", func() {
+			Eventually(databaseCondition(keyDB, infrav1.DatabaseReadyConditionType), timeout, interval).Should(And(
+				HaveField("Reason", infrav1.DatabaseProvisioningSuccessfulReason),
+				HaveField("Status", metav1.ConditionTrue),
+			))
+			Expect(databaseCondition(keyDB, infrav1.ExtensionReadyConditionType)()).To(HaveField("Status", metav1.ConditionTrue))
+			Expect(databaseCondition(keyDB, infrav1.SchemaReadyConditionType)()).To(HaveField("Status", metav1.ConditionTrue))
+		})
+
+		It("creates the database with extensions, schemas and search path", func() {
+			db, ok := postgresql.Database(dbName)
+			Expect(ok).To(BeTrue())
+			Expect(db.Extensions).To(ConsistOf("pgcrypto"))
+			Expect(db.Schemas).To(ConsistOf("public", "app"))
+			Expect(db.SearchPath).To(Equal([]string{"app", "public"}))
+		})
+
+		It("closes all connections", func() {
+			Eventually(postgresql.Connections, timeout, interval).Should(BeZero())
+		})
+	})
+
+	Describe("Extensions on CockroachDB", Ordered, func() {
+		var keyDB types.NamespacedName
+
+		namespace, rootSecret := setupNamespace()
+
+		It("creates database", func() {
+			keyDB, _ = createDatabase(namespace.Name, rootSecret.Name, infrav1.PostgreSQLDatabaseSpec{
+				Flavor:     infrav1.PostgreSQLFlavorCockroachDB,
+				Extensions: infrav1.Extensions{{Name: "pgcrypto"}},
+			})
+		})
+
+		It("skips the extensions but provisions the database", func() {
+			Eventually(databaseCondition(keyDB, infrav1.DatabaseReadyConditionType), timeout, interval).Should(
+				HaveField("Status", metav1.ConditionTrue),
+			)
+			Expect(databaseCondition(keyDB, infrav1.ExtensionReadyConditionType)()).To(And(
+				HaveField("Reason", infrav1.UnsupportedByFlavorReason),
+				HaveField("Status", metav1.ConditionFalse),
+			))
+		})
+	})
+
+	Describe("Database provisioning failures", Ordered, func() {
+		var (
+			keyDB  types.NamespacedName
+			dbName string
+		)
+
+		namespace, rootSecret := setupNamespace()
+
+		It("fails to connect with invalid root credentials", func() {
+			Expect(k8sClient.Create(context.Background(), &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "invalid-" + rootSecret.Name,
+					Namespace: namespace.Name,
+				},
+				Data: map[string][]byte{
+					"username": []byte(rootUsername),
+					"password": []byte("invalid"),
+				},
+			})).Should(Succeed())
+
+			keyInvalid, _ := createDatabase(namespace.Name, "invalid-"+rootSecret.Name, infrav1.PostgreSQLDatabaseSpec{})
+			Eventually(databaseCondition(keyInvalid, infrav1.DatabaseReadyConditionType), timeout, interval).Should(And(
+				HaveField("Reason", infrav1.ConnectionFailedReason),
+				HaveField("Status", metav1.ConditionFalse),
+				HaveField("Message", ContainSubstring("password authentication failed")),
+			))
+
+			Expect(testutil.ToFloat64(metrics.ReconcileTotal.WithLabelValues("PostgreSQLDatabase", infrav1.ConnectionFailedReason))).To(BeNumerically(">", 0))
+		})
+
+		It("reports a failure to create the database", func() {
+			postgresql.FailOn("CreateDatabaseIfNotExists", errors.New("disk full"))
+			DeferCleanup(postgresql.FailOn, "CreateDatabaseIfNotExists", nil)
+
+			keyDB, dbName = createDatabase(namespace.Name, rootSecret.Name, infrav1.PostgreSQLDatabaseSpec{})
+			Eventually(databaseCondition(keyDB, infrav1.DatabaseReadyConditionType), timeout, interval).Should(And(
+				HaveField("Reason", infrav1.CreateDatabaseFailedReason),
+				HaveField("Status", metav1.ConditionFalse),
+				HaveField("Message", ContainSubstring("disk full")),
+			))
+
+			_, ok := postgresql.Database(dbName)
+			Expect(ok).To(BeFalse())
+		})
+
+		It("recovers once the server is healthy again", func() {
+			Eventually(databaseCondition(keyDB, infrav1.DatabaseReadyConditionType), timeout, interval).Should(
+				HaveField("Status", metav1.ConditionTrue),
+			)
+
+			_, ok := postgresql.Database(dbName)
+			Expect(ok).To(BeTrue())
+		})
+	})
+
+	Describe("User lifecycle", Ordered, func() {
+		var (
+			keyDB    types.NamespacedName
+			keyUser  types.NamespacedName
+			dbName   string
+			username string
+		)
+
+		namespace, rootSecret := setupNamespace()
+
+		It("creates user", func() {
+			postgresql.CreateRole("app_read")
+
+			keyDB, dbName = createDatabase(namespace.Name, rootSecret.Name, infrav1.PostgreSQLDatabaseSpec{})
+			username = "user_" + randStringRunes(5)
+			keyUser = createUser(keyDB, username, infrav1.PostgreSQLUserSpec{
+				Roles:      []string{"app_read"},
+				Attributes: []string{"CREATEDB"},
+			})
+		})
+
+		It("expects ready user", func() {
+			Eventually(userCondition(keyUser), timeout, interval).Should(And(
+				HaveField("Reason", infrav1.UserProvisioningSuccessfulReason),
+				HaveField("Status", metav1.ConditionTrue),
+			))
+		})
+
+		It("creates the role with roles, grants and attributes", func() {
+			Expect(postgresql.Authenticate(username, "secret")).To(Succeed())
+
+			role, ok := postgresql.Role(username)
+			Expect(ok).To(BeTrue())
+			Expect(role.MemberOf).To(ConsistOf("app_read"))
+			Expect(role.Attributes).To(ConsistOf("CREATEDB"))
+			Expect(role.Databases).To(ConsistOf(dbName))
+			Expect(role.ValidUntil).To(BeNil())
+			Expect(role.Grants).To(HaveLen(1))
+			Expect(role.Grants[0].Object).To(Equal("SCHEMA"))
+			Expect(role.Grants[0].ObjectName).To(Equal("public"))
+		})
+
+		It("updates the password once the secret changes", func() {
+			Eventually(func() error {
+				secret := &corev1.Secret{}
+				if err := k8sClient.Get(context.Background(), keyUser, secret); err != nil {
+					return err
+				}
+
+				secret.Data["password"] = []byte("new-secret")
+				return k8sClient.Update(context.Background(), secret)
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func() error {
+				return postgresql.Authenticate(username, "new-secret")
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("reports provisioning failures", func() {
+			postgresql.FailOn("SetupUser", errors.New("connection reset by peer"))
+			DeferCleanup(postgresql.FailOn, "SetupUser", nil)
+
+			updateUser(keyUser, func(user *infrav1.PostgreSQLUser) {
+				user.Spec.Attributes = append(user.Spec.Attributes, "NOREPLICATION")
+			})
+
+			Eventually(userCondition(keyUser), timeout, interval).Should(And(
+				HaveField("Reason", infrav1.ConnectionFailedReason),
+				HaveField("Status", metav1.ConditionFalse),
+				HaveField("Message", ContainSubstring("connection reset by peer")),
+			))
+		})
+
+		It("recovers once the server is healthy again", func() {
+			Eventually(userCondition(keyUser), timeout, interval).Should(
+				HaveField("Status", metav1.ConditionTrue),
+			)
+
+			role, _ := postgresql.Role(username)
+			Expect(role.Attributes).To(ConsistOf("CREATEDB", "NOREPLICATION"))
+		})
+
+		It("expires the user once validUntil passed", func() {
+			postgresql.OpenSession(username)
+			postgresql.OpenSession(username)
+
+			updateUser(keyUser, func(user *infrav1.PostgreSQLUser) {
+				user.Spec.ValidUntil = &metav1.Time{Time: time.Now().Add(-time.Minute)}
+			})
+
+			Eventually(userCondition(keyUser), timeout, interval).Should(And(
+				HaveField("Reason", infrav1.UserExpiredReason),
+				HaveField("Status", metav1.ConditionFalse),
+			))
+
+			role, _ := postgresql.Role(username)
+			Expect(role.Login).To(BeFalse())
+			Expect(role.ValidUntil).NotTo(BeNil())
+			Expect(postgresql.Authenticate(username, "new-secret")).NotTo(Succeed())
+			Expect(postgresql.Sessions(username)).To(BeZero())
+			Expect(postgresql.CallsTo("TerminateSessions")).To(ContainElement(HaveField("Username", username)))
+		})
+
+		It("reactivates the user once validUntil is removed", func() {
+			updateUser(keyUser, func(user *infrav1.PostgreSQLUser) {
+				user.Spec.ValidUntil = nil
+			})
+
+			Eventually(userCondition(keyUser), timeout, interval).Should(And(
+				HaveField("Reason", infrav1.UserProvisioningSuccessfulReason),
+				HaveField("Status", metav1.ConditionTrue),
+			))
+			Expect(postgresql.Authenticate(username, "new-secret")).To(Succeed())
+		})
+
+		It("disables the user on deletion", func() {
+			postgresql.OpenSession(username)
+			deleteUser(keyUser)
+
+			Eventually(userGone(keyUser), timeout, interval).Should(BeTrue())
+
+			role, ok := postgresql.Role(username)
+			Expect(ok).To(BeTrue())
+			Expect(role.Login).To(BeFalse())
+			Expect(role.Databases).NotTo(ContainElement(dbName))
+			Expect(postgresql.Authenticate(username, "new-secret")).NotTo(Succeed())
+			Expect(postgresql.Sessions(username)).To(BeZero())
+		})
+	})
+
+	Describe("User which expires while provisioned", Ordered, func() {
+		var (
+			keyUser  types.NamespacedName
+			username string
+		)
+
+		namespace, rootSecret := setupNamespace()
+
+		It("creates user", func() {
+			keyDB, _ := createDatabase(namespace.Name, rootSecret.Name, infrav1.PostgreSQLDatabaseSpec{})
+			Eventually(databaseCondition(keyDB, infrav1.DatabaseReadyConditionType), timeout, interval).Should(
+				HaveField("Status", metav1.ConditionTrue),
+			)
+
+			username = "user_" + randStringRunes(5)
+			keyUser = createUser(keyDB, username, infrav1.PostgreSQLUserSpec{
+				ValidUntil: &metav1.Time{Time: time.Now().Add(5 * time.Second)},
+			})
+		})
+
+		It("provisions the user with a password expiry", func() {
+			Eventually(userCondition(keyUser), timeout, interval).Should(And(
+				HaveField("Reason", infrav1.UserProvisioningSuccessfulReason),
+				HaveField("Status", metav1.ConditionTrue),
+			))
+
+			role, _ := postgresql.Role(username)
+			Expect(role.ValidUntil).NotTo(BeNil())
+		})
+
+		It("expires the user without a spec change", func() {
+			Eventually(userCondition(keyUser), timeout, interval).Should(
+				HaveField("Reason", infrav1.UserExpiredReason),
+			)
+
+			role, _ := postgresql.Role(username)
+			Expect(role.Login).To(BeFalse())
+		})
+	})
+
+	Describe("User with drop deletion policy", Ordered, func() {
+		var (
+			keyUser  types.NamespacedName
+			dbName   string
+			username string
+		)
+
+		namespace, rootSecret := setupNamespace()
+
+		It("creates user", func() {
+			var keyDB types.NamespacedName
+			keyDB, dbName = createDatabase(namespace.Name, rootSecret.Name, infrav1.PostgreSQLDatabaseSpec{})
+			username = "user_" + randStringRunes(5)
+			keyUser = createUser(keyDB, username, infrav1.PostgreSQLUserSpec{
+				DeletionPolicy:  infrav1.DeletionPolicyDrop,
+				ReassignOwnedTo: rootUsername,
+			})
+
+			Eventually(userCondition(keyUser), timeout, interval).Should(
+				HaveField("Status", metav1.ConditionTrue),
+			)
+		})
+
+		It("keeps the finalizer while the drop fails", func() {
+			postgresql.FailOn("DropUser", errors.New("role is in use"))
+			DeferCleanup(postgresql.FailOn, "DropUser", nil)
+
+			deleteUser(keyUser)
+
+			Eventually(userCondition(keyUser), timeout, interval).Should(And(
+				HaveField("Reason", infrav1.ConnectionFailedReason),
+				HaveField("Message", ContainSubstring("role is in use")),
+			))
+			Expect(userGone(keyUser)()).To(BeFalse())
+		})
+
+		It("drops the user and reassigns its objects", func() {
+			Eventually(userGone(keyUser), timeout, interval).Should(BeTrue())
+
+			_, ok := postgresql.Role(username)
+			Expect(ok).To(BeFalse())
+			Expect(postgresql.CallsTo("DropUser")).To(ContainElement(databasetest.Call{
+				Method:   "DropUser",
+				Database: dbName,
+				Username: username,
+				Name:     rootUsername,
+			}))
+		})
+	})
+
+	Describe("User with an unknown role", Ordered, func() {
+		var keyUser types.NamespacedName
+
+		namespace, rootSecret := setupNamespace()
+
+		It("creates user", func() {
+			keyDB, _ := createDatabase(namespace.Name, rootSecret.Name, infrav1.PostgreSQLDatabaseSpec{})
+			keyUser = createUser(keyDB, "user_"+randStringRunes(5), infrav1.PostgreSQLUserSpec{
+				Roles: []string{"does-not-exist"},
+			})
+		})
+
+		It("fails to provision the user", func() {
+			Eventually(userCondition(keyUser), timeout, interval).Should(And(
+				HaveField("Reason", infrav1.ConnectionFailedReason),
+				HaveField("Status", metav1.ConditionFalse),
+				HaveField("Message", ContainSubstring(`role "does-not-exist" does not exist`)),
+			))
+		})
+	})
+})
@@ -0,0 +1,139 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inmemory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	infrav1 "github.com/doodlescheduling/db-controller/api/v1"
+	"github.com/doodlescheduling/db-controller/internal/controllers"
+	"github.com/doodlescheduling/db-controller/internal/database/databasetest"
+	"github.com/doodlescheduling/db-controller/internal/metrics"
+)
+
+var _ = Describe("ServerChecker", Ordered, func() {
+	var checker *controllers.ServerChecker
+	address := "postgres-" + randStringRunes(5) + ":5432"
+
+	namespace, rootSecret := setupNamespace()
+
+	serverStatus := func() controllers.ServerStatus {
+		for _, s := range checker.Servers() {
+			if s.Engine == "PostgreSQL" && s.Address == address {
+				return s
+			}
+		}
+
+		return controllers.ServerStatus{}
+	}
+
+	BeforeAll(func() {
+		checker = &controllers.ServerChecker{
+			Client:        k8sClient,
+			Log:           ctrl.Log.WithName("servers"),
+			Provisioners:  registry,
+			Timeout:       5 * time.Second,
+			FailReadiness: true,
+		}
+
+		for _, name := range []string{"first", "second"} {
+			Expect(k8sClient.Create(context.Background(), &infrav1.PostgreSQLDatabase{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: namespace.Name,
+				},
+				Spec: infrav1.PostgreSQLDatabaseSpec{
+					DatabaseSpec: infrav1.DatabaseSpec{
+						Address: address,
+						RootSecret: &infrav1.SecretReference{
+							Name: rootSecret.Name,
+						},
+					},
+				},
+			})).Should(Succeed())
+		}
+	})
+
+	It("fails readiness before the first check", func() {
+		Expect(checker.Check(nil)).To(MatchError(ContainSubstring("not been checked")))
+	})
+
+	It("reports a server referenced by multiple databases once", func() {
+		checker.CheckServers(context.Background())
+
+		Expect(serverStatus()).To(And(
+			HaveField("Reachable", true),
+			HaveField("Error", BeEmpty()),
+			HaveField("References", ConsistOf(
+				"PostgreSQLDatabase/"+namespace.Name+"/first",
+				"PostgreSQLDatabase/"+namespace.Name+"/second",
+			)),
+		))
+		Expect(testutil.ToFloat64(metrics.ServerUp.WithLabelValues("PostgreSQL", address))).To(Equal(float64(1)))
+	})
+
+	It("reports an unreachable server and fails readiness", func() {
+		postgresql.FailOn(databasetest.Connect, errors.New("connection refused"))
+		DeferCleanup(postgresql.FailOn, databasetest.Connect, nil)
+
+		failures := testutil.ToFloat64(metrics.ConnectionFailuresTotal.WithLabelValues("PostgreSQL", metrics.ServerAddress(address)))
+		checker.CheckServers(context.Background())
+
+		Expect(serverStatus()).To(And(
+			HaveField("Reachable", false),
+			HaveField("Error", ContainSubstring("connection refused")),
+		))
+		Expect(testutil.ToFloat64(metrics.ServerUp.WithLabelValues("PostgreSQL", address))).To(Equal(float64(0)))
+		Expect(checker.Check(nil)).To(MatchError(ContainSubstring(address)))
+
+		// The checks are not reported as connection failures of the controllers
+		Expect(testutil.ToFloat64(metrics.ConnectionFailuresTotal.WithLabelValues("PostgreSQL", metrics.ServerAddress(address)))).To(Equal(failures))
+	})
+
+	It("does not fail readiness if disabled", func() {
+		checker.FailReadiness = false
+		DeferCleanup(func() { checker.FailReadiness = true })
+
+		Expect(checker.Check(nil)).To(Succeed())
+	})
+
+	It("serves the status as JSON", func() {
+		rec := httptest.NewRecorder()
+		checker.ServeHTTP(rec, httptest.NewRequest("GET", "/servers", nil))
+
+		var body struct {
+			Servers []controllers.ServerStatus `json:"servers"`
+		}
+
+		Expect(json.Unmarshal(rec.Body.Bytes(), &body)).To(Succeed())
+		Expect(body.Servers).To(ContainElement(And(
+			HaveField("Engine", "PostgreSQL"),
+			HaveField("Address", address),
+			HaveField("Reachable", false),
+		)))
+	})
+})
@@ -0,0 +1,217 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package inmemory runs the PostgreSQL and MongoDB controllers against the in-memory provisioners of
+// databasetest. Only envtest is required, no database containers are started.
+package inmemory
+
+import (
+	"context"
+	"math/rand"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	infrav1 "github.com/doodlescheduling/db-controller/api/v1"
+	"github.com/doodlescheduling/db-controller/internal/controllers"
+	"github.com/doodlescheduling/db-controller/internal/database"
+	"github.com/doodlescheduling/db-controller/internal/database/databasetest"
+)
+
+const (
+	rootUsername = "root"
+	rootPassword = "password"
+)
+
+var (
+	cfg       *rest.Config
+	k8sClient client.Client
+	testEnv   *envtest.Environment
+	ctx       context.Context
+	cancel    context.CancelFunc
+
+	// The fakes accept the credentials of the root secret created by setupNamespace()
+	postgresql = databasetest.NewPostgreSQLServer(rootUsername, rootPassword)
+	mongodb    = databasetest.NewMongoDBServer(rootUsername, rootPassword)
+	atlas      = databasetest.NewAtlasServer(rootUsername, rootPassword)
+
+	// registry holds the fakes, it is shared by the controllers and the server checker
+	registry *database.Registry
+)
+
+func TestAPIs(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "In-memory Controller Suite")
+}
+
+var _ = BeforeSuite(func() {
+	logf.SetLogger(zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true)))
+
+	ctx, cancel = context.WithCancel(context.TODO())
+
+	By("bootstrapping test environment")
+	testEnv = &envtest.Environment{
+		CRDDirectoryPaths:     []string{filepath.Join("..", "..", "..", "config", "base", "crd", "bases")},
+		ErrorIfCRDPathMissing: true,
+	}
+
+	var err error
+	cfg, err = testEnv.Start()
+	Expect(err).NotTo(HaveOccurred())
+	Expect(cfg).NotTo(BeNil())
+
+	err = infrav1.AddToScheme(scheme.Scheme)
+	Expect(err).NotTo(HaveOccurred())
+
+	k8sClient, err = client.New(cfg, client.Options{Scheme: scheme.Scheme})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(k8sClient).NotTo(BeNil())
+
+	k8sManager, err := ctrl.NewManager(cfg, ctrl.Options{
+		Scheme: scheme.Scheme,
+	})
+	Expect(err).ToNot(HaveOccurred())
+
+	registry = database.NewRegistry()
+	postgresql.Register(registry)
+	mongodb.Register(registry)
+	atlas.Register(registry)
+
+	// MongoDBDatabase setup
+	err = (&controllers.MongoDBDatabaseReconciler{
+		Client:       k8sManager.GetClient(),
+		Log:          ctrl.Log.WithName("controllers").WithName("MongoDBDatabase"),
+		Scheme:       k8sManager.GetScheme(),
+		Recorder:     k8sManager.GetEventRecorder("MongoDBDatabase"),
+		Provisioners: registry,
+	}).SetupWithManager(k8sManager, 1)
+	Expect(err).ToNot(HaveOccurred(), "failed to setup MongoDBDatabase")
+
+	// MongoDBUser setup
+	err = (&controllers.MongoDBUserReconciler{
+		Client:       k8sManager.GetClient(),
+		Log:          ctrl.Log.WithName("controllers").WithName("MongoDBUser"),
+		Scheme:       k8sManager.GetScheme(),
+		Recorder:     k8sManager.GetEventRecorder("MongoDBUser"),
+		Provisioners: registry,
+	}).SetupWithManager(k8sManager, 1)
+	Expect(err).ToNot(HaveOccurred(), "failed to setup MongoDBUser")
+
+	// PostgreSQLDatabase setup
+	err = (&controllers.PostgreSQLDatabaseReconciler{
+		Client:       k8sManager.GetClient(),
+		Log:          ctrl.Log.WithName("controllers").WithName("PostgreSQLDatabase"),
+		Scheme:       k8sManager.GetScheme(),
+		Recorder:     k8sManager.GetEventRecorder("PostgreSQLDatabase"),
+		Provisioners: registry,
+	}).SetupWithManager(k8sManager, 1)
+	Expect(err).ToNot(HaveOccurred(), "failed to setup PostgreSQLDatabase")
+
+	// PostgreSQLUser setup
+	err = (&controllers.PostgreSQLUserReconciler{
+		Client:       k8sManager.GetClient(),
+		Log:          ctrl.Log.WithName("controllers").WithName("PostgreSQLUser"),
+		Scheme:       k8sManager.GetScheme(),
+		Recorder:     k8sManager.GetEventRecorder("PostgreSQLUser"),
+		Provisioners: registry,
+	}).SetupWithManager(k8sManager, 1)
+	Expect(err).ToNot(HaveOccurred(), "failed to setup PostgreSQLUser")
+
+	go func() {
+		defer GinkgoRecover()
+		err = k8sManager.Start(ctx)
+		Expect(err).ToNot(HaveOccurred(), "failed to run manager")
+	}()
+})
+
+var _ = AfterSuite(func() {
+	cancel()
+	By("tearing down the test environment")
+	err := testEnv.Stop()
+	Expect(err).NotTo(HaveOccurred())
+})
+
+var letterRunes = []rune("abcdefghijklmnopqrstuvwxyz1234567890")
+
+func randStringRunes(n int) string {
+	b := make([]rune, n)
+	for i := range b {
+		b[i] = letterRunes[rand.Intn(len(letterRunes))]
+	}
+	return string(b)
+}
+
+func setupNamespace() (*v1.Namespace, *v1.Secret) {
+	const (
+		timeout  = time.Second * 10
+		interval = time.Second * 1
+	)
+
+	namespace := &v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "ns-" + randStringRunes(5)},
+	}
+
+	rootSecret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "secret-" + randStringRunes(5),
+			Namespace: namespace.Name,
+		},
+		Data: map[string][]byte{
+			"username": []byte(rootUsername),
+			"password": []byte(rootPassword),
+		},
+	}
+
+	BeforeAll(func() {
+		Expect(k8sClient.Create(context.Background(), namespace)).Should(Succeed(), "failed to create test namespace")
+		Expect(k8sClient.Create(context.Background(), rootSecret)).Should(Succeed())
+	})
+
+	AfterAll(func() {
+		Eventually(func() error {
+			return k8sClient.Delete(context.Background(), namespace)
+		}, timeout, interval).Should(Succeed(), "failed to delete test namespace")
+	})
+
+	return namespace, rootSecret
+}
+
+// createSecret creates a secret holding the credentials of a user
+func createSecret(namespace, name, username, password string) {
+	Expect(k8sClient.Create(context.Background(), &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Data: map[string][]byte{
+			"username": []byte(username),
+			"password": []byte(password),
+		},
+	})).Should(Succeed())
+}
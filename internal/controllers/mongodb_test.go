@@ -14,8 +14,17 @@ limitations under the License.
 package controllers
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
+	"math/big"
+	"net"
 	"strings"
 	"time"
 
@@ -29,9 +38,10 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/readpref"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 
-	infrav1beta1 "github.com/doodlescheduling/db-controller/api/v1beta1"
+	infrav1 "github.com/doodlescheduling/db-controller/api/v1"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -78,6 +88,140 @@ func setupMongoDBContainer(ctx context.Context, image string) (*mongodbContainer
 	return &mongodbContainer{Container: container, URI: uri}, nil
 }
 
+// mongodbCertificates holds a CA, a server certificate signed by it for localhost and a client certificate
+type mongodbCertificates struct {
+	CA                []byte
+	ServerKeyPair     []byte
+	ClientCertificate []byte
+	ClientKey         []byte
+	ClientSubject     string
+}
+
+func newMongoDBCertificates() (*mongodbCertificates, error) {
+	caKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "db-controller-test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return nil, err
+	}
+
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		return nil, err
+	}
+
+	issue := func(serial int64, template *x509.Certificate) ([]byte, []byte, error) {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		template.SerialNumber = big.NewInt(serial)
+		template.NotBefore = caTemplate.NotBefore
+		template.NotAfter = caTemplate.NotAfter
+		template.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+
+		der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), nil
+	}
+
+	serverCert, serverKey, err := issue(2, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "localhost", Organization: []string{"db-controller-server"}},
+		DNSNames:    []string{"localhost"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	clientSubject := pkix.Name{CommonName: "root", OrganizationalUnit: []string{"db-controller"}, Organization: []string{"db-controller-client"}}
+	clientCert, clientKey, err := issue(3, &x509.Certificate{
+		Subject:     clientSubject,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &mongodbCertificates{
+		CA:                pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}),
+		ServerKeyPair:     append(serverCert, serverKey...),
+		ClientCertificate: clientCert,
+		ClientKey:         clientKey,
+		ClientSubject:     clientSubject.String(),
+	}, nil
+}
+
+// setupMongoDBTLSContainer starts a MongoDB server which requires TLS, clients may authenticate using a password
+// or a client certificate signed by the CA
+func setupMongoDBTLSContainer(ctx context.Context, image string, certs *mongodbCertificates) (*mongodbContainer, error) {
+	req := testcontainers.ContainerRequest{
+		Image:        image,
+		ExposedPorts: []string{"27017/tcp"},
+		WaitingFor: wait.ForListeningPort("27017/tcp").
+			WithStartupTimeout(60 * time.Second),
+		Env: map[string]string{
+			"MONGO_INITDB_ROOT_USERNAME": "root",
+			"MONGO_INITDB_ROOT_PASSWORD": "password",
+		},
+		Tmpfs: map[string]string{
+			"/data/db": "",
+		},
+		Files: []testcontainers.ContainerFile{
+			{Reader: bytes.NewReader(certs.ServerKeyPair), ContainerFilePath: "/etc/mongo/server.pem", FileMode: 0o644},
+			{Reader: bytes.NewReader(certs.CA), ContainerFilePath: "/etc/mongo/ca.pem", FileMode: 0o644},
+		},
+		Cmd: []string{
+			"--tlsMode", "requireTLS",
+			"--tlsCertificateKeyFile", "/etc/mongo/server.pem",
+			"--tlsCAFile", "/etc/mongo/ca.pem",
+			"--tlsAllowConnectionsWithoutCertificates",
+		},
+	}
+
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	port, err := container.MappedPort(ctx, "27017/tcp")
+	if err != nil {
+		return nil, err
+	}
+
+	// The server certificate is only valid for localhost
+	uri := fmt.Sprintf("mongodb://localhost:%s", port.Port())
+
+	return &mongodbContainer{Container: container, URI: uri}, nil
+}
+
 var _ = Describe("MongoDB", func() {
 	const (
 		timeout  = time.Second * 10
@@ -96,7 +240,7 @@ var _ = Describe("MongoDB", func() {
 
 			Describe("fails if database not found", Ordered, func() {
 				var (
-					createdUser *infrav1beta1.MongoDBUser
+					createdUser *infrav1.MongoDBUser
 					keyUser     types.NamespacedName
 				)
 
@@ -107,16 +251,16 @@ var _ = Describe("MongoDB", func() {
 						Name:      "mongodbuser-" + randStringRunes(5),
 						Namespace: namespace.Name,
 					}
-					createdUser = &infrav1beta1.MongoDBUser{
+					createdUser = &infrav1.MongoDBUser{
 						ObjectMeta: metav1.ObjectMeta{
 							Name:      keyUser.Name,
 							Namespace: keyUser.Namespace,
 						},
-						Spec: infrav1beta1.MongoDBUserSpec{
-							Database: &infrav1beta1.DatabaseReference{
+						Spec: infrav1.MongoDBUserSpec{
+							Database: &infrav1.DatabaseReference{
 								Name: "does-not-exist",
 							},
-							Credentials: &infrav1beta1.SecretReference{
+							Credentials: &infrav1.SecretReference{
 								Name: "does-not-exist",
 							},
 						},
@@ -126,22 +270,22 @@ var _ = Describe("MongoDB", func() {
 				})
 
 				It("fails reconcile because there is no database", func() {
-					got := &infrav1beta1.MongoDBUser{}
+					got := &infrav1.MongoDBUser{}
 					Eventually(func() bool {
 						_ = k8sClient.Get(context.Background(), keyUser, got)
 
 						return len(got.Status.Conditions) == 1 &&
-							got.Status.Conditions[0].Reason == infrav1beta1.DatabaseNotFoundReason &&
+							got.Status.Conditions[0].Reason == infrav1.DatabaseNotFoundReason &&
 							got.Status.Conditions[0].Status == "False" &&
-							got.Status.Conditions[0].Type == infrav1beta1.UserReadyConditionType
+							got.Status.Conditions[0].Type == infrav1.UserReadyConditionType
 					}, timeout, interval).Should(BeTrue())
 				})
 			})
 
 			Describe("fails if datatabase root secret not found", Ordered, func() {
 				var (
-					createdDB   *infrav1beta1.MongoDBDatabase
-					createdUser *infrav1beta1.MongoDBUser
+					createdDB   *infrav1.MongoDBDatabase
+					createdUser *infrav1.MongoDBUser
 					keyUser     types.NamespacedName
 					keyDB       types.NamespacedName
 				)
@@ -153,14 +297,14 @@ var _ = Describe("MongoDB", func() {
 						Name:      "mongodbdatabase-" + randStringRunes(5),
 						Namespace: namespace.Name,
 					}
-					createdDB = &infrav1beta1.MongoDBDatabase{
+					createdDB = &infrav1.MongoDBDatabase{
 						ObjectMeta: metav1.ObjectMeta{
 							Name:      keyDB.Name,
 							Namespace: keyDB.Namespace,
 						},
-						Spec: infrav1beta1.MongoDBDatabaseSpec{
-							DatabaseSpec: &infrav1beta1.DatabaseSpec{
-								RootSecret: &infrav1beta1.SecretReference{
+						Spec: infrav1.MongoDBDatabaseSpec{
+							DatabaseSpec: infrav1.DatabaseSpec{
+								RootSecret: &infrav1.SecretReference{
 									Name: "does-not-exist",
 								},
 							},
@@ -174,16 +318,16 @@ var _ = Describe("MongoDB", func() {
 						Name:      "mongodbuser-" + randStringRunes(5),
 						Namespace: namespace.Name,
 					}
-					createdUser = &infrav1beta1.MongoDBUser{
+					createdUser = &infrav1.MongoDBUser{
 						ObjectMeta: metav1.ObjectMeta{
 							Name:      keyUser.Name,
 							Namespace: keyUser.Namespace,
 						},
-						Spec: infrav1beta1.MongoDBUserSpec{
-							Database: &infrav1beta1.DatabaseReference{
+						Spec: infrav1.MongoDBUserSpec{
+							Database: &infrav1.DatabaseReference{
 								Name: keyDB.Name,
 							},
-							Credentials: &infrav1beta1.SecretReference{
+							Credentials: &infrav1.SecretReference{
 								Name: "does-not-exist",
 							},
 						},
@@ -192,14 +336,14 @@ var _ = Describe("MongoDB", func() {
 				})
 
 				It("fails reconcile because the db root secret is not found", func() {
-					got := &infrav1beta1.MongoDBUser{}
+					got := &infrav1.MongoDBUser{}
 					Eventually(func() bool {
 						_ = k8sClient.Get(context.Background(), keyUser, got)
 
 						return len(got.Status.Conditions) == 1 &&
-							got.Status.Conditions[0].Reason == infrav1beta1.CredentialsNotFoundReason &&
+							got.Status.Conditions[0].Reason == infrav1.CredentialsNotFoundReason &&
 							got.Status.Conditions[0].Status == "False" &&
-							got.Status.Conditions[0].Type == infrav1beta1.UserReadyConditionType
+							got.Status.Conditions[0].Type == infrav1.UserReadyConditionType
 
 					}, timeout, interval).Should(BeTrue())
 				})
@@ -207,8 +351,8 @@ var _ = Describe("MongoDB", func() {
 
 			Describe("fails if user secret is not found", Ordered, func() {
 				var (
-					createdDB   *infrav1beta1.MongoDBDatabase
-					createdUser *infrav1beta1.MongoDBUser
+					createdDB   *infrav1.MongoDBDatabase
+					createdUser *infrav1.MongoDBUser
 					keyUser     types.NamespacedName
 					keyDB       types.NamespacedName
 				)
@@ -220,15 +364,15 @@ var _ = Describe("MongoDB", func() {
 						Name:      "mongodbdatabase-" + randStringRunes(5),
 						Namespace: namespace.Name,
 					}
-					createdDB = &infrav1beta1.MongoDBDatabase{
+					createdDB = &infrav1.MongoDBDatabase{
 						ObjectMeta: metav1.ObjectMeta{
 							Name:      keyDB.Name,
 							Namespace: keyDB.Namespace,
 						},
-						Spec: infrav1beta1.MongoDBDatabaseSpec{
-							DatabaseSpec: &infrav1beta1.DatabaseSpec{
+						Spec: infrav1.MongoDBDatabaseSpec{
+							DatabaseSpec: infrav1.DatabaseSpec{
 								Address: "mongodb://does-not-exist:27017",
-								RootSecret: &infrav1beta1.SecretReference{
+								RootSecret: &infrav1.SecretReference{
 									Name: rootSecret.Name,
 								},
 							},
@@ -242,16 +386,16 @@ var _ = Describe("MongoDB", func() {
 						Name:      "mongodbuser-" + randStringRunes(5),
 						Namespace: namespace.Name,
 					}
-					createdUser = &infrav1beta1.MongoDBUser{
+					createdUser = &infrav1.MongoDBUser{
 						ObjectMeta: metav1.ObjectMeta{
 							Name:      keyUser.Name,
 							Namespace: keyUser.Namespace,
 						},
-						Spec: infrav1beta1.MongoDBUserSpec{
-							Database: &infrav1beta1.DatabaseReference{
+						Spec: infrav1.MongoDBUserSpec{
+							Database: &infrav1.DatabaseReference{
 								Name: keyDB.Name,
 							},
-							Credentials: &infrav1beta1.SecretReference{
+							Credentials: &infrav1.SecretReference{
 								Name: "does-not-exist",
 							},
 						},
@@ -260,13 +404,13 @@ var _ = Describe("MongoDB", func() {
 				})
 
 				It("expects reconcile to fail because the user secret is not found", func() {
-					got := &infrav1beta1.MongoDBUser{}
+					got := &infrav1.MongoDBUser{}
 					Eventually(func() bool {
 						_ = k8sClient.Get(context.Background(), keyUser, got)
 						return len(got.Status.Conditions) == 1 &&
-							got.Status.Conditions[0].Reason == infrav1beta1.CredentialsNotFoundReason &&
+							got.Status.Conditions[0].Reason == infrav1.CredentialsNotFoundReason &&
 							got.Status.Conditions[0].Status == "False" &&
-							got.Status.Conditions[0].Type == infrav1beta1.UserReadyConditionType
+							got.Status.Conditions[0].Type == infrav1.UserReadyConditionType
 
 					}, timeout, interval).Should(BeTrue())
 				})
@@ -274,8 +418,8 @@ var _ = Describe("MongoDB", func() {
 
 			Describe("fails if database can't be reached", Ordered, func() {
 				var (
-					createdDB     *infrav1beta1.MongoDBDatabase
-					createdUser   *infrav1beta1.MongoDBUser
+					createdDB     *infrav1.MongoDBDatabase
+					createdUser   *infrav1.MongoDBUser
 					keyUser       types.NamespacedName
 					keyDB         types.NamespacedName
 					keySecret     types.NamespacedName
@@ -290,18 +434,18 @@ var _ = Describe("MongoDB", func() {
 						Name:      "mongodbdatabase-" + randStringRunes(5),
 						Namespace: namespace.Name,
 					}
-					createdDB = &infrav1beta1.MongoDBDatabase{
+					createdDB = &infrav1.MongoDBDatabase{
 						ObjectMeta: metav1.ObjectMeta{
 							Name:      keyDB.Name,
 							Namespace: keyDB.Namespace,
 						},
-						Spec: infrav1beta1.MongoDBDatabaseSpec{
-							DatabaseSpec: &infrav1beta1.DatabaseSpec{
+						Spec: infrav1.MongoDBDatabaseSpec{
+							DatabaseSpec: infrav1.DatabaseSpec{
 								Timeout: &metav1.Duration{
 									Duration: time.Millisecond * 100,
 								},
 								Address: container.URI,
-								RootSecret: &infrav1beta1.SecretReference{
+								RootSecret: &infrav1.SecretReference{
 									Name: rootSecret.Name,
 								},
 							},
@@ -334,16 +478,16 @@ var _ = Describe("MongoDB", func() {
 						Name:      "mongodbuser-" + randStringRunes(5),
 						Namespace: namespace.Name,
 					}
-					createdUser = &infrav1beta1.MongoDBUser{
+					createdUser = &infrav1.MongoDBUser{
 						ObjectMeta: metav1.ObjectMeta{
 							Name:      keyUser.Name,
 							Namespace: keyUser.Namespace,
 						},
-						Spec: infrav1beta1.MongoDBUserSpec{
-							Database: &infrav1beta1.DatabaseReference{
+						Spec: infrav1.MongoDBUserSpec{
+							Database: &infrav1.DatabaseReference{
 								Name: keyDB.Name,
 							},
-							Credentials: &infrav1beta1.SecretReference{
+							Credentials: &infrav1.SecretReference{
 								Name: keySecret.Name,
 							},
 						},
@@ -352,21 +496,21 @@ var _ = Describe("MongoDB", func() {
 				})
 
 				It("fails reconcile because user credentials are not found", func() {
-					got := &infrav1beta1.MongoDBUser{}
+					got := &infrav1.MongoDBUser{}
 					Eventually(func() bool {
 						_ = k8sClient.Get(context.Background(), keyUser, got)
 						return len(got.Status.Conditions) == 1 &&
-							got.Status.Conditions[0].Reason == infrav1beta1.ConnectionFailedReason &&
+							got.Status.Conditions[0].Reason == infrav1.ConnectionFailedReason &&
 							got.Status.Conditions[0].Status == "False" &&
-							got.Status.Conditions[0].Type == infrav1beta1.UserReadyConditionType
+							got.Status.Conditions[0].Type == infrav1.UserReadyConditionType
 					}, timeout, interval).Should(BeTrue())
 				})
 			})
 
 			Describe("fails if user secret exists but fields not found", Ordered, func() {
 				var (
-					createdDB     *infrav1beta1.MongoDBDatabase
-					createdUser   *infrav1beta1.MongoDBUser
+					createdDB     *infrav1.MongoDBDatabase
+					createdUser   *infrav1.MongoDBUser
 					keyUser       types.NamespacedName
 					keyDB         types.NamespacedName
 					createdSecret *corev1.Secret
@@ -381,18 +525,18 @@ var _ = Describe("MongoDB", func() {
 						Name:      "mongodbdatabase-" + randStringRunes(5),
 						Namespace: namespace.Name,
 					}
-					createdDB = &infrav1beta1.MongoDBDatabase{
+					createdDB = &infrav1.MongoDBDatabase{
 						ObjectMeta: metav1.ObjectMeta{
 							Name:      keyDB.Name,
 							Namespace: keyDB.Namespace,
 						},
-						Spec: infrav1beta1.MongoDBDatabaseSpec{
-							DatabaseSpec: &infrav1beta1.DatabaseSpec{
+						Spec: infrav1.MongoDBDatabaseSpec{
+							DatabaseSpec: infrav1.DatabaseSpec{
 								Timeout: &metav1.Duration{
 									Duration: time.Millisecond * 100,
 								},
 								Address: container.URI,
-								RootSecret: &infrav1beta1.SecretReference{
+								RootSecret: &infrav1.SecretReference{
 									Name: rootSecret.Name,
 								},
 							},
@@ -425,16 +569,16 @@ var _ = Describe("MongoDB", func() {
 				})
 
 				It("adds user", func() {
-					createdUser = &infrav1beta1.MongoDBUser{
+					createdUser = &infrav1.MongoDBUser{
 						ObjectMeta: metav1.ObjectMeta{
 							Name:      keyUser.Name,
 							Namespace: keyUser.Namespace,
 						},
-						Spec: infrav1beta1.MongoDBUserSpec{
-							Database: &infrav1beta1.DatabaseReference{
+						Spec: infrav1.MongoDBUserSpec{
+							Database: &infrav1.DatabaseReference{
 								Name: keyDB.Name,
 							},
-							Credentials: &infrav1beta1.SecretReference{
+							Credentials: &infrav1.SecretReference{
 								Name:      keySecret.Name,
 								UserField: "does-not-exist",
 							},
@@ -444,23 +588,732 @@ var _ = Describe("MongoDB", func() {
 				})
 
 				It("fails reconcile because user field in secret is not found", func() {
-					got := &infrav1beta1.MongoDBUser{}
+					got := &infrav1.MongoDBUser{}
 					Eventually(func() bool {
 						_ = k8sClient.Get(context.Background(), keyUser, got)
 						return len(got.Status.Conditions) == 1 &&
-							got.Status.Conditions[0].Reason == infrav1beta1.CredentialsNotFoundReason &&
+							got.Status.Conditions[0].Reason == infrav1.CredentialsNotFoundReason &&
 							got.Status.Conditions[0].Status == "False" &&
 							strings.Contains(got.Status.Conditions[0].Message, "credentials field not found in referenced secret:") &&
-							got.Status.Conditions[0].Type == infrav1beta1.UserReadyConditionType
+							got.Status.Conditions[0].Type == infrav1.UserReadyConditionType
 
 					}, timeout, interval).Should(BeTrue())
 				})
 			})
 
+			Describe("Seeds database", Ordered, func() {
+				var (
+					createdDB *infrav1.MongoDBDatabase
+					keyDB     types.NamespacedName
+					client    *mongo.Client
+				)
+
+				namespace, rootSecret := setupNamespace()
+
+				It("adds seed configmaps", func() {
+					Expect(k8sClient.Create(context.Background(), &corev1.ConfigMap{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "flags",
+							Namespace: namespace.Name,
+						},
+						Data: map[string]string{
+							"01-flags.json": `[{"_id": "feature-a", "enabled": true}, {"_id": "feature-b", "enabled": false}]`,
+						},
+					})).Should(Succeed())
+
+					Expect(k8sClient.Create(context.Background(), &corev1.ConfigMap{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "views",
+							Namespace: namespace.Name,
+						},
+						Data: map[string]string{
+							"01-view.json": `{"create": "enabledFlags", "viewOn": "flags", "pipeline": [{"$match": {"enabled": true}}]}`,
+						},
+					})).Should(Succeed())
+				})
+
+				It("adds database", func() {
+					keyDB = types.NamespacedName{
+						Name:      "mongodbdatabase-" + randStringRunes(5),
+						Namespace: namespace.Name,
+					}
+					createdDB = &infrav1.MongoDBDatabase{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      keyDB.Name,
+							Namespace: keyDB.Namespace,
+						},
+						Spec: infrav1.MongoDBDatabaseSpec{
+							DatabaseSpec: infrav1.DatabaseSpec{
+								Address: container.URI,
+								RootSecret: &infrav1.SecretReference{
+									Name: rootSecret.Name,
+								},
+							},
+							Seeds: []infrav1.MongoDBSeed{
+								{
+									Name:       "flags",
+									ConfigMap:  &infrav1.ConfigMapReference{Name: "flags"},
+									Type:       infrav1.MongoDBSeedDocuments,
+									Collection: "flags",
+								},
+								{
+									Name:      "views",
+									ConfigMap: &infrav1.ConfigMapReference{Name: "views"},
+									Type:      infrav1.MongoDBSeedCommands,
+								},
+							},
+						},
+					}
+					Expect(k8sClient.Create(context.Background(), createdDB)).Should(Succeed())
+				})
+
+				It("expects seeds to be applied", func() {
+					got := &infrav1.MongoDBDatabase{}
+					Eventually(func() bool {
+						_ = k8sClient.Get(context.Background(), keyDB, got)
+						for _, condition := range got.Status.Conditions {
+							if condition.Type == infrav1.SeedReadyConditionType {
+								return condition.Status == "True" &&
+									condition.Reason == infrav1.SeedSuccessfulReason
+							}
+						}
+
+						return false
+					}, timeout, interval).Should(BeTrue())
+				})
+
+				It("has the seeded documents and views", func() {
+					o := options.Client()
+					o.SetConnectTimeout(time.Second)
+					o.SetServerSelectionTimeout(time.Second)
+					o.ApplyURI(container.URI)
+					o.SetAuth(options.Credential{
+						Username: "root",
+						Password: "password",
+					})
+
+					client, err = mongo.Connect(ctx, o)
+					Expect(err).NotTo(HaveOccurred(), "failed to connect to mongodb")
+
+					count, err := client.Database(keyDB.Name).Collection("flags").CountDocuments(ctx, bson.D{})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(count).To(Equal(int64(2)))
+
+					count, err = client.Database(keyDB.Name).Collection("enabledFlags").CountDocuments(ctx, bson.D{})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(count).To(Equal(int64(1)))
+				})
+
+				It("records the applied seeds", func() {
+					count, err := client.Database(keyDB.Name).Collection("_db_controller_seeds").CountDocuments(ctx, bson.D{})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(count).To(Equal(int64(2)))
+				})
+
+				It("does not apply seeds twice", func() {
+					cm := &corev1.ConfigMap{}
+					Expect(k8sClient.Get(context.Background(), types.NamespacedName{Name: "flags", Namespace: namespace.Name}, cm)).Should(Succeed())
+					cm.Data["02-flags.json"] = `{"_id": "feature-c", "enabled": true}`
+					Expect(k8sClient.Update(context.Background(), cm)).Should(Succeed())
+
+					Eventually(func() (int64, error) {
+						return client.Database(keyDB.Name).Collection("flags").CountDocuments(ctx, bson.D{})
+					}, timeout, interval).Should(Equal(int64(3)))
+
+					Consistently(func() (int64, error) {
+						return client.Database(keyDB.Name).Collection("_db_controller_seeds").CountDocuments(ctx, bson.D{})
+					}, 2*time.Second, interval).Should(Equal(int64(3)))
+				})
+			})
+
+			Describe("Authentication mechanisms", Ordered, func() {
+				var (
+					keyUser  types.NamespacedName
+					keyDB    types.NamespacedName
+					password string
+				)
+
+				namespace, rootSecret := setupNamespace()
+
+				connect := func(mechanism string) error {
+					o := options.Client()
+					o.SetConnectTimeout(time.Second)
+					o.SetServerSelectionTimeout(time.Second)
+					o.ApplyURI(container.URI)
+					o.SetAuth(options.Credential{
+						AuthSource:    keyDB.Name,
+						AuthMechanism: mechanism,
+						Username:      keyUser.Name,
+						Password:      password,
+					})
+
+					client, err := mongo.Connect(ctx, o)
+					if err != nil {
+						return err
+					}
+
+					defer func() { _ = client.Disconnect(ctx) }()
+					return client.Ping(ctx, readpref.Primary())
+				}
+
+				It("adds database with explicit auth options", func() {
+					keyDB = types.NamespacedName{
+						Name:      "mongodbdatabase-" + randStringRunes(5),
+						Namespace: namespace.Name,
+					}
+					Expect(k8sClient.Create(context.Background(), &infrav1.MongoDBDatabase{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      keyDB.Name,
+							Namespace: keyDB.Namespace,
+						},
+						Spec: infrav1.MongoDBDatabaseSpec{
+							DatabaseSpec: infrav1.DatabaseSpec{
+								Address: container.URI,
+								RootSecret: &infrav1.SecretReference{
+									Name: rootSecret.Name,
+								},
+							},
+							AuthSource:     "admin",
+							AuthMechanism:  infrav1.MongoDBAuthMechanismSCRAMSHA256,
+							ReadPreference: "primary",
+						},
+					})).Should(Succeed())
+				})
+
+				It("adds user supporting SCRAM-SHA-256 only", func() {
+					keyUser = types.NamespacedName{
+						Name:      "mongodbuser-" + randStringRunes(5),
+						Namespace: namespace.Name,
+					}
+					password = randStringRunes(5)
+					Expect(k8sClient.Create(context.Background(), &corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      keyUser.Name,
+							Namespace: keyUser.Namespace,
+						},
+						Data: map[string][]byte{
+							"username": []byte(keyUser.Name),
+							"password": []byte(password),
+						},
+					})).Should(Succeed())
+
+					Expect(k8sClient.Create(context.Background(), &infrav1.MongoDBUser{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      keyUser.Name,
+							Namespace: keyUser.Namespace,
+						},
+						Spec: infrav1.MongoDBUserSpec{
+							Database: &infrav1.DatabaseReference{
+								Name: keyDB.Name,
+							},
+							Credentials: &infrav1.SecretReference{
+								Name: keyUser.Name,
+							},
+							Mechanisms: []infrav1.MongoDBUserMechanism{"SCRAM-SHA-256"},
+						},
+					})).Should(Succeed())
+				})
+
+				It("expects ready user", func() {
+					got := &infrav1.MongoDBUser{}
+					Eventually(func() bool {
+						_ = k8sClient.Get(context.Background(), keyUser, got)
+						return len(got.Status.Conditions) == 1 &&
+							got.Status.Conditions[0].Reason == infrav1.UserProvisioningSuccessfulReason &&
+							got.Status.Conditions[0].Status == "True"
+					}, timeout, interval).Should(BeTrue())
+				})
+
+				It("can authenticate using SCRAM-SHA-256", func() {
+					Eventually(func() error {
+						return connect("SCRAM-SHA-256")
+					}, timeout, interval).Should(Succeed())
+				})
+
+				It("can't authenticate using SCRAM-SHA-1", func() {
+					Expect(connect("SCRAM-SHA-1")).NotTo(Succeed())
+				})
+			})
+
+			Describe("Authentication restrictions and custom data", Ordered, func() {
+				var (
+					createdUser *infrav1.MongoDBUser
+					keyUser     types.NamespacedName
+					keyDB       types.NamespacedName
+					password    string
+				)
+
+				namespace, rootSecret := setupNamespace()
+
+				connect := func() error {
+					o := options.Client()
+					o.SetConnectTimeout(time.Second)
+					o.SetServerSelectionTimeout(time.Second)
+					o.ApplyURI(container.URI)
+					o.SetAuth(options.Credential{
+						AuthSource: keyDB.Name,
+						Username:   keyUser.Name,
+						Password:   password,
+					})
+
+					client, err := mongo.Connect(ctx, o)
+					if err != nil {
+						return err
+					}
+
+					defer func() { _ = client.Disconnect(ctx) }()
+					return client.Ping(ctx, readpref.Primary())
+				}
+
+				getUserInfo := func() bson.M {
+					o := options.Client()
+					o.ApplyURI(container.URI)
+					o.SetAuth(options.Credential{
+						Username: "root",
+						Password: "password",
+					})
+
+					client, err := mongo.Connect(ctx, o)
+					Expect(err).NotTo(HaveOccurred(), "failed to connect to mongodb")
+					defer func() { _ = client.Disconnect(ctx) }()
+
+					var info bson.M
+					Expect(client.Database(keyDB.Name).RunCommand(ctx, bson.D{
+						{Key: "usersInfo", Value: keyUser.Name},
+						{Key: "showAuthenticationRestrictions", Value: true},
+					}).Decode(&info)).To(Succeed())
+
+					users := info["users"].(bson.A)
+					Expect(users).To(HaveLen(1))
+					return users[0].(bson.M)
+				}
+
+				It("adds database", func() {
+					keyDB = types.NamespacedName{
+						Name:      "mongodbdatabase-" + randStringRunes(5),
+						Namespace: namespace.Name,
+					}
+					Expect(k8sClient.Create(context.Background(), &infrav1.MongoDBDatabase{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      keyDB.Name,
+							Namespace: keyDB.Namespace,
+						},
+						Spec: infrav1.MongoDBDatabaseSpec{
+							DatabaseSpec: infrav1.DatabaseSpec{
+								Address: container.URI,
+								RootSecret: &infrav1.SecretReference{
+									Name: rootSecret.Name,
+								},
+							},
+						},
+					})).Should(Succeed())
+				})
+
+				It("adds restricted user with custom data", func() {
+					keyUser = types.NamespacedName{
+						Name:      "mongodbuser-" + randStringRunes(5),
+						Namespace: namespace.Name,
+					}
+					password = randStringRunes(5)
+					Expect(k8sClient.Create(context.Background(), &corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      keyUser.Name,
+							Namespace: keyUser.Namespace,
+						},
+						Data: map[string][]byte{
+							"username": []byte(keyUser.Name),
+							"password": []byte(password),
+						},
+					})).Should(Succeed())
+
+					createdUser = &infrav1.MongoDBUser{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      keyUser.Name,
+							Namespace: keyUser.Namespace,
+						},
+						Spec: infrav1.MongoDBUserSpec{
+							Database: &infrav1.DatabaseReference{
+								Name: keyDB.Name,
+							},
+							Credentials: &infrav1.SecretReference{
+								Name: keyUser.Name,
+							},
+							AuthenticationRestrictions: []infrav1.MongoDBAuthenticationRestriction{
+								{ClientSource: []string{"192.0.2.1/32"}},
+							},
+							CustomData: &runtime.RawExtension{Raw: []byte(`{"team":"payments"}`)},
+						},
+					}
+					Expect(k8sClient.Create(context.Background(), createdUser)).Should(Succeed())
+				})
+
+				It("expects ready user", func() {
+					got := &infrav1.MongoDBUser{}
+					Eventually(func() bool {
+						_ = k8sClient.Get(context.Background(), keyUser, got)
+						return len(got.Status.Conditions) == 1 &&
+							got.Status.Conditions[0].Reason == infrav1.UserProvisioningSuccessfulReason &&
+							got.Status.Conditions[0].Status == "True"
+					}, timeout, interval).Should(BeTrue())
+				})
+
+				It("stores custom data and restrictions", func() {
+					info := getUserInfo()
+					Expect(info["customData"]).To(Equal(bson.M{"team": "payments"}))
+					Expect(info["authenticationRestrictions"]).To(HaveLen(1))
+				})
+
+				It("can't authenticate from outside the allowed client source", func() {
+					Expect(connect()).NotTo(Succeed())
+				})
+
+				It("removes the restrictions and custom data", func() {
+					Expect(k8sClient.Get(context.Background(), keyUser, createdUser)).Should(Succeed())
+					createdUser.Spec.AuthenticationRestrictions = nil
+					createdUser.Spec.CustomData = nil
+					Expect(k8sClient.Update(context.Background(), createdUser)).Should(Succeed())
+				})
+
+				It("can authenticate once restrictions are removed", func() {
+					Eventually(connect, timeout, interval).Should(Succeed())
+				})
+
+				It("cleared custom data", func() {
+					info := getUserInfo()
+					Expect(info["customData"]).To(BeEmpty())
+				})
+			})
+
+			Describe("Change detection", Ordered, func() {
+				var (
+					createdUser *infrav1.MongoDBUser
+					keyUser     types.NamespacedName
+					keyDB       types.NamespacedName
+					password    string
+				)
+
+				namespace, rootSecret := setupNamespace()
+
+				rootClient := func() *mongo.Client {
+					o := options.Client()
+					o.ApplyURI(container.URI)
+					o.SetAuth(options.Credential{
+						Username: "root",
+						Password: "password",
+					})
+
+					client, err := mongo.Connect(ctx, o)
+					Expect(err).NotTo(HaveOccurred(), "failed to connect to mongodb")
+					return client
+				}
+
+				connect := func(password string) error {
+					o := options.Client()
+					o.SetConnectTimeout(time.Second)
+					o.SetServerSelectionTimeout(time.Second)
+					o.ApplyURI(container.URI)
+					o.SetAuth(options.Credential{
+						AuthSource: keyDB.Name,
+						Username:   keyUser.Name,
+						Password:   password,
+					})
+
+					client, err := mongo.Connect(ctx, o)
+					if err != nil {
+						return err
+					}
+
+					defer func() { _ = client.Disconnect(ctx) }()
+					return client.Ping(ctx, readpref.Primary())
+				}
+
+				It("adds database", func() {
+					keyDB = types.NamespacedName{
+						Name:      "mongodbdatabase-" + randStringRunes(5),
+						Namespace: namespace.Name,
+					}
+					Expect(k8sClient.Create(context.Background(), &infrav1.MongoDBDatabase{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      keyDB.Name,
+							Namespace: keyDB.Namespace,
+						},
+						Spec: infrav1.MongoDBDatabaseSpec{
+							DatabaseSpec: infrav1.DatabaseSpec{
+								Address: container.URI,
+								RootSecret: &infrav1.SecretReference{
+									Name: rootSecret.Name,
+								},
+							},
+						},
+					})).Should(Succeed())
+				})
+
+				It("adds user", func() {
+					keyUser = types.NamespacedName{
+						Name:      "mongodbuser-" + randStringRunes(5),
+						Namespace: namespace.Name,
+					}
+					password = randStringRunes(5)
+					Expect(k8sClient.Create(context.Background(), &corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      keyUser.Name,
+							Namespace: keyUser.Namespace,
+						},
+						Data: map[string][]byte{
+							"username": []byte(keyUser.Name),
+							"password": []byte(password),
+						},
+					})).Should(Succeed())
+
+					createdUser = &infrav1.MongoDBUser{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      keyUser.Name,
+							Namespace: keyUser.Namespace,
+						},
+						Spec: infrav1.MongoDBUserSpec{
+							Database: &infrav1.DatabaseReference{
+								Name: keyDB.Name,
+							},
+							Credentials: &infrav1.SecretReference{
+								Name: keyUser.Name,
+							},
+						},
+					}
+					Expect(k8sClient.Create(context.Background(), createdUser)).Should(Succeed())
+				})
+
+				It("stores a hash of the applied credentials", func() {
+					got := &infrav1.MongoDBUser{}
+					Eventually(func() string {
+						_ = k8sClient.Get(context.Background(), keyUser, got)
+						return got.Status.CredentialsHash
+					}, timeout, interval).ShouldNot(BeEmpty())
+
+					Expect(got.Status.CredentialsHash).NotTo(ContainSubstring(password))
+					Expect(got.Status.CredentialsSalt).NotTo(BeEmpty())
+				})
+
+				It("changes the password directly in mongodb", func() {
+					client := rootClient()
+					defer func() { _ = client.Disconnect(ctx) }()
+
+					Expect(client.Database(keyDB.Name).RunCommand(ctx, bson.D{
+						{Key: "updateUser", Value: keyUser.Name},
+						{Key: "pwd", Value: "out-of-band"},
+					}).Err()).To(Succeed())
+				})
+
+				It("changes the roles", func() {
+					Expect(k8sClient.Get(context.Background(), keyUser, createdUser)).Should(Succeed())
+					createdUser.Spec.Roles = []infrav1.MongoDBUserRole{
+						{Name: "read"},
+					}
+					Expect(k8sClient.Update(context.Background(), createdUser)).Should(Succeed())
+				})
+
+				It("updates the roles in mongodb", func() {
+					client := rootClient()
+					defer func() { _ = client.Disconnect(ctx) }()
+
+					Eventually(func() interface{} {
+						var info bson.M
+						_ = client.Database(keyDB.Name).RunCommand(ctx, bson.D{
+							{Key: "usersInfo", Value: keyUser.Name},
+						}).Decode(&info)
+
+						users, ok := info["users"].(bson.A)
+						if !ok || len(users) != 1 {
+							return nil
+						}
+
+						return users[0].(bson.M)["roles"]
+					}, timeout, interval).Should(Equal(bson.A{bson.M{"role": "read", "db": keyDB.Name}}))
+				})
+
+				It("did not reset the password", func() {
+					Expect(connect("out-of-band")).To(Succeed())
+					Expect(connect(password)).NotTo(Succeed())
+				})
+
+				It("changes the password in the user secret", func() {
+					password = randStringRunes(5)
+					secret := &corev1.Secret{}
+					Expect(k8sClient.Get(context.Background(), keyUser, secret)).Should(Succeed())
+					secret.Data["password"] = []byte(password)
+					Expect(k8sClient.Update(context.Background(), secret)).Should(Succeed())
+				})
+
+				It("applies the new password", func() {
+					Eventually(func() error {
+						return connect(password)
+					}, timeout, interval).Should(Succeed())
+				})
+			})
+
+			Describe("Session termination", Ordered, func() {
+				var (
+					createdUser *infrav1.MongoDBUser
+					keyUser     types.NamespacedName
+					keyDB       types.NamespacedName
+					password    string
+					session     mongo.Session
+					cursor      *mongo.Cursor
+				)
+
+				namespace, rootSecret := setupNamespace()
+
+				It("adds database", func() {
+					keyDB = types.NamespacedName{
+						Name:      "mongodbdatabase-" + randStringRunes(5),
+						Namespace: namespace.Name,
+					}
+					Expect(k8sClient.Create(context.Background(), &infrav1.MongoDBDatabase{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      keyDB.Name,
+							Namespace: keyDB.Namespace,
+						},
+						Spec: infrav1.MongoDBDatabaseSpec{
+							DatabaseSpec: infrav1.DatabaseSpec{
+								Address: container.URI,
+								RootSecret: &infrav1.SecretReference{
+									Name: rootSecret.Name,
+								},
+							},
+						},
+					})).Should(Succeed())
+				})
+
+				It("adds user", func() {
+					keyUser = types.NamespacedName{
+						Name:      "mongodbuser-" + randStringRunes(5),
+						Namespace: namespace.Name,
+					}
+					password = randStringRunes(5)
+					Expect(k8sClient.Create(context.Background(), &corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      keyUser.Name,
+							Namespace: keyUser.Namespace,
+						},
+						Data: map[string][]byte{
+							"username": []byte(keyUser.Name),
+							"password": []byte(password),
+						},
+					})).Should(Succeed())
+
+					createdUser = &infrav1.MongoDBUser{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      keyUser.Name,
+							Namespace: keyUser.Namespace,
+						},
+						Spec: infrav1.MongoDBUserSpec{
+							Database: &infrav1.DatabaseReference{
+								Name: keyDB.Name,
+							},
+							Credentials: &infrav1.SecretReference{
+								Name: keyUser.Name,
+							},
+							Roles: []infrav1.MongoDBUserRole{
+								{Name: "readWrite", DB: keyDB.Name},
+							},
+						},
+					}
+					Expect(k8sClient.Create(context.Background(), createdUser)).Should(Succeed())
+				})
+
+				It("expects ready user", func() {
+					got := &infrav1.MongoDBUser{}
+					Eventually(func() bool {
+						_ = k8sClient.Get(context.Background(), keyUser, got)
+						return len(got.Status.Conditions) == 1 &&
+							got.Status.Conditions[0].Reason == infrav1.UserProvisioningSuccessfulReason &&
+							got.Status.Conditions[0].Status == "True"
+					}, timeout, interval).Should(BeTrue())
+				})
+
+				It("opens a session with an open cursor as the user", func() {
+					o := options.Client()
+					o.SetConnectTimeout(time.Second)
+					o.SetServerSelectionTimeout(time.Second)
+					o.ApplyURI(container.URI)
+					o.SetAuth(options.Credential{
+						AuthSource: keyDB.Name,
+						Username:   keyUser.Name,
+						Password:   password,
+					})
+
+					client, err := mongo.Connect(ctx, o)
+					Expect(err).NotTo(HaveOccurred(), "failed to connect to mongodb")
+					DeferCleanup(func() { _ = client.Disconnect(ctx) })
+
+					session, err = client.StartSession()
+					Expect(err).NotTo(HaveOccurred(), "failed to start session")
+					DeferCleanup(func() { session.EndSession(ctx) })
+
+					sctx := mongo.NewSessionContext(ctx, session)
+					collection := client.Database(keyDB.Name).Collection("sessions")
+					Eventually(func() error {
+						_, err := collection.InsertMany(sctx, []interface{}{bson.D{}, bson.D{}, bson.D{}})
+						return err
+					}, timeout, interval).Should(Succeed())
+
+					cursor, err = collection.Find(sctx, bson.D{}, options.Find().SetBatchSize(1))
+					Expect(err).NotTo(HaveOccurred(), "failed to open cursor")
+					Expect(cursor.Next(sctx)).To(BeTrue())
+				})
+
+				It("deletes user", func() {
+					Expect(k8sClient.Delete(context.Background(), createdUser)).Should(Succeed())
+				})
+
+				It("expects gone", func() {
+					got := &infrav1.MongoDBUser{}
+					Eventually(func() error {
+						return k8sClient.Get(context.Background(), keyUser, got)
+					}, timeout, interval).ShouldNot(Succeed())
+				})
+
+				It("killed the operations and cursors of the user", func() {
+					o := options.Client()
+					o.ApplyURI(container.URI)
+					o.SetAuth(options.Credential{
+						Username: "root",
+						Password: "password",
+					})
+
+					client, err := mongo.Connect(ctx, o)
+					Expect(err).NotTo(HaveOccurred(), "failed to connect to mongodb")
+					defer func() { _ = client.Disconnect(ctx) }()
+
+					ops, err := client.Database("admin").Aggregate(ctx, bson.A{
+						bson.D{{Key: "$currentOp", Value: bson.D{
+							{Key: "allUsers", Value: true},
+							{Key: "idleSessions", Value: true},
+							{Key: "idleCursors", Value: true},
+						}}},
+						bson.D{{Key: "$match", Value: bson.D{
+							{Key: "effectiveUsers", Value: bson.D{
+								{Key: "$elemMatch", Value: bson.D{{Key: "user", Value: keyUser.Name}, {Key: "db", Value: keyDB.Name}}},
+							}},
+						}}},
+					})
+					Expect(err).NotTo(HaveOccurred(), "failed to list operations")
+
+					var found []bson.M
+					Expect(ops.All(ctx, &found)).To(Succeed())
+					Expect(found).To(BeEmpty())
+				})
+
+				It("can't continue the open cursor of the killed session", func() {
+					sctx := mongo.NewSessionContext(ctx, session)
+					Expect(cursor.Next(sctx)).To(BeFalse())
+					Expect(cursor.Err()).To(HaveOccurred())
+				})
+			})
+
 			Describe("Successful user creation", Ordered, func() {
 				var (
-					createdDB     *infrav1beta1.MongoDBDatabase
-					createdUser   *infrav1beta1.MongoDBUser
+					createdDB     *infrav1.MongoDBDatabase
+					createdUser   *infrav1.MongoDBUser
 					createdSecret *corev1.Secret
 					keyUser       types.NamespacedName
 					keyDB         types.NamespacedName
@@ -480,18 +1333,18 @@ var _ = Describe("MongoDB", func() {
 							Name:      "mongodbdatabase-" + randStringRunes(5),
 							Namespace: namespace.Name,
 						}
-						createdDB = &infrav1beta1.MongoDBDatabase{
+						createdDB = &infrav1.MongoDBDatabase{
 							ObjectMeta: metav1.ObjectMeta{
 								Name:      keyDB.Name,
 								Namespace: keyDB.Namespace,
 							},
-							Spec: infrav1beta1.MongoDBDatabaseSpec{
-								DatabaseSpec: &infrav1beta1.DatabaseSpec{
+							Spec: infrav1.MongoDBDatabaseSpec{
+								DatabaseSpec: infrav1.DatabaseSpec{
 									Timeout: &metav1.Duration{
 										Duration: time.Millisecond * 100,
 									},
 									Address: container.URI,
-									RootSecret: &infrav1beta1.SecretReference{
+									RootSecret: &infrav1.SecretReference{
 										Name: rootSecret.Name,
 									},
 								},
@@ -510,20 +1363,20 @@ var _ = Describe("MongoDB", func() {
 							Name:      "mongodbuser-" + randStringRunes(5),
 							Namespace: namespace.Name,
 						}
-						createdUser = &infrav1beta1.MongoDBUser{
+						createdUser = &infrav1.MongoDBUser{
 							ObjectMeta: metav1.ObjectMeta{
 								Name:      keyUser.Name,
 								Namespace: keyUser.Namespace,
 							},
-							Spec: infrav1beta1.MongoDBUserSpec{
-								Database: &infrav1beta1.DatabaseReference{
+							Spec: infrav1.MongoDBUserSpec{
+								Database: &infrav1.DatabaseReference{
 									Name: keyDB.Name,
 								},
-								Credentials: &infrav1beta1.SecretReference{
+								Credentials: &infrav1.SecretReference{
 									Name: keySecret.Name,
 								},
-								Roles: &[]infrav1beta1.MongoDBUserRole{
-									infrav1beta1.MongoDBUserRole{
+								Roles: []infrav1.MongoDBUserRole{
+									infrav1.MongoDBUserRole{
 										Name: "readWrite",
 										DB:   "foo",
 									},
@@ -549,13 +1402,13 @@ var _ = Describe("MongoDB", func() {
 					})
 
 					It("expects ready user", func() {
-						got := &infrav1beta1.MongoDBUser{}
+						got := &infrav1.MongoDBUser{}
 						Eventually(func() bool {
 							_ = k8sClient.Get(context.Background(), keyUser, got)
 							return len(got.Status.Conditions) == 1 &&
-								got.Status.Conditions[0].Reason == infrav1beta1.UserProvisioningSuccessfulReason &&
+								got.Status.Conditions[0].Reason == infrav1.UserProvisioningSuccessfulReason &&
 								got.Status.Conditions[0].Status == "True" &&
-								got.Status.Conditions[0].Type == infrav1beta1.UserReadyConditionType
+								got.Status.Conditions[0].Type == infrav1.UserReadyConditionType
 
 						}, timeout, interval).Should(BeTrue())
 					})
@@ -620,13 +1473,13 @@ var _ = Describe("MongoDB", func() {
 					})
 
 					It("expects ready user", func() {
-						got := &infrav1beta1.MongoDBUser{}
+						got := &infrav1.MongoDBUser{}
 						Eventually(func() bool {
 							_ = k8sClient.Get(context.Background(), keyUser, got)
 							return len(got.Status.Conditions) == 1 &&
-								got.Status.Conditions[0].Reason == infrav1beta1.UserProvisioningSuccessfulReason &&
+								got.Status.Conditions[0].Reason == infrav1.UserProvisioningSuccessfulReason &&
 								got.Status.Conditions[0].Status == "True" &&
-								got.Status.Conditions[0].Type == infrav1beta1.UserReadyConditionType
+								got.Status.Conditions[0].Type == infrav1.UserReadyConditionType
 
 						}, timeout, interval).Should(BeTrue())
 					})
@@ -661,8 +1514,8 @@ var _ = Describe("MongoDB", func() {
 						err := k8sClient.Get(context.Background(), keyUser, createdUser)
 						Expect(err).Should(Succeed())
 
-						createdUser.Spec.Roles = &[]infrav1beta1.MongoDBUserRole{
-							infrav1beta1.MongoDBUserRole{
+						createdUser.Spec.Roles = []infrav1.MongoDBUserRole{
+							infrav1.MongoDBUserRole{
 								Name: "read",
 								DB:   "foo",
 							},
@@ -672,14 +1525,14 @@ var _ = Describe("MongoDB", func() {
 					})
 
 					It("expects ready user", func() {
-						got := &infrav1beta1.MongoDBUser{}
+						got := &infrav1.MongoDBUser{}
 						Eventually(func() bool {
 							_ = k8sClient.Get(context.Background(), keyUser, got)
 
 							return len(got.Status.Conditions) == 1 &&
-								got.Status.Conditions[0].Reason == infrav1beta1.UserProvisioningSuccessfulReason &&
+								got.Status.Conditions[0].Reason == infrav1.UserProvisioningSuccessfulReason &&
 								got.Status.Conditions[0].Status == "True" &&
-								got.Status.Conditions[0].Type == infrav1beta1.UserReadyConditionType &&
+								got.Status.Conditions[0].Type == infrav1.UserReadyConditionType &&
 								got.ObjectMeta.Generation == got.Status.ObservedGeneration
 
 						}, timeout, interval).Should(BeTrue())
@@ -726,15 +1579,15 @@ var _ = Describe("MongoDB", func() {
 					})
 
 					It("expects ready user after setting validUntil", func() {
-						got := &infrav1beta1.MongoDBUser{}
+						got := &infrav1.MongoDBUser{}
 
 						Eventually(func() bool {
 							_ = k8sClient.Get(context.Background(), keyUser, got)
 
 							return len(got.Status.Conditions) == 1 &&
-								got.Status.Conditions[0].Reason == infrav1beta1.UserProvisioningSuccessfulReason &&
+								got.Status.Conditions[0].Reason == infrav1.UserProvisioningSuccessfulReason &&
 								got.Status.Conditions[0].Status == "True" &&
-								got.Status.Conditions[0].Type == infrav1beta1.UserReadyConditionType &&
+								got.Status.Conditions[0].Type == infrav1.UserReadyConditionType &&
 								got.ObjectMeta.Generation == got.Status.ObservedGeneration
 						}, timeout, interval).Should(BeTrue())
 					})
@@ -790,30 +1643,30 @@ var _ = Describe("MongoDB", func() {
 					})
 
 					It("sets expired status after validUntil expires", func() {
-						got := &infrav1beta1.MongoDBUser{}
+						got := &infrav1.MongoDBUser{}
 
 						Eventually(func() bool {
 							_ = k8sClient.Get(context.Background(), keyUser, got)
 
 							return len(got.Status.Conditions) == 1 &&
-								got.Status.Conditions[0].Reason == infrav1beta1.UserExpiredReason &&
+								got.Status.Conditions[0].Reason == infrav1.UserExpiredReason &&
 								got.Status.Conditions[0].Status == "False" &&
-								got.Status.Conditions[0].Type == infrav1beta1.UserReadyConditionType &&
+								got.Status.Conditions[0].Type == infrav1.UserReadyConditionType &&
 								got.ObjectMeta.Generation == got.Status.ObservedGeneration
 						}, timeout, interval).Should(BeTrue())
 					})
 
 					It("keeps the MongoDBUser resource after expiration", func() {
-						got := &infrav1beta1.MongoDBUser{}
+						got := &infrav1.MongoDBUser{}
 
 						Expect(k8sClient.Get(context.Background(), keyUser, got)).Should(Succeed())
 					})
 
 					It("keeps the finalizer after expiration", func() {
-						got := &infrav1beta1.MongoDBUser{}
+						got := &infrav1.MongoDBUser{}
 
 						Expect(k8sClient.Get(context.Background(), keyUser, got)).Should(Succeed())
-						Expect(got.Finalizers).To(ContainElement(infrav1beta1.Finalizer))
+						Expect(got.Finalizers).To(ContainElement(infrav1.Finalizer))
 					})
 				})
 
@@ -827,7 +1680,7 @@ var _ = Describe("MongoDB", func() {
 					})
 
 					It("expects gone", func() {
-						got := &infrav1beta1.MongoDBUser{}
+						got := &infrav1.MongoDBUser{}
 						Eventually(func() error {
 							return k8sClient.Get(context.Background(), keyUser, got)
 						}, timeout, interval).ShouldNot(Succeed())
@@ -857,3 +1710,181 @@ var _ = Describe("MongoDB", func() {
 		})
 	}
 })
+
+var _ = Describe("MongoDB X.509", Ordered, func() {
+	const (
+		timeout  = time.Second * 10
+		interval = time.Second * 1
+	)
+
+	var (
+		container *mongodbContainer
+		certs     *mongodbCertificates
+		keyDB     types.NamespacedName
+		keyUser   types.NamespacedName
+		password  string
+	)
+
+	namespace, _ := setupNamespace()
+
+	BeforeAll(func() {
+		var err error
+		certs, err = newMongoDBCertificates()
+		Expect(err).NotTo(HaveOccurred(), "failed to create certificates")
+
+		container, err = setupMongoDBTLSContainer(context.TODO(), "mongo:6", certs)
+		Expect(err).NotTo(HaveOccurred(), "failed to start mongodb container")
+		DeferCleanup(func() { _ = container.Terminate(context.TODO()) })
+	})
+
+	connect := func(cred options.Credential, withCertificate bool) (*mongo.Client, error) {
+		pool := x509.NewCertPool()
+		Expect(pool.AppendCertsFromPEM(certs.CA)).To(BeTrue())
+
+		tlsConfig := &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+		if withCertificate {
+			cert, err := tls.X509KeyPair(certs.ClientCertificate, certs.ClientKey)
+			Expect(err).NotTo(HaveOccurred())
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+
+		o := options.Client()
+		o.SetConnectTimeout(time.Second)
+		o.SetServerSelectionTimeout(time.Second)
+		o.ApplyURI(container.URI)
+		o.SetTLSConfig(tlsConfig)
+		o.SetAuth(cred)
+
+		client, err := mongo.Connect(ctx, o)
+		if err != nil {
+			return nil, err
+		}
+
+		return client, client.Ping(ctx, readpref.Primary())
+	}
+
+	It("creates the root user authenticating with the client certificate", func() {
+		var client *mongo.Client
+		Eventually(func() (err error) {
+			client, err = connect(options.Credential{Username: "root", Password: "password"}, false)
+			return err
+		}, timeout*3, interval).Should(Succeed())
+		defer func() { _ = client.Disconnect(ctx) }()
+
+		Expect(client.Database("$external").RunCommand(ctx, bson.D{
+			{Key: "createUser", Value: certs.ClientSubject},
+			{Key: "roles", Value: bson.A{bson.D{{Key: "role", Value: "root"}, {Key: "db", Value: "admin"}}}},
+		}).Err()).To(Succeed())
+	})
+
+	It("adds database authenticating with the client certificate", func() {
+		keyDB = types.NamespacedName{
+			Name:      "mongodbdatabase-" + randStringRunes(5),
+			Namespace: namespace.Name,
+		}
+
+		Expect(k8sClient.Create(context.Background(), &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      keyDB.Name + "-root",
+				Namespace: keyDB.Namespace,
+			},
+			Data: map[string][]byte{
+				"username": []byte(certs.ClientSubject),
+			},
+		})).Should(Succeed())
+
+		Expect(k8sClient.Create(context.Background(), &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      keyDB.Name + "-tls",
+				Namespace: keyDB.Namespace,
+			},
+			Data: map[string][]byte{
+				corev1.TLSCertKey:       certs.ClientCertificate,
+				corev1.TLSPrivateKeyKey: certs.ClientKey,
+				"ca.crt":                certs.CA,
+			},
+		})).Should(Succeed())
+
+		Expect(k8sClient.Create(context.Background(), &infrav1.MongoDBDatabase{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      keyDB.Name,
+				Namespace: keyDB.Namespace,
+			},
+			Spec: infrav1.MongoDBDatabaseSpec{
+				DatabaseSpec: infrav1.DatabaseSpec{
+					Address: container.URI,
+					RootSecret: &infrav1.SecretReference{
+						Name: keyDB.Name + "-root",
+					},
+				},
+				AuthMechanism: infrav1.MongoDBAuthMechanismX509,
+				TLSSecret: &infrav1.TLSSecretReference{
+					Name: keyDB.Name + "-tls",
+				},
+			},
+		})).Should(Succeed())
+	})
+
+	It("expects ready database", func() {
+		got := &infrav1.MongoDBDatabase{}
+		Eventually(func() bool {
+			_ = k8sClient.Get(context.Background(), keyDB, got)
+			return len(got.Status.Conditions) == 1 &&
+				got.Status.Conditions[0].Reason == infrav1.DatabaseProvisioningSuccessfulReason &&
+				got.Status.Conditions[0].Status == "True"
+		}, timeout, interval).Should(BeTrue())
+	})
+
+	It("adds user", func() {
+		keyUser = types.NamespacedName{
+			Name:      "mongodbuser-" + randStringRunes(5),
+			Namespace: namespace.Name,
+		}
+		password = randStringRunes(5)
+		Expect(k8sClient.Create(context.Background(), &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      keyUser.Name,
+				Namespace: keyUser.Namespace,
+			},
+			Data: map[string][]byte{
+				"username": []byte(keyUser.Name),
+				"password": []byte(password),
+			},
+		})).Should(Succeed())
+
+		Expect(k8sClient.Create(context.Background(), &infrav1.MongoDBUser{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      keyUser.Name,
+				Namespace: keyUser.Namespace,
+			},
+			Spec: infrav1.MongoDBUserSpec{
+				Database: &infrav1.DatabaseReference{
+					Name: keyDB.Name,
+				},
+				Credentials: &infrav1.SecretReference{
+					Name: keyUser.Name,
+				},
+			},
+		})).Should(Succeed())
+	})
+
+	It("expects ready user", func() {
+		got := &infrav1.MongoDBUser{}
+		Eventually(func() bool {
+			_ = k8sClient.Get(context.Background(), keyUser, got)
+			return len(got.Status.Conditions) == 1 &&
+				got.Status.Conditions[0].Reason == infrav1.UserProvisioningSuccessfulReason &&
+				got.Status.Conditions[0].Status == "True"
+		}, timeout, interval).Should(BeTrue())
+	})
+
+	It("can authenticate over tls", func() {
+		client, err := connect(options.Credential{
+			AuthSource: keyDB.Name,
+			Username:   keyUser.Name,
+			Password:   password,
+		}, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(client.Disconnect(ctx)).To(Succeed())
+	})
+})
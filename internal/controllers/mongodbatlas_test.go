@@ -0,0 +1,525 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.mongodb.org/atlas/mongodbatlas"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	infrav1 "github.com/doodlescheduling/db-controller/api/v1"
+	"github.com/doodlescheduling/db-controller/internal/database/atlastest"
+)
+
+var _ = Describe("MongoDB Atlas", Ordered, func() {
+	const (
+		timeout  = time.Second * 10
+		interval = time.Second * 1
+		groupID  = "atlas-group"
+	)
+
+	var atlas *atlastest.Server
+
+	BeforeAll(func() {
+		// The api key matches the root secret created by setupNamespace()
+		atlas = atlastest.NewServer("root", "password")
+		DeferCleanup(atlas.Close)
+	})
+
+	userCondition := func(key types.NamespacedName) func() metav1.Condition {
+		return func() metav1.Condition {
+			got := &infrav1.MongoDBUser{}
+			_ = k8sClient.Get(context.Background(), key, got)
+			if len(got.Status.Conditions) != 1 {
+				return metav1.Condition{}
+			}
+
+			return got.Status.Conditions[0]
+		}
+	}
+
+	createDatabase := func(namespace, rootSecret string, accessList ...infrav1.AtlasAccessListEntry) types.NamespacedName {
+		keyDB := types.NamespacedName{
+			Name:      "mongodbdatabase-" + randStringRunes(5),
+			Namespace: namespace,
+		}
+
+		Expect(k8sClient.Create(context.Background(), &infrav1.MongoDBDatabase{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      keyDB.Name,
+				Namespace: keyDB.Namespace,
+			},
+			Spec: infrav1.MongoDBDatabaseSpec{
+				Atlas: &infrav1.MongoDBAtlasSpec{
+					GroupID: groupID,
+					BaseURL: atlas.URL,
+					APIKeySecret: &infrav1.AtlasAPIKeySecretReference{
+						Name:            rootSecret,
+						PublicKeyField:  "username",
+						PrivateKeyField: "password",
+					},
+					AccessList: accessList,
+				},
+			},
+		})).Should(Succeed())
+
+		return keyDB
+	}
+
+	createUser := func(namespace string, keyDB types.NamespacedName, spec infrav1.MongoDBUserSpec, data map[string][]byte) types.NamespacedName {
+		keyUser := types.NamespacedName{
+			Name:      "mongodbuser-" + randStringRunes(5),
+			Namespace: namespace,
+		}
+
+		Expect(k8sClient.Create(context.Background(), &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      keyUser.Name,
+				Namespace: keyUser.Namespace,
+			},
+			Data: data,
+		})).Should(Succeed())
+
+		spec.Database = &infrav1.DatabaseReference{
+			Name: keyDB.Name,
+		}
+		spec.Credentials = &infrav1.SecretReference{
+			Name: keyUser.Name,
+		}
+
+		Expect(k8sClient.Create(context.Background(), &infrav1.MongoDBUser{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      keyUser.Name,
+				Namespace: keyUser.Namespace,
+			},
+			Spec: spec,
+		})).Should(Succeed())
+
+		return keyUser
+	}
+
+	Describe("Successful user creation", Ordered, func() {
+		var (
+			keyUser  types.NamespacedName
+			keyDB    types.NamespacedName
+			username string
+			requests int
+		)
+
+		namespace, rootSecret := setupNamespace()
+
+		It("adds user", func() {
+			keyDB = createDatabase(namespace.Name, rootSecret.Name)
+			username = "user-" + randStringRunes(5)
+			keyUser = createUser(namespace.Name, keyDB, infrav1.MongoDBUserSpec{
+				Atlas: &infrav1.MongoDBAtlasUserSpec{
+					Scopes: []infrav1.MongoDBAtlasScope{
+						{Name: "my-cluster"},
+					},
+					Labels: []infrav1.MongoDBAtlasLabel{
+						{Key: "team", Value: "payments"},
+					},
+				},
+			}, map[string][]byte{
+				"username": []byte(username),
+				"password": []byte("secret"),
+			})
+		})
+
+		It("expects ready user", func() {
+			Eventually(userCondition(keyUser), timeout, interval).Should(And(
+				HaveField("Reason", infrav1.UserProvisioningSuccessfulReason),
+				HaveField("Status", metav1.ConditionTrue),
+			))
+		})
+
+		It("creates the user in atlas", func() {
+			user, ok := atlas.User(groupID, "admin", username)
+			Expect(ok).To(BeTrue())
+			Expect(user.Password).To(Equal("secret"))
+			Expect(user.Roles).To(HaveLen(1))
+			Expect(user.Roles[0].RoleName).To(Equal("readWrite"))
+			Expect(user.Roles[0].DatabaseName).To(Equal(keyDB.Name))
+			Expect(user.Scopes).To(HaveLen(1))
+			Expect(user.Scopes[0].Name).To(Equal("my-cluster"))
+			Expect(user.Scopes[0].Type).To(Equal("CLUSTER"))
+			Expect(user.Labels).To(HaveLen(1))
+			requests = len(atlas.Requests())
+		})
+
+		It("updates the user instead of creating it again", func() {
+			Eventually(func() error {
+				got := &infrav1.MongoDBUser{}
+				if err := k8sClient.Get(context.Background(), keyUser, got); err != nil {
+					return err
+				}
+
+				got.Spec.Atlas.Labels = append(got.Spec.Atlas.Labels, infrav1.MongoDBAtlasLabel{Key: "env", Value: "test"})
+				return k8sClient.Update(context.Background(), got)
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func() int {
+				user, _ := atlas.User(groupID, "admin", username)
+				return len(user.Labels)
+			}, timeout, interval).Should(Equal(2))
+
+			for _, req := range atlas.Requests()[requests:] {
+				Expect(req.Method).NotTo(Equal(http.MethodPost))
+			}
+		})
+
+		It("removes the user from atlas on deletion", func() {
+			Expect(k8sClient.Delete(context.Background(), &infrav1.MongoDBUser{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      keyUser.Name,
+					Namespace: keyUser.Namespace,
+				},
+			})).Should(Succeed())
+
+			Eventually(func() bool {
+				_, ok := atlas.User(groupID, "admin", username)
+				return ok
+			}, timeout, interval).Should(BeFalse())
+		})
+	})
+
+	Describe("AWS IAM user", Ordered, func() {
+		var (
+			keyUser types.NamespacedName
+			arn     string
+		)
+
+		namespace, rootSecret := setupNamespace()
+
+		It("adds user without password", func() {
+			keyDB := createDatabase(namespace.Name, rootSecret.Name)
+			arn = "arn:aws:iam::123456789012:role/" + randStringRunes(5)
+			keyUser = createUser(namespace.Name, keyDB, infrav1.MongoDBUserSpec{
+				Atlas: &infrav1.MongoDBAtlasUserSpec{
+					AWSIAMType: "ROLE",
+				},
+			}, map[string][]byte{
+				"username": []byte(arn),
+			})
+		})
+
+		It("expects ready user", func() {
+			Eventually(userCondition(keyUser), timeout, interval).Should(And(
+				HaveField("Reason", infrav1.UserProvisioningSuccessfulReason),
+				HaveField("Status", metav1.ConditionTrue),
+			))
+		})
+
+		It("creates the user in $external", func() {
+			user, ok := atlas.User(groupID, "$external", arn)
+			Expect(ok).To(BeTrue())
+			Expect(user.AWSIAMType).To(Equal("ROLE"))
+			Expect(user.Password).To(BeEmpty())
+		})
+	})
+
+	Describe("Expiring user", Ordered, func() {
+		var (
+			keyUser  types.NamespacedName
+			username string
+		)
+
+		namespace, rootSecret := setupNamespace()
+
+		It("adds user expiring within a week", func() {
+			keyDB := createDatabase(namespace.Name, rootSecret.Name)
+			username = "user-" + randStringRunes(5)
+			validUntil := metav1.NewTime(time.Now().Add(48 * time.Hour).Truncate(time.Second))
+			keyUser = createUser(namespace.Name, keyDB, infrav1.MongoDBUserSpec{
+				ValidUntil: &validUntil,
+				Atlas: &infrav1.MongoDBAtlasUserSpec{
+					Labels: []infrav1.MongoDBAtlasLabel{
+						{Key: "team", Value: "payments"},
+					},
+				},
+			}, map[string][]byte{
+				"username": []byte(username),
+				"password": []byte("secret"),
+			})
+		})
+
+		It("expects ready user", func() {
+			Eventually(userCondition(keyUser), timeout, interval).Should(And(
+				HaveField("Reason", infrav1.UserProvisioningSuccessfulReason),
+				HaveField("Status", metav1.ConditionTrue),
+			))
+		})
+
+		It("sets deleteAfterDate", func() {
+			user, ok := atlas.User(groupID, "admin", username)
+			Expect(ok).To(BeTrue())
+			Expect(user.DeleteAfterDate).NotTo(BeEmpty())
+			Expect(user.Labels).To(HaveLen(1))
+		})
+
+		It("clears deleteAfterDate and labels once removed from the spec", func() {
+			Eventually(func() error {
+				got := &infrav1.MongoDBUser{}
+				if err := k8sClient.Get(context.Background(), keyUser, got); err != nil {
+					return err
+				}
+
+				got.Spec.ValidUntil = nil
+				got.Spec.Atlas.Labels = nil
+				return k8sClient.Update(context.Background(), got)
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func() mongodbatlas.DatabaseUser {
+				user, _ := atlas.User(groupID, "admin", username)
+				return user
+			}, timeout, interval).Should(And(
+				HaveField("DeleteAfterDate", BeEmpty()),
+				HaveField("Labels", BeEmpty()),
+			))
+		})
+
+		It("does not update the user again once it is in sync", func() {
+			requests := len(atlas.Requests())
+
+			// Trigger another reconciliation using the credentials secret
+			Eventually(func() error {
+				secret := &corev1.Secret{}
+				if err := k8sClient.Get(context.Background(), keyUser, secret); err != nil {
+					return err
+				}
+
+				secret.Labels = map[string]string{"resync": randStringRunes(5)}
+				return k8sClient.Update(context.Background(), secret)
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func() []atlastest.Request {
+				return atlas.Requests()[requests:]
+			}, timeout, interval).Should(ContainElement(HaveField("Method", http.MethodGet)))
+
+			Consistently(func() []atlastest.Request {
+				return atlas.Requests()[requests:]
+			}, 2*time.Second, interval).ShouldNot(ContainElement(HaveField("Method", http.MethodPatch)))
+		})
+	})
+
+	Describe("Atlas API errors", Ordered, func() {
+		var (
+			keyUser types.NamespacedName
+		)
+
+		namespace, rootSecret := setupNamespace()
+
+		AfterAll(func() {
+			atlas.FailWith(0)
+		})
+
+		It("adds user while the atlas api fails", func() {
+			atlas.FailWith(http.StatusServiceUnavailable)
+			keyDB := createDatabase(namespace.Name, rootSecret.Name)
+			keyUser = createUser(namespace.Name, keyDB, infrav1.MongoDBUserSpec{}, map[string][]byte{
+				"username": []byte("user-" + randStringRunes(5)),
+				"password": []byte("secret"),
+			})
+		})
+
+		It("reports a server error", func() {
+			Eventually(userCondition(keyUser), timeout, interval).Should(And(
+				HaveField("Reason", infrav1.ServerErrorReason),
+				HaveField("Status", metav1.ConditionFalse),
+			))
+		})
+
+		It("reports rate limiting", func() {
+			atlas.FailWith(http.StatusTooManyRequests)
+			Eventually(userCondition(keyUser), timeout*3, interval).Should(
+				HaveField("Reason", infrav1.RateLimitedReason),
+			)
+		})
+
+		It("recovers once the atlas api is available again", func() {
+			atlas.FailWith(0)
+			Eventually(userCondition(keyUser), timeout*3, interval).Should(And(
+				HaveField("Reason", infrav1.UserProvisioningSuccessfulReason),
+				HaveField("Status", metav1.ConditionTrue),
+			))
+		})
+	})
+
+	Describe("Invalid api key", Ordered, func() {
+		var (
+			keyUser types.NamespacedName
+		)
+
+		namespace, _ := setupNamespace()
+
+		It("adds user with an invalid api key", func() {
+			invalidSecret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "invalid-" + randStringRunes(5),
+					Namespace: namespace.Name,
+				},
+				Data: map[string][]byte{
+					"username": []byte("root"),
+					"password": []byte("invalid"),
+				},
+			}
+			Expect(k8sClient.Create(context.Background(), invalidSecret)).Should(Succeed())
+
+			keyDB := createDatabase(namespace.Name, invalidSecret.Name)
+			keyUser = createUser(namespace.Name, keyDB, infrav1.MongoDBUserSpec{}, map[string][]byte{
+				"username": []byte("user-" + randStringRunes(5)),
+				"password": []byte("secret"),
+			})
+		})
+
+		It("reports failed authentication instead of creating the user", func() {
+			Eventually(userCondition(keyUser), timeout, interval).Should(And(
+				HaveField("Reason", infrav1.AuthenticationFailedReason),
+				HaveField("Status", metav1.ConditionFalse),
+			))
+		})
+	})
+
+	Describe("Access list", Ordered, func() {
+		var (
+			keyDB types.NamespacedName
+		)
+
+		namespace, rootSecret := setupNamespace()
+
+		It("adds database with access list", func() {
+			atlas.AddAccessListEntry(groupID, mongodbatlas.ProjectIPAccessList{
+				IPAddress: "192.0.2.10",
+				Comment:   "added manually",
+			})
+
+			keyDB = createDatabase(namespace.Name, rootSecret.Name,
+				infrav1.AtlasAccessListEntry{CIDRBlock: "10.0.0.0/16", Comment: "cluster egress"},
+				infrav1.AtlasAccessListEntry{IPAddress: "192.0.2.1"},
+			)
+		})
+
+		It("adds the entries to the access list", func() {
+			Eventually(func() bool {
+				_, ok := atlas.AccessListEntry(groupID, "10.0.0.0/16")
+				return ok
+			}, timeout, interval).Should(BeTrue())
+
+			entry, ok := atlas.AccessListEntry(groupID, "192.0.2.1/32")
+			Expect(ok).To(BeTrue())
+			Expect(entry.IPAddress).To(Equal("192.0.2.1"))
+		})
+
+		It("reports the managed entries", func() {
+			got := &infrav1.MongoDBDatabase{}
+			Eventually(func() []string {
+				_ = k8sClient.Get(context.Background(), keyDB, got)
+				return got.Status.AccessList
+			}, timeout, interval).Should(ConsistOf("10.0.0.0/16", "192.0.2.1"))
+		})
+
+		It("removes an entry from the spec", func() {
+			Eventually(func() error {
+				got := &infrav1.MongoDBDatabase{}
+				if err := k8sClient.Get(context.Background(), keyDB, got); err != nil {
+					return err
+				}
+
+				got.Spec.Atlas.AccessList = got.Spec.Atlas.AccessList[:1]
+				return k8sClient.Update(context.Background(), got)
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func() bool {
+				_, ok := atlas.AccessListEntry(groupID, "192.0.2.1/32")
+				return ok
+			}, timeout, interval).Should(BeFalse())
+		})
+
+		It("keeps entries not managed by the controller", func() {
+			_, ok := atlas.AccessListEntry(groupID, "192.0.2.10/32")
+			Expect(ok).To(BeTrue())
+		})
+
+		It("removes the managed entries on deletion", func() {
+			Expect(k8sClient.Delete(context.Background(), &infrav1.MongoDBDatabase{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      keyDB.Name,
+					Namespace: keyDB.Namespace,
+				},
+			})).Should(Succeed())
+
+			Eventually(func() bool {
+				_, ok := atlas.AccessListEntry(groupID, "10.0.0.0/16")
+				return ok
+			}, timeout, interval).Should(BeFalse())
+
+			_, ok := atlas.AccessListEntry(groupID, "192.0.2.10/32")
+			Expect(ok).To(BeTrue())
+		})
+	})
+
+	Describe("Deletion after the api key secret was deleted", Ordered, func() {
+		var (
+			keyDB types.NamespacedName
+		)
+
+		namespace, rootSecret := setupNamespace()
+
+		It("adds database with access list", func() {
+			keyDB = createDatabase(namespace.Name, rootSecret.Name,
+				infrav1.AtlasAccessListEntry{CIDRBlock: "10.1.0.0/16"},
+			)
+
+			Eventually(func() bool {
+				_, ok := atlas.AccessListEntry(groupID, "10.1.0.0/16")
+				return ok
+			}, timeout, interval).Should(BeTrue())
+		})
+
+		It("removes the finalizer without the api key secret", func() {
+			Expect(k8sClient.Delete(context.Background(), &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      rootSecret.Name,
+					Namespace: namespace.Name,
+				},
+			})).Should(Succeed())
+
+			Expect(k8sClient.Delete(context.Background(), &infrav1.MongoDBDatabase{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      keyDB.Name,
+					Namespace: keyDB.Namespace,
+				},
+			})).Should(Succeed())
+
+			Eventually(func() error {
+				return k8sClient.Get(context.Background(), keyDB, &infrav1.MongoDBDatabase{})
+			}, timeout, interval).ShouldNot(Succeed())
+		})
+
+		It("leaves the entries in the access list", func() {
+			_, ok := atlas.AccessListEntry(groupID, "10.1.0.0/16")
+			Expect(ok).To(BeTrue())
+		})
+	})
+})
@@ -18,12 +18,19 @@ package controllers
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"slices"
+	"sort"
+	"time"
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
@@ -34,13 +41,17 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
-	infrav1beta1 "github.com/doodlescheduling/db-controller/api/v1beta1"
+	infrav1 "github.com/doodlescheduling/db-controller/api/v1"
+	"github.com/doodlescheduling/db-controller/internal/audit"
+	"github.com/doodlescheduling/db-controller/internal/database"
 	"github.com/doodlescheduling/db-controller/internal/stringutils"
+	"github.com/doodlescheduling/db-controller/internal/tracing"
 )
 
 // +kubebuilder:rbac:groups=dbprovisioning.infra.doodle.com,resources=mongodbdatabases,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=dbprovisioning.infra.doodle.com,resources=mongodbdatabases/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // MongoDBDatabaseReconciler reconciles a MongoDBDatabase object
@@ -49,29 +60,68 @@ type MongoDBDatabaseReconciler struct {
 	Log      logr.Logger
 	Scheme   *runtime.Scheme
 	Recorder events.EventRecorder
+	// Provisioners is the registry to connect to the servers, the default registry is used if not set
+	Provisioners *database.Registry
+
+	triggers audit.Triggers
 }
 
 func (r *MongoDBDatabaseReconciler) SetupWithManager(mgr ctrl.Manager, maxConcurrentReconciles int) error {
 	// Index the MongoDBDatabase by the Secret references they point at
-	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &infrav1beta1.MongoDBDatabase{}, secretIndexKey,
+	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &infrav1.MongoDBDatabase{}, secretIndexKey,
 		func(o client.Object) []string {
-			db := o.(*infrav1beta1.MongoDBDatabase)
-			return []string{
-				fmt.Sprintf("%s/%s", db.GetNamespace(), db.Spec.RootSecret.Name),
+			db := o.(*infrav1.MongoDBDatabase)
+			var keys []string
+
+			if db.Spec.RootSecret != nil {
+				keys = append(keys, fmt.Sprintf("%s/%s", db.GetNamespace(), db.Spec.RootSecret.Name))
+			}
+
+			if db.Spec.Atlas != nil && db.Spec.Atlas.APIKeySecret != nil {
+				keys = append(keys, fmt.Sprintf("%s/%s", db.GetNamespace(), db.Spec.Atlas.APIKeySecret.Name))
 			}
+
+			if ref := db.GetTLSSecret(); ref != nil {
+				keys = append(keys, fmt.Sprintf("%s/%s", ref.Namespace, ref.Name))
+			}
+
+			return keys
+		},
+	); err != nil {
+		return err
+	}
+
+	// Index the MongoDBDatabase by the ConfigMap references of their seeds
+	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &infrav1.MongoDBDatabase{}, configMapIndexKey,
+		func(o client.Object) []string {
+			db := o.(*infrav1.MongoDBDatabase)
+			var keys []string
+			for _, seed := range db.Spec.Seeds {
+				if seed.ConfigMap == nil {
+					continue
+				}
+
+				keys = append(keys, fmt.Sprintf("%s/%s", db.GetNamespace(), seed.ConfigMap.Name))
+			}
+
+			return keys
 		},
 	); err != nil {
 		return err
 	}
 
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&infrav1beta1.MongoDBDatabase{}, builder.WithPredicates(
+		For(&infrav1.MongoDBDatabase{}, builder.WithPredicates(
 			predicate.GenerationChangedPredicate{},
 		)).
 		Watches(
 			&corev1.Secret{},
 			handler.EnqueueRequestsFromMapFunc(r.requestsForSecretChange),
 		).
+		Watches(
+			&corev1.ConfigMap{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForConfigMapChange),
+		).
 		WithOptions(controller.Options{MaxConcurrentReconciles: maxConcurrentReconciles}).
 		Complete(r)
 }
@@ -82,7 +132,7 @@ func (r *MongoDBDatabaseReconciler) requestsForSecretChange(ctx context.Context,
 		panic(fmt.Sprintf("expected a Secret, got %T", o))
 	}
 
-	var list infrav1beta1.MongoDBDatabaseList
+	var list infrav1.MongoDBDatabaseList
 	if err := r.List(ctx, &list, client.MatchingFields{
 		secretIndexKey: objectKey(s).String(),
 	}); err != nil {
@@ -92,6 +142,29 @@ func (r *MongoDBDatabaseReconciler) requestsForSecretChange(ctx context.Context,
 	var reqs []reconcile.Request
 	for _, i := range list.Items {
 		r.Log.Info("referenced secret from a MongoDBDatabase changed detected", "namespace", i.GetNamespace(), "name", i.GetName())
+		r.triggers.SecretChanged(objectKey(&i), objectKey(s))
+		reqs = append(reqs, reconcile.Request{NamespacedName: objectKey(&i)})
+	}
+
+	return reqs
+}
+
+func (r *MongoDBDatabaseReconciler) requestsForConfigMapChange(ctx context.Context, o client.Object) []reconcile.Request {
+	cm, ok := o.(*corev1.ConfigMap)
+	if !ok {
+		panic(fmt.Sprintf("expected a ConfigMap, got %T", o))
+	}
+
+	var list infrav1.MongoDBDatabaseList
+	if err := r.List(ctx, &list, client.MatchingFields{
+		configMapIndexKey: objectKey(cm).String(),
+	}); err != nil {
+		return nil
+	}
+
+	var reqs []reconcile.Request
+	for _, i := range list.Items {
+		r.Log.Info("referenced configmap from a MongoDBDatabase changed detected", "namespace", i.GetNamespace(), "name", i.GetName())
 		reqs = append(reqs, reconcile.Request{NamespacedName: objectKey(&i)})
 	}
 
@@ -99,11 +172,14 @@ func (r *MongoDBDatabaseReconciler) requestsForSecretChange(ctx context.Context,
 }
 
 func (r *MongoDBDatabaseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx, span := tracing.Start(ctx, "MongoDBDatabase.Reconcile", tracing.Object("MongoDBDatabase", req.NamespacedName)...)
+	defer span.End()
+
 	logger := r.Log.WithValues("mongodbdatabase", req.NamespacedName)
 	logger.Info("reconciling MongoDBDatabase")
 
 	// get database resource by namespaced name
-	var db infrav1beta1.MongoDBDatabase
+	var db infrav1.MongoDBDatabase
 	if err := r.Get(ctx, req.NamespacedName, &db); err != nil {
 		if apierrors.IsNotFound(err) {
 			// resource no longer present. Consider dropping a database? What about data, it will be lost.. Probably acceptable for devboxes
@@ -112,12 +188,12 @@ func (r *MongoDBDatabaseReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		return ctrl.Result{}, err
 	}
 
-	_ = db.SetDefaults()
+	ctx = audit.WithSource(ctx, r.triggers.Source("MongoDBDatabase", &db, db.Status.ObservedGeneration))
 
 	// examine DeletionTimestamp to determine if object is under deletion
 	if db.DeletionTimestamp.IsZero() {
-		if !stringutils.ContainsString(db.GetFinalizers(), infrav1beta1.Finalizer) {
-			controllerutil.AddFinalizer(&db, infrav1beta1.Finalizer)
+		if !stringutils.ContainsString(db.GetFinalizers(), infrav1.Finalizer) {
+			controllerutil.AddFinalizer(&db, infrav1.Finalizer)
 			if err := r.Update(ctx, &db); err != nil {
 				return ctrl.Result{}, err
 			}
@@ -131,8 +207,8 @@ func (r *MongoDBDatabaseReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		reconcileContext = c
 	}
 
-	db, reconcileErr := r.reconcile(reconcileContext, db)
-	res := ctrl.Result{}
+	db, res, reconcileErr := r.reconcile(reconcileContext, db)
+	tracing.RecordError(span, reconcileErr)
 	db.Status.ObservedGeneration = db.GetGeneration()
 
 	if reconcileErr != nil {
@@ -140,9 +216,11 @@ func (r *MongoDBDatabaseReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 	} else {
 		msg := "Database successfully provisioned"
 		r.Recorder.Eventf(&db, nil, "Normal", "info", "Reconcile", "%s", msg)
-		infrav1beta1.DatabaseReadyCondition(&db, infrav1beta1.DatabaseProvisioningSuccessfulReason, msg)
+		infrav1.DatabaseReadyCondition(&db, infrav1.DatabaseProvisioningSuccessfulReason, msg)
 	}
 
+	recordReconcile("MongoDBDatabase", db.Status.Conditions, infrav1.DatabaseReadyConditionType)
+
 	// Update status after reconciliation.
 	if err := r.patchStatus(ctx, &db); err != nil {
 		logger.Error(err, "unable to update status after reconciliation")
@@ -152,49 +230,235 @@ func (r *MongoDBDatabaseReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 	return res, reconcileErr
 }
 
-func (r *MongoDBDatabaseReconciler) reconcile(ctx context.Context, db infrav1beta1.MongoDBDatabase) (infrav1beta1.MongoDBDatabase, error) {
-	if db.Spec.AtlasGroupId != "" {
+func (r *MongoDBDatabaseReconciler) reconcile(ctx context.Context, db infrav1.MongoDBDatabase) (infrav1.MongoDBDatabase, ctrl.Result, error) {
+	if db.IsAtlas() {
 		return r.reconcileAtlasDatabase(ctx, db)
 	}
 
-	return r.reconcileGenericDatabase(ctx, db)
+	db, err := r.reconcileGenericDatabase(ctx, db)
+	return db, ctrl.Result{}, err
 }
 
-func (r *MongoDBDatabaseReconciler) reconcileGenericDatabase(ctx context.Context, db infrav1beta1.MongoDBDatabase) (infrav1beta1.MongoDBDatabase, error) {
+func (r *MongoDBDatabaseReconciler) reconcileGenericDatabase(ctx context.Context, db infrav1.MongoDBDatabase) (infrav1.MongoDBDatabase, error) {
 	if !db.DeletionTimestamp.IsZero() {
 		return r.finalizeDatabase(ctx, db)
 	}
 
-	return db, nil
-}
+	if len(db.Spec.Seeds) == 0 {
+		return db, nil
+	}
 
-func (r *MongoDBDatabaseReconciler) reconcileAtlasDatabase(ctx context.Context, db infrav1beta1.MongoDBDatabase) (infrav1beta1.MongoDBDatabase, error) {
-	pubKey, privKey, addr, err := getSecret(ctx, r.Client, db.GetRootSecret())
+	usr, pw, addr, err := getMongoDBRootSecret(ctx, r.Client, db)
 
 	if err != nil {
-		infrav1beta1.DatabaseNotReadyCondition(&db, infrav1beta1.CredentialsNotFoundReason, err.Error())
+		infrav1.DatabaseNotReadyCondition(&db, infrav1.CredentialsNotFoundReason, err.Error())
 		return db, err
 	}
 
-	dbHandler, err := setupAtlas(ctx, db, pubKey, privKey, addr)
+	dbHandler, err := setupMongoDB[database.MongoDBSeeder](ctx, r.Client, r.Provisioners, db, usr, pw, addr)
 
 	if err != nil {
-		infrav1beta1.DatabaseNotReadyCondition(&db, infrav1beta1.ConnectionFailedReason, err.Error())
+		infrav1.DatabaseNotReadyCondition(&db, infrav1.ConnectionFailedReason, err.Error())
 		return db, err
 	}
 
 	defer func() { _ = dbHandler.Close(ctx) }()
 
+	return r.seedDatabase(ctx, db, dbHandler)
+}
+
+func (r *MongoDBDatabaseReconciler) seedDatabase(ctx context.Context, db infrav1.MongoDBDatabase, dbHandler database.MongoDBSeeder) (infrav1.MongoDBDatabase, error) {
+	var seeds []database.MongoDBSeed
+
+	for _, seed := range db.Spec.Seeds {
+		var cm corev1.ConfigMap
+		cmName := types.NamespacedName{
+			Namespace: db.GetNamespace(),
+			Name:      seed.ConfigMap.Name,
+		}
+
+		if err := r.Get(ctx, cmName, &cm); err != nil {
+			err = fmt.Errorf("referencing configmap of seed %s was not found: %w", seed.Name, err)
+			infrav1.SeedNotReadyCondition(&db, infrav1.ConfigMapNotFoundReason, err.Error())
+			return db, err
+		}
+
+		keys := make([]string, 0, len(cm.Data))
+		for key := range cm.Data {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			checksum := sha256.Sum256([]byte(cm.Data[key]))
+			seeds = append(seeds, database.MongoDBSeed{
+				ID:         fmt.Sprintf("%s/%s", seed.Name, key),
+				Type:       database.MongoDBSeedType(seed.Type),
+				Collection: seed.Collection,
+				Checksum:   hex.EncodeToString(checksum[:]),
+				Data:       []byte(cm.Data[key]),
+			})
+		}
+	}
+
+	var applied int
+	for i, seed := range seeds {
+		isApplied, err := dbHandler.ApplySeed(ctx, db.GetDatabaseName(), seed)
+		if err != nil {
+			err = fmt.Errorf("failed to apply seed %s (%d/%d): %w", seed.ID, i+1, len(seeds), err)
+			infrav1.SeedNotReadyCondition(&db, infrav1.SeedFailedReason, err.Error())
+			return db, err
+		}
+
+		if isApplied {
+			applied++
+		}
+	}
+
+	infrav1.SeedReadyCondition(&db, infrav1.SeedSuccessfulReason, fmt.Sprintf("%d seeds applied, %d applied in this reconciliation", len(seeds), applied))
+	return db, nil
+}
+
+func (r *MongoDBDatabaseReconciler) reconcileAtlasDatabase(ctx context.Context, db infrav1.MongoDBDatabase) (infrav1.MongoDBDatabase, ctrl.Result, error) {
+	res := ctrl.Result{}
+	pubKey, privKey, _, err := getMongoDBRootSecret(ctx, r.Client, db)
+
+	if err != nil {
+		// The API key secret was deleted first, the access list entries are left in the Atlas project
+		if !db.DeletionTimestamp.IsZero() && apierrors.IsNotFound(err) {
+			r.Recorder.Eventf(&db, nil, "Normal", "error", "Reconcile", "Access list entries were not removed from the Atlas project: %s", err.Error())
+			db, err = r.finalizeDatabase(ctx, db)
+			return db, res, err
+		}
+
+		infrav1.DatabaseNotReadyCondition(&db, infrav1.CredentialsNotFoundReason, err.Error())
+		return db, res, err
+	}
+
+	dbHandler, err := setupAtlas[database.AtlasAccessListProvisioner](ctx, r.Provisioners, db, pubKey, privKey)
+
+	if err != nil {
+		infrav1.DatabaseNotReadyCondition(&db, errorReason(err, infrav1.ConnectionFailedReason), err.Error())
+		return db, res, err
+	}
+
+	defer func() { _ = dbHandler.Close(ctx) }()
+
 	if !db.DeletionTimestamp.IsZero() {
-		return r.finalizeDatabase(ctx, db)
+		db, err = r.removeAccessList(ctx, db, dbHandler, db.Status.AccessList)
+		if err != nil {
+			return db, res, err
+		}
+
+		db, err = r.finalizeDatabase(ctx, db)
+		return db, res, err
+	}
+
+	if len(db.Spec.Seeds) > 0 {
+		infrav1.SeedNotReadyCondition(&db, infrav1.SeedFailedReason, "seeds are not supported for MongoDB Atlas")
+	}
+
+	if len(db.GetAccessList()) > 0 || len(db.Status.AccessList) > 0 {
+		return r.reconcileAccessList(ctx, db, dbHandler)
+	}
+
+	return db, res, nil
+}
+
+// reconcileAccessList adds the entries from the spec to the Atlas project IP access list and removes entries
+// which were previously managed but are not part of the spec anymore or have expired.
+func (r *MongoDBDatabaseReconciler) reconcileAccessList(ctx context.Context, db infrav1.MongoDBDatabase, dbHandler database.AtlasAccessListProvisioner) (infrav1.MongoDBDatabase, ctrl.Result, error) {
+	res := ctrl.Result{}
+	now := time.Now().UTC()
+
+	var (
+		entries []database.AtlasAccessListEntry
+		managed []string
+	)
+
+	for _, entry := range db.GetAccessList() {
+		if (entry.CIDRBlock == "") == (entry.IPAddress == "") {
+			err := errors.New("access list entry must have either a cidrBlock or an ipAddress")
+			infrav1.AccessListNotReadyCondition(&db, infrav1.AccessListFailedReason, err.Error())
+			return db, res, err
+		}
+
+		e := database.AtlasAccessListEntry{
+			CIDRBlock: entry.CIDRBlock,
+			IPAddress: entry.IPAddress,
+			Comment:   entry.Comment,
+		}
+
+		if entry.ExpiresAt != nil {
+			expiresAt := entry.ExpiresAt.UTC()
+			if !expiresAt.After(now) {
+				continue
+			}
+
+			requeueAfter := expiresAt.Sub(now)
+
+			// Atlas only accepts a deleteAfterDate within a week, until then the controller removes the entry on expiry
+			if deleteAfterWindow := expiresAt.Add(-atlasMaxDeleteAfter); deleteAfterWindow.After(now) {
+				requeueAfter = deleteAfterWindow.Sub(now)
+			} else {
+				e.DeleteAfterDate = &expiresAt
+			}
+
+			if res.RequeueAfter == 0 || requeueAfter < res.RequeueAfter {
+				res.RequeueAfter = requeueAfter
+			}
+		}
+
+		entries = append(entries, e)
+		managed = append(managed, entry.GetEntry())
+	}
+
+	var removed []string
+	for _, entry := range db.Status.AccessList {
+		if !slices.Contains(managed, entry) {
+			removed = append(removed, entry)
+		}
+	}
+
+	db, err := r.removeAccessList(ctx, db, dbHandler, removed)
+	if err != nil {
+		return db, res, err
+	}
+
+	// Track the entries before submitting them so they are removed later on even if the request fails
+	db.Status.AccessList = managed
+
+	if err := dbHandler.SetupAccessList(ctx, entries); err != nil {
+		err = fmt.Errorf("failed to setup access list: %w", err)
+		infrav1.AccessListNotReadyCondition(&db, errorReason(err, infrav1.AccessListFailedReason), err.Error())
+		return db, res, err
+	}
+
+	infrav1.AccessListReadyCondition(&db, infrav1.AccessListSuccessfulReason, fmt.Sprintf("%d access list entries managed", len(managed)))
+	return db, res, nil
+}
+
+// removeAccessList removes entries from the Atlas project IP access list and from the managed entries in the status
+func (r *MongoDBDatabaseReconciler) removeAccessList(ctx context.Context, db infrav1.MongoDBDatabase, dbHandler database.AtlasAccessListProvisioner, entries []string) (infrav1.MongoDBDatabase, error) {
+	for _, entry := range slices.Clone(entries) {
+		if err := dbHandler.DeleteAccessListEntry(ctx, entry); err != nil {
+			err = fmt.Errorf("failed to remove access list entry %s: %w", entry, err)
+			infrav1.AccessListNotReadyCondition(&db, errorReason(err, infrav1.AccessListFailedReason), err.Error())
+			return db, err
+		}
+
+		db.Status.AccessList = slices.DeleteFunc(db.Status.AccessList, func(e string) bool {
+			return e == entry
+		})
 	}
 
 	return db, nil
 }
 
-func (r *MongoDBDatabaseReconciler) finalizeDatabase(ctx context.Context, db infrav1beta1.MongoDBDatabase) (infrav1beta1.MongoDBDatabase, error) {
-	if stringutils.ContainsString(db.Finalizers, infrav1beta1.Finalizer) {
-		db.Finalizers = stringutils.RemoveString(db.Finalizers, infrav1beta1.Finalizer)
+func (r *MongoDBDatabaseReconciler) finalizeDatabase(ctx context.Context, db infrav1.MongoDBDatabase) (infrav1.MongoDBDatabase, error) {
+	if stringutils.ContainsString(db.Finalizers, infrav1.Finalizer) {
+		db.Finalizers = stringutils.RemoveString(db.Finalizers, infrav1.Finalizer)
 		if err := r.Update(ctx, &db); err != nil {
 			return db, err
 		}
@@ -203,9 +467,9 @@ func (r *MongoDBDatabaseReconciler) finalizeDatabase(ctx context.Context, db inf
 	return db, nil
 }
 
-func (r *MongoDBDatabaseReconciler) patchStatus(ctx context.Context, database *infrav1beta1.MongoDBDatabase) error {
+func (r *MongoDBDatabaseReconciler) patchStatus(ctx context.Context, database *infrav1.MongoDBDatabase) error {
 	key := client.ObjectKeyFromObject(database)
-	latest := &infrav1beta1.MongoDBDatabase{}
+	latest := &infrav1.MongoDBDatabase{}
 	if err := r.Get(ctx, key, latest); err != nil {
 		return err
 	}
@@ -18,7 +18,9 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -36,8 +38,12 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
-	infrav1beta1 "github.com/doodlescheduling/db-controller/api/v1beta1"
+	infrav1 "github.com/doodlescheduling/db-controller/api/v1"
+	"github.com/doodlescheduling/db-controller/internal/audit"
+	"github.com/doodlescheduling/db-controller/internal/database"
+	"github.com/doodlescheduling/db-controller/internal/metrics"
 	"github.com/doodlescheduling/db-controller/internal/stringutils"
+	"github.com/doodlescheduling/db-controller/internal/tracing"
 )
 
 // +kubebuilder:rbac:groups=dbprovisioning.infra.doodle.com,resources=mongodbusers,verbs=get;list;watch;create;update;patch;delete
@@ -45,19 +51,26 @@ import (
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
+// atlasMaxDeleteAfter is the furthest in the future Atlas accepts a deleteAfterDate
+const atlasMaxDeleteAfter = 7 * 24 * time.Hour
+
 // MongoDBUserReconciler reconciles a MongoDBUser object
 type MongoDBUserReconciler struct {
 	client.Client
 	Log      logr.Logger
 	Scheme   *runtime.Scheme
 	Recorder events.EventRecorder
+	// Provisioners is the registry to connect to the servers, the default registry is used if not set
+	Provisioners *database.Registry
+
+	triggers audit.Triggers
 }
 
 func (r *MongoDBUserReconciler) SetupWithManager(mgr ctrl.Manager, maxConcurrentReconciles int) error {
 	// Index the MongoDBUser by the Credentials references they point at
-	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &infrav1beta1.MongoDBUser{}, credentialsIndexKey,
+	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &infrav1.MongoDBUser{}, credentialsIndexKey,
 		func(o client.Object) []string {
-			usr := o.(*infrav1beta1.MongoDBUser)
+			usr := o.(*infrav1.MongoDBUser)
 			return []string{
 				fmt.Sprintf("%s/%s", usr.GetNamespace(), usr.Spec.Credentials.Name),
 			}
@@ -67,9 +80,9 @@ func (r *MongoDBUserReconciler) SetupWithManager(mgr ctrl.Manager, maxConcurrent
 	}
 
 	// Index the MongoDBUser by the Database references they point at
-	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &infrav1beta1.MongoDBUser{}, dbIndexKey,
+	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &infrav1.MongoDBUser{}, dbIndexKey,
 		func(o client.Object) []string {
-			usr := o.(*infrav1beta1.MongoDBUser)
+			usr := o.(*infrav1.MongoDBUser)
 			return []string{
 				fmt.Sprintf("%s/%s", usr.GetNamespace(), usr.Spec.Database.Name),
 			}
@@ -79,7 +92,7 @@ func (r *MongoDBUserReconciler) SetupWithManager(mgr ctrl.Manager, maxConcurrent
 	}
 
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&infrav1beta1.MongoDBUser{}, builder.WithPredicates(
+		For(&infrav1.MongoDBUser{}, builder.WithPredicates(
 			predicate.GenerationChangedPredicate{},
 		)).
 		Watches(
@@ -87,7 +100,7 @@ func (r *MongoDBUserReconciler) SetupWithManager(mgr ctrl.Manager, maxConcurrent
 			handler.EnqueueRequestsFromMapFunc(r.requestsForSecretChange),
 		).
 		Watches(
-			&infrav1beta1.MongoDBDatabase{},
+			&infrav1.MongoDBDatabase{},
 			handler.EnqueueRequestsFromMapFunc(r.requestsForDatabaseChange),
 		).
 		WithOptions(controller.Options{MaxConcurrentReconciles: maxConcurrentReconciles}).
@@ -100,7 +113,7 @@ func (r *MongoDBUserReconciler) requestsForSecretChange(ctx context.Context, o c
 		panic(fmt.Sprintf("expected a Secret, got %T", o))
 	}
 
-	var list infrav1beta1.MongoDBUserList
+	var list infrav1.MongoDBUserList
 	if err := r.List(ctx, &list, client.MatchingFields{
 		credentialsIndexKey: objectKey(s).String(),
 	}); err != nil {
@@ -110,6 +123,7 @@ func (r *MongoDBUserReconciler) requestsForSecretChange(ctx context.Context, o c
 	var reqs []reconcile.Request
 	for _, i := range list.Items {
 		r.Log.Info("referenced secret from a mongodbuser change detected", "namespace", i.GetNamespace(), "name", i.GetName())
+		r.triggers.SecretChanged(objectKey(&i), objectKey(s))
 		reqs = append(reqs, reconcile.Request{NamespacedName: objectKey(&i)})
 	}
 
@@ -117,12 +131,12 @@ func (r *MongoDBUserReconciler) requestsForSecretChange(ctx context.Context, o c
 }
 
 func (r *MongoDBUserReconciler) requestsForDatabaseChange(ctx context.Context, o client.Object) []reconcile.Request {
-	s, ok := o.(*infrav1beta1.MongoDBDatabase)
+	s, ok := o.(*infrav1.MongoDBDatabase)
 	if !ok {
 		panic(fmt.Sprintf("expected a MongoDBDatabase, got %T", o))
 	}
 
-	var list infrav1beta1.MongoDBUserList
+	var list infrav1.MongoDBUserList
 	if err := r.List(ctx, &list, client.MatchingFields{
 		dbIndexKey: objectKey(s).String(),
 	}); err != nil {
@@ -139,22 +153,28 @@ func (r *MongoDBUserReconciler) requestsForDatabaseChange(ctx context.Context, o
 }
 
 func (r *MongoDBUserReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx, span := tracing.Start(ctx, "MongoDBUser.Reconcile", tracing.Object("MongoDBUser", req.NamespacedName)...)
+	defer span.End()
+
 	logger := r.Log.WithValues("MongoDBUser", req.NamespacedName)
 	logger.Info("reconciling MongoDBUser")
 
 	// get database resource by namespaced name
-	var user infrav1beta1.MongoDBUser
+	var user infrav1.MongoDBUser
 	if err := r.Get(ctx, req.NamespacedName, &user); err != nil {
 		if apierrors.IsNotFound(err) {
+			metrics.Users.Forget("MongoDBUser", req.NamespacedName)
 			return ctrl.Result{}, nil
 		}
 		return ctrl.Result{}, err
 	}
 
+	ctx = audit.WithSource(ctx, r.triggers.Source("MongoDBUser", &user, user.Status.ObservedGeneration))
+
 	// examine DeletionTimestamp to determine if object is under deletion
 	if user.DeletionTimestamp.IsZero() {
-		if !stringutils.ContainsString(user.GetFinalizers(), infrav1beta1.Finalizer) {
-			controllerutil.AddFinalizer(&user, infrav1beta1.Finalizer)
+		if !stringutils.ContainsString(user.GetFinalizers(), infrav1.Finalizer) {
+			controllerutil.AddFinalizer(&user, infrav1.Finalizer)
 			if err := r.Update(ctx, &user); err != nil {
 				return ctrl.Result{}, err
 			}
@@ -162,6 +182,7 @@ func (r *MongoDBUserReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	}
 
 	user, res, reconcileErr := r.reconcile(ctx, user)
+	tracing.RecordError(span, reconcileErr)
 	user.Status.ObservedGeneration = user.GetGeneration()
 
 	if reconcileErr != nil {
@@ -169,12 +190,15 @@ func (r *MongoDBUserReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	} else if !isUserExpired(user.Status.Conditions) {
 		msg := "User successfully provisioned"
 		r.Recorder.Eventf(&user, nil, "Normal", "info", "Reconcile", "%s", msg)
-		infrav1beta1.UserReadyCondition(&user, infrav1beta1.UserProvisioningSuccessfulReason, msg)
+		infrav1.UserReadyCondition(&user, infrav1.UserProvisioningSuccessfulReason, msg)
 	} else {
 		msg := "User has expired and was disabled"
 		r.Recorder.Eventf(&user, nil, "Normal", "info", "Reconcile", "%s", msg)
 	}
 
+	recordReconcile("MongoDBUser", user.Status.Conditions, infrav1.UserReadyConditionType)
+	recordUser("MongoDBUser", &user, user.Spec.ValidUntil, user.Status.Conditions, reconcileErr)
+
 	// Update status after reconciliation.
 	if err := r.patchStatus(ctx, &user); err != nil {
 		logger.Error(err, "unable to update status after reconciliation")
@@ -184,10 +208,10 @@ func (r *MongoDBUserReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	return res, reconcileErr
 }
 
-func (r *MongoDBUserReconciler) reconcile(ctx context.Context, user infrav1beta1.MongoDBUser) (infrav1beta1.MongoDBUser, ctrl.Result, error) {
+func (r *MongoDBUserReconciler) reconcile(ctx context.Context, user infrav1.MongoDBUser) (infrav1.MongoDBUser, ctrl.Result, error) {
 	res := ctrl.Result{}
 	// Fetch referencing database
-	var db infrav1beta1.MongoDBDatabase
+	var db infrav1.MongoDBDatabase
 	databaseName := types.NamespacedName{
 		Namespace: user.GetNamespace(),
 		Name:      user.GetDatabase(),
@@ -196,7 +220,7 @@ func (r *MongoDBUserReconciler) reconcile(ctx context.Context, user infrav1beta1
 	err := r.Get(ctx, databaseName, &db)
 	if err != nil {
 		err = fmt.Errorf("referencing database was not found: %w", err)
-		infrav1beta1.UserNotReadyCondition(&user, infrav1beta1.DatabaseNotFoundReason, err.Error())
+		infrav1.UserNotReadyCondition(&user, infrav1.DatabaseNotFoundReason, err.Error())
 		return user, res, err
 	}
 
@@ -206,172 +230,150 @@ func (r *MongoDBUserReconciler) reconcile(ctx context.Context, user infrav1beta1
 		defer cancel()
 	}
 
-	if db.Spec.AtlasGroupId != "" {
-		return r.reconcileAtlasUser(ctx, user, db, res)
-	}
-
-	return r.reconcileGenericUser(ctx, user, db, res)
-}
-
-func (r *MongoDBUserReconciler) reconcileGenericUser(ctx context.Context, user infrav1beta1.MongoDBUser, db infrav1beta1.MongoDBDatabase, res ctrl.Result) (infrav1beta1.MongoDBUser, ctrl.Result, error) {
 	// Fetch referencing root secret
-	rootUsr, rootPw, _, err := getSecret(ctx, r.Client, db.GetRootSecret())
+	rootUsr, rootPw, _, err := getMongoDBRootSecret(ctx, r.Client, db)
 
 	if err != nil {
-		infrav1beta1.UserNotReadyCondition(&user, infrav1beta1.CredentialsNotFoundReason, err.Error())
+		infrav1.UserNotReadyCondition(&user, infrav1.CredentialsNotFoundReason, err.Error())
 		return user, res, err
 	}
 
-	// Fetch referencing secret
-	usr, pw, addr, err := getSecret(ctx, r.Client, user.GetCredentials())
+	isAtlas := db.IsAtlas()
+
+	// Fetch referencing secret, Atlas users authenticating externally don't require a password
+	var usr, pw, addr string
+	if !isAtlas || user.RequiresPassword() {
+		usr, pw, addr, err = getSecret(ctx, r.Client, user.GetCredentials())
+	} else {
+		usr, addr, err = getSecretUsername(ctx, r.Client, user.GetCredentials())
+		if err == nil && usr == "" {
+			err = errors.New("defined username field not found in secret")
+		}
+	}
 
 	if err != nil {
-		infrav1beta1.UserNotReadyCondition(&user, infrav1beta1.CredentialsNotFoundReason, err.Error())
+		infrav1.UserNotReadyCondition(&user, infrav1.CredentialsNotFoundReason, err.Error())
 		return user, res, err
 	}
 
 	user.Status.Username = usr
 
-	dbHandler, err := setupMongoDB(ctx, db, rootUsr, rootPw, addr)
+	var dbHandler database.UserProvisioner
+	if isAtlas {
+		dbHandler, err = setupAtlas[database.UserProvisioner](ctx, r.Provisioners, db, rootUsr, rootPw)
+	} else {
+		dbHandler, err = setupMongoDB[database.UserProvisioner](ctx, r.Client, r.Provisioners, db, rootUsr, rootPw, addr)
+	}
 
 	if err != nil {
-		infrav1beta1.UserNotReadyCondition(&user, infrav1beta1.ConnectionFailedReason, err.Error())
+		infrav1.UserNotReadyCondition(&user, errorReason(err, infrav1.ConnectionFailedReason), err.Error())
 		return user, res, err
 	}
 
 	defer func() { _ = dbHandler.Close(ctx) }()
 
 	if !user.DeletionTimestamp.IsZero() {
-		user, err := r.finalizeUser(ctx, user, db, dbHandler)
+		err := finalizeUser(ctx, r.Client, r.Recorder, &user, dbHandler, r.statusUser(user, db), true)
 		return user, res, err
 	}
 
+	userSpec := database.User{
+		Database: db.GetDatabaseName(),
+		Username: usr,
+		Password: pw,
+		Roles:    extractMongoDBUserRoles(user.GetRoles()),
+	}
+
+	now := time.Now().UTC()
 	if user.Spec.ValidUntil != nil {
 		validUntil := user.Spec.ValidUntil.UTC()
-		now := time.Now().UTC()
 
 		if !validUntil.After(now) {
-			user, err := r.disableUser(ctx, user, db, dbHandler)
-			if err != nil {
+			// MongoDB has no way to block the logins of a user, expired users get dropped
+			if err := dropUser(ctx, r.Recorder, &user, dbHandler, r.statusUser(user, db)); err != nil {
 				return user, res, err
 			}
-			infrav1beta1.UserNotReadyCondition(
+			infrav1.UserNotReadyCondition(
 				&user,
-				infrav1beta1.UserExpiredReason,
+				infrav1.UserExpiredReason,
 				"User has expired and was disabled",
 			)
-			return user, res, err
+			return user, res, nil
 		}
-		res.RequeueAfter = validUntil.Sub(now)
-	}
-
-	err = dbHandler.SetupUser(ctx, db.GetDatabaseName(), usr, pw, extractMongoDBUserRoles(user.GetRoles()))
-	if err != nil {
-		err = fmt.Errorf("failed to provision user account: %w", err)
-		infrav1beta1.UserNotReadyCondition(&user, infrav1beta1.ConnectionFailedReason, err.Error())
-		return user, res, err
-	}
-
-	return user, res, nil
-}
-
-func (r *MongoDBUserReconciler) reconcileAtlasUser(ctx context.Context, user infrav1beta1.MongoDBUser, db infrav1beta1.MongoDBDatabase, res ctrl.Result) (infrav1beta1.MongoDBUser, ctrl.Result, error) {
-	// Fetch referencing root secret
-	pubKey, privKey, _, err := getSecret(ctx, r.Client, db.GetRootSecret())
-
-	if err != nil {
-		infrav1beta1.UserNotReadyCondition(&user, infrav1beta1.CredentialsNotFoundReason, err.Error())
-		return user, res, err
-	}
-
-	// Fetch referencing secret
-	usr, pw, addr, err := getSecret(ctx, r.Client, user.GetCredentials())
 
-	if err != nil {
-		infrav1beta1.UserNotReadyCondition(&user, infrav1beta1.CredentialsNotFoundReason, err.Error())
-		return user, res, err
+		res.RequeueAfter = validUntil.Sub(now)
+		userSpec.ValidUntil = &validUntil
 	}
 
-	user.Status.Username = usr
-
-	dbHandler, err := setupAtlas(ctx, db, pubKey, privKey, addr)
-
-	if err != nil {
-		infrav1beta1.UserNotReadyCondition(&user, infrav1beta1.ConnectionFailedReason, err.Error())
-		return user, res, err
+	// The credentials hash includes the authentication mechanisms of MongoDB users, Atlas manages them itself
+	mongoDBOpts := extractMongoDBUserOptions(user)
+	credentials := []string{usr, pw}
+	if !isAtlas {
+		credentials = append(credentials, strings.Join(mongoDBOpts.Mechanisms, ","))
 	}
 
-	defer func() { _ = dbHandler.Close(ctx) }()
+	updatePassword := !credentialsMatch(user.Status.CredentialsHash, user.Status.CredentialsSalt, credentials...)
 
-	if !user.DeletionTimestamp.IsZero() {
-		user, err := r.finalizeUser(ctx, user, db, dbHandler)
-		return user, res, err
-	}
-
-	if user.Spec.ValidUntil != nil {
-		validUntil := user.Spec.ValidUntil.UTC()
-		now := time.Now().UTC()
+	if isAtlas {
+		atlasOpts := extractAtlasUserOptions(user)
+		atlasOpts.UpdatePassword = updatePassword
 
-		if !validUntil.After(now) {
-			user, err := r.disableUser(ctx, user, db, dbHandler)
-			if err != nil {
-				return user, res, err
+		// Atlas only accepts a deleteAfterDate within a week, requeue once ValidUntil enters that window
+		if validUntil := userSpec.ValidUntil; validUntil != nil {
+			if deleteAfterWindow := validUntil.Add(-atlasMaxDeleteAfter); deleteAfterWindow.After(now) {
+				res.RequeueAfter = deleteAfterWindow.Sub(now)
+			} else {
+				atlasOpts.DeleteAfterDate = validUntil
 			}
-			infrav1beta1.UserNotReadyCondition(
-				&user,
-				infrav1beta1.UserExpiredReason,
-				"User has expired and was disabled",
-			)
-			return user, res, err
 		}
 
-		res.RequeueAfter = validUntil.Sub(now)
+		userSpec.Options = atlasOpts
+	} else {
+		mongoDBOpts.UpdatePassword = updatePassword
+		userSpec.Options = mongoDBOpts
 	}
 
-	err = dbHandler.SetupUser(ctx, db.GetDatabaseName(), usr, pw, extractMongoDBUserRoles(user.GetRoles()))
+	err = dbHandler.SetupUser(ctx, userSpec)
 	if err != nil {
 		err = fmt.Errorf("failed to provision user account: %w", err)
-		infrav1beta1.UserNotReadyCondition(&user, infrav1beta1.ConnectionFailedReason, err.Error())
+		infrav1.UserNotReadyCondition(&user, errorReason(err, infrav1.ConnectionFailedReason), err.Error())
 		return user, res, err
 	}
 
+	if updatePassword {
+		return r.storeCredentialsHash(user, res, credentials...)
+	}
+
 	return user, res, nil
 }
 
-func (r *MongoDBUserReconciler) finalizeUser(ctx context.Context, user infrav1beta1.MongoDBUser, db infrav1beta1.MongoDBDatabase, userDropper userDropper) (infrav1beta1.MongoDBUser, error) {
-
-	user, err := r.disableUser(ctx, user, db, userDropper)
-	if err != nil {
-		return user, err
-	}
-	if stringutils.ContainsString(user.Finalizers, infrav1beta1.Finalizer) {
-		user.Finalizers = stringutils.RemoveString(user.Finalizers, infrav1beta1.Finalizer)
-		if err := r.Update(ctx, &user); err != nil {
-			return user, err
+// storeCredentialsHash records the applied credentials so the password is not reset on subsequent reconciliations.
+// Each user gets its own random salt which is kept in the status along with the hash.
+func (r *MongoDBUserReconciler) storeCredentialsHash(user infrav1.MongoDBUser, res ctrl.Result, credentials ...string) (infrav1.MongoDBUser, ctrl.Result, error) {
+	if user.Status.CredentialsSalt == "" {
+		user.Status.CredentialsSalt = generateToken(32)
+		if user.Status.CredentialsSalt == "" {
+			err := errors.New("failed to generate credentials salt")
+			infrav1.UserNotReadyCondition(&user, infrav1.UserNotProvisionedReason, err.Error())
+			return user, res, err
 		}
 	}
 
-	return user, nil
+	user.Status.CredentialsHash = hashCredentials(user.Status.CredentialsSalt, credentials...)
+	return user, res, nil
 }
 
-func (r *MongoDBUserReconciler) disableUser(ctx context.Context, user infrav1beta1.MongoDBUser, db infrav1beta1.MongoDBDatabase, userDropper userDropper) (infrav1beta1.MongoDBUser, error) {
-
-	if user.Status.Username == "" {
-		return user, nil
-	}
-
-	err := userDropper.DropUser(ctx, db.GetDatabaseName(), user.Status.Username)
-	if err != nil {
-		err = fmt.Errorf("failed to remove user account: %w", err)
-		infrav1beta1.UserNotReadyCondition(&user, infrav1beta1.ConnectionFailedReason, err.Error())
-		return user, err
+// statusUser returns the user last provisioned by the controller, it lives in the database users authenticate against
+func (r *MongoDBUserReconciler) statusUser(user infrav1.MongoDBUser, db infrav1.MongoDBDatabase) database.User {
+	return database.User{
+		Database: getMongoDBUserDatabase(user, db),
+		Username: user.Status.Username,
 	}
-	return user, nil
 }
 
-func (r *MongoDBUserReconciler) patchStatus(ctx context.Context, database *infrav1beta1.MongoDBUser) error {
+func (r *MongoDBUserReconciler) patchStatus(ctx context.Context, database *infrav1.MongoDBUser) error {
 	key := client.ObjectKeyFromObject(database)
-	latest := &infrav1beta1.MongoDBUser{}
+	latest := &infrav1.MongoDBUser{}
 	if err := r.Get(ctx, key, latest); err != nil {
 		return err
 	}
@@ -0,0 +1,785 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"time"
+
+	_ "github.com/microsoft/go-mssqldb"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	infrav1 "github.com/doodlescheduling/db-controller/api/v1"
+)
+
+const (
+	mssqlRootUsername = "sa"
+	mssqlRootPassword = "Db-Controller-1234"
+)
+
+type mssqlContainer struct {
+	testcontainers.Container
+	Addr string
+	URI  string
+}
+
+func setupMSSQLContainer(ctx context.Context, image string) (*mssqlContainer, error) {
+	req := testcontainers.ContainerRequest{
+		Image:        image,
+		ExposedPorts: []string{"1433/tcp"},
+		WaitingFor: wait.ForSQL("1433/tcp", "sqlserver", func(host string, port string) string {
+			return mssqlDSN(fmt.Sprintf("%s:%s", host, port), mssqlRootUsername, mssqlRootPassword, "")
+		}).WithStartupTimeout(180 * time.Second),
+		Env: map[string]string{
+			"ACCEPT_EULA":       "Y",
+			"MSSQL_SA_PASSWORD": mssqlRootPassword,
+		},
+	}
+
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	host, err := container.Host(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	port, err := container.MappedPort(ctx, "1433/tcp")
+	if err != nil {
+		return nil, err
+	}
+
+	addr := fmt.Sprintf("%s:%s", host, port.Port())
+	return &mssqlContainer{Container: container, Addr: addr, URI: "sqlserver://" + addr}, nil
+}
+
+func mssqlDSN(addr, username, password, database string) string {
+	u := &url.URL{
+		Scheme: "sqlserver",
+		User:   url.UserPassword(username, password),
+		Host:   addr,
+	}
+
+	q := u.Query()
+	q.Set("dial timeout", "2")
+	if database != "" {
+		q.Set("database", database)
+	}
+
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// mssqlConnect opens a single session to the server
+func mssqlConnect(addr, username, password, database string) (*sql.Conn, error) {
+	db, err := sql.Open("sqlserver", mssqlDSN(addr, username, password, database))
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	if err := conn.PingContext(context.Background()); err != nil {
+		_ = conn.Close()
+		_ = db.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+var _ = Describe("MSSQL", func() {
+	const (
+		timeout  = time.Second * 5
+		interval = time.Second * 1
+	)
+
+	for _, image := range []string{"mcr.microsoft.com/mssql/server:2019-latest", "mcr.microsoft.com/mssql/server:2022-latest"} {
+		var _ = Describe(image, func() {
+			var (
+				container *mssqlContainer
+				err       error
+			)
+
+			container, err = setupMSSQLContainer(context.Background(), image)
+			Expect(err).NotTo(HaveOccurred(), "failed to start mssql container")
+
+			rootQuery := func(database, query string, args ...interface{}) *sql.Row {
+				conn, err := mssqlConnect(container.Addr, mssqlRootUsername, mssqlRootPassword, database)
+				Expect(err).NotTo(HaveOccurred(), "failed to connect to mssql")
+				defer func() { _ = conn.Close() }()
+
+				return conn.QueryRowContext(context.Background(), query, args...)
+			}
+
+			rootExec := func(database, query string) {
+				conn, err := mssqlConnect(container.Addr, mssqlRootUsername, mssqlRootPassword, database)
+				Expect(err).NotTo(HaveOccurred(), "failed to connect to mssql")
+				defer func() { _ = conn.Close() }()
+
+				_, err = conn.ExecContext(context.Background(), query)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			createRootSecret := func(namespace string) *infrav1.SecretReference {
+				secret := &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "mssql-root-" + randStringRunes(5),
+						Namespace: namespace,
+					},
+					Data: map[string][]byte{
+						"username": []byte(mssqlRootUsername),
+						"password": []byte(mssqlRootPassword),
+					},
+				}
+
+				Expect(k8sClient.Create(context.Background(), secret)).Should(Succeed())
+				return &infrav1.SecretReference{
+					Name: secret.Name,
+				}
+			}
+
+			createDatabase := func(namespace string, rootSecret *infrav1.SecretReference) types.NamespacedName {
+				key := types.NamespacedName{
+					Name:      "mssqldatabase-" + randStringRunes(5),
+					Namespace: namespace,
+				}
+
+				db := &infrav1.MSSQLDatabase{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      key.Name,
+						Namespace: key.Namespace,
+					},
+					Spec: infrav1.MSSQLDatabaseSpec{
+						DatabaseSpec: infrav1.DatabaseSpec{
+							Timeout: &metav1.Duration{
+								Duration: time.Second * 5,
+							},
+							Address:    container.URI,
+							RootSecret: rootSecret,
+						},
+					},
+				}
+
+				Expect(k8sClient.Create(context.Background(), db)).Should(Succeed())
+
+				got := &infrav1.MSSQLDatabase{}
+				Eventually(func() bool {
+					_ = k8sClient.Get(context.Background(), key, got)
+					return len(got.Status.Conditions) == 1 &&
+						got.Status.Conditions[0].Reason == infrav1.DatabaseProvisioningSuccessfulReason &&
+						got.Status.Conditions[0].Status == "True"
+				}, timeout, interval).Should(BeTrue())
+
+				return key
+			}
+
+			createSecret := func(namespace, username, password string) types.NamespacedName {
+				key := types.NamespacedName{
+					Name:      "secret-" + randStringRunes(5),
+					Namespace: namespace,
+				}
+
+				Expect(k8sClient.Create(context.Background(), &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      key.Name,
+						Namespace: key.Namespace,
+					},
+					Data: map[string][]byte{
+						"username": []byte(username),
+						"password": []byte(password),
+					},
+				})).Should(Succeed())
+
+				return key
+			}
+
+			createUser := func(namespace, database, secret string, policy infrav1.DeletionPolicy) *infrav1.MSSQLUser {
+				user := &infrav1.MSSQLUser{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "mssqluser-" + randStringRunes(5),
+						Namespace: namespace,
+					},
+					Spec: infrav1.MSSQLUserSpec{
+						Database: &infrav1.DatabaseReference{
+							Name: database,
+						},
+						Credentials: &infrav1.SecretReference{
+							Name: secret,
+						},
+						DeletionPolicy: policy,
+					},
+				}
+
+				Expect(k8sClient.Create(context.Background(), user)).Should(Succeed())
+				return user
+			}
+
+			userReady := func(key types.NamespacedName) func() bool {
+				return func() bool {
+					got := &infrav1.MSSQLUser{}
+					_ = k8sClient.Get(context.Background(), key, got)
+					return len(got.Status.Conditions) == 1 &&
+						got.Status.Conditions[0].Reason == infrav1.UserProvisioningSuccessfulReason &&
+						got.Status.Conditions[0].Status == "True" &&
+						got.Status.Conditions[0].Type == infrav1.UserReadyConditionType &&
+						got.ObjectMeta.Generation == got.Status.ObservedGeneration
+				}
+			}
+
+			updateUser := func(key types.NamespacedName, mutate func(user *infrav1.MSSQLUser)) {
+				Eventually(func() error {
+					user := &infrav1.MSSQLUser{}
+					if err := k8sClient.Get(context.Background(), key, user); err != nil {
+						return err
+					}
+
+					mutate(user)
+					return k8sClient.Update(context.Background(), user)
+				}, timeout, interval).Should(Succeed())
+			}
+
+			userGone := func(key types.NamespacedName) {
+				Eventually(func() error {
+					return k8sClient.Get(context.Background(), key, &infrav1.MSSQLUser{})
+				}, timeout, interval).ShouldNot(Succeed())
+			}
+
+			loginCount := func(username string) int64 {
+				var count int64
+				Expect(rootQuery("", "SELECT COUNT(*) FROM sys.server_principals WHERE name = @p1", username).Scan(&count)).To(Succeed())
+				return count
+			}
+
+			databaseUserCount := func(database, username string) int64 {
+				var count int64
+				Expect(rootQuery(database, "SELECT COUNT(*) FROM sys.database_principals WHERE name = @p1", username).Scan(&count)).To(Succeed())
+				return count
+			}
+
+			Describe("fails if database can't be reached", Ordered, func() {
+				var keyDB types.NamespacedName
+
+				namespace, rootSecret := setupNamespace()
+
+				It("adds database", func() {
+					keyDB = types.NamespacedName{
+						Name:      "mssqldatabase-" + randStringRunes(5),
+						Namespace: namespace.Name,
+					}
+					createdDB := &infrav1.MSSQLDatabase{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      keyDB.Name,
+							Namespace: keyDB.Namespace,
+						},
+						Spec: infrav1.MSSQLDatabaseSpec{
+							DatabaseSpec: infrav1.DatabaseSpec{
+								Timeout: &metav1.Duration{
+									Duration: time.Millisecond * 100,
+								},
+								Address: "sqlserver://does-not-exist:1433",
+								RootSecret: &infrav1.SecretReference{
+									Name: rootSecret.Name,
+								},
+							},
+						},
+					}
+					Expect(k8sClient.Create(context.Background(), createdDB)).Should(Succeed())
+				})
+
+				It("fails reconcile because database can't be reached", func() {
+					got := &infrav1.MSSQLDatabase{}
+					Eventually(func() bool {
+						_ = k8sClient.Get(context.Background(), keyDB, got)
+						return len(got.Status.Conditions) == 1 &&
+							got.Status.Conditions[0].Reason == infrav1.ConnectionFailedReason &&
+							got.Status.Conditions[0].Status == "False" &&
+							got.Status.Conditions[0].Type == infrav1.DatabaseReadyConditionType
+					}, timeout, interval).Should(BeTrue())
+				})
+			})
+
+			Describe("Successful user creation", Ordered, func() {
+				var (
+					createdUser   *infrav1.MSSQLUser
+					createdSecret *corev1.Secret
+					keyUser       types.NamespacedName
+					keyDB         types.NamespacedName
+					keySecret     types.NamespacedName
+					password      string
+				)
+
+				namespace, _ := setupNamespace()
+
+				Describe("creates database with collation", Ordered, func() {
+					It("adds database", func() {
+						keyDB = types.NamespacedName{
+							Name:      "mssqldatabase-" + randStringRunes(5),
+							Namespace: namespace.Name,
+						}
+						createdDB := &infrav1.MSSQLDatabase{
+							ObjectMeta: metav1.ObjectMeta{
+								Name:      keyDB.Name,
+								Namespace: keyDB.Namespace,
+							},
+							Spec: infrav1.MSSQLDatabaseSpec{
+								DatabaseSpec: infrav1.DatabaseSpec{
+									Timeout: &metav1.Duration{
+										Duration: time.Second * 5,
+									},
+									Address:    container.URI,
+									RootSecret: createRootSecret(namespace.Name),
+								},
+								Collation: "Latin1_General_CS_AS",
+							},
+						}
+
+						Expect(k8sClient.Create(context.Background(), createdDB)).Should(Succeed())
+					})
+
+					It("expects ready database", func() {
+						got := &infrav1.MSSQLDatabase{}
+						Eventually(func() bool {
+							_ = k8sClient.Get(context.Background(), keyDB, got)
+							return len(got.Status.Conditions) == 1 &&
+								got.Status.Conditions[0].Reason == infrav1.DatabaseProvisioningSuccessfulReason &&
+								got.Status.Conditions[0].Status == "True" &&
+								got.Status.Conditions[0].Type == infrav1.DatabaseReadyConditionType
+						}, timeout, interval).Should(BeTrue())
+					})
+
+					It("created the database with the collation", func() {
+						var collation string
+						Expect(rootQuery("", "SELECT collation_name FROM sys.databases WHERE name = @p1", keyDB.Name).Scan(&collation)).To(Succeed())
+						Expect(collation).To(Equal("Latin1_General_CS_AS"))
+					})
+
+					It("adds tables and a custom role", func() {
+						rootExec(keyDB.Name, "CREATE TABLE foo (id integer);")
+						rootExec(keyDB.Name, "CREATE ROLE app_reader;")
+						rootExec(keyDB.Name, "GRANT SELECT ON foo TO app_reader;")
+					})
+				})
+
+				Describe("creates login and user if it does not exists", Ordered, func() {
+					It("adds secret", func() {
+						keyUser = types.NamespacedName{
+							Name:      "mssqluser-" + randStringRunes(5),
+							Namespace: namespace.Name,
+						}
+						keySecret = types.NamespacedName{
+							Name:      "secret-" + randStringRunes(5),
+							Namespace: namespace.Name,
+						}
+						password = randStringRunes(5)
+						createdSecret = &corev1.Secret{
+							ObjectMeta: metav1.ObjectMeta{
+								Name:      keySecret.Name,
+								Namespace: keySecret.Namespace,
+							},
+							Data: map[string][]byte{
+								"username": []byte(keyUser.Name),
+								"password": []byte(password),
+							},
+						}
+						Expect(k8sClient.Create(context.Background(), createdSecret)).Should(Succeed())
+					})
+
+					It("adds user", func() {
+						createdUser = &infrav1.MSSQLUser{
+							ObjectMeta: metav1.ObjectMeta{
+								Name:      keyUser.Name,
+								Namespace: keyUser.Namespace,
+							},
+							Spec: infrav1.MSSQLUserSpec{
+								Database: &infrav1.DatabaseReference{
+									Name: keyDB.Name,
+								},
+								Credentials: &infrav1.SecretReference{
+									Name: keySecret.Name,
+								},
+								Roles:          []string{"db_datareader", "db_datawriter"},
+								DeletionPolicy: infrav1.DeletionPolicyDrop,
+							},
+						}
+						Expect(k8sClient.Create(context.Background(), createdUser)).Should(Succeed())
+					})
+
+					It("expects ready user", func() {
+						Eventually(userReady(keyUser), timeout, interval).Should(BeTrue())
+					})
+
+					It("created the login and the database user", func() {
+						Expect(loginCount(keyUser.Name)).To(Equal(int64(1)))
+						Expect(databaseUserCount(keyDB.Name, keyUser.Name)).To(Equal(int64(1)))
+					})
+
+					It("can read and write the created database", func() {
+						var conn *sql.Conn
+						Eventually(func() error {
+							c, err := mssqlConnect(container.Addr, keyUser.Name, password, keyDB.Name)
+							conn = c
+							return err
+						}, timeout, interval).Should(Succeed())
+
+						defer func() { _ = conn.Close() }()
+
+						_, err := conn.ExecContext(context.Background(), "INSERT INTO foo VALUES (1);")
+						Expect(err).NotTo(HaveOccurred())
+
+						_, err = conn.ExecContext(context.Background(), "SELECT * FROM foo;")
+						Expect(err).NotTo(HaveOccurred())
+					})
+
+					It("can't create tables without db_ddladmin", func() {
+						conn, err := mssqlConnect(container.Addr, keyUser.Name, password, keyDB.Name)
+						Expect(err).NotTo(HaveOccurred())
+						defer func() { _ = conn.Close() }()
+
+						_, err = conn.ExecContext(context.Background(), "CREATE TABLE bar (id integer);")
+						Expect(err).To(HaveOccurred())
+					})
+
+					It("has no access to another database", func() {
+						_, err := mssqlConnect(container.Addr, keyUser.Name, password, "msdb")
+						Expect(err).To(HaveOccurred())
+					})
+
+					It("can't access the created database with invalid credentials", func() {
+						_, err := mssqlConnect(container.Addr, keyUser.Name, "invalid-password", keyDB.Name)
+						Expect(err).To(HaveOccurred())
+					})
+				})
+
+				Describe("Change password for user", Ordered, func() {
+					It("changes password in referenced user secret", func() {
+						password = randStringRunes(5)
+						createdSecret.Data = map[string][]byte{
+							"username": []byte(keyUser.Name),
+							"password": []byte(password),
+						}
+						Expect(k8sClient.Update(context.Background(), createdSecret)).Should(Succeed())
+					})
+
+					It("can access the database with the new password", func() {
+						Eventually(func() error {
+							conn, err := mssqlConnect(container.Addr, keyUser.Name, password, keyDB.Name)
+							if err == nil {
+								_ = conn.Close()
+							}
+							return err
+						}, timeout, interval).Should(Succeed())
+					})
+				})
+
+				Describe("Roles", Ordered, func() {
+					It("replaces the fixed roles with the custom role", func() {
+						updateUser(keyUser, func(user *infrav1.MSSQLUser) {
+							user.Spec.Roles = []string{"app_reader"}
+						})
+
+						Eventually(userReady(keyUser), timeout, interval).Should(BeTrue())
+					})
+
+					It("can only select from table foo", func() {
+						conn, err := mssqlConnect(container.Addr, keyUser.Name, password, keyDB.Name)
+						Expect(err).NotTo(HaveOccurred())
+						defer func() { _ = conn.Close() }()
+
+						_, err = conn.ExecContext(context.Background(), "SELECT * FROM foo;")
+						Expect(err).NotTo(HaveOccurred())
+
+						_, err = conn.ExecContext(context.Background(), "INSERT INTO foo VALUES (1);")
+						Expect(err).To(HaveOccurred())
+					})
+
+					It("fails if a role does not exist", func() {
+						updateUser(keyUser, func(user *infrav1.MSSQLUser) {
+							user.Spec.Roles = []string{"does_not_exist"}
+						})
+
+						got := &infrav1.MSSQLUser{}
+						Eventually(func() bool {
+							_ = k8sClient.Get(context.Background(), keyUser, got)
+							return len(got.Status.Conditions) == 1 &&
+								got.Status.Conditions[0].Status == "False" &&
+								got.ObjectMeta.Generation == got.Status.ObservedGeneration
+						}, timeout, interval).Should(BeTrue())
+					})
+
+					It("grants db_owner again", func() {
+						updateUser(keyUser, func(user *infrav1.MSSQLUser) {
+							user.Spec.Roles = []string{"db_owner"}
+						})
+
+						Eventually(userReady(keyUser), timeout, interval).Should(BeTrue())
+					})
+				})
+
+				Describe("ValidUntil", Ordered, func() {
+					var session *sql.Conn
+
+					It("opens a session before validUntil expires", func() {
+						Eventually(func() error {
+							c, err := mssqlConnect(container.Addr, keyUser.Name, password, keyDB.Name)
+							session = c
+							return err
+						}, timeout, interval).Should(Succeed())
+					})
+
+					It("sets validUntil in the past for the user", func() {
+						updateUser(keyUser, func(user *infrav1.MSSQLUser) {
+							validUntil := metav1.NewTime(time.Now().Add(-1 * time.Hour).UTC())
+							user.Spec.ValidUntil = &validUntil
+						})
+					})
+
+					It("sets expired status after validUntil expires", func() {
+						got := &infrav1.MSSQLUser{}
+
+						Eventually(func() bool {
+							_ = k8sClient.Get(context.Background(), keyUser, got)
+
+							return len(got.Status.Conditions) == 1 &&
+								got.Status.Conditions[0].Reason == infrav1.UserExpiredReason &&
+								got.Status.Conditions[0].Status == "False" &&
+								got.ObjectMeta.Generation == got.Status.ObservedGeneration
+						}, timeout, interval).Should(BeTrue())
+					})
+
+					It("cannot access the database after validUntil expired", func() {
+						_, err := mssqlConnect(container.Addr, keyUser.Name, password, keyDB.Name)
+						Expect(err).To(HaveOccurred())
+					})
+
+					It("terminated the open session after validUntil expired", func() {
+						Eventually(func() error {
+							_, err := session.ExecContext(context.Background(), "SELECT 1;")
+							return err
+						}, timeout, interval).ShouldNot(Succeed())
+					})
+
+					It("clears validUntil for the user", func() {
+						updateUser(keyUser, func(user *infrav1.MSSQLUser) {
+							user.Spec.ValidUntil = nil
+						})
+
+						Eventually(userReady(keyUser), timeout, interval).Should(BeTrue())
+					})
+
+					It("can access the database again after clearing validUntil", func() {
+						Eventually(func() error {
+							conn, err := mssqlConnect(container.Addr, keyUser.Name, password, keyDB.Name)
+							if err == nil {
+								_ = conn.Close()
+							}
+							return err
+						}, timeout, interval).Should(Succeed())
+					})
+				})
+
+				Describe("Delete user drops login and user from mssql", Ordered, func() {
+					It("deletes user", func() {
+						Expect(k8sClient.Delete(context.Background(), createdUser)).Should(Succeed())
+					})
+
+					It("expects gone", func() {
+						userGone(keyUser)
+					})
+
+					It("dropped the database user and the login", func() {
+						Expect(databaseUserCount(keyDB.Name, keyUser.Name)).To(Equal(int64(0)))
+						Expect(loginCount(keyUser.Name)).To(Equal(int64(0)))
+					})
+				})
+			})
+
+			Describe("Delete user after the database was dropped", Ordered, func() {
+				var (
+					keyDB    types.NamespacedName
+					username string
+					user     *infrav1.MSSQLUser
+				)
+
+				namespace, _ := setupNamespace()
+
+				It("adds database and user", func() {
+					keyDB = createDatabase(namespace.Name, createRootSecret(namespace.Name))
+					username = "mssqllogin-" + randStringRunes(5)
+					keySecret := createSecret(namespace.Name, username, randStringRunes(5))
+
+					user = createUser(namespace.Name, keyDB.Name, keySecret.Name, infrav1.DeletionPolicyDrop)
+					Eventually(userReady(objectKey(user)), timeout, interval).Should(BeTrue())
+				})
+
+				It("drops the database", func() {
+					rootExec("", fmt.Sprintf("ALTER DATABASE [%s] SET SINGLE_USER WITH ROLLBACK IMMEDIATE; DROP DATABASE [%s];", keyDB.Name, keyDB.Name))
+				})
+
+				It("deletes user", func() {
+					Expect(k8sClient.Delete(context.Background(), user)).Should(Succeed())
+					userGone(objectKey(user))
+				})
+
+				It("dropped the login", func() {
+					Expect(loginCount(username)).To(Equal(int64(0)))
+				})
+			})
+
+			Describe("Delete user after its secret was deleted", Ordered, func() {
+				var (
+					keySecret types.NamespacedName
+					username  string
+					user      *infrav1.MSSQLUser
+				)
+
+				namespace, _ := setupNamespace()
+
+				It("adds database and user", func() {
+					keyDB := createDatabase(namespace.Name, createRootSecret(namespace.Name))
+					username = "mssqllogin-" + randStringRunes(5)
+					keySecret = createSecret(namespace.Name, username, randStringRunes(5))
+
+					user = createUser(namespace.Name, keyDB.Name, keySecret.Name, infrav1.DeletionPolicyDrop)
+					Eventually(userReady(objectKey(user)), timeout, interval).Should(BeTrue())
+				})
+
+				It("deletes the secret and the user", func() {
+					Expect(k8sClient.Delete(context.Background(), &corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{Name: keySecret.Name, Namespace: keySecret.Namespace},
+					})).Should(Succeed())
+					Expect(k8sClient.Delete(context.Background(), user)).Should(Succeed())
+					userGone(objectKey(user))
+				})
+
+				It("dropped the login", func() {
+					Expect(loginCount(username)).To(Equal(int64(0)))
+				})
+			})
+
+			Describe("Delete user after its database resource was deleted", Ordered, func() {
+				var (
+					keyDB types.NamespacedName
+					user  *infrav1.MSSQLUser
+				)
+
+				namespace, _ := setupNamespace()
+
+				It("adds database and user", func() {
+					keyDB = createDatabase(namespace.Name, createRootSecret(namespace.Name))
+					keySecret := createSecret(namespace.Name, "mssqllogin-"+randStringRunes(5), randStringRunes(5))
+
+					user = createUser(namespace.Name, keyDB.Name, keySecret.Name, infrav1.DeletionPolicyDrop)
+					Eventually(userReady(objectKey(user)), timeout, interval).Should(BeTrue())
+				})
+
+				It("deletes the database resource", func() {
+					Expect(k8sClient.Delete(context.Background(), &infrav1.MSSQLDatabase{
+						ObjectMeta: metav1.ObjectMeta{Name: keyDB.Name, Namespace: keyDB.Namespace},
+					})).Should(Succeed())
+					Eventually(func() error {
+						return k8sClient.Get(context.Background(), keyDB, &infrav1.MSSQLDatabase{})
+					}, timeout, interval).ShouldNot(Succeed())
+				})
+
+				It("removes the finalizer of the user", func() {
+					Expect(k8sClient.Delete(context.Background(), user)).Should(Succeed())
+					userGone(objectKey(user))
+				})
+			})
+
+			Describe("Login mapped to multiple databases", Ordered, func() {
+				var (
+					keyDB1, keyDB2     types.NamespacedName
+					keySecret          types.NamespacedName
+					username, password string
+					user1, user2       *infrav1.MSSQLUser
+				)
+
+				namespace, _ := setupNamespace()
+
+				It("adds two databases", func() {
+					rootSecret := createRootSecret(namespace.Name)
+					keyDB1 = createDatabase(namespace.Name, rootSecret)
+					keyDB2 = createDatabase(namespace.Name, rootSecret)
+				})
+
+				It("adds the same login to both databases", func() {
+					username = "mssqllogin-" + randStringRunes(5)
+					password = randStringRunes(5)
+					keySecret = createSecret(namespace.Name, username, password)
+
+					user1 = createUser(namespace.Name, keyDB1.Name, keySecret.Name, infrav1.DeletionPolicyDrop)
+					user2 = createUser(namespace.Name, keyDB2.Name, keySecret.Name, infrav1.DeletionPolicyDisable)
+
+					Eventually(userReady(objectKey(user1)), timeout, interval).Should(BeTrue())
+					Eventually(userReady(objectKey(user2)), timeout, interval).Should(BeTrue())
+				})
+
+				It("drops the user of the first database", func() {
+					Expect(k8sClient.Delete(context.Background(), user1)).Should(Succeed())
+					userGone(objectKey(user1))
+				})
+
+				It("dropped only the database user and kept the login", func() {
+					Expect(databaseUserCount(keyDB1.Name, username)).To(Equal(int64(0)))
+					Expect(loginCount(username)).To(Equal(int64(1)))
+				})
+
+				It("can still access the second database", func() {
+					conn, err := mssqlConnect(container.Addr, username, password, keyDB2.Name)
+					Expect(err).NotTo(HaveOccurred())
+					_ = conn.Close()
+				})
+
+				It("disables the user of the second database", func() {
+					Expect(k8sClient.Delete(context.Background(), user2)).Should(Succeed())
+					userGone(objectKey(user2))
+				})
+
+				It("kept the database user but disabled the login", func() {
+					Expect(databaseUserCount(keyDB2.Name, username)).To(Equal(int64(1)))
+
+					var disabled bool
+					Expect(rootQuery("", "SELECT is_disabled FROM sys.server_principals WHERE name = @p1", username).Scan(&disabled)).To(Succeed())
+					Expect(disabled).To(BeTrue())
+
+					_, err := mssqlConnect(container.Addr, username, password, keyDB2.Name)
+					Expect(err).To(HaveOccurred())
+				})
+			})
+		})
+	}
+})
@@ -0,0 +1,216 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	infrav1 "github.com/doodlescheduling/db-controller/api/v1"
+	"github.com/doodlescheduling/db-controller/internal/database"
+	"github.com/doodlescheduling/db-controller/internal/stringutils"
+	"github.com/doodlescheduling/db-controller/internal/tracing"
+)
+
+// +kubebuilder:rbac:groups=dbprovisioning.infra.doodle.com,resources=mssqldatabases,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=dbprovisioning.infra.doodle.com,resources=mssqldatabases/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// MSSQLDatabaseReconciler reconciles a MSSQLDatabase object
+type MSSQLDatabaseReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder events.EventRecorder
+	// Provisioners is the registry to connect to the servers, the default registry is used if not set
+	Provisioners *database.Registry
+}
+
+func (r *MSSQLDatabaseReconciler) SetupWithManager(mgr ctrl.Manager, maxConcurrentReconciles int) error {
+	// Index the MSSQLDatabase by the Secret references they point at
+	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &infrav1.MSSQLDatabase{}, secretIndexKey,
+		func(o client.Object) []string {
+			vb := o.(*infrav1.MSSQLDatabase)
+			return []string{
+				fmt.Sprintf("%s/%s", vb.GetNamespace(), vb.Spec.RootSecret.Name),
+			}
+		},
+	); err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&infrav1.MSSQLDatabase{}, builder.WithPredicates(
+			predicate.GenerationChangedPredicate{},
+		)).
+		Watches(
+			&corev1.Secret{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForSecretChange),
+		).
+		WithOptions(controller.Options{MaxConcurrentReconciles: maxConcurrentReconciles}).
+		Complete(r)
+}
+
+func (r *MSSQLDatabaseReconciler) requestsForSecretChange(ctx context.Context, o client.Object) []reconcile.Request {
+	s, ok := o.(*corev1.Secret)
+	if !ok {
+		panic(fmt.Sprintf("expected a Secret, got %T", o))
+	}
+
+	var list infrav1.MSSQLDatabaseList
+	if err := r.List(ctx, &list, client.MatchingFields{
+		secretIndexKey: objectKey(s).String(),
+	}); err != nil {
+		return nil
+	}
+
+	var reqs []reconcile.Request
+	for _, i := range list.Items {
+		r.Log.Info("referenced secret from a MSSQLDatabase changed detected", "namespace", i.GetNamespace(), "name", i.GetName())
+		reqs = append(reqs, reconcile.Request{NamespacedName: objectKey(&i)})
+	}
+
+	return reqs
+}
+
+func (r *MSSQLDatabaseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx, span := tracing.Start(ctx, "MSSQLDatabase.Reconcile", tracing.Object("MSSQLDatabase", req.NamespacedName)...)
+	defer span.End()
+
+	logger := r.Log.WithValues("MSSQLDatabase", req.NamespacedName)
+	logger.Info("reconciling MSSQLDatabase")
+
+	// get database resource by namespaced name
+	var db infrav1.MSSQLDatabase
+	if err := r.Get(ctx, req.NamespacedName, &db); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	_ = db.SetDefaults()
+
+	// examine DeletionTimestamp to determine if object is under deletion
+	if db.DeletionTimestamp.IsZero() {
+		if !stringutils.ContainsString(db.GetFinalizers(), infrav1.Finalizer) {
+			controllerutil.AddFinalizer(&db, infrav1.Finalizer)
+			if err := r.Update(ctx, &db); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
+	reconcileContext := ctx
+	if db.Spec.Timeout != nil {
+		c, cancel := context.WithTimeout(ctx, db.Spec.Timeout.Duration)
+		defer cancel()
+		reconcileContext = c
+	}
+
+	db, reconcileErr := r.reconcile(reconcileContext, db)
+	tracing.RecordError(span, reconcileErr)
+	res := ctrl.Result{}
+	db.Status.ObservedGeneration = db.GetGeneration()
+
+	if reconcileErr != nil {
+		r.Recorder.Eventf(&db, nil, "Normal", "error", "Reconcile", "%s", reconcileErr.Error())
+	} else {
+		msg := "Database successfully provisioned"
+		r.Recorder.Eventf(&db, nil, "Normal", "info", "Reconcile", "%s", msg)
+		infrav1.DatabaseReadyCondition(&db, infrav1.DatabaseProvisioningSuccessfulReason, msg)
+	}
+
+	recordReconcile("MSSQLDatabase", db.Status.Conditions, infrav1.DatabaseReadyConditionType)
+
+	// Update status after reconciliation.
+	if err := r.patchStatus(ctx, &db); err != nil {
+		logger.Error(err, "unable to update status after reconciliation")
+		return res, err
+	}
+
+	return res, reconcileErr
+}
+
+func (r *MSSQLDatabaseReconciler) reconcile(ctx context.Context, db infrav1.MSSQLDatabase) (infrav1.MSSQLDatabase, error) {
+	if !db.DeletionTimestamp.IsZero() {
+		return r.finalizeDatabase(ctx, db)
+	}
+
+	usr, pw, addr, err := getSecret(ctx, r.Client, db.GetRootSecret())
+
+	if err != nil {
+		infrav1.DatabaseNotReadyCondition(&db, infrav1.CredentialsNotFoundReason, err.Error())
+		return db, err
+	}
+
+	dbHandler, err := setupMSSQL[database.MSSQLDatabaseProvisioner](ctx, r.Provisioners, db, usr, pw, addr)
+
+	if err != nil {
+		infrav1.DatabaseNotReadyCondition(&db, infrav1.ConnectionFailedReason, err.Error())
+		return db, err
+	}
+
+	defer func() { _ = dbHandler.Close(ctx) }()
+
+	err = dbHandler.CreateDatabaseWithOptions(ctx, db.GetDatabaseName(), database.MSSQLDatabaseOptions{
+		Collation: db.Spec.Collation,
+	})
+	if err != nil {
+		err = fmt.Errorf("failed to provision database: %w", err)
+		infrav1.DatabaseNotReadyCondition(&db, infrav1.CreateDatabaseFailedReason, err.Error())
+		return db, err
+	}
+
+	return db, nil
+}
+
+func (r *MSSQLDatabaseReconciler) finalizeDatabase(ctx context.Context, db infrav1.MSSQLDatabase) (infrav1.MSSQLDatabase, error) {
+	if stringutils.ContainsString(db.Finalizers, infrav1.Finalizer) {
+		db.Finalizers = stringutils.RemoveString(db.Finalizers, infrav1.Finalizer)
+		if err := r.Update(ctx, &db); err != nil {
+			return db, err
+		}
+	}
+
+	return db, nil
+}
+
+func (r *MSSQLDatabaseReconciler) patchStatus(ctx context.Context, database *infrav1.MSSQLDatabase) error {
+	key := client.ObjectKeyFromObject(database)
+	latest := &infrav1.MSSQLDatabase{}
+	if err := r.Get(ctx, key, latest); err != nil {
+		return err
+	}
+
+	return r.Client.Status().Patch(ctx, database, client.MergeFrom(latest))
+}
@@ -0,0 +1,330 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	infrav1 "github.com/doodlescheduling/db-controller/api/v1"
+	"github.com/doodlescheduling/db-controller/internal/database"
+	"github.com/doodlescheduling/db-controller/internal/metrics"
+	"github.com/doodlescheduling/db-controller/internal/stringutils"
+	"github.com/doodlescheduling/db-controller/internal/tracing"
+)
+
+// +kubebuilder:rbac:groups=dbprovisioning.infra.doodle.com,resources=mssqlusers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=dbprovisioning.infra.doodle.com,resources=mssqlusers/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// MSSQLUserReconciler reconciles a MSSQLUser object
+type MSSQLUserReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder events.EventRecorder
+	// Provisioners is the registry to connect to the servers, the default registry is used if not set
+	Provisioners *database.Registry
+}
+
+func (r *MSSQLUserReconciler) SetupWithManager(mgr ctrl.Manager, maxConcurrentReconciles int) error {
+	// Index the MSSQLUser by the Credentials references they point at
+	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &infrav1.MSSQLUser{}, credentialsIndexKey,
+		func(o client.Object) []string {
+			usr := o.(*infrav1.MSSQLUser)
+			return []string{
+				fmt.Sprintf("%s/%s", usr.GetNamespace(), usr.Spec.Credentials.Name),
+			}
+		},
+	); err != nil {
+		return err
+	}
+
+	// Index the MSSQLUser by the Database references they point at
+	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &infrav1.MSSQLUser{}, dbIndexKey,
+		func(o client.Object) []string {
+			usr := o.(*infrav1.MSSQLUser)
+			return []string{
+				fmt.Sprintf("%s/%s", usr.GetNamespace(), usr.Spec.Database.Name),
+			}
+		},
+	); err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&infrav1.MSSQLUser{}, builder.WithPredicates(
+			predicate.GenerationChangedPredicate{},
+		)).
+		Watches(
+			&corev1.Secret{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForSecretChange),
+		).
+		Watches(
+			&infrav1.MSSQLDatabase{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForDatabaseChange),
+		).
+		WithOptions(controller.Options{MaxConcurrentReconciles: maxConcurrentReconciles}).
+		Complete(r)
+}
+
+func (r *MSSQLUserReconciler) requestsForSecretChange(ctx context.Context, o client.Object) []reconcile.Request {
+	s, ok := o.(*corev1.Secret)
+	if !ok {
+		panic(fmt.Sprintf("expected a Secret, got %T", o))
+	}
+
+	var list infrav1.MSSQLUserList
+	if err := r.List(ctx, &list, client.MatchingFields{
+		credentialsIndexKey: objectKey(s).String(),
+	}); err != nil {
+		return nil
+	}
+
+	var reqs []reconcile.Request
+	for _, i := range list.Items {
+		r.Log.Info("referenced secret from a mssqluser change detected", "namespace", i.GetNamespace(), "name", i.GetName())
+		reqs = append(reqs, reconcile.Request{NamespacedName: objectKey(&i)})
+	}
+
+	return reqs
+}
+
+func (r *MSSQLUserReconciler) requestsForDatabaseChange(ctx context.Context, o client.Object) []reconcile.Request {
+	s, ok := o.(*infrav1.MSSQLDatabase)
+	if !ok {
+		panic(fmt.Sprintf("expected a MSSQLDatabase, got %T", o))
+	}
+
+	var list infrav1.MSSQLUserList
+	if err := r.List(ctx, &list, client.MatchingFields{
+		dbIndexKey: objectKey(s).String(),
+	}); err != nil {
+		return nil
+	}
+
+	var reqs []reconcile.Request
+	for _, i := range list.Items {
+		r.Log.Info("referenced database from a mssqluser change detected, reconcile", "namespace", i.GetNamespace(), "name", i.GetName())
+		reqs = append(reqs, reconcile.Request{NamespacedName: objectKey(&i)})
+	}
+
+	return reqs
+}
+
+func (r *MSSQLUserReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx, span := tracing.Start(ctx, "MSSQLUser.Reconcile", tracing.Object("MSSQLUser", req.NamespacedName)...)
+	defer span.End()
+
+	logger := r.Log.WithValues("MSSQLUser", req.NamespacedName)
+	logger.Info("reconciling MSSQLUser")
+
+	var user infrav1.MSSQLUser
+	if err := r.Get(ctx, req.NamespacedName, &user); err != nil {
+		if apierrors.IsNotFound(err) {
+			metrics.Users.Forget("MSSQLUser", req.NamespacedName)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// examine DeletionTimestamp to determine if object is under deletion
+	if user.DeletionTimestamp.IsZero() {
+		if !stringutils.ContainsString(user.GetFinalizers(), infrav1.Finalizer) {
+			controllerutil.AddFinalizer(&user, infrav1.Finalizer)
+			if err := r.Update(ctx, &user); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
+	user, res, reconcileErr := r.reconcile(ctx, user)
+	tracing.RecordError(span, reconcileErr)
+	user.Status.ObservedGeneration = user.GetGeneration()
+
+	if reconcileErr != nil {
+		r.Recorder.Eventf(&user, nil, "Normal", "error", "Reconcile", "%s", reconcileErr.Error())
+	} else if !isUserExpired(user.Status.Conditions) {
+		msg := "User successfully provisioned"
+		r.Recorder.Eventf(&user, nil, "Normal", "info", "Reconcile", "%s", msg)
+		infrav1.UserReadyCondition(&user, infrav1.UserProvisioningSuccessfulReason, msg)
+	} else {
+		msg := "User has expired and was disabled"
+		r.Recorder.Eventf(&user, nil, "Normal", "info", "Reconcile", "%s", msg)
+	}
+
+	recordReconcile("MSSQLUser", user.Status.Conditions, infrav1.UserReadyConditionType)
+	recordUser("MSSQLUser", &user, user.Spec.ValidUntil, user.Status.Conditions, reconcileErr)
+
+	// Update status after reconciliation.
+	if err := r.patchStatus(ctx, &user); err != nil {
+		logger.Error(err, "unable to update status after reconciliation")
+		return res, err
+	}
+
+	return res, reconcileErr
+}
+
+func (r *MSSQLUserReconciler) reconcile(ctx context.Context, user infrav1.MSSQLUser) (infrav1.MSSQLUser, ctrl.Result, error) {
+	res := ctrl.Result{}
+
+	// Fetch referencing database
+	var db infrav1.MSSQLDatabase
+	databaseName := types.NamespacedName{
+		Namespace: user.GetNamespace(),
+		Name:      user.GetDatabase(),
+	}
+
+	err := r.Get(ctx, databaseName, &db)
+	if err != nil {
+		err = fmt.Errorf("referencing database was not found: %w", err)
+		if !user.DeletionTimestamp.IsZero() && apierrors.IsNotFound(err) {
+			return user, res, releaseUser(ctx, r.Client, r.Recorder, &user, err)
+		}
+
+		infrav1.UserNotReadyCondition(&user, infrav1.DatabaseNotFoundReason, err.Error())
+		return user, res, err
+	}
+
+	if db.Spec.Timeout != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, db.Spec.Timeout.Duration)
+		defer cancel()
+	}
+
+	// Fetch referencing root secret
+	rootUsr, rootPw, addr, err := getSecret(ctx, r.Client, db.GetRootSecret())
+
+	if err != nil {
+		if !user.DeletionTimestamp.IsZero() && apierrors.IsNotFound(err) {
+			return user, res, releaseUser(ctx, r.Client, r.Recorder, &user, err)
+		}
+
+		infrav1.UserNotReadyCondition(&user, infrav1.CredentialsNotFoundReason, err.Error())
+		return user, res, err
+	}
+
+	dbHandler, err := setupMSSQL[database.UserProvisioner](ctx, r.Provisioners, db, rootUsr, rootPw, addr)
+
+	if err != nil {
+		infrav1.UserNotReadyCondition(&user, infrav1.ConnectionFailedReason, err.Error())
+		return user, res, err
+	}
+
+	defer func() { _ = dbHandler.Close(ctx) }()
+
+	// The user secret is not needed to remove the account which was provisioned using the username in the status
+	if !user.DeletionTimestamp.IsZero() {
+		err := finalizeUser(ctx, r.Client, r.Recorder, &user, dbHandler, r.statusUser(user, db), user.Spec.DeletionPolicy == infrav1.DeletionPolicyDrop)
+		return user, res, err
+	}
+
+	// Fetch referencing secret
+	usr, pw, _, err := getSecret(ctx, r.Client, user.GetCredentials())
+
+	if err != nil {
+		infrav1.UserNotReadyCondition(&user, infrav1.CredentialsNotFoundReason, err.Error())
+		return user, res, err
+	}
+
+	// The username changed, drop the previous user instead of leaving it behind
+	if user.Status.Username != "" && user.Status.Username != usr {
+		if err := dropUser(ctx, r.Recorder, &user, dbHandler, r.statusUser(user, db)); err != nil {
+			return user, res, err
+		}
+	}
+
+	user.Status.Username = usr
+
+	if user.Spec.ValidUntil != nil {
+		validUntil := user.Spec.ValidUntil.UTC()
+		now := time.Now().UTC()
+
+		if !validUntil.After(now) {
+			if err := expireUser(ctx, r.Recorder, &user, dbHandler, r.statusUser(user, db)); err != nil {
+				return user, res, err
+			}
+			infrav1.UserNotReadyCondition(
+				&user,
+				infrav1.UserExpiredReason,
+				"User has expired and was disabled",
+			)
+			return user, res, nil
+		}
+
+		res.RequeueAfter = validUntil.Sub(now)
+	}
+
+	userSpec := database.User{
+		Database: db.GetDatabaseName(),
+		Username: usr,
+		Password: pw,
+		Options: database.MSSQLUserOptions{
+			DefaultSchema: user.GetDefaultSchema(),
+			CheckPolicy:   user.Spec.CheckPolicy,
+		},
+	}
+
+	for _, role := range user.Spec.Roles {
+		userSpec.Roles = append(userSpec.Roles, database.Role{Name: role})
+	}
+
+	err = dbHandler.SetupUser(ctx, userSpec)
+	if err != nil {
+		err = fmt.Errorf("failed to provision user account: %w", err)
+		infrav1.UserNotReadyCondition(&user, infrav1.ConnectionFailedReason, err.Error())
+		return user, res, err
+	}
+
+	return user, res, nil
+}
+
+// statusUser returns the login and database user which were provisioned.
+// The login is only dropped or disabled if it is not mapped to a user in another database.
+func (r *MSSQLUserReconciler) statusUser(user infrav1.MSSQLUser, db infrav1.MSSQLDatabase) database.User {
+	return database.User{
+		Database: db.GetDatabaseName(),
+		Username: user.Status.Username,
+	}
+}
+
+func (r *MSSQLUserReconciler) patchStatus(ctx context.Context, database *infrav1.MSSQLUser) error {
+	key := client.ObjectKeyFromObject(database)
+	latest := &infrav1.MSSQLUser{}
+	if err := r.Get(ctx, key, latest); err != nil {
+		return err
+	}
+
+	return r.Client.Status().Patch(ctx, database, client.MergeFrom(latest))
+}
@@ -0,0 +1,598 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	infrav1 "github.com/doodlescheduling/db-controller/api/v1"
+)
+
+const (
+	mysqlRootUsername = "root"
+	mysqlRootPassword = "password"
+)
+
+type mysqlContainer struct {
+	testcontainers.Container
+	Addr string
+	URI  string
+}
+
+func setupMySQLContainer(ctx context.Context, image string) (*mysqlContainer, error) {
+	req := testcontainers.ContainerRequest{
+		Image:        image,
+		ExposedPorts: []string{"3306/tcp"},
+		WaitingFor: wait.ForSQL("3306/tcp", "mysql", func(host string, port string) string {
+			return mysqlDSN(fmt.Sprintf("%s:%s", host, port), mysqlRootUsername, mysqlRootPassword, "")
+		}).WithStartupTimeout(120 * time.Second),
+		Env: map[string]string{
+			"MYSQL_ROOT_PASSWORD":   mysqlRootPassword,
+			"MARIADB_ROOT_PASSWORD": mysqlRootPassword,
+		},
+	}
+
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	host, err := container.Host(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	port, err := container.MappedPort(ctx, "3306/tcp")
+	if err != nil {
+		return nil, err
+	}
+
+	addr := fmt.Sprintf("%s:%s", host, port.Port())
+	return &mysqlContainer{Container: container, Addr: addr, URI: "mysql://" + addr}, nil
+}
+
+func mysqlDSN(addr, username, password, database string) string {
+	cfg := mysql.NewConfig()
+	cfg.Net = "tcp"
+	cfg.Addr = addr
+	cfg.User = username
+	cfg.Passwd = password
+	cfg.DBName = database
+	cfg.Timeout = 2 * time.Second
+
+	return cfg.FormatDSN()
+}
+
+// mysqlConnect opens a single session to the server
+func mysqlConnect(addr, username, password, database string) (*sql.Conn, error) {
+	db, err := sql.Open("mysql", mysqlDSN(addr, username, password, database))
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	if err := conn.PingContext(context.Background()); err != nil {
+		_ = conn.Close()
+		_ = db.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+var _ = Describe("MySQL", func() {
+	const (
+		timeout  = time.Second * 5
+		interval = time.Second * 1
+	)
+
+	for _, image := range []string{"mysql:8.0", "mariadb:11"} {
+		var _ = Describe(image, func() {
+			var (
+				container *mysqlContainer
+				err       error
+			)
+
+			container, err = setupMySQLContainer(context.Background(), image)
+			Expect(err).NotTo(HaveOccurred(), "failed to start mysql container")
+
+			rootQuery := func(query string, args ...interface{}) *sql.Row {
+				conn, err := mysqlConnect(container.Addr, mysqlRootUsername, mysqlRootPassword, "")
+				Expect(err).NotTo(HaveOccurred(), "failed to connect to mysql")
+				defer func() { _ = conn.Close() }()
+
+				return conn.QueryRowContext(context.Background(), query, args...)
+			}
+
+			userReady := func(key types.NamespacedName) func() bool {
+				return func() bool {
+					got := &infrav1.MySQLUser{}
+					_ = k8sClient.Get(context.Background(), key, got)
+					return len(got.Status.Conditions) == 1 &&
+						got.Status.Conditions[0].Reason == infrav1.UserProvisioningSuccessfulReason &&
+						got.Status.Conditions[0].Status == "True" &&
+						got.Status.Conditions[0].Type == infrav1.UserReadyConditionType &&
+						got.ObjectMeta.Generation == got.Status.ObservedGeneration
+				}
+			}
+
+			updateUser := func(key types.NamespacedName, mutate func(user *infrav1.MySQLUser)) {
+				Eventually(func() error {
+					user := &infrav1.MySQLUser{}
+					if err := k8sClient.Get(context.Background(), key, user); err != nil {
+						return err
+					}
+
+					mutate(user)
+					return k8sClient.Update(context.Background(), user)
+				}, timeout, interval).Should(Succeed())
+			}
+
+			Describe("fails if database can't be reached", Ordered, func() {
+				var keyDB types.NamespacedName
+
+				namespace, rootSecret := setupNamespace()
+
+				It("adds database", func() {
+					keyDB = types.NamespacedName{
+						Name:      "mysqldatabase-" + randStringRunes(5),
+						Namespace: namespace.Name,
+					}
+					createdDB := &infrav1.MySQLDatabase{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      keyDB.Name,
+							Namespace: keyDB.Namespace,
+						},
+						Spec: infrav1.MySQLDatabaseSpec{
+							DatabaseSpec: infrav1.DatabaseSpec{
+								Timeout: &metav1.Duration{
+									Duration: time.Millisecond * 100,
+								},
+								Address: "mysql://does-not-exist:3306",
+								RootSecret: &infrav1.SecretReference{
+									Name: rootSecret.Name,
+								},
+							},
+						},
+					}
+					Expect(k8sClient.Create(context.Background(), createdDB)).Should(Succeed())
+				})
+
+				It("fails reconcile because database can't be reached", func() {
+					got := &infrav1.MySQLDatabase{}
+					Eventually(func() bool {
+						_ = k8sClient.Get(context.Background(), keyDB, got)
+						return len(got.Status.Conditions) == 1 &&
+							got.Status.Conditions[0].Reason == infrav1.ConnectionFailedReason &&
+							got.Status.Conditions[0].Status == "False" &&
+							got.Status.Conditions[0].Type == infrav1.DatabaseReadyConditionType
+					}, timeout, interval).Should(BeTrue())
+				})
+			})
+
+			Describe("Successful user creation", Ordered, func() {
+				var (
+					createdDB     *infrav1.MySQLDatabase
+					createdUser   *infrav1.MySQLUser
+					createdSecret *corev1.Secret
+					keyUser       types.NamespacedName
+					keyDB         types.NamespacedName
+					keySecret     types.NamespacedName
+					password      string
+				)
+
+				namespace, rootSecret := setupNamespace()
+
+				Describe("creates database with character set and collation", Ordered, func() {
+					It("adds database", func() {
+						keyDB = types.NamespacedName{
+							Name:      "mysqldatabase-" + randStringRunes(5),
+							Namespace: namespace.Name,
+						}
+						createdDB = &infrav1.MySQLDatabase{
+							ObjectMeta: metav1.ObjectMeta{
+								Name:      keyDB.Name,
+								Namespace: keyDB.Namespace,
+							},
+							Spec: infrav1.MySQLDatabaseSpec{
+								DatabaseSpec: infrav1.DatabaseSpec{
+									Timeout: &metav1.Duration{
+										Duration: time.Second * 2,
+									},
+									Address: container.URI,
+									RootSecret: &infrav1.SecretReference{
+										Name: rootSecret.Name,
+									},
+								},
+								CharacterSet: "latin1",
+								Collation:    "latin1_german1_ci",
+							},
+						}
+
+						Expect(k8sClient.Create(context.Background(), createdDB)).Should(Succeed())
+					})
+
+					It("expects ready database", func() {
+						got := &infrav1.MySQLDatabase{}
+						Eventually(func() bool {
+							_ = k8sClient.Get(context.Background(), keyDB, got)
+							return len(got.Status.Conditions) == 1 &&
+								got.Status.Conditions[0].Reason == infrav1.DatabaseProvisioningSuccessfulReason &&
+								got.Status.Conditions[0].Status == "True" &&
+								got.Status.Conditions[0].Type == infrav1.DatabaseReadyConditionType
+						}, timeout, interval).Should(BeTrue())
+					})
+
+					It("created the database with the character set and collation", func() {
+						var charset, collation string
+						Expect(rootQuery("SELECT DEFAULT_CHARACTER_SET_NAME, DEFAULT_COLLATION_NAME FROM information_schema.SCHEMATA WHERE SCHEMA_NAME=?", keyDB.Name).Scan(&charset, &collation)).To(Succeed())
+						Expect(charset).To(Equal("latin1"))
+						Expect(collation).To(Equal("latin1_german1_ci"))
+					})
+				})
+
+				Describe("creates user if it does not exists", Ordered, func() {
+					It("adds secret", func() {
+						keyUser = types.NamespacedName{
+							Name:      "mysqluser-" + randStringRunes(5),
+							Namespace: namespace.Name,
+						}
+						keySecret = types.NamespacedName{
+							Name:      "secret-" + randStringRunes(5),
+							Namespace: namespace.Name,
+						}
+						password = randStringRunes(5)
+						createdSecret = &corev1.Secret{
+							ObjectMeta: metav1.ObjectMeta{
+								Name:      keySecret.Name,
+								Namespace: keySecret.Namespace,
+							},
+							Data: map[string][]byte{
+								"username": []byte(keyUser.Name),
+								"password": []byte(password),
+							},
+						}
+						Expect(k8sClient.Create(context.Background(), createdSecret)).Should(Succeed())
+					})
+
+					It("adds user", func() {
+						createdUser = &infrav1.MySQLUser{
+							ObjectMeta: metav1.ObjectMeta{
+								Name:      keyUser.Name,
+								Namespace: keyUser.Namespace,
+							},
+							Spec: infrav1.MySQLUserSpec{
+								Database: &infrav1.DatabaseReference{
+									Name: keyDB.Name,
+								},
+								Credentials: &infrav1.SecretReference{
+									Name: keySecret.Name,
+								},
+								AuthPlugin: "mysql_native_password",
+								ResourceLimits: &infrav1.MySQLResourceLimits{
+									MaxUserConnections: 5,
+								},
+								DeletionPolicy: infrav1.DeletionPolicyDrop,
+							},
+						}
+						Expect(k8sClient.Create(context.Background(), createdUser)).Should(Succeed())
+					})
+
+					It("expects ready user", func() {
+						Eventually(userReady(keyUser), timeout, interval).Should(BeTrue())
+					})
+
+					It("created the user with the auth plugin and resource limits", func() {
+						var plugin string
+						var maxUserConnections int64
+						Expect(rootQuery("SELECT plugin, max_user_connections FROM mysql.user WHERE User=? AND Host='%'", keyUser.Name).Scan(&plugin, &maxUserConnections)).To(Succeed())
+						Expect(plugin).To(Equal("mysql_native_password"))
+						Expect(maxUserConnections).To(Equal(int64(5)))
+					})
+
+					It("can access the created database", func() {
+						var conn *sql.Conn
+						Eventually(func() error {
+							c, err := mysqlConnect(container.Addr, keyUser.Name, password, keyDB.Name)
+							conn = c
+							return err
+						}, timeout, interval).Should(Succeed())
+
+						defer func() { _ = conn.Close() }()
+
+						_, err := conn.ExecContext(context.Background(), "CREATE TABLE foo (id integer);")
+						Expect(err).NotTo(HaveOccurred(), "failed to create table")
+
+						_, err = conn.ExecContext(context.Background(), "CREATE TABLE bar (id integer);")
+						Expect(err).NotTo(HaveOccurred(), "failed to create table")
+					})
+
+					It("has no access to another database", func() {
+						_, err := mysqlConnect(container.Addr, keyUser.Name, password, "mysql")
+						Expect(err).To(HaveOccurred())
+					})
+
+					It("can't access the created database with invalid credentials", func() {
+						_, err := mysqlConnect(container.Addr, keyUser.Name, "invalid-password", keyDB.Name)
+						Expect(err).To(HaveOccurred())
+					})
+				})
+
+				Describe("Change password for user", Ordered, func() {
+					It("changes password in referenced user secret", func() {
+						password = randStringRunes(5)
+						createdSecret.Data = map[string][]byte{
+							"username": []byte(keyUser.Name),
+							"password": []byte(password),
+						}
+						Expect(k8sClient.Update(context.Background(), createdSecret)).Should(Succeed())
+					})
+
+					It("can access the database with the new password", func() {
+						Eventually(func() error {
+							conn, err := mysqlConnect(container.Addr, keyUser.Name, password, keyDB.Name)
+							if err == nil {
+								_ = conn.Close()
+							}
+							return err
+						}, timeout, interval).Should(Succeed())
+					})
+				})
+
+				Describe("Grants", Ordered, func() {
+					It("restricts the user to select on table foo", func() {
+						updateUser(keyUser, func(user *infrav1.MySQLUser) {
+							user.Spec.Grants = []infrav1.MySQLGrant{
+								{
+									Table:      "foo",
+									Privileges: []infrav1.Privilege{"SELECT"},
+								},
+							}
+						})
+					})
+
+					It("expects ready user", func() {
+						Eventually(userReady(keyUser), timeout, interval).Should(BeTrue())
+					})
+
+					It("can only select from table foo", func() {
+						conn, err := mysqlConnect(container.Addr, keyUser.Name, password, keyDB.Name)
+						Expect(err).NotTo(HaveOccurred())
+						defer func() { _ = conn.Close() }()
+
+						_, err = conn.ExecContext(context.Background(), "SELECT * FROM foo;")
+						Expect(err).NotTo(HaveOccurred())
+
+						_, err = conn.ExecContext(context.Background(), "INSERT INTO foo VALUES (1);")
+						Expect(err).To(HaveOccurred())
+
+						_, err = conn.ExecContext(context.Background(), "SELECT * FROM bar;")
+						Expect(err).To(HaveOccurred())
+					})
+
+					It("rejects invalid privileges", func() {
+						updateUser(keyUser, func(user *infrav1.MySQLUser) {
+							user.Spec.Grants = []infrav1.MySQLGrant{
+								{
+									Table:      "foo",
+									Privileges: []infrav1.Privilege{"SELECT; DROP DATABASE mysql"},
+								},
+							}
+						})
+
+						got := &infrav1.MySQLUser{}
+						Eventually(func() bool {
+							_ = k8sClient.Get(context.Background(), keyUser, got)
+							return len(got.Status.Conditions) == 1 &&
+								got.Status.Conditions[0].Status == "False" &&
+								got.ObjectMeta.Generation == got.Status.ObservedGeneration
+						}, timeout, interval).Should(BeTrue())
+					})
+
+					It("grants all privileges on the database again", func() {
+						updateUser(keyUser, func(user *infrav1.MySQLUser) {
+							user.Spec.Grants = []infrav1.MySQLGrant{
+								{
+									Table:      "*",
+									Privileges: []infrav1.Privilege{"ALL"},
+								},
+							}
+						})
+
+						Eventually(userReady(keyUser), timeout, interval).Should(BeTrue())
+					})
+
+					It("revoked the table privileges", func() {
+						var count int64
+						Expect(rootQuery("SELECT COUNT(*) FROM information_schema.TABLE_PRIVILEGES WHERE GRANTEE=? AND TABLE_SCHEMA=?", fmt.Sprintf("'%s'@'%%'", keyUser.Name), keyDB.Name).Scan(&count)).To(Succeed())
+						Expect(count).To(Equal(int64(0)))
+					})
+				})
+
+				Describe("ValidUntil", Ordered, func() {
+					var session *sql.Conn
+
+					It("opens a session before validUntil expires", func() {
+						Eventually(func() error {
+							c, err := mysqlConnect(container.Addr, keyUser.Name, password, keyDB.Name)
+							session = c
+							return err
+						}, timeout, interval).Should(Succeed())
+					})
+
+					It("sets validUntil in the past for the user", func() {
+						updateUser(keyUser, func(user *infrav1.MySQLUser) {
+							validUntil := metav1.NewTime(time.Now().Add(-1 * time.Hour).UTC())
+							user.Spec.ValidUntil = &validUntil
+						})
+					})
+
+					It("sets expired status after validUntil expires", func() {
+						got := &infrav1.MySQLUser{}
+
+						Eventually(func() bool {
+							_ = k8sClient.Get(context.Background(), keyUser, got)
+
+							return len(got.Status.Conditions) == 1 &&
+								got.Status.Conditions[0].Reason == infrav1.UserExpiredReason &&
+								got.Status.Conditions[0].Status == "False" &&
+								got.ObjectMeta.Generation == got.Status.ObservedGeneration
+						}, timeout, interval).Should(BeTrue())
+					})
+
+					It("cannot access the database after validUntil expired", func() {
+						_, err := mysqlConnect(container.Addr, keyUser.Name, password, keyDB.Name)
+						Expect(err).To(HaveOccurred())
+					})
+
+					It("terminated the open session after validUntil expired", func() {
+						Eventually(func() error {
+							_, err := session.ExecContext(context.Background(), "SELECT 1;")
+							return err
+						}, timeout, interval).ShouldNot(Succeed())
+					})
+
+					It("clears validUntil for the user", func() {
+						updateUser(keyUser, func(user *infrav1.MySQLUser) {
+							user.Spec.ValidUntil = nil
+						})
+
+						Eventually(userReady(keyUser), timeout, interval).Should(BeTrue())
+					})
+
+					It("can access the database again after clearing validUntil", func() {
+						Eventually(func() error {
+							conn, err := mysqlConnect(container.Addr, keyUser.Name, password, keyDB.Name)
+							if err == nil {
+								_ = conn.Close()
+							}
+							return err
+						}, timeout, interval).Should(Succeed())
+					})
+				})
+
+				Describe("Host pattern", Ordered, func() {
+					It("changes the host of the user", func() {
+						updateUser(keyUser, func(user *infrav1.MySQLUser) {
+							user.Spec.Host = "192.0.2.%"
+						})
+
+						Eventually(userReady(keyUser), timeout, interval).Should(BeTrue())
+					})
+
+					It("moved the account to the new host", func() {
+						var host string
+						Expect(rootQuery("SELECT Host FROM mysql.user WHERE User=?", keyUser.Name).Scan(&host)).To(Succeed())
+						Expect(host).To(Equal("192.0.2.%"))
+					})
+
+					It("can't connect from a host outside of the pattern", func() {
+						_, err := mysqlConnect(container.Addr, keyUser.Name, password, keyDB.Name)
+						Expect(err).To(HaveOccurred())
+					})
+				})
+
+				Describe("Sessions of a same-named account on another host", Ordered, func() {
+					var session *sql.Conn
+
+					rootExec := func(query string) {
+						conn, err := mysqlConnect(container.Addr, mysqlRootUsername, mysqlRootPassword, "")
+						Expect(err).NotTo(HaveOccurred(), "failed to connect to mysql")
+						defer func() { _ = conn.Close() }()
+
+						_, err = conn.ExecContext(context.Background(), query)
+						Expect(err).NotTo(HaveOccurred())
+					}
+
+					It("opens a session as an unmanaged account with the same name", func() {
+						rootExec(fmt.Sprintf("CREATE USER '%s'@'%%' IDENTIFIED BY '%s';", keyUser.Name, password))
+						DeferCleanup(rootExec, fmt.Sprintf("DROP USER IF EXISTS '%s'@'%%';", keyUser.Name))
+
+						Eventually(func() error {
+							c, err := mysqlConnect(container.Addr, keyUser.Name, password, "")
+							session = c
+							return err
+						}, timeout, interval).Should(Succeed())
+					})
+
+					It("expires the managed account", func() {
+						updateUser(keyUser, func(user *infrav1.MySQLUser) {
+							validUntil := metav1.NewTime(time.Now().Add(-1 * time.Hour).UTC())
+							user.Spec.ValidUntil = &validUntil
+						})
+
+						got := &infrav1.MySQLUser{}
+						Eventually(func() bool {
+							_ = k8sClient.Get(context.Background(), keyUser, got)
+							return len(got.Status.Conditions) == 1 &&
+								got.Status.Conditions[0].Reason == infrav1.UserExpiredReason &&
+								got.ObjectMeta.Generation == got.Status.ObservedGeneration
+						}, timeout, interval).Should(BeTrue())
+					})
+
+					It("kept the session of the other account", func() {
+						Consistently(func() error {
+							_, err := session.ExecContext(context.Background(), "SELECT 1;")
+							return err
+						}, 2*time.Second, interval).Should(Succeed())
+
+						Expect(session.Close()).To(Succeed())
+					})
+				})
+
+				Describe("Delete user drops user from mysql", Ordered, func() {
+					It("deletes user", func() {
+						Expect(k8sClient.Delete(context.Background(), createdUser)).Should(Succeed())
+					})
+
+					It("expects gone", func() {
+						got := &infrav1.MySQLUser{}
+						Eventually(func() error {
+							return k8sClient.Get(context.Background(), keyUser, got)
+						}, timeout, interval).ShouldNot(Succeed())
+					})
+
+					It("dropped the user", func() {
+						var count int64
+						Expect(rootQuery("SELECT COUNT(*) FROM mysql.user WHERE User=?", keyUser.Name).Scan(&count)).To(Succeed())
+						Expect(count).To(Equal(int64(0)))
+					})
+				})
+			})
+		})
+	}
+})
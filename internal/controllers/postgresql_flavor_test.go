@@ -0,0 +1,390 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	infrav1 "github.com/doodlescheduling/db-controller/api/v1"
+)
+
+type postgresqlFlavor struct {
+	flavor       infrav1.PostgreSQLFlavor
+	image        string
+	port         string
+	cmd          []string
+	env          map[string]string
+	rootUsername string
+	rootPassword string
+	// extension is supported by the flavor, an empty extension expects extensions to be skipped
+	extension string
+}
+
+var postgresqlFlavors = []postgresqlFlavor{
+	{
+		flavor: infrav1.PostgreSQLFlavorCockroachDB,
+		image:  "cockroachdb/cockroach:latest-v24.1",
+		port:   "26257/tcp",
+		cmd:    []string{"start-single-node"},
+		env: map[string]string{
+			"COCKROACH_USER":     "dbadmin",
+			"COCKROACH_PASSWORD": "password",
+		},
+		rootUsername: "dbadmin",
+		rootPassword: "password",
+	},
+	{
+		flavor:       infrav1.PostgreSQLFlavorYugabyteDB,
+		image:        "yugabytedb/yugabyte:2.20.7.1-b10",
+		port:         "5433/tcp",
+		cmd:          []string{"bin/yugabyted", "start", "--background=false", "--tserver_flags=ysql_enable_auth=true"},
+		rootUsername: "yugabyte",
+		rootPassword: "yugabyte",
+		extension:    "pgcrypto",
+	},
+}
+
+func setupPostgreSQLFlavorContainer(ctx context.Context, flavor postgresqlFlavor) (*postgresqlContainer, error) {
+	req := testcontainers.ContainerRequest{
+		Image:        flavor.image,
+		ExposedPorts: []string{flavor.port},
+		Cmd:          flavor.cmd,
+		Env:          flavor.env,
+		WaitingFor: wait.ForListeningPort(flavor.port).
+			WithStartupTimeout(180 * time.Second),
+	}
+
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	host, err := container.Host(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	port, err := container.MappedPort(ctx, flavor.port)
+	if err != nil {
+		return nil, err
+	}
+
+	uri := fmt.Sprintf("postgresql://%s:%s", host, port.Port())
+
+	return &postgresqlContainer{Container: container, URI: uri}, nil
+}
+
+var _ = Describe("PostgreSQL flavors", func() {
+	const (
+		timeout  = time.Second * 10
+		interval = time.Second * 1
+	)
+
+	for _, flavor := range postgresqlFlavors {
+		var _ = Describe(string(flavor.flavor), func() {
+			var (
+				container *postgresqlContainer
+				err       error
+			)
+
+			container, err = setupPostgreSQLFlavorContainer(context.Background(), flavor)
+			Expect(err).NotTo(HaveOccurred(), "failed to start container")
+
+			connect := func(username, password, database string) (*pgx.Conn, error) {
+				popt, err := url.Parse(container.URI)
+				Expect(err).NotTo(HaveOccurred(), "failed to parse postgresql uri")
+
+				popt.User = url.UserPassword(username, password)
+				q := popt.Query()
+				q.Add("connect_timeout", "2")
+				popt.RawQuery = q.Encode()
+				popt.Path = database
+
+				return pgx.Connect(context.Background(), popt.String())
+			}
+
+			createRootSecret := func(namespace string) string {
+				secret := &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "root-" + randStringRunes(5),
+						Namespace: namespace,
+					},
+					Data: map[string][]byte{
+						"username": []byte(flavor.rootUsername),
+						"password": []byte(flavor.rootPassword),
+					},
+				}
+
+				Expect(k8sClient.Create(context.Background(), secret)).Should(Succeed())
+				return secret.Name
+			}
+
+			userCondition := func(key types.NamespacedName) *metav1.Condition {
+				got := &infrav1.PostgreSQLUser{}
+				if err := k8sClient.Get(context.Background(), key, got); err != nil {
+					return nil
+				}
+
+				if got.Generation != got.Status.ObservedGeneration {
+					return nil
+				}
+
+				return meta.FindStatusCondition(got.Status.Conditions, infrav1.UserReadyConditionType)
+			}
+
+			updateUser := func(key types.NamespacedName, mutate func(user *infrav1.PostgreSQLUser)) {
+				Eventually(func() error {
+					user := &infrav1.PostgreSQLUser{}
+					if err := k8sClient.Get(context.Background(), key, user); err != nil {
+						return err
+					}
+
+					mutate(user)
+					return k8sClient.Update(context.Background(), user)
+				}, timeout, interval).Should(Succeed())
+			}
+
+			expectUserReason := func(key types.NamespacedName, reason string) {
+				Eventually(func() string {
+					if c := userCondition(key); c != nil {
+						return c.Reason
+					}
+
+					return ""
+				}, timeout, interval).Should(Equal(reason))
+			}
+
+			Describe("Provisioning", Ordered, func() {
+				var (
+					createdUser   *infrav1.PostgreSQLUser
+					createdSecret *corev1.Secret
+					keyUser       types.NamespacedName
+					keyDB         types.NamespacedName
+					password      string
+				)
+
+				namespace, _ := setupNamespace()
+
+				It("adds database", func() {
+					keyDB = types.NamespacedName{
+						Name:      "postgresdatabase-" + randStringRunes(5),
+						Namespace: namespace.Name,
+					}
+
+					extension := flavor.extension
+					if extension == "" {
+						extension = "pgcrypto"
+					}
+
+					createdDB := &infrav1.PostgreSQLDatabase{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      keyDB.Name,
+							Namespace: keyDB.Namespace,
+						},
+						Spec: infrav1.PostgreSQLDatabaseSpec{
+							DatabaseSpec: infrav1.DatabaseSpec{
+								Timeout: &metav1.Duration{
+									Duration: time.Second * 5,
+								},
+								Address: container.URI,
+								RootSecret: &infrav1.SecretReference{
+									Name: createRootSecret(namespace.Name),
+								},
+							},
+							Flavor: flavor.flavor,
+							Extensions: infrav1.Extensions{
+								{Name: extension},
+							},
+						},
+					}
+
+					Expect(k8sClient.Create(context.Background(), createdDB)).Should(Succeed())
+				})
+
+				It("expects ready database", func() {
+					Eventually(func() bool {
+						got := &infrav1.PostgreSQLDatabase{}
+						_ = k8sClient.Get(context.Background(), keyDB, got)
+						return meta.IsStatusConditionTrue(got.Status.Conditions, infrav1.DatabaseReadyConditionType)
+					}, timeout, interval).Should(BeTrue())
+				})
+
+				It("reports the extensions", func() {
+					got := &infrav1.PostgreSQLDatabase{}
+					Expect(k8sClient.Get(context.Background(), keyDB, got)).To(Succeed())
+
+					condition := meta.FindStatusCondition(got.Status.Conditions, infrav1.ExtensionReadyConditionType)
+					Expect(condition).NotTo(BeNil())
+
+					if flavor.extension == "" {
+						Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+						Expect(condition.Reason).To(Equal(infrav1.UnsupportedByFlavorReason))
+					} else {
+						Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+					}
+				})
+
+				It("adds user", func() {
+					keyUser = types.NamespacedName{
+						Name:      "postgresuser-" + randStringRunes(5),
+						Namespace: namespace.Name,
+					}
+
+					password = randStringRunes(8)
+					createdSecret = &corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "secret-" + randStringRunes(5),
+							Namespace: namespace.Name,
+						},
+						Data: map[string][]byte{
+							"username": []byte(keyUser.Name),
+							"password": []byte(password),
+						},
+					}
+					Expect(k8sClient.Create(context.Background(), createdSecret)).Should(Succeed())
+
+					createdUser = &infrav1.PostgreSQLUser{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      keyUser.Name,
+							Namespace: keyUser.Namespace,
+						},
+						Spec: infrav1.PostgreSQLUserSpec{
+							Database: &infrav1.DatabaseReference{
+								Name: keyDB.Name,
+							},
+							Credentials: &infrav1.SecretReference{
+								Name: createdSecret.Name,
+							},
+							DeletionPolicy: infrav1.DeletionPolicyDrop,
+						},
+					}
+					Expect(k8sClient.Create(context.Background(), createdUser)).Should(Succeed())
+				})
+
+				It("expects ready user", func() {
+					expectUserReason(keyUser, infrav1.UserProvisioningSuccessfulReason)
+				})
+
+				It("can access the created database", func() {
+					var client *pgx.Conn
+					Eventually(func() error {
+						c, err := connect(keyUser.Name, password, keyDB.Name)
+						client = c
+						return err
+					}, timeout, interval).Should(Succeed())
+
+					defer func() { _ = client.Close(context.Background()) }()
+
+					_, err := client.Exec(context.Background(), "CREATE TABLE foo (key integer);")
+					Expect(err).NotTo(HaveOccurred(), "failed to create table")
+				})
+
+				It("changes the password", func() {
+					password = randStringRunes(8)
+					createdSecret.Data["password"] = []byte(password)
+					Expect(k8sClient.Update(context.Background(), createdSecret)).Should(Succeed())
+
+					Eventually(func() error {
+						c, err := connect(keyUser.Name, password, keyDB.Name)
+						if err == nil {
+							_ = c.Close(context.Background())
+						}
+						return err
+					}, timeout, interval).Should(Succeed())
+				})
+
+				if flavor.flavor == infrav1.PostgreSQLFlavorCockroachDB {
+					It("reports unsupported role attributes", func() {
+						updateUser(keyUser, func(user *infrav1.PostgreSQLUser) {
+							user.Spec.Attributes = []string{"REPLICATION"}
+						})
+
+						expectUserReason(keyUser, infrav1.UnsupportedByFlavorReason)
+					})
+
+					It("accepts supported role attributes", func() {
+						updateUser(keyUser, func(user *infrav1.PostgreSQLUser) {
+							user.Spec.Attributes = []string{"CREATEDB"}
+						})
+
+						expectUserReason(keyUser, infrav1.UserProvisioningSuccessfulReason)
+					})
+				}
+
+				It("expires the user", func() {
+					updateUser(keyUser, func(user *infrav1.PostgreSQLUser) {
+						validUntil := metav1.NewTime(time.Now().Add(-1 * time.Hour).UTC())
+						user.Spec.ValidUntil = &validUntil
+					})
+
+					expectUserReason(keyUser, infrav1.UserExpiredReason)
+
+					_, err := connect(keyUser.Name, password, keyDB.Name)
+					Expect(err).To(HaveOccurred())
+				})
+
+				It("reactivates the user", func() {
+					updateUser(keyUser, func(user *infrav1.PostgreSQLUser) {
+						user.Spec.ValidUntil = nil
+					})
+
+					expectUserReason(keyUser, infrav1.UserProvisioningSuccessfulReason)
+
+					Eventually(func() error {
+						c, err := connect(keyUser.Name, password, keyDB.Name)
+						if err == nil {
+							_ = c.Close(context.Background())
+						}
+						return err
+					}, timeout, interval).Should(Succeed())
+				})
+
+				It("drops the user on deletion", func() {
+					Expect(k8sClient.Delete(context.Background(), createdUser)).Should(Succeed())
+
+					Eventually(func() error {
+						return k8sClient.Get(context.Background(), keyUser, &infrav1.PostgreSQLUser{})
+					}, timeout, interval).ShouldNot(Succeed())
+
+					root, err := connect(flavor.rootUsername, flavor.rootPassword, keyDB.Name)
+					Expect(err).NotTo(HaveOccurred())
+					defer func() { _ = root.Close(context.Background()) }()
+
+					var count int64
+					Expect(root.QueryRow(context.Background(), "SELECT count(*) FROM pg_roles WHERE rolname = $1", keyUser.Name).Scan(&count)).To(Succeed())
+					Expect(count).To(Equal(int64(0)))
+				})
+			})
+		})
+	}
+})
@@ -32,7 +32,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 
-	infrav1beta1 "github.com/doodlescheduling/db-controller/api/v1beta1"
+	infrav1 "github.com/doodlescheduling/db-controller/api/v1"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -99,7 +99,7 @@ var _ = Describe("PostgreSQL", func() {
 
 			Describe("fails if database not found", Ordered, func() {
 				var (
-					createdUser *infrav1beta1.PostgreSQLUser
+					createdUser *infrav1.PostgreSQLUser
 					keyUser     types.NamespacedName
 				)
 
@@ -110,16 +110,16 @@ var _ = Describe("PostgreSQL", func() {
 						Name:      "postgresuser-" + randStringRunes(5),
 						Namespace: namespace.Name,
 					}
-					createdUser = &infrav1beta1.PostgreSQLUser{
+					createdUser = &infrav1.PostgreSQLUser{
 						ObjectMeta: metav1.ObjectMeta{
 							Name:      keyUser.Name,
 							Namespace: keyUser.Namespace,
 						},
-						Spec: infrav1beta1.PostgreSQLUserSpec{
-							Database: &infrav1beta1.DatabaseReference{
+						Spec: infrav1.PostgreSQLUserSpec{
+							Database: &infrav1.DatabaseReference{
 								Name: "does-not-exist",
 							},
-							Credentials: &infrav1beta1.SecretReference{
+							Credentials: &infrav1.SecretReference{
 								Name: "does-not-exist",
 							},
 						},
@@ -129,22 +129,22 @@ var _ = Describe("PostgreSQL", func() {
 				})
 
 				It("fails reconcile because there is no database", func() {
-					got := &infrav1beta1.PostgreSQLUser{}
+					got := &infrav1.PostgreSQLUser{}
 					Eventually(func() bool {
 						_ = k8sClient.Get(context.Background(), keyUser, got)
 
 						return len(got.Status.Conditions) == 1 &&
-							got.Status.Conditions[0].Reason == infrav1beta1.DatabaseNotFoundReason &&
+							got.Status.Conditions[0].Reason == infrav1.DatabaseNotFoundReason &&
 							got.Status.Conditions[0].Status == "False" &&
-							got.Status.Conditions[0].Type == infrav1beta1.UserReadyConditionType
+							got.Status.Conditions[0].Type == infrav1.UserReadyConditionType
 					}, timeout, interval).Should(BeTrue())
 				})
 			})
 
 			Describe("fails if database root secret not found", Ordered, func() {
 				var (
-					createdDB   *infrav1beta1.PostgreSQLDatabase
-					createdUser *infrav1beta1.PostgreSQLUser
+					createdDB   *infrav1.PostgreSQLDatabase
+					createdUser *infrav1.PostgreSQLUser
 					keyUser     types.NamespacedName
 					keyDB       types.NamespacedName
 				)
@@ -156,17 +156,17 @@ var _ = Describe("PostgreSQL", func() {
 						Name:      "postgresdatabase-" + randStringRunes(5),
 						Namespace: namespace.Name,
 					}
-					createdDB = &infrav1beta1.PostgreSQLDatabase{
+					createdDB = &infrav1.PostgreSQLDatabase{
 						ObjectMeta: metav1.ObjectMeta{
 							Name:      keyDB.Name,
 							Namespace: keyDB.Namespace,
 						},
-						Spec: infrav1beta1.PostgreSQLDatabaseSpec{
-							DatabaseSpec: &infrav1beta1.DatabaseSpec{
+						Spec: infrav1.PostgreSQLDatabaseSpec{
+							DatabaseSpec: infrav1.DatabaseSpec{
 								Timeout: &metav1.Duration{
 									Duration: time.Millisecond * 100,
 								},
-								RootSecret: &infrav1beta1.SecretReference{
+								RootSecret: &infrav1.SecretReference{
 									Name: "does-not-exist",
 								},
 							},
@@ -180,16 +180,16 @@ var _ = Describe("PostgreSQL", func() {
 						Name:      "postgresuser-" + randStringRunes(5),
 						Namespace: namespace.Name,
 					}
-					createdUser = &infrav1beta1.PostgreSQLUser{
+					createdUser = &infrav1.PostgreSQLUser{
 						ObjectMeta: metav1.ObjectMeta{
 							Name:      keyUser.Name,
 							Namespace: keyUser.Namespace,
 						},
-						Spec: infrav1beta1.PostgreSQLUserSpec{
-							Database: &infrav1beta1.DatabaseReference{
+						Spec: infrav1.PostgreSQLUserSpec{
+							Database: &infrav1.DatabaseReference{
 								Name: keyDB.Name,
 							},
-							Credentials: &infrav1beta1.SecretReference{
+							Credentials: &infrav1.SecretReference{
 								Name: "does-not-exist",
 							},
 						},
@@ -198,14 +198,14 @@ var _ = Describe("PostgreSQL", func() {
 				})
 
 				It("fails reconcile because the db root secret is not found", func() {
-					got := &infrav1beta1.PostgreSQLUser{}
+					got := &infrav1.PostgreSQLUser{}
 					Eventually(func() bool {
 						_ = k8sClient.Get(context.Background(), keyUser, got)
 
 						return len(got.Status.Conditions) == 1 &&
-							got.Status.Conditions[0].Reason == infrav1beta1.CredentialsNotFoundReason &&
+							got.Status.Conditions[0].Reason == infrav1.CredentialsNotFoundReason &&
 							got.Status.Conditions[0].Status == "False" &&
-							got.Status.Conditions[0].Type == infrav1beta1.UserReadyConditionType
+							got.Status.Conditions[0].Type == infrav1.UserReadyConditionType
 
 					}, timeout, interval).Should(BeTrue())
 				})
@@ -213,8 +213,8 @@ var _ = Describe("PostgreSQL", func() {
 
 			Describe("fails if user secret is not found", Ordered, func() {
 				var (
-					createdDB   *infrav1beta1.PostgreSQLDatabase
-					createdUser *infrav1beta1.PostgreSQLUser
+					createdDB   *infrav1.PostgreSQLDatabase
+					createdUser *infrav1.PostgreSQLUser
 					keyUser     types.NamespacedName
 					keyDB       types.NamespacedName
 				)
@@ -226,18 +226,18 @@ var _ = Describe("PostgreSQL", func() {
 						Name:      "postgresdatabase-" + randStringRunes(5),
 						Namespace: namespace.Name,
 					}
-					createdDB = &infrav1beta1.PostgreSQLDatabase{
+					createdDB = &infrav1.PostgreSQLDatabase{
 						ObjectMeta: metav1.ObjectMeta{
 							Name:      keyDB.Name,
 							Namespace: keyDB.Namespace,
 						},
-						Spec: infrav1beta1.PostgreSQLDatabaseSpec{
-							DatabaseSpec: &infrav1beta1.DatabaseSpec{
+						Spec: infrav1.PostgreSQLDatabaseSpec{
+							DatabaseSpec: infrav1.DatabaseSpec{
 								Timeout: &metav1.Duration{
 									Duration: time.Millisecond * 100,
 								},
 								Address: "postgres://does-not-exist:5432",
-								RootSecret: &infrav1beta1.SecretReference{
+								RootSecret: &infrav1.SecretReference{
 									Name: rootSecret.Name,
 								},
 							},
@@ -251,16 +251,16 @@ var _ = Describe("PostgreSQL", func() {
 						Name:      "postgresuser-" + randStringRunes(5),
 						Namespace: namespace.Name,
 					}
-					createdUser = &infrav1beta1.PostgreSQLUser{
+					createdUser = &infrav1.PostgreSQLUser{
 						ObjectMeta: metav1.ObjectMeta{
 							Name:      keyUser.Name,
 							Namespace: keyUser.Namespace,
 						},
-						Spec: infrav1beta1.PostgreSQLUserSpec{
-							Database: &infrav1beta1.DatabaseReference{
+						Spec: infrav1.PostgreSQLUserSpec{
+							Database: &infrav1.DatabaseReference{
 								Name: keyDB.Name,
 							},
-							Credentials: &infrav1beta1.SecretReference{
+							Credentials: &infrav1.SecretReference{
 								Name: "does-not-exist",
 							},
 						},
@@ -269,21 +269,21 @@ var _ = Describe("PostgreSQL", func() {
 				})
 
 				It("expects reconcile to fail because the user secret is not found", func() {
-					got := &infrav1beta1.PostgreSQLUser{}
+					got := &infrav1.PostgreSQLUser{}
 					Eventually(func() bool {
 						_ = k8sClient.Get(context.Background(), keyUser, got)
 						return len(got.Status.Conditions) == 1 &&
-							got.Status.Conditions[0].Reason == infrav1beta1.CredentialsNotFoundReason &&
+							got.Status.Conditions[0].Reason == infrav1.CredentialsNotFoundReason &&
 							got.Status.Conditions[0].Status == "False" &&
-							got.Status.Conditions[0].Type == infrav1beta1.UserReadyConditionType
+							got.Status.Conditions[0].Type == infrav1.UserReadyConditionType
 
 					}, timeout, interval).Should(BeTrue())
 				})
 			})
 			Describe("fails if database can't be reached", Ordered, func() {
 				var (
-					createdDB     *infrav1beta1.PostgreSQLDatabase
-					createdUser   *infrav1beta1.PostgreSQLUser
+					createdDB     *infrav1.PostgreSQLDatabase
+					createdUser   *infrav1.PostgreSQLUser
 					keyUser       types.NamespacedName
 					keyDB         types.NamespacedName
 					keySecret     types.NamespacedName
@@ -298,18 +298,18 @@ var _ = Describe("PostgreSQL", func() {
 						Name:      "postgresdatabase-" + randStringRunes(5),
 						Namespace: namespace.Name,
 					}
-					createdDB = &infrav1beta1.PostgreSQLDatabase{
+					createdDB = &infrav1.PostgreSQLDatabase{
 						ObjectMeta: metav1.ObjectMeta{
 							Name:      keyDB.Name,
 							Namespace: keyDB.Namespace,
 						},
-						Spec: infrav1beta1.PostgreSQLDatabaseSpec{
-							DatabaseSpec: &infrav1beta1.DatabaseSpec{
+						Spec: infrav1.PostgreSQLDatabaseSpec{
+							DatabaseSpec: infrav1.DatabaseSpec{
 								Timeout: &metav1.Duration{
 									Duration: time.Millisecond * 100,
 								},
 								Address: "postgres://does-not-exist:5432",
-								RootSecret: &infrav1beta1.SecretReference{
+								RootSecret: &infrav1.SecretReference{
 									Name: rootSecret.Name,
 								},
 							},
@@ -342,16 +342,16 @@ var _ = Describe("PostgreSQL", func() {
 						Name:      "postgresuser-" + randStringRunes(5),
 						Namespace: namespace.Name,
 					}
-					createdUser = &infrav1beta1.PostgreSQLUser{
+					createdUser = &infrav1.PostgreSQLUser{
 						ObjectMeta: metav1.ObjectMeta{
 							Name:      keyUser.Name,
 							Namespace: keyUser.Namespace,
 						},
-						Spec: infrav1beta1.PostgreSQLUserSpec{
-							Database: &infrav1beta1.DatabaseReference{
+						Spec: infrav1.PostgreSQLUserSpec{
+							Database: &infrav1.DatabaseReference{
 								Name: keyDB.Name,
 							},
-							Credentials: &infrav1beta1.SecretReference{
+							Credentials: &infrav1.SecretReference{
 								Name: keySecret.Name,
 							},
 						},
@@ -360,13 +360,13 @@ var _ = Describe("PostgreSQL", func() {
 				})
 
 				It("fails reconcile because database can't be reached", func() {
-					got := &infrav1beta1.PostgreSQLUser{}
+					got := &infrav1.PostgreSQLUser{}
 					Eventually(func() bool {
 						_ = k8sClient.Get(context.Background(), keyUser, got)
 						return len(got.Status.Conditions) == 1 &&
-							got.Status.Conditions[0].Reason == infrav1beta1.ConnectionFailedReason &&
+							got.Status.Conditions[0].Reason == infrav1.ConnectionFailedReason &&
 							got.Status.Conditions[0].Status == "False" &&
-							got.Status.Conditions[0].Type == infrav1beta1.UserReadyConditionType
+							got.Status.Conditions[0].Type == infrav1.UserReadyConditionType
 
 					}, timeout, interval).Should(BeTrue())
 				})
@@ -374,8 +374,8 @@ var _ = Describe("PostgreSQL", func() {
 
 			Describe("fails if user secret exists but fields not found", Ordered, func() {
 				var (
-					createdDB     *infrav1beta1.PostgreSQLDatabase
-					createdUser   *infrav1beta1.PostgreSQLUser
+					createdDB     *infrav1.PostgreSQLDatabase
+					createdUser   *infrav1.PostgreSQLUser
 					keyUser       types.NamespacedName
 					keyDB         types.NamespacedName
 					createdSecret *corev1.Secret
@@ -390,18 +390,18 @@ var _ = Describe("PostgreSQL", func() {
 						Name:      "postgresdatabase-" + randStringRunes(5),
 						Namespace: namespace.Name,
 					}
-					createdDB = &infrav1beta1.PostgreSQLDatabase{
+					createdDB = &infrav1.PostgreSQLDatabase{
 						ObjectMeta: metav1.ObjectMeta{
 							Name:      keyDB.Name,
 							Namespace: keyDB.Namespace,
 						},
-						Spec: infrav1beta1.PostgreSQLDatabaseSpec{
-							DatabaseSpec: &infrav1beta1.DatabaseSpec{
+						Spec: infrav1.PostgreSQLDatabaseSpec{
+							DatabaseSpec: infrav1.DatabaseSpec{
 								Timeout: &metav1.Duration{
 									Duration: time.Millisecond * 100,
 								},
 								Address: container.URI,
-								RootSecret: &infrav1beta1.SecretReference{
+								RootSecret: &infrav1.SecretReference{
 									Name: rootSecret.Name,
 								},
 							},
@@ -419,16 +419,16 @@ var _ = Describe("PostgreSQL", func() {
 						Name:      "postgresuser-" + randStringRunes(5),
 						Namespace: namespace.Name,
 					}
-					createdUser = &infrav1beta1.PostgreSQLUser{
+					createdUser = &infrav1.PostgreSQLUser{
 						ObjectMeta: metav1.ObjectMeta{
 							Name:      keyUser.Name,
 							Namespace: keyUser.Namespace,
 						},
-						Spec: infrav1beta1.PostgreSQLUserSpec{
-							Database: &infrav1beta1.DatabaseReference{
+						Spec: infrav1.PostgreSQLUserSpec{
+							Database: &infrav1.DatabaseReference{
 								Name: keyDB.Name,
 							},
-							Credentials: &infrav1beta1.SecretReference{
+							Credentials: &infrav1.SecretReference{
 								Name:      keySecret.Name,
 								UserField: "does-not-exist",
 							},
@@ -453,23 +453,140 @@ var _ = Describe("PostgreSQL", func() {
 				})
 
 				It("fails reconcile because user field in secret is not found", func() {
-					got := &infrav1beta1.PostgreSQLUser{}
+					got := &infrav1.PostgreSQLUser{}
 					Eventually(func() bool {
 						_ = k8sClient.Get(context.Background(), keyUser, got)
 						return len(got.Status.Conditions) == 1 &&
-							got.Status.Conditions[0].Reason == infrav1beta1.CredentialsNotFoundReason &&
+							got.Status.Conditions[0].Reason == infrav1.CredentialsNotFoundReason &&
 							got.Status.Conditions[0].Status == "False" &&
 							strings.Contains(got.Status.Conditions[0].Message, "credentials field not found in referenced secret:") &&
-							got.Status.Conditions[0].Type == infrav1beta1.UserReadyConditionType
+							got.Status.Conditions[0].Type == infrav1.UserReadyConditionType
 
 					}, timeout, interval).Should(BeTrue())
 				})
 			})
 
+			Describe("Drop user on deletion", Ordered, func() {
+				var (
+					createdUser *infrav1.PostgreSQLUser
+					keyUser     types.NamespacedName
+					keyDB       types.NamespacedName
+					password    string
+				)
+
+				namespace, rootSecret := setupNamespace()
+
+				rootURI := func(database string) string {
+					popt, err := url.Parse(container.URI)
+					Expect(err).NotTo(HaveOccurred(), "failed to parse postgresql uri")
+					popt.User = url.UserPassword(postgresRootUsername, postgresRootPassword)
+					popt.Path = database
+					return popt.String()
+				}
+
+				It("adds database", func() {
+					keyDB = types.NamespacedName{
+						Name:      "postgresdatabase-" + randStringRunes(5),
+						Namespace: namespace.Name,
+					}
+					Expect(k8sClient.Create(context.Background(), &infrav1.PostgreSQLDatabase{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      keyDB.Name,
+							Namespace: keyDB.Namespace,
+						},
+						Spec: infrav1.PostgreSQLDatabaseSpec{
+							DatabaseSpec: infrav1.DatabaseSpec{
+								Address: container.URI,
+								RootSecret: &infrav1.SecretReference{
+									Name: rootSecret.Name,
+								},
+							},
+						},
+					})).Should(Succeed())
+				})
+
+				It("adds user with drop policy", func() {
+					keyUser = types.NamespacedName{
+						Name:      "postgresuser-" + randStringRunes(5),
+						Namespace: namespace.Name,
+					}
+					password = randStringRunes(5)
+					Expect(k8sClient.Create(context.Background(), &corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      keyUser.Name,
+							Namespace: keyUser.Namespace,
+						},
+						Data: map[string][]byte{
+							"username": []byte(keyUser.Name),
+							"password": []byte(password),
+						},
+					})).Should(Succeed())
+
+					createdUser = &infrav1.PostgreSQLUser{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      keyUser.Name,
+							Namespace: keyUser.Namespace,
+						},
+						Spec: infrav1.PostgreSQLUserSpec{
+							Database: &infrav1.DatabaseReference{
+								Name: keyDB.Name,
+							},
+							Credentials: &infrav1.SecretReference{
+								Name: keyUser.Name,
+							},
+							DeletionPolicy: infrav1.DeletionPolicyDrop,
+						},
+					}
+					Expect(k8sClient.Create(context.Background(), createdUser)).Should(Succeed())
+				})
+
+				It("creates a table owned by the user", func() {
+					popt, err := url.Parse(container.URI)
+					Expect(err).NotTo(HaveOccurred(), "failed to parse postgresql uri")
+					popt.User = url.UserPassword(keyUser.Name, password)
+					popt.Path = keyDB.Name
+
+					var client *pgx.Conn
+					Eventually(func() error {
+						c, err := pgx.Connect(ctx, popt.String())
+						client = c
+						return err
+					}, timeout, interval).Should(Succeed())
+
+					defer func() { _ = client.Close(ctx) }()
+
+					_, err = client.Exec(ctx, "CREATE TABLE owned (key integer);")
+					Expect(err).NotTo(HaveOccurred(), "failed to create table")
+				})
+
+				It("deletes user", func() {
+					Expect(k8sClient.Delete(context.Background(), createdUser)).Should(Succeed())
+
+					got := &infrav1.PostgreSQLUser{}
+					Eventually(func() error {
+						return k8sClient.Get(context.Background(), keyUser, got)
+					}, timeout, interval).ShouldNot(Succeed())
+				})
+
+				It("dropped the role and reassigned owned objects", func() {
+					client, err := pgx.Connect(ctx, rootURI(keyDB.Name))
+					Expect(err).NotTo(HaveOccurred(), "failed to connect to postgresql")
+					defer func() { _ = client.Close(ctx) }()
+
+					var count int
+					Expect(client.QueryRow(ctx, "SELECT count(*) FROM pg_roles WHERE rolname=$1", keyUser.Name).Scan(&count)).To(Succeed())
+					Expect(count).To(Equal(0))
+
+					var owner string
+					Expect(client.QueryRow(ctx, "SELECT tableowner FROM pg_tables WHERE tablename='owned'").Scan(&owner)).To(Succeed())
+					Expect(owner).To(Equal(postgresRootUsername))
+				})
+			})
+
 			Describe("Successful user creation", Ordered, func() {
 				var (
-					createdDB     *infrav1beta1.PostgreSQLDatabase
-					createdUser   *infrav1beta1.PostgreSQLUser
+					createdDB     *infrav1.PostgreSQLDatabase
+					createdUser   *infrav1.PostgreSQLUser
 					createdSecret *corev1.Secret
 					keyUser       types.NamespacedName
 					keyDB         types.NamespacedName
@@ -485,18 +602,18 @@ var _ = Describe("PostgreSQL", func() {
 							Name:      "postgresdatabase-" + randStringRunes(5),
 							Namespace: namespace.Name,
 						}
-						createdDB = &infrav1beta1.PostgreSQLDatabase{
+						createdDB = &infrav1.PostgreSQLDatabase{
 							ObjectMeta: metav1.ObjectMeta{
 								Name:      keyDB.Name,
 								Namespace: keyDB.Namespace,
 							},
-							Spec: infrav1beta1.PostgreSQLDatabaseSpec{
-								DatabaseSpec: &infrav1beta1.DatabaseSpec{
+							Spec: infrav1.PostgreSQLDatabaseSpec{
+								DatabaseSpec: infrav1.DatabaseSpec{
 									Timeout: &metav1.Duration{
 										Duration: time.Millisecond * 100,
 									},
 									Address: container.URI,
-									RootSecret: &infrav1beta1.SecretReference{
+									RootSecret: &infrav1.SecretReference{
 										Name: rootSecret.Name,
 									},
 								},
@@ -530,16 +647,16 @@ var _ = Describe("PostgreSQL", func() {
 					})
 
 					It("adds user", func() {
-						createdUser = &infrav1beta1.PostgreSQLUser{
+						createdUser = &infrav1.PostgreSQLUser{
 							ObjectMeta: metav1.ObjectMeta{
 								Name:      keyUser.Name,
 								Namespace: keyUser.Namespace,
 							},
-							Spec: infrav1beta1.PostgreSQLUserSpec{
-								Database: &infrav1beta1.DatabaseReference{
+							Spec: infrav1.PostgreSQLUserSpec{
+								Database: &infrav1.DatabaseReference{
 									Name: keyDB.Name,
 								},
-								Credentials: &infrav1beta1.SecretReference{
+								Credentials: &infrav1.SecretReference{
 									Name: keySecret.Name,
 								},
 							},
@@ -548,13 +665,13 @@ var _ = Describe("PostgreSQL", func() {
 					})
 
 					It("expects ready user", func() {
-						got := &infrav1beta1.PostgreSQLUser{}
+						got := &infrav1.PostgreSQLUser{}
 						Eventually(func() bool {
 							_ = k8sClient.Get(context.Background(), keyUser, got)
 							return len(got.Status.Conditions) == 1 &&
-								got.Status.Conditions[0].Reason == infrav1beta1.UserProvisioningSuccessfulReason &&
+								got.Status.Conditions[0].Reason == infrav1.UserProvisioningSuccessfulReason &&
 								got.Status.Conditions[0].Status == "True" &&
-								got.Status.Conditions[0].Type == infrav1beta1.UserReadyConditionType
+								got.Status.Conditions[0].Type == infrav1.UserReadyConditionType
 
 						}, timeout, interval).Should(BeTrue())
 					})
@@ -631,13 +748,13 @@ var _ = Describe("PostgreSQL", func() {
 					})
 
 					It("expects ready user", func() {
-						got := &infrav1beta1.PostgreSQLUser{}
+						got := &infrav1.PostgreSQLUser{}
 						Eventually(func() bool {
 							_ = k8sClient.Get(context.Background(), keyUser, got)
 							return len(got.Status.Conditions) == 1 &&
-								got.Status.Conditions[0].Reason == infrav1beta1.UserProvisioningSuccessfulReason &&
+								got.Status.Conditions[0].Reason == infrav1.UserProvisioningSuccessfulReason &&
 								got.Status.Conditions[0].Status == "True" &&
-								got.Status.Conditions[0].Type == infrav1beta1.UserReadyConditionType
+								got.Status.Conditions[0].Type == infrav1.UserReadyConditionType
 
 						}, timeout, interval).Should(BeTrue())
 					})
@@ -662,6 +779,8 @@ var _ = Describe("PostgreSQL", func() {
 				})
 
 				Describe("ValidUntil", Ordered, func() {
+					var session *pgx.Conn
+
 					It("sets validUntil in the future for the user", func() {
 						err := k8sClient.Get(context.Background(), keyUser, createdUser)
 						Expect(err).Should(Succeed())
@@ -673,15 +792,15 @@ var _ = Describe("PostgreSQL", func() {
 					})
 
 					It("expects ready user after setting validUntil in the future", func() {
-						got := &infrav1beta1.PostgreSQLUser{}
+						got := &infrav1.PostgreSQLUser{}
 
 						Eventually(func() bool {
 							_ = k8sClient.Get(context.Background(), keyUser, got)
 
 							return len(got.Status.Conditions) == 1 &&
-								got.Status.Conditions[0].Reason == infrav1beta1.UserProvisioningSuccessfulReason &&
+								got.Status.Conditions[0].Reason == infrav1.UserProvisioningSuccessfulReason &&
 								got.Status.Conditions[0].Status == "True" &&
-								got.Status.Conditions[0].Type == infrav1beta1.UserReadyConditionType &&
+								got.Status.Conditions[0].Type == infrav1.UserReadyConditionType &&
 								got.ObjectMeta.Generation == got.Status.ObservedGeneration
 						}, timeout, interval).Should(BeTrue())
 					})
@@ -709,6 +828,35 @@ var _ = Describe("PostgreSQL", func() {
 						}()
 					})
 
+					It("sets valid until on the role", func() {
+						popt, err := url.Parse(container.URI)
+						Expect(err).NotTo(HaveOccurred(), "failed to parse postgresql uri")
+						popt.User = url.UserPassword(postgresRootUsername, postgresRootPassword)
+						popt.Path = keyDB.Name
+
+						client, err := pgx.Connect(ctx, popt.String())
+						Expect(err).NotTo(HaveOccurred(), "failed to connect to postgresql")
+						defer func() { _ = client.Close(ctx) }()
+
+						var validUntil time.Time
+						Expect(client.QueryRow(ctx, "SELECT rolvaliduntil FROM pg_roles WHERE rolname=$1", keyUser.Name).Scan(&validUntil)).To(Succeed())
+						Expect(validUntil.Unix()).To(Equal(createdUser.Spec.ValidUntil.Unix()))
+					})
+
+					It("opens a session before validUntil expires", func() {
+						popt, err := url.Parse(container.URI)
+						Expect(err).NotTo(HaveOccurred(), "failed to parse postgresql uri")
+
+						popt.User = url.UserPassword(keyUser.Name, password)
+						popt.Path = keyDB.Name
+
+						Eventually(func() error {
+							c, err := pgx.Connect(ctx, popt.String())
+							session = c
+							return err
+						}, timeout, interval).Should(Succeed())
+					})
+
 					It("sets validUntil in the past for the user", func() {
 						err := k8sClient.Get(context.Background(), keyUser, createdUser)
 						Expect(err).Should(Succeed())
@@ -720,15 +868,15 @@ var _ = Describe("PostgreSQL", func() {
 					})
 
 					It("sets expired status after validUntil expires", func() {
-						got := &infrav1beta1.PostgreSQLUser{}
+						got := &infrav1.PostgreSQLUser{}
 
 						Eventually(func() bool {
 							_ = k8sClient.Get(context.Background(), keyUser, got)
 
 							return len(got.Status.Conditions) == 1 &&
-								got.Status.Conditions[0].Reason == infrav1beta1.UserExpiredReason &&
+								got.Status.Conditions[0].Reason == infrav1.UserExpiredReason &&
 								got.Status.Conditions[0].Status == "False" &&
-								got.Status.Conditions[0].Type == infrav1beta1.UserReadyConditionType &&
+								got.Status.Conditions[0].Type == infrav1.UserReadyConditionType &&
 								got.ObjectMeta.Generation == got.Status.ObservedGeneration
 						}, timeout, interval).Should(BeTrue())
 					})
@@ -752,26 +900,89 @@ var _ = Describe("PostgreSQL", func() {
 						}, timeout, interval).ShouldNot(Succeed())
 					})
 
+					It("terminated the open session after validUntil expired", func() {
+						Eventually(func() error {
+							_, err := session.Exec(ctx, "SELECT 1;")
+							return err
+						}, timeout, interval).ShouldNot(Succeed())
+					})
+
 					It("keeps the PostgreSQLUser resource after expiration", func() {
-						got := &infrav1beta1.PostgreSQLUser{}
+						got := &infrav1.PostgreSQLUser{}
 
 						Expect(k8sClient.Get(context.Background(), keyUser, got)).Should(Succeed())
 					})
 
 					It("keeps the finalizer after expiration", func() {
-						got := &infrav1beta1.PostgreSQLUser{}
+						got := &infrav1.PostgreSQLUser{}
 
 						Expect(k8sClient.Get(context.Background(), keyUser, got)).Should(Succeed())
-						Expect(got.Finalizers).To(ContainElement(infrav1beta1.Finalizer))
+						Expect(got.Finalizers).To(ContainElement(infrav1.Finalizer))
+					})
+
+					It("clears validUntil for the user", func() {
+						err := k8sClient.Get(context.Background(), keyUser, createdUser)
+						Expect(err).Should(Succeed())
+
+						createdUser.Spec.ValidUntil = nil
+						Expect(k8sClient.Update(context.Background(), createdUser)).Should(Succeed())
+					})
+
+					It("expects ready user after clearing validUntil", func() {
+						got := &infrav1.PostgreSQLUser{}
+
+						Eventually(func() bool {
+							_ = k8sClient.Get(context.Background(), keyUser, got)
+
+							return len(got.Status.Conditions) == 1 &&
+								got.Status.Conditions[0].Reason == infrav1.UserProvisioningSuccessfulReason &&
+								got.Status.Conditions[0].Status == "True" &&
+								got.ObjectMeta.Generation == got.Status.ObservedGeneration
+						}, timeout, interval).Should(BeTrue())
+					})
+
+					It("can access the database again after clearing validUntil", func() {
+						popt, err := url.Parse(container.URI)
+						Expect(err).NotTo(HaveOccurred(), "failed to parse postgresql uri")
+
+						popt.User = url.UserPassword(keyUser.Name, password)
+						q, _ := url.ParseQuery(popt.RawQuery)
+						q.Add("connect_timeout", "2")
+						popt.RawQuery = q.Encode()
+						popt.Path = keyDB.Name
+
+						Eventually(func() error {
+							conn, err := pgx.Connect(ctx, popt.String())
+							if err == nil {
+								_ = conn.Close(ctx)
+							}
+							return err
+						}, timeout, interval).Should(Succeed())
 					})
 				})
 				Describe("Delete user removes user from postgres", Ordered, func() {
+					var session *pgx.Conn
+
+					It("opens a session before deletion", func() {
+						popt, err := url.Parse(container.URI)
+						Expect(err).NotTo(HaveOccurred(), "failed to parse postgresql uri")
+
+						popt.User = url.UserPassword(keyUser.Name, password)
+						popt.Path = keyDB.Name
+
+						Eventually(func() error {
+							c, err := pgx.Connect(ctx, popt.String())
+							session = c
+							return err
+						}, timeout, interval).Should(Succeed())
+					})
+
 					It("deletes user", func() {
 						Expect(k8sClient.Delete(context.Background(), createdUser)).Should(Succeed())
 					})
 
 					It("expects gone", func() {
-						got := &infrav1beta1.PostgreSQLUser{}
+						got := &infrav1.PostgreSQLUser{}
 						Eventually(func() error {
 							return k8sClient.Get(context.Background(), keyUser, got)
 						}, timeout, interval).ShouldNot(Succeed())
@@ -794,6 +1005,28 @@ var _ = Describe("PostgreSQL", func() {
 							return err
 						}, timeout, interval).ShouldNot(Succeed())
 					})
+
+					It("terminated the open session", func() {
+						Eventually(func() error {
+							_, err := session.Exec(ctx, "SELECT 1;")
+							return err
+						}, timeout, interval).ShouldNot(Succeed())
+					})
+
+					It("disallowed the login of the disabled role", func() {
+						popt, err := url.Parse(container.URI)
+						Expect(err).NotTo(HaveOccurred(), "failed to parse postgresql uri")
+						popt.User = url.UserPassword(postgresRootUsername, postgresRootPassword)
+						popt.Path = keyDB.Name
+
+						client, err := pgx.Connect(ctx, popt.String())
+						Expect(err).NotTo(HaveOccurred(), "failed to connect to postgresql")
+						defer func() { _ = client.Close(ctx) }()
+
+						var canLogin bool
+						Expect(client.QueryRow(ctx, "SELECT rolcanlogin FROM pg_roles WHERE rolname=$1", keyUser.Name).Scan(&canLogin)).To(Succeed())
+						Expect(canLogin).To(BeFalse())
+					})
 				})
 			})
 		})
@@ -18,6 +18,7 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/go-logr/logr"
@@ -34,8 +35,11 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
-	infrav1beta1 "github.com/doodlescheduling/db-controller/api/v1beta1"
+	infrav1 "github.com/doodlescheduling/db-controller/api/v1"
+	"github.com/doodlescheduling/db-controller/internal/audit"
+	"github.com/doodlescheduling/db-controller/internal/database"
 	"github.com/doodlescheduling/db-controller/internal/stringutils"
+	"github.com/doodlescheduling/db-controller/internal/tracing"
 )
 
 // +kubebuilder:rbac:groups=dbprovisioning.infra.doodle.com,resources=postgresqldatabases,verbs=get;list;watch;create;update;patch;delete
@@ -49,13 +53,17 @@ type PostgreSQLDatabaseReconciler struct {
 	Log      logr.Logger
 	Scheme   *runtime.Scheme
 	Recorder events.EventRecorder
+	// Provisioners is the registry to connect to the servers, the default registry is used if not set
+	Provisioners *database.Registry
+
+	triggers audit.Triggers
 }
 
 func (r *PostgreSQLDatabaseReconciler) SetupWithManager(mgr ctrl.Manager, maxConcurrentReconciles int) error {
 	// Index the PostgreSQLDatabase by the Secret references they point at
-	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &infrav1beta1.PostgreSQLDatabase{}, secretIndexKey,
+	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &infrav1.PostgreSQLDatabase{}, secretIndexKey,
 		func(o client.Object) []string {
-			vb := o.(*infrav1beta1.PostgreSQLDatabase)
+			vb := o.(*infrav1.PostgreSQLDatabase)
 			return []string{
 				fmt.Sprintf("%s/%s", vb.GetNamespace(), vb.Spec.RootSecret.Name),
 			}
@@ -65,7 +73,7 @@ func (r *PostgreSQLDatabaseReconciler) SetupWithManager(mgr ctrl.Manager, maxCon
 	}
 
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&infrav1beta1.PostgreSQLDatabase{}, builder.WithPredicates(
+		For(&infrav1.PostgreSQLDatabase{}, builder.WithPredicates(
 			predicate.GenerationChangedPredicate{},
 		)).
 		Watches(
@@ -82,7 +90,7 @@ func (r *PostgreSQLDatabaseReconciler) requestsForSecretChange(ctx context.Conte
 		panic(fmt.Sprintf("expected a Secret, got %T", o))
 	}
 
-	var list infrav1beta1.PostgreSQLDatabaseList
+	var list infrav1.PostgreSQLDatabaseList
 	if err := r.List(ctx, &list, client.MatchingFields{
 		secretIndexKey: objectKey(s).String(),
 	}); err != nil {
@@ -92,6 +100,7 @@ func (r *PostgreSQLDatabaseReconciler) requestsForSecretChange(ctx context.Conte
 	var reqs []reconcile.Request
 	for _, i := range list.Items {
 		r.Log.Info("referenced secret from a PostgreSQLDatabase changed detected", "namespace", i.GetNamespace(), "name", i.GetName())
+		r.triggers.SecretChanged(objectKey(&i), objectKey(s))
 		reqs = append(reqs, reconcile.Request{NamespacedName: objectKey(&i)})
 	}
 
@@ -99,11 +108,14 @@ func (r *PostgreSQLDatabaseReconciler) requestsForSecretChange(ctx context.Conte
 }
 
 func (r *PostgreSQLDatabaseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx, span := tracing.Start(ctx, "PostgreSQLDatabase.Reconcile", tracing.Object("PostgreSQLDatabase", req.NamespacedName)...)
+	defer span.End()
+
 	logger := r.Log.WithValues("PostgreSQLDatabase", req.NamespacedName)
 	logger.Info("reconciling PostgreSQLDatabase")
 
 	// get database resource by namespaced name
-	var db infrav1beta1.PostgreSQLDatabase
+	var db infrav1.PostgreSQLDatabase
 	if err := r.Get(ctx, req.NamespacedName, &db); err != nil {
 		if apierrors.IsNotFound(err) {
 			// resource no longer present. Consider dropping a database? What about data, it will be lost.. Probably acceptable for devboxes
@@ -112,12 +124,12 @@ func (r *PostgreSQLDatabaseReconciler) Reconcile(ctx context.Context, req ctrl.R
 		return ctrl.Result{}, err
 	}
 
-	_ = db.SetDefaults()
+	ctx = audit.WithSource(ctx, r.triggers.Source("PostgreSQLDatabase", &db, db.Status.ObservedGeneration))
 
 	// examine DeletionTimestamp to determine if object is under deletion
 	if db.DeletionTimestamp.IsZero() {
-		if !stringutils.ContainsString(db.GetFinalizers(), infrav1beta1.Finalizer) {
-			controllerutil.AddFinalizer(&db, infrav1beta1.Finalizer)
+		if !stringutils.ContainsString(db.GetFinalizers(), infrav1.Finalizer) {
+			controllerutil.AddFinalizer(&db, infrav1.Finalizer)
 			if err := r.Update(ctx, &db); err != nil {
 				return ctrl.Result{}, err
 			}
@@ -132,6 +144,7 @@ func (r *PostgreSQLDatabaseReconciler) Reconcile(ctx context.Context, req ctrl.R
 	}
 
 	db, reconcileErr := r.reconcile(reconcileContext, db)
+	tracing.RecordError(span, reconcileErr)
 	res := ctrl.Result{}
 	db.Status.ObservedGeneration = db.GetGeneration()
 
@@ -140,9 +153,11 @@ func (r *PostgreSQLDatabaseReconciler) Reconcile(ctx context.Context, req ctrl.R
 	} else {
 		msg := "Database successfully provisioned"
 		r.Recorder.Eventf(&db, nil, "Normal", "info", "Reconcile", "%s", msg)
-		infrav1beta1.DatabaseReadyCondition(&db, infrav1beta1.DatabaseProvisioningSuccessfulReason, msg)
+		infrav1.DatabaseReadyCondition(&db, infrav1.DatabaseProvisioningSuccessfulReason, msg)
 	}
 
+	recordReconcile("PostgreSQLDatabase", db.Status.Conditions, infrav1.DatabaseReadyConditionType)
+
 	// Update status after reconciliation.
 	if err := r.patchStatus(ctx, &db); err != nil {
 		logger.Error(err, "unable to update status after reconciliation")
@@ -152,18 +167,18 @@ func (r *PostgreSQLDatabaseReconciler) Reconcile(ctx context.Context, req ctrl.R
 	return res, reconcileErr
 }
 
-func (r *PostgreSQLDatabaseReconciler) reconcile(ctx context.Context, db infrav1beta1.PostgreSQLDatabase) (infrav1beta1.PostgreSQLDatabase, error) {
+func (r *PostgreSQLDatabaseReconciler) reconcile(ctx context.Context, db infrav1.PostgreSQLDatabase) (infrav1.PostgreSQLDatabase, error) {
 	usr, pw, addr, err := getSecret(ctx, r.Client, db.GetRootSecret())
 
 	if err != nil {
-		infrav1beta1.DatabaseNotReadyCondition(&db, infrav1beta1.CredentialsNotFoundReason, err.Error())
+		infrav1.DatabaseNotReadyCondition(&db, infrav1.CredentialsNotFoundReason, err.Error())
 		return db, err
 	}
 
-	rootDBHandler, err := setupPostgreSQL(ctx, db, usr, pw, addr, false)
+	rootDBHandler, err := setupPostgreSQL[database.DatabaseProvisioner](ctx, r.Provisioners, db, usr, pw, addr, false)
 
 	if err != nil {
-		infrav1beta1.DatabaseNotReadyCondition(&db, infrav1beta1.ConnectionFailedReason, err.Error())
+		infrav1.DatabaseNotReadyCondition(&db, infrav1.ConnectionFailedReason, err.Error())
 		return db, err
 	}
 
@@ -176,38 +191,51 @@ func (r *PostgreSQLDatabaseReconciler) reconcile(ctx context.Context, db infrav1
 	err = rootDBHandler.CreateDatabaseIfNotExists(ctx, db.GetDatabaseName())
 	if err != nil {
 		err = fmt.Errorf("failed to provision database: %w", err)
-		infrav1beta1.DatabaseNotReadyCondition(&db, infrav1beta1.CreateDatabaseFailedReason, err.Error())
+		infrav1.DatabaseNotReadyCondition(&db, infrav1.CreateDatabaseFailedReason, err.Error())
 		return db, err
 	}
 
-	dbHandler, err := setupPostgreSQL(ctx, db, usr, pw, addr, true)
+	dbHandler, err := setupPostgreSQL[database.PostgreSQLDatabaseProvisioner](ctx, r.Provisioners, db, usr, pw, addr, true)
 
 	if err != nil {
-		infrav1beta1.DatabaseNotReadyCondition(&db, infrav1beta1.ConnectionFailedReason, err.Error())
+		infrav1.DatabaseNotReadyCondition(&db, infrav1.ConnectionFailedReason, err.Error())
 		return db, err
 	}
 
 	defer func() { _ = dbHandler.Close(ctx) }()
 
+	extensionsSkipped := false
 	for _, ext := range db.Spec.Extensions {
-		if err := dbHandler.EnableExtension(ctx, db.GetDatabaseName(), ext.Name); err != nil {
+		err := dbHandler.EnableExtension(ctx, db.GetDatabaseName(), ext.Name)
+		if errors.Is(err, database.ErrUnsupportedByFlavor) {
+			// Extensions are skipped rather than failing the whole database
+			msg := fmt.Sprintf("extensions are not supported by flavor %s and were skipped", db.GetFlavor())
+			r.Recorder.Eventf(&db, nil, "Normal", "error", "Reconcile", "%s", msg)
+			infrav1.ExtensionNotReadyCondition(&db, infrav1.UnsupportedByFlavorReason, msg)
+			extensionsSkipped = true
+			break
+		}
+
+		if err != nil {
 			err = fmt.Errorf("failed to create extension %s in database: %w", ext.Name, err)
-			infrav1beta1.ExtensionNotReadyCondition(&db, infrav1beta1.CreateExtensionsFailedReason, err.Error())
+			infrav1.ExtensionNotReadyCondition(&db, infrav1.CreateExtensionsFailedReason, err.Error())
 			return db, err
 		}
 	}
 
-	infrav1beta1.ExtensionReadyCondition(&db, infrav1beta1.CreateExtensionsSuccessfulReason, "")
+	if !extensionsSkipped {
+		infrav1.ExtensionReadyCondition(&db, infrav1.CreateExtensionsSuccessfulReason, "")
+	}
 
 	for _, schema := range db.Spec.Schemas {
 		if err := dbHandler.CreateSchema(ctx, db.GetDatabaseName(), schema.Name); err != nil {
 			err = fmt.Errorf("failed to create schemas %s in database: %w", schema.Name, err)
-			infrav1beta1.SchemaNotReadyCondition(&db, infrav1beta1.CreateSchemasFailedReason, err.Error())
+			infrav1.SchemaNotReadyCondition(&db, infrav1.CreateSchemasFailedReason, err.Error())
 			return db, err
 		}
 	}
 
-	infrav1beta1.SchemaReadyCondition(&db, infrav1beta1.CreateSchemasSuccessfulReason, "")
+	infrav1.SchemaReadyCondition(&db, infrav1.CreateSchemasSuccessfulReason, "")
 
 	var searchPath []string
 	for _, schema := range db.Spec.SearchPath {
@@ -226,9 +254,9 @@ func (r *PostgreSQLDatabaseReconciler) reconcile(ctx context.Context, db infrav1
 	return db, nil
 }
 
-func (r *PostgreSQLDatabaseReconciler) finalizeDatabase(ctx context.Context, db infrav1beta1.PostgreSQLDatabase) (infrav1beta1.PostgreSQLDatabase, error) {
-	if stringutils.ContainsString(db.Finalizers, infrav1beta1.Finalizer) {
-		db.Finalizers = stringutils.RemoveString(db.Finalizers, infrav1beta1.Finalizer)
+func (r *PostgreSQLDatabaseReconciler) finalizeDatabase(ctx context.Context, db infrav1.PostgreSQLDatabase) (infrav1.PostgreSQLDatabase, error) {
+	if stringutils.ContainsString(db.Finalizers, infrav1.Finalizer) {
+		db.Finalizers = stringutils.RemoveString(db.Finalizers, infrav1.Finalizer)
 		if err := r.Update(ctx, &db); err != nil {
 			return db, err
 		}
@@ -237,9 +265,9 @@ func (r *PostgreSQLDatabaseReconciler) finalizeDatabase(ctx context.Context, db
 	return db, nil
 }
 
-func (r *PostgreSQLDatabaseReconciler) patchStatus(ctx context.Context, database *infrav1beta1.PostgreSQLDatabase) error {
+func (r *PostgreSQLDatabaseReconciler) patchStatus(ctx context.Context, database *infrav1.PostgreSQLDatabase) error {
 	key := client.ObjectKeyFromObject(database)
-	latest := &infrav1beta1.PostgreSQLDatabase{}
+	latest := &infrav1.PostgreSQLDatabase{}
 	if err := r.Get(ctx, key, latest); err != nil {
 		return err
 	}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"time"
 
@@ -38,9 +39,12 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
-	infrav1beta1 "github.com/doodlescheduling/db-controller/api/v1beta1"
+	infrav1 "github.com/doodlescheduling/db-controller/api/v1"
+	"github.com/doodlescheduling/db-controller/internal/audit"
 	"github.com/doodlescheduling/db-controller/internal/database"
+	"github.com/doodlescheduling/db-controller/internal/metrics"
 	"github.com/doodlescheduling/db-controller/internal/stringutils"
+	"github.com/doodlescheduling/db-controller/internal/tracing"
 )
 
 // +kubebuilder:rbac:groups=dbprovisioning.infra.doodle.com,resources=postgresqlusers,verbs=get;list;watch;create;update;patch;delete
@@ -54,13 +58,17 @@ type PostgreSQLUserReconciler struct {
 	Log      logr.Logger
 	Scheme   *runtime.Scheme
 	Recorder events.EventRecorder
+	// Provisioners is the registry to connect to the servers, the default registry is used if not set
+	Provisioners *database.Registry
+
+	triggers audit.Triggers
 }
 
 func (r *PostgreSQLUserReconciler) SetupWithManager(mgr ctrl.Manager, maxConcurrentReconciles int) error {
 	// Index the PostgreSQLUser by the Credentials references they point at
-	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &infrav1beta1.PostgreSQLUser{}, credentialsIndexKey,
+	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &infrav1.PostgreSQLUser{}, credentialsIndexKey,
 		func(o client.Object) []string {
-			usr := o.(*infrav1beta1.PostgreSQLUser)
+			usr := o.(*infrav1.PostgreSQLUser)
 			return []string{
 				fmt.Sprintf("%s/%s", usr.GetNamespace(), usr.Spec.Credentials.Name),
 			}
@@ -70,9 +78,9 @@ func (r *PostgreSQLUserReconciler) SetupWithManager(mgr ctrl.Manager, maxConcurr
 	}
 
 	// Index the PostgreSQLUser by the Database references they point at
-	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &infrav1beta1.PostgreSQLUser{}, dbIndexKey,
+	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &infrav1.PostgreSQLUser{}, dbIndexKey,
 		func(o client.Object) []string {
-			usr := o.(*infrav1beta1.PostgreSQLUser)
+			usr := o.(*infrav1.PostgreSQLUser)
 			return []string{
 				fmt.Sprintf("%s/%s", usr.GetNamespace(), usr.Spec.Database.Name),
 			}
@@ -82,7 +90,7 @@ func (r *PostgreSQLUserReconciler) SetupWithManager(mgr ctrl.Manager, maxConcurr
 	}
 
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&infrav1beta1.PostgreSQLUser{}, builder.WithPredicates(
+		For(&infrav1.PostgreSQLUser{}, builder.WithPredicates(
 			predicate.GenerationChangedPredicate{},
 		)).
 		Watches(
@@ -90,7 +98,7 @@ func (r *PostgreSQLUserReconciler) SetupWithManager(mgr ctrl.Manager, maxConcurr
 			handler.EnqueueRequestsFromMapFunc(r.requestsForSecretChange),
 		).
 		Watches(
-			&infrav1beta1.PostgreSQLDatabase{},
+			&infrav1.PostgreSQLDatabase{},
 			handler.EnqueueRequestsFromMapFunc(r.requestsForDatabaseChange),
 		).
 		WithOptions(controller.Options{MaxConcurrentReconciles: maxConcurrentReconciles}).
@@ -103,7 +111,7 @@ func (r *PostgreSQLUserReconciler) requestsForSecretChange(ctx context.Context,
 		panic(fmt.Sprintf("expected a Secret, got %T", o))
 	}
 
-	var list infrav1beta1.PostgreSQLUserList
+	var list infrav1.PostgreSQLUserList
 	if err := r.List(ctx, &list, client.MatchingFields{
 		credentialsIndexKey: objectKey(s).String(),
 	}); err != nil {
@@ -113,6 +121,7 @@ func (r *PostgreSQLUserReconciler) requestsForSecretChange(ctx context.Context,
 	var reqs []reconcile.Request
 	for _, i := range list.Items {
 		r.Log.Info("referenced secret from a postgresqluser change detected", "namespace", i.GetNamespace(), "name", i.GetName())
+		r.triggers.SecretChanged(objectKey(&i), objectKey(s))
 		reqs = append(reqs, reconcile.Request{NamespacedName: objectKey(&i)})
 	}
 
@@ -120,12 +129,12 @@ func (r *PostgreSQLUserReconciler) requestsForSecretChange(ctx context.Context,
 }
 
 func (r *PostgreSQLUserReconciler) requestsForDatabaseChange(ctx context.Context, o client.Object) []reconcile.Request {
-	s, ok := o.(*infrav1beta1.PostgreSQLDatabase)
+	s, ok := o.(*infrav1.PostgreSQLDatabase)
 	if !ok {
 		panic(fmt.Sprintf("expected a PostgreSQLDatabase, got %T", o))
 	}
 
-	var list infrav1beta1.PostgreSQLUserList
+	var list infrav1.PostgreSQLUserList
 	if err := r.List(ctx, &list, client.MatchingFields{
 		dbIndexKey: objectKey(s).String(),
 	}); err != nil {
@@ -142,21 +151,27 @@ func (r *PostgreSQLUserReconciler) requestsForDatabaseChange(ctx context.Context
 }
 
 func (r *PostgreSQLUserReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx, span := tracing.Start(ctx, "PostgreSQLUser.Reconcile", tracing.Object("PostgreSQLUser", req.NamespacedName)...)
+	defer span.End()
+
 	logger := r.Log.WithValues("PostgreSQLUser", req.NamespacedName)
 	logger.Info("reconciling PostgreSQLUser")
 
-	var user infrav1beta1.PostgreSQLUser
+	var user infrav1.PostgreSQLUser
 	if err := r.Get(ctx, req.NamespacedName, &user); err != nil {
 		if apierrors.IsNotFound(err) {
+			metrics.Users.Forget("PostgreSQLUser", req.NamespacedName)
 			return ctrl.Result{}, nil
 		}
 		return ctrl.Result{}, err
 	}
 
+	ctx = audit.WithSource(ctx, r.triggers.Source("PostgreSQLUser", &user, user.Status.ObservedGeneration))
+
 	// examine DeletionTimestamp to determine if object is under deletion
 	if user.DeletionTimestamp.IsZero() {
-		if !stringutils.ContainsString(user.GetFinalizers(), infrav1beta1.Finalizer) {
-			controllerutil.AddFinalizer(&user, infrav1beta1.Finalizer)
+		if !stringutils.ContainsString(user.GetFinalizers(), infrav1.Finalizer) {
+			controllerutil.AddFinalizer(&user, infrav1.Finalizer)
 			if err := r.Update(ctx, &user); err != nil {
 				return ctrl.Result{}, err
 			}
@@ -164,6 +179,7 @@ func (r *PostgreSQLUserReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 	}
 
 	user, res, reconcileErr := r.reconcile(ctx, user)
+	tracing.RecordError(span, reconcileErr)
 	user.Status.ObservedGeneration = user.GetGeneration()
 
 	if reconcileErr != nil {
@@ -171,12 +187,15 @@ func (r *PostgreSQLUserReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 	} else if !isUserExpired(user.Status.Conditions) {
 		msg := "User successfully provisioned"
 		r.Recorder.Eventf(&user, nil, "Normal", "info", "Reconcile", "%s", msg)
-		infrav1beta1.UserReadyCondition(&user, infrav1beta1.UserProvisioningSuccessfulReason, msg)
+		infrav1.UserReadyCondition(&user, infrav1.UserProvisioningSuccessfulReason, msg)
 	} else {
 		msg := "User has expired and was disabled"
 		r.Recorder.Eventf(&user, nil, "Normal", "info", "Reconcile", "%s", msg)
 	}
 
+	recordReconcile("PostgreSQLUser", user.Status.Conditions, infrav1.UserReadyConditionType)
+	recordUser("PostgreSQLUser", &user, user.Spec.ValidUntil, user.Status.Conditions, reconcileErr)
+
 	// Update status after reconciliation.
 	if err := r.patchStatus(ctx, &user); err != nil {
 		logger.Error(err, "unable to update status after reconciliation")
@@ -186,11 +205,11 @@ func (r *PostgreSQLUserReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 	return res, reconcileErr
 }
 
-func (r *PostgreSQLUserReconciler) reconcile(ctx context.Context, user infrav1beta1.PostgreSQLUser) (infrav1beta1.PostgreSQLUser, ctrl.Result, error) {
+func (r *PostgreSQLUserReconciler) reconcile(ctx context.Context, user infrav1.PostgreSQLUser) (infrav1.PostgreSQLUser, ctrl.Result, error) {
 	res := ctrl.Result{}
 
 	// Fetch referencing database
-	var db infrav1beta1.PostgreSQLDatabase
+	var db infrav1.PostgreSQLDatabase
 	databaseName := types.NamespacedName{
 		Namespace: user.GetNamespace(),
 		Name:      user.GetDatabase(),
@@ -199,7 +218,7 @@ func (r *PostgreSQLUserReconciler) reconcile(ctx context.Context, user infrav1be
 	err := r.Get(ctx, databaseName, &db)
 	if err != nil {
 		err = fmt.Errorf("referencing database was not found: %w", err)
-		infrav1beta1.UserNotReadyCondition(&user, infrav1beta1.DatabaseNotFoundReason, err.Error())
+		infrav1.UserNotReadyCondition(&user, infrav1.DatabaseNotFoundReason, err.Error())
 		return user, res, err
 	}
 
@@ -213,7 +232,7 @@ func (r *PostgreSQLUserReconciler) reconcile(ctx context.Context, user infrav1be
 	usr, pw, _, err := getSecret(ctx, r.Client, user.GetCredentials())
 
 	if err != nil {
-		infrav1beta1.UserNotReadyCondition(&user, infrav1beta1.CredentialsNotFoundReason, err.Error())
+		infrav1.UserNotReadyCondition(&user, infrav1.CredentialsNotFoundReason, err.Error())
 		return user, res, err
 	}
 	user.Status.Username = usr
@@ -221,21 +240,21 @@ func (r *PostgreSQLUserReconciler) reconcile(ctx context.Context, user infrav1be
 	rootUsr, rootPw, addr, err := getSecret(ctx, r.Client, db.GetRootSecret())
 
 	if err != nil {
-		infrav1beta1.UserNotReadyCondition(&user, infrav1beta1.CredentialsNotFoundReason, err.Error())
+		infrav1.UserNotReadyCondition(&user, infrav1.CredentialsNotFoundReason, err.Error())
 		return user, res, err
 	}
 
-	dbHandler, err := setupPostgreSQL(ctx, db, rootUsr, rootPw, addr, true)
+	dbHandler, err := setupPostgreSQL[database.UserProvisioner](ctx, r.Provisioners, db, rootUsr, rootPw, addr, true)
 
 	if err != nil {
-		infrav1beta1.UserNotReadyCondition(&user, infrav1beta1.ConnectionFailedReason, err.Error())
+		infrav1.UserNotReadyCondition(&user, infrav1.ConnectionFailedReason, err.Error())
 		return user, res, err
 	}
 
 	defer func() { _ = dbHandler.Close(ctx) }()
 
 	if !user.DeletionTimestamp.IsZero() {
-		user, err := r.finalizeUser(ctx, user, db, dbHandler)
+		err := finalizeUser(ctx, r.Client, r.Recorder, &user, dbHandler, r.statusUser(user, db), user.Spec.DeletionPolicy == infrav1.DeletionPolicyDrop)
 		return user, res, err
 	}
 
@@ -244,16 +263,15 @@ func (r *PostgreSQLUserReconciler) reconcile(ctx context.Context, user infrav1be
 		now := time.Now().UTC()
 
 		if !validUntil.After(now) {
-			user, err := r.disableUser(ctx, user, db, dbHandler)
-			if err != nil {
+			if err := expireUser(ctx, r.Recorder, &user, dbHandler, r.statusUser(user, db)); err != nil {
 				return user, res, err
 			}
-			infrav1beta1.UserNotReadyCondition(
+			infrav1.UserNotReadyCondition(
 				&user,
-				infrav1beta1.UserExpiredReason,
+				infrav1.UserExpiredReason,
 				"User has expired and was disabled",
 			)
-			return user, res, err
+			return user, res, nil
 		}
 
 		res.RequeueAfter = validUntil.Sub(now)
@@ -274,19 +292,35 @@ func (r *PostgreSQLUserReconciler) reconcile(ctx context.Context, user infrav1be
 		})
 	}
 
-	userSpec := database.PostgresqlUser{
-		Database:   db.GetDatabaseName(),
-		Username:   usr,
-		Password:   pw,
-		Roles:      user.Spec.Roles,
-		Grants:     grants,
-		Attributes: user.Spec.Attributes,
+	userSpec := database.User{
+		Database: db.GetDatabaseName(),
+		Username: usr,
+		Password: pw,
+		Options: database.PostgreSQLUserOptions{
+			Grants:     grants,
+			Attributes: user.Spec.Attributes,
+		},
+	}
+
+	for _, role := range user.Spec.Roles {
+		userSpec.Roles = append(userSpec.Roles, database.Role{Name: role})
+	}
+
+	if user.Spec.ValidUntil != nil {
+		validUntil := user.Spec.ValidUntil.UTC()
+		userSpec.ValidUntil = &validUntil
 	}
 
 	err = dbHandler.SetupUser(ctx, userSpec)
+	if errors.Is(err, database.ErrUnsupportedByFlavor) {
+		err = fmt.Errorf("failed to provision user account on flavor %s: %w", db.GetFlavor(), err)
+		infrav1.UserNotReadyCondition(&user, infrav1.UnsupportedByFlavorReason, err.Error())
+		return user, res, err
+	}
+
 	if err != nil {
 		err = fmt.Errorf("failed to provision user account: %w", err)
-		infrav1beta1.UserNotReadyCondition(&user, infrav1beta1.ConnectionFailedReason, err.Error())
+		infrav1.UserNotReadyCondition(&user, infrav1.ConnectionFailedReason, err.Error())
 		return user, res, err
 	}
 
@@ -301,58 +335,29 @@ func generateToken(length int) string {
 	return hex.EncodeToString(b)
 }
 
-func (r *PostgreSQLUserReconciler) finalizeUser(ctx context.Context, user infrav1beta1.PostgreSQLUser, db infrav1beta1.PostgreSQLDatabase, dbHandler *database.PostgreSQLRepository) (infrav1beta1.PostgreSQLUser, error) {
-
-	user, err := r.disableUser(ctx, user, db, dbHandler)
-	if err != nil {
-		return user, err
-	}
-
-	if stringutils.ContainsString(user.Finalizers, infrav1beta1.Finalizer) {
-		user.Finalizers = stringutils.RemoveString(user.Finalizers, infrav1beta1.Finalizer)
-		if err := r.Update(ctx, &user); err != nil {
-			return user, err
-		}
-	}
-
-	return user, nil
-}
-
-func (r *PostgreSQLUserReconciler) disableUser(ctx context.Context, user infrav1beta1.PostgreSQLUser, db infrav1beta1.PostgreSQLDatabase, dbHandler *database.PostgreSQLRepository) (infrav1beta1.PostgreSQLUser, error) {
-	if user.Status.Username == "" {
-		return user, nil
-	}
-
-	//We can't easily drop a user from postgres since it owns objects
-	//Instead privileges are revoked and the password gets randomized
-
-	userSpec := database.PostgresqlUser{
+// statusUser returns the account which was provisioned for the user.
+// The objects owned by the account are reassigned once it gets dropped,
+// it can't be dropped otherwise.
+func (r *PostgreSQLUserReconciler) statusUser(user infrav1.PostgreSQLUser, db infrav1.PostgreSQLDatabase) database.User {
+	userSpec := database.User{
 		Database: db.GetDatabaseName(),
 		Username: user.Status.Username,
-		Password: generateToken(32),
-	}
-
-	err := dbHandler.SetupUser(ctx, userSpec)
-	if err != nil {
-		err = fmt.Errorf("failed to update user account: %w", err)
-		infrav1beta1.UserNotReadyCondition(&user, infrav1beta1.ConnectionFailedReason, err.Error())
-		return user, err
+		Options: database.PostgreSQLUserOptions{
+			ReassignOwnedTo: user.Spec.ReassignOwnedTo,
+		},
 	}
 
-	err = dbHandler.RevokeAllPrivileges(ctx, userSpec)
-
-	if err != nil {
-		err = fmt.Errorf("failed to revoke privileges from user account: %w", err)
-		infrav1beta1.UserNotReadyCondition(&user, infrav1beta1.ConnectionFailedReason, err.Error())
-		return user, err
+	if user.Spec.ValidUntil != nil {
+		validUntil := user.Spec.ValidUntil.UTC()
+		userSpec.ValidUntil = &validUntil
 	}
 
-	return user, nil
+	return userSpec
 }
 
-func (r *PostgreSQLUserReconciler) patchStatus(ctx context.Context, database *infrav1beta1.PostgreSQLUser) error {
+func (r *PostgreSQLUserReconciler) patchStatus(ctx context.Context, database *infrav1.PostgreSQLUser) error {
 	key := client.ObjectKeyFromObject(database)
-	latest := &infrav1beta1.PostgreSQLUser{}
+	latest := &infrav1.PostgreSQLUser{}
 	if err := r.Get(ctx, key, latest); err != nil {
 		return err
 	}
@@ -0,0 +1,570 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	rabbithole "github.com/michaelklishin/rabbit-hole/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	infrav1 "github.com/doodlescheduling/db-controller/api/v1"
+)
+
+const (
+	rabbitmqRootUsername = "admin"
+	rabbitmqRootPassword = "password"
+)
+
+type rabbitmqContainer struct {
+	testcontainers.Container
+	URI string
+}
+
+func setupRabbitMQContainer(ctx context.Context, image string) (*rabbitmqContainer, error) {
+	req := testcontainers.ContainerRequest{
+		Image:        image,
+		ExposedPorts: []string{"15672/tcp"},
+		WaitingFor:   wait.ForHTTP("/api/overview").WithPort("15672/tcp").WithBasicAuth(rabbitmqRootUsername, rabbitmqRootPassword).WithStartupTimeout(120 * time.Second),
+		Env: map[string]string{
+			"RABBITMQ_DEFAULT_USER": rabbitmqRootUsername,
+			"RABBITMQ_DEFAULT_PASS": rabbitmqRootPassword,
+		},
+	}
+
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	host, err := container.Host(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	port, err := container.MappedPort(ctx, "15672/tcp")
+	if err != nil {
+		return nil, err
+	}
+
+	return &rabbitmqContainer{Container: container, URI: fmt.Sprintf("http://%s:%s", host, port.Port())}, nil
+}
+
+var _ = Describe("RabbitMQ", func() {
+	const (
+		timeout  = time.Second * 5
+		interval = time.Second * 1
+	)
+
+	for _, image := range []string{"rabbitmq:3-management", "rabbitmq:4-management"} {
+		var _ = Describe(image, func() {
+			var (
+				container *rabbitmqContainer
+				err       error
+			)
+
+			container, err = setupRabbitMQContainer(context.Background(), image)
+			Expect(err).NotTo(HaveOccurred(), "failed to start rabbitmq container")
+
+			rootClient, err := rabbithole.NewClient(container.URI, rabbitmqRootUsername, rabbitmqRootPassword)
+			Expect(err).NotTo(HaveOccurred(), "failed to setup rabbitmq management client")
+
+			createRootSecret := func(namespace string) *infrav1.SecretReference {
+				secret := &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "rabbitmq-root-" + randStringRunes(5),
+						Namespace: namespace,
+					},
+					Data: map[string][]byte{
+						"username": []byte(rabbitmqRootUsername),
+						"password": []byte(rabbitmqRootPassword),
+					},
+				}
+
+				Expect(k8sClient.Create(context.Background(), secret)).Should(Succeed())
+				return &infrav1.SecretReference{
+					Name: secret.Name,
+				}
+			}
+
+			createVhost := func(namespace string, rootSecret *infrav1.SecretReference) types.NamespacedName {
+				key := types.NamespacedName{
+					Name:      "rabbitmqvhost-" + randStringRunes(5),
+					Namespace: namespace,
+				}
+
+				vhost := &infrav1.RabbitMQVhost{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      key.Name,
+						Namespace: key.Namespace,
+					},
+					Spec: infrav1.RabbitMQVhostSpec{
+						DatabaseSpec: infrav1.DatabaseSpec{
+							Timeout: &metav1.Duration{
+								Duration: time.Second * 5,
+							},
+							Address:    container.URI,
+							RootSecret: rootSecret,
+						},
+					},
+				}
+
+				Expect(k8sClient.Create(context.Background(), vhost)).Should(Succeed())
+
+				got := &infrav1.RabbitMQVhost{}
+				Eventually(func() bool {
+					_ = k8sClient.Get(context.Background(), key, got)
+					return len(got.Status.Conditions) == 1 &&
+						got.Status.Conditions[0].Reason == infrav1.DatabaseProvisioningSuccessfulReason &&
+						got.Status.Conditions[0].Status == "True"
+				}, timeout, interval).Should(BeTrue())
+
+				return key
+			}
+
+			createSecret := func(namespace, username, password string) types.NamespacedName {
+				key := types.NamespacedName{
+					Name:      "secret-" + randStringRunes(5),
+					Namespace: namespace,
+				}
+
+				Expect(k8sClient.Create(context.Background(), &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      key.Name,
+						Namespace: key.Namespace,
+					},
+					Data: map[string][]byte{
+						"username": []byte(username),
+						"password": []byte(password),
+					},
+				})).Should(Succeed())
+
+				return key
+			}
+
+			createUser := func(namespace, vhost, secret string, policy infrav1.DeletionPolicy) *infrav1.RabbitMQUser {
+				user := &infrav1.RabbitMQUser{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "rabbitmquser-" + randStringRunes(5),
+						Namespace: namespace,
+					},
+					Spec: infrav1.RabbitMQUserSpec{
+						Vhost: &infrav1.DatabaseReference{
+							Name: vhost,
+						},
+						Credentials: &infrav1.SecretReference{
+							Name: secret,
+						},
+						DeletionPolicy: policy,
+					},
+				}
+
+				Expect(k8sClient.Create(context.Background(), user)).Should(Succeed())
+				return user
+			}
+
+			userReady := func(key types.NamespacedName) func() bool {
+				return func() bool {
+					got := &infrav1.RabbitMQUser{}
+					_ = k8sClient.Get(context.Background(), key, got)
+					return len(got.Status.Conditions) == 1 &&
+						got.Status.Conditions[0].Reason == infrav1.UserProvisioningSuccessfulReason &&
+						got.Status.Conditions[0].Status == "True" &&
+						got.Status.Conditions[0].Type == infrav1.UserReadyConditionType &&
+						got.ObjectMeta.Generation == got.Status.ObservedGeneration
+				}
+			}
+
+			updateUser := func(key types.NamespacedName, mutate func(user *infrav1.RabbitMQUser)) {
+				Eventually(func() error {
+					user := &infrav1.RabbitMQUser{}
+					if err := k8sClient.Get(context.Background(), key, user); err != nil {
+						return err
+					}
+
+					mutate(user)
+					return k8sClient.Update(context.Background(), user)
+				}, timeout, interval).Should(Succeed())
+			}
+
+			userGone := func(key types.NamespacedName) {
+				Eventually(func() error {
+					return k8sClient.Get(context.Background(), key, &infrav1.RabbitMQUser{})
+				}, timeout, interval).ShouldNot(Succeed())
+			}
+
+			// whoami authenticates against the management api, the user needs the management tag
+			whoami := func(username, password string) error {
+				client, err := rabbithole.NewClient(container.URI, username, password)
+				if err != nil {
+					return err
+				}
+
+				_, err = client.Whoami()
+				return err
+			}
+
+			permissionsIn := func(vhost, username string) (rabbithole.PermissionInfo, error) {
+				return rootClient.GetPermissionsIn(vhost, username)
+			}
+
+			Describe("fails if management api can't be reached", Ordered, func() {
+				var keyVhost types.NamespacedName
+
+				namespace, rootSecret := setupNamespace()
+
+				It("adds vhost", func() {
+					keyVhost = types.NamespacedName{
+						Name:      "rabbitmqvhost-" + randStringRunes(5),
+						Namespace: namespace.Name,
+					}
+					createdVhost := &infrav1.RabbitMQVhost{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      keyVhost.Name,
+							Namespace: keyVhost.Namespace,
+						},
+						Spec: infrav1.RabbitMQVhostSpec{
+							DatabaseSpec: infrav1.DatabaseSpec{
+								Timeout: &metav1.Duration{
+									Duration: time.Millisecond * 100,
+								},
+								Address: "http://does-not-exist:15672",
+								RootSecret: &infrav1.SecretReference{
+									Name: rootSecret.Name,
+								},
+							},
+						},
+					}
+					Expect(k8sClient.Create(context.Background(), createdVhost)).Should(Succeed())
+				})
+
+				It("fails reconcile because management api can't be reached", func() {
+					got := &infrav1.RabbitMQVhost{}
+					Eventually(func() bool {
+						_ = k8sClient.Get(context.Background(), keyVhost, got)
+						return len(got.Status.Conditions) == 1 &&
+							got.Status.Conditions[0].Reason == infrav1.ConnectionFailedReason &&
+							got.Status.Conditions[0].Status == "False" &&
+							got.Status.Conditions[0].Type == infrav1.DatabaseReadyConditionType
+					}, timeout, interval).Should(BeTrue())
+				})
+			})
+
+			Describe("Successful user creation", Ordered, func() {
+				var (
+					createdSecret *corev1.Secret
+					keyUser       types.NamespacedName
+					keyVhost      types.NamespacedName
+					keySecret     types.NamespacedName
+					password      string
+				)
+
+				namespace, _ := setupNamespace()
+
+				Describe("creates vhost with description and tags", Ordered, func() {
+					It("adds vhost", func() {
+						keyVhost = types.NamespacedName{
+							Name:      "rabbitmqvhost-" + randStringRunes(5),
+							Namespace: namespace.Name,
+						}
+						createdVhost := &infrav1.RabbitMQVhost{
+							ObjectMeta: metav1.ObjectMeta{
+								Name:      keyVhost.Name,
+								Namespace: keyVhost.Namespace,
+							},
+							Spec: infrav1.RabbitMQVhostSpec{
+								DatabaseSpec: infrav1.DatabaseSpec{
+									Timeout: &metav1.Duration{
+										Duration: time.Second * 5,
+									},
+									Address:    container.URI,
+									RootSecret: createRootSecret(namespace.Name),
+								},
+								Description: "db-controller",
+								Tags:        []string{"test"},
+							},
+						}
+
+						Expect(k8sClient.Create(context.Background(), createdVhost)).Should(Succeed())
+					})
+
+					It("expects ready vhost", func() {
+						got := &infrav1.RabbitMQVhost{}
+						Eventually(func() bool {
+							_ = k8sClient.Get(context.Background(), keyVhost, got)
+							return len(got.Status.Conditions) == 1 &&
+								got.Status.Conditions[0].Reason == infrav1.DatabaseProvisioningSuccessfulReason &&
+								got.Status.Conditions[0].Status == "True" &&
+								got.Status.Conditions[0].Type == infrav1.DatabaseReadyConditionType
+						}, timeout, interval).Should(BeTrue())
+					})
+
+					It("created the vhost with description and tags", func() {
+						vhost, err := rootClient.GetVhost(keyVhost.Name)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(vhost.Description).To(Equal("db-controller"))
+						Expect([]string(vhost.Tags)).To(ConsistOf("test"))
+					})
+				})
+
+				Describe("creates user if it does not exists", Ordered, func() {
+					It("adds secret", func() {
+						keyUser = types.NamespacedName{
+							Name:      "rabbitmquser-" + randStringRunes(5),
+							Namespace: namespace.Name,
+						}
+						keySecret = types.NamespacedName{
+							Name:      "secret-" + randStringRunes(5),
+							Namespace: namespace.Name,
+						}
+						password = randStringRunes(5)
+						createdSecret = &corev1.Secret{
+							ObjectMeta: metav1.ObjectMeta{
+								Name:      keySecret.Name,
+								Namespace: keySecret.Namespace,
+							},
+							Data: map[string][]byte{
+								"username": []byte(keyUser.Name),
+								"password": []byte(password),
+							},
+						}
+						Expect(k8sClient.Create(context.Background(), createdSecret)).Should(Succeed())
+					})
+
+					It("adds user", func() {
+						createdUser := &infrav1.RabbitMQUser{
+							ObjectMeta: metav1.ObjectMeta{
+								Name:      keyUser.Name,
+								Namespace: keyUser.Namespace,
+							},
+							Spec: infrav1.RabbitMQUserSpec{
+								Vhost: &infrav1.DatabaseReference{
+									Name: keyVhost.Name,
+								},
+								Credentials: &infrav1.SecretReference{
+									Name: keySecret.Name,
+								},
+								Tags: []string{"management"},
+								Permissions: &infrav1.RabbitMQPermissions{
+									Configure: "^app\\.",
+									Write:     ".*",
+									Read:      ".*",
+								},
+								TopicPermissions: []infrav1.RabbitMQTopicPermission{
+									{
+										Exchange: "amq.topic",
+										Write:    "^app\\.",
+										Read:     ".*",
+									},
+								},
+								DeletionPolicy: infrav1.DeletionPolicyDrop,
+							},
+						}
+						Expect(k8sClient.Create(context.Background(), createdUser)).Should(Succeed())
+					})
+
+					It("expects ready user", func() {
+						Eventually(userReady(keyUser), timeout, interval).Should(BeTrue())
+					})
+
+					It("created the user with tags and permissions", func() {
+						user, err := rootClient.GetUser(keyUser.Name)
+						Expect(err).NotTo(HaveOccurred())
+						Expect([]string(user.Tags)).To(ConsistOf("management"))
+
+						permissions, err := permissionsIn(keyVhost.Name, keyUser.Name)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(permissions.Configure).To(Equal("^app\\."))
+						Expect(permissions.Write).To(Equal(".*"))
+						Expect(permissions.Read).To(Equal(".*"))
+					})
+
+					It("created the topic permissions", func() {
+						permissions, err := rootClient.GetTopicPermissionsIn(keyVhost.Name, keyUser.Name)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(permissions).To(HaveLen(1))
+						Expect(permissions[0].Exchange).To(Equal("amq.topic"))
+						Expect(permissions[0].Write).To(Equal("^app\\."))
+					})
+
+					It("can authenticate with the credentials", func() {
+						Expect(whoami(keyUser.Name, password)).To(Succeed())
+					})
+
+					It("can't authenticate with invalid credentials", func() {
+						Expect(whoami(keyUser.Name, "invalid-password")).NotTo(Succeed())
+					})
+				})
+
+				Describe("Change password for user", Ordered, func() {
+					It("changes password in referenced user secret", func() {
+						password = randStringRunes(5)
+						createdSecret.Data = map[string][]byte{
+							"username": []byte(keyUser.Name),
+							"password": []byte(password),
+						}
+						Expect(k8sClient.Update(context.Background(), createdSecret)).Should(Succeed())
+					})
+
+					It("can authenticate with the new password", func() {
+						Eventually(func() error {
+							return whoami(keyUser.Name, password)
+						}, timeout, interval).Should(Succeed())
+					})
+				})
+
+				Describe("Topic permissions", Ordered, func() {
+					It("removes the topic permissions", func() {
+						updateUser(keyUser, func(user *infrav1.RabbitMQUser) {
+							user.Spec.TopicPermissions = nil
+						})
+
+						Eventually(userReady(keyUser), timeout, interval).Should(BeTrue())
+					})
+
+					It("has no topic permissions", func() {
+						Eventually(func() int {
+							permissions, _ := rootClient.GetTopicPermissionsIn(keyVhost.Name, keyUser.Name)
+							return len(permissions)
+						}, timeout, interval).Should(Equal(0))
+					})
+				})
+
+				Describe("ValidUntil", Ordered, func() {
+					It("sets validUntil in the past for the user", func() {
+						updateUser(keyUser, func(user *infrav1.RabbitMQUser) {
+							validUntil := metav1.NewTime(time.Now().Add(-1 * time.Hour).UTC())
+							user.Spec.ValidUntil = &validUntil
+						})
+					})
+
+					It("sets expired status after validUntil expires", func() {
+						got := &infrav1.RabbitMQUser{}
+
+						Eventually(func() bool {
+							_ = k8sClient.Get(context.Background(), keyUser, got)
+
+							return len(got.Status.Conditions) == 1 &&
+								got.Status.Conditions[0].Reason == infrav1.UserExpiredReason &&
+								got.Status.Conditions[0].Status == "False" &&
+								got.ObjectMeta.Generation == got.Status.ObservedGeneration
+						}, timeout, interval).Should(BeTrue())
+					})
+
+					It("cleared the permissions on the vhost", func() {
+						_, err := permissionsIn(keyVhost.Name, keyUser.Name)
+						Expect(err).To(HaveOccurred())
+					})
+
+					It("clears validUntil for the user", func() {
+						updateUser(keyUser, func(user *infrav1.RabbitMQUser) {
+							user.Spec.ValidUntil = nil
+						})
+
+						Eventually(userReady(keyUser), timeout, interval).Should(BeTrue())
+					})
+
+					It("granted the permissions again", func() {
+						_, err := permissionsIn(keyVhost.Name, keyUser.Name)
+						Expect(err).NotTo(HaveOccurred())
+					})
+				})
+
+				Describe("Delete user", Ordered, func() {
+					It("deletes the user resource", func() {
+						Expect(k8sClient.Delete(context.Background(), &infrav1.RabbitMQUser{
+							ObjectMeta: metav1.ObjectMeta{
+								Name:      keyUser.Name,
+								Namespace: keyUser.Namespace,
+							},
+						})).Should(Succeed())
+
+						userGone(keyUser)
+					})
+
+					It("dropped the user", func() {
+						_, err := rootClient.GetUser(keyUser.Name)
+						Expect(err).To(HaveOccurred())
+					})
+				})
+			})
+
+			Describe("User with permissions on multiple vhosts", Ordered, func() {
+				var (
+					vhostA, vhostB types.NamespacedName
+					userA, userB   *infrav1.RabbitMQUser
+					username       string
+					password       string
+				)
+
+				namespace, _ := setupNamespace()
+
+				It("creates two vhosts and the same user on both", func() {
+					rootSecret := createRootSecret(namespace.Name)
+					vhostA = createVhost(namespace.Name, rootSecret)
+					vhostB = createVhost(namespace.Name, rootSecret)
+
+					username = "rabbitmquser-" + randStringRunes(5)
+					password = randStringRunes(5)
+					secret := createSecret(namespace.Name, username, password)
+
+					userA = createUser(namespace.Name, vhostA.Name, secret.Name, infrav1.DeletionPolicyDrop)
+					userB = createUser(namespace.Name, vhostB.Name, secret.Name, infrav1.DeletionPolicyDisable)
+
+					Eventually(userReady(objectKey(userA)), timeout, interval).Should(BeTrue())
+					Eventually(userReady(objectKey(userB)), timeout, interval).Should(BeTrue())
+				})
+
+				It("keeps the user while it has permissions on another vhost", func() {
+					Expect(k8sClient.Delete(context.Background(), userA)).Should(Succeed())
+					userGone(objectKey(userA))
+
+					_, err := permissionsIn(vhostA.Name, username)
+					Expect(err).To(HaveOccurred())
+
+					_, err = permissionsIn(vhostB.Name, username)
+					Expect(err).NotTo(HaveOccurred())
+
+					_, err = rootClient.GetUser(username)
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				It("disables the user once the last vhost is removed", func() {
+					Expect(k8sClient.Delete(context.Background(), userB)).Should(Succeed())
+					userGone(objectKey(userB))
+
+					_, err := permissionsIn(vhostB.Name, username)
+					Expect(err).To(HaveOccurred())
+
+					_, err = rootClient.GetUser(username)
+					Expect(err).NotTo(HaveOccurred())
+				})
+			})
+		})
+	}
+})
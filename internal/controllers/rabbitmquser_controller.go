@@ -0,0 +1,328 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	infrav1 "github.com/doodlescheduling/db-controller/api/v1"
+	"github.com/doodlescheduling/db-controller/internal/database"
+	"github.com/doodlescheduling/db-controller/internal/metrics"
+	"github.com/doodlescheduling/db-controller/internal/stringutils"
+	"github.com/doodlescheduling/db-controller/internal/tracing"
+)
+
+// +kubebuilder:rbac:groups=dbprovisioning.infra.doodle.com,resources=rabbitmqusers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=dbprovisioning.infra.doodle.com,resources=rabbitmqusers/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// RabbitMQUserReconciler reconciles a RabbitMQUser object
+type RabbitMQUserReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder events.EventRecorder
+	// Provisioners is the registry to connect to the servers, the default registry is used if not set
+	Provisioners *database.Registry
+}
+
+func (r *RabbitMQUserReconciler) SetupWithManager(mgr ctrl.Manager, maxConcurrentReconciles int) error {
+	// Index the RabbitMQUser by the Credentials references they point at
+	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &infrav1.RabbitMQUser{}, credentialsIndexKey,
+		func(o client.Object) []string {
+			usr := o.(*infrav1.RabbitMQUser)
+			return []string{
+				fmt.Sprintf("%s/%s", usr.GetNamespace(), usr.Spec.Credentials.Name),
+			}
+		},
+	); err != nil {
+		return err
+	}
+
+	// Index the RabbitMQUser by the Vhost references they point at
+	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &infrav1.RabbitMQUser{}, dbIndexKey,
+		func(o client.Object) []string {
+			usr := o.(*infrav1.RabbitMQUser)
+			return []string{
+				fmt.Sprintf("%s/%s", usr.GetNamespace(), usr.Spec.Vhost.Name),
+			}
+		},
+	); err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&infrav1.RabbitMQUser{}, builder.WithPredicates(
+			predicate.GenerationChangedPredicate{},
+		)).
+		Watches(
+			&corev1.Secret{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForSecretChange),
+		).
+		Watches(
+			&infrav1.RabbitMQVhost{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForVhostChange),
+		).
+		WithOptions(controller.Options{MaxConcurrentReconciles: maxConcurrentReconciles}).
+		Complete(r)
+}
+
+func (r *RabbitMQUserReconciler) requestsForSecretChange(ctx context.Context, o client.Object) []reconcile.Request {
+	s, ok := o.(*corev1.Secret)
+	if !ok {
+		panic(fmt.Sprintf("expected a Secret, got %T", o))
+	}
+
+	var list infrav1.RabbitMQUserList
+	if err := r.List(ctx, &list, client.MatchingFields{
+		credentialsIndexKey: objectKey(s).String(),
+	}); err != nil {
+		return nil
+	}
+
+	var reqs []reconcile.Request
+	for _, i := range list.Items {
+		r.Log.Info("referenced secret from a rabbitmquser change detected", "namespace", i.GetNamespace(), "name", i.GetName())
+		reqs = append(reqs, reconcile.Request{NamespacedName: objectKey(&i)})
+	}
+
+	return reqs
+}
+
+func (r *RabbitMQUserReconciler) requestsForVhostChange(ctx context.Context, o client.Object) []reconcile.Request {
+	s, ok := o.(*infrav1.RabbitMQVhost)
+	if !ok {
+		panic(fmt.Sprintf("expected a RabbitMQVhost, got %T", o))
+	}
+
+	var list infrav1.RabbitMQUserList
+	if err := r.List(ctx, &list, client.MatchingFields{
+		dbIndexKey: objectKey(s).String(),
+	}); err != nil {
+		return nil
+	}
+
+	var reqs []reconcile.Request
+	for _, i := range list.Items {
+		r.Log.Info("referenced vhost from a rabbitmquser change detected, reconcile", "namespace", i.GetNamespace(), "name", i.GetName())
+		reqs = append(reqs, reconcile.Request{NamespacedName: objectKey(&i)})
+	}
+
+	return reqs
+}
+
+func (r *RabbitMQUserReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx, span := tracing.Start(ctx, "RabbitMQUser.Reconcile", tracing.Object("RabbitMQUser", req.NamespacedName)...)
+	defer span.End()
+
+	logger := r.Log.WithValues("RabbitMQUser", req.NamespacedName)
+	logger.Info("reconciling RabbitMQUser")
+
+	var user infrav1.RabbitMQUser
+	if err := r.Get(ctx, req.NamespacedName, &user); err != nil {
+		if apierrors.IsNotFound(err) {
+			metrics.Users.Forget("RabbitMQUser", req.NamespacedName)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// examine DeletionTimestamp to determine if object is under deletion
+	if user.DeletionTimestamp.IsZero() {
+		if !stringutils.ContainsString(user.GetFinalizers(), infrav1.Finalizer) {
+			controllerutil.AddFinalizer(&user, infrav1.Finalizer)
+			if err := r.Update(ctx, &user); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
+	user, res, reconcileErr := r.reconcile(ctx, user)
+	tracing.RecordError(span, reconcileErr)
+	user.Status.ObservedGeneration = user.GetGeneration()
+
+	if reconcileErr != nil {
+		r.Recorder.Eventf(&user, nil, "Normal", "error", "Reconcile", "%s", reconcileErr.Error())
+	} else if !isUserExpired(user.Status.Conditions) {
+		msg := "User successfully provisioned"
+		r.Recorder.Eventf(&user, nil, "Normal", "info", "Reconcile", "%s", msg)
+		infrav1.UserReadyCondition(&user, infrav1.UserProvisioningSuccessfulReason, msg)
+	} else {
+		msg := "User has expired and was disabled"
+		r.Recorder.Eventf(&user, nil, "Normal", "info", "Reconcile", "%s", msg)
+	}
+
+	recordReconcile("RabbitMQUser", user.Status.Conditions, infrav1.UserReadyConditionType)
+	recordUser("RabbitMQUser", &user, user.Spec.ValidUntil, user.Status.Conditions, reconcileErr)
+
+	// Update status after reconciliation.
+	if err := r.patchStatus(ctx, &user); err != nil {
+		logger.Error(err, "unable to update status after reconciliation")
+		return res, err
+	}
+
+	return res, reconcileErr
+}
+
+func (r *RabbitMQUserReconciler) reconcile(ctx context.Context, user infrav1.RabbitMQUser) (infrav1.RabbitMQUser, ctrl.Result, error) {
+	res := ctrl.Result{}
+
+	// Fetch referencing vhost
+	var db infrav1.RabbitMQVhost
+	vhostName := types.NamespacedName{
+		Namespace: user.GetNamespace(),
+		Name:      user.GetVhost(),
+	}
+
+	err := r.Get(ctx, vhostName, &db)
+	if err != nil {
+		err = fmt.Errorf("referencing vhost was not found: %w", err)
+		infrav1.UserNotReadyCondition(&user, infrav1.DatabaseNotFoundReason, err.Error())
+		return user, res, err
+	}
+
+	if db.Spec.Timeout != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, db.Spec.Timeout.Duration)
+		defer cancel()
+	}
+
+	// Fetch referencing secret
+	usr, pw, _, err := getSecret(ctx, r.Client, user.GetCredentials())
+
+	if err != nil {
+		infrav1.UserNotReadyCondition(&user, infrav1.CredentialsNotFoundReason, err.Error())
+		return user, res, err
+	}
+
+	// Fetch referencing root secret
+	rootUsr, rootPw, addr, err := getSecret(ctx, r.Client, db.GetRootSecret())
+
+	if err != nil {
+		infrav1.UserNotReadyCondition(&user, infrav1.CredentialsNotFoundReason, err.Error())
+		return user, res, err
+	}
+
+	dbHandler, err := setupRabbitMQ[database.UserProvisioner](ctx, r.Provisioners, db, rootUsr, rootPw, addr)
+
+	if err != nil {
+		infrav1.UserNotReadyCondition(&user, infrav1.ConnectionFailedReason, err.Error())
+		return user, res, err
+	}
+
+	defer func() { _ = dbHandler.Close(ctx) }()
+
+	if !user.DeletionTimestamp.IsZero() {
+		err := finalizeUser(ctx, r.Client, r.Recorder, &user, dbHandler, r.statusUser(user, db), user.Spec.DeletionPolicy == infrav1.DeletionPolicyDrop)
+		return user, res, err
+	}
+
+	// The username changed, drop the previous user instead of leaving it behind
+	if user.Status.Username != "" && user.Status.Username != usr {
+		if err := dropUser(ctx, r.Recorder, &user, dbHandler, r.statusUser(user, db)); err != nil {
+			return user, res, err
+		}
+	}
+
+	user.Status.Username = usr
+
+	if user.Spec.ValidUntil != nil {
+		validUntil := user.Spec.ValidUntil.UTC()
+		now := time.Now().UTC()
+
+		if !validUntil.After(now) {
+			if err := expireUser(ctx, r.Recorder, &user, dbHandler, r.statusUser(user, db)); err != nil {
+				return user, res, err
+			}
+			infrav1.UserNotReadyCondition(
+				&user,
+				infrav1.UserExpiredReason,
+				"User has expired and was disabled",
+			)
+			return user, res, nil
+		}
+
+		res.RequeueAfter = validUntil.Sub(now)
+	}
+
+	permissions := user.GetPermissions()
+	opts := database.RabbitMQUserOptions{
+		Tags:      user.Spec.Tags,
+		Configure: permissions.Configure,
+		Write:     permissions.Write,
+		Read:      permissions.Read,
+	}
+
+	for _, permission := range user.Spec.TopicPermissions {
+		opts.TopicPermissions = append(opts.TopicPermissions, database.RabbitMQTopicPermission{
+			Exchange: permission.Exchange,
+			Write:    permission.Write,
+			Read:     permission.Read,
+		})
+	}
+
+	err = dbHandler.SetupUser(ctx, database.User{
+		Database: db.GetDatabaseName(),
+		Username: usr,
+		Password: pw,
+		Options:  opts,
+	})
+	if err != nil {
+		err = fmt.Errorf("failed to provision user account: %w", err)
+		infrav1.UserNotReadyCondition(&user, infrav1.ConnectionFailedReason, err.Error())
+		return user, res, err
+	}
+
+	return user, res, nil
+}
+
+// statusUser returns the user which was provisioned, the database of the user is the vhost.
+// The user is only deleted or its password randomized if it has no permissions on another vhost.
+func (r *RabbitMQUserReconciler) statusUser(user infrav1.RabbitMQUser, db infrav1.RabbitMQVhost) database.User {
+	return database.User{
+		Database: db.GetDatabaseName(),
+		Username: user.Status.Username,
+	}
+}
+
+func (r *RabbitMQUserReconciler) patchStatus(ctx context.Context, user *infrav1.RabbitMQUser) error {
+	key := client.ObjectKeyFromObject(user)
+	latest := &infrav1.RabbitMQUser{}
+	if err := r.Get(ctx, key, latest); err != nil {
+		return err
+	}
+
+	return r.Client.Status().Patch(ctx, user, client.MergeFrom(latest))
+}
@@ -0,0 +1,423 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/redis/go-redis/v9"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	infrav1 "github.com/doodlescheduling/db-controller/api/v1"
+)
+
+const (
+	redisRootUsername = "default"
+	redisRootPassword = "password"
+)
+
+type redisContainer struct {
+	testcontainers.Container
+	Addr string
+	URI  string
+}
+
+func setupRedisContainer(ctx context.Context, image string) (*redisContainer, error) {
+	req := testcontainers.ContainerRequest{
+		Image:        image,
+		ExposedPorts: []string{"6379/tcp"},
+		Cmd:          []string{"redis-server", "--requirepass", redisRootPassword},
+		WaitingFor: wait.ForLog("Ready to accept connections").
+			WithStartupTimeout(60 * time.Second),
+	}
+
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	host, err := container.Host(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	port, err := container.MappedPort(ctx, "6379/tcp")
+	if err != nil {
+		return nil, err
+	}
+
+	addr := fmt.Sprintf("%s:%s", host, port.Port())
+	return &redisContainer{Container: container, Addr: addr, URI: "redis://" + addr}, nil
+}
+
+var _ = Describe("Redis", func() {
+	const (
+		timeout  = time.Second * 5
+		interval = time.Second * 1
+	)
+
+	for _, image := range []string{"redis:6", "redis:7"} {
+		var _ = Describe(image, func() {
+			var (
+				container *redisContainer
+				err       error
+			)
+
+			container, err = setupRedisContainer(context.Background(), image)
+			Expect(err).NotTo(HaveOccurred(), "failed to start redis container")
+
+			redisClient := func(username, password string) *redis.Client {
+				return redis.NewClient(&redis.Options{
+					Addr:            container.Addr,
+					Username:        username,
+					Password:        password,
+					DisableIdentity: true,
+					MaxRetries:      -1,
+				})
+			}
+
+			userCondition := func(key types.NamespacedName, reason string, status metav1.ConditionStatus) func() bool {
+				return func() bool {
+					got := &infrav1.RedisUser{}
+					_ = k8sClient.Get(context.Background(), key, got)
+					return len(got.Status.Conditions) == 1 &&
+						got.Status.Conditions[0].Reason == reason &&
+						got.Status.Conditions[0].Status == status &&
+						got.Status.Conditions[0].Type == infrav1.UserReadyConditionType &&
+						got.ObjectMeta.Generation == got.Status.ObservedGeneration
+				}
+			}
+
+			updateUser := func(key types.NamespacedName, mutate func(user *infrav1.RedisUser)) {
+				Eventually(func() error {
+					user := &infrav1.RedisUser{}
+					if err := k8sClient.Get(context.Background(), key, user); err != nil {
+						return err
+					}
+
+					mutate(user)
+					return k8sClient.Update(context.Background(), user)
+				}, timeout, interval).Should(Succeed())
+			}
+
+			createServer := func(namespace, address, rootSecret string) types.NamespacedName {
+				key := types.NamespacedName{
+					Name:      "redisserver-" + randStringRunes(5),
+					Namespace: namespace,
+				}
+
+				server := &infrav1.RedisServer{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      key.Name,
+						Namespace: key.Namespace,
+					},
+					Spec: infrav1.RedisServerSpec{
+						Timeout: &metav1.Duration{
+							Duration: time.Second,
+						},
+						Address: address,
+						RootSecret: &infrav1.SecretReference{
+							Name: rootSecret,
+						},
+					},
+				}
+
+				Expect(k8sClient.Create(context.Background(), server)).Should(Succeed())
+				return key
+			}
+
+			createRootSecret := func(namespace string) string {
+				secret := &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "redis-root-" + randStringRunes(5),
+						Namespace: namespace,
+					},
+					Data: map[string][]byte{
+						"username": []byte(redisRootUsername),
+						"password": []byte(redisRootPassword),
+					},
+				}
+
+				Expect(k8sClient.Create(context.Background(), secret)).Should(Succeed())
+				return secret.Name
+			}
+
+			Describe("fails if server can't be reached", Ordered, func() {
+				var keyServer types.NamespacedName
+
+				namespace, rootSecret := setupNamespace()
+
+				It("adds server", func() {
+					keyServer = createServer(namespace.Name, "redis://does-not-exist:6379", rootSecret.Name)
+				})
+
+				It("fails reconcile because server can't be reached", func() {
+					got := &infrav1.RedisServer{}
+					Eventually(func() bool {
+						_ = k8sClient.Get(context.Background(), keyServer, got)
+						return len(got.Status.Conditions) == 1 &&
+							got.Status.Conditions[0].Reason == infrav1.ConnectionFailedReason &&
+							got.Status.Conditions[0].Status == "False" &&
+							got.Status.Conditions[0].Type == infrav1.ServerReadyConditionType
+					}, timeout, interval).Should(BeTrue())
+				})
+			})
+
+			Describe("fails if server not found", Ordered, func() {
+				var keyUser types.NamespacedName
+
+				namespace, _ := setupNamespace()
+
+				It("creates user", func() {
+					keyUser = types.NamespacedName{
+						Name:      "redisuser-" + randStringRunes(5),
+						Namespace: namespace.Name,
+					}
+					createdUser := &infrav1.RedisUser{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      keyUser.Name,
+							Namespace: keyUser.Namespace,
+						},
+						Spec: infrav1.RedisUserSpec{
+							Server: &infrav1.DatabaseReference{
+								Name: "does-not-exist",
+							},
+							Credentials: &infrav1.SecretReference{
+								Name: "does-not-exist",
+							},
+						},
+					}
+
+					Expect(k8sClient.Create(context.Background(), createdUser)).Should(Succeed())
+				})
+
+				It("fails reconcile because there is no server", func() {
+					Eventually(userCondition(keyUser, infrav1.DatabaseNotFoundReason, metav1.ConditionFalse), timeout, interval).Should(BeTrue())
+				})
+			})
+
+			Describe("Successful user creation", Ordered, func() {
+				var (
+					createdUser   *infrav1.RedisUser
+					createdSecret *corev1.Secret
+					keyUser       types.NamespacedName
+					keyServer     types.NamespacedName
+					password      string
+				)
+
+				namespace, _ := setupNamespace()
+
+				Describe("creates user if it does not exists", Ordered, func() {
+					It("adds server", func() {
+						keyServer = createServer(namespace.Name, container.URI, createRootSecret(namespace.Name))
+					})
+
+					It("expects ready server", func() {
+						got := &infrav1.RedisServer{}
+						Eventually(func() bool {
+							_ = k8sClient.Get(context.Background(), keyServer, got)
+							return len(got.Status.Conditions) == 1 &&
+								got.Status.Conditions[0].Reason == infrav1.ConnectionSuccessfulReason &&
+								got.Status.Conditions[0].Status == "True" &&
+								got.Status.Conditions[0].Type == infrav1.ServerReadyConditionType
+						}, timeout, interval).Should(BeTrue())
+					})
+
+					It("adds secret", func() {
+						keyUser = types.NamespacedName{
+							Name:      "redisuser-" + randStringRunes(5),
+							Namespace: namespace.Name,
+						}
+						password = randStringRunes(5)
+						createdSecret = &corev1.Secret{
+							ObjectMeta: metav1.ObjectMeta{
+								Name:      "secret-" + randStringRunes(5),
+								Namespace: namespace.Name,
+							},
+							Data: map[string][]byte{
+								"username": []byte(keyUser.Name),
+								"password": []byte(password),
+							},
+						}
+						Expect(k8sClient.Create(context.Background(), createdSecret)).Should(Succeed())
+					})
+
+					It("adds user", func() {
+						createdUser = &infrav1.RedisUser{
+							ObjectMeta: metav1.ObjectMeta{
+								Name:      keyUser.Name,
+								Namespace: keyUser.Namespace,
+							},
+							Spec: infrav1.RedisUserSpec{
+								Server: &infrav1.DatabaseReference{
+									Name: keyServer.Name,
+								},
+								Credentials: &infrav1.SecretReference{
+									Name: createdSecret.Name,
+								},
+								KeyPatterns:     []string{"tenant-a:*"},
+								ChannelPatterns: []string{"tenant-a:*"},
+								Categories:      []infrav1.RedisCategory{"+@all", "-@dangerous"},
+							},
+						}
+						Expect(k8sClient.Create(context.Background(), createdUser)).Should(Succeed())
+					})
+
+					It("expects ready user", func() {
+						Eventually(userCondition(keyUser, infrav1.UserProvisioningSuccessfulReason, metav1.ConditionTrue), timeout, interval).Should(BeTrue())
+					})
+
+					It("can access keys matching the key patterns", func() {
+						client := redisClient(keyUser.Name, password)
+						defer func() { _ = client.Close() }()
+
+						Expect(client.Set(context.Background(), "tenant-a:foo", "bar", 0).Err()).To(Succeed())
+						Expect(client.Get(context.Background(), "tenant-a:foo").Val()).To(Equal("bar"))
+					})
+
+					It("can't access other keys", func() {
+						client := redisClient(keyUser.Name, password)
+						defer func() { _ = client.Close() }()
+
+						Expect(client.Set(context.Background(), "tenant-b:foo", "bar", 0).Err()).To(MatchError(ContainSubstring("NOPERM")))
+					})
+
+					It("can only publish to channels matching the channel patterns", func() {
+						client := redisClient(keyUser.Name, password)
+						defer func() { _ = client.Close() }()
+
+						Expect(client.Publish(context.Background(), "tenant-a:events", "hello").Err()).To(Succeed())
+						Expect(client.Publish(context.Background(), "tenant-b:events", "hello").Err()).To(MatchError(ContainSubstring("NOPERM")))
+					})
+
+					It("can't run commands of excluded categories", func() {
+						client := redisClient(keyUser.Name, password)
+						defer func() { _ = client.Close() }()
+
+						Expect(client.FlushAll(context.Background()).Err()).To(MatchError(ContainSubstring("NOPERM")))
+					})
+
+					It("can't authenticate with invalid credentials", func() {
+						client := redisClient(keyUser.Name, "invalid-password")
+						defer func() { _ = client.Close() }()
+
+						Expect(client.Ping(context.Background()).Err()).To(HaveOccurred())
+					})
+				})
+
+				Describe("Change password for user", Ordered, func() {
+					It("changes password in referenced user secret", func() {
+						password = randStringRunes(5)
+						createdSecret.Data = map[string][]byte{
+							"username": []byte(keyUser.Name),
+							"password": []byte(password),
+						}
+						Expect(k8sClient.Update(context.Background(), createdSecret)).Should(Succeed())
+					})
+
+					It("can authenticate with the new password", func() {
+						Eventually(func() error {
+							client := redisClient(keyUser.Name, password)
+							defer func() { _ = client.Close() }()
+							return client.Ping(context.Background()).Err()
+						}, timeout, interval).Should(Succeed())
+					})
+				})
+
+				Describe("ValidUntil", Ordered, func() {
+					var session *redis.Conn
+
+					It("opens a session before validUntil expires", func() {
+						client := redisClient(keyUser.Name, password)
+						session = client.Conn()
+						Expect(session.Ping(context.Background()).Err()).To(Succeed())
+					})
+
+					It("sets validUntil in the past for the user", func() {
+						updateUser(keyUser, func(user *infrav1.RedisUser) {
+							validUntil := metav1.NewTime(time.Now().Add(-1 * time.Hour).UTC())
+							user.Spec.ValidUntil = &validUntil
+						})
+					})
+
+					It("sets expired status after validUntil expires", func() {
+						Eventually(userCondition(keyUser, infrav1.UserExpiredReason, metav1.ConditionFalse), timeout, interval).Should(BeTrue())
+					})
+
+					It("cannot authenticate after validUntil expired", func() {
+						client := redisClient(keyUser.Name, password)
+						defer func() { _ = client.Close() }()
+
+						Expect(client.Ping(context.Background()).Err()).To(HaveOccurred())
+					})
+
+					It("terminated the open session after validUntil expired", func() {
+						Expect(session.Ping(context.Background()).Err()).To(HaveOccurred())
+					})
+
+					It("clears validUntil for the user", func() {
+						updateUser(keyUser, func(user *infrav1.RedisUser) {
+							user.Spec.ValidUntil = nil
+						})
+
+						Eventually(userCondition(keyUser, infrav1.UserProvisioningSuccessfulReason, metav1.ConditionTrue), timeout, interval).Should(BeTrue())
+					})
+
+					It("can authenticate again after clearing validUntil", func() {
+						client := redisClient(keyUser.Name, password)
+						defer func() { _ = client.Close() }()
+
+						Expect(client.Ping(context.Background()).Err()).To(Succeed())
+					})
+				})
+
+				Describe("Delete user removes user from redis", Ordered, func() {
+					It("deletes user", func() {
+						Expect(k8sClient.Delete(context.Background(), createdUser)).Should(Succeed())
+					})
+
+					It("expects gone", func() {
+						got := &infrav1.RedisUser{}
+						Eventually(func() error {
+							return k8sClient.Get(context.Background(), keyUser, got)
+						}, timeout, interval).ShouldNot(Succeed())
+					})
+
+					It("dropped the user", func() {
+						client := redisClient(redisRootUsername, redisRootPassword)
+						defer func() { _ = client.Close() }()
+
+						users, err := client.Do(context.Background(), "ACL", "USERS").StringSlice()
+						Expect(err).NotTo(HaveOccurred())
+						Expect(users).NotTo(ContainElement(keyUser.Name))
+					})
+				})
+			})
+		})
+	}
+})
@@ -0,0 +1,177 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	infrav1 "github.com/doodlescheduling/db-controller/api/v1"
+	"github.com/doodlescheduling/db-controller/internal/database"
+	"github.com/doodlescheduling/db-controller/internal/tracing"
+)
+
+// +kubebuilder:rbac:groups=dbprovisioning.infra.doodle.com,resources=redisservers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=dbprovisioning.infra.doodle.com,resources=redisservers/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// RedisServerReconciler reconciles a RedisServer object
+type RedisServerReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder events.EventRecorder
+	// Provisioners is the registry to connect to the servers, the default registry is used if not set
+	Provisioners *database.Registry
+}
+
+func (r *RedisServerReconciler) SetupWithManager(mgr ctrl.Manager, maxConcurrentReconciles int) error {
+	// Index the RedisServer by the Secret references they point at
+	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &infrav1.RedisServer{}, secretIndexKey,
+		func(o client.Object) []string {
+			vb := o.(*infrav1.RedisServer)
+			return []string{
+				fmt.Sprintf("%s/%s", vb.GetNamespace(), vb.Spec.RootSecret.Name),
+			}
+		},
+	); err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&infrav1.RedisServer{}, builder.WithPredicates(
+			predicate.GenerationChangedPredicate{},
+		)).
+		Watches(
+			&corev1.Secret{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForSecretChange),
+		).
+		WithOptions(controller.Options{MaxConcurrentReconciles: maxConcurrentReconciles}).
+		Complete(r)
+}
+
+func (r *RedisServerReconciler) requestsForSecretChange(ctx context.Context, o client.Object) []reconcile.Request {
+	s, ok := o.(*corev1.Secret)
+	if !ok {
+		panic(fmt.Sprintf("expected a Secret, got %T", o))
+	}
+
+	var list infrav1.RedisServerList
+	if err := r.List(ctx, &list, client.MatchingFields{
+		secretIndexKey: objectKey(s).String(),
+	}); err != nil {
+		return nil
+	}
+
+	var reqs []reconcile.Request
+	for _, i := range list.Items {
+		r.Log.Info("referenced secret from a RedisServer changed detected", "namespace", i.GetNamespace(), "name", i.GetName())
+		reqs = append(reqs, reconcile.Request{NamespacedName: objectKey(&i)})
+	}
+
+	return reqs
+}
+
+func (r *RedisServerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx, span := tracing.Start(ctx, "RedisServer.Reconcile", tracing.Object("RedisServer", req.NamespacedName)...)
+	defer span.End()
+
+	logger := r.Log.WithValues("RedisServer", req.NamespacedName)
+	logger.Info("reconciling RedisServer")
+
+	var server infrav1.RedisServer
+	if err := r.Get(ctx, req.NamespacedName, &server); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	reconcileContext := ctx
+	if server.Spec.Timeout != nil {
+		c, cancel := context.WithTimeout(ctx, server.Spec.Timeout.Duration)
+		defer cancel()
+		reconcileContext = c
+	}
+
+	server, reconcileErr := r.reconcile(reconcileContext, server)
+	tracing.RecordError(span, reconcileErr)
+	res := ctrl.Result{}
+	server.Status.ObservedGeneration = server.GetGeneration()
+
+	if reconcileErr != nil {
+		r.Recorder.Eventf(&server, nil, "Normal", "error", "Reconcile", "%s", reconcileErr.Error())
+	} else {
+		msg := "Server is reachable"
+		r.Recorder.Eventf(&server, nil, "Normal", "info", "Reconcile", "%s", msg)
+		infrav1.ServerReadyCondition(&server, infrav1.ConnectionSuccessfulReason, msg)
+	}
+
+	recordReconcile("RedisServer", server.Status.Conditions, infrav1.ServerReadyConditionType)
+
+	// Update status after reconciliation.
+	if err := r.patchStatus(ctx, &server); err != nil {
+		logger.Error(err, "unable to update status after reconciliation")
+		return res, err
+	}
+
+	return res, reconcileErr
+}
+
+// reconcile verifies the root credentials, the users themselves are managed by the RedisUser controller
+func (r *RedisServerReconciler) reconcile(ctx context.Context, server infrav1.RedisServer) (infrav1.RedisServer, error) {
+	usr, pw, addr, err := getSecret(ctx, r.Client, server.GetRootSecret())
+
+	if err != nil {
+		infrav1.ServerNotReadyCondition(&server, infrav1.CredentialsNotFoundReason, err.Error())
+		return server, err
+	}
+
+	handler, err := setupRedis[database.Provisioner](ctx, r.Provisioners, server, usr, pw, addr)
+
+	if err != nil {
+		infrav1.ServerNotReadyCondition(&server, infrav1.ConnectionFailedReason, err.Error())
+		return server, err
+	}
+
+	_ = handler.Close(ctx)
+	return server, nil
+}
+
+func (r *RedisServerReconciler) patchStatus(ctx context.Context, server *infrav1.RedisServer) error {
+	key := client.ObjectKeyFromObject(server)
+	latest := &infrav1.RedisServer{}
+	if err := r.Get(ctx, key, latest); err != nil {
+		return err
+	}
+
+	return r.Client.Status().Patch(ctx, server, client.MergeFrom(latest))
+}
@@ -0,0 +1,412 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "github.com/doodlescheduling/db-controller/api/v1"
+	"github.com/doodlescheduling/db-controller/internal/database"
+	"github.com/doodlescheduling/db-controller/internal/metrics"
+)
+
+// defaultAtlasBaseURL is used by the Atlas client if no base URL is set
+const defaultAtlasBaseURL = "https://cloud.mongodb.com/"
+
+// ServerStatus is the result of the last connectivity check of a server
+type ServerStatus struct {
+	// Engine of the server like PostgreSQL or MongoDB
+	Engine string `json:"engine"`
+	// Address of the server without credentials
+	Address string `json:"address"`
+	// Reachable is true if the connection succeeded
+	Reachable bool `json:"reachable"`
+	// Error of the failed connection attempt
+	Error string `json:"error,omitempty"`
+	// LastCheck is the time of the connection attempt
+	LastCheck time.Time `json:"lastCheck"`
+	// References holds the database resources which use the server as <kind>/<namespace>/<name>
+	References []string `json:"references"`
+}
+
+type serverKey struct {
+	engine  string
+	address string
+}
+
+// server is a distinct server, it is connected with the root credentials of its first reference
+type server struct {
+	ServerStatus
+	engine database.Engine
+	opts   database.ProvisionerOptions
+}
+
+// ServerChecker periodically connects to each distinct server referenced by the database resources
+// using the same connection options as the controllers.
+// The connections are not observed by the connection metrics of the controllers, the checker only reports db_controller_server_up.
+// The results are served by ServeHTTP, exported as db_controller_server_up and optionally fail the readiness check
+// served by Check.
+type ServerChecker struct {
+	Client       client.Client
+	Log          logr.Logger
+	Provisioners *database.Registry
+
+	// Interval between two checks
+	Interval time.Duration
+	// Timeout of a single connection attempt
+	Timeout time.Duration
+	// FailReadiness fails Check while any server is unreachable
+	FailReadiness bool
+
+	mu      sync.RWMutex
+	checked bool
+	servers map[serverKey]ServerStatus
+}
+
+// NeedLeaderElection returns false, every replica reports its own connectivity
+func (c *ServerChecker) NeedLeaderElection() bool {
+	return false
+}
+
+// Start checks the servers in the configured interval until the context is cancelled
+func (c *ServerChecker) Start(ctx context.Context) error {
+	ticker := time.NewTicker(c.Interval)
+	defer ticker.Stop()
+
+	for {
+		c.CheckServers(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckServers connects to all servers referenced by the database resources and records the results
+func (c *ServerChecker) CheckServers(ctx context.Context) {
+	servers, err := c.discover(ctx)
+	if err != nil {
+		c.Log.Error(err, "failed to discover servers")
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, s := range servers {
+		wg.Go(func() {
+			c.check(ctx, s)
+		})
+	}
+
+	wg.Wait()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result := make(map[serverKey]ServerStatus, len(servers))
+	for key, s := range servers {
+		result[key] = s.ServerStatus
+		metrics.ServerUp.WithLabelValues(key.engine, key.address).Set(boolToFloat(s.Reachable))
+
+		previous, ok := c.servers[key]
+		switch {
+		case !s.Reachable && (!ok || previous.Reachable):
+			c.Log.Info("server is unreachable", "engine", key.engine, "address", key.address, "error", s.Error)
+		case s.Reachable && ok && !previous.Reachable:
+			c.Log.Info("server is reachable again", "engine", key.engine, "address", key.address)
+		}
+	}
+
+	for key := range c.servers {
+		if _, ok := result[key]; !ok {
+			metrics.ServerUp.DeleteLabelValues(key.engine, key.address)
+		}
+	}
+
+	c.servers = result
+	c.checked = true
+}
+
+func (c *ServerChecker) check(ctx context.Context, s *server) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	s.LastCheck = time.Now()
+	handler, err := database.NewProvisioner[database.Provisioner](ctx, provisionerRegistry(c.Provisioners), s.engine, s.opts)
+	if err != nil {
+		s.Error = err.Error()
+		return
+	}
+
+	s.Reachable = true
+	_ = handler.Close(ctx)
+}
+
+// Servers returns the results of the last check ordered by engine and address
+func (c *ServerChecker) Servers() []ServerStatus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	list := make([]ServerStatus, 0, len(c.servers))
+	for _, s := range c.servers {
+		list = append(list, s)
+	}
+
+	slices.SortFunc(list, func(a, b ServerStatus) int {
+		if n := strings.Compare(a.Engine, b.Engine); n != 0 {
+			return n
+		}
+
+		return strings.Compare(a.Address, b.Address)
+	})
+
+	return list
+}
+
+// Check implements healthz.Checker, it only fails if FailReadiness is set and a server is unreachable
+// or no check has completed yet
+func (c *ServerChecker) Check(_ *http.Request) error {
+	if !c.FailReadiness {
+		return nil
+	}
+
+	c.mu.RLock()
+	checked := c.checked
+	c.mu.RUnlock()
+
+	if !checked {
+		return errors.New("servers have not been checked yet")
+	}
+
+	var unreachable []string
+	for _, s := range c.Servers() {
+		if !s.Reachable {
+			unreachable = append(unreachable, fmt.Sprintf("%s %s", s.Engine, s.Address))
+		}
+	}
+
+	if len(unreachable) > 0 {
+		return fmt.Errorf("unreachable servers: %s", strings.Join(unreachable, ", "))
+	}
+
+	return nil
+}
+
+// ServeHTTP writes the results of the last check as JSON
+func (c *ServerChecker) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(struct {
+		Servers []ServerStatus `json:"servers"`
+	}{
+		Servers: c.Servers(),
+	})
+}
+
+// discover returns the distinct servers referenced by the database resources.
+// Resources whose root or TLS secret can not be read are skipped, their controller reports the failure.
+func (c *ServerChecker) discover(ctx context.Context) (map[serverKey]*server, error) {
+	servers := make(map[serverKey]*server)
+	add := func(label string, engine database.Engine, opts database.ProvisionerOptions, obj client.Object) {
+		key := serverKey{engine: label, address: metrics.ServerAddress(opts.URI)}
+		reference := fmt.Sprintf("%s/%s/%s", obj.GetObjectKind().GroupVersionKind().Kind, obj.GetNamespace(), obj.GetName())
+
+		if s, ok := servers[key]; ok {
+			s.References = append(s.References, reference)
+			return
+		}
+
+		servers[key] = &server{
+			ServerStatus: ServerStatus{
+				Engine:     key.engine,
+				Address:    key.address,
+				References: []string{reference},
+			},
+			engine: engine,
+			opts:   opts,
+		}
+	}
+
+	skip := func(obj client.Object, err error) {
+		c.Log.V(1).Info("skipping server check", "namespace", obj.GetNamespace(), "name", obj.GetName(), "error", err.Error())
+	}
+
+	var postgresqlDatabases infrav1.PostgreSQLDatabaseList
+	if err := c.Client.List(ctx, &postgresqlDatabases); err != nil {
+		return nil, err
+	}
+
+	for _, db := range postgresqlDatabases.Items {
+		usr, pw, addr, err := c.rootCredentials(ctx, db.GetRootSecret(), db.Spec.Address)
+		if err != nil {
+			skip(&db, err)
+			continue
+		}
+
+		db.SetGroupVersionKind(infrav1.GroupVersion.WithKind("PostgreSQLDatabase"))
+		add(string(database.EnginePostgreSQL), database.EnginePostgreSQL, postgreSQLOptions(db, usr, pw, addr, false), &db)
+	}
+
+	var mongodbDatabases infrav1.MongoDBDatabaseList
+	if err := c.Client.List(ctx, &mongodbDatabases); err != nil {
+		return nil, err
+	}
+
+	for _, db := range mongodbDatabases.Items {
+		usr, pw, addr, err := getMongoDBRootSecret(ctx, c.Client, db)
+		if err != nil {
+			skip(&db, err)
+			continue
+		}
+
+		db.SetGroupVersionKind(infrav1.GroupVersion.WithKind("MongoDBDatabase"))
+		if db.IsAtlas() {
+			add(database.MongoDBFlavorAtlas, database.EngineMongoDB, atlasOptions(db, usr, pw), &db)
+			continue
+		}
+
+		opts, err := mongoDBOptions(ctx, c.Client, db, usr, pw, addr)
+		if err != nil {
+			skip(&db, err)
+			continue
+		}
+
+		add(string(database.EngineMongoDB), database.EngineMongoDB, opts, &db)
+	}
+
+	var mysqlDatabases infrav1.MySQLDatabaseList
+	if err := c.Client.List(ctx, &mysqlDatabases); err != nil {
+		return nil, err
+	}
+
+	for _, db := range mysqlDatabases.Items {
+		usr, pw, addr, err := c.rootCredentials(ctx, db.GetRootSecret(), db.Spec.Address)
+		if err != nil {
+			skip(&db, err)
+			continue
+		}
+
+		db.SetGroupVersionKind(infrav1.GroupVersion.WithKind("MySQLDatabase"))
+		add(string(database.EngineMySQL), database.EngineMySQL, mySQLOptions(db, usr, pw, addr), &db)
+	}
+
+	var clickhouseDatabases infrav1.ClickHouseDatabaseList
+	if err := c.Client.List(ctx, &clickhouseDatabases); err != nil {
+		return nil, err
+	}
+
+	for _, db := range clickhouseDatabases.Items {
+		usr, pw, addr, err := c.rootCredentials(ctx, db.GetRootSecret(), db.Spec.Address)
+		if err != nil {
+			skip(&db, err)
+			continue
+		}
+
+		db.SetGroupVersionKind(infrav1.GroupVersion.WithKind("ClickHouseDatabase"))
+		add(string(database.EngineClickHouse), database.EngineClickHouse, clickHouseOptions(db, usr, pw, addr), &db)
+	}
+
+	var mssqlDatabases infrav1.MSSQLDatabaseList
+	if err := c.Client.List(ctx, &mssqlDatabases); err != nil {
+		return nil, err
+	}
+
+	for _, db := range mssqlDatabases.Items {
+		usr, pw, addr, err := c.rootCredentials(ctx, db.GetRootSecret(), db.Spec.Address)
+		if err != nil {
+			skip(&db, err)
+			continue
+		}
+
+		db.SetGroupVersionKind(infrav1.GroupVersion.WithKind("MSSQLDatabase"))
+		add(string(database.EngineMSSQL), database.EngineMSSQL, mssqlOptions(db, usr, pw, addr), &db)
+	}
+
+	var rabbitmqVhosts infrav1.RabbitMQVhostList
+	if err := c.Client.List(ctx, &rabbitmqVhosts); err != nil {
+		return nil, err
+	}
+
+	for _, vhost := range rabbitmqVhosts.Items {
+		usr, pw, addr, err := c.rootCredentials(ctx, vhost.GetRootSecret(), vhost.Spec.Address)
+		if err != nil {
+			skip(&vhost, err)
+			continue
+		}
+
+		vhost.SetGroupVersionKind(infrav1.GroupVersion.WithKind("RabbitMQVhost"))
+		add(string(database.EngineRabbitMQ), database.EngineRabbitMQ, rabbitMQOptions(vhost, usr, pw, addr), &vhost)
+	}
+
+	var redisServers infrav1.RedisServerList
+	if err := c.Client.List(ctx, &redisServers); err != nil {
+		return nil, err
+	}
+
+	for _, redisServer := range redisServers.Items {
+		usr, pw, addr, err := c.rootCredentials(ctx, redisServer.GetRootSecret(), redisServer.Spec.Address)
+		if err != nil {
+			skip(&redisServer, err)
+			continue
+		}
+
+		redisServer.SetGroupVersionKind(infrav1.GroupVersion.WithKind("RedisServer"))
+		add(string(database.EngineRedis), database.EngineRedis, redisOptions(redisServer, usr, pw, addr), &redisServer)
+	}
+
+	return servers, nil
+}
+
+// rootCredentials reads the root secret, the address from the spec takes precedence over the one from the secret
+func (c *ServerChecker) rootCredentials(ctx context.Context, ref *infrav1.SecretReference, address string) (string, string, string, error) {
+	if ref == nil {
+		return "", "", "", errors.New("no root secret referenced")
+	}
+
+	usr, pw, addr, err := getSecret(ctx, c.Client, ref)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	if address != "" {
+		addr = address
+	}
+
+	return usr, pw, addr, nil
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
@@ -25,7 +25,7 @@ import (
 
 	ctrl "sigs.k8s.io/controller-runtime"
 
-	"github.com/doodlescheduling/db-controller/api/v1beta1"
+	infrav1 "github.com/doodlescheduling/db-controller/api/v1"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	v1 "k8s.io/api/core/v1"
@@ -71,7 +71,7 @@ var _ = BeforeSuite(func() {
 	Expect(err).NotTo(HaveOccurred())
 	Expect(cfg).NotTo(BeNil())
 
-	err = v1beta1.AddToScheme(scheme.Scheme)
+	err = infrav1.AddToScheme(scheme.Scheme)
 	Expect(err).NotTo(HaveOccurred())
 
 	//+kubebuilder:scaffold:scheme
@@ -123,6 +123,96 @@ var _ = BeforeSuite(func() {
 	}).SetupWithManager(k8sManager, 1)
 	Expect(err).ToNot(HaveOccurred(), "failed to setup PostgreSQLUser")
 
+	// MySQLDatabase setup
+	err = (&MySQLDatabaseReconciler{
+		Client:   k8sManager.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("MySQLDatabase"),
+		Scheme:   k8sManager.GetScheme(),
+		Recorder: k8sManager.GetEventRecorder("MySQLDatabase"),
+	}).SetupWithManager(k8sManager, 1)
+	Expect(err).ToNot(HaveOccurred(), "failed to setup MySQLDatabase")
+
+	// MySQLUser setup
+	err = (&MySQLUserReconciler{
+		Client:   k8sManager.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("MySQLUser"),
+		Scheme:   k8sManager.GetScheme(),
+		Recorder: k8sManager.GetEventRecorder("MySQLUser"),
+	}).SetupWithManager(k8sManager, 1)
+	Expect(err).ToNot(HaveOccurred(), "failed to setup MySQLUser")
+
+	// RedisServer setup
+	err = (&RedisServerReconciler{
+		Client:   k8sManager.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("RedisServer"),
+		Scheme:   k8sManager.GetScheme(),
+		Recorder: k8sManager.GetEventRecorder("RedisServer"),
+	}).SetupWithManager(k8sManager, 1)
+	Expect(err).ToNot(HaveOccurred(), "failed to setup RedisServer")
+
+	// RedisUser setup
+	err = (&RedisUserReconciler{
+		Client:   k8sManager.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("RedisUser"),
+		Scheme:   k8sManager.GetScheme(),
+		Recorder: k8sManager.GetEventRecorder("RedisUser"),
+	}).SetupWithManager(k8sManager, 1)
+	Expect(err).ToNot(HaveOccurred(), "failed to setup RedisUser")
+
+	// ClickHouseDatabase setup
+	err = (&ClickHouseDatabaseReconciler{
+		Client:   k8sManager.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("ClickHouseDatabase"),
+		Scheme:   k8sManager.GetScheme(),
+		Recorder: k8sManager.GetEventRecorder("ClickHouseDatabase"),
+	}).SetupWithManager(k8sManager, 1)
+	Expect(err).ToNot(HaveOccurred(), "failed to setup ClickHouseDatabase")
+
+	// ClickHouseUser setup
+	err = (&ClickHouseUserReconciler{
+		Client:   k8sManager.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("ClickHouseUser"),
+		Scheme:   k8sManager.GetScheme(),
+		Recorder: k8sManager.GetEventRecorder("ClickHouseUser"),
+	}).SetupWithManager(k8sManager, 1)
+	Expect(err).ToNot(HaveOccurred(), "failed to setup ClickHouseUser")
+
+	// MSSQLDatabase setup
+	err = (&MSSQLDatabaseReconciler{
+		Client:   k8sManager.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("MSSQLDatabase"),
+		Scheme:   k8sManager.GetScheme(),
+		Recorder: k8sManager.GetEventRecorder("MSSQLDatabase"),
+	}).SetupWithManager(k8sManager, 1)
+	Expect(err).ToNot(HaveOccurred(), "failed to setup MSSQLDatabase")
+
+	// MSSQLUser setup
+	err = (&MSSQLUserReconciler{
+		Client:   k8sManager.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("MSSQLUser"),
+		Scheme:   k8sManager.GetScheme(),
+		Recorder: k8sManager.GetEventRecorder("MSSQLUser"),
+	}).SetupWithManager(k8sManager, 1)
+	Expect(err).ToNot(HaveOccurred(), "failed to setup MSSQLUser")
+
+	// RabbitMQVhost setup
+	err = (&RabbitMQVhostReconciler{
+		Client:   k8sManager.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("RabbitMQVhost"),
+		Scheme:   k8sManager.GetScheme(),
+		Recorder: k8sManager.GetEventRecorder("RabbitMQVhost"),
+	}).SetupWithManager(k8sManager, 1)
+	Expect(err).ToNot(HaveOccurred(), "failed to setup RabbitMQVhost")
+
+	// RabbitMQUser setup
+	err = (&RabbitMQUserReconciler{
+		Client:   k8sManager.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("RabbitMQUser"),
+		Scheme:   k8sManager.GetScheme(),
+		Recorder: k8sManager.GetEventRecorder("RabbitMQUser"),
+	}).SetupWithManager(k8sManager, 1)
+	Expect(err).ToNot(HaveOccurred(), "failed to setup RabbitMQUser")
+
 	go func() {
 		defer GinkgoRecover()
 		err = k8sManager.Start(ctx)
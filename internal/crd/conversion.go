@@ -0,0 +1,61 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ErrConversionRequired is returned by RequireConversion if a CRD serves multiple versions without the conversion webhook
+var ErrConversionRequired = errors.New("conversion webhook required")
+
+// RequireConversion returns ErrConversionRequired if any CRD of the api group serves multiple versions
+// without using the conversion webhook.
+// Without the webhook the API server only rewrites the apiVersion of an object which prunes all fields
+// that moved between the versions, for example the Atlas settings of a v1beta1 MongoDBDatabase.
+func RequireConversion(ctx context.Context, c client.Reader) error {
+	crds, err := listDefinitions(ctx, c)
+	if err != nil {
+		return fmt.Errorf("failed to list CRDs: %w", err)
+	}
+
+	var missing []string
+	for _, crd := range crds {
+		served := 0
+		for _, version := range crd.Spec.Versions {
+			if version.Served {
+				served++
+			}
+		}
+
+		if served > 1 && (crd.Spec.Conversion == nil || crd.Spec.Conversion.Strategy != apiextensionsv1.WebhookConverter) {
+			missing = append(missing, crd.Name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrConversionRequired, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package crd manages the CustomResourceDefinitions of the dbprovisioning.infra.doodle.com group.
+// It verifies the CRDs use the conversion webhook served by the controller and migrates
+// stored objects to the storage version.
+package crd
+
+import (
+	"context"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "github.com/doodlescheduling/db-controller/api/v1"
+)
+
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;list;watch
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions/status,verbs=get;update;patch
+
+// listDefinitions returns the CustomResourceDefinitions of the infra api group
+func listDefinitions(ctx context.Context, c client.Reader) ([]apiextensionsv1.CustomResourceDefinition, error) {
+	list := &apiextensionsv1.CustomResourceDefinitionList{}
+	if err := c.List(ctx, list); err != nil {
+		return nil, err
+	}
+
+	var crds []apiextensionsv1.CustomResourceDefinition
+	for _, crd := range list.Items {
+		if crd.Spec.Group == infrav1.GroupVersion.Group {
+			crds = append(crds, crd)
+		}
+	}
+
+	return crds, nil
+}
+
+// storageVersion returns the version which is persisted in etcd
+func storageVersion(crd *apiextensionsv1.CustomResourceDefinition) string {
+	for _, version := range crd.Spec.Versions {
+		if version.Storage {
+			return version.Name
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,128 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// StorageVersionMigrator rewrites all stored objects in the storage version of their CRD.
+// Once all objects are rewritten the previous versions are removed from status.storedVersions,
+// which allows to stop serving them in a later release.
+type StorageVersionMigrator struct {
+	Client client.Client
+	// Reader lists the objects bypassing the cache, the cache only holds objects matching the watch selector
+	Reader client.Reader
+	Log    logr.Logger
+}
+
+// NeedLeaderElection returns true, only the leader migrates objects
+func (m *StorageVersionMigrator) NeedLeaderElection() bool {
+	return true
+}
+
+// Start migrates all CRDs of the api group once.
+// A failed migration does not stop the manager, it is retried on the next start.
+func (m *StorageVersionMigrator) Start(ctx context.Context) error {
+	crds, err := listDefinitions(ctx, m.Reader)
+	if err != nil {
+		m.Log.Error(err, "failed to list CRDs")
+		return nil
+	}
+
+	for _, crd := range crds {
+		if err := m.migrate(ctx, &crd); err != nil {
+			m.Log.Error(err, "storage version migration failed", "crd", crd.Name)
+		}
+	}
+
+	return nil
+}
+
+func (m *StorageVersionMigrator) migrate(ctx context.Context, crd *apiextensionsv1.CustomResourceDefinition) error {
+	version := storageVersion(crd)
+	if version == "" {
+		return fmt.Errorf("no storage version defined")
+	}
+
+	if len(crd.Status.StoredVersions) == 1 && crd.Status.StoredVersions[0] == version {
+		return nil
+	}
+
+	gvk := schema.GroupVersionKind{
+		Group:   crd.Spec.Group,
+		Version: version,
+		Kind:    crd.Spec.Names.ListKind,
+	}
+
+	var (
+		migrated int
+		token    string
+	)
+
+	for {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk)
+		if err := m.Reader.List(ctx, list, client.Limit(500), client.Continue(token)); err != nil {
+			return fmt.Errorf("failed to list objects: %w", err)
+		}
+
+		for _, item := range list.Items {
+			if err := m.rewrite(ctx, &item); err != nil {
+				return fmt.Errorf("failed to rewrite %s/%s: %w", item.GetNamespace(), item.GetName(), err)
+			}
+
+			migrated++
+		}
+
+		token = list.GetContinue()
+		if token == "" {
+			break
+		}
+	}
+
+	crd.Status.StoredVersions = []string{version}
+	if err := m.Client.Status().Update(ctx, crd); err != nil {
+		return fmt.Errorf("failed to update stored versions: %w", err)
+	}
+
+	m.Log.Info("storage version migrated", "crd", crd.Name, "version", version, "objects", migrated)
+	return nil
+}
+
+// rewrite issues an update without changes, the api server persists the object in the storage version
+func (m *StorageVersionMigrator) rewrite(ctx context.Context, obj *unstructured.Unstructured) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		err := m.Client.Update(ctx, obj)
+		if apierrors.IsConflict(err) {
+			if err := m.Reader.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+				return client.IgnoreNotFound(err)
+			}
+		}
+
+		return client.IgnoreNotFound(err)
+	})
+}
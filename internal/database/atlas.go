@@ -2,57 +2,149 @@ package database
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/mongodb-forks/digest"
 	"go.mongodb.org/atlas/mongodbatlas"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+
+	infrav1 "github.com/doodlescheduling/db-controller/api/v1"
+	"github.com/doodlescheduling/db-controller/internal/audit"
+	"github.com/doodlescheduling/db-controller/internal/tracing"
 )
 
 type AtlasOptions struct {
+	BaseURL    string
 	GroupID    string
 	PublicKey  string
 	PrivateKey string
 }
 
+// AtlasUserOptions holds optional settings of a MongoDB Atlas user
+type AtlasUserOptions struct {
+	// UpdatePassword sets the password of an already existing user
+	UpdatePassword  bool
+	Scopes          []AtlasScope
+	Labels          []AtlasLabel
+	DeleteAfterDate *time.Time
+	X509Type        string
+	AWSIAMType      string
+	LDAPAuthType    string
+}
+
+type AtlasScope struct {
+	Name string
+	Type string
+}
+
+type AtlasLabel struct {
+	Key   string
+	Value string
+}
+
+// AtlasAccessListEntry is an entry of the project IP access list, either CIDRBlock or IPAddress is set
+type AtlasAccessListEntry struct {
+	CIDRBlock       string
+	IPAddress       string
+	Comment         string
+	DeleteAfterDate *time.Time
+}
+
+var (
+	ErrAtlasUnauthorized = errors.New("atlas api authentication failed")
+	ErrAtlasRateLimited  = errors.New("atlas api rate limit exceeded")
+	ErrAtlasServerError  = errors.New("atlas api server error")
+)
+
 type AtlasRepository struct {
 	atlas   *mongodbatlas.Client
 	groupId string
+	address string
 }
 
-func NewAtlasRepository(ctx context.Context, opts AtlasOptions) (*AtlasRepository, error) {
+func NewAtlasRepository(ctx context.Context, opts AtlasOptions) (_ *AtlasRepository, err error) {
+	ctx, span := startSpan(ctx, "NewAtlasRepository", semconv.DBSystemNameMongoDB, opts.BaseURL)
+	defer func() { tracing.End(span, err) }()
+
 	t := digest.NewTransport(opts.PublicKey, opts.PrivateKey)
 	tc, err := t.Client()
 	if err != nil {
 		return nil, err
 	}
 
+	var clientOpts []mongodbatlas.ClientOpt
+	if opts.BaseURL != "" {
+		clientOpts = append(clientOpts, mongodbatlas.SetBaseURL(strings.TrimSuffix(opts.BaseURL, "/")+"/"))
+	}
+
+	client, err := mongodbatlas.New(tc, clientOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Verify the api key and the project, the client itself does not connect
+	if _, _, err := client.Projects.GetOneProject(ctx, opts.GroupID); err != nil {
+		return nil, atlasError(err)
+	}
+
 	return &AtlasRepository{
 		groupId: opts.GroupID,
-		atlas:   mongodbatlas.NewClient(tc),
+		atlas:   client,
+		address: client.BaseURL.String(),
 	}, nil
 }
 
+func (m *AtlasRepository) startSpan(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return startSpan(ctx, "AtlasRepository."+method, semconv.DBSystemNameMongoDB, m.address, attrs...)
+}
+
 func (m *AtlasRepository) Close(ctx context.Context) error {
 	return nil
 }
 
-func (m *AtlasRepository) SetupUser(ctx context.Context, database string, username string, password string, roles MongoDBRoles) error {
-	doesUserExist, err := m.doesUserExist(ctx, database, username)
+// SetupUser creates the user or updates an existing one.
+// The password of an existing user is only changed if UpdatePassword is set, all other settings are only updated
+// if they drifted from the ones in Atlas.
+func (m *AtlasRepository) SetupUser(ctx context.Context, database string, username string, password string, roles MongoDBRoles, userOpts AtlasUserOptions) (err error) {
+	ctx, span := m.startSpan(ctx, "SetupUser", tracing.Database(database), tracing.User(username))
+	defer func() { tracing.End(span, err) }()
+
+	authDatabase := AtlasAuthDatabase(userOpts)
+	current, err := m.getUser(ctx, authDatabase, username)
 	if err != nil {
 		return err
 	}
 
-	if !doesUserExist {
-		if err := m.createUser(context.Background(), database, username, password, roles); err != nil {
+	user := m.newUser(database, username, password, roles, userOpts)
+
+	if current == nil {
+		if err := m.createUser(ctx, user); err != nil {
 			return err
 		}
-		if doesUserExistNow, err := m.doesUserExist(ctx, database, username); err != nil {
+		if doesUserExistNow, err := m.doesUserExist(ctx, authDatabase, username); err != nil {
 			return err
 		} else if !doesUserExistNow {
 			return errors.New("user doesn't exist after create")
 		}
 	} else {
-		if err := m.updateUserPasswordAndRoles(ctx, database, username, password, roles); err != nil {
+		if !userOpts.UpdatePassword {
+			user.Password = ""
+		}
+
+		if user.Password == "" && equalAtlasUsers(current, user) {
+			return nil
+		}
+
+		if err := m.updateUser(ctx, user); err != nil {
 			return err
 		}
 	}
@@ -60,18 +152,53 @@ func (m *AtlasRepository) SetupUser(ctx context.Context, database string, userna
 	return nil
 }
 
-func (m *AtlasRepository) DropUser(ctx context.Context, database string, username string) error {
-	_, err := m.atlas.DatabaseUsers.Delete(ctx, database, m.groupId, username)
-	return err
+// DropUser deletes the user, a user which does not exist anymore (for example because its deleteAfterDate passed) is ignored
+func (m *AtlasRepository) DropUser(ctx context.Context, database string, username string) (err error) {
+	ctx, span := m.startSpan(ctx, "DropUser", tracing.Database(database), tracing.User(username))
+	defer func() { tracing.End(span, err) }()
+
+	if doesUserExist, err := m.doesUserExist(ctx, database, username); err != nil || !doesUserExist {
+		return err
+	}
+
+	_, err = m.atlas.DatabaseUsers.Delete(ctx, database, m.groupId, username)
+	m.record(ctx, database, "deleteDatabaseUser", map[string]string{
+		"groupId":      m.groupId,
+		"databaseName": database,
+		"username":     username,
+	}, err)
+
+	return atlasError(err)
+}
+
+// AtlasAuthDatabase returns the database Atlas stores the user in.
+// Users authenticating using X.509, AWS IAM or as LDAP user are stored in $external, all others in admin.
+func AtlasAuthDatabase(userOpts AtlasUserOptions) string {
+	user := mongodbatlas.DatabaseUser{
+		X509Type:     userOpts.X509Type,
+		AWSIAMType:   userOpts.AWSIAMType,
+		LDAPAuthType: userOpts.LDAPAuthType,
+	}
+
+	return user.GetAuthDB()
 }
 
 func (m *AtlasRepository) doesUserExist(ctx context.Context, database string, username string) (bool, error) {
-	_, _, err := m.atlas.DatabaseUsers.Get(ctx, database, m.groupId, username)
+	user, err := m.getUser(ctx, database, username)
+	return user != nil, err
+}
+
+func (m *AtlasRepository) getUser(ctx context.Context, database string, username string) (*mongodbatlas.DatabaseUser, error) {
+	user, res, err := m.atlas.DatabaseUsers.Get(ctx, database, m.groupId, username)
+	if res != nil && res.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+
 	if err != nil {
-		return false, nil
+		return nil, atlasError(err)
 	}
 
-	return true, err
+	return user, nil
 }
 
 func (m *AtlasRepository) getRoles(database string, roles MongoDBRoles) []mongodbatlas.Role {
@@ -99,25 +226,319 @@ func (m *AtlasRepository) getRoles(database string, roles MongoDBRoles) []mongod
 	return rs
 }
 
-func (m *AtlasRepository) createUser(ctx context.Context, database string, username string, password string, roles MongoDBRoles) error {
+func (m *AtlasRepository) newUser(database string, username string, password string, roles MongoDBRoles, userOpts AtlasUserOptions) *mongodbatlas.DatabaseUser {
 	user := &mongodbatlas.DatabaseUser{
 		Username:     username,
 		Password:     password,
-		DatabaseName: database,
+		DatabaseName: AtlasAuthDatabase(userOpts),
 		Roles:        m.getRoles(database, roles),
+		Scopes:       []mongodbatlas.Scope{},
+		X509Type:     userOpts.X509Type,
+		AWSIAMType:   userOpts.AWSIAMType,
+		LDAPAuthType: userOpts.LDAPAuthType,
+	}
+
+	// Users authenticating externally don't have a password
+	for _, t := range []string{userOpts.X509Type, userOpts.AWSIAMType, userOpts.LDAPAuthType} {
+		if t != "" && t != infrav1.MongoDBAtlasUserTypeNone {
+			user.Password = ""
+		}
+	}
+
+	for _, scope := range userOpts.Scopes {
+		user.Scopes = append(user.Scopes, mongodbatlas.Scope{
+			Name: scope.Name,
+			Type: scope.Type,
+		})
+	}
+
+	for _, label := range userOpts.Labels {
+		user.Labels = append(user.Labels, mongodbatlas.Label{
+			Key:   label.Key,
+			Value: label.Value,
+		})
 	}
 
+	if userOpts.DeleteAfterDate != nil {
+		user.DeleteAfterDate = userOpts.DeleteAfterDate.UTC().Format(time.RFC3339)
+	}
+
+	return user
+}
+
+func (m *AtlasRepository) createUser(ctx context.Context, user *mongodbatlas.DatabaseUser) error {
 	_, _, err := m.atlas.DatabaseUsers.Create(ctx, m.groupId, user)
-	return err
+	m.record(ctx, user.DatabaseName, "createDatabaseUser", redactAtlasUser(m.groupId, user), err)
+	return atlasError(err)
 }
 
-func (m *AtlasRepository) updateUserPasswordAndRoles(ctx context.Context, database string, username string, password string, roles MongoDBRoles) error {
-	user := &mongodbatlas.DatabaseUser{
-		Username: username,
-		Password: password,
-		Roles:    m.getRoles(database, roles),
+// atlasUserUpdate is the body of a user update.
+// mongodbatlas.DatabaseUser omits empty labels and deleteAfterDate which would leave them untouched,
+// both are always sent so removing them from the spec clears them in Atlas.
+type atlasUserUpdate struct {
+	*mongodbatlas.DatabaseUser
+	Labels          []mongodbatlas.Label `json:"labels"`
+	DeleteAfterDate *string              `json:"deleteAfterDate"`
+}
+
+// updateUser updates the user, the password is left untouched if empty
+func (m *AtlasRepository) updateUser(ctx context.Context, user *mongodbatlas.DatabaseUser) error {
+	update := &atlasUserUpdate{
+		DatabaseUser: user,
+		Labels:       user.Labels,
+	}
+
+	if update.Labels == nil {
+		update.Labels = []mongodbatlas.Label{}
+	}
+
+	if user.DeleteAfterDate != "" {
+		update.DeleteAfterDate = &user.DeleteAfterDate
+	}
+
+	path := fmt.Sprintf("api/atlas/v1.0/groups/%s/databaseUsers/%s/%s", m.groupId, user.GetAuthDB(), url.PathEscape(user.Username))
+	req, err := m.atlas.NewRequest(ctx, http.MethodPatch, path, update)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.atlas.Do(ctx, req, new(mongodbatlas.DatabaseUser))
+	m.record(ctx, user.DatabaseName, "updateDatabaseUser", &atlasUserUpdate{
+		DatabaseUser:    redactAtlasUser(m.groupId, user),
+		Labels:          update.Labels,
+		DeleteAfterDate: update.DeleteAfterDate,
+	}, err)
+	return atlasError(err)
+}
+
+// SetupAccessList adds the entries to the project IP access list.
+// Only entries which are missing or differ from the existing ones are submitted.
+func (m *AtlasRepository) SetupAccessList(ctx context.Context, entries []AtlasAccessListEntry) (err error) {
+	ctx, span := m.startSpan(ctx, "SetupAccessList")
+	defer func() { tracing.End(span, err) }()
+
+	current, err := m.getAccessList(ctx)
+	if err != nil {
+		return err
+	}
+
+	var update []*mongodbatlas.ProjectIPAccessList
+	for _, entry := range entries {
+		desired := &mongodbatlas.ProjectIPAccessList{
+			CIDRBlock: entry.CIDRBlock,
+			IPAddress: entry.IPAddress,
+			Comment:   entry.Comment,
+		}
+
+		if entry.DeleteAfterDate != nil {
+			desired.DeleteAfterDate = entry.DeleteAfterDate.UTC().Format(time.RFC3339)
+		}
+
+		key := entry.CIDRBlock
+		if key == "" {
+			key = entry.IPAddress
+		}
+
+		existing, ok := current[key]
+		if ok && existing.Comment == desired.Comment && equalAtlasDate(existing.DeleteAfterDate, desired.DeleteAfterDate) {
+			continue
+		}
+
+		update = append(update, desired)
+	}
+
+	if len(update) == 0 {
+		return nil
+	}
+
+	_, _, err = m.atlas.ProjectIPAccessList.Create(ctx, m.groupId, update)
+	m.record(ctx, "", "createAccessListEntries", map[string]any{
+		"groupId": m.groupId,
+		"entries": update,
+	}, err)
+
+	return atlasError(err)
+}
+
+// DeleteAccessListEntry removes a CIDR block or IP address from the project IP access list.
+// An entry which does not exist (for example because it expired) is ignored.
+func (m *AtlasRepository) DeleteAccessListEntry(ctx context.Context, entry string) (err error) {
+	ctx, span := m.startSpan(ctx, "DeleteAccessListEntry")
+	defer func() { tracing.End(span, err) }()
+
+	res, err := m.atlas.ProjectIPAccessList.Delete(ctx, m.groupId, entry)
+	m.record(ctx, "", "deleteAccessListEntry", map[string]string{
+		"groupId": m.groupId,
+		"entry":   entry,
+	}, err)
+
+	if res != nil && res.StatusCode == http.StatusNotFound {
+		return nil
+	}
+
+	return atlasError(err)
+}
+
+// record records a request to the Atlas API in the audit log, the body must not contain a password
+func (m *AtlasRepository) record(ctx context.Context, database, operation string, body any, err error) {
+	stmt, marshalErr := json.Marshal(body)
+	if marshalErr != nil {
+		stmt = []byte(operation)
+	}
+
+	audit.Record(ctx, audit.Entry{
+		Engine:    MongoDBFlavorAtlas,
+		Server:    m.address,
+		Database:  database,
+		Operation: operation,
+		Statement: string(stmt),
+	}, err)
+}
+
+// redactAtlasUser returns a copy of the user with the group id set and the password redacted
+func redactAtlasUser(groupId string, user *mongodbatlas.DatabaseUser) *mongodbatlas.DatabaseUser {
+	redacted := *user
+	redacted.GroupID = groupId
+	if redacted.Password != "" {
+		redacted.Password = audit.Redacted
+	}
+
+	return &redacted
+}
+
+// getAccessList returns the project IP access list indexed by CIDR block and IP address
+func (m *AtlasRepository) getAccessList(ctx context.Context) (map[string]mongodbatlas.ProjectIPAccessList, error) {
+	entries := make(map[string]mongodbatlas.ProjectIPAccessList)
+	opts := &mongodbatlas.ListOptions{PageNum: 1, ItemsPerPage: 500}
+	var count int
+
+	for {
+		list, _, err := m.atlas.ProjectIPAccessList.List(ctx, m.groupId, opts)
+		if err != nil {
+			return nil, atlasError(err)
+		}
+
+		// Atlas reports single IP addresses additionally as /32 CIDR block
+		for _, entry := range list.Results {
+			for _, key := range []string{entry.CIDRBlock, entry.IPAddress} {
+				if key != "" {
+					entries[key] = entry
+				}
+			}
+		}
+
+		count += len(list.Results)
+		if len(list.Results) == 0 || count >= list.TotalCount {
+			return entries, nil
+		}
+
+		opts.PageNum++
+	}
+}
+
+// atlasError wraps errors returned by the Atlas API with ErrAtlasUnauthorized, ErrAtlasRateLimited or ErrAtlasServerError
+func atlasError(err error) error {
+	var errResponse *mongodbatlas.ErrorResponse
+	if !errors.As(err, &errResponse) || errResponse.Response == nil {
+		return err
+	}
+
+	switch code := errResponse.Response.StatusCode; {
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return fmt.Errorf("%w: %w", ErrAtlasUnauthorized, err)
+	case code == http.StatusTooManyRequests:
+		return fmt.Errorf("%w: %w", ErrAtlasRateLimited, err)
+	case code >= http.StatusInternalServerError:
+		return fmt.Errorf("%w: %w", ErrAtlasServerError, err)
 	}
 
-	_, _, err := m.atlas.DatabaseUsers.Update(ctx, m.groupId, username, user)
 	return err
 }
+
+// equalAtlasUsers compares the settings managed by the controller
+func equalAtlasUsers(a, b *mongodbatlas.DatabaseUser) bool {
+	return equalAtlasRoles(a.Roles, b.Roles) &&
+		equalUnordered(a.Scopes, b.Scopes, func(s mongodbatlas.Scope) string { return s.Type + "/" + s.Name }) &&
+		equalUnordered(a.Labels, b.Labels, func(l mongodbatlas.Label) string { return l.Key + "=" + l.Value }) &&
+		equalAtlasDate(a.DeleteAfterDate, b.DeleteAfterDate)
+}
+
+func equalAtlasDate(a, b string) bool {
+	if a == "" || b == "" {
+		return a == b
+	}
+
+	x, errX := time.Parse(time.RFC3339, a)
+	y, errY := time.Parse(time.RFC3339, b)
+	if errX != nil || errY != nil {
+		return a == b
+	}
+
+	return x.Equal(y)
+}
+
+// equalAtlasRoles compares two sets of roles regardless of their order and duplicates
+func equalAtlasRoles(a, b []mongodbatlas.Role) bool {
+	return equalUnordered(a, b, func(r mongodbatlas.Role) string {
+		return r.DatabaseName + "." + r.CollectionName + "." + r.RoleName
+	})
+}
+
+// equalUnordered compares two sets regardless of their order and duplicates
+func equalUnordered[T any](a, b []T, key func(T) string) bool {
+	normalize := func(items []T) []string {
+		var keys []string
+		for _, item := range items {
+			keys = append(keys, key(item))
+		}
+
+		slices.Sort(keys)
+		return slices.Compact(keys)
+	}
+
+	return slices.Equal(normalize(a), normalize(b))
+}
+
+// AtlasAccessListProvisioner manages the IP access list of a MongoDB Atlas project
+type AtlasAccessListProvisioner interface {
+	Provisioner
+	SetupAccessList(ctx context.Context, entries []AtlasAccessListEntry) error
+	DeleteAccessListEntry(ctx context.Context, entry string) error
+}
+
+// atlasProvisioner adapts the AtlasRepository to the engine neutral provisioner interfaces
+type atlasProvisioner struct {
+	*AtlasRepository
+}
+
+func newAtlasProvisioner(ctx context.Context, opts ProvisionerOptions) (Provisioner, error) {
+	repository, err := NewAtlasRepository(ctx, AtlasOptions{
+		BaseURL:    opts.URI,
+		GroupID:    opts.GroupID,
+		PublicKey:  opts.Username,
+		PrivateKey: opts.Password,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &atlasProvisioner{repository}, nil
+}
+
+// CreateDatabaseIfNotExists is a no-op since databases are created implicitly within Atlas clusters
+func (p *atlasProvisioner) CreateDatabaseIfNotExists(ctx context.Context, database string) error {
+	return nil
+}
+
+func (p *atlasProvisioner) SetupUser(ctx context.Context, user User) error {
+	opts, err := userOptions[AtlasUserOptions](user)
+	if err != nil {
+		return err
+	}
+
+	return p.AtlasRepository.SetupUser(ctx, user.Database, user.Username, user.Password, mongoDBRoles(user.Roles), opts)
+}
+
+func (p *atlasProvisioner) DropUser(ctx context.Context, user User) error {
+	return p.AtlasRepository.DropUser(ctx, user.Database, user.Username)
+}
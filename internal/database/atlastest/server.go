@@ -0,0 +1,367 @@
+// Package atlastest provides an in-process fake of the MongoDB Atlas API for tests.
+package atlastest
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/atlas/mongodbatlas"
+)
+
+const (
+	realm = "MMS Public API"
+	nonce = "atlastest"
+)
+
+// Request is a request received by the server
+type Request struct {
+	Method string
+	Path   string
+}
+
+// Server is a fake MongoDB Atlas API which keeps all state in memory.
+// Requests are authenticated using HTTP digest authentication like the Atlas API does.
+type Server struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	publicKey  string
+	privateKey string
+	users      map[string]mongodbatlas.DatabaseUser
+	accessList map[string]mongodbatlas.ProjectIPAccessList
+	failWith   int
+	requests   []Request
+}
+
+// NewServer starts a fake Atlas API accepting the given API key pair
+func NewServer(publicKey, privateKey string) *Server {
+	s := &Server{
+		publicKey:  publicKey,
+		privateKey: privateKey,
+		users:      make(map[string]mongodbatlas.DatabaseUser),
+		accessList: make(map[string]mongodbatlas.ProjectIPAccessList),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/atlas/v1.0/groups/{group}", s.getProject)
+	mux.HandleFunc("POST /api/atlas/v1.0/groups/{group}/databaseUsers", s.createUser)
+	mux.HandleFunc("GET /api/atlas/v1.0/groups/{group}/databaseUsers/{db}/{username}", s.getUser)
+	mux.HandleFunc("PATCH /api/atlas/v1.0/groups/{group}/databaseUsers/{db}/{username}", s.updateUser)
+	mux.HandleFunc("DELETE /api/atlas/v1.0/groups/{group}/databaseUsers/{db}/{username}", s.deleteUser)
+	mux.HandleFunc("GET /api/atlas/v1.0/groups/{group}/accessList", s.listAccessList)
+	mux.HandleFunc("POST /api/atlas/v1.0/groups/{group}/accessList", s.createAccessList)
+	mux.HandleFunc("DELETE /api/atlas/v1.0/groups/{group}/accessList/{entry}", s.deleteAccessListEntry)
+
+	s.Server = httptest.NewServer(s.authenticate(mux))
+	return s
+}
+
+// FailWith makes all subsequent requests fail with the given HTTP status code, 0 disables the failure again
+func (s *Server) FailWith(statusCode int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failWith = statusCode
+}
+
+// User returns a database user
+func (s *Server) User(groupID, database, username string) (mongodbatlas.DatabaseUser, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[userKey(groupID, database, username)]
+	return user, ok
+}
+
+// AccessListEntry returns an entry of the project IP access list by its CIDR block
+func (s *Server) AccessListEntry(groupID, cidrBlock string) (mongodbatlas.ProjectIPAccessList, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.accessList[groupID+"/"+cidrBlock]
+	return entry, ok
+}
+
+// AddAccessListEntry adds an entry to the project IP access list which is not managed by the controller
+func (s *Server) AddAccessListEntry(groupID string, entry mongodbatlas.ProjectIPAccessList) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry = normalizeAccessListEntry(groupID, entry)
+	s.accessList[groupID+"/"+entry.CIDRBlock] = entry
+}
+
+// Requests returns all authenticated requests received so far
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if auth == "" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Digest realm="%s", nonce="%s", qop="auth", algorithm=MD5`, realm, nonce))
+			writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "no credentials provided")
+			return
+		}
+
+		if !s.validDigest(r.Method, auth) {
+			writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "invalid api key")
+			return
+		}
+
+		s.mu.Lock()
+		s.requests = append(s.requests, Request{Method: r.Method, Path: r.URL.Path})
+		failWith := s.failWith
+		s.mu.Unlock()
+
+		if failWith != 0 {
+			writeError(w, failWith, "INJECTED_FAILURE", "injected failure")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) validDigest(method, auth string) bool {
+	if !strings.HasPrefix(auth, "Digest ") {
+		return false
+	}
+
+	params := make(map[string]string)
+	for _, param := range strings.Split(strings.TrimPrefix(auth, "Digest "), ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		params[key] = strings.Trim(value, `"`)
+	}
+
+	s.mu.Lock()
+	publicKey, privateKey := s.publicKey, s.privateKey
+	s.mu.Unlock()
+
+	if params["username"] != publicKey {
+		return false
+	}
+
+	ha1 := md5Hex(fmt.Sprintf("%s:%s:%s", publicKey, realm, privateKey))
+	ha2 := md5Hex(fmt.Sprintf("%s:%s", method, params["uri"]))
+	expected := md5Hex(fmt.Sprintf("%s:%s:%s:%s:%s:%s", ha1, params["nonce"], params["nc"], params["cnonce"], params["qop"], ha2))
+
+	return params["response"] == expected
+}
+
+// getProject returns a project for every group id, the fake does not manage projects
+func (s *Server) getProject(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, mongodbatlas.Project{
+		ID:   r.PathValue("group"),
+		Name: r.PathValue("group"),
+	})
+}
+
+func (s *Server) createUser(w http.ResponseWriter, r *http.Request) {
+	var user mongodbatlas.DatabaseUser
+	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+
+	user.GroupID = r.PathValue("group")
+	key := userKey(user.GroupID, user.DatabaseName, user.Username)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[key]; ok {
+		writeError(w, http.StatusConflict, "USER_ALREADY_EXISTS", "the user already exists")
+		return
+	}
+
+	s.users[key] = user
+	writeJSON(w, http.StatusCreated, redact(user))
+}
+
+func (s *Server) getUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := s.User(r.PathValue("group"), r.PathValue("db"), r.PathValue("username"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "USERNAME_NOT_FOUND", "no user found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, redact(user))
+}
+
+// updateUser only changes the fields which are present in the request like the Atlas API does
+func (s *Server) updateUser(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+
+	var update mongodbatlas.DatabaseUser
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &update); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+
+	if err := json.Unmarshal(body, &fields); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+
+	key := userKey(r.PathValue("group"), r.PathValue("db"), r.PathValue("username"))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[key]
+	if !ok {
+		writeError(w, http.StatusNotFound, "USERNAME_NOT_FOUND", "no user found")
+		return
+	}
+
+	if update.Password != "" {
+		user.Password = update.Password
+	}
+
+	if _, ok := fields["roles"]; ok {
+		user.Roles = update.Roles
+	}
+
+	if _, ok := fields["scopes"]; ok {
+		user.Scopes = update.Scopes
+	}
+
+	if _, ok := fields["labels"]; ok {
+		user.Labels = update.Labels
+	}
+
+	if _, ok := fields["deleteAfterDate"]; ok {
+		user.DeleteAfterDate = update.DeleteAfterDate
+	}
+
+	s.users[key] = user
+	writeJSON(w, http.StatusOK, redact(user))
+}
+
+func (s *Server) deleteUser(w http.ResponseWriter, r *http.Request) {
+	key := userKey(r.PathValue("group"), r.PathValue("db"), r.PathValue("username"))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[key]; !ok {
+		writeError(w, http.StatusNotFound, "USERNAME_NOT_FOUND", "no user found")
+		return
+	}
+
+	delete(s.users, key)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listAccessList(w http.ResponseWriter, r *http.Request) {
+	groupID := r.PathValue("group")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := mongodbatlas.ProjectIPAccessLists{
+		Results: []mongodbatlas.ProjectIPAccessList{},
+	}
+
+	for key, entry := range s.accessList {
+		if strings.HasPrefix(key, groupID+"/") {
+			list.Results = append(list.Results, entry)
+		}
+	}
+
+	list.TotalCount = len(list.Results)
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) createAccessList(w http.ResponseWriter, r *http.Request) {
+	var entries []mongodbatlas.ProjectIPAccessList
+	if err := json.NewDecoder(r.Body).Decode(&entries); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+
+	groupID := r.PathValue("group")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, entry := range entries {
+		entry = normalizeAccessListEntry(groupID, entry)
+		s.accessList[groupID+"/"+entry.CIDRBlock] = entry
+	}
+
+	writeJSON(w, http.StatusCreated, mongodbatlas.ProjectIPAccessLists{Results: []mongodbatlas.ProjectIPAccessList{}})
+}
+
+func (s *Server) deleteAccessListEntry(w http.ResponseWriter, r *http.Request) {
+	entry := mongodbatlas.ProjectIPAccessList{CIDRBlock: r.PathValue("entry")}
+	if !strings.Contains(entry.CIDRBlock, "/") {
+		entry = mongodbatlas.ProjectIPAccessList{IPAddress: entry.CIDRBlock}
+	}
+
+	entry = normalizeAccessListEntry(r.PathValue("group"), entry)
+	key := entry.GroupID + "/" + entry.CIDRBlock
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.accessList[key]; !ok {
+		writeError(w, http.StatusNotFound, "ATLAS_NETWORK_PERMISSION_ENTRY_NOT_FOUND", "no access list entry found")
+		return
+	}
+
+	delete(s.accessList, key)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// normalizeAccessListEntry reports single IP addresses additionally as /32 CIDR block like the Atlas API does
+func normalizeAccessListEntry(groupID string, entry mongodbatlas.ProjectIPAccessList) mongodbatlas.ProjectIPAccessList {
+	entry.GroupID = groupID
+	if entry.IPAddress != "" {
+		entry.CIDRBlock = entry.IPAddress + "/32"
+	}
+
+	return entry
+}
+
+func userKey(groupID, database, username string) string {
+	return groupID + "/" + database + "/" + username
+}
+
+// redact removes the password like the Atlas API does in its responses
+func redact(user mongodbatlas.DatabaseUser) mongodbatlas.DatabaseUser {
+	user.Password = ""
+	return user
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, statusCode int, errorCode, detail string) {
+	writeJSON(w, statusCode, map[string]interface{}{
+		"error":     statusCode,
+		"errorCode": errorCode,
+		"reason":    http.StatusText(statusCode),
+		"detail":    detail,
+	})
+}
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,75 @@
+package database
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/doodlescheduling/db-controller/internal/audit"
+	"github.com/doodlescheduling/db-controller/internal/tracing"
+)
+
+// recordStatement records a SQL statement in the audit log, password literals are redacted
+func recordStatement(ctx context.Context, engine Engine, server, database, stmt string, err error) {
+	audit.Record(ctx, audit.Entry{
+		Engine:    string(engine),
+		Server:    server,
+		Database:  database,
+		Operation: tracing.StatementType(stmt),
+		Statement: audit.RedactSQL(stmt),
+	}, err)
+}
+
+// isReadOnlyStatement reports whether the SQL statement does not change the server.
+// Queries terminating sessions are not read only even though they are SELECT statements.
+func isReadOnlyStatement(stmt string) bool {
+	switch tracing.StatementType(stmt) {
+	case "SELECT", "SHOW":
+		return !strings.Contains(strings.ToLower(stmt), "pg_terminate_backend")
+	}
+
+	return false
+}
+
+// recordCommand records a MongoDB command in the audit log, the pwd field is redacted
+func recordCommand(ctx context.Context, server, database string, command bson.D, err error) {
+	if !audit.Enabled() || len(command) == 0 {
+		return
+	}
+
+	command = slices.Clone(command)
+	for i, e := range command {
+		if e.Key == "pwd" {
+			command[i] = primitive.E{Key: e.Key, Value: audit.Redacted}
+		}
+	}
+
+	stmt, marshalErr := bson.MarshalExtJSON(command, false, false)
+	if marshalErr != nil {
+		stmt = []byte(command[0].Key)
+	}
+
+	audit.Record(ctx, audit.Entry{
+		Engine:    string(EngineMongoDB),
+		Server:    server,
+		Database:  database,
+		Operation: command[0].Key,
+		Statement: string(stmt),
+	}, err)
+}
+
+// recordSeedCommand records a command of a MongoDB seed.
+// Only the command name is recorded since the arguments of a seed may hold arbitrary data.
+func recordSeedCommand(ctx context.Context, server, database, seed string, command bson.D, err error) {
+	if len(command) == 0 {
+		return
+	}
+
+	recordCommand(ctx, server, database, bson.D{
+		primitive.E{Key: command[0].Key, Value: audit.Redacted},
+		primitive.E{Key: "seed", Value: seed},
+	}, err)
+}
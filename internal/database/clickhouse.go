@@ -0,0 +1,610 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/doodlescheduling/db-controller/internal/tracing"
+)
+
+type ClickHouseOptions struct {
+	URI      string
+	Username string
+	Password string
+	Cluster  string
+}
+
+type ClickHouseRepository struct {
+	db   *sql.DB
+	opts ClickHouseOptions
+}
+
+type ClickHouseUser struct {
+	Database string
+	Username string
+	Password string
+	Hosts    []ClickHouseHost
+	Grants   []ClickHouseGrant
+	Profile  string
+	Settings []ClickHouseSetting
+	Quotas   []ClickHouseQuota
+}
+
+type ClickHouseHost struct {
+	Type  string
+	Value string
+}
+
+type ClickHouseGrant struct {
+	Table      string
+	Privileges []Privilege
+}
+
+type ClickHouseSetting struct {
+	Name     string
+	Value    string
+	Readonly bool
+}
+
+type ClickHouseQuota struct {
+	Interval         time.Duration
+	MaxQueries       int64
+	MaxErrors        int64
+	MaxResultRows    int64
+	MaxReadRows      int64
+	MaxExecutionTime int64
+}
+
+// ClickHouseAllPrivileges grants all privileges available at the given level
+const ClickHouseAllPrivileges = "ALL"
+
+var (
+	clickhouseKeywordPattern   = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+	clickhouseSettingPattern   = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+	clickhousePrivilegePattern = regexp.MustCompile(`^[A-Z][A-Z ]*[A-Z]$`)
+	clickhouseHostTypes        = []string{"IP", "NAME", "REGEXP", "LIKE"}
+)
+
+func NewClickHouseRepository(ctx context.Context, opts ClickHouseOptions) (_ *ClickHouseRepository, err error) {
+	ctx, span := startSpan(ctx, "NewClickHouseRepository", semconv.DBSystemNameClickHouse, opts.URI, tracing.User(opts.Username))
+	defer func() { tracing.End(span, err) }()
+
+	uri := opts.URI
+	if !strings.Contains(uri, "://") {
+		uri = "clickhouse://" + uri
+	}
+
+	copts, err := clickhouse.ParseDSN(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	copts.Auth.Username = opts.Username
+	copts.Auth.Password = opts.Password
+	copts.Auth.Database = ""
+
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) > 0 {
+		copts.DialTimeout = time.Until(deadline)
+	}
+
+	db := clickhouse.OpenDB(copts)
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &ClickHouseRepository{
+		db:   db,
+		opts: opts,
+	}, nil
+}
+
+func (c *ClickHouseRepository) startSpan(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return startSpan(ctx, "ClickHouseRepository."+method, semconv.DBSystemNameClickHouse, c.opts.URI, attrs...)
+}
+
+func (c *ClickHouseRepository) Close(ctx context.Context) error {
+	if c.db != nil {
+		return c.db.Close()
+	}
+
+	return nil
+}
+
+// CreateDatabaseIfNotExists creates the database using the given engine, the server default is used if engine is empty
+func (c *ClickHouseRepository) CreateDatabaseIfNotExists(ctx context.Context, database, engine string, engineArguments []string) (err error) {
+	ctx, span := c.startSpan(ctx, "CreateDatabaseIfNotExists", tracing.Database(database))
+	defer func() { tracing.End(span, err) }()
+
+	var engineClause string
+	if engine != "" {
+		if !clickhouseKeywordPattern.MatchString(engine) {
+			return fmt.Errorf("invalid database engine %q", engine)
+		}
+
+		engineClause = " ENGINE = " + engine
+		if len(engineArguments) > 0 {
+			var args []string
+			for _, arg := range engineArguments {
+				args = append(args, quoteClickHouseString(arg))
+			}
+
+			engineClause += "(" + strings.Join(args, ", ") + ")"
+		}
+	}
+
+	if _, err := execContext(ctx, c.db, fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s%s%s;", quoteClickHouseIdentifier(database), c.onCluster(), engineClause)); err != nil {
+		return err
+	}
+
+	if databaseExists, err := c.doesDatabaseExist(ctx, database); err != nil {
+		return err
+	} else if !databaseExists {
+		return errors.New("database doesn't exist after create")
+	}
+
+	return nil
+}
+
+func (c *ClickHouseRepository) SetupUser(ctx context.Context, user ClickHouseUser) (err error) {
+	ctx, span := c.startSpan(ctx, "SetupUser", tracing.Database(user.Database), tracing.User(user.Username))
+	defer func() { tracing.End(span, err) }()
+
+	if err := c.createOrUpdateUser(ctx, user); err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+	if err := c.setQuota(ctx, user); err != nil {
+		return fmt.Errorf("failed to set quota: %w", err)
+	}
+	if err := c.syncGrants(ctx, user); err != nil {
+		return fmt.Errorf("failed to apply grants: %w", err)
+	}
+	return nil
+}
+
+// ExpireUser blocks all logins of the user and kills its running queries
+func (c *ClickHouseRepository) ExpireUser(ctx context.Context, user ClickHouseUser) (err error) {
+	ctx, span := c.startSpan(ctx, "ExpireUser", tracing.Database(user.Database), tracing.User(user.Username))
+	defer func() { tracing.End(span, err) }()
+
+	if userExists, err := c.doesUserExist(ctx, user); err != nil {
+		return err
+	} else if !userExists {
+		return nil
+	}
+
+	if _, err := execContext(ctx, c.db, fmt.Sprintf("ALTER USER %s%s HOST NONE;", quoteClickHouseIdentifier(user.Username), c.onCluster())); err != nil {
+		return err
+	}
+
+	return c.killQueries(ctx, user)
+}
+
+// DisableUser randomizes the password, revokes all privileges on the database, blocks all logins and kills running queries
+func (c *ClickHouseRepository) DisableUser(ctx context.Context, user ClickHouseUser) (err error) {
+	ctx, span := c.startSpan(ctx, "DisableUser", tracing.Database(user.Database), tracing.User(user.Username))
+	defer func() { tracing.End(span, err) }()
+
+	if userExists, err := c.doesUserExist(ctx, user); err != nil {
+		return err
+	} else if !userExists {
+		return nil
+	}
+
+	if _, err := execContext(ctx, c.db, fmt.Sprintf("ALTER USER %s%s IDENTIFIED BY %s HOST NONE;", quoteClickHouseIdentifier(user.Username), c.onCluster(), quoteClickHouseString(user.Password))); err != nil {
+		return err
+	}
+
+	user.Grants = nil
+	if err := c.syncGrants(ctx, user); err != nil {
+		return fmt.Errorf("failed to revoke privileges: %w", err)
+	}
+
+	return c.killQueries(ctx, user)
+}
+
+// DropUser drops the user as well as its quota
+func (c *ClickHouseRepository) DropUser(ctx context.Context, user ClickHouseUser) (err error) {
+	ctx, span := c.startSpan(ctx, "DropUser", tracing.Database(user.Database), tracing.User(user.Username))
+	defer func() { tracing.End(span, err) }()
+
+	if err := c.killQueries(ctx, user); err != nil {
+		return err
+	}
+
+	if _, err := execContext(ctx, c.db, fmt.Sprintf("DROP QUOTA IF EXISTS %s%s;", quoteClickHouseIdentifier(clickhouseQuotaName(user)), c.onCluster())); err != nil {
+		return err
+	}
+
+	if _, err := execContext(ctx, c.db, fmt.Sprintf("DROP USER IF EXISTS %s%s;", quoteClickHouseIdentifier(user.Username), c.onCluster())); err != nil {
+		return err
+	}
+
+	if userExists, err := c.doesUserExist(ctx, user); err != nil {
+		return err
+	} else if userExists {
+		return errors.New("user still exists after drop")
+	}
+
+	return nil
+}
+
+// TODO Prepared Statements
+func (c *ClickHouseRepository) createOrUpdateUser(ctx context.Context, user ClickHouseUser) error {
+	hosts, err := clickhouseHosts(user.Hosts)
+	if err != nil {
+		return err
+	}
+
+	settings, err := clickhouseSettings(user)
+	if err != nil {
+		return err
+	}
+
+	clauses := fmt.Sprintf("IDENTIFIED BY %s HOST %s DEFAULT DATABASE %s SETTINGS %s",
+		quoteClickHouseString(user.Password),
+		hosts,
+		quoteClickHouseIdentifier(user.Database),
+		settings,
+	)
+
+	userExists, err := c.doesUserExist(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	if userExists {
+		_, err := execContext(ctx, c.db, fmt.Sprintf("ALTER USER %s%s %s;", quoteClickHouseIdentifier(user.Username), c.onCluster(), clauses))
+		return err
+	}
+
+	if _, err := execContext(ctx, c.db, fmt.Sprintf("CREATE USER %s%s %s;", quoteClickHouseIdentifier(user.Username), c.onCluster(), clauses)); err != nil {
+		return err
+	}
+
+	if userExistsNow, err := c.doesUserExist(ctx, user); err != nil {
+		return err
+	} else if !userExistsNow {
+		return errors.New("user doesn't exist after create")
+	}
+
+	return nil
+}
+
+func clickhouseHosts(hosts []ClickHouseHost) (string, error) {
+	if len(hosts) == 0 {
+		return "ANY", nil
+	}
+
+	var list []string
+	for _, host := range hosts {
+		if !slices.Contains(clickhouseHostTypes, host.Type) {
+			return "", fmt.Errorf("invalid host type %q", host.Type)
+		}
+
+		list = append(list, fmt.Sprintf("%s %s", host.Type, quoteClickHouseString(host.Value)))
+	}
+
+	return strings.Join(list, ", "), nil
+}
+
+func clickhouseSettings(user ClickHouseUser) (string, error) {
+	var list []string
+	if user.Profile != "" {
+		list = append(list, "PROFILE "+quoteClickHouseString(user.Profile))
+	}
+
+	for _, setting := range user.Settings {
+		if !clickhouseSettingPattern.MatchString(setting.Name) {
+			return "", fmt.Errorf("invalid setting %q", setting.Name)
+		}
+
+		s := fmt.Sprintf("%s = %s", setting.Name, quoteClickHouseString(setting.Value))
+		if setting.Readonly {
+			s += " READONLY"
+		}
+
+		list = append(list, s)
+	}
+
+	if len(list) == 0 {
+		return "NONE", nil
+	}
+
+	return strings.Join(list, ", "), nil
+}
+
+// setQuota replaces the quota of the user, the quota is dropped if no intervals are defined
+func (c *ClickHouseRepository) setQuota(ctx context.Context, user ClickHouseUser) error {
+	name := quoteClickHouseIdentifier(clickhouseQuotaName(user))
+	if len(user.Quotas) == 0 {
+		_, err := execContext(ctx, c.db, fmt.Sprintf("DROP QUOTA IF EXISTS %s%s;", name, c.onCluster()))
+		return err
+	}
+
+	var intervals []string
+	for _, quota := range user.Quotas {
+		seconds := int64(math.Ceil(quota.Interval.Seconds()))
+		if seconds < 1 {
+			return fmt.Errorf("invalid quota interval %s", quota.Interval)
+		}
+
+		var limits []string
+		for _, limit := range []struct {
+			name  string
+			value int64
+		}{
+			{"queries", quota.MaxQueries},
+			{"errors", quota.MaxErrors},
+			{"result_rows", quota.MaxResultRows},
+			{"read_rows", quota.MaxReadRows},
+			{"execution_time", quota.MaxExecutionTime},
+		} {
+			if limit.value > 0 {
+				limits = append(limits, fmt.Sprintf("%s = %d", limit.name, limit.value))
+			}
+		}
+
+		if len(limits) == 0 {
+			intervals = append(intervals, fmt.Sprintf("FOR INTERVAL %d second NO LIMITS", seconds))
+			continue
+		}
+
+		intervals = append(intervals, fmt.Sprintf("FOR INTERVAL %d second MAX %s", seconds, strings.Join(limits, ", ")))
+	}
+
+	_, err := execContext(ctx, c.db, fmt.Sprintf("CREATE QUOTA OR REPLACE %s%s %s TO %s;", name, c.onCluster(), strings.Join(intervals, ", "), quoteClickHouseIdentifier(user.Username)))
+	return err
+}
+
+// syncGrants grants the privileges of user.Grants and revokes all other privileges on the database and its tables
+func (c *ClickHouseRepository) syncGrants(ctx context.Context, user ClickHouseUser) error {
+	desired := make(map[string][]string)
+	for _, grant := range user.Grants {
+		table := grant.Table
+		if table == "" {
+			table = "*"
+		}
+
+		for _, p := range grant.Privileges {
+			privilege := strings.ToUpper(strings.TrimSpace(string(p)))
+			if !clickhousePrivilegePattern.MatchString(privilege) {
+				return fmt.Errorf("invalid privilege %q", p)
+			}
+
+			desired[table] = append(desired[table], privilege)
+		}
+	}
+
+	current, err := c.getPrivileges(ctx, user)
+	if err != nil {
+		return fmt.Errorf("failed to lookup privileges: %w", err)
+	}
+
+	for table, privileges := range current {
+		wanted := desired[table]
+		if slices.Contains(wanted, ClickHouseAllPrivileges) {
+			continue
+		}
+
+		var revoke []string
+		for _, p := range privileges {
+			if !slices.Contains(wanted, p) {
+				revoke = append(revoke, p)
+			}
+		}
+
+		if len(revoke) == 0 {
+			continue
+		}
+
+		if _, err := execContext(ctx, c.db, fmt.Sprintf("REVOKE%s %s ON %s FROM %s;", c.onCluster(), strings.Join(revoke, ", "), clickhouseObject(user.Database, table), quoteClickHouseIdentifier(user.Username))); err != nil {
+			return err
+		}
+	}
+
+	for table, privileges := range desired {
+		if _, err := execContext(ctx, c.db, fmt.Sprintf("GRANT%s %s ON %s TO %s;", c.onCluster(), strings.Join(privileges, ", "), clickhouseObject(user.Database, table), quoteClickHouseIdentifier(user.Username))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getPrivileges returns the privileges of the user on the database (table *) and its tables
+func (c *ClickHouseRepository) getPrivileges(ctx context.Context, user ClickHouseUser) (map[string][]string, error) {
+	privileges := make(map[string][]string)
+
+	rows, err := c.db.QueryContext(ctx, "SELECT ifNull(table, '*'), access_type FROM system.grants WHERE user_name = ? AND database = ? AND is_partial_revoke = 0", user.Username, user.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var table, privilege string
+		if err := rows.Scan(&table, &privilege); err != nil {
+			return nil, err
+		}
+
+		privileges[table] = append(privileges[table], privilege)
+	}
+
+	return privileges, rows.Err()
+}
+
+func (c *ClickHouseRepository) killQueries(ctx context.Context, user ClickHouseUser) error {
+	_, err := execContext(ctx, c.db, fmt.Sprintf("KILL QUERY%s WHERE user = %s ASYNC;", c.onCluster(), quoteClickHouseString(user.Username)))
+	return err
+}
+
+func (c *ClickHouseRepository) doesDatabaseExist(ctx context.Context, database string) (bool, error) {
+	var result uint64
+	err := c.db.QueryRowContext(ctx, "SELECT count() FROM system.databases WHERE name = ?", database).Scan(&result)
+	if err != nil {
+		return false, err
+	}
+	return result == 1, nil
+}
+
+func (c *ClickHouseRepository) doesUserExist(ctx context.Context, user ClickHouseUser) (bool, error) {
+	var result uint64
+	err := c.db.QueryRowContext(ctx, "SELECT count() FROM system.users WHERE name = ?", user.Username).Scan(&result)
+	if err != nil {
+		return false, err
+	}
+	return result == 1, nil
+}
+
+func (c *ClickHouseRepository) onCluster() string {
+	if c.opts.Cluster == "" {
+		return ""
+	}
+
+	return " ON CLUSTER " + quoteClickHouseIdentifier(c.opts.Cluster)
+}
+
+// clickhouseQuotaName returns the name of the quota managed for the user
+func clickhouseQuotaName(user ClickHouseUser) string {
+	return user.Username + "_quota"
+}
+
+// clickhouseObject returns the quoted db.table privilege level, a table * refers to all tables of the database
+func clickhouseObject(database, table string) string {
+	if table == "*" {
+		return quoteClickHouseIdentifier(database) + ".*"
+	}
+
+	return quoteClickHouseIdentifier(database) + "." + quoteClickHouseIdentifier(table)
+}
+
+var (
+	clickhouseIdentifierEscaper = strings.NewReplacer(`\`, `\\`, "`", "\\`")
+	clickhouseStringEscaper     = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+)
+
+func quoteClickHouseIdentifier(name string) string {
+	return "`" + clickhouseIdentifierEscaper.Replace(name) + "`"
+}
+
+func quoteClickHouseString(value string) string {
+	return "'" + clickhouseStringEscaper.Replace(value) + "'"
+}
+
+// ClickHouseUserOptions holds the ClickHouse specific settings of a User
+type ClickHouseUserOptions struct {
+	Hosts    []ClickHouseHost
+	Grants   []ClickHouseGrant
+	Profile  string
+	Settings []ClickHouseSetting
+	Quotas   []ClickHouseQuota
+}
+
+// ClickHouseDatabaseOptions holds the settings a ClickHouse database is created with
+type ClickHouseDatabaseOptions struct {
+	Engine          string
+	EngineArguments []string
+}
+
+// ClickHouseDatabaseProvisioner creates databases using a database engine
+type ClickHouseDatabaseProvisioner interface {
+	DatabaseProvisioner
+	CreateDatabaseWithOptions(ctx context.Context, database string, opts ClickHouseDatabaseOptions) error
+}
+
+// clickHouseProvisioner adapts the ClickHouseRepository to the engine neutral provisioner interfaces.
+// It does not implement SessionTerminator since the repository kills the running queries of a user itself.
+type clickHouseProvisioner struct {
+	*ClickHouseRepository
+}
+
+func newClickHouseProvisioner(ctx context.Context, opts ProvisionerOptions) (Provisioner, error) {
+	repository, err := NewClickHouseRepository(ctx, ClickHouseOptions{
+		URI:      opts.URI,
+		Username: opts.Username,
+		Password: opts.Password,
+		Cluster:  opts.Cluster,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &clickHouseProvisioner{repository}, nil
+}
+
+// CreateDatabaseIfNotExists creates the database using the server default engine
+func (p *clickHouseProvisioner) CreateDatabaseIfNotExists(ctx context.Context, database string) error {
+	return p.ClickHouseRepository.CreateDatabaseIfNotExists(ctx, database, "", nil)
+}
+
+func (p *clickHouseProvisioner) CreateDatabaseWithOptions(ctx context.Context, database string, opts ClickHouseDatabaseOptions) error {
+	return p.ClickHouseRepository.CreateDatabaseIfNotExists(ctx, database, opts.Engine, opts.EngineArguments)
+}
+
+func (p *clickHouseProvisioner) SetupUser(ctx context.Context, user User) error {
+	chUser, err := clickhouseUser(user)
+	if err != nil {
+		return err
+	}
+
+	return p.ClickHouseRepository.SetupUser(ctx, chUser)
+}
+
+func (p *clickHouseProvisioner) DropUser(ctx context.Context, user User) error {
+	chUser, err := clickhouseUser(user)
+	if err != nil {
+		return err
+	}
+
+	return p.ClickHouseRepository.DropUser(ctx, chUser)
+}
+
+func (p *clickHouseProvisioner) DisableUser(ctx context.Context, user User) error {
+	chUser, err := clickhouseUser(user)
+	if err != nil {
+		return err
+	}
+
+	return p.ClickHouseRepository.DisableUser(ctx, chUser)
+}
+
+func (p *clickHouseProvisioner) ExpireUser(ctx context.Context, user User) error {
+	chUser, err := clickhouseUser(user)
+	if err != nil {
+		return err
+	}
+
+	return p.ClickHouseRepository.ExpireUser(ctx, chUser)
+}
+
+func clickhouseUser(user User) (ClickHouseUser, error) {
+	opts, err := userOptions[ClickHouseUserOptions](user)
+	if err != nil {
+		return ClickHouseUser{}, err
+	}
+
+	return ClickHouseUser{
+		Database: user.Database,
+		Username: user.Username,
+		Password: user.Password,
+		Hosts:    opts.Hosts,
+		Grants:   opts.Grants,
+		Profile:  opts.Profile,
+		Settings: opts.Settings,
+		Quotas:   opts.Quotas,
+	}, nil
+}
@@ -0,0 +1,233 @@
+package databasetest
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/doodlescheduling/db-controller/internal/database"
+)
+
+// AtlasUser is a database user of the fake Atlas project
+type AtlasUser struct {
+	AuthDatabase    string
+	Username        string
+	Password        string
+	Roles           []database.Role
+	Scopes          []database.AtlasScope
+	Labels          []database.AtlasLabel
+	DeleteAfterDate *time.Time
+	X509Type        string
+	AWSIAMType      string
+	LDAPAuthType    string
+}
+
+// AtlasServer is a fake MongoDB Atlas admin API which keeps all state in memory
+type AtlasServer struct {
+	recorder
+
+	publicKey  string
+	privateKey string
+	users      map[string]*AtlasUser
+	accessList map[string]database.AtlasAccessListEntry
+}
+
+// NewAtlasServer creates a fake Atlas API accepting the given API key pair
+func NewAtlasServer(publicKey, privateKey string) *AtlasServer {
+	return &AtlasServer{
+		publicKey:  publicKey,
+		privateKey: privateKey,
+		users:      make(map[string]*AtlasUser),
+		accessList: make(map[string]database.AtlasAccessListEntry),
+	}
+}
+
+// Register registers the fake as the MongoDB provisioner of the Atlas flavor
+func (s *AtlasServer) Register(registry *database.Registry) {
+	registry.Register(database.EngineMongoDB, database.MongoDBFlavorAtlas, s.connect)
+}
+
+// User returns a copy of a database user
+func (s *AtlasServer) User(groupID, authDatabase, username string) (AtlasUser, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[atlasKey(groupID, authDatabase, username)]
+	if !ok {
+		return AtlasUser{}, false
+	}
+
+	c := *user
+	c.Roles = slices.Clone(user.Roles)
+	c.Scopes = slices.Clone(user.Scopes)
+	c.Labels = slices.Clone(user.Labels)
+	return c, true
+}
+
+// AccessListEntry returns an entry of the project IP access list by its CIDR block or IP address
+func (s *AtlasServer) AccessListEntry(groupID, entry string) (database.AtlasAccessListEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.accessList[atlasKey(groupID, entry)]
+	return e, ok
+}
+
+// connect does not verify the API key, like the Atlas API the key is verified by every request
+func (s *AtlasServer) connect(ctx context.Context, opts database.ProvisionerOptions) (database.Provisioner, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.record(ctx, Call{Method: Connect, Username: opts.Username}); err != nil {
+		return nil, err
+	}
+
+	s.open++
+	return &atlasProvisioner{server: s, opts: opts}, nil
+}
+
+// atlasProvisioner is a client of the fake Atlas API bound to a project
+type atlasProvisioner struct {
+	server *AtlasServer
+	opts   database.ProvisionerOptions
+}
+
+var (
+	_ database.DatabaseProvisioner        = &atlasProvisioner{}
+	_ database.UserProvisioner            = &atlasProvisioner{}
+	_ database.AtlasAccessListProvisioner = &atlasProvisioner{}
+)
+
+func (p *atlasProvisioner) Close(ctx context.Context) error {
+	p.server.close()
+	return nil
+}
+
+// request records the call and verifies the API key, mu must be held
+func (p *atlasProvisioner) request(ctx context.Context, call Call) error {
+	if err := p.server.record(ctx, call); err != nil {
+		return err
+	}
+
+	if p.opts.Username != p.server.publicKey || p.opts.Password != p.server.privateKey {
+		return fmt.Errorf("%w: invalid api key", database.ErrAtlasUnauthorized)
+	}
+
+	return nil
+}
+
+// CreateDatabaseIfNotExists is a no-op since databases are created implicitly within Atlas clusters
+func (p *atlasProvisioner) CreateDatabaseIfNotExists(ctx context.Context, db string) error {
+	return nil
+}
+
+// SetupUser creates the user or updates an existing one, the password of an existing user is only changed if
+// AtlasUserOptions.UpdatePassword is set
+func (p *atlasProvisioner) SetupUser(ctx context.Context, user database.User) error {
+	s := p.server
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var opts database.AtlasUserOptions
+	if user.Options != nil {
+		o, ok := user.Options.(database.AtlasUserOptions)
+		if !ok {
+			return fmt.Errorf("unexpected user options %T, expected %T", user.Options, opts)
+		}
+		opts = o
+	}
+
+	authDatabase := database.AtlasAuthDatabase(opts)
+	if err := p.request(ctx, Call{Method: "SetupUser", Database: authDatabase, Username: user.Username}); err != nil {
+		return err
+	}
+
+	if opts.DeleteAfterDate != nil && opts.DeleteAfterDate.After(time.Now().Add(7*24*time.Hour)) {
+		return fmt.Errorf("deleteAfterDate %s is more than a week in the future", opts.DeleteAfterDate.Format(time.RFC3339))
+	}
+
+	roles := make([]database.Role, 0, len(user.Roles))
+	for _, role := range user.Roles {
+		if role.Database == "" {
+			role.Database = user.Database
+		}
+		roles = append(roles, role)
+	}
+
+	key := atlasKey(p.opts.GroupID, authDatabase, user.Username)
+	current, ok := s.users[key]
+	if !ok {
+		current = &AtlasUser{
+			AuthDatabase: authDatabase,
+			Username:     user.Username,
+			Password:     user.Password,
+		}
+		s.users[key] = current
+	} else if opts.UpdatePassword {
+		current.Password = user.Password
+	}
+
+	current.Roles = roles
+	current.Scopes = slices.Clone(opts.Scopes)
+	current.Labels = slices.Clone(opts.Labels)
+	current.DeleteAfterDate = opts.DeleteAfterDate
+	current.X509Type = opts.X509Type
+	current.AWSIAMType = opts.AWSIAMType
+	current.LDAPAuthType = opts.LDAPAuthType
+	return nil
+}
+
+// DropUser deletes the user stored in the auth database user.Database, a missing user is ignored
+func (p *atlasProvisioner) DropUser(ctx context.Context, user database.User) error {
+	s := p.server
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := p.request(ctx, Call{Method: "DropUser", Database: user.Database, Username: user.Username}); err != nil {
+		return err
+	}
+
+	delete(s.users, atlasKey(p.opts.GroupID, user.Database, user.Username))
+	return nil
+}
+
+func (p *atlasProvisioner) SetupAccessList(ctx context.Context, entries []database.AtlasAccessListEntry) error {
+	s := p.server
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := p.request(ctx, Call{Method: "SetupAccessList"}); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		key := entry.CIDRBlock
+		if key == "" {
+			key = entry.IPAddress
+		}
+
+		s.accessList[atlasKey(p.opts.GroupID, key)] = entry
+	}
+
+	return nil
+}
+
+// DeleteAccessListEntry removes a CIDR block or IP address from the access list, a missing entry is ignored
+func (p *atlasProvisioner) DeleteAccessListEntry(ctx context.Context, entry string) error {
+	s := p.server
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := p.request(ctx, Call{Method: "DeleteAccessListEntry", Name: entry}); err != nil {
+		return err
+	}
+
+	delete(s.accessList, atlasKey(p.opts.GroupID, entry))
+	return nil
+}
+
+func atlasKey(parts ...string) string {
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,286 @@
+package databasetest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/doodlescheduling/db-controller/internal/database"
+)
+
+const mongoDBAdminDatabase = "admin"
+
+// MongoDBUser is a user of the fake server
+type MongoDBUser struct {
+	Database                   string
+	Username                   string
+	Password                   string
+	Roles                      []database.Role
+	Mechanisms                 []string
+	AuthenticationRestrictions []database.MongoDBAuthenticationRestriction
+	CustomData                 []byte
+}
+
+// MongoDBServer is a fake MongoDB server which keeps all state in memory
+type MongoDBServer struct {
+	recorder
+
+	users    map[string]*MongoDBUser
+	seeds    map[string]map[string]string
+	sessions map[string]int64
+	options  []database.ProvisionerOptions
+}
+
+// NewMongoDBServer creates a fake server with a root user in the admin database using the given credentials
+func NewMongoDBServer(username, password string) *MongoDBServer {
+	return &MongoDBServer{
+		users: map[string]*MongoDBUser{
+			mongoDBUserKey(mongoDBAdminDatabase, username): {
+				Database: mongoDBAdminDatabase,
+				Username: username,
+				Password: password,
+				Roles:    []database.Role{{Name: "root", Database: mongoDBAdminDatabase}},
+			},
+		},
+		seeds:    make(map[string]map[string]string),
+		sessions: make(map[string]int64),
+	}
+}
+
+// Register registers the fake as the MongoDB provisioner, the Atlas flavor is not affected
+func (s *MongoDBServer) Register(registry *database.Registry) {
+	registry.Register(database.EngineMongoDB, "", s.connect)
+}
+
+// User returns a copy of a user
+func (s *MongoDBServer) User(db, username string) (MongoDBUser, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[mongoDBUserKey(db, username)]
+	if !ok {
+		return MongoDBUser{}, false
+	}
+
+	c := *user
+	c.Roles = slices.Clone(user.Roles)
+	c.Mechanisms = slices.Clone(user.Mechanisms)
+	c.AuthenticationRestrictions = slices.Clone(user.AuthenticationRestrictions)
+	c.CustomData = slices.Clone(user.CustomData)
+	return c, true
+}
+
+// Seeds returns the checksums of the seeds applied to a database indexed by the seed id
+func (s *MongoDBServer) Seeds(db string) map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seeds := make(map[string]string, len(s.seeds[db]))
+	for id, checksum := range s.seeds[db] {
+		seeds[id] = checksum
+	}
+
+	return seeds
+}
+
+// ConnectOptions returns the options of all connection attempts, passwords are never recorded
+func (s *MongoDBServer) ConnectOptions() []database.ProvisionerOptions {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.options)
+}
+
+// OpenSession simulates an active session of a user
+func (s *MongoDBServer) OpenSession(db, username string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[mongoDBUserKey(db, username)]++
+}
+
+// Sessions returns the number of active sessions of a user
+func (s *MongoDBServer) Sessions(db, username string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sessions[mongoDBUserKey(db, username)]
+}
+
+// Authenticate verifies the credentials of a user stored in the database db
+func (s *MongoDBServer) Authenticate(db, username, password string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.authenticate(db, username, password)
+}
+
+func (s *MongoDBServer) authenticate(db, username, password string) error {
+	user, ok := s.users[mongoDBUserKey(db, username)]
+	if !ok || user.Password != password {
+		return fmt.Errorf("authentication failed for user %s in database %s", username, db)
+	}
+
+	return nil
+}
+
+func (s *MongoDBServer) connect(ctx context.Context, opts database.ProvisionerOptions) (database.Provisioner, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	authDatabase := opts.AuthDatabaseName
+	if authDatabase == "" {
+		authDatabase = mongoDBAdminDatabase
+	}
+
+	recorded := opts
+	recorded.Password = ""
+	s.options = append(s.options, recorded)
+
+	if err := s.record(ctx, Call{Method: Connect, Database: authDatabase, Username: opts.Username}); err != nil {
+		return nil, err
+	}
+
+	// Users authenticating using a client certificate are not verified
+	if opts.AuthMechanism != "MONGODB-X509" {
+		if err := s.authenticate(authDatabase, opts.Username, opts.Password); err != nil {
+			return nil, err
+		}
+	}
+
+	s.open++
+	return &mongoDBProvisioner{server: s}, nil
+}
+
+// mongoDBProvisioner is a connection to the fake server
+type mongoDBProvisioner struct {
+	server *MongoDBServer
+}
+
+var (
+	_ database.DatabaseProvisioner = &mongoDBProvisioner{}
+	_ database.UserProvisioner     = &mongoDBProvisioner{}
+	_ database.MongoDBSeeder       = &mongoDBProvisioner{}
+	_ database.SessionTerminator   = &mongoDBProvisioner{}
+)
+
+func (p *mongoDBProvisioner) Close(ctx context.Context) error {
+	p.server.close()
+	return nil
+}
+
+// CreateDatabaseIfNotExists is a no-op since MongoDB creates databases implicitly
+func (p *mongoDBProvisioner) CreateDatabaseIfNotExists(ctx context.Context, db string) error {
+	s := p.server
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.record(ctx, Call{Method: "CreateDatabaseIfNotExists", Database: db})
+}
+
+// SetupUser creates the user or updates an existing one, the password of an existing user is only changed if
+// MongoDBUserOptions.UpdatePassword is set
+func (p *mongoDBProvisioner) SetupUser(ctx context.Context, user database.User) error {
+	s := p.server
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.record(ctx, Call{Method: "SetupUser", Database: user.Database, Username: user.Username}); err != nil {
+		return err
+	}
+
+	var opts database.MongoDBUserOptions
+	if user.Options != nil {
+		o, ok := user.Options.(database.MongoDBUserOptions)
+		if !ok {
+			return fmt.Errorf("unexpected user options %T, expected %T", user.Options, opts)
+		}
+		opts = o
+	}
+
+	if len(opts.CustomData) > 0 && !json.Valid(opts.CustomData) {
+		return fmt.Errorf("failed to parse custom data: invalid json")
+	}
+
+	roles := make([]database.Role, 0, len(user.Roles))
+	for _, role := range user.Roles {
+		if role.Database == "" {
+			role.Database = user.Database
+		}
+		roles = append(roles, role)
+	}
+
+	key := mongoDBUserKey(user.Database, user.Username)
+	current, ok := s.users[key]
+	if !ok {
+		current = &MongoDBUser{
+			Database: user.Database,
+			Username: user.Username,
+			Password: user.Password,
+		}
+		s.users[key] = current
+	} else if opts.UpdatePassword {
+		current.Password = user.Password
+	}
+
+	current.Roles = roles
+	current.Mechanisms = slices.Clone(opts.Mechanisms)
+	current.AuthenticationRestrictions = slices.Clone(opts.AuthenticationRestrictions)
+	current.CustomData = slices.Clone(opts.CustomData)
+	return nil
+}
+
+func (p *mongoDBProvisioner) DropUser(ctx context.Context, user database.User) error {
+	s := p.server
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.record(ctx, Call{Method: "DropUser", Database: user.Database, Username: user.Username}); err != nil {
+		return err
+	}
+
+	delete(s.users, mongoDBUserKey(user.Database, user.Username))
+	return nil
+}
+
+// TerminateSessions terminates the sessions opened by OpenSession
+func (p *mongoDBProvisioner) TerminateSessions(ctx context.Context, user database.User) (int64, error) {
+	s := p.server
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.record(ctx, Call{Method: "TerminateSessions", Database: user.Database, Username: user.Username}); err != nil {
+		return 0, err
+	}
+
+	key := mongoDBUserKey(user.Database, user.Username)
+	sessions := s.sessions[key]
+	delete(s.sessions, key)
+	return sessions, nil
+}
+
+// ApplySeed records the seed as applied unless it was applied before, the data must be valid JSON
+func (p *mongoDBProvisioner) ApplySeed(ctx context.Context, db string, seed database.MongoDBSeed) (bool, error) {
+	s := p.server
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.record(ctx, Call{Method: "ApplySeed", Database: db, Name: seed.ID}); err != nil {
+		return false, err
+	}
+
+	if _, ok := s.seeds[db][seed.ID]; ok {
+		return false, nil
+	}
+
+	if !json.Valid(seed.Data) {
+		return false, fmt.Errorf("failed to parse seed %s: invalid json", seed.ID)
+	}
+
+	if s.seeds[db] == nil {
+		s.seeds[db] = make(map[string]string)
+	}
+
+	s.seeds[db][seed.ID] = seed.Checksum
+	return true, nil
+}
+
+func mongoDBUserKey(db, username string) string {
+	return db + "/" + username
+}
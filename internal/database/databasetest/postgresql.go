@@ -0,0 +1,488 @@
+package databasetest
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/doodlescheduling/db-controller/internal/database"
+)
+
+// postgreSQLRoleAttributes are the role attributes accepted by the server
+var postgreSQLRoleAttributes = []string{
+	"LOGIN", "NOLOGIN",
+	"SUPERUSER", "NOSUPERUSER",
+	"CREATEDB", "NOCREATEDB",
+	"CREATEROLE", "NOCREATEROLE",
+	"REPLICATION", "NOREPLICATION",
+	"BYPASSRLS", "NOBYPASSRLS",
+	"INHERIT", "NOINHERIT",
+}
+
+// cockroachDBRoleAttributes are the role attributes accepted by the CockroachDB flavor
+var cockroachDBRoleAttributes = []string{
+	"LOGIN", "NOLOGIN",
+	"CREATEDB", "NOCREATEDB",
+	"CREATEROLE", "NOCREATEROLE",
+}
+
+// PostgreSQLDatabase is a database of the fake server
+type PostgreSQLDatabase struct {
+	Name       string
+	Extensions []string
+	Schemas    []string
+	SearchPath []string
+}
+
+// PostgreSQLRole is a role of the fake server, users are roles which are allowed to login
+type PostgreSQLRole struct {
+	Name       string
+	Password   string
+	Login      bool
+	ValidUntil *time.Time
+	// MemberOf lists the roles granted to the role
+	MemberOf   []string
+	Grants     []database.Grant
+	Attributes []string
+	// Databases lists the databases the role has all privileges on
+	Databases []string
+}
+
+// PostgreSQLServer is a fake PostgreSQL server which keeps all state in memory
+type PostgreSQLServer struct {
+	recorder
+
+	databases map[string]*PostgreSQLDatabase
+	roles     map[string]*PostgreSQLRole
+	sessions  map[string]int64
+}
+
+// NewPostgreSQLServer creates a fake server with a superuser using the given credentials
+func NewPostgreSQLServer(username, password string) *PostgreSQLServer {
+	return &PostgreSQLServer{
+		databases: map[string]*PostgreSQLDatabase{
+			"postgres": newPostgreSQLDatabase("postgres"),
+		},
+		roles: map[string]*PostgreSQLRole{
+			username: {
+				Name:       username,
+				Password:   password,
+				Login:      true,
+				Attributes: []string{"SUPERUSER"},
+			},
+		},
+		sessions: make(map[string]int64),
+	}
+}
+
+func newPostgreSQLDatabase(name string) *PostgreSQLDatabase {
+	return &PostgreSQLDatabase{
+		Name:    name,
+		Schemas: []string{"public"},
+	}
+}
+
+// Register registers the fake as the PostgreSQL provisioner for all flavors
+func (s *PostgreSQLServer) Register(registry *database.Registry) {
+	registry.Register(database.EnginePostgreSQL, "", s.connect)
+}
+
+// CreateRole creates a role which is not allowed to login, for example to be granted to users
+func (s *PostgreSQLServer) CreateRole(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.roles[name]; !ok {
+		s.roles[name] = &PostgreSQLRole{Name: name}
+	}
+}
+
+// Role returns a copy of a role
+func (s *PostgreSQLServer) Role(name string) (PostgreSQLRole, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	role, ok := s.roles[name]
+	if !ok {
+		return PostgreSQLRole{}, false
+	}
+
+	c := *role
+	c.MemberOf = slices.Clone(role.MemberOf)
+	c.Grants = slices.Clone(role.Grants)
+	c.Attributes = slices.Clone(role.Attributes)
+	c.Databases = slices.Clone(role.Databases)
+	return c, true
+}
+
+// Database returns a copy of a database
+func (s *PostgreSQLServer) Database(name string) (PostgreSQLDatabase, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	db, ok := s.databases[name]
+	if !ok {
+		return PostgreSQLDatabase{}, false
+	}
+
+	c := *db
+	c.Extensions = slices.Clone(db.Extensions)
+	c.Schemas = slices.Clone(db.Schemas)
+	c.SearchPath = slices.Clone(db.SearchPath)
+	return c, true
+}
+
+// OpenSession simulates an active session of a user
+func (s *PostgreSQLServer) OpenSession(username string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[username]++
+}
+
+// Sessions returns the number of active sessions of a user
+func (s *PostgreSQLServer) Sessions(username string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sessions[username]
+}
+
+// Authenticate verifies the credentials of a user the same way the server does on login
+func (s *PostgreSQLServer) Authenticate(username, password string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.authenticate(username, password)
+}
+
+func (s *PostgreSQLServer) authenticate(username, password string) error {
+	role, ok := s.roles[username]
+	if !ok || !role.Login || role.Password != password {
+		return fmt.Errorf("password authentication failed for user %q", username)
+	}
+
+	if role.ValidUntil != nil && !role.ValidUntil.After(time.Now()) {
+		return fmt.Errorf("password authentication failed for user %q", username)
+	}
+
+	return nil
+}
+
+func (s *PostgreSQLServer) connect(ctx context.Context, opts database.ProvisionerOptions) (database.Provisioner, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.record(ctx, Call{Method: Connect, Database: opts.DatabaseName, Username: opts.Username}); err != nil {
+		return nil, err
+	}
+
+	if err := s.authenticate(opts.Username, opts.Password); err != nil {
+		return nil, err
+	}
+
+	if opts.DatabaseName != "" {
+		if _, ok := s.databases[opts.DatabaseName]; !ok {
+			return nil, fmt.Errorf("database %q does not exist", opts.DatabaseName)
+		}
+	}
+
+	s.open++
+	return &postgreSQLProvisioner{server: s, opts: opts}, nil
+}
+
+// postgreSQLProvisioner is a connection to the fake server
+type postgreSQLProvisioner struct {
+	server *PostgreSQLServer
+	opts   database.ProvisionerOptions
+}
+
+var (
+	_ database.PostgreSQLDatabaseProvisioner = &postgreSQLProvisioner{}
+	_ database.UserProvisioner               = &postgreSQLProvisioner{}
+	_ database.UserDisabler                  = &postgreSQLProvisioner{}
+	_ database.UserExpirer                   = &postgreSQLProvisioner{}
+	_ database.SessionTerminator             = &postgreSQLProvisioner{}
+)
+
+func (p *postgreSQLProvisioner) Close(ctx context.Context) error {
+	p.server.close()
+	return nil
+}
+
+func (p *postgreSQLProvisioner) isCockroachDB() bool {
+	return p.opts.Flavor == database.PostgreSQLFlavorCockroachDB
+}
+
+func (p *postgreSQLProvisioner) CreateDatabaseIfNotExists(ctx context.Context, db string) error {
+	s := p.server
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.record(ctx, Call{Method: "CreateDatabaseIfNotExists", Database: db}); err != nil {
+		return err
+	}
+
+	if _, ok := s.databases[db]; !ok {
+		s.databases[db] = newPostgreSQLDatabase(db)
+	}
+
+	return nil
+}
+
+func (p *postgreSQLProvisioner) EnableExtension(ctx context.Context, db, name string) error {
+	s := p.server
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.record(ctx, Call{Method: "EnableExtension", Database: db, Name: name}); err != nil {
+		return err
+	}
+
+	if p.isCockroachDB() {
+		return fmt.Errorf("extension %s: %w", name, database.ErrUnsupportedByFlavor)
+	}
+
+	d, err := s.database(db)
+	if err != nil {
+		return err
+	}
+
+	if !slices.Contains(d.Extensions, name) {
+		d.Extensions = append(d.Extensions, name)
+	}
+
+	return nil
+}
+
+func (p *postgreSQLProvisioner) CreateSchema(ctx context.Context, db, name string) error {
+	s := p.server
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.record(ctx, Call{Method: "CreateSchema", Database: db, Name: name}); err != nil {
+		return err
+	}
+
+	d, err := s.database(db)
+	if err != nil {
+		return err
+	}
+
+	if !slices.Contains(d.Schemas, name) {
+		d.Schemas = append(d.Schemas, name)
+	}
+
+	return nil
+}
+
+func (p *postgreSQLProvisioner) SetSearchPath(ctx context.Context, db string, searchPath []string) error {
+	s := p.server
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.record(ctx, Call{Method: "SetSearchPath", Database: db, Name: strings.Join(searchPath, ",")}); err != nil {
+		return err
+	}
+
+	d, err := s.database(db)
+	if err != nil {
+		return err
+	}
+
+	d.SearchPath = slices.Clone(searchPath)
+	return nil
+}
+
+// SetupUser creates the user if it does not exist and applies the spec.
+// Like the PostgreSQL repository roles, grants and attributes are only added, never revoked.
+func (p *postgreSQLProvisioner) SetupUser(ctx context.Context, user database.User) error {
+	s := p.server
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.record(ctx, Call{Method: "SetupUser", Database: user.Database, Username: user.Username}); err != nil {
+		return err
+	}
+
+	opts, err := postgreSQLUserOptions(user)
+	if err != nil {
+		return err
+	}
+
+	d, err := s.database(user.Database)
+	if err != nil {
+		return fmt.Errorf("failed to grant all privileges: %w", err)
+	}
+
+	for _, name := range user.Roles {
+		if _, ok := s.roles[name.Name]; !ok {
+			return fmt.Errorf("failed to set roles: role %q does not exist", name.Name)
+		}
+	}
+
+	for _, grant := range opts.Grants {
+		if strings.EqualFold(grant.Object, "SCHEMA") && !slices.Contains(d.Schemas, grant.ObjectName) {
+			return fmt.Errorf("failed to apply grant rules: schema %q does not exist", grant.ObjectName)
+		}
+	}
+
+	for _, attribute := range opts.Attributes {
+		if !slices.Contains(postgreSQLRoleAttributes, attribute) {
+			return fmt.Errorf("failed to set attributes: invalid role attribute %q", attribute)
+		}
+
+		if p.isCockroachDB() && !slices.Contains(cockroachDBRoleAttributes, attribute) {
+			return fmt.Errorf("failed to set attributes: role attribute %s: %w", attribute, database.ErrUnsupportedByFlavor)
+		}
+	}
+
+	role, ok := s.roles[user.Username]
+	if !ok {
+		role = &PostgreSQLRole{Name: user.Username}
+		s.roles[user.Username] = role
+	}
+
+	role.Password = user.Password
+	role.Login = true
+	role.ValidUntil = user.ValidUntil
+
+	if !slices.Contains(role.Databases, user.Database) {
+		role.Databases = append(role.Databases, user.Database)
+	}
+
+	for _, name := range user.Roles {
+		if !slices.Contains(role.MemberOf, name.Name) {
+			role.MemberOf = append(role.MemberOf, name.Name)
+		}
+	}
+
+	for _, grant := range opts.Grants {
+		if !slices.ContainsFunc(role.Grants, func(g database.Grant) bool { return equalGrants(g, grant) }) {
+			role.Grants = append(role.Grants, grant)
+		}
+	}
+
+	for _, attribute := range opts.Attributes {
+		switch attribute {
+		case "LOGIN":
+			role.Login = true
+		case "NOLOGIN":
+			role.Login = false
+		default:
+			if !slices.Contains(role.Attributes, attribute) {
+				role.Attributes = append(role.Attributes, attribute)
+			}
+		}
+	}
+
+	return nil
+}
+
+// DropUser drops the user, the objects it owns are reassigned to opts.ReassignOwnedTo
+func (p *postgreSQLProvisioner) DropUser(ctx context.Context, user database.User) error {
+	s := p.server
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	opts, err := postgreSQLUserOptions(user)
+	if err != nil {
+		return err
+	}
+
+	if err := s.record(ctx, Call{Method: "DropUser", Database: user.Database, Username: user.Username, Name: opts.ReassignOwnedTo}); err != nil {
+		return err
+	}
+
+	if opts.ReassignOwnedTo != "" {
+		if _, ok := s.roles[opts.ReassignOwnedTo]; !ok {
+			return fmt.Errorf("role %q does not exist", opts.ReassignOwnedTo)
+		}
+	}
+
+	delete(s.roles, user.Username)
+	return nil
+}
+
+// DisableUser prevents new logins of the user, sets its password and revokes its privileges on the database
+func (p *postgreSQLProvisioner) DisableUser(ctx context.Context, user database.User) error {
+	s := p.server
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.record(ctx, Call{Method: "DisableUser", Database: user.Database, Username: user.Username}); err != nil {
+		return err
+	}
+
+	role, ok := s.roles[user.Username]
+	if !ok {
+		return nil
+	}
+
+	role.Login = false
+	role.Password = user.Password
+	role.Databases = slices.DeleteFunc(role.Databases, func(db string) bool {
+		return db == user.Database
+	})
+
+	return nil
+}
+
+// ExpireUser prevents new logins of the user
+func (p *postgreSQLProvisioner) ExpireUser(ctx context.Context, user database.User) error {
+	s := p.server
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.record(ctx, Call{Method: "ExpireUser", Database: user.Database, Username: user.Username}); err != nil {
+		return err
+	}
+
+	if role, ok := s.roles[user.Username]; ok {
+		role.Login = false
+		role.ValidUntil = user.ValidUntil
+	}
+
+	return nil
+}
+
+// TerminateSessions terminates the sessions opened by OpenSession
+func (p *postgreSQLProvisioner) TerminateSessions(ctx context.Context, user database.User) (int64, error) {
+	s := p.server
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.record(ctx, Call{Method: "TerminateSessions", Database: user.Database, Username: user.Username}); err != nil {
+		return 0, err
+	}
+
+	sessions := s.sessions[user.Username]
+	delete(s.sessions, user.Username)
+	return sessions, nil
+}
+
+// database returns a database of the server, mu must be held
+func (s *PostgreSQLServer) database(name string) (*PostgreSQLDatabase, error) {
+	d, ok := s.databases[name]
+	if !ok {
+		return nil, fmt.Errorf("database %q does not exist", name)
+	}
+
+	return d, nil
+}
+
+func postgreSQLUserOptions(user database.User) (database.PostgreSQLUserOptions, error) {
+	if user.Options == nil {
+		return database.PostgreSQLUserOptions{}, nil
+	}
+
+	opts, ok := user.Options.(database.PostgreSQLUserOptions)
+	if !ok {
+		return opts, fmt.Errorf("unexpected user options %T, expected %T", user.Options, opts)
+	}
+
+	return opts, nil
+}
+
+func equalGrants(a, b database.Grant) bool {
+	return a.Object == b.Object && a.ObjectName == b.ObjectName && a.User == b.User && slices.Equal(a.Privileges, b.Privileges)
+}
@@ -0,0 +1,94 @@
+// Package databasetest provides in-memory fakes of the PostgreSQL, MongoDB and MongoDB Atlas provisioners for tests.
+// The fakes register themselves in a database.Registry and keep the state of a server in memory,
+// failures can be injected per method and all calls are recorded.
+package databasetest
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// Connect is the method name used to inject failures into the provisioner factory of a fake
+const Connect = "Connect"
+
+// Call is a call received by a fake, passwords are never recorded
+type Call struct {
+	Method   string
+	Database string
+	Username string
+	// Name holds the extension, schema, seed, access list entry or new owner the call refers to
+	Name string
+}
+
+// recorder records calls and holds the injected failures, mu guards the state of the embedding fake as well
+type recorder struct {
+	mu       sync.Mutex
+	calls    []Call
+	failures map[string]error
+	open     int
+}
+
+// FailOn makes all subsequent calls of the method fail with err, a nil error removes the failure again
+func (r *recorder) FailOn(method string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.failures == nil {
+		r.failures = make(map[string]error)
+	}
+
+	if err == nil {
+		delete(r.failures, method)
+		return
+	}
+
+	r.failures[method] = err
+}
+
+// Calls returns all calls received so far
+func (r *recorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.calls)
+}
+
+// CallsTo returns the calls of a method
+func (r *recorder) CallsTo(method string) []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var calls []Call
+	for _, call := range r.calls {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+
+	return calls
+}
+
+// Connections returns the number of connections which were not closed yet
+func (r *recorder) Connections() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.open
+}
+
+// record records the call and returns the injected failure of the method if any, mu must be held
+func (r *recorder) record(ctx context.Context, call Call) error {
+	r.calls = append(r.calls, call)
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return r.failures[call.Method]
+}
+
+// close releases a connection
+func (r *recorder) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.open--
+}
@@ -1,25 +1,64 @@
 package database
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
+	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/doodlescheduling/db-controller/internal/tracing"
 )
 
 type MongoDBOptions struct {
 	URI              string
 	DatabaseName     string
 	AuthDatabaseName string
+	AuthMechanism    string
 	Username         string
 	Password         string
+	ReplicaSet       string
+	ReadPreference   string
+	TLSCertificate   []byte
+	TLSKey           []byte
+	TLSCA            []byte
+}
+
+// MongoDBUserOptions holds optional settings of a MongoDB user
+type MongoDBUserOptions struct {
+	// UpdatePassword sets the password of an already existing user
+	UpdatePassword             bool
+	Mechanisms                 []string
+	AuthenticationRestrictions []MongoDBAuthenticationRestriction
+	// CustomData is a JSON document
+	CustomData []byte
 }
 
+type MongoDBAuthenticationRestriction struct {
+	ClientSource  []string `json:"clientSource,omitempty" bson:"clientSource,omitempty"`
+	ServerAddress []string `json:"serverAddress,omitempty" bson:"serverAddress,omitempty"`
+}
+
+const (
+	mongoDBScheme      = "mongodb://"
+	mongoDBSRVScheme   = "mongodb+srv://"
+	mongoDBX509        = "MONGODB-X509"
+	mongoDBExternalSrc = "$external"
+)
+
 type MongoDBRoles []MongoDBRole
 type MongoDBRole struct {
 	Name string `json:"role" bson:"role"`
@@ -28,14 +67,33 @@ type MongoDBRole struct {
 
 type MongoDBUsers []MongoDBUser
 type MongoDBUser struct {
-	User  string       `json:"user" bson:"user"`
-	DB    string       `json:"db" bson:"db"`
-	Roles MongoDBRoles `json:"roles" bson:"roles"`
+	User                       string                             `json:"user" bson:"user"`
+	DB                         string                             `json:"db" bson:"db"`
+	Roles                      MongoDBRoles                       `json:"roles" bson:"roles"`
+	AuthenticationRestrictions []MongoDBAuthenticationRestriction `json:"authenticationRestrictions,omitempty" bson:"authenticationRestrictions,omitempty"`
+	CustomData                 bson.Raw                           `json:"customData,omitempty" bson:"customData,omitempty"`
+}
+
+type MongoDBSeedType string
+
+const (
+	MongoDBSeedDocuments MongoDBSeedType = "Documents"
+	MongoDBSeedCommands  MongoDBSeedType = "Commands"
+)
+
+// MongoDBSeed is a set of extended JSON documents or commands applied once to a database
+type MongoDBSeed struct {
+	ID         string
+	Type       MongoDBSeedType
+	Collection string
+	Checksum   string
+	Data       []byte
 }
 
 const (
 	adminDatabase   = "admin"
 	usersCollection = "system.users"
+	seedsCollection = "_db_controller_seeds"
 )
 
 type MongoDBRepository struct {
@@ -43,20 +101,70 @@ type MongoDBRepository struct {
 	opts   MongoDBOptions
 }
 
-func NewMongoDBRepository(ctx context.Context, opts MongoDBOptions) (*MongoDBRepository, error) {
+func NewMongoDBRepository(ctx context.Context, opts MongoDBOptions) (_ *MongoDBRepository, err error) {
+	ctx, span := startSpan(ctx, "NewMongoDBRepository", semconv.DBSystemNameMongoDB, opts.URI, tracing.Database(opts.DatabaseName), tracing.User(opts.Username))
+	defer func() { tracing.End(span, err) }()
+
 	o := options.Client()
 
 	uri := opts.URI
-	if !strings.HasPrefix(uri, "mongodb://") {
-		uri = "mongodb://" + uri
+	if !strings.HasPrefix(uri, mongoDBScheme) && !strings.HasPrefix(uri, mongoDBSRVScheme) {
+		uri = mongoDBScheme + uri
 	}
 
 	o.ApplyURI(uri)
 
-	o.SetAuth(options.Credential{
-		Username: opts.Username,
-		Password: opts.Password,
-	})
+	cred := options.Credential{}
+	if o.Auth != nil {
+		cred.AuthSource = o.Auth.AuthSource
+		cred.AuthMechanism = o.Auth.AuthMechanism
+	}
+
+	if opts.AuthDatabaseName != "" {
+		cred.AuthSource = opts.AuthDatabaseName
+	}
+
+	if opts.AuthMechanism != "" {
+		cred.AuthMechanism = opts.AuthMechanism
+	}
+
+	cred.Username = opts.Username
+	if cred.AuthMechanism == mongoDBX509 {
+		cred.AuthSource = mongoDBExternalSrc
+	} else {
+		cred.Password = opts.Password
+	}
+
+	o.SetAuth(cred)
+
+	if opts.ReplicaSet != "" {
+		o.SetReplicaSet(opts.ReplicaSet)
+	}
+
+	if opts.ReadPreference != "" {
+		mode, err := readpref.ModeFromString(opts.ReadPreference)
+		if err != nil {
+			return nil, err
+		}
+
+		rp, err := readpref.New(mode)
+		if err != nil {
+			return nil, err
+		}
+
+		o.SetReadPreference(rp)
+	}
+
+	if len(opts.TLSCA) > 0 || len(opts.TLSCertificate) > 0 {
+		tlsConfig, err := newTLSConfig(opts)
+		if err != nil {
+			return nil, err
+		}
+
+		o.SetTLSConfig(tlsConfig)
+	}
+
+	o.SetMonitor((&mongoDBTracer{}).monitor())
 
 	client, err := mongo.Connect(ctx, o)
 	if err != nil {
@@ -73,6 +181,36 @@ func NewMongoDBRepository(ctx context.Context, opts MongoDBOptions) (*MongoDBRep
 	}, nil
 }
 
+func newTLSConfig(opts MongoDBOptions) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if len(opts.TLSCA) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(opts.TLSCA) {
+			return nil, errors.New("failed to parse tls ca certificate")
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if len(opts.TLSCertificate) > 0 {
+		cert, err := tls.X509KeyPair(opts.TLSCertificate, opts.TLSKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse tls client certificate: %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+func (m *MongoDBRepository) startSpan(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return startSpan(ctx, "MongoDBRepository."+method, semconv.DBSystemNameMongoDB, m.opts.URI, attrs...)
+}
+
 func (m *MongoDBRepository) Close(ctx context.Context) error {
 	if m.client != nil {
 		return m.client.Disconnect(ctx)
@@ -81,14 +219,22 @@ func (m *MongoDBRepository) Close(ctx context.Context) error {
 	return nil
 }
 
-func (m *MongoDBRepository) SetupUser(ctx context.Context, database string, username string, password string, roles MongoDBRoles) error {
-	doesUserExist, err := m.doesUserExist(ctx, database, username)
+func (m *MongoDBRepository) SetupUser(ctx context.Context, database string, username string, password string, roles MongoDBRoles, userOpts MongoDBUserOptions) (err error) {
+	ctx, span := m.startSpan(ctx, "SetupUser", tracing.Database(database), tracing.User(username))
+	defer func() { tracing.End(span, err) }()
+
+	customData, err := parseCustomData(userOpts.CustomData)
+	if err != nil {
+		return fmt.Errorf("failed to parse custom data: %w", err)
+	}
+
+	users, err := m.getAllUsers(ctx, database, username)
 	if err != nil {
 		return err
 	}
 
-	if !doesUserExist {
-		if err := m.createUser(ctx, database, username, password, roles); err != nil {
+	if len(users) == 0 {
+		if err := m.createUser(ctx, database, username, password, roles, userOpts, customData); err != nil {
 			return err
 		}
 		if doesUserExistNow, err := m.doesUserExist(ctx, database, username); err != nil {
@@ -97,7 +243,7 @@ func (m *MongoDBRepository) SetupUser(ctx context.Context, database string, user
 			return errors.New("user doesn't exist after create")
 		}
 	} else {
-		if err := m.updateUserPasswordAndRoles(ctx, database, username, password, roles); err != nil {
+		if err := m.updateUserPasswordAndRoles(ctx, database, username, password, roles, userOpts, customData, users[0]); err != nil {
 			return err
 		}
 	}
@@ -105,7 +251,10 @@ func (m *MongoDBRepository) SetupUser(ctx context.Context, database string, user
 	return nil
 }
 
-func (m *MongoDBRepository) DropUser(ctx context.Context, database string, username string) error {
+func (m *MongoDBRepository) DropUser(ctx context.Context, database string, username string) (err error) {
+	ctx, span := m.startSpan(ctx, "DropUser", tracing.Database(database), tracing.User(username))
+	defer func() { tracing.End(span, err) }()
+
 	doesUserExist, err := m.doesUserExist(ctx, database, username)
 	if err != nil {
 		return err
@@ -123,6 +272,65 @@ func (m *MongoDBRepository) DropUser(ctx context.Context, database string, usern
 	return nil
 }
 
+// TerminateSessions kills all operations and sessions of the user and returns the number of
+// operations which were killed. Idle sessions are killed on all nodes of the deployment but can't be counted.
+func (m *MongoDBRepository) TerminateSessions(ctx context.Context, database string, username string) (_ int64, err error) {
+	ctx, span := m.startSpan(ctx, "TerminateSessions", tracing.Database(database), tracing.User(username))
+	defer func() { tracing.End(span, err) }()
+
+	user := bson.D{primitive.E{Key: "user", Value: username}, primitive.E{Key: "db", Value: database}}
+	pipeline := bson.A{
+		bson.D{primitive.E{Key: "$currentOp", Value: bson.D{
+			primitive.E{Key: "allUsers", Value: true},
+			primitive.E{Key: "idleConnections", Value: true},
+			primitive.E{Key: "idleSessions", Value: true},
+			primitive.E{Key: "idleCursors", Value: true},
+		}}},
+		bson.D{primitive.E{Key: "$match", Value: bson.D{
+			primitive.E{Key: "effectiveUsers", Value: bson.D{
+				primitive.E{Key: "$elemMatch", Value: user},
+			}},
+		}}},
+	}
+
+	cursor, err := m.client.Database(adminDatabase).Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() { _ = cursor.Close(ctx) }()
+
+	var killed int64
+	for cursor.Next(ctx) {
+		opid, err := cursor.Current.LookupErr("opid")
+		if err != nil {
+			continue
+		}
+
+		command := &bson.D{primitive.E{Key: "killOp", Value: 1}, primitive.E{Key: "op", Value: opid}}
+		if _, err := m.runCommand(ctx, adminDatabase, command).Raw(); err != nil {
+			return 0, err
+		}
+
+		killed++
+	}
+
+	if err := cursor.Err(); err != nil {
+		return 0, err
+	}
+
+	// $currentOp only reports the operations of the node the client is connected to,
+	// killAllSessionsByPattern also reaches the idle sessions on the other nodes.
+	command := &bson.D{primitive.E{Key: "killAllSessionsByPattern", Value: bson.A{
+		bson.D{primitive.E{Key: "users", Value: bson.A{user}}},
+	}}}
+	if _, err := m.runCommand(ctx, adminDatabase, command).Raw(); err != nil {
+		return 0, err
+	}
+
+	return killed, nil
+}
+
 func (m *MongoDBRepository) doesUserExist(ctx context.Context, database string, username string) (bool, error) {
 	users, err := m.getAllUsers(ctx, database, username)
 	if err != nil {
@@ -154,32 +362,42 @@ func (m *MongoDBRepository) getAllUsers(ctx context.Context, database string, us
 	return users, nil
 }
 
-func (m *MongoDBRepository) getRoles(database string, roles MongoDBRoles) []bson.M {
+func (m *MongoDBRepository) getRoles(database string, roles MongoDBRoles) MongoDBRoles {
 	// by default, assign readWrite role (backward compatibility)
 	if len(roles) == 0 {
-		return []bson.M{{
-			"role": "readWrite",
-			"db":   database,
+		return MongoDBRoles{{
+			Name: "readWrite",
+			DB:   database,
 		}}
 	}
-	rs := make([]bson.M, 0)
+	rs := make(MongoDBRoles, 0)
 	for _, r := range roles {
 		db := r.DB
 		if db == "" {
 			db = database
 		}
 
-		rs = append(rs, bson.M{
-			"role": r.Name,
-			"db":   db,
+		rs = append(rs, MongoDBRole{
+			Name: r.Name,
+			DB:   db,
 		})
 	}
 	return rs
 }
 
-func (m *MongoDBRepository) createUser(ctx context.Context, database string, username string, password string, roles MongoDBRoles) error {
+func (m *MongoDBRepository) createUser(ctx context.Context, database string, username string, password string, roles MongoDBRoles, userOpts MongoDBUserOptions, customData bson.Raw) error {
 	command := &bson.D{primitive.E{Key: "createUser", Value: username}, primitive.E{Key: "pwd", Value: password},
 		primitive.E{Key: "roles", Value: m.getRoles(database, roles)}}
+	command = m.withUserOptions(command, userOpts)
+
+	if len(userOpts.AuthenticationRestrictions) > 0 {
+		*command = append(*command, primitive.E{Key: "authenticationRestrictions", Value: userOpts.AuthenticationRestrictions})
+	}
+
+	if customData != nil {
+		*command = append(*command, primitive.E{Key: "customData", Value: customData})
+	}
+
 	r := m.runCommand(ctx, database, command)
 	if _, err := r.Raw(); err != nil {
 		return err
@@ -187,9 +405,46 @@ func (m *MongoDBRepository) createUser(ctx context.Context, database string, use
 	return nil
 }
 
-func (m *MongoDBRepository) updateUserPasswordAndRoles(ctx context.Context, database string, username string, password string, roles MongoDBRoles) error {
-	command := &bson.D{primitive.E{Key: "updateUser", Value: username}, primitive.E{Key: "pwd", Value: password},
-		primitive.E{Key: "roles", Value: m.getRoles(database, roles)}}
+func (m *MongoDBRepository) updateUserPasswordAndRoles(ctx context.Context, database string, username string, password string, roles MongoDBRoles, userOpts MongoDBUserOptions, customData bson.Raw, current MongoDBUser) error {
+	command := &bson.D{primitive.E{Key: "updateUser", Value: username}}
+
+	if userOpts.UpdatePassword {
+		*command = append(*command, primitive.E{Key: "pwd", Value: password})
+		command = m.withUserOptions(command, userOpts)
+	}
+
+	// Only send roles, authenticationRestrictions and customData if they drifted from what is stored in system.users
+	if !equalRoles(current.Roles, m.getRoles(database, roles)) {
+		*command = append(*command, primitive.E{Key: "roles", Value: m.getRoles(database, roles)})
+	}
+
+	if !equalAuthenticationRestrictions(current.AuthenticationRestrictions, userOpts.AuthenticationRestrictions) {
+		restrictions := userOpts.AuthenticationRestrictions
+		if restrictions == nil {
+			restrictions = []MongoDBAuthenticationRestriction{}
+		}
+
+		*command = append(*command, primitive.E{Key: "authenticationRestrictions", Value: restrictions})
+	}
+
+	currentCustomData := current.CustomData
+	if elements, err := currentCustomData.Elements(); err != nil || len(elements) == 0 {
+		currentCustomData = nil
+	}
+
+	if !bytes.Equal(currentCustomData, customData) {
+		if customData == nil {
+			*command = append(*command, primitive.E{Key: "customData", Value: bson.D{}})
+		} else {
+			*command = append(*command, primitive.E{Key: "customData", Value: customData})
+		}
+	}
+
+	// Nothing has changed
+	if len(*command) == 1 {
+		return nil
+	}
+
 	r := m.runCommand(ctx, database, command)
 	if _, err := r.Raw(); err != nil {
 		return err
@@ -197,6 +452,233 @@ func (m *MongoDBRepository) updateUserPasswordAndRoles(ctx context.Context, data
 	return nil
 }
 
+func (m *MongoDBRepository) withUserOptions(command *bson.D, userOpts MongoDBUserOptions) *bson.D {
+	if len(userOpts.Mechanisms) > 0 {
+		*command = append(*command, primitive.E{Key: "mechanisms", Value: userOpts.Mechanisms})
+	}
+
+	return command
+}
+
+// ApplySeed applies the seed to the database unless it was already applied before.
+// Applied seeds are recorded in a controller owned collection within the database.
+// It returns true if the seed was applied by this call.
+func (m *MongoDBRepository) ApplySeed(ctx context.Context, database string, seed MongoDBSeed) (_ bool, err error) {
+	ctx, span := m.startSpan(ctx, "ApplySeed", tracing.Database(database))
+	defer func() { tracing.End(span, err) }()
+
+	isApplied, err := m.isSeedApplied(ctx, database, seed.ID)
+	if err != nil {
+		return false, err
+	}
+
+	if isApplied {
+		return false, nil
+	}
+
+	docs, err := parseExtJSON(seed.Data)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse seed %s: %w", seed.ID, err)
+	}
+
+	switch seed.Type {
+	case MongoDBSeedDocuments:
+		if seed.Collection == "" {
+			return false, fmt.Errorf("no collection defined for seed %s", seed.ID)
+		}
+
+		if len(docs) > 0 {
+			var insert []interface{}
+			for _, doc := range docs {
+				insert = append(insert, doc)
+			}
+
+			_, err := m.client.Database(database).Collection(seed.Collection).InsertMany(ctx, insert)
+			// The documents are not recorded, they may hold arbitrary data
+			recordCommand(ctx, m.opts.URI, database, bson.D{
+				primitive.E{Key: "insert", Value: seed.Collection},
+				primitive.E{Key: "seed", Value: seed.ID},
+				primitive.E{Key: "documents", Value: len(insert)},
+			}, err)
+
+			if err != nil {
+				return false, err
+			}
+		}
+	case MongoDBSeedCommands, "":
+		for _, doc := range docs {
+			err := m.client.Database(database).RunCommand(ctx, doc).Err()
+			recordSeedCommand(ctx, m.opts.URI, database, seed.ID, doc, err)
+
+			if err != nil {
+				return false, err
+			}
+		}
+	default:
+		return false, fmt.Errorf("unsupported seed type %q", seed.Type)
+	}
+
+	return true, m.markSeedApplied(ctx, database, seed)
+}
+
+func (m *MongoDBRepository) isSeedApplied(ctx context.Context, database string, id string) (bool, error) {
+	collection := m.client.Database(database).Collection(seedsCollection)
+	count, err := collection.CountDocuments(ctx, bson.D{primitive.E{Key: "_id", Value: id}})
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (m *MongoDBRepository) markSeedApplied(ctx context.Context, database string, seed MongoDBSeed) error {
+	doc := bson.D{
+		primitive.E{Key: "_id", Value: seed.ID},
+		primitive.E{Key: "checksum", Value: seed.Checksum},
+		primitive.E{Key: "appliedAt", Value: time.Now().UTC()},
+	}
+
+	collection := m.client.Database(database).Collection(seedsCollection)
+	_, err := collection.InsertOne(ctx, doc)
+	recordCommand(ctx, m.opts.URI, database, bson.D{
+		primitive.E{Key: "insert", Value: seedsCollection},
+		primitive.E{Key: "documents", Value: bson.A{doc}},
+	}, err)
+
+	return err
+}
+
+// parseCustomData converts the JSON document into bson, an empty document is treated as no custom data
+func parseCustomData(data []byte) (bson.Raw, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var doc bson.D
+	if err := bson.UnmarshalExtJSON(data, false, &doc); err != nil {
+		return nil, err
+	}
+
+	if len(doc) == 0 {
+		return nil, nil
+	}
+
+	return bson.Marshal(doc)
+}
+
+func equalAuthenticationRestrictions(a, b []MongoDBAuthenticationRestriction) bool {
+	return slices.EqualFunc(a, b, func(x, y MongoDBAuthenticationRestriction) bool {
+		return slices.Equal(x.ClientSource, y.ClientSource) && slices.Equal(x.ServerAddress, y.ServerAddress)
+	})
+}
+
+// equalRoles compares two sets of roles regardless of their order and duplicates
+func equalRoles(a, b MongoDBRoles) bool {
+	normalize := func(roles MongoDBRoles) MongoDBRoles {
+		roles = slices.SortedFunc(slices.Values(roles), func(x, y MongoDBRole) int {
+			return strings.Compare(x.DB+"."+x.Name, y.DB+"."+y.Name)
+		})
+
+		return slices.Compact(roles)
+	}
+
+	return slices.Equal(normalize(a), normalize(b))
+}
+
+// parseExtJSON parses either a single extended JSON document or an array of documents
+func parseExtJSON(data []byte) ([]bson.D, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	if data[0] == '[' {
+		var docs []bson.D
+		if err := bson.UnmarshalExtJSON(data, false, &docs); err != nil {
+			return nil, err
+		}
+
+		return docs, nil
+	}
+
+	var doc bson.D
+	if err := bson.UnmarshalExtJSON(data, false, &doc); err != nil {
+		return nil, err
+	}
+
+	return []bson.D{doc}, nil
+}
+
+// runCommand runs the command and records it in the audit log
 func (m *MongoDBRepository) runCommand(ctx context.Context, database string, command *bson.D) *mongo.SingleResult {
-	return m.client.Database(database).RunCommand(ctx, *command)
+	r := m.client.Database(database).RunCommand(ctx, *command)
+	recordCommand(ctx, m.opts.URI, database, *command, r.Err())
+	return r
+}
+
+// MongoDBSeeder applies seeds to a database
+type MongoDBSeeder interface {
+	Provisioner
+	ApplySeed(ctx context.Context, database string, seed MongoDBSeed) (bool, error)
+}
+
+// mongoDBProvisioner adapts the MongoDBRepository to the engine neutral provisioner interfaces
+type mongoDBProvisioner struct {
+	*MongoDBRepository
+}
+
+func newMongoDBProvisioner(ctx context.Context, opts ProvisionerOptions) (Provisioner, error) {
+	repository, err := NewMongoDBRepository(ctx, MongoDBOptions{
+		URI:              opts.URI,
+		DatabaseName:     opts.DatabaseName,
+		AuthDatabaseName: opts.AuthDatabaseName,
+		AuthMechanism:    opts.AuthMechanism,
+		Username:         opts.Username,
+		Password:         opts.Password,
+		ReplicaSet:       opts.ReplicaSet,
+		ReadPreference:   opts.ReadPreference,
+		TLSCertificate:   opts.TLSCertificate,
+		TLSKey:           opts.TLSKey,
+		TLSCA:            opts.TLSCA,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &mongoDBProvisioner{repository}, nil
+}
+
+// CreateDatabaseIfNotExists is a no-op since MongoDB creates databases implicitly
+func (p *mongoDBProvisioner) CreateDatabaseIfNotExists(ctx context.Context, database string) error {
+	return nil
+}
+
+func (p *mongoDBProvisioner) SetupUser(ctx context.Context, user User) error {
+	opts, err := userOptions[MongoDBUserOptions](user)
+	if err != nil {
+		return err
+	}
+
+	return p.MongoDBRepository.SetupUser(ctx, user.Database, user.Username, user.Password, mongoDBRoles(user.Roles), opts)
+}
+
+func (p *mongoDBProvisioner) DropUser(ctx context.Context, user User) error {
+	return p.MongoDBRepository.DropUser(ctx, user.Database, user.Username)
+}
+
+func (p *mongoDBProvisioner) TerminateSessions(ctx context.Context, user User) (int64, error) {
+	return p.MongoDBRepository.TerminateSessions(ctx, user.Database, user.Username)
+}
+
+func mongoDBRoles(roles []Role) MongoDBRoles {
+	list := make(MongoDBRoles, 0, len(roles))
+	for _, role := range roles {
+		list = append(list, MongoDBRole{
+			Name: role.Name,
+			DB:   role.Database,
+		})
+	}
+
+	return list
 }
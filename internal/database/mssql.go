@@ -0,0 +1,652 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/microsoft/go-mssqldb"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/doodlescheduling/db-controller/internal/tracing"
+)
+
+type MSSQLOptions struct {
+	URI      string
+	Username string
+	Password string
+}
+
+type MSSQLRepository struct {
+	db      *sql.DB
+	address string
+}
+
+// MSSQLUser is a server login mapped to a user of the same name in the database
+type MSSQLUser struct {
+	Database      string
+	Username      string
+	Password      string
+	DefaultSchema string
+	Roles         []string
+	CheckPolicy   bool
+}
+
+var mssqlCollationPattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+func NewMSSQLRepository(ctx context.Context, opts MSSQLOptions) (_ *MSSQLRepository, err error) {
+	ctx, span := startSpan(ctx, "NewMSSQLRepository", semconv.DBSystemNameMicrosoftSQLServer, opts.URI, tracing.User(opts.Username))
+	defer func() { tracing.End(span, err) }()
+
+	uri := opts.URI
+	if !strings.Contains(uri, "://") {
+		uri = "sqlserver://" + uri
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	u.Scheme = "sqlserver"
+	u.User = url.UserPassword(opts.Username, opts.Password)
+
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) > 0 {
+		q := u.Query()
+		q.Set("dial timeout", strconv.Itoa(int(math.Ceil(time.Until(deadline).Seconds()))))
+		u.RawQuery = q.Encode()
+	}
+
+	db, err := sql.Open("sqlserver", u.String())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &MSSQLRepository{
+		db:      db,
+		address: opts.URI,
+	}, nil
+}
+
+func (m *MSSQLRepository) startSpan(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return startSpan(ctx, "MSSQLRepository."+method, semconv.DBSystemNameMicrosoftSQLServer, m.address, attrs...)
+}
+
+func (m *MSSQLRepository) Close(ctx context.Context) error {
+	if m.db != nil {
+		return m.db.Close()
+	}
+
+	return nil
+}
+
+// CreateDatabaseIfNotExists creates the database, the collation is only applied if the database gets created
+func (m *MSSQLRepository) CreateDatabaseIfNotExists(ctx context.Context, database, collation string) (err error) {
+	ctx, span := m.startSpan(ctx, "CreateDatabaseIfNotExists", tracing.Database(database))
+	defer func() { tracing.End(span, err) }()
+
+	if databaseExists, err := m.doesDatabaseExist(ctx, database); err != nil {
+		return err
+	} else if databaseExists {
+		return nil
+	}
+
+	stmt := fmt.Sprintf("CREATE DATABASE %s", quoteMSSQLIdentifier(database))
+	if collation != "" {
+		if !mssqlCollationPattern.MatchString(collation) {
+			return fmt.Errorf("invalid collation %q", collation)
+		}
+
+		stmt += " COLLATE " + collation
+	}
+
+	if _, err := execContext(ctx, m.db, stmt+";"); err != nil {
+		return err
+	}
+
+	if databaseExists, err := m.doesDatabaseExist(ctx, database); err != nil {
+		return err
+	} else if !databaseExists {
+		return errors.New("database doesn't exist after create")
+	}
+
+	return nil
+}
+
+// SetupUser creates or updates the login, maps it to the database user and syncs the role memberships
+func (m *MSSQLRepository) SetupUser(ctx context.Context, user MSSQLUser) (err error) {
+	ctx, span := m.startSpan(ctx, "SetupUser", tracing.Database(user.Database), tracing.User(user.Username))
+	defer func() { tracing.End(span, err) }()
+
+	if err := m.createOrUpdateLogin(ctx, user); err != nil {
+		return fmt.Errorf("failed to create login: %w", err)
+	}
+
+	conn, err := m.useDatabase(ctx, user.Database)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = conn.Close() }()
+
+	if err := m.createOrUpdateDatabaseUser(ctx, conn, user); err != nil {
+		return fmt.Errorf("failed to create database user: %w", err)
+	}
+	if err := m.syncRoles(ctx, conn, user); err != nil {
+		return fmt.Errorf("failed to apply roles: %w", err)
+	}
+	return nil
+}
+
+// ExpireUser revokes the connect permission of the database user
+func (m *MSSQLRepository) ExpireUser(ctx context.Context, user MSSQLUser) (err error) {
+	ctx, span := m.startSpan(ctx, "ExpireUser", tracing.Database(user.Database), tracing.User(user.Username))
+	defer func() { tracing.End(span, err) }()
+
+	conn, err := m.useDatabase(ctx, user.Database)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = conn.Close() }()
+
+	if userExists, err := m.doesDatabaseUserExist(ctx, conn, user); err != nil {
+		return err
+	} else if !userExists {
+		return nil
+	}
+
+	_, err = execContext(ctx, conn, fmt.Sprintf("REVOKE CONNECT FROM %s;", quoteMSSQLIdentifier(user.Username)))
+	return err
+}
+
+// DisableUser removes all role memberships and revokes the connect permission of the database user.
+// The login is disabled if it is not mapped to a user in any other database.
+func (m *MSSQLRepository) DisableUser(ctx context.Context, user MSSQLUser) (err error) {
+	ctx, span := m.startSpan(ctx, "DisableUser", tracing.Database(user.Database), tracing.User(user.Username))
+	defer func() { tracing.End(span, err) }()
+
+	// The database user is gone if the database was dropped before, only the login is left
+	if exists, err := m.doesDatabaseExist(ctx, user.Database); err != nil {
+		return err
+	} else if exists {
+		if err := m.disableDatabaseUser(ctx, user); err != nil {
+			return err
+		}
+	}
+
+	if mapped, err := m.isLoginMappedElsewhere(ctx, user); err != nil {
+		return err
+	} else if mapped {
+		return nil
+	}
+
+	if loginExists, err := m.doesLoginExist(ctx, user); err != nil {
+		return err
+	} else if !loginExists {
+		return nil
+	}
+
+	_, err = execContext(ctx, m.db, fmt.Sprintf("ALTER LOGIN %s DISABLE;", quoteMSSQLIdentifier(user.Username)))
+	return err
+}
+
+// DropUser drops the database user.
+// The login is dropped if it is not mapped to a user in any other database.
+func (m *MSSQLRepository) DropUser(ctx context.Context, user MSSQLUser) (err error) {
+	ctx, span := m.startSpan(ctx, "DropUser", tracing.Database(user.Database), tracing.User(user.Username))
+	defer func() { tracing.End(span, err) }()
+
+	// The database user is gone if the database was dropped before, which is normal if both resources
+	// are deleted together. The login is cleaned up regardless.
+	if exists, err := m.doesDatabaseExist(ctx, user.Database); err != nil {
+		return err
+	} else if exists {
+		if err := m.dropDatabaseUser(ctx, user); err != nil {
+			return err
+		}
+	}
+
+	if mapped, err := m.isLoginMappedElsewhere(ctx, user); err != nil {
+		return err
+	} else if mapped {
+		return nil
+	}
+
+	if loginExists, err := m.doesLoginExist(ctx, user); err != nil {
+		return err
+	} else if !loginExists {
+		return nil
+	}
+
+	if _, err := execContext(ctx, m.db, fmt.Sprintf("DROP LOGIN %s;", quoteMSSQLIdentifier(user.Username))); err != nil {
+		return err
+	}
+
+	if loginExists, err := m.doesLoginExist(ctx, user); err != nil {
+		return err
+	} else if loginExists {
+		return errors.New("login still exists after drop")
+	}
+
+	return nil
+}
+
+// TerminateSessions kills all sessions of the login within the database and returns the number of killed sessions
+func (m *MSSQLRepository) TerminateSessions(ctx context.Context, user MSSQLUser) (_ int64, err error) {
+	ctx, span := m.startSpan(ctx, "TerminateSessions", tracing.Database(user.Database), tracing.User(user.Username))
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := m.db.QueryContext(ctx, "SELECT session_id FROM sys.dm_exec_sessions WHERE login_name = @p1 AND database_id = DB_ID(@p2) AND session_id <> @@SPID", user.Username, user.Database)
+	if err != nil {
+		return 0, err
+	}
+
+	var sessions []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			_ = rows.Close()
+			return 0, err
+		}
+
+		sessions = append(sessions, id)
+	}
+
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	var terminated int64
+	for _, id := range sessions {
+		// KILL does not accept parameters, the session id is an integer read from the server
+		if _, err := execContext(ctx, m.db, fmt.Sprintf("KILL %d;", id)); err != nil {
+			return terminated, err
+		}
+
+		terminated++
+	}
+
+	return terminated, nil
+}
+
+// TODO Prepared Statements
+func (m *MSSQLRepository) createOrUpdateLogin(ctx context.Context, user MSSQLUser) error {
+	checkPolicy := "OFF"
+	if user.CheckPolicy {
+		checkPolicy = "ON"
+	}
+
+	loginExists, err := m.doesLoginExist(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	if loginExists {
+		if _, err := execContext(ctx, m.db, fmt.Sprintf("ALTER LOGIN %s WITH PASSWORD = %s, CHECK_POLICY = %s;", quoteMSSQLIdentifier(user.Username), quoteMSSQLString(user.Password), checkPolicy)); err != nil {
+			return err
+		}
+
+		_, err := execContext(ctx, m.db, fmt.Sprintf("ALTER LOGIN %s ENABLE;", quoteMSSQLIdentifier(user.Username)))
+		return err
+	}
+
+	if _, err := execContext(ctx, m.db, fmt.Sprintf("CREATE LOGIN %s WITH PASSWORD = %s, DEFAULT_DATABASE = %s, CHECK_POLICY = %s;", quoteMSSQLIdentifier(user.Username), quoteMSSQLString(user.Password), quoteMSSQLIdentifier(user.Database), checkPolicy)); err != nil {
+		return err
+	}
+
+	if loginExistsNow, err := m.doesLoginExist(ctx, user); err != nil {
+		return err
+	} else if !loginExistsNow {
+		return errors.New("login doesn't exist after create")
+	}
+
+	return nil
+}
+
+// createOrUpdateDatabaseUser maps the login to the database user, an existing user is remapped in case the login was recreated
+func (m *MSSQLRepository) createOrUpdateDatabaseUser(ctx context.Context, conn *sql.Conn, user MSSQLUser) error {
+	userExists, err := m.doesDatabaseUserExist(ctx, conn, user)
+	if err != nil {
+		return err
+	}
+
+	if userExists {
+		if _, err := execContext(ctx, conn, fmt.Sprintf("ALTER USER %s WITH LOGIN = %s, DEFAULT_SCHEMA = %s;", quoteMSSQLIdentifier(user.Username), quoteMSSQLIdentifier(user.Username), quoteMSSQLIdentifier(user.DefaultSchema))); err != nil {
+			return err
+		}
+	} else if _, err := execContext(ctx, conn, fmt.Sprintf("CREATE USER %s FOR LOGIN %s WITH DEFAULT_SCHEMA = %s;", quoteMSSQLIdentifier(user.Username), quoteMSSQLIdentifier(user.Username), quoteMSSQLIdentifier(user.DefaultSchema))); err != nil {
+		return err
+	}
+
+	// The connect permission is revoked while the user is expired or disabled
+	_, err = execContext(ctx, conn, fmt.Sprintf("GRANT CONNECT TO %s;", quoteMSSQLIdentifier(user.Username)))
+	return err
+}
+
+// syncRoles adds the database user to user.Roles and removes it from all other database roles
+func (m *MSSQLRepository) syncRoles(ctx context.Context, conn *sql.Conn, user MSSQLUser) error {
+	current, err := m.getRoles(ctx, conn, user)
+	if err != nil {
+		return fmt.Errorf("failed to lookup roles: %w", err)
+	}
+
+	for _, role := range current {
+		if slices.Contains(user.Roles, role) {
+			continue
+		}
+
+		if _, err := execContext(ctx, conn, fmt.Sprintf("ALTER ROLE %s DROP MEMBER %s;", quoteMSSQLIdentifier(role), quoteMSSQLIdentifier(user.Username))); err != nil {
+			return err
+		}
+	}
+
+	for _, role := range user.Roles {
+		if slices.Contains(current, role) {
+			continue
+		}
+
+		var count int64
+		if err := conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM sys.database_principals WHERE name = @p1 AND type = 'R'", role).Scan(&count); err != nil {
+			return err
+		}
+
+		if count == 0 {
+			return fmt.Errorf("role %q does not exist", role)
+		}
+
+		if _, err := execContext(ctx, conn, fmt.Sprintf("ALTER ROLE %s ADD MEMBER %s;", quoteMSSQLIdentifier(role), quoteMSSQLIdentifier(user.Username))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *MSSQLRepository) getRoles(ctx context.Context, conn *sql.Conn, user MSSQLUser) ([]string, error) {
+	var roles []string
+
+	rows, err := conn.QueryContext(ctx, `SELECT r.name FROM sys.database_role_members rm
+JOIN sys.database_principals r ON rm.role_principal_id = r.principal_id
+JOIN sys.database_principals u ON rm.member_principal_id = u.principal_id
+WHERE u.name = @p1`, user.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, err
+		}
+
+		roles = append(roles, role)
+	}
+
+	return roles, rows.Err()
+}
+
+// disableDatabaseUser removes all role memberships and revokes the connect permission of the database user
+func (m *MSSQLRepository) disableDatabaseUser(ctx context.Context, user MSSQLUser) error {
+	conn, err := m.useDatabase(ctx, user.Database)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = conn.Close() }()
+
+	userExists, err := m.doesDatabaseUserExist(ctx, conn, user)
+	if err != nil {
+		return err
+	}
+
+	if userExists {
+		user.Roles = nil
+		if err := m.syncRoles(ctx, conn, user); err != nil {
+			return fmt.Errorf("failed to revoke roles: %w", err)
+		}
+
+		if _, err := execContext(ctx, conn, fmt.Sprintf("REVOKE CONNECT FROM %s;", quoteMSSQLIdentifier(user.Username))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dropDatabaseUser drops the database user if it exists
+func (m *MSSQLRepository) dropDatabaseUser(ctx context.Context, user MSSQLUser) error {
+	conn, err := m.useDatabase(ctx, user.Database)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = conn.Close() }()
+
+	_, err = execContext(ctx, conn, fmt.Sprintf("DROP USER IF EXISTS %s;", quoteMSSQLIdentifier(user.Username)))
+	return err
+}
+
+// isLoginMappedElsewhere checks whether the login is mapped to a user in any other online database
+func (m *MSSQLRepository) isLoginMappedElsewhere(ctx context.Context, user MSSQLUser) (bool, error) {
+	var sid []byte
+	err := m.db.QueryRowContext(ctx, "SELECT sid FROM sys.server_principals WHERE name = @p1", user.Username).Scan(&sid)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := m.db.QueryContext(ctx, "SELECT name FROM sys.databases WHERE state = 0 AND name <> @p1", user.Database)
+	if err != nil {
+		return false, err
+	}
+
+	var databases []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			_ = rows.Close()
+			return false, err
+		}
+
+		databases = append(databases, name)
+	}
+
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+
+	for _, database := range databases {
+		var count int64
+		if err := m.db.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s.sys.database_principals WHERE sid = @p1", quoteMSSQLIdentifier(database)), sid).Scan(&count); err != nil {
+			return false, err
+		}
+
+		if count > 0 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// useDatabase returns a dedicated connection switched to the database, database users are scoped to it
+func (m *MSSQLRepository) useDatabase(ctx context.Context, database string) (*sql.Conn, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := execContext(ctx, conn, fmt.Sprintf("USE %s;", quoteMSSQLIdentifier(database))); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+func (m *MSSQLRepository) doesDatabaseExist(ctx context.Context, database string) (bool, error) {
+	var result int64
+	err := m.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sys.databases WHERE name = @p1", database).Scan(&result)
+	if err != nil {
+		return false, err
+	}
+	return result == 1, nil
+}
+
+func (m *MSSQLRepository) doesLoginExist(ctx context.Context, user MSSQLUser) (bool, error) {
+	var result int64
+	err := m.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sys.server_principals WHERE name = @p1 AND type = 'S'", user.Username).Scan(&result)
+	if err != nil {
+		return false, err
+	}
+	return result == 1, nil
+}
+
+func (m *MSSQLRepository) doesDatabaseUserExist(ctx context.Context, conn *sql.Conn, user MSSQLUser) (bool, error) {
+	var result int64
+	err := conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM sys.database_principals WHERE name = @p1 AND type = 'S'", user.Username).Scan(&result)
+	if err != nil {
+		return false, err
+	}
+	return result == 1, nil
+}
+
+func quoteMSSQLIdentifier(name string) string {
+	return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+}
+
+func quoteMSSQLString(value string) string {
+	return "N'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// MSSQLUserOptions holds the SQL Server specific settings of a User
+type MSSQLUserOptions struct {
+	DefaultSchema string
+	CheckPolicy   bool
+}
+
+// MSSQLDatabaseOptions holds the settings a SQL Server database is created with
+type MSSQLDatabaseOptions struct {
+	Collation string
+}
+
+// MSSQLDatabaseProvisioner creates databases with a collation
+type MSSQLDatabaseProvisioner interface {
+	DatabaseProvisioner
+	CreateDatabaseWithOptions(ctx context.Context, database string, opts MSSQLDatabaseOptions) error
+}
+
+// mssqlProvisioner adapts the MSSQLRepository to the engine neutral provisioner interfaces
+type mssqlProvisioner struct {
+	*MSSQLRepository
+}
+
+func newMSSQLProvisioner(ctx context.Context, opts ProvisionerOptions) (Provisioner, error) {
+	repository, err := NewMSSQLRepository(ctx, MSSQLOptions{
+		URI:      opts.URI,
+		Username: opts.Username,
+		Password: opts.Password,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &mssqlProvisioner{repository}, nil
+}
+
+// CreateDatabaseIfNotExists creates the database using the server default collation
+func (p *mssqlProvisioner) CreateDatabaseIfNotExists(ctx context.Context, database string) error {
+	return p.MSSQLRepository.CreateDatabaseIfNotExists(ctx, database, "")
+}
+
+func (p *mssqlProvisioner) CreateDatabaseWithOptions(ctx context.Context, database string, opts MSSQLDatabaseOptions) error {
+	return p.MSSQLRepository.CreateDatabaseIfNotExists(ctx, database, opts.Collation)
+}
+
+func (p *mssqlProvisioner) SetupUser(ctx context.Context, user User) error {
+	msUser, err := mssqlUser(user)
+	if err != nil {
+		return err
+	}
+
+	return p.MSSQLRepository.SetupUser(ctx, msUser)
+}
+
+func (p *mssqlProvisioner) DropUser(ctx context.Context, user User) error {
+	msUser, err := mssqlUser(user)
+	if err != nil {
+		return err
+	}
+
+	return p.MSSQLRepository.DropUser(ctx, msUser)
+}
+
+func (p *mssqlProvisioner) DisableUser(ctx context.Context, user User) error {
+	msUser, err := mssqlUser(user)
+	if err != nil {
+		return err
+	}
+
+	return p.MSSQLRepository.DisableUser(ctx, msUser)
+}
+
+func (p *mssqlProvisioner) ExpireUser(ctx context.Context, user User) error {
+	msUser, err := mssqlUser(user)
+	if err != nil {
+		return err
+	}
+
+	return p.MSSQLRepository.ExpireUser(ctx, msUser)
+}
+
+func (p *mssqlProvisioner) TerminateSessions(ctx context.Context, user User) (int64, error) {
+	msUser, err := mssqlUser(user)
+	if err != nil {
+		return 0, err
+	}
+
+	return p.MSSQLRepository.TerminateSessions(ctx, msUser)
+}
+
+func mssqlUser(user User) (MSSQLUser, error) {
+	opts, err := userOptions[MSSQLUserOptions](user)
+	if err != nil {
+		return MSSQLUser{}, err
+	}
+
+	msUser := MSSQLUser{
+		Database:      user.Database,
+		Username:      user.Username,
+		Password:      user.Password,
+		DefaultSchema: opts.DefaultSchema,
+		CheckPolicy:   opts.CheckPolicy,
+	}
+
+	for _, role := range user.Roles {
+		msUser.Roles = append(msUser.Roles, role.Name)
+	}
+
+	return msUser, nil
+}
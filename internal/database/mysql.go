@@ -0,0 +1,667 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/doodlescheduling/db-controller/internal/tracing"
+)
+
+type MySQLOptions struct {
+	URI          string
+	DatabaseName string
+	Username     string
+	Password     string
+}
+
+type MySQLRepository struct {
+	db      *sql.DB
+	opts    MySQLOptions
+	mariaDB bool
+}
+
+// MySQLAllPrivileges grants all privileges available at the given level
+const MySQLAllPrivileges = "ALL"
+
+func NewMySQLRepository(ctx context.Context, opts MySQLOptions) (_ *MySQLRepository, err error) {
+	ctx, span := startSpan(ctx, "NewMySQLRepository", semconv.DBSystemNameMySQL, opts.URI, tracing.Database(opts.DatabaseName), tracing.User(opts.Username))
+	defer func() { tracing.End(span, err) }()
+
+	addr := opts.URI
+	addr = strings.TrimPrefix(addr, "mysql://")
+	addr = strings.TrimPrefix(addr, "mariadb://")
+	addr, _, _ = strings.Cut(addr, "/")
+
+	cfg := mysql.NewConfig()
+	cfg.Net = "tcp"
+	cfg.Addr = addr
+	cfg.User = opts.Username
+	cfg.Passwd = opts.Password
+	cfg.DBName = opts.DatabaseName
+
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) > 0 {
+		cfg.Timeout = time.Until(deadline)
+	}
+
+	connector, err := mysql.NewConnector(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	db := sql.OpenDB(connector)
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	var version string
+	if err := db.QueryRowContext(ctx, "SELECT VERSION();").Scan(&version); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &MySQLRepository{
+		db:      db,
+		opts:    opts,
+		mariaDB: strings.Contains(strings.ToLower(version), "mariadb"),
+	}, nil
+}
+
+func (s *MySQLRepository) startSpan(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return startSpan(ctx, "MySQLRepository."+method, semconv.DBSystemNameMySQL, s.opts.URI, attrs...)
+}
+
+func (s *MySQLRepository) Close(ctx context.Context) error {
+	if s.db != nil {
+		return s.db.Close()
+	}
+
+	return nil
+}
+
+type MySQLUser struct {
+	Database   string
+	Username   string
+	Password   string
+	Host       string
+	AuthPlugin string
+	Grants     []MySQLGrant
+	Limits     MySQLResourceLimits
+}
+
+type MySQLGrant struct {
+	Table      string
+	Privileges []Privilege
+}
+
+type MySQLResourceLimits struct {
+	MaxQueriesPerHour     int64
+	MaxUpdatesPerHour     int64
+	MaxConnectionsPerHour int64
+	MaxUserConnections    int64
+}
+
+var validMySQLPrivileges = []string{
+	"ALL",
+	"ALL PRIVILEGES",
+	"ALTER",
+	"ALTER ROUTINE",
+	"CREATE",
+	"CREATE ROUTINE",
+	"CREATE TEMPORARY TABLES",
+	"CREATE VIEW",
+	"DELETE",
+	"DELETE HISTORY",
+	"DROP",
+	"EVENT",
+	"EXECUTE",
+	"INDEX",
+	"INSERT",
+	"LOCK TABLES",
+	"REFERENCES",
+	"SELECT",
+	"SHOW VIEW",
+	"TRIGGER",
+	"UPDATE",
+}
+
+var mysqlKeywordPattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// CreateDatabaseIfNotExists creates the database and applies the character set and collation if given
+func (s *MySQLRepository) CreateDatabaseIfNotExists(ctx context.Context, database, characterSet, collation string) (err error) {
+	ctx, span := s.startSpan(ctx, "CreateDatabaseIfNotExists", tracing.Database(database))
+	defer func() { tracing.End(span, err) }()
+
+	options, err := databaseOptions(characterSet, collation)
+	if err != nil {
+		return err
+	}
+
+	if _, err := execContext(ctx, s.db, fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s%s;", quoteMySQLIdentifier(database), options)); err != nil {
+		return err
+	}
+
+	if databaseExists, err := s.doesDatabaseExist(ctx, database); err != nil {
+		return err
+	} else if !databaseExists {
+		return errors.New("database doesn't exist after create")
+	}
+
+	if options == "" {
+		return nil
+	}
+
+	_, err = execContext(ctx, s.db, fmt.Sprintf("ALTER DATABASE %s%s;", quoteMySQLIdentifier(database), options))
+	return err
+}
+
+func databaseOptions(characterSet, collation string) (string, error) {
+	var options string
+	if characterSet != "" {
+		if !mysqlKeywordPattern.MatchString(characterSet) {
+			return "", fmt.Errorf("invalid character set %q", characterSet)
+		}
+
+		options += " CHARACTER SET " + characterSet
+	}
+
+	if collation != "" {
+		if !mysqlKeywordPattern.MatchString(collation) {
+			return "", fmt.Errorf("invalid collation %q", collation)
+		}
+
+		options += " COLLATE " + collation
+	}
+
+	return options, nil
+}
+
+func (s *MySQLRepository) SetupUser(ctx context.Context, user MySQLUser) (err error) {
+	ctx, span := s.startSpan(ctx, "SetupUser", tracing.Database(user.Database), tracing.User(user.Username))
+	defer func() { tracing.End(span, err) }()
+
+	if err := s.createOrUpdateUser(ctx, user); err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+	if err := s.setResourceLimits(ctx, user); err != nil {
+		return fmt.Errorf("failed to set resource limits: %w", err)
+	}
+	if err := s.setAccountLock(ctx, user, false); err != nil {
+		return fmt.Errorf("failed to unlock user: %w", err)
+	}
+	if err := s.syncGrants(ctx, user); err != nil {
+		return fmt.Errorf("failed to apply grants: %w", err)
+	}
+	return nil
+}
+
+// RenameUser moves an existing user to another host pattern, nothing happens if the user does not exist
+func (s *MySQLRepository) RenameUser(ctx context.Context, user MySQLUser, host string) (err error) {
+	ctx, span := s.startSpan(ctx, "RenameUser", tracing.Database(user.Database), tracing.User(user.Username))
+	defer func() { tracing.End(span, err) }()
+
+	from := user
+	from.Host = host
+
+	if userExists, err := s.doesUserExist(ctx, from); err != nil {
+		return err
+	} else if !userExists {
+		return nil
+	}
+
+	_, err = execContext(ctx, s.db, fmt.Sprintf("RENAME USER %s TO %s;", mysqlAccount(from), mysqlAccount(user)))
+	return err
+}
+
+// ExpireUser locks the account which prevents any new logins.
+// Existing sessions are not affected, see TerminateSessions.
+func (s *MySQLRepository) ExpireUser(ctx context.Context, user MySQLUser) (err error) {
+	ctx, span := s.startSpan(ctx, "ExpireUser", tracing.Database(user.Database), tracing.User(user.Username))
+	defer func() { tracing.End(span, err) }()
+
+	if userExists, err := s.doesUserExist(ctx, user); err != nil {
+		return err
+	} else if !userExists {
+		return nil
+	}
+
+	return s.setAccountLock(ctx, user, true)
+}
+
+// DisableUser randomizes the password, revokes all privileges on the database and locks the account
+func (s *MySQLRepository) DisableUser(ctx context.Context, user MySQLUser) (err error) {
+	ctx, span := s.startSpan(ctx, "DisableUser", tracing.Database(user.Database), tracing.User(user.Username))
+	defer func() { tracing.End(span, err) }()
+
+	if userExists, err := s.doesUserExist(ctx, user); err != nil {
+		return err
+	} else if !userExists {
+		return nil
+	}
+
+	if err := s.createOrUpdateUser(ctx, user); err != nil {
+		return fmt.Errorf("failed to set password: %w", err)
+	}
+
+	if err := s.RevokeAllPrivileges(ctx, user); err != nil {
+		return fmt.Errorf("failed to revoke privileges: %w", err)
+	}
+
+	return s.setAccountLock(ctx, user, true)
+}
+
+// RevokeAllPrivileges revokes all privileges of the user on the database and its tables
+func (s *MySQLRepository) RevokeAllPrivileges(ctx context.Context, user MySQLUser) (err error) {
+	ctx, span := s.startSpan(ctx, "RevokeAllPrivileges", tracing.Database(user.Database), tracing.User(user.Username))
+	defer func() { tracing.End(span, err) }()
+
+	user.Grants = nil
+	return s.syncGrants(ctx, user)
+}
+
+// TerminateSessions terminates all active sessions of the user and returns the number of terminated sessions
+func (s *MySQLRepository) TerminateSessions(ctx context.Context, user MySQLUser) (_ int64, err error) {
+	ctx, span := s.startSpan(ctx, "TerminateSessions", tracing.Database(user.Database), tracing.User(user.Username))
+	defer func() { tracing.End(span, err) }()
+
+	// Sessions of accounts with the same name but another host pattern are not affected.
+	// HOST holds host:port for TCP connections, the port is stripped before matching the pattern.
+	rows, err := s.db.QueryContext(ctx, "SELECT ID FROM information_schema.PROCESSLIST WHERE USER=? AND REGEXP_REPLACE(HOST, ':[0-9]+$', '') LIKE ? AND ID <> CONNECTION_ID();", user.Username, user.Host)
+	if err != nil {
+		return 0, err
+	}
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			_ = rows.Close()
+			return 0, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err := rows.Close(); err != nil {
+		return 0, err
+	}
+
+	var result int64
+	for _, id := range ids {
+		if _, err := execContext(ctx, s.db, fmt.Sprintf("KILL %d;", id)); err != nil {
+			var mysqlErr *mysql.MySQLError
+			// The session has ended in the meantime
+			if errors.As(err, &mysqlErr) && mysqlErr.Number == 1094 {
+				continue
+			}
+
+			return result, err
+		}
+
+		result++
+	}
+
+	return result, nil
+}
+
+func (s *MySQLRepository) DropUser(ctx context.Context, user MySQLUser) (err error) {
+	ctx, span := s.startSpan(ctx, "DropUser", tracing.Database(user.Database), tracing.User(user.Username))
+	defer func() { tracing.End(span, err) }()
+
+	if _, err := execContext(ctx, s.db, fmt.Sprintf("DROP USER IF EXISTS %s;", mysqlAccount(user))); err != nil {
+		return err
+	}
+
+	if userExists, err := s.doesUserExist(ctx, user); err != nil {
+		return err
+	} else if userExists {
+		return errors.New("user still exists after drop")
+	}
+
+	return nil
+}
+
+func (s *MySQLRepository) createOrUpdateUser(ctx context.Context, user MySQLUser) error {
+	auth, err := s.identifiedBy(user)
+	if err != nil {
+		return err
+	}
+
+	userExists, err := s.doesUserExist(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	if userExists {
+		_, err := execContext(ctx, s.db, fmt.Sprintf("ALTER USER %s %s;", mysqlAccount(user), auth))
+		return err
+	}
+
+	if _, err := execContext(ctx, s.db, fmt.Sprintf("CREATE USER %s %s;", mysqlAccount(user), auth)); err != nil {
+		return err
+	}
+
+	if userExistsNow, err := s.doesUserExist(ctx, user); err != nil {
+		return err
+	} else if !userExistsNow {
+		return errors.New("user doesn't exist after create")
+	}
+
+	return nil
+}
+
+// identifiedBy returns the authentication clause, MariaDB uses a different syntax for plugins
+func (s *MySQLRepository) identifiedBy(user MySQLUser) (string, error) {
+	password := quoteMySQLString(user.Password)
+	if user.AuthPlugin == "" {
+		return fmt.Sprintf("IDENTIFIED BY %s", password), nil
+	}
+
+	if !mysqlKeywordPattern.MatchString(user.AuthPlugin) {
+		return "", fmt.Errorf("invalid authentication plugin %q", user.AuthPlugin)
+	}
+
+	if s.mariaDB {
+		return fmt.Sprintf("IDENTIFIED VIA %s USING PASSWORD(%s)", user.AuthPlugin, password), nil
+	}
+
+	return fmt.Sprintf("IDENTIFIED WITH %s BY %s", user.AuthPlugin, password), nil
+}
+
+func (s *MySQLRepository) setResourceLimits(ctx context.Context, user MySQLUser) error {
+	_, err := execContext(ctx, s.db, fmt.Sprintf("ALTER USER %s WITH MAX_QUERIES_PER_HOUR %d MAX_UPDATES_PER_HOUR %d MAX_CONNECTIONS_PER_HOUR %d MAX_USER_CONNECTIONS %d;",
+		mysqlAccount(user),
+		user.Limits.MaxQueriesPerHour,
+		user.Limits.MaxUpdatesPerHour,
+		user.Limits.MaxConnectionsPerHour,
+		user.Limits.MaxUserConnections,
+	))
+	return err
+}
+
+func (s *MySQLRepository) setAccountLock(ctx context.Context, user MySQLUser, lock bool) error {
+	state := "UNLOCK"
+	if lock {
+		state = "LOCK"
+	}
+
+	_, err := execContext(ctx, s.db, fmt.Sprintf("ALTER USER %s ACCOUNT %s;", mysqlAccount(user), state))
+	return err
+}
+
+// syncGrants grants the privileges of user.Grants and revokes all other privileges on the database and its tables
+func (s *MySQLRepository) syncGrants(ctx context.Context, user MySQLUser) error {
+	desired := make(map[string][]string)
+	for _, grant := range user.Grants {
+		table := grant.Table
+		if table == "" {
+			table = "*"
+		}
+
+		for _, p := range grant.Privileges {
+			privilege := strings.ToUpper(strings.TrimSpace(string(p)))
+			if !slices.Contains(validMySQLPrivileges, privilege) {
+				return fmt.Errorf("invalid privilege %q", p)
+			}
+
+			if privilege == "ALL PRIVILEGES" {
+				privilege = MySQLAllPrivileges
+			}
+
+			desired[table] = append(desired[table], privilege)
+		}
+	}
+
+	current, err := s.getPrivileges(ctx, user)
+	if err != nil {
+		return fmt.Errorf("failed to lookup privileges: %w", err)
+	}
+
+	for table, privileges := range current {
+		wanted := desired[table]
+		if slices.Contains(wanted, MySQLAllPrivileges) {
+			continue
+		}
+
+		var revoke []string
+		for _, p := range privileges {
+			if !slices.Contains(wanted, p) {
+				revoke = append(revoke, p)
+			}
+		}
+
+		if len(revoke) == 0 {
+			continue
+		}
+
+		if _, err := execContext(ctx, s.db, fmt.Sprintf("REVOKE %s ON %s FROM %s;", strings.Join(revoke, ", "), mysqlObject(user.Database, table), mysqlAccount(user))); err != nil {
+			return err
+		}
+	}
+
+	for table, privileges := range desired {
+		if _, err := execContext(ctx, s.db, fmt.Sprintf("GRANT %s ON %s TO %s;", strings.Join(privileges, ", "), mysqlObject(user.Database, table), mysqlAccount(user))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getPrivileges returns the privileges of the user on the database (table *) and its tables
+func (s *MySQLRepository) getPrivileges(ctx context.Context, user MySQLUser) (map[string][]string, error) {
+	privileges := make(map[string][]string)
+	grantee := mysqlAccount(user)
+
+	rows, err := s.db.QueryContext(ctx, "SELECT '*', PRIVILEGE_TYPE FROM information_schema.SCHEMA_PRIVILEGES WHERE GRANTEE=? AND TABLE_SCHEMA=? UNION ALL SELECT TABLE_NAME, PRIVILEGE_TYPE FROM information_schema.TABLE_PRIVILEGES WHERE GRANTEE=? AND TABLE_SCHEMA=?;",
+		grantee, user.Database, grantee, user.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var table, privilege string
+		if err := rows.Scan(&table, &privilege); err != nil {
+			return nil, err
+		}
+
+		privileges[table] = append(privileges[table], privilege)
+	}
+
+	return privileges, rows.Err()
+}
+
+func (s *MySQLRepository) doesDatabaseExist(ctx context.Context, database string) (bool, error) {
+	var result int64
+	err := s.db.QueryRowContext(ctx, "SELECT 1 FROM information_schema.SCHEMATA WHERE SCHEMA_NAME=?;", database).Scan(&result)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return result == 1, nil
+}
+
+func (s *MySQLRepository) doesUserExist(ctx context.Context, user MySQLUser) (bool, error) {
+	var result int64
+	err := s.db.QueryRowContext(ctx, "SELECT 1 FROM mysql.user WHERE User=? AND Host=?;", user.Username, user.Host).Scan(&result)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return result == 1, nil
+}
+
+// mysqlAccount returns the quoted 'user'@'host' account name
+func mysqlAccount(user MySQLUser) string {
+	return fmt.Sprintf("%s@%s", quoteMySQLString(user.Username), quoteMySQLString(user.Host))
+}
+
+// mysqlObject returns the quoted db.table privilege level, a table * refers to all tables of the database
+func mysqlObject(database, table string) string {
+	if table == "*" {
+		return quoteMySQLIdentifier(database) + ".*"
+	}
+
+	return quoteMySQLIdentifier(database) + "." + quoteMySQLIdentifier(table)
+}
+
+func quoteMySQLIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+var mysqlStringEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`'`, `\'`,
+	"\x00", `\0`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\x1a", `\Z`,
+)
+
+func quoteMySQLString(value string) string {
+	return "'" + mysqlStringEscaper.Replace(value) + "'"
+}
+
+// MySQLUserOptions holds the MySQL specific settings of a User
+type MySQLUserOptions struct {
+	// Host pattern the account is bound to
+	Host       string
+	AuthPlugin string
+	Grants     []MySQLGrant
+	Limits     MySQLResourceLimits
+}
+
+// MySQLDatabaseOptions holds the settings a MySQL database is created with
+type MySQLDatabaseOptions struct {
+	CharacterSet string
+	Collation    string
+}
+
+// MySQLDatabaseProvisioner creates databases with a character set and collation
+type MySQLDatabaseProvisioner interface {
+	DatabaseProvisioner
+	CreateDatabaseWithOptions(ctx context.Context, database string, opts MySQLDatabaseOptions) error
+}
+
+// MySQLUserProvisioner moves accounts to another host pattern
+type MySQLUserProvisioner interface {
+	UserProvisioner
+	RenameUser(ctx context.Context, user User, host string) error
+}
+
+// mySQLProvisioner adapts the MySQLRepository to the engine neutral provisioner interfaces
+type mySQLProvisioner struct {
+	*MySQLRepository
+}
+
+func newMySQLProvisioner(ctx context.Context, opts ProvisionerOptions) (Provisioner, error) {
+	repository, err := NewMySQLRepository(ctx, MySQLOptions{
+		URI:          opts.URI,
+		DatabaseName: opts.DatabaseName,
+		Username:     opts.Username,
+		Password:     opts.Password,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &mySQLProvisioner{repository}, nil
+}
+
+// CreateDatabaseIfNotExists creates the database using the server default character set and collation
+func (p *mySQLProvisioner) CreateDatabaseIfNotExists(ctx context.Context, database string) error {
+	return p.MySQLRepository.CreateDatabaseIfNotExists(ctx, database, "", "")
+}
+
+func (p *mySQLProvisioner) CreateDatabaseWithOptions(ctx context.Context, database string, opts MySQLDatabaseOptions) error {
+	return p.MySQLRepository.CreateDatabaseIfNotExists(ctx, database, opts.CharacterSet, opts.Collation)
+}
+
+func (p *mySQLProvisioner) SetupUser(ctx context.Context, user User) error {
+	myUser, err := mysqlUser(user)
+	if err != nil {
+		return err
+	}
+
+	return p.MySQLRepository.SetupUser(ctx, myUser)
+}
+
+// RenameUser moves the account from host to the host pattern of the user
+func (p *mySQLProvisioner) RenameUser(ctx context.Context, user User, host string) error {
+	myUser, err := mysqlUser(user)
+	if err != nil {
+		return err
+	}
+
+	return p.MySQLRepository.RenameUser(ctx, myUser, host)
+}
+
+func (p *mySQLProvisioner) DropUser(ctx context.Context, user User) error {
+	myUser, err := mysqlUser(user)
+	if err != nil {
+		return err
+	}
+
+	return p.MySQLRepository.DropUser(ctx, myUser)
+}
+
+func (p *mySQLProvisioner) DisableUser(ctx context.Context, user User) error {
+	myUser, err := mysqlUser(user)
+	if err != nil {
+		return err
+	}
+
+	return p.MySQLRepository.DisableUser(ctx, myUser)
+}
+
+func (p *mySQLProvisioner) ExpireUser(ctx context.Context, user User) error {
+	myUser, err := mysqlUser(user)
+	if err != nil {
+		return err
+	}
+
+	return p.MySQLRepository.ExpireUser(ctx, myUser)
+}
+
+func (p *mySQLProvisioner) TerminateSessions(ctx context.Context, user User) (int64, error) {
+	myUser, err := mysqlUser(user)
+	if err != nil {
+		return 0, err
+	}
+
+	return p.MySQLRepository.TerminateSessions(ctx, myUser)
+}
+
+func mysqlUser(user User) (MySQLUser, error) {
+	opts, err := userOptions[MySQLUserOptions](user)
+	if err != nil {
+		return MySQLUser{}, err
+	}
+
+	return MySQLUser{
+		Database:   user.Database,
+		Username:   user.Username,
+		Password:   user.Password,
+		Host:       opts.Host,
+		AuthPlugin: opts.AuthPlugin,
+		Grants:     opts.Grants,
+		Limits:     opts.Limits,
+	}, nil
+}
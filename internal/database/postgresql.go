@@ -7,8 +7,14 @@ import (
 	"net/url"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/doodlescheduling/db-controller/internal/tracing"
 )
 
 type PostgreSQLOptions struct {
@@ -16,10 +22,12 @@ type PostgreSQLOptions struct {
 	DatabaseName string
 	Username     string
 	Password     string
+	Flavor       string
 }
 
 type PostgreSQLRepository struct {
 	conn *pgx.Conn
+	opts PostgreSQLOptions
 }
 
 const (
@@ -27,7 +35,20 @@ const (
 	DefaultPostgreSQLReadWriteRole = "readWrite"
 )
 
-func NewPostgreSQLRepository(ctx context.Context, opts PostgreSQLOptions) (*PostgreSQLRepository, error) {
+// Servers speaking the PostgreSQL wire protocol, the flavor selects the SQL dialect
+const (
+	PostgreSQLFlavorPostgreSQL  = "PostgreSQL"
+	PostgreSQLFlavorCockroachDB = "CockroachDB"
+	PostgreSQLFlavorYugabyteDB  = "YugabyteDB"
+)
+
+// ErrUnsupportedByFlavor is returned for features the flavor of the server does not support
+var ErrUnsupportedByFlavor = errors.New("not supported by the database flavor")
+
+func NewPostgreSQLRepository(ctx context.Context, opts PostgreSQLOptions) (_ *PostgreSQLRepository, err error) {
+	ctx, span := startSpan(ctx, "NewPostgreSQLRepository", semconv.DBSystemNamePostgreSQL, opts.URI, tracing.Database(opts.DatabaseName), tracing.User(opts.Username))
+	defer func() { tracing.End(span, err) }()
+
 	uri := opts.URI
 	if !strings.HasPrefix(uri, "postgresql://") && !strings.HasPrefix(uri, "postgres://") {
 		uri = "postgresql://" + uri
@@ -48,16 +69,31 @@ func NewPostgreSQLRepository(ctx context.Context, opts PostgreSQLOptions) (*Post
 		popt.Path = opts.DatabaseName
 	}
 
-	conn, err := pgx.Connect(ctx, popt.String())
+	config, err := pgx.ParseConfig(popt.String())
+	if err != nil {
+		return nil, err
+	}
+
+	config.Tracer = pgxTracer{server: opts.URI}
+	conn, err := pgx.ConnectConfig(ctx, config)
 	if err != nil {
 		return nil, err
 	}
 
 	return &PostgreSQLRepository{
 		conn: conn,
+		opts: opts,
 	}, nil
 }
 
+func (s *PostgreSQLRepository) isCockroachDB() bool {
+	return s.opts.Flavor == PostgreSQLFlavorCockroachDB
+}
+
+func (s *PostgreSQLRepository) startSpan(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return startSpan(ctx, "PostgreSQLRepository."+method, semconv.DBSystemNamePostgreSQL, s.opts.URI, attrs...)
+}
+
 func (s *PostgreSQLRepository) Close(ctx context.Context) error {
 	if s.conn != nil {
 		return s.conn.Close(ctx)
@@ -67,7 +103,10 @@ func (s *PostgreSQLRepository) Close(ctx context.Context) error {
 }
 
 // TODO Prepared Statements
-func (s *PostgreSQLRepository) CreateDatabaseIfNotExists(ctx context.Context, database string) error {
+func (s *PostgreSQLRepository) CreateDatabaseIfNotExists(ctx context.Context, database string) (err error) {
+	ctx, span := s.startSpan(ctx, "CreateDatabaseIfNotExists", tracing.Database(database))
+	defer func() { tracing.End(span, err) }()
+
 	if databaseExists, err := s.doesDatabaseExist(ctx, database); err != nil {
 		return err
 	} else {
@@ -97,6 +136,7 @@ type PostgresqlUser struct {
 	Roles      []string
 	Grants     []Grant
 	Attributes []string
+	ValidUntil *time.Time
 }
 
 type Grant struct {
@@ -108,16 +148,24 @@ type Grant struct {
 
 type Privilege string
 
-var SelectPrivilege Privilege = "SELECT"
-var AlPrivilege Privilege = "ALL"
+const (
+	SelectPrivilege Privilege = "SELECT"
+	AllPrivileges   Privilege = "ALL"
+)
+
+func (s *PostgreSQLRepository) SetupUser(ctx context.Context, user PostgresqlUser) (err error) {
+	ctx, span := s.startSpan(ctx, "SetupUser", tracing.Database(user.Database), tracing.User(user.Username))
+	defer func() { tracing.End(span, err) }()
 
-func (s *PostgreSQLRepository) SetupUser(ctx context.Context, user PostgresqlUser) error {
 	if err := s.createUserIfNotExists(ctx, user); err != nil {
 		return fmt.Errorf("failed to create user: %w", err)
 	}
 	if err := s.setPasswordForUser(ctx, user); err != nil {
 		return fmt.Errorf("failed to set password: %w", err)
 	}
+	if err := s.setValidUntil(ctx, user); err != nil {
+		return fmt.Errorf("failed to set valid until: %w", err)
+	}
 	if err := s.grantAllPrivileges(ctx, user); err != nil {
 		return fmt.Errorf("failed to grant all privileges: %w", err)
 	}
@@ -130,10 +178,82 @@ func (s *PostgreSQLRepository) SetupUser(ctx context.Context, user PostgresqlUse
 	if err := s.setAttributes(ctx, user); err != nil {
 		return fmt.Errorf("failed to set attributes: %w", err)
 	}
+	if err := s.setLogin(ctx, user); err != nil {
+		return fmt.Errorf("failed to allow login: %w", err)
+	}
 	return nil
 }
 
-func (s *PostgreSQLRepository) DropUser(ctx context.Context, user PostgresqlUser) error {
+// DisableUser prevents any new logins of the user, sets its password and revokes all its privileges.
+// Existing sessions are not affected, see TerminateSessions.
+func (s *PostgreSQLRepository) DisableUser(ctx context.Context, user PostgresqlUser) (err error) {
+	ctx, span := s.startSpan(ctx, "DisableUser", tracing.Database(user.Database), tracing.User(user.Username))
+	defer func() { tracing.End(span, err) }()
+
+	if userExists, err := s.doesUserExist(ctx, user); err != nil {
+		return err
+	} else if !userExists {
+		return nil
+	}
+
+	if _, err := s.conn.Exec(ctx, fmt.Sprintf("ALTER ROLE %s WITH NOLOGIN;", (pgx.Identifier{user.Username}).Sanitize())); err != nil {
+		return fmt.Errorf("failed to disallow login: %w", err)
+	}
+	if err := s.setPasswordForUser(ctx, user); err != nil {
+		return fmt.Errorf("failed to set password: %w", err)
+	}
+	if err := s.RevokeAllPrivileges(ctx, user); err != nil {
+		return fmt.Errorf("failed to revoke privileges: %w", err)
+	}
+	return nil
+}
+
+// ExpireUser prevents any new logins of the user and sets its password expiry to user.ValidUntil.
+// Existing sessions are not affected, see TerminateSessions.
+func (s *PostgreSQLRepository) ExpireUser(ctx context.Context, user PostgresqlUser) (err error) {
+	ctx, span := s.startSpan(ctx, "ExpireUser", tracing.Database(user.Database), tracing.User(user.Username))
+	defer func() { tracing.End(span, err) }()
+
+	if userExists, err := s.doesUserExist(ctx, user); err != nil {
+		return err
+	} else if !userExists {
+		return nil
+	}
+
+	_, err = s.conn.Exec(ctx, fmt.Sprintf("ALTER ROLE %s WITH NOLOGIN VALID UNTIL %s;", (pgx.Identifier{user.Username}).Sanitize(), s.validUntil(user.ValidUntil)))
+	return err
+}
+
+// TerminateSessions terminates all active sessions of the user and returns the number of terminated sessions
+func (s *PostgreSQLRepository) TerminateSessions(ctx context.Context, user PostgresqlUser) (_ int64, err error) {
+	ctx, span := s.startSpan(ctx, "TerminateSessions", tracing.Database(user.Database), tracing.User(user.Username))
+	defer func() { tracing.End(span, err) }()
+
+	username, err := s.conn.PgConn().EscapeString(user.Username)
+	if err != nil {
+		return 0, err
+	}
+
+	var result int64
+	if s.isCockroachDB() {
+		// CockroachDB has no pg_terminate_backend, sessions are cancelled cluster wide instead
+		sessions := fmt.Sprintf("SELECT session_id FROM [SHOW CLUSTER SESSIONS] WHERE user_name='%s'", username)
+		if err := s.conn.QueryRow(ctx, fmt.Sprintf("SELECT count(*) FROM (%s);", sessions)).Scan(&result); err != nil {
+			return 0, err
+		}
+
+		_, err = s.conn.Exec(ctx, fmt.Sprintf("CANCEL SESSIONS IF EXISTS (%s);", sessions))
+		return result, err
+	}
+
+	err = s.conn.QueryRow(ctx, fmt.Sprintf("SELECT count(pg_terminate_backend(pid)) FROM pg_stat_activity WHERE usename='%s' AND pid <> pg_backend_pid();", username)).Scan(&result)
+	return result, err
+}
+
+func (s *PostgreSQLRepository) DropUser(ctx context.Context, user PostgresqlUser) (err error) {
+	ctx, span := s.startSpan(ctx, "DropUser", tracing.Database(user.Database), tracing.User(user.Username))
+	defer func() { tracing.End(span, err) }()
+
 	if err := s.RevokeAllPrivileges(ctx, user); err != nil {
 		return err
 	}
@@ -143,22 +263,112 @@ func (s *PostgreSQLRepository) DropUser(ctx context.Context, user PostgresqlUser
 	return nil
 }
 
-func (s *PostgreSQLRepository) CreateSchema(ctx context.Context, db, name string) error {
-	_, err := s.conn.Exec(ctx, fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s;", (pgx.Identifier{name}).Sanitize()))
+// DropUserReassignOwned reassigns all objects owned by the user to newOwner and drops remaining privileges
+// in every database the user has dependencies in. The user gets dropped afterwards.
+// If newOwner is empty the objects are reassigned to the connected user.
+func (s *PostgreSQLRepository) DropUserReassignOwned(ctx context.Context, user PostgresqlUser, newOwner string) (err error) {
+	ctx, span := s.startSpan(ctx, "DropUserReassignOwned", tracing.Database(user.Database), tracing.User(user.Username))
+	defer func() { tracing.End(span, err) }()
+
+	if userExists, err := s.doesUserExist(ctx, user); err != nil {
+		return err
+	} else if !userExists {
+		return nil
+	}
+
+	databases, err := s.getDependentDatabases(ctx, user)
+	if err != nil {
+		return fmt.Errorf("failed to lookup dependent databases: %w", err)
+	}
+
+	// Privileges on shared objects are dropped by DROP OWNED within any database
+	if !slices.Contains(databases, user.Database) {
+		databases = append(databases, user.Database)
+	}
+
+	for _, database := range databases {
+		if err := s.reassignAndDropOwned(ctx, database, user, newOwner); err != nil {
+			return fmt.Errorf("failed to drop owned objects in database %s: %w", database, err)
+		}
+	}
+
+	return s.dropUserIfNotExist(ctx, user)
+}
+
+func (s *PostgreSQLRepository) reassignAndDropOwned(ctx context.Context, database string, user PostgresqlUser, newOwner string) error {
+	opts := s.opts
+	opts.DatabaseName = database
+
+	handler, err := NewPostgreSQLRepository(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = handler.Close(ctx) }()
+
+	owner := "CURRENT_USER"
+	if newOwner != "" {
+		owner = (pgx.Identifier{newOwner}).Sanitize()
+	}
+
+	if _, err := handler.conn.Exec(ctx, fmt.Sprintf("REASSIGN OWNED BY %s TO %s;", (pgx.Identifier{user.Username}).Sanitize(), owner)); err != nil {
+		return err
+	}
+
+	_, err = handler.conn.Exec(ctx, fmt.Sprintf("DROP OWNED BY %s;", (pgx.Identifier{user.Username}).Sanitize()))
+	return err
+}
+
+func (s *PostgreSQLRepository) getDependentDatabases(ctx context.Context, user PostgresqlUser) ([]string, error) {
+	username, err := s.conn.PgConn().EscapeString(user.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.conn.Query(ctx, fmt.Sprintf("SELECT DISTINCT d.datname FROM pg_shdepend s JOIN pg_database d ON d.oid = s.dbid JOIN pg_roles r ON r.oid = s.refobjid WHERE r.rolname='%s' AND d.datallowconn;", username))
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+func (s *PostgreSQLRepository) CreateSchema(ctx context.Context, db, name string) (err error) {
+	ctx, span := s.startSpan(ctx, "CreateSchema", tracing.Database(db))
+	defer func() { tracing.End(span, err) }()
+
+	_, err = s.conn.Exec(ctx, fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s;", (pgx.Identifier{name}).Sanitize()))
 	return err
 }
 
-func (s *PostgreSQLRepository) SetSearchPath(ctx context.Context, db string, searchPath []string) error {
+func (s *PostgreSQLRepository) SetSearchPath(ctx context.Context, db string, searchPath []string) (err error) {
+	ctx, span := s.startSpan(ctx, "SetSearchPath", tracing.Database(db))
+	defer func() { tracing.End(span, err) }()
+
 	var path []string
 	for _, v := range searchPath {
 		path = append(path, (pgx.Identifier{v}).Sanitize())
 	}
 
-	_, err := s.conn.Exec(ctx, fmt.Sprintf("ALTER DATABASE %s SET search_path TO %s;", (pgx.Identifier{db}).Sanitize(), strings.Join(path, ",")))
+	if s.isCockroachDB() {
+		_, err := s.conn.Exec(ctx, fmt.Sprintf("ALTER ROLE ALL IN DATABASE %s SET search_path = %s;", (pgx.Identifier{db}).Sanitize(), strings.Join(path, ",")))
+		return err
+	}
+
+	_, err = s.conn.Exec(ctx, fmt.Sprintf("ALTER DATABASE %s SET search_path TO %s;", (pgx.Identifier{db}).Sanitize(), strings.Join(path, ",")))
 	return err
 }
 
-func (s *PostgreSQLRepository) EnableExtension(ctx context.Context, db, name string) error {
+// EnableExtension creates the extension if it does not exist yet.
+// CockroachDB does not support extensions, ErrUnsupportedByFlavor is returned in this case.
+func (s *PostgreSQLRepository) EnableExtension(ctx context.Context, db, name string) (err error) {
+	ctx, span := s.startSpan(ctx, "EnableExtension", tracing.Database(db))
+	defer func() { tracing.End(span, err) }()
+
+	if s.isCockroachDB() {
+		return fmt.Errorf("extension %s: %w", name, ErrUnsupportedByFlavor)
+	}
+
 	if extensionExists, err := s.doesExtensionExist(ctx, db, name); err != nil {
 		return err
 	} else if !extensionExists {
@@ -224,12 +434,46 @@ func (s *PostgreSQLRepository) setPasswordForUser(ctx context.Context, user Post
 		return err
 	}
 
+	// CockroachDB always stores hashed passwords and does not accept the ENCRYPTED keyword
+	if s.isCockroachDB() {
+		_, err = s.conn.Exec(ctx, fmt.Sprintf("ALTER USER %s WITH PASSWORD '%s';", (pgx.Identifier{user.Username}).Sanitize(), password))
+		return err
+	}
+
 	_, err = s.conn.Exec(ctx, fmt.Sprintf("ALTER USER %s WITH ENCRYPTED PASSWORD '%s';", (pgx.Identifier{user.Username}).Sanitize(), password))
 	return err
 }
 
+func (s *PostgreSQLRepository) setValidUntil(ctx context.Context, user PostgresqlUser) error {
+	_, err := s.conn.Exec(ctx, fmt.Sprintf("ALTER ROLE %s WITH VALID UNTIL %s;", (pgx.Identifier{user.Username}).Sanitize(), s.validUntil(user.ValidUntil)))
+	return err
+}
+
+// setLogin allows the login of a user which was expired or disabled before, unless its attributes declare NOLOGIN
+func (s *PostgreSQLRepository) setLogin(ctx context.Context, user PostgresqlUser) error {
+	if slices.Contains(user.Attributes, "NOLOGIN") {
+		return nil
+	}
+
+	_, err := s.conn.Exec(ctx, fmt.Sprintf("ALTER ROLE %s WITH LOGIN;", (pgx.Identifier{user.Username}).Sanitize()))
+	return err
+}
+
+// validUntil returns the quoted VALID UNTIL literal, a missing timestamp never expires
+func (s *PostgreSQLRepository) validUntil(t *time.Time) string {
+	if t == nil {
+		if s.isCockroachDB() {
+			return "NULL"
+		}
+
+		return "'infinity'"
+	}
+
+	return fmt.Sprintf("'%s'", t.UTC().Format(time.RFC3339))
+}
+
 func (s *PostgreSQLRepository) grantAllPrivileges(ctx context.Context, user PostgresqlUser) error {
-	_, err := s.conn.Exec(ctx, fmt.Sprintf("GRANT ALL PRIVILEGES ON DATABASE %s TO %s;", (pgx.Identifier{user.Database}).Sanitize(), (pgx.Identifier{user.Username}).Sanitize()))
+	_, err := s.conn.Exec(ctx, fmt.Sprintf("GRANT %s ON DATABASE %s TO %s;", s.allPrivileges(), (pgx.Identifier{user.Database}).Sanitize(), (pgx.Identifier{user.Username}).Sanitize()))
 	return err
 }
 
@@ -246,7 +490,16 @@ func (s *PostgreSQLRepository) setRoles(ctx context.Context, user PostgresqlUser
 
 func (s *PostgreSQLRepository) grantRules(ctx context.Context, user PostgresqlUser) error {
 	for _, grant := range user.Grants {
+		// The object type and privileges are keywords which can not be quoted
+		if err := ValidateGrantObject(grant.Object); err != nil {
+			return err
+		}
+
 		for _, p := range grant.Privileges {
+			if err := ValidatePrivilege(p); err != nil {
+				return err
+			}
+
 			_, err := s.conn.Exec(ctx, fmt.Sprintf("GRANT %s ON %s %s TO %s;", string(p), grant.Object, (pgx.Identifier{grant.ObjectName}).Sanitize(), (pgx.Identifier{user.Username}).Sanitize()))
 			if err != nil {
 				return err
@@ -275,10 +528,102 @@ var validRoleAttributes = []string{
 	"NOINHERIT",
 }
 
+// cockroachDBRoleAttributes are the role attributes CockroachDB supports out of validRoleAttributes
+var cockroachDBRoleAttributes = []string{
+	"LOGIN",
+	"NOLOGIN",
+	"CREATEDB",
+	"NOCREATEDB",
+	"CREATEROLE",
+	"NOCREATEROLE",
+}
+
+// validPrivileges are the privileges which can be granted on PostgreSQL and CockroachDB objects
+var validPrivileges = []Privilege{
+	"SELECT",
+	"INSERT",
+	"UPDATE",
+	"DELETE",
+	"TRUNCATE",
+	"REFERENCES",
+	"TRIGGER",
+	"CREATE",
+	"CONNECT",
+	"TEMPORARY",
+	"TEMP",
+	"EXECUTE",
+	"USAGE",
+	"SET",
+	"ALTER SYSTEM",
+	"MAINTAIN",
+	"ALL",
+	"ALL PRIVILEGES",
+	"DROP",
+	"ZONECONFIG",
+	"BACKUP",
+	"RESTORE",
+	"CHANGEFEED",
+}
+
+// validGrantObjects are the object types a privilege can be granted on, an empty object type refers to a table
+var validGrantObjects = []string{
+	"",
+	"TABLE",
+	"SEQUENCE",
+	"DATABASE",
+	"DOMAIN",
+	"FOREIGN DATA WRAPPER",
+	"FOREIGN SERVER",
+	"FUNCTION",
+	"PROCEDURE",
+	"ROUTINE",
+	"LANGUAGE",
+	"PARAMETER",
+	"SCHEMA",
+	"TABLESPACE",
+	"TYPE",
+	"ALL TABLES IN SCHEMA",
+	"ALL SEQUENCES IN SCHEMA",
+	"ALL FUNCTIONS IN SCHEMA",
+	"ALL PROCEDURES IN SCHEMA",
+	"ALL ROUTINES IN SCHEMA",
+}
+
+// ValidateRoleAttribute returns an error if attribute is not a role attribute
+func ValidateRoleAttribute(attribute string) error {
+	if !slices.Contains(validRoleAttributes, attribute) {
+		return fmt.Errorf("invalid role attribute %q", attribute)
+	}
+
+	return nil
+}
+
+// ValidatePrivilege returns an error if privilege is not a privilege which can be granted, the privilege is case-insensitive
+func ValidatePrivilege(privilege Privilege) error {
+	if !slices.Contains(validPrivileges, Privilege(strings.ToUpper(string(privilege)))) {
+		return fmt.Errorf("invalid privilege %q", privilege)
+	}
+
+	return nil
+}
+
+// ValidateGrantObject returns an error if object is not an object type privileges can be granted on, the object type is case-insensitive
+func ValidateGrantObject(object string) error {
+	if !slices.Contains(validGrantObjects, strings.ToUpper(object)) {
+		return fmt.Errorf("invalid grant object type %q", object)
+	}
+
+	return nil
+}
+
 func (s *PostgreSQLRepository) setAttributes(ctx context.Context, user PostgresqlUser) error {
 	for _, attribute := range user.Attributes {
-		if !slices.Contains(validRoleAttributes, attribute) {
-			return fmt.Errorf("invalid role attribute %q", attribute)
+		if err := ValidateRoleAttribute(attribute); err != nil {
+			return err
+		}
+
+		if s.isCockroachDB() && !slices.Contains(cockroachDBRoleAttributes, attribute) {
+			return fmt.Errorf("role attribute %s: %w", attribute, ErrUnsupportedByFlavor)
 		}
 
 		_, err := s.conn.Exec(ctx, fmt.Sprintf("ALTER ROLE %s WITH %s;", (pgx.Identifier{user.Username}).Sanitize(), attribute))
@@ -290,11 +635,24 @@ func (s *PostgreSQLRepository) setAttributes(ctx context.Context, user Postgresq
 	return nil
 }
 
-func (s *PostgreSQLRepository) RevokeAllPrivileges(ctx context.Context, user PostgresqlUser) error {
-	_, err := s.conn.Exec(ctx, fmt.Sprintf("REVOKE ALL PRIVILEGES ON DATABASE %s FROM %s;", (pgx.Identifier{user.Database}).Sanitize(), (pgx.Identifier{user.Username}).Sanitize()))
+func (s *PostgreSQLRepository) RevokeAllPrivileges(ctx context.Context, user PostgresqlUser) (err error) {
+	ctx, span := s.startSpan(ctx, "RevokeAllPrivileges", tracing.Database(user.Database), tracing.User(user.Username))
+	defer func() { tracing.End(span, err) }()
+
+	_, err = s.conn.Exec(ctx, fmt.Sprintf("REVOKE %s ON DATABASE %s FROM %s;", s.allPrivileges(), (pgx.Identifier{user.Database}).Sanitize(), (pgx.Identifier{user.Username}).Sanitize()))
 	return err
 }
 
+// allPrivileges returns the keyword granting all privileges on a database.
+// CockroachDB only accepts ALL which also covers the ZONECONFIG and BACKUP privileges.
+func (s *PostgreSQLRepository) allPrivileges() string {
+	if s.isCockroachDB() {
+		return "ALL"
+	}
+
+	return "ALL PRIVILEGES"
+}
+
 func (s *PostgreSQLRepository) doesDatabaseExist(ctx context.Context, database string) (bool, error) {
 	database, err := s.conn.PgConn().EscapeString(database)
 	if err != nil {
@@ -345,3 +703,109 @@ func (s *PostgreSQLRepository) doesExtensionExist(ctx context.Context, db, name
 	}
 	return result == 1, nil
 }
+
+// PostgreSQLUserOptions holds the PostgreSQL specific settings of a User
+type PostgreSQLUserOptions struct {
+	Grants     []Grant
+	Attributes []string
+	// ReassignOwnedTo receives the objects owned by the user once it gets dropped,
+	// the connected user is used if empty
+	ReassignOwnedTo string
+}
+
+// PostgreSQLDatabaseProvisioner manages the extensions, schemas and search path of a database
+type PostgreSQLDatabaseProvisioner interface {
+	DatabaseProvisioner
+	EnableExtension(ctx context.Context, db, name string) error
+	CreateSchema(ctx context.Context, db, name string) error
+	SetSearchPath(ctx context.Context, db string, searchPath []string) error
+}
+
+// postgreSQLProvisioner adapts the PostgreSQLRepository to the engine neutral provisioner interfaces
+type postgreSQLProvisioner struct {
+	*PostgreSQLRepository
+}
+
+func newPostgreSQLProvisioner(ctx context.Context, opts ProvisionerOptions) (Provisioner, error) {
+	repository, err := NewPostgreSQLRepository(ctx, PostgreSQLOptions{
+		URI:          opts.URI,
+		DatabaseName: opts.DatabaseName,
+		Username:     opts.Username,
+		Password:     opts.Password,
+		Flavor:       opts.Flavor,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &postgreSQLProvisioner{repository}, nil
+}
+
+func (p *postgreSQLProvisioner) SetupUser(ctx context.Context, user User) error {
+	pgUser, _, err := postgresqlUser(user)
+	if err != nil {
+		return err
+	}
+
+	return p.PostgreSQLRepository.SetupUser(ctx, pgUser)
+}
+
+// DropUser reassigns the objects owned by the user before it gets dropped
+func (p *postgreSQLProvisioner) DropUser(ctx context.Context, user User) error {
+	pgUser, opts, err := postgresqlUser(user)
+	if err != nil {
+		return err
+	}
+
+	return p.DropUserReassignOwned(ctx, pgUser, opts.ReassignOwnedTo)
+}
+
+// DisableUser disallows the login of the user, sets its password and revokes all its privileges.
+// Users can't easily be dropped since they might own objects.
+func (p *postgreSQLProvisioner) DisableUser(ctx context.Context, user User) error {
+	return p.PostgreSQLRepository.DisableUser(ctx, PostgresqlUser{
+		Database: user.Database,
+		Username: user.Username,
+		Password: user.Password,
+	})
+}
+
+func (p *postgreSQLProvisioner) ExpireUser(ctx context.Context, user User) error {
+	pgUser, _, err := postgresqlUser(user)
+	if err != nil {
+		return err
+	}
+
+	return p.PostgreSQLRepository.ExpireUser(ctx, pgUser)
+}
+
+func (p *postgreSQLProvisioner) TerminateSessions(ctx context.Context, user User) (int64, error) {
+	pgUser, _, err := postgresqlUser(user)
+	if err != nil {
+		return 0, err
+	}
+
+	return p.PostgreSQLRepository.TerminateSessions(ctx, pgUser)
+}
+
+func postgresqlUser(user User) (PostgresqlUser, PostgreSQLUserOptions, error) {
+	opts, err := userOptions[PostgreSQLUserOptions](user)
+	if err != nil {
+		return PostgresqlUser{}, opts, err
+	}
+
+	pgUser := PostgresqlUser{
+		Database:   user.Database,
+		Username:   user.Username,
+		Password:   user.Password,
+		Grants:     opts.Grants,
+		Attributes: opts.Attributes,
+		ValidUntil: user.ValidUntil,
+	}
+
+	for _, role := range user.Roles {
+		pgUser.Roles = append(pgUser.Roles, role.Name)
+	}
+
+	return pgUser, opts, nil
+}
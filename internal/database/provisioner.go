@@ -0,0 +1,212 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Engine identifies the kind of server a provisioner talks to
+type Engine string
+
+const (
+	EnginePostgreSQL Engine = "PostgreSQL"
+	EngineMongoDB    Engine = "MongoDB"
+	EngineMySQL      Engine = "MySQL"
+	EngineClickHouse Engine = "ClickHouse"
+	EngineMSSQL      Engine = "MSSQL"
+	EngineRabbitMQ   Engine = "RabbitMQ"
+	EngineRedis      Engine = "Redis"
+)
+
+// MongoDBFlavorAtlas selects the MongoDB Atlas admin API instead of a MongoDB server
+const MongoDBFlavorAtlas = "Atlas"
+
+// ErrProvisionerNotFound is returned if no provisioner is registered for an engine and flavor
+var ErrProvisionerNotFound = errors.New("no provisioner registered")
+
+// ProvisionerOptions holds the connection settings passed to a ProvisionerFactory.
+// Engines ignore the settings they have no use for.
+type ProvisionerOptions struct {
+	// URI of the server, for Atlas the base url of the admin API
+	URI string
+	// Username to authenticate with, for Atlas the public key
+	Username string
+	// Password to authenticate with, for Atlas the private key
+	Password string
+	// DatabaseName the connection is bound to
+	DatabaseName string
+	// Flavor of the engine, an empty flavor selects the default implementation
+	Flavor string
+
+	// MongoDB specific settings
+	AuthDatabaseName string
+	AuthMechanism    string
+	ReplicaSet       string
+	ReadPreference   string
+	TLSCertificate   []byte
+	TLSKey           []byte
+	TLSCA            []byte
+
+	// GroupID is the MongoDB Atlas project
+	GroupID string
+
+	// Cluster the ClickHouse statements are executed on
+	Cluster string
+	// SaveACL persists the Redis users to the ACL file after every change
+	SaveACL bool
+}
+
+// Role is a role granted to a user, Database is only used by engines which scope roles to a database
+type Role struct {
+	Name     string
+	Database string
+}
+
+// User is the engine neutral specification of a user.
+// Engine specific settings are passed in Options, see PostgreSQLUserOptions, MongoDBUserOptions, AtlasUserOptions,
+// MySQLUserOptions, ClickHouseUserOptions, MSSQLUserOptions, RabbitMQUserOptions and RedisUserOptions.
+type User struct {
+	Database   string
+	Username   string
+	Password   string
+	Roles      []Role
+	ValidUntil *time.Time
+	Options    any
+}
+
+// Provisioner is a connection to a server
+type Provisioner interface {
+	Close(ctx context.Context) error
+}
+
+// DatabaseProvisioner creates databases
+type DatabaseProvisioner interface {
+	Provisioner
+	CreateDatabaseIfNotExists(ctx context.Context, database string) error
+}
+
+// UserProvisioner manages users
+type UserProvisioner interface {
+	Provisioner
+	SetupUser(ctx context.Context, user User) error
+	DropUser(ctx context.Context, user User) error
+}
+
+// UserDisabler is implemented by provisioners which can disable a user without dropping it
+type UserDisabler interface {
+	DisableUser(ctx context.Context, user User) error
+}
+
+// UserExpirer is implemented by provisioners which can block new logins of a user once it expired
+type UserExpirer interface {
+	ExpireUser(ctx context.Context, user User) error
+}
+
+// SessionTerminator is implemented by provisioners which can terminate the active sessions of a user
+type SessionTerminator interface {
+	TerminateSessions(ctx context.Context, user User) (int64, error)
+}
+
+// ProvisionerFactory connects a new provisioner
+type ProvisionerFactory func(ctx context.Context, opts ProvisionerOptions) (Provisioner, error)
+
+type registryKey struct {
+	engine Engine
+	flavor string
+}
+
+// Registry holds the provisioner factories keyed by engine and flavor
+type Registry struct {
+	mu        sync.RWMutex
+	factories map[registryKey]ProvisionerFactory
+}
+
+// DefaultRegistry holds the provisioners of all built-in engines
+var DefaultRegistry = NewRegistry()
+
+func init() {
+	DefaultRegistry.Register(EnginePostgreSQL, "", newPostgreSQLProvisioner)
+	DefaultRegistry.Register(EngineMongoDB, "", newMongoDBProvisioner)
+	DefaultRegistry.Register(EngineMongoDB, MongoDBFlavorAtlas, newAtlasProvisioner)
+	DefaultRegistry.Register(EngineMySQL, "", newMySQLProvisioner)
+	DefaultRegistry.Register(EngineClickHouse, "", newClickHouseProvisioner)
+	DefaultRegistry.Register(EngineMSSQL, "", newMSSQLProvisioner)
+	DefaultRegistry.Register(EngineRabbitMQ, "", newRabbitMQProvisioner)
+	DefaultRegistry.Register(EngineRedis, "", newRedisProvisioner)
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		factories: make(map[registryKey]ProvisionerFactory),
+	}
+}
+
+// Register adds a factory for the engine and flavor, an existing factory gets replaced.
+// A factory registered with an empty flavor is used for all flavors without a dedicated factory.
+func (r *Registry) Register(engine Engine, flavor string, factory ProvisionerFactory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.factories[registryKey{engine: engine, flavor: flavor}] = factory
+}
+
+// New connects a provisioner for the engine and opts.Flavor
+func (r *Registry) New(ctx context.Context, engine Engine, opts ProvisionerOptions) (Provisioner, error) {
+	r.mu.RLock()
+	factory, ok := r.factories[registryKey{engine: engine, flavor: opts.Flavor}]
+	if !ok {
+		factory, ok = r.factories[registryKey{engine: engine}]
+	}
+	r.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w for engine %s flavor %q", ErrProvisionerNotFound, engine, opts.Flavor)
+	}
+
+	return factory(ctx, opts)
+}
+
+// NewDatabaseProvisioner connects a provisioner which is able to create databases
+func (r *Registry) NewDatabaseProvisioner(ctx context.Context, engine Engine, opts ProvisionerOptions) (DatabaseProvisioner, error) {
+	return NewProvisioner[DatabaseProvisioner](ctx, r, engine, opts)
+}
+
+// NewUserProvisioner connects a provisioner which is able to manage users
+func (r *Registry) NewUserProvisioner(ctx context.Context, engine Engine, opts ProvisionerOptions) (UserProvisioner, error) {
+	return NewProvisioner[UserProvisioner](ctx, r, engine, opts)
+}
+
+// NewProvisioner connects a provisioner from the registry which implements T,
+// engine specific interfaces like PostgreSQLDatabaseProvisioner can be requested as well
+func NewProvisioner[T Provisioner](ctx context.Context, r *Registry, engine Engine, opts ProvisionerOptions) (T, error) {
+	var empty T
+	p, err := r.New(ctx, engine, opts)
+	if err != nil {
+		return empty, err
+	}
+
+	t, ok := p.(T)
+	if !ok {
+		_ = p.Close(ctx)
+		return empty, fmt.Errorf("provisioner %T for engine %s does not implement %T", p, engine, (*T)(nil))
+	}
+
+	return t, nil
+}
+
+// userOptions returns the engine specific options of the user, the zero value is returned if none are set
+func userOptions[T any](user User) (T, error) {
+	var opts T
+	if user.Options == nil {
+		return opts, nil
+	}
+
+	opts, ok := user.Options.(T)
+	if !ok {
+		return opts, fmt.Errorf("unexpected user options %T, expected %T", user.Options, opts)
+	}
+
+	return opts, nil
+}
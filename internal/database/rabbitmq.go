@@ -0,0 +1,402 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	rabbithole "github.com/michaelklishin/rabbit-hole/v2"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/doodlescheduling/db-controller/internal/tracing"
+)
+
+type RabbitMQOptions struct {
+	URI      string
+	Username string
+	Password string
+}
+
+// RabbitMQRepository talks to the RabbitMQ management HTTP API
+type RabbitMQRepository struct {
+	client  *rabbithole.Client
+	address string
+}
+
+// RabbitMQVhost is a virtual host
+type RabbitMQVhost struct {
+	Name        string
+	Description string
+	Tags        []string
+	Tracing     bool
+}
+
+// RabbitMQTopicPermission restricts the routing keys on a topic exchange
+type RabbitMQTopicPermission struct {
+	Exchange string
+	Write    string
+	Read     string
+}
+
+// RabbitMQUser is a user with permissions on a single virtual host
+type RabbitMQUser struct {
+	Vhost            string
+	Username         string
+	Password         string
+	Tags             []string
+	Configure        string
+	Write            string
+	Read             string
+	TopicPermissions []RabbitMQTopicPermission
+}
+
+func NewRabbitMQRepository(ctx context.Context, opts RabbitMQOptions) (_ *RabbitMQRepository, err error) {
+	ctx, span := startSpan(ctx, "NewRabbitMQRepository", semconv.MessagingSystemRabbitMQ, opts.URI, tracing.User(opts.Username))
+	defer func() { tracing.End(span, err) }()
+
+	client, err := rabbithole.NewClient(opts.URI, opts.Username, opts.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	// The management client does not support contexts, the deadline is applied to each request instead
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) > 0 {
+		client.SetTimeout(time.Until(deadline))
+	}
+
+	if _, err := client.Overview(); err != nil {
+		return nil, err
+	}
+
+	return &RabbitMQRepository{
+		client:  client,
+		address: opts.URI,
+	}, nil
+}
+
+func (r *RabbitMQRepository) startSpan(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return startSpan(ctx, "RabbitMQRepository."+method, semconv.MessagingSystemRabbitMQ, r.address, attrs...)
+}
+
+func (r *RabbitMQRepository) Close(ctx context.Context) error {
+	return nil
+}
+
+// CreateOrUpdateVhost creates the virtual host or updates its description, tags and tracing setting
+func (r *RabbitMQRepository) CreateOrUpdateVhost(ctx context.Context, vhost RabbitMQVhost) (err error) {
+	ctx, span := r.startSpan(ctx, "CreateOrUpdateVhost", tracing.Database(vhost.Name))
+	defer func() { tracing.End(span, err) }()
+
+	if err := closeRabbitMQResponse(r.client.PutVhost(vhost.Name, rabbithole.VhostSettings{
+		Description: vhost.Description,
+		Tags:        rabbithole.VhostTags(vhost.Tags),
+		Tracing:     vhost.Tracing,
+	})); err != nil {
+		return err
+	}
+
+	if _, err := r.client.GetVhost(vhost.Name); err != nil {
+		return errors.New("vhost doesn't exist after create")
+	}
+
+	return nil
+}
+
+// SetupUser creates or updates the user and syncs its permissions and topic permissions on the virtual host
+func (r *RabbitMQRepository) SetupUser(ctx context.Context, user RabbitMQUser) (err error) {
+	ctx, span := r.startSpan(ctx, "SetupUser", tracing.Database(user.Vhost), tracing.User(user.Username))
+	defer func() { tracing.End(span, err) }()
+
+	tags := user.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	if err := closeRabbitMQResponse(r.client.PutUser(user.Username, rabbithole.UserSettings{
+		Tags:     rabbithole.UserTags(tags),
+		Password: user.Password,
+	})); err != nil {
+		return err
+	}
+
+	if err := closeRabbitMQResponse(r.client.UpdatePermissionsIn(user.Vhost, user.Username, rabbithole.Permissions{
+		Configure: user.Configure,
+		Write:     user.Write,
+		Read:      user.Read,
+	})); err != nil {
+		return err
+	}
+
+	return r.syncTopicPermissions(ctx, user)
+}
+
+// ExpireUser clears the permissions of the user on the virtual host
+func (r *RabbitMQRepository) ExpireUser(ctx context.Context, user RabbitMQUser) (err error) {
+	ctx, span := r.startSpan(ctx, "ExpireUser", tracing.Database(user.Vhost), tracing.User(user.Username))
+	defer func() { tracing.End(span, err) }()
+
+	return r.clearPermissions(user)
+}
+
+// DisableUser clears the permissions of the user on the virtual host.
+// The password is randomized if the user has no permissions on any other virtual host.
+func (r *RabbitMQRepository) DisableUser(ctx context.Context, user RabbitMQUser) (err error) {
+	ctx, span := r.startSpan(ctx, "DisableUser", tracing.Database(user.Vhost), tracing.User(user.Username))
+	defer func() { tracing.End(span, err) }()
+
+	if err := r.clearPermissions(user); err != nil {
+		return err
+	}
+
+	info, err := r.client.GetUser(user.Username)
+	if isRabbitMQNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if used, err := r.hasPermissionsElsewhere(user); err != nil {
+		return err
+	} else if used {
+		return nil
+	}
+
+	return closeRabbitMQResponse(r.client.PutUser(user.Username, rabbithole.UserSettings{
+		Tags:     info.Tags,
+		Password: user.Password,
+	}))
+}
+
+// DropUser clears the permissions of the user on the virtual host.
+// The user is deleted if it has no permissions on any other virtual host.
+func (r *RabbitMQRepository) DropUser(ctx context.Context, user RabbitMQUser) (err error) {
+	ctx, span := r.startSpan(ctx, "DropUser", tracing.Database(user.Vhost), tracing.User(user.Username))
+	defer func() { tracing.End(span, err) }()
+
+	if err := r.clearPermissions(user); err != nil {
+		return err
+	}
+
+	if used, err := r.hasPermissionsElsewhere(user); err != nil {
+		return err
+	} else if used {
+		return nil
+	}
+
+	if err := closeRabbitMQResponse(r.client.DeleteUser(user.Username)); err != nil && !isRabbitMQNotFound(err) {
+		return err
+	}
+
+	if _, err := r.client.GetUser(user.Username); err == nil {
+		return errors.New("user still exists after drop")
+	} else if !isRabbitMQNotFound(err) {
+		return err
+	}
+
+	return nil
+}
+
+// TerminateSessions closes all connections of the user to the virtual host and returns the number of closed connections
+func (r *RabbitMQRepository) TerminateSessions(ctx context.Context, user RabbitMQUser) (_ int64, err error) {
+	ctx, span := r.startSpan(ctx, "TerminateSessions", tracing.Database(user.Vhost), tracing.User(user.Username))
+	defer func() { tracing.End(span, err) }()
+
+	connections, err := r.client.ListConnections()
+	if err != nil {
+		return 0, err
+	}
+
+	var closed int64
+	for _, conn := range connections {
+		if conn.User != user.Username || conn.Vhost != user.Vhost {
+			continue
+		}
+
+		if err := closeRabbitMQResponse(r.client.CloseConnection(conn.Name)); err != nil && !isRabbitMQNotFound(err) {
+			return closed, err
+		}
+
+		closed++
+	}
+
+	return closed, nil
+}
+
+func (r *RabbitMQRepository) syncTopicPermissions(ctx context.Context, user RabbitMQUser) error {
+	current, err := r.client.GetTopicPermissionsIn(user.Vhost, user.Username)
+	if err != nil && !isRabbitMQNotFound(err) {
+		return err
+	}
+
+	var exchanges []string
+	for _, permission := range user.TopicPermissions {
+		exchanges = append(exchanges, permission.Exchange)
+		if err := closeRabbitMQResponse(r.client.UpdateTopicPermissionsIn(user.Vhost, user.Username, rabbithole.TopicPermissions{
+			Exchange: permission.Exchange,
+			Write:    permission.Write,
+			Read:     permission.Read,
+		})); err != nil {
+			return err
+		}
+	}
+
+	for _, permission := range current {
+		if slices.Contains(exchanges, permission.Exchange) {
+			continue
+		}
+
+		if err := closeRabbitMQResponse(r.client.DeleteTopicPermissionsIn(user.Vhost, user.Username, permission.Exchange)); err != nil && !isRabbitMQNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *RabbitMQRepository) clearPermissions(user RabbitMQUser) error {
+	if err := closeRabbitMQResponse(r.client.ClearPermissionsIn(user.Vhost, user.Username)); err != nil && !isRabbitMQNotFound(err) {
+		return err
+	}
+
+	if err := closeRabbitMQResponse(r.client.ClearTopicPermissionsIn(user.Vhost, user.Username)); err != nil && !isRabbitMQNotFound(err) {
+		return err
+	}
+
+	return nil
+}
+
+func (r *RabbitMQRepository) hasPermissionsElsewhere(user RabbitMQUser) (bool, error) {
+	permissions, err := r.client.ListPermissionsOf(user.Username)
+	if isRabbitMQNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	for _, permission := range permissions {
+		if permission.Vhost != user.Vhost {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// closeRabbitMQResponse releases the response body of a mutating request to the management API
+func closeRabbitMQResponse(res *http.Response, err error) error {
+	if res != nil && res.Body != nil {
+		_ = res.Body.Close()
+	}
+
+	return err
+}
+
+func isRabbitMQNotFound(err error) bool {
+	var res rabbithole.ErrorResponse
+	if errors.As(err, &res) {
+		return res.StatusCode == http.StatusNotFound
+	}
+
+	return false
+}
+
+// RabbitMQUserOptions holds the RabbitMQ specific settings of a User
+type RabbitMQUserOptions struct {
+	Tags             []string
+	Configure        string
+	Write            string
+	Read             string
+	TopicPermissions []RabbitMQTopicPermission
+}
+
+// RabbitMQVhostProvisioner manages virtual hosts
+type RabbitMQVhostProvisioner interface {
+	Provisioner
+	CreateOrUpdateVhost(ctx context.Context, vhost RabbitMQVhost) error
+}
+
+// rabbitMQProvisioner adapts the RabbitMQRepository to the engine neutral provisioner interfaces,
+// the database of a User is the virtual host
+type rabbitMQProvisioner struct {
+	*RabbitMQRepository
+}
+
+func newRabbitMQProvisioner(ctx context.Context, opts ProvisionerOptions) (Provisioner, error) {
+	repository, err := NewRabbitMQRepository(ctx, RabbitMQOptions{
+		URI:      opts.URI,
+		Username: opts.Username,
+		Password: opts.Password,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &rabbitMQProvisioner{repository}, nil
+}
+
+func (p *rabbitMQProvisioner) SetupUser(ctx context.Context, user User) error {
+	mqUser, err := rabbitmqUser(user)
+	if err != nil {
+		return err
+	}
+
+	return p.RabbitMQRepository.SetupUser(ctx, mqUser)
+}
+
+func (p *rabbitMQProvisioner) DropUser(ctx context.Context, user User) error {
+	mqUser, err := rabbitmqUser(user)
+	if err != nil {
+		return err
+	}
+
+	return p.RabbitMQRepository.DropUser(ctx, mqUser)
+}
+
+func (p *rabbitMQProvisioner) DisableUser(ctx context.Context, user User) error {
+	mqUser, err := rabbitmqUser(user)
+	if err != nil {
+		return err
+	}
+
+	return p.RabbitMQRepository.DisableUser(ctx, mqUser)
+}
+
+func (p *rabbitMQProvisioner) ExpireUser(ctx context.Context, user User) error {
+	mqUser, err := rabbitmqUser(user)
+	if err != nil {
+		return err
+	}
+
+	return p.RabbitMQRepository.ExpireUser(ctx, mqUser)
+}
+
+func (p *rabbitMQProvisioner) TerminateSessions(ctx context.Context, user User) (int64, error) {
+	mqUser, err := rabbitmqUser(user)
+	if err != nil {
+		return 0, err
+	}
+
+	return p.RabbitMQRepository.TerminateSessions(ctx, mqUser)
+}
+
+func rabbitmqUser(user User) (RabbitMQUser, error) {
+	opts, err := userOptions[RabbitMQUserOptions](user)
+	if err != nil {
+		return RabbitMQUser{}, err
+	}
+
+	return RabbitMQUser{
+		Vhost:            user.Database,
+		Username:         user.Username,
+		Password:         user.Password,
+		Tags:             opts.Tags,
+		Configure:        opts.Configure,
+		Write:            opts.Write,
+		Read:             opts.Read,
+		TopicPermissions: opts.TopicPermissions,
+	}, nil
+}
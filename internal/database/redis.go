@@ -0,0 +1,256 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/doodlescheduling/db-controller/internal/tracing"
+)
+
+type RedisOptions struct {
+	URI      string
+	Username string
+	Password string
+}
+
+type RedisRepository struct {
+	client  *redis.Client
+	address string
+}
+
+type RedisUser struct {
+	Username        string
+	Password        string
+	KeyPatterns     []string
+	ChannelPatterns []string
+	Categories      []string
+}
+
+var redisCategoryPattern = regexp.MustCompile(`^[+-]@[a-z]+$`)
+
+func NewRedisRepository(ctx context.Context, opts RedisOptions) (_ *RedisRepository, err error) {
+	ctx, span := startSpan(ctx, "NewRedisRepository", semconv.DBSystemNameRedis, opts.URI, tracing.User(opts.Username))
+	defer func() { tracing.End(span, err) }()
+
+	var ropts *redis.Options
+	if strings.HasPrefix(opts.URI, "redis://") || strings.HasPrefix(opts.URI, "rediss://") {
+		o, err := redis.ParseURL(opts.URI)
+		if err != nil {
+			return nil, err
+		}
+
+		ropts = o
+	} else {
+		ropts = &redis.Options{
+			Addr: opts.URI,
+		}
+	}
+
+	ropts.Username = opts.Username
+	ropts.Password = opts.Password
+	ropts.DisableIdentity = true
+
+	client := redis.NewClient(ropts)
+	client.AddHook(redisTracer{})
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+
+	return &RedisRepository{
+		client:  client,
+		address: opts.URI,
+	}, nil
+}
+
+func (r *RedisRepository) startSpan(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return startSpan(ctx, "RedisRepository."+method, semconv.DBSystemNameRedis, r.address, attrs...)
+}
+
+func (r *RedisRepository) Close(ctx context.Context) error {
+	if r.client != nil {
+		return r.client.Close()
+	}
+
+	return nil
+}
+
+// SetupUser creates or replaces the ACL rules of the user.
+// All existing rules are reset within the same command so the user never has more permissions than defined.
+func (r *RedisRepository) SetupUser(ctx context.Context, user RedisUser) (err error) {
+	ctx, span := r.startSpan(ctx, "SetupUser", tracing.User(user.Username))
+	defer func() { tracing.End(span, err) }()
+
+	if user.Username == "" {
+		return errors.New("username must not be empty")
+	}
+
+	args := []interface{}{"ACL", "SETUSER", user.Username, "reset", "on", "#" + hashRedisPassword(user.Password)}
+
+	for _, pattern := range user.KeyPatterns {
+		args = append(args, "~"+pattern)
+	}
+
+	for _, pattern := range user.ChannelPatterns {
+		args = append(args, "&"+pattern)
+	}
+
+	for _, category := range user.Categories {
+		if !redisCategoryPattern.MatchString(category) {
+			return fmt.Errorf("invalid command category %q", category)
+		}
+
+		args = append(args, category)
+	}
+
+	return r.client.Do(ctx, args...).Err()
+}
+
+// DisableUser switches the user off and removes all its passwords, nothing happens if the user does not exist
+func (r *RedisRepository) DisableUser(ctx context.Context, username string) (err error) {
+	ctx, span := r.startSpan(ctx, "DisableUser", tracing.User(username))
+	defer func() { tracing.End(span, err) }()
+
+	if userExists, err := r.doesUserExist(ctx, username); err != nil {
+		return err
+	} else if !userExists {
+		return nil
+	}
+
+	return r.client.Do(ctx, "ACL", "SETUSER", username, "off", "resetpass").Err()
+}
+
+func (r *RedisRepository) DropUser(ctx context.Context, username string) (err error) {
+	ctx, span := r.startSpan(ctx, "DropUser", tracing.User(username))
+	defer func() { tracing.End(span, err) }()
+
+	return r.client.Do(ctx, "ACL", "DELUSER", username).Err()
+}
+
+// TerminateSessions closes all connections authenticated as the user and returns the number of closed connections
+func (r *RedisRepository) TerminateSessions(ctx context.Context, username string) (_ int64, err error) {
+	ctx, span := r.startSpan(ctx, "TerminateSessions", tracing.User(username))
+	defer func() { tracing.End(span, err) }()
+
+	return r.client.ClientKillByFilter(ctx, "USER", username).Result()
+}
+
+// SaveACL persists the users to the ACL file of the server
+func (r *RedisRepository) SaveACL(ctx context.Context) (err error) {
+	ctx, span := r.startSpan(ctx, "SaveACL")
+	defer func() { tracing.End(span, err) }()
+
+	return r.client.Do(ctx, "ACL", "SAVE").Err()
+}
+
+func (r *RedisRepository) doesUserExist(ctx context.Context, username string) (bool, error) {
+	users, err := r.client.Do(ctx, "ACL", "USERS").StringSlice()
+	if err != nil {
+		return false, err
+	}
+
+	return slices.Contains(users, username), nil
+}
+
+// hashRedisPassword returns the sha256 hex digest, this keeps the plaintext password out of the server command log
+func hashRedisPassword(password string) string {
+	sum := sha256.Sum256([]byte(password))
+	return hex.EncodeToString(sum[:])
+}
+
+// RedisUserOptions holds the Redis specific settings of a User
+type RedisUserOptions struct {
+	KeyPatterns     []string
+	ChannelPatterns []string
+	Categories      []string
+}
+
+// redisProvisioner adapts the RedisRepository to the engine neutral provisioner interfaces,
+// users are not bound to a database.
+// The users are persisted to the ACL file after every change if saveACL is set.
+type redisProvisioner struct {
+	*RedisRepository
+	saveACL bool
+}
+
+func newRedisProvisioner(ctx context.Context, opts ProvisionerOptions) (Provisioner, error) {
+	repository, err := NewRedisRepository(ctx, RedisOptions{
+		URI:      opts.URI,
+		Username: opts.Username,
+		Password: opts.Password,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &redisProvisioner{RedisRepository: repository, saveACL: opts.SaveACL}, nil
+}
+
+func (p *redisProvisioner) SetupUser(ctx context.Context, user User) error {
+	opts, err := userOptions[RedisUserOptions](user)
+	if err != nil {
+		return err
+	}
+
+	err = p.RedisRepository.SetupUser(ctx, RedisUser{
+		Username:        user.Username,
+		Password:        user.Password,
+		KeyPatterns:     opts.KeyPatterns,
+		ChannelPatterns: opts.ChannelPatterns,
+		Categories:      opts.Categories,
+	})
+	if err != nil {
+		return err
+	}
+
+	return p.persistACL(ctx)
+}
+
+// DropUser deletes the user, Redis closes the connections of deleted users itself
+func (p *redisProvisioner) DropUser(ctx context.Context, user User) error {
+	if err := p.RedisRepository.DropUser(ctx, user.Username); err != nil {
+		return err
+	}
+
+	return p.persistACL(ctx)
+}
+
+func (p *redisProvisioner) DisableUser(ctx context.Context, user User) error {
+	if err := p.RedisRepository.DisableUser(ctx, user.Username); err != nil {
+		return err
+	}
+
+	return p.persistACL(ctx)
+}
+
+// ExpireUser switches the user off, Redis has no notion of an expiring password
+func (p *redisProvisioner) ExpireUser(ctx context.Context, user User) error {
+	return p.DisableUser(ctx, user)
+}
+
+func (p *redisProvisioner) TerminateSessions(ctx context.Context, user User) (int64, error) {
+	return p.RedisRepository.TerminateSessions(ctx, user.Username)
+}
+
+func (p *redisProvisioner) persistACL(ctx context.Context) error {
+	if !p.saveACL {
+		return nil
+	}
+
+	if err := p.SaveACL(ctx); err != nil {
+		return fmt.Errorf("failed to save acl file: %w", err)
+	}
+
+	return nil
+}
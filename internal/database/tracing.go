@@ -0,0 +1,136 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/doodlescheduling/db-controller/internal/tracing"
+)
+
+// startSpan starts the span of a repository constructor or method.
+// The span carries the database system and the server address next to attrs.
+func startSpan(ctx context.Context, name string, system attribute.KeyValue, server string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracing.Start(ctx, name, append([]attribute.KeyValue{system, tracing.Server(server)}, attrs...)...)
+}
+
+// execer is implemented by sql.DB and sql.Conn
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// execContext executes the statement within a span named after the statement type
+func execContext(ctx context.Context, db execer, query string, args ...any) (sql.Result, error) {
+	ctx, span := tracing.Start(ctx, tracing.StatementType(query), tracing.Statement(query))
+	res, err := db.ExecContext(ctx, query, args...)
+	tracing.End(span, err)
+	return res, err
+}
+
+// pgxTracer starts a span for each statement sent over a pgx connection.
+// Mutating statements are additionally recorded in the audit log.
+type pgxTracer struct {
+	server string
+}
+
+type pgxStatementKey struct{}
+
+func (pgxTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	ctx, _ = tracing.Start(ctx, tracing.StatementType(data.SQL), tracing.Statement(data.SQL))
+	return context.WithValue(ctx, pgxStatementKey{}, data.SQL)
+}
+
+func (t pgxTracer) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	err := data.Err
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = nil
+	}
+
+	if stmt, ok := ctx.Value(pgxStatementKey{}).(string); ok && !isReadOnlyStatement(stmt) {
+		recordStatement(ctx, EnginePostgreSQL, t.server, conn.Config().Database, stmt, err)
+	}
+
+	tracing.End(trace.SpanFromContext(ctx), err)
+}
+
+// mongoDBTracer starts a span for each command sent to MongoDB.
+// Only the command name is recorded, the command itself may contain a password.
+type mongoDBTracer struct {
+	spans sync.Map
+}
+
+func (t *mongoDBTracer) monitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, evt *event.CommandStartedEvent) {
+			_, span := tracing.Start(ctx, evt.CommandName, tracing.Database(evt.DatabaseName), tracing.Operation(evt.CommandName))
+			t.spans.Store(mongoDBRequestKey(evt.ConnectionID, evt.RequestID), span)
+		},
+		Succeeded: func(ctx context.Context, evt *event.CommandSucceededEvent) {
+			t.end(evt.CommandFinishedEvent, nil)
+		},
+		Failed: func(ctx context.Context, evt *event.CommandFailedEvent) {
+			t.end(evt.CommandFinishedEvent, errors.New(evt.Failure))
+		},
+	}
+}
+
+func (t *mongoDBTracer) end(evt event.CommandFinishedEvent, err error) {
+	if span, ok := t.spans.LoadAndDelete(mongoDBRequestKey(evt.ConnectionID, evt.RequestID)); ok {
+		tracing.End(span.(trace.Span), err)
+	}
+}
+
+func mongoDBRequestKey(connectionID string, requestID int64) string {
+	return fmt.Sprintf("%s/%d", connectionID, requestID)
+}
+
+// redisTracer starts a span for each command sent to Redis.
+// Only the command and the subcommand are recorded, the arguments may contain a password.
+type redisTracer struct{}
+
+func (redisTracer) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (redisTracer) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		name := redisCommandName(cmd)
+		ctx, span := tracing.Start(ctx, name, tracing.Operation(name))
+		err := next(ctx, cmd)
+		if errors.Is(err, redis.Nil) {
+			tracing.End(span, nil)
+		} else {
+			tracing.End(span, err)
+		}
+
+		return err
+	}
+}
+
+func (redisTracer) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+// redisCommandName returns the command name including the subcommand of container commands like ACL SETUSER
+func redisCommandName(cmd redis.Cmder) string {
+	name := strings.ToUpper(cmd.Name())
+	switch name {
+	case "ACL", "CLIENT", "CONFIG":
+		if args := cmd.Args(); len(args) > 1 {
+			if sub, ok := args[1].(string); ok {
+				return name + " " + strings.ToUpper(sub)
+			}
+		}
+	}
+
+	return name
+}
@@ -0,0 +1,133 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics holds the custom collectors of the controller.
+// They are registered with the controller-runtime registry and served on --metrics-addr
+// next to the controller-runtime metrics.
+package metrics
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const namespace = "db_controller"
+
+var (
+	// ReconcileTotal counts the reconcile results by kind and the reason of the ready condition
+	ReconcileTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reconcile_total",
+		Help:      "Total number of reconciliations by kind and the reason of the ready condition.",
+	}, []string{"kind", "reason"})
+
+	// ConnectionDuration observes the time it takes to connect to a server
+	ConnectionDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "connection_duration_seconds",
+		Help:      "Duration of connecting to a server by engine and address.",
+		Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"engine", "address"})
+
+	// ConnectionFailuresTotal counts the failed attempts to connect to a server
+	ConnectionFailuresTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "connection_failures_total",
+		Help:      "Total number of failed connection attempts by engine and address.",
+	}, []string{"engine", "address"})
+
+	// ServerUp reports whether the last connectivity check of a server succeeded
+	ServerUp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "server_up",
+		Help:      "Whether the last connectivity check of a server referenced by the database resources succeeded (1) or not (0).",
+	}, []string{"engine", "address"})
+
+	// AuditWriteFailuresTotal counts the failed attempts to write an audit entry, including retried webhook deliveries
+	AuditWriteFailuresTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "audit_write_failures_total",
+		Help:      "Total number of failed attempts to write an audit entry to the sink.",
+	})
+
+	// AuditEntriesDroppedTotal counts the audit entries which were lost
+	AuditEntriesDroppedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "audit_entries_dropped_total",
+		Help:      "Total number of audit entries which could not be written to the sink and were dropped.",
+	})
+
+	// AuditEntriesPending reports the audit entries queued for the webhook sink
+	AuditEntriesPending = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "audit_entries_pending",
+		Help:      "Number of audit entries queued for the webhook sink which were not delivered yet.",
+	})
+
+	// Users exposes the expiry and the credential sync of the users
+	Users = NewUserCollector(7 * 24 * time.Hour)
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(
+		ReconcileTotal,
+		ConnectionDuration,
+		ConnectionFailuresTotal,
+		ServerUp,
+		AuditWriteFailuresTotal,
+		AuditEntriesDroppedTotal,
+		AuditEntriesPending,
+		Users,
+	)
+}
+
+// RecordReconcile counts a reconciliation, an empty reason is recorded as Unknown
+func RecordReconcile(kind, reason string) {
+	if reason == "" {
+		reason = "Unknown"
+	}
+
+	ReconcileTotal.WithLabelValues(kind, reason).Inc()
+}
+
+// ObserveConnection records the duration and the outcome of connecting to a server
+func ObserveConnection(engine, address string, duration time.Duration, err error) {
+	address = ServerAddress(address)
+	ConnectionDuration.WithLabelValues(engine, address).Observe(duration.Seconds())
+
+	if err != nil {
+		ConnectionFailuresTotal.WithLabelValues(engine, address).Inc()
+	}
+}
+
+// ServerAddress strips credentials, the path and query parameters from a server address
+// so it can be used as label value.
+// Addresses which are not an URL, for example host:port, are returned without any user info.
+func ServerAddress(address string) string {
+	if u, err := url.Parse(address); err == nil && u.Host != "" {
+		return u.Host
+	}
+
+	if i := strings.LastIndex(address, "@"); i != -1 {
+		return address[i+1:]
+	}
+
+	return address
+}
@@ -0,0 +1,188 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var (
+	usersExpiredDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "users_expired"),
+		"Number of users whose validUntil has passed by kind and namespace.",
+		[]string{"kind", "namespace"}, nil,
+	)
+
+	usersExpiringDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "users_expiring"),
+		"Number of users whose validUntil is within the expiry window by kind and namespace.",
+		[]string{"kind", "namespace"}, nil,
+	)
+
+	userExpiryWindowDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "users_expiry_window_seconds"),
+		"The window in which users are reported as expiring.",
+		nil, nil,
+	)
+
+	userCredentialsSyncAgeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "user_credentials_sync_age_seconds"),
+		"Seconds since the credentials of the user were last applied successfully.",
+		[]string{"kind", "namespace", "name"}, nil,
+	)
+
+	userCredentialsSyncedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "user_credentials_synced"),
+		"Whether the last reconciliation of the user applied its credentials successfully.",
+		[]string{"kind", "namespace", "name"}, nil,
+	)
+)
+
+type userKey struct {
+	kind string
+	types.NamespacedName
+}
+
+type userState struct {
+	validUntil *time.Time
+	synced     bool
+	lastSync   time.Time
+}
+
+// UserCollector exposes the expiry and the credential sync of the users the controllers reconciled.
+// The state is kept in memory and reported when the metrics are scraped, so the expiry
+// gauges move without a reconciliation.
+type UserCollector struct {
+	mu           sync.Mutex
+	users        map[userKey]userState
+	expiryWindow time.Duration
+	now          func() time.Time
+}
+
+// NewUserCollector returns a collector which reports users as expiring once their validUntil is within expiryWindow
+func NewUserCollector(expiryWindow time.Duration) *UserCollector {
+	return &UserCollector{
+		users:        make(map[userKey]userState),
+		expiryWindow: expiryWindow,
+		now:          time.Now,
+	}
+}
+
+// SetExpiryWindow changes the window in which users are reported as expiring
+func (c *UserCollector) SetExpiryWindow(expiryWindow time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.expiryWindow = expiryWindow
+}
+
+// Observe records the validUntil of a user, a nil validUntil means the user does not expire
+func (c *UserCollector) Observe(kind string, key types.NamespacedName, validUntil *time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	k := userKey{kind: kind, NamespacedName: key}
+	state := c.users[k]
+	state.validUntil = validUntil
+	c.users[k] = state
+}
+
+// CredentialsSynced records whether the credentials of a user were applied successfully
+func (c *UserCollector) CredentialsSynced(kind string, key types.NamespacedName, synced bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	k := userKey{kind: kind, NamespacedName: key}
+	state := c.users[k]
+	state.synced = synced
+	if synced {
+		state.lastSync = c.now()
+	}
+
+	c.users[k] = state
+}
+
+// Forget removes a user which was deleted
+func (c *UserCollector) Forget(kind string, key types.NamespacedName) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.users, userKey{kind: kind, NamespacedName: key})
+}
+
+// Describe implements prometheus.Collector
+func (c *UserCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- usersExpiredDesc
+	ch <- usersExpiringDesc
+	ch <- userExpiryWindowDesc
+	ch <- userCredentialsSyncAgeDesc
+	ch <- userCredentialsSyncedDesc
+}
+
+// Collect implements prometheus.Collector
+func (c *UserCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	type group struct {
+		kind      string
+		namespace string
+	}
+
+	now := c.now()
+	expired := make(map[group]int)
+	expiring := make(map[group]int)
+
+	for key, state := range c.users {
+		// Every kind and namespace with users is reported, even without any expired or expiring user
+		g := group{kind: key.kind, namespace: key.Namespace}
+		expired[g] += 0
+		expiring[g] += 0
+
+		switch {
+		case state.validUntil == nil:
+		case !state.validUntil.After(now):
+			expired[g]++
+		case state.validUntil.Sub(now) <= c.expiryWindow:
+			expiring[g]++
+		}
+
+		var synced float64
+		if state.synced {
+			synced = 1
+		}
+
+		ch <- prometheus.MustNewConstMetric(userCredentialsSyncedDesc, prometheus.GaugeValue, synced, key.kind, key.Namespace, key.Name)
+
+		if !state.lastSync.IsZero() {
+			ch <- prometheus.MustNewConstMetric(userCredentialsSyncAgeDesc, prometheus.GaugeValue,
+				now.Sub(state.lastSync).Seconds(), key.kind, key.Namespace, key.Name)
+		}
+	}
+
+	for g, count := range expired {
+		ch <- prometheus.MustNewConstMetric(usersExpiredDesc, prometheus.GaugeValue, float64(count), g.kind, g.namespace)
+	}
+
+	for g, count := range expiring {
+		ch <- prometheus.MustNewConstMetric(usersExpiringDesc, prometheus.GaugeValue, float64(count), g.kind, g.namespace)
+	}
+
+	ch <- prometheus.MustNewConstMetric(userExpiryWindowDesc, prometheus.GaugeValue, c.expiryWindow.Seconds())
+}
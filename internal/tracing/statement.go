@@ -0,0 +1,71 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"strings"
+	"unicode"
+)
+
+// compound holds the keywords which are followed by the type of the object they act on
+var compound = map[string]struct{}{
+	"ALTER":    {},
+	"CANCEL":   {},
+	"CREATE":   {},
+	"DROP":     {},
+	"KILL":     {},
+	"REASSIGN": {},
+	"RENAME":   {},
+}
+
+// StatementType returns the leading keywords of a SQL statement like SELECT, GRANT or CREATE ROLE.
+// Identifiers and literals are never part of the result.
+func StatementType(stmt string) string {
+	fields := strings.FieldsFunc(strings.ToUpper(stmt), func(r rune) bool {
+		return unicode.IsSpace(r) || r == ';' || r == '('
+	})
+
+	if len(fields) == 0 {
+		return ""
+	}
+
+	statement := fields[0]
+	if _, ok := compound[statement]; !ok || len(fields) == 1 {
+		return statement
+	}
+
+	object := fields[1]
+	if object == "OR" && len(fields) > 3 && fields[2] == "REPLACE" {
+		object = fields[3]
+	}
+
+	if !isKeyword(object) {
+		return statement
+	}
+
+	return statement + " " + object
+}
+
+func isKeyword(s string) bool {
+	for _, r := range s {
+		if (r < 'A' || r > 'Z') && r != '_' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,152 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing configures the OpenTelemetry tracer provider and holds the span helpers
+// used by the controllers and the database repositories.
+// Spans carry the database, the user and the statement type but never the statement itself,
+// statements may contain passwords.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/doodlescheduling/db-controller/internal/metrics"
+)
+
+const instrumentationName = "github.com/doodlescheduling/db-controller"
+
+// UserKey is the user a database call acts on.
+// db.user was removed from the semantic conventions but is still understood by most backends.
+const UserKey = attribute.Key("db.user")
+
+// Options configures the export of traces
+type Options struct {
+	// Endpoint is the host:port of the OTLP gRPC collector, tracing is disabled if empty
+	Endpoint string
+	// Insecure disables TLS for the connection to the collector
+	Insecure bool
+	// SampleRatio is the fraction of traces sampled, spans of a sampled parent are always sampled
+	SampleRatio float64
+	// ServiceName is reported as service.name resource attribute
+	ServiceName string
+}
+
+// Setup installs a global tracer provider which exports spans to the OTLP collector.
+// The returned function flushes the remaining spans and must be called before the process exits.
+// If no endpoint is configured the global no-op provider is kept.
+func Setup(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	if opts.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporterOpts := []otlptracegrpc.Option{
+		otlptracegrpc.WithEndpoint(opts.Endpoint),
+	}
+
+	if opts.Insecure {
+		exporterOpts = append(exporterOpts, otlptracegrpc.WithInsecure())
+	}
+
+	exporter, err := otlptracegrpc.New(ctx, exporterOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create otlp exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(opts.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider.Shutdown, nil
+}
+
+// Start starts a span using the global tracer provider
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// RecordError marks the span as failed if err is not nil
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// End records err and ends the span
+func End(span trace.Span, err error) {
+	RecordError(span, err)
+	span.End()
+}
+
+// Object returns the attributes of a reconciled object
+func Object(kind string, key types.NamespacedName) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("k8s.object.kind", kind),
+		semconv.K8SNamespaceName(key.Namespace),
+		attribute.String("k8s.object.name", key.Name),
+	}
+}
+
+// Database returns the attribute of the database a call acts on
+func Database(name string) attribute.KeyValue {
+	return semconv.DBNamespace(name)
+}
+
+// User returns the attribute of the user a call acts on
+func User(name string) attribute.KeyValue {
+	return UserKey.String(name)
+}
+
+// Server returns the attribute of the server address, credentials in the address are stripped
+func Server(address string) attribute.KeyValue {
+	return semconv.ServerAddress(metrics.ServerAddress(address))
+}
+
+// Statement returns the statement type of a SQL statement, see StatementType
+func Statement(stmt string) attribute.KeyValue {
+	return Operation(StatementType(stmt))
+}
+
+// Operation returns the attribute of an operation which is not a SQL statement, like a MongoDB command
+func Operation(name string) attribute.KeyValue {
+	return semconv.DBOperationName(name)
+}
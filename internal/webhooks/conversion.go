@@ -0,0 +1,51 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	infrav1 "github.com/doodlescheduling/db-controller/api/v1"
+)
+
+// SetupConversionWebhookWithManager registers the conversion webhook for all kinds.
+// v1 is the hub, the served v1beta1 version is converted from and to it.
+func SetupConversionWebhookWithManager(mgr ctrl.Manager) error {
+	for _, obj := range []runtime.Object{
+		&infrav1.ClickHouseDatabase{},
+		&infrav1.ClickHouseUser{},
+		&infrav1.MongoDBDatabase{},
+		&infrav1.MongoDBUser{},
+		&infrav1.MSSQLDatabase{},
+		&infrav1.MSSQLUser{},
+		&infrav1.MySQLDatabase{},
+		&infrav1.MySQLUser{},
+		&infrav1.PostgreSQLDatabase{},
+		&infrav1.PostgreSQLUser{},
+		&infrav1.RabbitMQUser{},
+		&infrav1.RabbitMQVhost{},
+		&infrav1.RedisServer{},
+		&infrav1.RedisUser{},
+	} {
+		if err := ctrl.NewWebhookManagedBy(mgr, obj).Complete(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
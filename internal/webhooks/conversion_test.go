@@ -0,0 +1,168 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "github.com/doodlescheduling/db-controller/api/v1"
+	infrav1beta1 "github.com/doodlescheduling/db-controller/api/v1beta1"
+	"github.com/doodlescheduling/db-controller/internal/crd"
+)
+
+var _ = Describe("Conversion webhook", Ordered, func() {
+	namespace := setupNamespace()
+
+	It("serves a v1beta1 Atlas MongoDBDatabase as v1", func() {
+		db := &infrav1beta1.MongoDBDatabase{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "mongodbdatabase-" + randStringRunes(5),
+				Namespace: namespace.Name,
+			},
+			Spec: infrav1beta1.MongoDBDatabaseSpec{
+				DatabaseSpec: &infrav1beta1.DatabaseSpec{
+					RootSecret: &infrav1beta1.SecretReference{Name: "atlas"},
+				},
+				AtlasGroupId: "group",
+				AccessList: []infrav1beta1.AtlasAccessListEntry{
+					{IPAddress: "10.0.0.1"},
+				},
+			},
+		}
+		Expect(k8sClient.Create(context.Background(), db)).Should(Succeed())
+
+		key := types.NamespacedName{Name: db.Name, Namespace: db.Namespace}
+		got := &infrav1.MongoDBDatabase{}
+		Expect(k8sClient.Get(context.Background(), key, got)).Should(Succeed())
+		Expect(got.Spec.RootSecret).To(BeNil())
+		Expect(got.Spec.DatabaseName).To(Equal(db.Name))
+		Expect(got.Spec.Atlas).NotTo(BeNil())
+		Expect(got.Spec.Atlas.GroupID).To(Equal("group"))
+		Expect(got.Spec.Atlas.APIKeySecret.Name).To(Equal("atlas"))
+		Expect(got.Spec.Atlas.APIKeySecret.Namespace).To(Equal(namespace.Name))
+		Expect(got.Spec.Atlas.APIKeySecret.PublicKeyField).To(Equal("username"))
+		Expect(got.Spec.Atlas.APIKeySecret.PrivateKeyField).To(Equal("password"))
+		Expect(got.Spec.Atlas.AccessList).To(ConsistOf(infrav1.AtlasAccessListEntry{IPAddress: "10.0.0.1"}))
+	})
+
+	It("serves a v1 MongoDBUser as v1beta1", func() {
+		user := &infrav1.MongoDBUser{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "mongodbuser-" + randStringRunes(5),
+				Namespace: namespace.Name,
+			},
+			Spec: infrav1.MongoDBUserSpec{
+				Database:    &infrav1.DatabaseReference{Name: "database"},
+				Credentials: &infrav1.SecretReference{Name: "credentials"},
+				Roles: []infrav1.MongoDBUserRole{
+					{Name: "read"},
+				},
+			},
+		}
+		Expect(k8sClient.Create(context.Background(), user)).Should(Succeed())
+
+		got := &infrav1beta1.MongoDBUser{}
+		Expect(k8sClient.Get(context.Background(), types.NamespacedName{Name: user.Name, Namespace: user.Namespace}, got)).Should(Succeed())
+		Expect(got.Spec.Roles).NotTo(BeNil())
+		Expect(*got.Spec.Roles).To(HaveLen(1))
+		Expect((*got.Spec.Roles)[0].Name).To(Equal("read"))
+	})
+
+	It("reads a v1beta1 Atlas MongoDBDatabase stored before the upgrade as v1", func() {
+		crdClient, err := client.New(cfg, client.Options{Scheme: crdScheme()})
+		Expect(err).NotTo(HaveOccurred())
+
+		By("storing v1beta1 as before the upgrade")
+		setStorageVersion(crdClient, "mongodbdatabases.dbprovisioning.infra.doodle.com", "v1beta1")
+		DeferCleanup(setStorageVersion, crdClient, "mongodbdatabases.dbprovisioning.infra.doodle.com", "v1")
+
+		db := &infrav1beta1.MongoDBDatabase{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "mongodbdatabase-" + randStringRunes(5),
+				Namespace: namespace.Name,
+			},
+			Spec: infrav1beta1.MongoDBDatabaseSpec{
+				DatabaseSpec: &infrav1beta1.DatabaseSpec{
+					RootSecret: &infrav1beta1.SecretReference{Name: "atlas"},
+				},
+				AtlasGroupId: "group",
+				AtlasBaseURL: "https://atlas.example.com/",
+				AccessList: []infrav1beta1.AtlasAccessListEntry{
+					{CIDRBlock: "10.0.0.0/8"},
+				},
+			},
+		}
+		Expect(k8sClient.Create(context.Background(), db)).Should(Succeed())
+
+		By("upgrading to v1 as storage version")
+		setStorageVersion(crdClient, "mongodbdatabases.dbprovisioning.infra.doodle.com", "v1")
+
+		got := &infrav1.MongoDBDatabase{}
+		Expect(k8sClient.Get(context.Background(), types.NamespacedName{Name: db.Name, Namespace: db.Namespace}, got)).Should(Succeed())
+		Expect(got.Spec.RootSecret).To(BeNil())
+		Expect(got.Spec.Atlas).NotTo(BeNil())
+		Expect(got.Spec.Atlas.GroupID).To(Equal("group"))
+		Expect(got.Spec.Atlas.BaseURL).To(Equal("https://atlas.example.com/"))
+		Expect(got.Spec.Atlas.APIKeySecret.Name).To(Equal("atlas"))
+		Expect(got.Spec.Atlas.AccessList).To(ConsistOf(infrav1.AtlasAccessListEntry{CIDRBlock: "10.0.0.0/8"}))
+	})
+
+	It("requires the conversion webhook for CRDs serving multiple versions", func() {
+		crdClient, err := client.New(cfg, client.Options{Scheme: crdScheme()})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(crd.RequireConversion(context.Background(), crdClient)).To(Succeed())
+
+		definition := &apiextensionsv1.CustomResourceDefinition{}
+		Expect(crdClient.Get(context.Background(), types.NamespacedName{Name: "mongodbdatabases.dbprovisioning.infra.doodle.com"}, definition)).To(Succeed())
+		definition.ResourceVersion = ""
+		definition.Spec.Conversion = &apiextensionsv1.CustomResourceConversion{Strategy: apiextensionsv1.NoneConverter}
+
+		withoutConversion := fake.NewClientBuilder().WithScheme(crdScheme()).WithObjects(definition).Build()
+		Expect(crd.RequireConversion(context.Background(), withoutConversion)).To(MatchError(crd.ErrConversionRequired))
+	})
+})
+
+func crdScheme() *runtime.Scheme {
+	s := runtime.NewScheme()
+	Expect(apiextensionsv1.AddToScheme(s)).To(Succeed())
+	return s
+}
+
+// setStorageVersion marks version as the storage version of the CRD and waits until it is recorded as stored version
+func setStorageVersion(c client.Client, name, version string) {
+	definition := &apiextensionsv1.CustomResourceDefinition{}
+	Expect(c.Get(context.Background(), types.NamespacedName{Name: name}, definition)).To(Succeed())
+
+	for i := range definition.Spec.Versions {
+		definition.Spec.Versions[i].Storage = definition.Spec.Versions[i].Name == version
+	}
+
+	Expect(c.Update(context.Background(), definition)).To(Succeed())
+	Eventually(func() []string {
+		Expect(c.Get(context.Background(), types.NamespacedName{Name: name}, definition)).To(Succeed())
+		return definition.Status.StoredVersions
+	}).Should(ContainElement(version))
+}
@@ -0,0 +1,183 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	infrav1 "github.com/doodlescheduling/db-controller/api/v1"
+)
+
+var _ = Describe("MongoDB webhooks", Ordered, func() {
+	namespace := setupNamespace()
+
+	newDatabase := func(spec infrav1.MongoDBDatabaseSpec) *infrav1.MongoDBDatabase {
+		spec.DatabaseSpec = infrav1.DatabaseSpec{
+			RootSecret: &infrav1.SecretReference{Name: "root"},
+		}
+
+		return &infrav1.MongoDBDatabase{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "mongodbdatabase-" + randStringRunes(5),
+				Namespace: namespace.Name,
+			},
+			Spec: spec,
+		}
+	}
+
+	newAtlasDatabase := func(accessList []infrav1.AtlasAccessListEntry) *infrav1.MongoDBDatabase {
+		return &infrav1.MongoDBDatabase{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "mongodbdatabase-" + randStringRunes(5),
+				Namespace: namespace.Name,
+			},
+			Spec: infrav1.MongoDBDatabaseSpec{
+				Atlas: &infrav1.MongoDBAtlasSpec{
+					GroupID:      "group",
+					APIKeySecret: &infrav1.AtlasAPIKeySecretReference{Name: "atlas"},
+					AccessList:   accessList,
+				},
+			},
+		}
+	}
+
+	newUser := func(spec infrav1.MongoDBUserSpec) *infrav1.MongoDBUser {
+		spec.Database = &infrav1.DatabaseReference{Name: "database"}
+		spec.Credentials = &infrav1.SecretReference{Name: "credentials"}
+
+		return &infrav1.MongoDBUser{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "mongodbuser-" + randStringRunes(5),
+				Namespace: namespace.Name,
+			},
+			Spec: spec,
+		}
+	}
+
+	expectInvalid := func(err error, field string) {
+		Expect(apierrors.IsInvalid(err)).To(BeTrue(), "expected an invalid error, got %v", err)
+		Expect(err.Error()).To(ContainSubstring(field))
+	}
+
+	// The validators are called directly, the CEL rules of the CRD schemas would reject most specs first
+	validateDatabase := func(db *infrav1.MongoDBDatabase) error {
+		_, err := (&MongoDBDatabaseValidator{}).ValidateCreate(context.Background(), db)
+		return err
+	}
+
+	validateUser := func(user *infrav1.MongoDBUser) error {
+		_, err := (&MongoDBUserValidator{}).ValidateCreate(context.Background(), user)
+		return err
+	}
+
+	It("defaults the database name and the secret namespaces", func() {
+		db := newDatabase(infrav1.MongoDBDatabaseSpec{
+			AuthMechanism: infrav1.MongoDBAuthMechanismX509,
+			TLSSecret:     &infrav1.TLSSecretReference{Name: "tls"},
+		})
+		Expect(k8sClient.Create(context.Background(), db)).Should(Succeed())
+
+		got := &infrav1.MongoDBDatabase{}
+		Expect(k8sClient.Get(context.Background(), types.NamespacedName{Name: db.Name, Namespace: db.Namespace}, got)).Should(Succeed())
+		Expect(got.Spec.DatabaseName).To(Equal(db.Name))
+		Expect(got.Spec.RootSecret.Namespace).To(Equal(namespace.Name))
+		Expect(got.Spec.TLSSecret.Namespace).To(Equal(namespace.Name))
+	})
+
+	It("rejects MONGODB-X509 without tls secret", func() {
+		expectInvalid(validateDatabase(newDatabase(infrav1.MongoDBDatabaseSpec{
+			AuthMechanism: infrav1.MongoDBAuthMechanismX509,
+		})), "spec.tlsSecret")
+	})
+
+	It("defaults the namespace of the atlas api key secret", func() {
+		db := newAtlasDatabase(nil)
+		Expect(k8sClient.Create(context.Background(), db)).Should(Succeed())
+
+		got := &infrav1.MongoDBDatabase{}
+		Expect(k8sClient.Get(context.Background(), types.NamespacedName{Name: db.Name, Namespace: db.Namespace}, got)).Should(Succeed())
+		Expect(got.Spec.RootSecret).To(BeNil())
+		Expect(got.Spec.Atlas.APIKeySecret.Namespace).To(Equal(namespace.Name))
+	})
+
+	It("rejects invalid access list entries", func() {
+		err := validateDatabase(newAtlasDatabase([]infrav1.AtlasAccessListEntry{
+			{CIDRBlock: "10.0.0.0/8", IPAddress: "10.0.0.1"},
+			{CIDRBlock: "10.0.0.0/33"},
+			{IPAddress: "localhost"},
+			{IPAddress: "10.0.0.2"},
+			{IPAddress: "10.0.0.2"},
+		}))
+
+		expectInvalid(err, "spec.atlas.accessList[0]")
+		Expect(err.Error()).To(ContainSubstring("spec.atlas.accessList[1].cidrBlock"))
+		Expect(err.Error()).To(ContainSubstring("spec.atlas.accessList[2].ipAddress"))
+		Expect(err.Error()).To(ContainSubstring("spec.atlas.accessList[4]"))
+	})
+
+	It("rejects seeds for atlas and document seeds without collection", func() {
+		db := newAtlasDatabase(nil)
+		db.Spec.Seeds = []infrav1.MongoDBSeed{
+			{Name: "users", ConfigMap: &infrav1.ConfigMapReference{Name: "users"}, Type: infrav1.MongoDBSeedDocuments},
+		}
+
+		err := validateDatabase(db)
+		expectInvalid(err, "spec.seeds")
+		Expect(err.Error()).To(ContainSubstring("spec.seeds[0].collection"))
+	})
+
+	It("rejects seeds with duplicate names", func() {
+		expectInvalid(validateDatabase(newDatabase(infrav1.MongoDBDatabaseSpec{
+			Seeds: []infrav1.MongoDBSeed{
+				{Name: "users", ConfigMap: &infrav1.ConfigMapReference{Name: "users"}},
+				{Name: "users", ConfigMap: &infrav1.ConfigMapReference{Name: "more-users"}},
+			},
+		})), "spec.seeds[1].name")
+	})
+
+	It("defaults the credentials namespace of a valid user", func() {
+		user := newUser(infrav1.MongoDBUserSpec{
+			CustomData: &runtime.RawExtension{Raw: []byte(`{"team":"payments"}`)},
+			ValidUntil: &metav1.Time{Time: time.Now().Add(time.Hour)},
+		})
+		Expect(k8sClient.Create(context.Background(), user)).Should(Succeed())
+
+		got := &infrav1.MongoDBUser{}
+		Expect(k8sClient.Get(context.Background(), types.NamespacedName{Name: user.Name, Namespace: user.Namespace}, got)).Should(Succeed())
+		Expect(got.Spec.Credentials.Namespace).To(Equal(namespace.Name))
+	})
+
+	It("rejects custom data which is not a document", func() {
+		expectInvalid(validateUser(newUser(infrav1.MongoDBUserSpec{
+			CustomData: &runtime.RawExtension{Raw: []byte(`["payments"]`)},
+		})), "spec.customData")
+	})
+
+	It("rejects validUntil in the past", func() {
+		expectInvalid(validateUser(newUser(infrav1.MongoDBUserSpec{
+			ValidUntil: &metav1.Time{Time: time.Now().Add(-time.Hour)},
+		})), "spec.validUntil")
+	})
+})
@@ -0,0 +1,158 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"context"
+	"net"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	infrav1 "github.com/doodlescheduling/db-controller/api/v1"
+)
+
+// SetupMongoDBDatabaseWebhookWithManager registers the MongoDBDatabase webhooks
+func SetupMongoDBDatabaseWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr, &infrav1.MongoDBDatabase{}).
+		WithDefaulter(&MongoDBDatabaseDefaulter{}).
+		WithValidator(&MongoDBDatabaseValidator{}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-dbprovisioning-infra-doodle-com-v1-mongodbdatabase,mutating=true,failurePolicy=fail,sideEffects=None,groups=dbprovisioning.infra.doodle.com,resources=mongodbdatabases,verbs=create;update,versions=v1,name=mmongodbdatabase.dbprovisioning.infra.doodle.com,admissionReviewVersions=v1
+
+// MongoDBDatabaseDefaulter sets the database name and the root and TLS secret namespaces
+type MongoDBDatabaseDefaulter struct{}
+
+func (d *MongoDBDatabaseDefaulter) Default(ctx context.Context, db *infrav1.MongoDBDatabase) error {
+	db.Default()
+	return nil
+}
+
+// +kubebuilder:webhook:path=/validate-dbprovisioning-infra-doodle-com-v1-mongodbdatabase,mutating=false,failurePolicy=fail,sideEffects=None,groups=dbprovisioning.infra.doodle.com,resources=mongodbdatabases,verbs=create;update,versions=v1,name=vmongodbdatabase.dbprovisioning.infra.doodle.com,admissionReviewVersions=v1
+
+// MongoDBDatabaseValidator rejects MongoDBDatabase specs which can not be reconciled
+type MongoDBDatabaseValidator struct{}
+
+func (v *MongoDBDatabaseValidator) ValidateCreate(ctx context.Context, db *infrav1.MongoDBDatabase) (admission.Warnings, error) {
+	return v.validate(db)
+}
+
+func (v *MongoDBDatabaseValidator) ValidateUpdate(ctx context.Context, oldDB, db *infrav1.MongoDBDatabase) (admission.Warnings, error) {
+	if !db.DeletionTimestamp.IsZero() {
+		return nil, nil
+	}
+
+	return v.validate(db)
+}
+
+func (v *MongoDBDatabaseValidator) ValidateDelete(ctx context.Context, db *infrav1.MongoDBDatabase) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *MongoDBDatabaseValidator) validate(db *infrav1.MongoDBDatabase) (admission.Warnings, error) {
+	var errs field.ErrorList
+	spec := field.NewPath("spec")
+	isAtlas := db.IsAtlas()
+
+	if isAtlas {
+		errs = validateAtlas(db, spec)
+	} else {
+		errs = validateDatabaseSpec(db.Spec.DatabaseSpec, spec)
+	}
+
+	if !isAtlas && db.Spec.AuthMechanism == infrav1.MongoDBAuthMechanismX509 && db.Spec.TLSSecret == nil {
+		errs = append(errs, field.Required(spec.Child("tlsSecret"), "the client certificate is required by MONGODB-X509"))
+	}
+
+	if db.Spec.TLSSecret != nil && db.Spec.TLSSecret.Name == "" {
+		errs = append(errs, field.Required(spec.Child("tlsSecret", "name"), ""))
+	}
+
+	if isAtlas && len(db.Spec.Seeds) > 0 {
+		errs = append(errs, field.Forbidden(spec.Child("seeds"), "seeding is not supported for MongoDB Atlas"))
+	}
+
+	seeds := make(map[string]struct{}, len(db.Spec.Seeds))
+	for i, seed := range db.Spec.Seeds {
+		path := spec.Child("seeds").Index(i)
+		if seed.Name == "" {
+			errs = append(errs, field.Required(path.Child("name"), ""))
+		} else if _, ok := seeds[seed.Name]; ok {
+			errs = append(errs, field.Duplicate(path.Child("name"), seed.Name))
+		}
+
+		seeds[seed.Name] = struct{}{}
+
+		if seed.ConfigMap == nil || seed.ConfigMap.Name == "" {
+			errs = append(errs, field.Required(path.Child("configMap", "name"), ""))
+		}
+
+		if seed.Type == infrav1.MongoDBSeedDocuments && seed.Collection == "" {
+			errs = append(errs, field.Required(path.Child("collection"), "required if the type is Documents"))
+		}
+	}
+
+	return nil, invalid("MongoDBDatabase", db.Name, errs)
+}
+
+// validateAtlas validates the Atlas project settings of a database which is provisioned in MongoDB Atlas
+func validateAtlas(db *infrav1.MongoDBDatabase, spec *field.Path) field.ErrorList {
+	path := spec.Child("atlas")
+	errs := validateTimeout(db.Spec.Timeout, spec.Child("timeout"))
+
+	if db.Spec.RootSecret != nil {
+		errs = append(errs, field.Forbidden(spec.Child("rootSecret"), "the Atlas API key pair is referenced by atlas.apiKeySecret"))
+	}
+
+	if db.Spec.Atlas.GroupID == "" {
+		errs = append(errs, field.Required(path.Child("groupId"), ""))
+	}
+
+	if db.Spec.Atlas.APIKeySecret == nil {
+		errs = append(errs, field.Required(path.Child("apiKeySecret"), ""))
+	} else if db.Spec.Atlas.APIKeySecret.Name == "" {
+		errs = append(errs, field.Required(path.Child("apiKeySecret", "name"), ""))
+	}
+
+	entries := make(map[string]struct{}, len(db.Spec.Atlas.AccessList))
+	for i, entry := range db.Spec.Atlas.AccessList {
+		path := path.Child("accessList").Index(i)
+
+		switch {
+		case (entry.CIDRBlock == "") == (entry.IPAddress == ""):
+			errs = append(errs, field.Invalid(path, entry.GetEntry(), "access list entry must have either a cidrBlock or an ipAddress"))
+			continue
+		case entry.CIDRBlock != "":
+			if _, _, err := net.ParseCIDR(entry.CIDRBlock); err != nil {
+				errs = append(errs, field.Invalid(path.Child("cidrBlock"), entry.CIDRBlock, "must be a valid CIDR block"))
+			}
+		case net.ParseIP(entry.IPAddress) == nil:
+			errs = append(errs, field.Invalid(path.Child("ipAddress"), entry.IPAddress, "must be a valid IP address"))
+		}
+
+		if _, ok := entries[entry.GetEntry()]; ok {
+			errs = append(errs, field.Duplicate(path, entry.GetEntry()))
+		}
+
+		entries[entry.GetEntry()] = struct{}{}
+	}
+
+	return errs
+}
@@ -0,0 +1,97 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	infrav1 "github.com/doodlescheduling/db-controller/api/v1"
+)
+
+// SetupMongoDBUserWebhookWithManager registers the MongoDBUser webhooks
+func SetupMongoDBUserWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr, &infrav1.MongoDBUser{}).
+		WithDefaulter(&MongoDBUserDefaulter{}).
+		WithValidator(&MongoDBUserValidator{}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-dbprovisioning-infra-doodle-com-v1-mongodbuser,mutating=true,failurePolicy=fail,sideEffects=None,groups=dbprovisioning.infra.doodle.com,resources=mongodbusers,verbs=create;update,versions=v1,name=mmongodbuser.dbprovisioning.infra.doodle.com,admissionReviewVersions=v1
+
+// MongoDBUserDefaulter sets the credentials secret namespace
+type MongoDBUserDefaulter struct{}
+
+func (d *MongoDBUserDefaulter) Default(ctx context.Context, user *infrav1.MongoDBUser) error {
+	user.Default()
+	return nil
+}
+
+// +kubebuilder:webhook:path=/validate-dbprovisioning-infra-doodle-com-v1-mongodbuser,mutating=false,failurePolicy=fail,sideEffects=None,groups=dbprovisioning.infra.doodle.com,resources=mongodbusers,verbs=create;update,versions=v1,name=vmongodbuser.dbprovisioning.infra.doodle.com,admissionReviewVersions=v1
+
+// MongoDBUserValidator rejects MongoDBUser specs which can not be reconciled
+type MongoDBUserValidator struct{}
+
+func (v *MongoDBUserValidator) ValidateCreate(ctx context.Context, user *infrav1.MongoDBUser) (admission.Warnings, error) {
+	return v.validate(user, nil)
+}
+
+func (v *MongoDBUserValidator) ValidateUpdate(ctx context.Context, oldUser, user *infrav1.MongoDBUser) (admission.Warnings, error) {
+	if !user.DeletionTimestamp.IsZero() {
+		return nil, nil
+	}
+
+	return v.validate(user, oldUser)
+}
+
+func (v *MongoDBUserValidator) ValidateDelete(ctx context.Context, user *infrav1.MongoDBUser) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *MongoDBUserValidator) validate(user, oldUser *infrav1.MongoDBUser) (admission.Warnings, error) {
+	spec := field.NewPath("spec")
+
+	errs := validateDatabaseReference(user.Spec.Database, spec.Child("database"))
+	errs = append(errs, validateSecretReference(user.Spec.Credentials, spec.Child("credentials"))...)
+
+	for i, role := range user.GetRoles() {
+		if role.Name == "" {
+			errs = append(errs, field.Required(spec.Child("roles").Index(i).Child("name"), ""))
+		}
+	}
+
+	if user.Spec.CustomData != nil && len(user.Spec.CustomData.Raw) > 0 {
+		var doc map[string]any
+		if err := json.Unmarshal(user.Spec.CustomData.Raw, &doc); err != nil || doc == nil {
+			errs = append(errs, field.Invalid(spec.Child("customData"), string(user.Spec.CustomData.Raw), "must be a JSON document"))
+		}
+	}
+
+	var oldValidUntil *metav1.Time
+	if oldUser != nil {
+		oldValidUntil = oldUser.Spec.ValidUntil
+	}
+
+	errs = append(errs, validateValidUntil(user.Spec.ValidUntil, oldValidUntil, spec.Child("validUntil"))...)
+
+	return nil, invalid("MongoDBUser", user.Name, errs)
+}
@@ -0,0 +1,153 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	infrav1 "github.com/doodlescheduling/db-controller/api/v1"
+)
+
+var _ = Describe("PostgreSQL webhooks", Ordered, func() {
+	namespace := setupNamespace()
+
+	newUser := func(spec infrav1.PostgreSQLUserSpec) *infrav1.PostgreSQLUser {
+		spec.Database = &infrav1.DatabaseReference{Name: "database"}
+		spec.Credentials = &infrav1.SecretReference{Name: "credentials"}
+
+		return &infrav1.PostgreSQLUser{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "postgresqluser-" + randStringRunes(5),
+				Namespace: namespace.Name,
+			},
+			Spec: spec,
+		}
+	}
+
+	// The validator is called directly, the CEL rules of the CRD schema would reject most specs first
+	expectInvalid := func(obj *infrav1.PostgreSQLUser, field string) {
+		_, err := (&PostgreSQLUserValidator{}).ValidateCreate(context.Background(), obj)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue(), "expected an invalid error, got %v", err)
+		Expect(err.Error()).To(ContainSubstring(field))
+	}
+
+	It("defaults the database name and the root secret namespace", func() {
+		db := &infrav1.PostgreSQLDatabase{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "postgresqldatabase-" + randStringRunes(5),
+				Namespace: namespace.Name,
+			},
+			Spec: infrav1.PostgreSQLDatabaseSpec{
+				DatabaseSpec: infrav1.DatabaseSpec{
+					RootSecret: &infrav1.SecretReference{Name: "root"},
+				},
+			},
+		}
+		Expect(k8sClient.Create(context.Background(), db)).Should(Succeed())
+
+		got := &infrav1.PostgreSQLDatabase{}
+		Expect(k8sClient.Get(context.Background(), types.NamespacedName{Name: db.Name, Namespace: db.Namespace}, got)).Should(Succeed())
+		Expect(got.Spec.DatabaseName).To(Equal(db.Name))
+		Expect(got.Spec.RootSecret.Namespace).To(Equal(namespace.Name))
+	})
+
+	It("rejects an empty root secret name and duplicate extensions", func() {
+		_, err := (&PostgreSQLDatabaseValidator{}).ValidateCreate(context.Background(), &infrav1.PostgreSQLDatabase{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "postgresqldatabase-" + randStringRunes(5),
+				Namespace: namespace.Name,
+			},
+			Spec: infrav1.PostgreSQLDatabaseSpec{
+				DatabaseSpec: infrav1.DatabaseSpec{
+					RootSecret: &infrav1.SecretReference{},
+				},
+				Extensions: infrav1.Extensions{{Name: "pg_trgm"}, {Name: "pg_trgm"}},
+			},
+		})
+
+		Expect(apierrors.IsInvalid(err)).To(BeTrue(), "expected an invalid error, got %v", err)
+		Expect(err.Error()).To(ContainSubstring("spec.rootSecret.name"))
+		Expect(err.Error()).To(ContainSubstring("spec.extensions[1].name"))
+	})
+
+	It("defaults the credentials namespace of a valid user", func() {
+		user := newUser(infrav1.PostgreSQLUserSpec{
+			Attributes: []string{"CREATEDB"},
+			Grants: []infrav1.Grant{
+				{Object: "ALL TABLES IN SCHEMA", ObjectName: "public", Privileges: []infrav1.Privilege{"select", "INSERT"}},
+			},
+			ValidUntil: &metav1.Time{Time: time.Now().Add(time.Hour)},
+		})
+		Expect(k8sClient.Create(context.Background(), user)).Should(Succeed())
+
+		got := &infrav1.PostgreSQLUser{}
+		Expect(k8sClient.Get(context.Background(), types.NamespacedName{Name: user.Name, Namespace: user.Namespace}, got)).Should(Succeed())
+		Expect(got.Spec.Credentials.Namespace).To(Equal(namespace.Name))
+	})
+
+	It("rejects an unknown attribute", func() {
+		expectInvalid(newUser(infrav1.PostgreSQLUserSpec{
+			Attributes: []string{"CREATEDB", "SUPERDUPERUSER"},
+		}), "spec.attributes[1]")
+	})
+
+	It("rejects an invalid privilege", func() {
+		expectInvalid(newUser(infrav1.PostgreSQLUserSpec{
+			Grants: []infrav1.Grant{
+				{Object: "SCHEMA", ObjectName: "public", Privileges: []infrav1.Privilege{"USAGE", "EVERYTHING"}},
+			},
+		}), "spec.grants[0].privileges[1]")
+	})
+
+	It("rejects an invalid grant object", func() {
+		expectInvalid(newUser(infrav1.PostgreSQLUserSpec{
+			Grants: []infrav1.Grant{
+				{Object: "SCHEMA public TO public; --", ObjectName: "public", Privileges: []infrav1.Privilege{"ALL"}},
+			},
+		}), "spec.grants[0].object")
+	})
+
+	It("rejects a grant without object name", func() {
+		expectInvalid(newUser(infrav1.PostgreSQLUserSpec{
+			Grants: []infrav1.Grant{
+				{Object: "SCHEMA", Privileges: []infrav1.Privilege{"ALL"}},
+			},
+		}), "spec.grants[0].objectName")
+	})
+
+	It("rejects validUntil in the past", func() {
+		expectInvalid(newUser(infrav1.PostgreSQLUserSpec{
+			ValidUntil: &metav1.Time{Time: time.Now().Add(-time.Hour)},
+		}), "spec.validUntil")
+	})
+
+	It("rejects changing validUntil to the past", func() {
+		user := newUser(infrav1.PostgreSQLUserSpec{})
+		updated := user.DeepCopy()
+		updated.Spec.ValidUntil = &metav1.Time{Time: time.Now().Add(-time.Hour)}
+		_, err := (&PostgreSQLUserValidator{}).ValidateUpdate(context.Background(), user, updated)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue(), "expected an invalid error, got %v", err)
+		Expect(err.Error()).To(ContainSubstring("spec.validUntil"))
+	})
+})
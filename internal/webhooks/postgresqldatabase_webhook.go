@@ -0,0 +1,105 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	infrav1 "github.com/doodlescheduling/db-controller/api/v1"
+)
+
+// SetupPostgreSQLDatabaseWebhookWithManager registers the PostgreSQLDatabase webhooks
+func SetupPostgreSQLDatabaseWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr, &infrav1.PostgreSQLDatabase{}).
+		WithDefaulter(&PostgreSQLDatabaseDefaulter{}).
+		WithValidator(&PostgreSQLDatabaseValidator{}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-dbprovisioning-infra-doodle-com-v1-postgresqldatabase,mutating=true,failurePolicy=fail,sideEffects=None,groups=dbprovisioning.infra.doodle.com,resources=postgresqldatabases,verbs=create;update,versions=v1,name=mpostgresqldatabase.dbprovisioning.infra.doodle.com,admissionReviewVersions=v1
+
+// PostgreSQLDatabaseDefaulter sets the database name and the root secret namespace
+type PostgreSQLDatabaseDefaulter struct{}
+
+func (d *PostgreSQLDatabaseDefaulter) Default(ctx context.Context, db *infrav1.PostgreSQLDatabase) error {
+	db.Default()
+	return nil
+}
+
+// +kubebuilder:webhook:path=/validate-dbprovisioning-infra-doodle-com-v1-postgresqldatabase,mutating=false,failurePolicy=fail,sideEffects=None,groups=dbprovisioning.infra.doodle.com,resources=postgresqldatabases,verbs=create;update,versions=v1,name=vpostgresqldatabase.dbprovisioning.infra.doodle.com,admissionReviewVersions=v1
+
+// PostgreSQLDatabaseValidator rejects PostgreSQLDatabase specs which can not be reconciled
+type PostgreSQLDatabaseValidator struct{}
+
+func (v *PostgreSQLDatabaseValidator) ValidateCreate(ctx context.Context, db *infrav1.PostgreSQLDatabase) (admission.Warnings, error) {
+	return v.validate(db)
+}
+
+func (v *PostgreSQLDatabaseValidator) ValidateUpdate(ctx context.Context, oldDB, db *infrav1.PostgreSQLDatabase) (admission.Warnings, error) {
+	if !db.DeletionTimestamp.IsZero() {
+		return nil, nil
+	}
+
+	return v.validate(db)
+}
+
+func (v *PostgreSQLDatabaseValidator) ValidateDelete(ctx context.Context, db *infrav1.PostgreSQLDatabase) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *PostgreSQLDatabaseValidator) validate(db *infrav1.PostgreSQLDatabase) (admission.Warnings, error) {
+	var warnings admission.Warnings
+	spec := field.NewPath("spec")
+	errs := validateDatabaseSpec(db.Spec.DatabaseSpec, spec)
+
+	extensions := make(map[string]struct{}, len(db.Spec.Extensions))
+	for i, extension := range db.Spec.Extensions {
+		path := spec.Child("extensions").Index(i).Child("name")
+		if extension.Name == "" {
+			errs = append(errs, field.Required(path, ""))
+			continue
+		}
+
+		if _, ok := extensions[extension.Name]; ok {
+			errs = append(errs, field.Duplicate(path, extension.Name))
+		}
+
+		extensions[extension.Name] = struct{}{}
+	}
+
+	if len(db.Spec.Extensions) > 0 && db.GetFlavor() == infrav1.PostgreSQLFlavorCockroachDB {
+		warnings = append(warnings, "extensions are not supported by CockroachDB and will be reported as unsupported")
+	}
+
+	for i, schema := range db.Spec.Schemas {
+		if schema.Name == "" {
+			errs = append(errs, field.Required(spec.Child("schemas").Index(i).Child("name"), ""))
+		}
+	}
+
+	for i, schema := range db.Spec.SearchPath {
+		if schema.Name == "" {
+			errs = append(errs, field.Required(spec.Child("searchPath").Index(i).Child("name"), ""))
+		}
+	}
+
+	return warnings, invalid("PostgreSQLDatabase", db.Name, errs)
+}
@@ -0,0 +1,122 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	infrav1 "github.com/doodlescheduling/db-controller/api/v1"
+	"github.com/doodlescheduling/db-controller/internal/database"
+)
+
+// SetupPostgreSQLUserWebhookWithManager registers the PostgreSQLUser webhooks
+func SetupPostgreSQLUserWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr, &infrav1.PostgreSQLUser{}).
+		WithDefaulter(&PostgreSQLUserDefaulter{}).
+		WithValidator(&PostgreSQLUserValidator{}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-dbprovisioning-infra-doodle-com-v1-postgresqluser,mutating=true,failurePolicy=fail,sideEffects=None,groups=dbprovisioning.infra.doodle.com,resources=postgresqlusers,verbs=create;update,versions=v1,name=mpostgresqluser.dbprovisioning.infra.doodle.com,admissionReviewVersions=v1
+
+// PostgreSQLUserDefaulter sets the credentials secret namespace
+type PostgreSQLUserDefaulter struct{}
+
+func (d *PostgreSQLUserDefaulter) Default(ctx context.Context, user *infrav1.PostgreSQLUser) error {
+	user.Default()
+	return nil
+}
+
+// +kubebuilder:webhook:path=/validate-dbprovisioning-infra-doodle-com-v1-postgresqluser,mutating=false,failurePolicy=fail,sideEffects=None,groups=dbprovisioning.infra.doodle.com,resources=postgresqlusers,verbs=create;update,versions=v1,name=vpostgresqluser.dbprovisioning.infra.doodle.com,admissionReviewVersions=v1
+
+// PostgreSQLUserValidator rejects PostgreSQLUser specs which can not be reconciled
+type PostgreSQLUserValidator struct{}
+
+func (v *PostgreSQLUserValidator) ValidateCreate(ctx context.Context, user *infrav1.PostgreSQLUser) (admission.Warnings, error) {
+	return v.validate(user, nil)
+}
+
+func (v *PostgreSQLUserValidator) ValidateUpdate(ctx context.Context, oldUser, user *infrav1.PostgreSQLUser) (admission.Warnings, error) {
+	if !user.DeletionTimestamp.IsZero() {
+		return nil, nil
+	}
+
+	return v.validate(user, oldUser)
+}
+
+func (v *PostgreSQLUserValidator) ValidateDelete(ctx context.Context, user *infrav1.PostgreSQLUser) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *PostgreSQLUserValidator) validate(user, oldUser *infrav1.PostgreSQLUser) (admission.Warnings, error) {
+	var warnings admission.Warnings
+	spec := field.NewPath("spec")
+
+	errs := validateDatabaseReference(user.Spec.Database, spec.Child("database"))
+	errs = append(errs, validateSecretReference(user.Spec.Credentials, spec.Child("credentials"))...)
+
+	for i, role := range user.Spec.Roles {
+		if role == "" {
+			errs = append(errs, field.Required(spec.Child("roles").Index(i), ""))
+		}
+	}
+
+	for i, attribute := range user.Spec.Attributes {
+		if err := database.ValidateRoleAttribute(attribute); err != nil {
+			errs = append(errs, field.Invalid(spec.Child("attributes").Index(i), attribute, err.Error()))
+		}
+	}
+
+	for i, grant := range user.Spec.Grants {
+		path := spec.Child("grants").Index(i)
+		if err := database.ValidateGrantObject(grant.Object); err != nil {
+			errs = append(errs, field.Invalid(path.Child("object"), grant.Object, err.Error()))
+		}
+
+		if grant.ObjectName == "" {
+			errs = append(errs, field.Required(path.Child("objectName"), ""))
+		}
+
+		if len(grant.Privileges) == 0 {
+			errs = append(errs, field.Required(path.Child("privileges"), ""))
+		}
+
+		for j, privilege := range grant.Privileges {
+			if err := database.ValidatePrivilege(database.Privilege(privilege)); err != nil {
+				errs = append(errs, field.Invalid(path.Child("privileges").Index(j), privilege, err.Error()))
+			}
+		}
+	}
+
+	var oldValidUntil *metav1.Time
+	if oldUser != nil {
+		oldValidUntil = oldUser.Spec.ValidUntil
+	}
+
+	errs = append(errs, validateValidUntil(user.Spec.ValidUntil, oldValidUntil, spec.Child("validUntil"))...)
+
+	if user.Spec.ReassignOwnedTo != "" && user.Spec.DeletionPolicy != infrav1.DeletionPolicyDrop {
+		warnings = append(warnings, "reassignOwnedTo is ignored unless the deletionPolicy is Drop")
+	}
+
+	return warnings, invalid("PostgreSQLUser", user.Name, errs)
+}
@@ -0,0 +1,90 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package webhooks implements the defaulting and validating admission webhooks.
+// The webhooks reject specs which would otherwise only fail once they are reconciled.
+// The controllers do not depend on them, invalid specs are still reported in the conditions if the webhooks are not deployed.
+package webhooks
+
+import (
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	infrav1 "github.com/doodlescheduling/db-controller/api/v1"
+)
+
+// invalid converts a non empty error list into an Invalid status error
+func invalid(kind, name string, errs field.ErrorList) error {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(infrav1.GroupVersion.WithKind(kind).GroupKind(), name, errs)
+}
+
+func validateSecretReference(ref *infrav1.SecretReference, path *field.Path) field.ErrorList {
+	if ref == nil {
+		return field.ErrorList{field.Required(path, "")}
+	}
+
+	if ref.Name == "" {
+		return field.ErrorList{field.Required(path.Child("name"), "")}
+	}
+
+	return nil
+}
+
+func validateDatabaseReference(ref *infrav1.DatabaseReference, path *field.Path) field.ErrorList {
+	if ref == nil {
+		return field.ErrorList{field.Required(path, "")}
+	}
+
+	if ref.Name == "" {
+		return field.ErrorList{field.Required(path.Child("name"), "")}
+	}
+
+	return nil
+}
+
+func validateDatabaseSpec(spec infrav1.DatabaseSpec, path *field.Path) field.ErrorList {
+	errs := validateSecretReference(spec.RootSecret, path.Child("rootSecret"))
+	return append(errs, validateTimeout(spec.Timeout, path.Child("timeout"))...)
+}
+
+func validateTimeout(timeout *metav1.Duration, path *field.Path) field.ErrorList {
+	if timeout != nil && timeout.Duration <= 0 {
+		return field.ErrorList{field.Invalid(path, timeout.Duration.String(), "must be greater than zero")}
+	}
+
+	return nil
+}
+
+// validateValidUntil rejects a timestamp in the past. Updates are only rejected if the timestamp changed,
+// a user which expired meanwhile must remain updatable.
+func validateValidUntil(validUntil, old *metav1.Time, path *field.Path) field.ErrorList {
+	if validUntil == nil || !validUntil.Time.Before(time.Now()) {
+		return nil
+	}
+
+	if old != nil && old.Equal(validUntil) {
+		return nil
+	}
+
+	return field.ErrorList{field.Invalid(path, validUntil.UTC().Format(time.RFC3339), "must not be in the past")}
+}
@@ -17,18 +17,26 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"time"
 
+	infrav1 "github.com/doodlescheduling/db-controller/api/v1"
 	infrav1beta1 "github.com/doodlescheduling/db-controller/api/v1beta1"
+	"github.com/doodlescheduling/db-controller/internal/audit"
 	"github.com/doodlescheduling/db-controller/internal/controllers"
+	"github.com/doodlescheduling/db-controller/internal/crd"
+	"github.com/doodlescheduling/db-controller/internal/metrics"
+	"github.com/doodlescheduling/db-controller/internal/tracing"
+	"github.com/doodlescheduling/db-controller/internal/webhooks"
 	"github.com/fluxcd/pkg/runtime/client"
 	helper "github.com/fluxcd/pkg/runtime/controller"
 	"github.com/fluxcd/pkg/runtime/leaderelection"
 	"github.com/fluxcd/pkg/runtime/logger"
 	flag "github.com/spf13/pflag"
 	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
@@ -37,6 +45,7 @@ import (
 	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -51,6 +60,8 @@ func init() {
 	_ = clientgoscheme.AddToScheme(scheme)
 
 	_ = corev1.AddToScheme(scheme)
+	_ = apiextensionsv1.AddToScheme(scheme)
+	_ = infrav1.AddToScheme(scheme)
 	_ = infrav1beta1.AddToScheme(scheme)
 	// +kubebuilder:scaffold:scheme
 }
@@ -60,6 +71,16 @@ var (
 	healthAddr              string
 	concurrent              int
 	gracefulShutdownTimeout time.Duration
+	enableWebhooks          bool
+	webhookPort             int
+	webhookCertDir          string
+	migrateStorageVersion   bool
+	userExpiryWindow        time.Duration
+	tracingOptions          tracing.Options
+	serverCheckInterval     time.Duration
+	serverCheckTimeout      time.Duration
+	serverCheckReadiness    bool
+	auditSink               string
 	clientOptions           client.Options
 	kubeConfigOpts          client.KubeConfigOptions
 	logOptions              logger.Options
@@ -77,6 +98,30 @@ func main() {
 		"The number of concurrent reconciles.")
 	flag.DurationVar(&gracefulShutdownTimeout, "graceful-shutdown-timeout", 600*time.Second,
 		"The duration given to the reconciler to finish before forcibly stopping.")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false,
+		"Serve the conversion webhook and the defaulting and validating admission webhooks.")
+	flag.IntVar(&webhookPort, "webhook-port", 9443,
+		"The port the webhook server binds to.")
+	flag.StringVar(&webhookCertDir, "webhook-cert-dir", "",
+		"The directory containing tls.crt and tls.key of the webhook server. By default <temp-dir>/k8s-webhook-server/serving-certs is used.")
+	flag.DurationVar(&userExpiryWindow, "user-expiry-window", 7*24*time.Hour,
+		"Users whose validUntil is within this window are reported as expiring by the db_controller_users_expiring metric.")
+	flag.BoolVar(&migrateStorageVersion, "migrate-storage-version", false,
+		"Rewrite all objects in the storage version and remove previous versions from the stored versions of the CRDs.")
+	flag.StringVar(&tracingOptions.Endpoint, "otlp-endpoint", "",
+		"The host:port of an OTLP gRPC collector traces are exported to. Tracing is disabled if empty.")
+	flag.BoolVar(&tracingOptions.Insecure, "otlp-insecure", false,
+		"Connect to the OTLP collector without TLS.")
+	flag.Float64Var(&tracingOptions.SampleRatio, "trace-sample-ratio", 1,
+		"The fraction of reconciliations which are traced, between 0 and 1.")
+	flag.DurationVar(&serverCheckInterval, "server-check-interval", time.Minute,
+		"The interval in which the connectivity to the servers referenced by the database resources is checked. The check is disabled if 0.")
+	flag.DurationVar(&serverCheckTimeout, "server-check-timeout", 10*time.Second,
+		"The timeout of a single connectivity check.")
+	flag.BoolVar(&serverCheckReadiness, "server-check-fail-readiness", false,
+		"Fail the /servers/readyz check of the metrics endpoint while any server referenced by the database resources is unreachable. Without --enable-webhooks the check is part of /readyz as well.")
+	flag.StringVar(&auditSink, "audit-sink", "",
+		"Record every mutating PostgreSQL, MongoDB and MongoDB Atlas statement in an audit log. Either stdout, a file path or an http(s) webhook URL. The audit log is disabled if empty.")
 
 	clientOptions.BindFlags(flag.CommandLine)
 	logOptions.BindFlags(flag.CommandLine)
@@ -87,6 +132,7 @@ func main() {
 
 	flag.Parse()
 	logger.SetLogger(logger.NewLogger(logOptions))
+	metrics.Users.SetExpiryWindow(userExpiryWindow)
 
 	leaderElectionId := fmt.Sprintf("%s-%s", controllerName, "leader-election")
 	if watchOptions.LabelSelector != "" {
@@ -99,6 +145,21 @@ func main() {
 		os.Exit(1)
 	}
 
+	tracingOptions.ServiceName = controllerName
+	shutdownTracing, err := tracing.Setup(context.Background(), tracingOptions)
+	if err != nil {
+		setupLog.Error(err, "unable to setup tracing")
+		os.Exit(1)
+	}
+
+	sink, err := audit.NewSink(auditSink)
+	if err != nil {
+		setupLog.Error(err, "unable to setup audit sink")
+		os.Exit(1)
+	}
+
+	audit.SetSink(sink)
+
 	opts := ctrl.Options{
 		Scheme: scheme,
 		Metrics: server.Options{
@@ -112,12 +173,26 @@ func main() {
 		RetryPeriod:                   &leaderElectionOptions.RetryPeriod,
 		GracefulShutdownTimeout:       &gracefulShutdownTimeout,
 		LeaderElectionID:              leaderElectionId,
+		WebhookServer: webhook.NewServer(webhook.Options{
+			Port:    webhookPort,
+			CertDir: webhookCertDir,
+		}),
 		Cache: ctrlcache.Options{
 			ByObject: map[ctrlclient.Object]ctrlcache.ByObject{
-				&infrav1beta1.MongoDBDatabase{}:    {Label: watchSelector},
-				&infrav1beta1.MongoDBUser{}:        {Label: watchSelector},
-				&infrav1beta1.PostgreSQLDatabase{}: {Label: watchSelector},
-				&infrav1beta1.PostgreSQLUser{}:     {Label: watchSelector},
+				&infrav1.MongoDBDatabase{}:    {Label: watchSelector},
+				&infrav1.MongoDBUser{}:        {Label: watchSelector},
+				&infrav1.PostgreSQLDatabase{}: {Label: watchSelector},
+				&infrav1.PostgreSQLUser{}:     {Label: watchSelector},
+				&infrav1.MySQLDatabase{}:      {Label: watchSelector},
+				&infrav1.MySQLUser{}:          {Label: watchSelector},
+				&infrav1.RedisServer{}:        {Label: watchSelector},
+				&infrav1.RedisUser{}:          {Label: watchSelector},
+				&infrav1.ClickHouseDatabase{}: {Label: watchSelector},
+				&infrav1.ClickHouseUser{}:     {Label: watchSelector},
+				&infrav1.MSSQLDatabase{}:      {Label: watchSelector},
+				&infrav1.MSSQLUser{}:          {Label: watchSelector},
+				&infrav1.RabbitMQVhost{}:      {Label: watchSelector},
+				&infrav1.RabbitMQUser{}:       {Label: watchSelector},
 			},
 		},
 	}
@@ -147,6 +222,41 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Server connectivity check
+	if serverCheckInterval > 0 {
+		checker := &controllers.ServerChecker{
+			Client:        mgr.GetClient(),
+			Log:           ctrl.Log.WithName("servers"),
+			Interval:      serverCheckInterval,
+			Timeout:       serverCheckTimeout,
+			FailReadiness: serverCheckReadiness,
+		}
+
+		if err = mgr.Add(checker); err != nil {
+			setupLog.Error(err, "unable to add server checker")
+			os.Exit(1)
+		}
+
+		// A single unreachable server would remove all replicas from the webhook service,
+		// every request which needs the conversion or admission webhooks would fail afterwards
+		if !enableWebhooks {
+			if err = mgr.AddReadyzCheck("servers", checker.Check); err != nil {
+				setupLog.Error(err, "Could not add server readiness probe")
+				os.Exit(1)
+			}
+		}
+
+		if err = mgr.AddMetricsServerExtraHandler("/servers", checker); err != nil {
+			setupLog.Error(err, "unable to add server status endpoint")
+			os.Exit(1)
+		}
+
+		if err = mgr.AddMetricsServerExtraHandler("/servers/readyz", healthz.CheckHandler{Checker: checker.Check}); err != nil {
+			setupLog.Error(err, "unable to add server readiness endpoint")
+			os.Exit(1)
+		}
+	}
+
 	// MongoDBDatabase setup
 	if err = (&controllers.MongoDBDatabaseReconciler{
 		Client:   mgr.GetClient(),
@@ -191,9 +301,190 @@ func main() {
 		os.Exit(1)
 	}
 
+	// MySQLDatabase setup
+	if err = (&controllers.MySQLDatabaseReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("MySQLDatabase"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorder("MySQLDatabase"),
+	}).SetupWithManager(mgr, concurrent); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "MySQLDatabase")
+		os.Exit(1)
+	}
+
+	// MySQLUser setup
+	if err = (&controllers.MySQLUserReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("MySQLUser"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorder("MySQLUser"),
+	}).SetupWithManager(mgr, concurrent); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "MySQLUser")
+		os.Exit(1)
+	}
+
+	// RedisServer setup
+	if err = (&controllers.RedisServerReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("RedisServer"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorder("RedisServer"),
+	}).SetupWithManager(mgr, concurrent); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "RedisServer")
+		os.Exit(1)
+	}
+
+	// RedisUser setup
+	if err = (&controllers.RedisUserReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("RedisUser"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorder("RedisUser"),
+	}).SetupWithManager(mgr, concurrent); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "RedisUser")
+		os.Exit(1)
+	}
+
+	// ClickHouseDatabase setup
+	if err = (&controllers.ClickHouseDatabaseReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("ClickHouseDatabase"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorder("ClickHouseDatabase"),
+	}).SetupWithManager(mgr, concurrent); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClickHouseDatabase")
+		os.Exit(1)
+	}
+
+	// ClickHouseUser setup
+	if err = (&controllers.ClickHouseUserReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("ClickHouseUser"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorder("ClickHouseUser"),
+	}).SetupWithManager(mgr, concurrent); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClickHouseUser")
+		os.Exit(1)
+	}
+
+	// MSSQLDatabase setup
+	if err = (&controllers.MSSQLDatabaseReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("MSSQLDatabase"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorder("MSSQLDatabase"),
+	}).SetupWithManager(mgr, concurrent); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "MSSQLDatabase")
+		os.Exit(1)
+	}
+
+	// MSSQLUser setup
+	if err = (&controllers.MSSQLUserReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("MSSQLUser"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorder("MSSQLUser"),
+	}).SetupWithManager(mgr, concurrent); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "MSSQLUser")
+		os.Exit(1)
+	}
+
+	// RabbitMQVhost setup
+	if err = (&controllers.RabbitMQVhostReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("RabbitMQVhost"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorder("RabbitMQVhost"),
+	}).SetupWithManager(mgr, concurrent); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "RabbitMQVhost")
+		os.Exit(1)
+	}
+
+	// RabbitMQUser setup
+	if err = (&controllers.RabbitMQUserReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("RabbitMQUser"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorder("RabbitMQUser"),
+	}).SetupWithManager(mgr, concurrent); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "RabbitMQUser")
+		os.Exit(1)
+	}
+
+	if enableWebhooks {
+		// Conversion webhook setup
+		if err = webhooks.SetupConversionWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "conversion")
+			os.Exit(1)
+		}
+
+		// MongoDBDatabase webhook setup
+		if err = webhooks.SetupMongoDBDatabaseWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "MongoDBDatabase")
+			os.Exit(1)
+		}
+
+		// MongoDBUser webhook setup
+		if err = webhooks.SetupMongoDBUserWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "MongoDBUser")
+			os.Exit(1)
+		}
+
+		// PostgreSQLDatabase webhook setup
+		if err = webhooks.SetupPostgreSQLDatabaseWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "PostgreSQLDatabase")
+			os.Exit(1)
+		}
+
+		// PostgreSQLUser webhook setup
+		if err = webhooks.SetupPostgreSQLUserWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "PostgreSQLUser")
+			os.Exit(1)
+		}
+	}
+
+	// The manager client is not usable before the manager is started
+	c, err := ctrlclient.New(mgr.GetConfig(), ctrlclient.Options{Scheme: scheme})
+	if err != nil {
+		setupLog.Error(err, "unable to create client")
+		os.Exit(1)
+	}
+
+	// Refuse to read v1beta1 objects as v1 with their moved fields pruned
+	if err = crd.RequireConversion(context.Background(), c); err != nil {
+		setupLog.Error(err, "the CRDs must use the conversion webhook, install them from config/base/crd or the helm chart")
+		os.Exit(1)
+	}
+
+	if migrateStorageVersion {
+		if err = mgr.Add(&crd.StorageVersionMigrator{
+			Client: mgr.GetClient(),
+			Reader: mgr.GetAPIReader(),
+			Log:    ctrl.Log.WithName("crd").WithName("migration"),
+		}); err != nil {
+			setupLog.Error(err, "unable to add storage version migrator")
+			os.Exit(1)
+		}
+	}
+
 	// +kubebuilder:scaffold:builder
 	setupLog.Info("starting manager")
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	err = mgr.Start(ctrl.SetupSignalHandler())
+
+	// Flush the spans of the last reconciliations before exiting
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		setupLog.Error(err, "unable to flush traces")
+	}
+	cancel()
+
+	if sink != nil {
+		if err := sink.Close(); err != nil {
+			setupLog.Error(err, "unable to close audit sink")
+		}
+	}
+
+	if err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}